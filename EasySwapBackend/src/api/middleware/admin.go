@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/ProjectsTask/EasySwapBase/errcode"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ProjectsTask/EasySwapBase/xhttp"
+	"github.com/gin-gonic/gin"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/common/session"
+)
+
+// AdminPermission 管理后台权限
+type AdminPermission int
+
+const (
+	AdminPermRead   AdminPermission = iota + 1 // 查询
+	AdminPermWrite                             // 变更合约、触发同步等
+	AdminPermManage                            // 维护管理员
+)
+
+// AdminRoleFunc 查询地址对应的管理员角色, 非管理员返回空字符串
+type AdminRoleFunc func(ctx context.Context, address string) (string, error)
+
+type adminCtxKey struct{}
+
+// AdminIdentity 当前请求的管理员身份
+type AdminIdentity struct {
+	Address  string
+	Role     string
+	ClientIp string
+}
+
+var rolePermissions = map[string][]AdminPermission{
+	base.AdminRoleViewer:   {AdminPermRead},
+	base.AdminRoleOperator: {AdminPermRead, AdminPermWrite},
+	base.AdminRoleSuper:    {AdminPermRead, AdminPermWrite, AdminPermManage},
+}
+
+// HasAdminPermission 判断角色是否拥有指定权限
+func HasAdminPermission(role string, perm AdminPermission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminMiddleware 管理后台鉴权: 必须登录, 且登录地址拥有对应权限
+// GET/HEAD 请求需要读权限, 其余请求需要写权限
+func AdminMiddleware(sessions *session.Manager, roleOf AdminRoleFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		perm := AdminPermWrite
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			perm = AdminPermRead
+		}
+		authorizeAdmin(c, sessions, roleOf, perm)
+	}
+}
+
+// RequireAdminPermission 在 AdminMiddleware 之后追加更高的权限要求
+func RequireAdminPermission(perm AdminPermission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := GetAdminIdentity(c.Request.Context())
+		if !ok || !HasAdminPermission(identity.Role, perm) {
+			xhttp.Error(c, errcode.ErrPermissionDenied)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func authorizeAdmin(c *gin.Context, sessions *session.Manager, roleOf AdminRoleFunc, perm AdminPermission) {
+	addrs, err := GetAuthUserAddress(c, sessions)
+	if err != nil || len(addrs) == 0 {
+		xhttp.Error(c, errcode.ErrTokenVerify)
+		c.Abort()
+		return
+	}
+
+	// session_id 可能包含多个地址, 取第一个满足权限的
+	for _, addr := range addrs {
+		role, err := roleOf(c.Request.Context(), addr)
+		if err != nil {
+			xhttp.Error(c, errcode.ErrUnexpected)
+			c.Abort()
+			return
+		}
+		if !HasAdminPermission(role, perm) {
+			continue
+		}
+
+		identity := &AdminIdentity{
+			Address:  strings.ToLower(addr),
+			Role:     role,
+			ClientIp: c.ClientIP(),
+		}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), adminCtxKey{}, identity))
+		c.Next()
+		return
+	}
+
+	xhttp.Error(c, errcode.ErrPermissionDenied)
+	c.Abort()
+}
+
+// GetAdminIdentity 获取 AdminMiddleware 写入的管理员身份
+func GetAdminIdentity(ctx context.Context) (*AdminIdentity, bool) {
+	identity, ok := ctx.Value(adminCtxKey{}).(*AdminIdentity)
+	return identity, ok
+}
@@ -1,26 +1,24 @@
 package middleware
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"encoding/hex"
-	"fmt"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 
 	"github.com/ProjectsTask/EasySwapBase/errcode"
-	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
 	"github.com/ProjectsTask/EasySwapBase/xhttp"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/common/session"
 )
 
 const CR_LOGIN_MSG_KEY string = "cache:orderbookdex:login:msg"
-const CR_LOGIN_KEY string = "cache:orderbookdex:login:address:data"
-const CR_LOGIN_SALT string = "gf_login_salt&$%"
+
+// authSessionsKey gin 上下文中保存已校验会话的 key
+const authSessionsKey = "auth_sessions"
 
 // 设置路由cookie
-func AuthMiddleWare(ctx *xkv.Store) gin.HandlerFunc {
+func AuthMiddleWare(sessions *session.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		values := c.Request.Header.Get("session_id")
 		if values == "" {
@@ -28,92 +26,68 @@ func AuthMiddleWare(ctx *xkv.Store) gin.HandlerFunc {
 			return
 		}
 
-		sessionIDs := strings.Split(values, ",")
-		for _, sessionID := range sessionIDs {
-			encryptCode, err := hex.DecodeString(sessionID)
-			if err != nil {
-				xhttp.Error(c, errcode.ErrTokenVerify)
-				c.Abort()
-				return
-			}
-
-			//解密
-			decrptCode, err := AesDecryptOFB(encryptCode, []byte(CR_LOGIN_SALT))
-			if err != nil {
-				xhttp.Error(c, errcode.ErrTokenExpire)
-				c.Abort()
-				return
-			}
-			//从redis里取数据
-			result, err := ctx.Get(string(decrptCode))
-			if result == "" || err != nil {
+		verified, err := verifySessions(c, sessions, values)
+		if err != nil {
+			if errors.Is(err, session.ErrSessionExpired) || errors.Is(err, session.ErrSessionRevoked) {
 				xhttp.Error(c, errcode.ErrTokenExpire)
-				c.Abort()
-				return
+			} else {
+				xhttp.Error(c, errcode.ErrTokenVerify)
 			}
+			c.Abort()
+			return
 		}
+		c.Set(authSessionsKey, verified)
 
 		c.Next()
 	}
 }
 
-func GetAuthUserAddress(c *gin.Context, ctx *xkv.Store) ([]string, error) {
-	values := c.Request.Header.Get("session_id")
-	if values == "" {
-		return nil, errors.New("failed on get token")
-	}
-
-	sessionIDs := strings.Split(values, ",")
-	var addrs []string
-	for _, sessionID := range sessionIDs {
-		encryptCode, err := hex.DecodeString(sessionID)
+// verifySessions 校验 header 中逗号分隔的多个 token(多钱包同时登录)
+func verifySessions(c *gin.Context, sessions *session.Manager, values string) ([]*session.Session, error) {
+	var verified []*session.Session
+	for _, token := range strings.Split(values, ",") {
+		s, err := sessions.Verify(c.Request.Context(), strings.TrimSpace(token))
 		if err != nil {
-			return nil, errors.Wrap(err, "failed on decode cookie")
+			return nil, err
 		}
+		verified = append(verified, s)
+	}
 
-		//解密
-		decrptCode, err := AesDecryptOFB(encryptCode, []byte(CR_LOGIN_SALT))
-		if err != nil {
-			return nil, errors.Wrap(err, "invalid cookie")
-		}
-		//从redis里取数据
-		result, err := ctx.Get(string(decrptCode))
-		if result == "" || err != nil {
-			return nil, errors.Wrap(err, "failed on read cookie from cache")
-		}
-		arr := strings.Split(string(decrptCode), CR_LOGIN_KEY+":")
-		if len(arr) != 2 {
-			return nil, errors.New("user cache info format err")
-		}
+	return verified, nil
+}
 
-		if arr[1] == "" {
-			return nil, errors.New("invalid user address")
+// GetAuthSessions 获取当前请求已登录的会话, 优先使用 AuthMiddleWare 的校验结果
+func GetAuthSessions(c *gin.Context, sessions *session.Manager) ([]*session.Session, error) {
+	if v, ok := c.Get(authSessionsKey); ok {
+		if verified, ok := v.([]*session.Session); ok && len(verified) > 0 {
+			return verified, nil
 		}
-		addrs = append(addrs, arr[1])
 	}
 
-	return addrs, nil
-}
-
-func AesDecryptOFB(data []byte, key []byte) ([]byte, error) {
-	block, _ := aes.NewCipher([]byte(key))
-	iv := data[:aes.BlockSize]
-	data = data[aes.BlockSize:]
-	if len(data)%aes.BlockSize != 0 {
-		return nil, fmt.Errorf("data is not a multiple of the block size")
+	values := c.Request.Header.Get("session_id")
+	if values == "" {
+		return nil, errors.New("failed on get token")
 	}
 
-	out := make([]byte, len(data))
-	mode := cipher.NewOFB(block, iv)
-	mode.XORKeyStream(out, data)
+	verified, err := verifySessions(c, sessions, values)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid session token")
+	}
+	c.Set(authSessionsKey, verified)
 
-	out = PKCS7UnPadding(out)
-	return out, nil
+	return verified, nil
 }
 
-// 去码
-func PKCS7UnPadding(origData []byte) []byte {
-	length := len(origData)
-	unpadding := int(origData[length-1])
-	return origData[:(length - unpadding)]
+func GetAuthUserAddress(c *gin.Context, sessions *session.Manager) ([]string, error) {
+	verified, err := GetAuthSessions(c, sessions)
+	if err != nil {
+		return nil, err
+	}
+
+	var addrs []string
+	for _, s := range verified {
+		addrs = append(addrs, s.Address)
+	}
+
+	return addrs, nil
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
@@ -18,14 +19,23 @@ type BodyLogWriter struct {
 }
 
 func (w BodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	if !w.streaming() {
+		w.body.Write(b)
+	}
 	return w.ResponseWriter.Write(b)
 }
 func (w BodyLogWriter) WriteString(s string) (int, error) {
-	w.body.WriteString(s)
+	if !w.streaming() {
+		w.body.WriteString(s)
+	}
 	return w.ResponseWriter.WriteString(s)
 }
 
+// streaming 事件流响应持续时间长, 不记录响应内容
+func (w BodyLogWriter) streaming() bool {
+	return strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream")
+}
+
 // RLog 请求响应日志打印处理
 func RLog() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -16,6 +16,28 @@ func loadV1(r *gin.Engine, svcCtx *svc.ServerCtx) {
 		user.POST("/login", v1.UserLoginHandler(svcCtx))                       // login
 		user.GET("/:address/login-message", v1.GetLoginMessageHandler(svcCtx)) // login msg
 		user.GET("/:address/sig-status", v1.GetSigStatusHandler(svcCtx))       // sig status
+
+		// 会话管理 - 需要认证
+		user.POST("/logout", middleware.AuthMiddleWare(svcCtx.Sessions), v1.UserLogoutHandler(svcCtx))                        // 注销当前会话
+		user.POST("/logout-all", middleware.AuthMiddleWare(svcCtx.Sessions), v1.UserLogoutAllHandler(svcCtx))                 // 注销所有设备
+		user.GET("/sessions", middleware.AuthMiddleWare(svcCtx.Sessions), v1.UserSessionsHandler(svcCtx))                     // 会话列表
+		user.DELETE("/sessions/:session_id", middleware.AuthMiddleWare(svcCtx.Sessions), v1.UserRevokeSessionHandler(svcCtx)) // 注销指定会话
+
+		// 关注、价格提醒与站内信 - 需要认证
+		user.GET("/watchlist", middleware.AuthMiddleWare(svcCtx.Sessions), v1.WatchlistHandler(svcCtx))                   // 关注列表
+		user.POST("/watchlist", middleware.AuthMiddleWare(svcCtx.Sessions), v1.AddWatchlistHandler(svcCtx))               // 关注合集或NFT
+		user.DELETE("/watchlist/:id", middleware.AuthMiddleWare(svcCtx.Sessions), v1.RemoveWatchlistHandler(svcCtx))      // 取消关注
+		user.GET("/alerts", middleware.AuthMiddleWare(svcCtx.Sessions), v1.AlertRulesHandler(svcCtx))                     // 提醒规则列表
+		user.POST("/alerts", middleware.AuthMiddleWare(svcCtx.Sessions), v1.CreateAlertRuleHandler(svcCtx))               // 创建提醒规则
+		user.PUT("/alerts/:id", middleware.AuthMiddleWare(svcCtx.Sessions), v1.UpdateAlertRuleHandler(svcCtx))            // 修改提醒规则
+		user.DELETE("/alerts/:id", middleware.AuthMiddleWare(svcCtx.Sessions), v1.DeleteAlertRuleHandler(svcCtx))         // 删除提醒规则
+		user.GET("/notifications", middleware.AuthMiddleWare(svcCtx.Sessions), v1.NotificationsHandler(svcCtx))           // 站内信列表
+		user.POST("/notifications/read", middleware.AuthMiddleWare(svcCtx.Sessions), v1.ReadNotificationsHandler(svcCtx)) // 标记已读
+		user.GET("/notify-settings", middleware.AuthMiddleWare(svcCtx.Sessions), v1.NotifySettingHandler(svcCtx))         // 站外通知配置
+		user.PUT("/notify-settings", middleware.AuthMiddleWare(svcCtx.Sessions), v1.UpdateNotifySettingHandler(svcCtx))   // 修改邮箱与 webhook 地址
+
+		// 合集铸造交易状态 - 需要认证, 只能查询本人的请求
+		user.GET("/mint-tx/:request_id", middleware.AuthMiddleWare(svcCtx.Sessions), v1.MintTxStatusHandler(svcCtx))
 	}
 
 	// collections
@@ -32,12 +54,14 @@ func loadV1(r *gin.Engine, svcCtx *svc.ServerCtx) {
 		collections.GET("/:address/:token_id/traits", v1.ItemTraitsHandler(svcCtx))
 		collections.GET("/:address/top-trait", v1.ItemTopTraitPriceHandler(svcCtx))
 		collections.GET("/:address/:token_id/owner", v1.ItemOwnerHandler(svcCtx))
+		collections.GET("/:address/:token_id/fillability", v1.ItemFillabilityHandler(svcCtx)) // 挂单能否成交(所有者与 vault 授权)
+		collections.GET("/:address/:token_id/holders", v1.ItemHoldersHandler(svcCtx))         // ERC1155 持有人及持有数量
 		collections.POST("/:address/:token_id/metadata", v1.ItemMetadataRefreshHandler(svcCtx))
 
-		collections.GET("/:address/:token_id/listing", middleware.AuthMiddleWare(svcCtx.KvStore), v1.ItemListingHandler(svcCtx))
+		collections.GET("/:address/:token_id/listing", middleware.AuthMiddleWare(svcCtx.Sessions), v1.ItemListingHandler(svcCtx))
 
 		// NFT 铸造接口 - 需要认证
-		collections.POST("/:address/mint", middleware.AuthMiddleWare(svcCtx.KvStore), v1.MintNFTHandler(svcCtx))
+		collections.POST("/:address/mint", middleware.AuthMiddleWare(svcCtx.Sessions), v1.MintNFTHandler(svcCtx))
 	}
 
 	activities := apiV1.Group("/activities")
@@ -58,12 +82,19 @@ func loadV1(r *gin.Engine, svcCtx *svc.ServerCtx) {
 		orders.GET("", v1.OrderInfosHandler(svcCtx))
 	}
 
+	// 实时推送 - Server-Sent Events
+	stream := apiV1.Group("/stream")
+	{
+		stream.GET("/market", v1.MarketStreamHandler(svcCtx)) // 订单簿与活动事件, 按合集/token/用户订阅, 支持游标续传
+	}
+
 	// 腾讯云COS文件上传相关接口
 	upload := apiV1.Group("/upload")
 	{
-		upload.POST("/cos-token", v1.GetCOSTokenHandler(svcCtx))                                                   // 获取COS临时访问凭证（免登录）
-		upload.GET("/cos-policy", middleware.AuthMiddleWare(svcCtx.KvStore), v1.GetCOSUploadPolicyHandler(svcCtx)) // 获取COS上传策略（需要认证）
-		upload.POST("/cos-callback", middleware.AuthMiddleWare(svcCtx.KvStore), v1.COSCallbackHandler(svcCtx))     // COS上传回调（需要认证）
+		upload.POST("/cos-token", middleware.AuthMiddleWare(svcCtx.Sessions), v1.GetCOSTokenHandler(svcCtx))          // 获取COS预签名上传地址（需要认证）
+		upload.GET("/cos-policy", middleware.AuthMiddleWare(svcCtx.Sessions), v1.GetCOSUploadPolicyHandler(svcCtx))   // 获取COS上传策略（需要认证）
+		upload.POST("/cos-callback", middleware.AuthMiddleWare(svcCtx.Sessions), v1.COSCallbackHandler(svcCtx))       // COS上传校验回调（需要认证）
+		upload.POST("/ipfs-metadata", middleware.AuthMiddleWare(svcCtx.Sessions), v1.PinTokenMetadataHandler(svcCtx)) // 图片与元数据固定到IPFS（需要认证）
 	}
 
 	// MetaNodeNFT 相关接口
@@ -74,12 +105,13 @@ func loadV1(r *gin.Engine, svcCtx *svc.ServerCtx) {
 		metanode.GET("/query", v1.MetaNodeQueryHandler(svcCtx))                // 查询NFT信息（免登录）
 		metanode.GET("/contract-info", v1.MetaNodeContractInfoHandler(svcCtx)) // 获取合约信息（免登录）
 		metanode.GET("/token/:token_id", v1.MetaNodeTokenInfoHandler(svcCtx))  // 获取特定Token信息（免登录）
+		metanode.GET("/tx/:request_id", v1.MetaNodeTxStatusHandler(svcCtx))    // 按请求ID查询铸造交易状态（免登录）
 	}
 
 	// 管理员接口 - 需要管理员权限认证
 	admin := apiV1.Group("/admin")
-	admin.Use(middleware.AuthMiddleWare(svcCtx.KvStore)) // 需要认证
-	// admin.Use(middleware.AdminMiddleware(svcCtx.KvStore)) // 需要管理员权限 (待实现)
+	admin.Use(middleware.AuthMiddleWare(svcCtx.Sessions))                                // 需要认证
+	admin.Use(middleware.AdminMiddleware(svcCtx.Sessions, v1.AdminRoleResolver(svcCtx))) // 需要管理员权限: GET 读权限, 其余写权限
 	{
 		// NFT 合约地址管理 - 支持链参数
 		contracts := admin.Group("/contracts")
@@ -109,5 +141,37 @@ func loadV1(r *gin.Engine, svcCtx *svc.ServerCtx) {
 			system.GET("/stats", v1.AdminGetSystemStatsHandler(svcCtx))              // 获取系统统计
 			system.POST("/refresh-metadata", v1.AdminRefreshMetadataHandler(svcCtx)) // 批量刷新元数据
 		}
+
+		// 管理员与审计日志
+		users := admin.Group("/users")
+		users.Use(middleware.RequireAdminPermission(middleware.AdminPermManage)) // 仅超级管理员
+		{
+			users.GET("", v1.AdminGetUsersHandler(svcCtx))               // 获取管理员列表
+			users.POST("", v1.AdminSetUserHandler(svcCtx))               // 添加/修改管理员
+			users.DELETE("/:address", v1.AdminDeleteUserHandler(svcCtx)) // 删除管理员
+		}
+		admin.GET("/audit-logs", v1.AdminGetAuditLogsHandler(svcCtx)) // 获取审计日志
+
+		// 铸造交易管理
+		txs := admin.Group("/txs")
+		{
+			txs.POST("/:request_id/speed-up", v1.AdminSpeedUpTxHandler(svcCtx)) // 加速待确认交易
+			txs.POST("/:request_id/cancel", v1.AdminCancelTxHandler(svcCtx))    // 取消待确认交易
+		}
+
+		// 市场事件 webhook 订阅与投递记录
+		webhooks := admin.Group("/webhooks")
+		{
+			webhooks.GET("", v1.AdminGetWebhooksHandler(svcCtx))          // 获取订阅列表
+			webhooks.POST("", v1.AdminCreateWebhookHandler(svcCtx))       // 创建订阅, 返回签名密钥
+			webhooks.GET("/:id", v1.AdminGetWebhookHandler(svcCtx))       // 获取订阅详情
+			webhooks.PUT("/:id", v1.AdminUpdateWebhookHandler(svcCtx))    // 修改订阅
+			webhooks.DELETE("/:id", v1.AdminDeleteWebhookHandler(svcCtx)) // 删除订阅
+		}
+		deliveries := admin.Group("/webhook-deliveries")
+		{
+			deliveries.GET("", v1.AdminGetWebhookDeliveriesHandler(svcCtx))            // 投递记录, status=dead 为死信
+			deliveries.POST("/:id/redeliver", v1.AdminRedeliverWebhookHandler(svcCtx)) // 重新投递
+		}
 	}
 }
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"context"
 	"strconv"
 
 	"github.com/ProjectsTask/EasySwapBase/errcode"
@@ -8,6 +9,7 @@ import (
 	"github.com/ProjectsTask/EasySwapBase/xhttp"
 	"github.com/gin-gonic/gin"
 
+	"github.com/ProjectsTask/EasySwapBackend/src/api/middleware"
 	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
 	"github.com/ProjectsTask/EasySwapBackend/src/service/v1"
 	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
@@ -251,10 +253,29 @@ func AdminSyncContractFromParamsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 			return
 		}
 
+		// 起始/结束区块可选, 通过 query 参数传入
+		var startBlock, endBlock int64
+		if startBlockStr := c.Query("start_block"); startBlockStr != "" {
+			startBlock, err = strconv.ParseInt(startBlockStr, 10, 64)
+			if err != nil {
+				xhttp.Error(c, errcode.NewCustomErr("invalid start_block"))
+				return
+			}
+		}
+		if endBlockStr := c.Query("end_block"); endBlockStr != "" {
+			endBlock, err = strconv.ParseInt(endBlockStr, 10, 64)
+			if err != nil {
+				xhttp.Error(c, errcode.NewCustomErr("invalid end_block"))
+				return
+			}
+		}
+
 		// 构建同步请求
 		req := types.AdminSyncContractReq{
 			ContractAddr: address,
 			ChainID:      chainID,
+			StartBlock:   startBlock,
+			EndBlock:     endBlock,
 		}
 
 		res, err := service.AdminSyncContract(c.Request.Context(), svcCtx, req)
@@ -376,3 +397,134 @@ func AdminRefreshMetadataHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 		xhttp.OkJson(c, res)
 	}
 }
+
+// =================== 管理员权限 ===================
+
+// AdminRoleResolver 供 middleware.AdminMiddleware 查询管理员角色
+func AdminRoleResolver(svcCtx *svc.ServerCtx) middleware.AdminRoleFunc {
+	return func(ctx context.Context, address string) (string, error) {
+		return service.GetAdminRole(ctx, svcCtx, address)
+	}
+}
+
+// AdminGetUsersHandler 获取管理员列表
+func AdminGetUsersHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		res, err := service.AdminGetUsers(c.Request.Context(), svcCtx)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// AdminSetUserHandler 添加或修改管理员
+func AdminSetUserHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := types.AdminSetUserReq{}
+		if err := c.BindJSON(&req); err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		if err := validator.Verify(&req); err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		res, err := service.AdminSetUser(c.Request.Context(), svcCtx, req)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// AdminDeleteUserHandler 删除管理员
+func AdminDeleteUserHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address := c.Param("address")
+		if address == "" {
+			xhttp.Error(c, errcode.NewCustomErr("address is required"))
+			return
+		}
+
+		res, err := service.AdminDeleteUser(c.Request.Context(), svcCtx, address)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// AdminGetAuditLogsHandler 获取审计日志
+func AdminGetAuditLogsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := types.AdminGetAuditLogsReq{
+			Page:     1,
+			PageSize: 20,
+		}
+
+		if err := c.ShouldBindQuery(&req); err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		if err := validator.Verify(&req); err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		res, err := service.AdminGetAuditLogs(c.Request.Context(), svcCtx, req)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// AdminSpeedUpTxHandler 加速待确认的铸造交易, 合集铸造交易需通过 caller 参数指定铸造用户地址
+func AdminSpeedUpTxHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.Param("request_id")
+		if requestID == "" {
+			xhttp.Error(c, errcode.NewCustomErr("request_id is required"))
+			return
+		}
+
+		res, err := service.AdminSpeedUpTx(c.Request.Context(), svcCtx, c.Query("caller"), requestID)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// AdminCancelTxHandler 取消待确认的铸造交易, 合集铸造交易需通过 caller 参数指定铸造用户地址
+func AdminCancelTxHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.Param("request_id")
+		if requestID == "" {
+			xhttp.Error(c, errcode.NewCustomErr("request_id is required"))
+			return
+		}
+
+		res, err := service.AdminCancelTx(c.Request.Context(), svcCtx, c.Query("caller"), requestID)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
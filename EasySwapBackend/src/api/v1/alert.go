@@ -0,0 +1,307 @@
+package v1
+
+import (
+	"strconv"
+
+	"github.com/ProjectsTask/EasySwapBase/errcode"
+	"github.com/ProjectsTask/EasySwapBase/kit/validator"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/xhttp"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/api/middleware"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/v1"
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+// WatchlistHandler 获取当前用户的关注列表
+func WatchlistHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		res, err := service.GetWatchlist(c.Request.Context(), svcCtx, address[0])
+		if err != nil {
+			alertError(c, "failed on get watchlist", err)
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// AddWatchlistHandler 关注合集或单个 NFT
+func AddWatchlistHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		req := types.AddWatchlistReq{}
+		if err := c.BindJSON(&req); err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+		if err := validator.Verify(&req); err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		res, err := service.AddWatchlist(c.Request.Context(), svcCtx, address[0], req)
+		if err != nil {
+			alertError(c, "failed on add watchlist", err)
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// RemoveWatchlistHandler 取消关注
+func RemoveWatchlistHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr("invalid watchlist id"))
+			return
+		}
+
+		if err := service.RemoveWatchlist(c.Request.Context(), svcCtx, address[0], id); err != nil {
+			alertError(c, "failed on remove watchlist", err)
+			return
+		}
+
+		xhttp.OkJson(c, nil)
+	}
+}
+
+// AlertRulesHandler 获取当前用户的提醒规则
+func AlertRulesHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		res, err := service.GetAlertRules(c.Request.Context(), svcCtx, address[0])
+		if err != nil {
+			alertError(c, "failed on get alert rules", err)
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// CreateAlertRuleHandler 创建提醒规则
+func CreateAlertRuleHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		req := types.CreateAlertRuleReq{}
+		if err := c.BindJSON(&req); err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+		if err := validator.Verify(&req); err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		res, err := service.CreateAlertRule(c.Request.Context(), svcCtx, address[0], req)
+		if err != nil {
+			alertError(c, "failed on create alert rule", err)
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// UpdateAlertRuleHandler 修改提醒规则
+func UpdateAlertRuleHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr("invalid alert rule id"))
+			return
+		}
+
+		req := types.UpdateAlertRuleReq{}
+		if err := c.BindJSON(&req); err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		res, err := service.UpdateAlertRule(c.Request.Context(), svcCtx, address[0], id, req)
+		if err != nil {
+			alertError(c, "failed on update alert rule", err)
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// DeleteAlertRuleHandler 删除提醒规则
+func DeleteAlertRuleHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr("invalid alert rule id"))
+			return
+		}
+
+		if err := service.DeleteAlertRule(c.Request.Context(), svcCtx, address[0], id); err != nil {
+			alertError(c, "failed on delete alert rule", err)
+			return
+		}
+
+		xhttp.OkJson(c, nil)
+	}
+}
+
+// NotificationsHandler 分页获取当前用户的站内信, unread=true 时只返回未读
+func NotificationsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		req := types.NotificationsReq{
+			Page:     1,
+			PageSize: 20,
+		}
+		if err := c.ShouldBindQuery(&req); err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+		if err := validator.Verify(&req); err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		res, err := service.GetNotifications(c.Request.Context(), svcCtx, address[0], req)
+		if err != nil {
+			alertError(c, "failed on get notifications", err)
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// ReadNotificationsHandler 将站内信标记为已读, ids 为空时标记全部
+func ReadNotificationsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		req := types.ReadNotificationsReq{}
+		if err := c.BindJSON(&req); err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+		if err := validator.Verify(&req); err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		res, err := service.ReadNotifications(c.Request.Context(), svcCtx, address[0], req.IDs)
+		if err != nil {
+			alertError(c, "failed on read notifications", err)
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// NotifySettingHandler 获取当前用户的站外通知配置
+func NotifySettingHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		res, err := service.GetNotifySetting(c.Request.Context(), svcCtx, address[0])
+		if err != nil {
+			alertError(c, "failed on get notify setting", err)
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// UpdateNotifySettingHandler 修改当前用户的邮箱与 webhook 通知地址
+func UpdateNotifySettingHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		req := types.UpdateNotifySettingReq{}
+		if err := c.BindJSON(&req); err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		res, err := service.UpdateNotifySetting(c.Request.Context(), svcCtx, address[0], req)
+		if err != nil {
+			alertError(c, "failed on update notify setting", err)
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// alertError 参数错误直接返回, 其余错误记录日志后返回通用错误
+func alertError(c *gin.Context, msg string, err error) {
+	if errcode.IsErr(err) {
+		xhttp.Error(c, err)
+		return
+	}
+	xzap.WithContext(c).Error(msg, zap.Error(err))
+	xhttp.Error(c, errcode.ErrUnexpected)
+}
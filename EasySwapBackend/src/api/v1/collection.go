@@ -130,6 +130,53 @@ func CollectionItemBidsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	}
 }
 
+// ItemHoldersHandler 查询 ERC1155 NFT 的持有人及持有数量
+func ItemHoldersHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filterParam := c.Query("filters")
+		if filterParam == "" {
+			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			return
+		}
+
+		var filter types.ItemHoldersFilterParams
+		err := json.Unmarshal([]byte(filterParam), &filter)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			return
+		}
+		if filter.Page <= 0 || filter.PageSize <= 0 {
+			xhttp.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			xhttp.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		tokenID := c.Params.ByName("token_id")
+		if tokenID == "" {
+			xhttp.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chain, ok := chainIDToChain[filter.ChainID]
+		if !ok {
+			xhttp.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		res, err := service.GetItemHolders(c.Request.Context(), svcCtx, chain, collectionAddr, tokenID, filter.Page, filter.PageSize)
+		if err != nil {
+			xhttp.Error(c, errcode.ErrUnexpected)
+			return
+		}
+		xhttp.OkJson(c, res)
+	}
+}
+
 func ItemDetailHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		collectionAddr := c.Params.ByName("address")
@@ -254,7 +301,7 @@ func HistorySalesHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 
 func ItemListingHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		address, err := middleware.GetAuthUserAddress(c, svcCtx.KvStore)
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
 		if err != nil {
 			xhttp.Error(c, err)
 			return
@@ -372,6 +419,46 @@ func ItemOwnerHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	}
 }
 
+func ItemFillabilityHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			xhttp.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		tokenID := c.Params.ByName("token_id")
+		if tokenID == "" {
+			xhttp.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
+		if err != nil {
+			xhttp.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chain, ok := chainIDToChain[int(chainID)]
+		if !ok {
+			xhttp.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		result, err := service.GetItemFillability(c.Request.Context(), svcCtx, chainID, chain, collectionAddr, tokenID)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: result,
+		})
+	}
+}
+
 func GetItemImageHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		collectionAddr := c.Params.ByName("address")
@@ -476,7 +563,7 @@ func CollectionDetailHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 func MintNFTHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 验证用户身份
-		address, err := middleware.GetAuthUserAddress(c, svcCtx.KvStore)
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
 		if err != nil {
 			xhttp.Error(c, err)
 			return
@@ -489,12 +576,6 @@ func MintNFTHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 			return
 		}
 
-		// 验证合约地址是否为授权的铸造合约
-		if collectionAddr != "0xF7367110305e0419425441e2280eBbAa980A9e42" {
-			xhttp.Error(c, errcode.NewCustomErr("Unauthorized contract address"))
-			return
-		}
-
 		// 解析请求参数
 		var mintReq types.MintRequest
 		if err := c.ShouldBindJSON(&mintReq); err != nil {
@@ -509,9 +590,13 @@ func MintNFTHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 			return
 		}
 
-		// 调用铸造服务
+		// 调用铸造服务, 合集与铸造权限由 [[mint.collections]] 配置校验
 		result, err := service.MintNFT(c.Request.Context(), svcCtx, chain, collectionAddr, address[0], &mintReq)
 		if err != nil {
+			if errcode.IsErr(err) {
+				xhttp.Error(c, err)
+				return
+			}
 			xzap.WithContext(c).Error("mint NFT failed", zap.Error(err))
 			xhttp.Error(c, errcode.NewCustomErr("Failed to mint NFT"))
 			return
@@ -524,3 +609,28 @@ func MintNFTHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 		})
 	}
 }
+
+// MintTxStatusHandler 按请求ID查询当前用户的合集铸造交易状态
+func MintTxStatusHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		requestID := c.Param("request_id")
+		if requestID == "" {
+			xhttp.Error(c, errcode.NewCustomErr("request_id parameter is required"))
+			return
+		}
+
+		result, err := service.GetUserMintTx(c.Request.Context(), svcCtx, address[0], requestID)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, result)
+	}
+}
@@ -14,9 +14,16 @@ import (
 	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
 )
 
-// GetCOSTokenHandler 获取腾讯云COS临时访问凭证（免登录版本）
+// GetCOSTokenHandler 获取腾讯云COS预签名上传地址
 func GetCOSTokenHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// 验证用户身份
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
 		// 解析请求参数
 		var req types.COSTokenRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -49,14 +56,8 @@ func GetCOSTokenHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 			return
 		}
 
-		// 使用默认用户地址或生成匿名用户标识
-		userAddr := "anonymous"
-		if clientIP := c.ClientIP(); clientIP != "" {
-			userAddr = "guest_" + clientIP
-		}
-
-		// 调用服务获取临时凭证
-		result, err := service.GetCOSTemporaryToken(c.Request.Context(), svcCtx, userAddr, &req)
+		// 调用服务获取预签名上传地址, 上传路径限定在当前用户前缀下
+		result, err := service.GetCOSTemporaryToken(c.Request.Context(), svcCtx, address[0], &req)
 		if err != nil {
 			xzap.WithContext(c).Error("failed to get COS temporary token", zap.Error(err))
 			xhttp.Error(c, errcode.NewCustomErr("Failed to get temporary token"))
@@ -71,7 +72,7 @@ func GetCOSTokenHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 func GetCOSUploadPolicyHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 验证用户身份
-		address, err := middleware.GetAuthUserAddress(c, svcCtx.KvStore)
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
 		if err != nil {
 			xhttp.Error(c, err)
 			return
@@ -96,8 +97,15 @@ func GetCOSUploadPolicyHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 			return
 		}
 
+		// 验证文件名, 用于生成上传路径与内容类型
+		fileName := c.Query("file_name")
+		if fileName == "" {
+			xhttp.Error(c, errcode.NewCustomErr("file_name parameter is required"))
+			return
+		}
+
 		// 获取上传策略
-		policy, err := service.GetCOSUploadPolicy(c.Request.Context(), svcCtx, address[0], fileType)
+		policy, err := service.GetCOSUploadPolicy(c.Request.Context(), svcCtx, address[0], fileType, fileName)
 		if err != nil {
 			xzap.WithContext(c).Error("failed to get COS upload policy", zap.Error(err))
 			xhttp.Error(c, errcode.NewCustomErr("Failed to get upload policy"))
@@ -105,16 +113,16 @@ func GetCOSUploadPolicyHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 		}
 
 		xhttp.OkJson(c, struct {
-			Result interface{} `json:"result"`
+			Result *types.COSUploadPolicy `json:"result"`
 		}{Result: policy})
 	}
 }
 
-// COSCallbackHandler COS上传回调处理（可选）
+// COSCallbackHandler COS上传回调处理: 校验文件存在且大小与内容类型合法, 不信任客户端上报的文件信息
 func COSCallbackHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 验证用户身份
-		address, err := middleware.GetAuthUserAddress(c, svcCtx.KvStore)
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
 		if err != nil {
 			xhttp.Error(c, err)
 			return
@@ -128,22 +136,61 @@ func COSCallbackHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 			return
 		}
 
+		object, err := service.VerifyCOSUpload(c.Request.Context(), svcCtx, address, &uploadResult)
+		if err != nil {
+			if errcode.IsErr(err) {
+				xhttp.Error(c, err)
+				return
+			}
+			xzap.WithContext(c).Error("failed to verify uploaded file", zap.Error(err))
+			xhttp.Error(c, errcode.NewCustomErr("Failed to verify uploaded file"))
+			return
+		}
+
 		// 记录上传成功的文件信息
 		xzap.WithContext(c).Info("file uploaded successfully",
 			zap.String("user", address[0]),
-			zap.String("key", uploadResult.Key),
-			zap.String("location", uploadResult.Location),
-			zap.String("bucket", uploadResult.Bucket),
+			zap.String("key", object.Key),
+			zap.Int64("size", object.Size),
+			zap.String("content_type", object.ContentType),
 		)
 
-		// 这里可以添加业务逻辑，比如：
-		// 1. 将文件信息保存到数据库
-		// 2. 生成缩略图
-		// 3. 进行内容审核
-		// 4. 更新用户资产信息
+		xhttp.OkJson(c, struct {
+			Result *types.COSUploadObject `json:"result"`
+		}{Result: object})
+	}
+}
+
+// PinTokenMetadataHandler 将上传的图片与生成的元数据固定到IPFS, 返回可直接用于铸造的 token_uri
+func PinTokenMetadataHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 验证用户身份
+		address, err := middleware.GetAuthUserAddress(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		var req types.IPFSMetadataRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			xzap.WithContext(c).Error("invalid request parameters", zap.Error(err))
+			xhttp.Error(c, errcode.NewCustomErr("Invalid request parameters"))
+			return
+		}
+
+		result, err := service.PinTokenMetadata(c.Request.Context(), svcCtx, address, &req)
+		if err != nil {
+			if errcode.IsErr(err) {
+				xhttp.Error(c, err)
+				return
+			}
+			xzap.WithContext(c).Error("failed to pin token metadata", zap.Error(err))
+			xhttp.Error(c, errcode.NewCustomErr("Failed to pin token metadata"))
+			return
+		}
 
 		xhttp.OkJson(c, struct {
-			Result string `json:"result"`
-		}{Result: "Upload callback processed successfully"})
+			Result *types.IPFSMetadataResult `json:"result"`
+		}{Result: result})
 	}
 }
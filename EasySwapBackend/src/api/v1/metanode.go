@@ -284,14 +284,30 @@ func getContractAddress(svcCtx *svc.ServerCtx, chainID int) string {
 	return "0x0000000000000000000000000000000000000000"
 }
 
-// getContractOwner 根据链ID获取合约所有者地址
+// getContractOwner 获取铸造交易的签名地址, 即合约所有者地址
 func getContractOwner(svcCtx *svc.ServerCtx, chainID int) string {
-	if svcCtx.C.MetaNode == nil || svcCtx.C.MetaNode.OwnerPrivateKey == "" {
+	if svcCtx.Signer == nil {
 		return "0x0000000000000000000000000000000000000000"
 	}
 
-	// 从私钥推导出地址（这里简化处理，实际应该用crypto包计算）
-	// 注意：这里只是为了显示，实际生产中不应该暴露私钥对应的地址
-	// 可以考虑在配置文件中单独配置所有者地址
-	return "0x0000000000000000000000000000000000000000" // 可以从私钥计算得出
+	return svcCtx.Signer.Address().Hex()
+}
+
+// MetaNodeTxStatusHandler 按请求ID查询铸造交易状态
+func MetaNodeTxStatusHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.Param("request_id")
+		if requestID == "" {
+			xhttp.Error(c, errcode.NewCustomErr("request_id parameter is required"))
+			return
+		}
+
+		result, err := service.GetManagedTx(c.Request.Context(), svcCtx, requestID)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, result)
+	}
 }
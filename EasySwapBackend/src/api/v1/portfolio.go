@@ -5,6 +5,7 @@ import (
 
 	"github.com/ProjectsTask/EasySwapBase/errcode"
 	"github.com/ProjectsTask/EasySwapBase/xhttp"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 
 	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
@@ -26,6 +27,10 @@ func UserMultiChainCollectionsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
 			return
 		}
+		if !isHexAddresses(filter.UserAddresses) {
+			xhttp.Error(c, errcode.ErrInvalidParams)
+			return
+		}
 
 		var chainNames []string
 		var chainIDs []int
@@ -58,6 +63,10 @@ func UserMultiChainItemsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
 			return
 		}
+		if !isHexAddresses(filter.UserAddresses) || !isHexAddresses(filter.CollectionAddresses) {
+			xhttp.Error(c, errcode.ErrInvalidParams)
+			return
+		}
 
 		// if filter.ChainID is empty, show all chain info
 		if len(filter.ChainID) == 0 {
@@ -100,6 +109,10 @@ func UserMultiChainListingsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
 			return
 		}
+		if !isHexAddresses(filter.UserAddresses) || !isHexAddresses(filter.CollectionAddresses) {
+			xhttp.Error(c, errcode.ErrInvalidParams)
+			return
+		}
 
 		// if filter.ChainID is empty, show all chain info
 		if len(filter.ChainID) == 0 {
@@ -142,6 +155,10 @@ func UserMultiChainBidsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
 			return
 		}
+		if !isHexAddresses(filter.UserAddresses) || !isHexAddresses(filter.CollectionAddresses) {
+			xhttp.Error(c, errcode.ErrInvalidParams)
+			return
+		}
 
 		// if filter.ChainID is empty, show all chain info
 		if len(filter.ChainID) == 0 {
@@ -169,3 +186,14 @@ func UserMultiChainBidsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 		xhttp.OkJson(c, res)
 	}
 }
+
+// isHexAddresses 检查地址列表是否都是合法的十六进制地址
+func isHexAddresses(addrs []string) bool {
+	for _, addr := range addrs {
+		if !common.IsHexAddress(addr) {
+			return false
+		}
+	}
+
+	return true
+}
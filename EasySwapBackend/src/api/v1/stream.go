@@ -0,0 +1,109 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/errcode"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/xhttp"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/v1"
+)
+
+// 未配置 push.heartbeat 时的默认心跳间隔
+const defaultStreamHeartbeat = 15 * time.Second
+
+// MarketStreamHandler 通过 Server-Sent Events 推送挂单、出价、取消、成交与地板价变化事件
+// 查询参数: chain_id, collections/tokens/users 逗号分隔, cursor 或 Last-Event-ID 为续传游标
+func MarketStreamHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr("Invalid chain_id"))
+			return
+		}
+
+		// EventSource 重连时通过 Last-Event-ID 携带最后收到的事件编号
+		rawCursor := c.GetHeader("Last-Event-ID")
+		if rawCursor == "" {
+			rawCursor = c.Query("cursor")
+		}
+		var cursor uint64
+		if rawCursor != "" {
+			if cursor, err = strconv.ParseUint(rawCursor, 10, 64); err != nil {
+				xhttp.Error(c, errcode.NewCustomErr("Invalid cursor"))
+				return
+			}
+		}
+
+		sub, err := service.SubscribeMarketEvents(c.Request.Context(), svcCtx, chainID,
+			splitQuery(c, "collections"), splitQuery(c, "tokens"), splitQuery(c, "users"), cursor)
+		if err != nil {
+			if errcode.IsErr(err) {
+				xhttp.Error(c, err)
+				return
+			}
+			xzap.WithContext(c).Error("failed on subscribe market events", zap.Error(err))
+			xhttp.Error(c, errcode.NewCustomErr("Subscribe market events failed."))
+			return
+		}
+
+		heartbeat := defaultStreamHeartbeat
+		if svcCtx.C.Push != nil && svcCtx.C.Push.Heartbeat > 0 {
+			heartbeat = time.Duration(svcCtx.C.Push.Heartbeat) * time.Second
+		}
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no") // 关闭 nginx 缓冲
+		c.Status(http.StatusOK)
+		writeStreamEvent(c, sub.Start(), "ready", gin.H{"cursor": sub.Start()})
+
+		events := sub.Events()
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				writeStreamEvent(c, event.ID, event.Type, event)
+			case <-ticker.C:
+				fmt.Fprint(c.Writer, ": ping\n\n")
+				c.Writer.Flush()
+			}
+		}
+	}
+}
+
+func writeStreamEvent(c *gin.Context, id uint64, event string, data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		xzap.WithContext(c).Error("failed on marshal stream event", zap.Error(err))
+		return
+	}
+	fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", id, event, raw)
+	c.Writer.Flush()
+}
+
+// splitQuery 解析逗号分隔的查询参数, 忽略空值
+func splitQuery(c *gin.Context, key string) []string {
+	var values []string
+	for _, value := range strings.Split(c.Query(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
@@ -1,11 +1,15 @@
 package v1
 
 import (
+	"strconv"
+
 	"github.com/ProjectsTask/EasySwapBase/errcode"
 	"github.com/ProjectsTask/EasySwapBase/kit/validator"
 	"github.com/ProjectsTask/EasySwapBase/xhttp"
 	"github.com/gin-gonic/gin"
 
+	"github.com/ProjectsTask/EasySwapBackend/src/api/middleware"
+	"github.com/ProjectsTask/EasySwapBackend/src/common/session"
 	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
 	"github.com/ProjectsTask/EasySwapBackend/src/service/v1"
 	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
@@ -24,7 +28,10 @@ func UserLoginHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 			return
 		}
 
-		res, err := service.UserLogin(c.Request.Context(), svcCtx, req)
+		res, err := service.UserLogin(c.Request.Context(), svcCtx, req, session.Device{
+			ClientIp:  c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
 		if err != nil {
 			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
 			return
@@ -44,7 +51,13 @@ func GetLoginMessageHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 			return
 		}
 
-		res, err := service.GetUserLoginMsg(c.Request.Context(), svcCtx, address)
+		chainID, err := strconv.Atoi(c.DefaultQuery("chain_id", "0"))
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr("invalid chain_id"))
+			return
+		}
+
+		res, err := service.GetUserLoginMsg(c.Request.Context(), svcCtx, address, chainID)
 		if err != nil {
 			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
 			return
@@ -71,3 +84,85 @@ func GetSigStatusHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 		xhttp.OkJson(c, res)
 	}
 }
+
+// UserLogoutHandler 注销当前会话
+func UserLogoutHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessions, err := middleware.GetAuthSessions(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, errcode.ErrTokenVerify)
+			return
+		}
+
+		res, err := service.UserLogout(c.Request.Context(), svcCtx, sessions)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// UserLogoutAllHandler 注销当前地址在所有设备上的会话
+func UserLogoutAllHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessions, err := middleware.GetAuthSessions(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, errcode.ErrTokenVerify)
+			return
+		}
+
+		res, err := service.UserLogoutAll(c.Request.Context(), svcCtx, sessions)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// UserSessionsHandler 获取当前地址的会话列表
+func UserSessionsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessions, err := middleware.GetAuthSessions(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, errcode.ErrTokenVerify)
+			return
+		}
+
+		res, err := service.GetUserSessions(c.Request.Context(), svcCtx, sessions)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// UserRevokeSessionHandler 注销指定会话
+func UserRevokeSessionHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessions, err := middleware.GetAuthSessions(c, svcCtx.Sessions)
+		if err != nil {
+			xhttp.Error(c, errcode.ErrTokenVerify)
+			return
+		}
+
+		sessionID := c.Params.ByName("session_id")
+		if sessionID == "" {
+			xhttp.Error(c, errcode.NewCustomErr("session id is null"))
+			return
+		}
+
+		res, err := service.UserRevokeSession(c.Request.Context(), svcCtx, sessions, sessionID)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
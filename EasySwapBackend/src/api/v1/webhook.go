@@ -0,0 +1,161 @@
+package v1
+
+import (
+	"strconv"
+
+	"github.com/ProjectsTask/EasySwapBase/errcode"
+	"github.com/ProjectsTask/EasySwapBase/kit/validator"
+	"github.com/ProjectsTask/EasySwapBase/xhttp"
+	"github.com/gin-gonic/gin"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/v1"
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+// AdminGetWebhooksHandler 获取 webhook 订阅列表
+func AdminGetWebhooksHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		res, err := service.AdminGetWebhooks(c.Request.Context(), svcCtx)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// AdminGetWebhookHandler 获取单个 webhook 订阅
+func AdminGetWebhookHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr("invalid webhook id"))
+			return
+		}
+
+		res, err := service.AdminGetWebhook(c.Request.Context(), svcCtx, id)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// AdminCreateWebhookHandler 创建 webhook 订阅
+func AdminCreateWebhookHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := types.AdminCreateWebhookReq{}
+		if err := c.BindJSON(&req); err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		if err := validator.Verify(&req); err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		res, err := service.AdminCreateWebhook(c.Request.Context(), svcCtx, req)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// AdminUpdateWebhookHandler 修改 webhook 订阅
+func AdminUpdateWebhookHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr("invalid webhook id"))
+			return
+		}
+
+		req := types.AdminUpdateWebhookReq{}
+		if err := c.BindJSON(&req); err != nil {
+			xhttp.Error(c, err)
+			return
+		}
+
+		res, err := service.AdminUpdateWebhook(c.Request.Context(), svcCtx, id, req)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// AdminDeleteWebhookHandler 删除 webhook 订阅
+func AdminDeleteWebhookHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr("invalid webhook id"))
+			return
+		}
+
+		res, err := service.AdminDeleteWebhook(c.Request.Context(), svcCtx, id)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// AdminGetWebhookDeliveriesHandler 获取投递记录, status=dead 时为死信列表
+func AdminGetWebhookDeliveriesHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := types.AdminGetWebhookDeliveriesReq{
+			Page:     1,
+			PageSize: 20,
+		}
+
+		if err := c.ShouldBindQuery(&req); err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		if err := validator.Verify(&req); err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		res, err := service.AdminGetWebhookDeliveries(c.Request.Context(), svcCtx, req)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// AdminRedeliverWebhookHandler 重新投递死信或已成功的记录
+func AdminRedeliverWebhookHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr("invalid delivery id"))
+			return
+		}
+
+		res, err := service.AdminRedeliverWebhook(c.Request.Context(), svcCtx, id)
+		if err != nil {
+			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
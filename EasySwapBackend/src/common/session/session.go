@@ -0,0 +1,300 @@
+package session
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/pkg/errors"
+)
+
+const (
+	// sessionKeyPrefix 会话详情, 过期时间与 token 一致
+	sessionKeyPrefix = "cache:orderbookdex:login:session"
+	// addressSessionsKeyPrefix 地址下的会话索引(zset, score 为过期时间)
+	addressSessionsKeyPrefix = "cache:orderbookdex:login:sessions"
+
+	tokenPartSep  = "."
+	sessionIDSize = 16
+	// maxTokenLength 超长 token 直接拒绝, 避免无意义的解码与签名计算
+	maxTokenLength = 512
+)
+
+var (
+	ErrInvalidToken   = errors.New("invalid session token")
+	ErrSessionExpired = errors.New("session expired")
+	ErrSessionRevoked = errors.New("session revoked")
+)
+
+// Session 登录会话
+type Session struct {
+	ID        string `json:"id"`
+	Address   string `json:"address"`
+	ClientIp  string `json:"client_ip"`
+	UserAgent string `json:"user_agent"`
+	CreatedAt int64  `json:"created_at"` // unix second
+	ExpiresAt int64  `json:"expires_at"` // unix second
+}
+
+// Device 创建会话时记录的客户端信息
+type Device struct {
+	ClientIp  string
+	UserAgent string
+}
+
+// claims token 中携带的签名内容
+type claims struct {
+	SessionID string `json:"sid"`
+	Address   string `json:"addr"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Manager 会话管理: token 由 HMAC-SHA256 签名, 会话状态保存在 kv 中, 删除后 token 立即失效
+type Manager struct {
+	kv     *xkv.Store
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewManager 新建会话管理器
+func NewManager(kv *xkv.Store, secret []byte, ttl time.Duration) *Manager {
+	return &Manager{
+		kv:     kv,
+		secret: secret,
+		ttl:    ttl,
+	}
+}
+
+func sessionKey(sessionID string) string {
+	return sessionKeyPrefix + ":" + sessionID
+}
+
+func addressSessionsKey(address string) string {
+	return addressSessionsKeyPrefix + ":" + strings.ToLower(address)
+}
+
+// Create 为地址创建新会话并签发 token
+func (m *Manager) Create(ctx context.Context, address string, device Device) (string, *Session, error) {
+	id := make([]byte, sessionIDSize)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, errors.Wrap(err, "failed on generate session id")
+	}
+
+	now := time.Now()
+	s := &Session{
+		ID:        hex.EncodeToString(id),
+		Address:   strings.ToLower(address),
+		ClientIp:  device.ClientIp,
+		UserAgent: device.UserAgent,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(m.ttl).Unix(),
+	}
+
+	token, err := m.sign(claims{
+		SessionID: s.ID,
+		Address:   s.Address,
+		IssuedAt:  s.CreatedAt,
+		ExpiresAt: s.ExpiresAt,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed on marshal session")
+	}
+	ttlSeconds := int(m.ttl / time.Second)
+	if err := m.kv.SetexCtx(ctx, sessionKey(s.ID), string(raw), ttlSeconds); err != nil {
+		return "", nil, errors.Wrap(err, "failed on save session")
+	}
+	indexKey := addressSessionsKey(s.Address)
+	if _, err := m.kv.ZaddCtx(ctx, indexKey, s.ExpiresAt, s.ID); err != nil {
+		return "", nil, errors.Wrap(err, "failed on index session")
+	}
+	// 索引随最后一个会话一起过期
+	if err := m.kv.ExpireCtx(ctx, indexKey, ttlSeconds); err != nil {
+		return "", nil, errors.Wrap(err, "failed on index session")
+	}
+
+	return token, s, nil
+}
+
+// Verify 校验 token 签名与过期时间, 并确认会话未被注销
+func (m *Manager) Verify(ctx context.Context, token string) (*Session, error) {
+	c, err := m.parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().Unix() >= c.ExpiresAt {
+		return nil, ErrSessionExpired
+	}
+
+	raw, err := m.kv.GetCtx(ctx, sessionKey(c.SessionID))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on read session")
+	}
+	if raw == "" {
+		return nil, ErrSessionRevoked
+	}
+
+	var s Session
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		return nil, errors.Wrap(err, "failed on unmarshal session")
+	}
+	if s.ID != c.SessionID || s.Address != c.Address {
+		return nil, ErrInvalidToken
+	}
+
+	return &s, nil
+}
+
+// List 列出地址下所有未过期的会话, 按创建时间排序
+func (m *Manager) List(ctx context.Context, address string) ([]*Session, error) {
+	indexKey := addressSessionsKey(address)
+	if _, err := m.kv.ZremrangebyscoreCtx(ctx, indexKey, 0, time.Now().Unix()); err != nil {
+		return nil, errors.Wrap(err, "failed on clean expired sessions")
+	}
+	ids, err := m.kv.ZrangeCtx(ctx, indexKey, 0, -1)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on list sessions")
+	}
+
+	var sessions []*Session
+	for _, id := range ids {
+		raw, err := m.kv.GetCtx(ctx, sessionKey(id))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on read session")
+		}
+		if raw == "" {
+			// 会话已被删除, 顺带清理索引
+			if _, err := m.kv.ZremCtx(ctx, indexKey, id); err != nil {
+				return nil, errors.Wrap(err, "failed on clean revoked session")
+			}
+			continue
+		}
+
+		var s Session
+		if err := json.Unmarshal([]byte(raw), &s); err != nil {
+			return nil, errors.Wrap(err, "failed on unmarshal session")
+		}
+		sessions = append(sessions, &s)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt < sessions[j].CreatedAt
+	})
+
+	return sessions, nil
+}
+
+// Revoke 注销地址下的指定会话, 会话不存在时返回 false
+func (m *Manager) Revoke(ctx context.Context, address, sessionID string) (bool, error) {
+	raw, err := m.kv.GetCtx(ctx, sessionKey(sessionID))
+	if err != nil {
+		return false, errors.Wrap(err, "failed on read session")
+	}
+	if raw == "" {
+		return false, nil
+	}
+
+	var s Session
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		return false, errors.Wrap(err, "failed on unmarshal session")
+	}
+	// 只能注销自己的会话
+	if !strings.EqualFold(s.Address, address) {
+		return false, nil
+	}
+
+	if _, err := m.kv.DelCtx(ctx, sessionKey(sessionID)); err != nil {
+		return false, errors.Wrap(err, "failed on delete session")
+	}
+	if _, err := m.kv.ZremCtx(ctx, addressSessionsKey(address), sessionID); err != nil {
+		return false, errors.Wrap(err, "failed on delete session index")
+	}
+
+	return true, nil
+}
+
+// RevokeAll 注销地址下的全部会话, 返回注销数量
+func (m *Manager) RevokeAll(ctx context.Context, address string) (int, error) {
+	indexKey := addressSessionsKey(address)
+	ids, err := m.kv.ZrangeCtx(ctx, indexKey, 0, -1)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on list sessions")
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, sessionKey(id))
+	}
+	count, err := m.kv.DelCtx(ctx, keys...)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on delete sessions")
+	}
+	if _, err := m.kv.DelCtx(ctx, indexKey); err != nil {
+		return 0, errors.Wrap(err, "failed on delete session index")
+	}
+
+	return count, nil
+}
+
+// sign 生成 token: base64url(claims).base64url(hmac)
+func (m *Manager) sign(c claims) (string, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", errors.Wrap(err, "failed on marshal session claims")
+	}
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+
+	return payload + tokenPartSep + base64.RawURLEncoding.EncodeToString(m.mac(payload)), nil
+}
+
+// parse 校验签名并解析 token, 任何格式错误都返回 ErrInvalidToken
+func (m *Manager) parse(token string) (*claims, error) {
+	if token == "" || len(token) > maxTokenLength {
+		return nil, ErrInvalidToken
+	}
+	parts := strings.Split(token, tokenPartSep)
+	if len(parts) != 2 {
+		return nil, ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, m.mac(parts[0])) {
+		return nil, ErrInvalidToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var c claims
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if c.SessionID == "" || c.Address == "" || c.ExpiresAt == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	return &c, nil
+}
+
+func (m *Manager) mac(payload string) []byte {
+	h := hmac.New(sha256.New, m.secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
@@ -0,0 +1,159 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/stores/kv"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+)
+
+const testAddress = "0xF39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+
+func newTestManager(t *testing.T, secret string) *Manager {
+	store := xkv.NewStore(kv.KvConf{{
+		RedisConf: redis.RedisConf{Host: miniredis.RunT(t).Addr(), Type: redis.NodeType},
+		Weight:    100,
+	}})
+
+	return NewManager(store, []byte(secret), time.Hour)
+}
+
+func TestCreateAndVerify(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, "0123456789abcdef0123456789abcdef")
+
+	token, s, err := m.Create(ctx, testAddress, Device{ClientIp: "127.0.0.1", UserAgent: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, strings.ToLower(testAddress), s.Address)
+
+	verified, err := m.Verify(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, s, verified)
+
+	sessions, err := m.List(ctx, testAddress)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+}
+
+func TestVerifyRejectsMalformedToken(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, "0123456789abcdef0123456789abcdef")
+	token, _, err := m.Create(ctx, testAddress, Device{})
+	assert.NoError(t, err)
+	payload := strings.Split(token, tokenPartSep)[0]
+
+	// 签名正确但内容不是合法 claims
+	notJSON := base64.RawURLEncoding.EncodeToString([]byte("not json"))
+	emptyClaims, err := m.sign(claims{})
+	assert.NoError(t, err)
+
+	tokens := map[string]string{
+		"empty":              "",
+		"no separator":       "abc",
+		"too many parts":     token + tokenPartSep + "abc",
+		"only separators":    "..",
+		"too long":           strings.Repeat("a", maxTokenLength+1),
+		"bad signature b64":  payload + tokenPartSep + "!!!",
+		"empty signature":    payload + tokenPartSep,
+		"bad payload b64":    "!!!" + tokenPartSep + base64.RawURLEncoding.EncodeToString(m.mac("!!!")),
+		"payload not json":   notJSON + tokenPartSep + base64.RawURLEncoding.EncodeToString(m.mac(notJSON)),
+		"missing claims":     emptyClaims,
+		"unicode separators": "。" + tokenPartSep + "。",
+	}
+	for name, token := range tokens {
+		assert.NotPanics(t, func() {
+			_, err := m.Verify(ctx, token)
+			assert.ErrorIs(t, err, ErrInvalidToken, name)
+		}, name)
+	}
+}
+
+func TestVerifyRejectsTamperedToken(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, "0123456789abcdef0123456789abcdef")
+	token, s, err := m.Create(ctx, testAddress, Device{})
+	assert.NoError(t, err)
+	parts := strings.Split(token, tokenPartSep)
+
+	// 替换 claims 中的地址, 保留原签名
+	forged, err := m.sign(claims{SessionID: s.ID, Address: "0x70997970c51812dc3a010c7d01b50e0d17dc79c8", IssuedAt: s.CreatedAt, ExpiresAt: s.ExpiresAt})
+	assert.NoError(t, err)
+	forgedPayload := strings.Split(forged, tokenPartSep)[0]
+
+	// 修改签名最后一个字符
+	sig := []byte(parts[1])
+	if sig[len(sig)-1] == 'A' {
+		sig[len(sig)-1] = 'B'
+	} else {
+		sig[len(sig)-1] = 'A'
+	}
+
+	// 其他密钥签发的 token
+	other := newTestManager(t, "fedcba9876543210fedcba9876543210")
+	otherToken, _, err := other.Create(ctx, testAddress, Device{})
+	assert.NoError(t, err)
+
+	tokens := map[string]string{
+		"swapped payload":  forgedPayload + tokenPartSep + parts[1],
+		"flipped sig":      parts[0] + tokenPartSep + string(sig),
+		"other secret":     otherToken,
+		"truncated sig":    parts[0] + tokenPartSep + parts[1][:len(parts[1])-4],
+		"appended payload": parts[0] + "A" + tokenPartSep + parts[1],
+	}
+	for name, token := range tokens {
+		assert.NotPanics(t, func() {
+			_, err := m.Verify(ctx, token)
+			assert.ErrorIs(t, err, ErrInvalidToken, name)
+		}, name)
+	}
+
+	// 签名有效但会话与 claims 不一致
+	_, err = m.Verify(ctx, forged)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifyRejectsExpiredAndRevokedToken(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, "0123456789abcdef0123456789abcdef")
+	token, s, err := m.Create(ctx, testAddress, Device{})
+	assert.NoError(t, err)
+
+	expired, err := m.sign(claims{SessionID: s.ID, Address: s.Address, IssuedAt: s.CreatedAt - 7200, ExpiresAt: time.Now().Unix() - 1})
+	assert.NoError(t, err)
+	_, err = m.Verify(ctx, expired)
+	assert.ErrorIs(t, err, ErrSessionExpired)
+
+	// 其他地址不能注销该会话
+	revoked, err := m.Revoke(ctx, "0x70997970c51812dc3a010c7d01b50e0d17dc79c8", s.ID)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = m.Revoke(ctx, testAddress, s.ID)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	_, err = m.Verify(ctx, token)
+	assert.ErrorIs(t, err, ErrSessionRevoked)
+
+	// 注销全部会话
+	first, _, err := m.Create(ctx, testAddress, Device{})
+	assert.NoError(t, err)
+	second, _, err := m.Create(ctx, testAddress, Device{})
+	assert.NoError(t, err)
+	count, err := m.RevokeAll(ctx, testAddress)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	for _, token := range []string{first, second} {
+		_, err = m.Verify(ctx, token)
+		assert.ErrorIs(t, err, ErrSessionRevoked)
+	}
+	sessions, err := m.List(ctx, testAddress)
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+}
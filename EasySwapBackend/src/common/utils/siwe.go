@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// EIP-4361 (Sign-In with Ethereum) 消息格式
+const (
+	siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+	siweVersion      = "1"
+
+	siweURITag            = "URI: "
+	siweVersionTag        = "Version: "
+	siweChainIDTag        = "Chain ID: "
+	siweNonceTag          = "Nonce: "
+	siweIssuedAtTag       = "Issued At: "
+	siweExpirationTimeTag = "Expiration Time: "
+	siweNotBeforeTag      = "Not Before: "
+	siweRequestIDTag      = "Request ID: "
+	siweResourcesTag      = "Resources:"
+)
+
+// SiweMessage Sign-In with Ethereum 结构化登录消息
+type SiweMessage struct {
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// String 按 EIP-4361 格式生成待签名消息
+func (m *SiweMessage) String() string {
+	var b strings.Builder
+	b.WriteString(m.Domain + siweHeaderSuffix + "\n")
+	b.WriteString(m.Address + "\n\n")
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(siweURITag + m.URI + "\n")
+	b.WriteString(siweVersionTag + m.Version + "\n")
+	b.WriteString(siweChainIDTag + strconv.Itoa(m.ChainID) + "\n")
+	b.WriteString(siweNonceTag + m.Nonce + "\n")
+	b.WriteString(siweIssuedAtTag + m.IssuedAt.UTC().Format(time.RFC3339))
+	if m.ExpirationTime != nil {
+		b.WriteString("\n" + siweExpirationTimeTag + m.ExpirationTime.UTC().Format(time.RFC3339))
+	}
+	if m.NotBefore != nil {
+		b.WriteString("\n" + siweNotBeforeTag + m.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if m.RequestID != "" {
+		b.WriteString("\n" + siweRequestIDTag + m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\n" + siweResourcesTag)
+		for _, resource := range m.Resources {
+			b.WriteString("\n- " + resource)
+		}
+	}
+
+	return b.String()
+}
+
+// ParseSiweMessage 解析 EIP-4361 登录消息
+func ParseSiweMessage(message string) (*SiweMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	if len(lines) < 2 {
+		return nil, errors.New("invalid siwe message")
+	}
+
+	if !strings.HasSuffix(lines[0], siweHeaderSuffix) {
+		return nil, errors.New("invalid siwe message header")
+	}
+	m := &SiweMessage{
+		Domain:  strings.TrimSuffix(lines[0], siweHeaderSuffix),
+		Address: strings.TrimSpace(lines[1]),
+	}
+	if m.Domain == "" {
+		return nil, errors.New("siwe message domain is empty")
+	}
+	if !common.IsHexAddress(m.Address) {
+		return nil, errors.New("invalid siwe message address")
+	}
+
+	// 地址后到 URI 之间为可选的 statement
+	i := 2
+	var statement []string
+	for ; i < len(lines) && !strings.HasPrefix(lines[i], siweURITag); i++ {
+		if lines[i] != "" {
+			statement = append(statement, lines[i])
+		}
+	}
+	if len(statement) > 1 {
+		return nil, errors.New("siwe message statement must be a single line")
+	}
+	m.Statement = strings.Join(statement, "")
+
+	var err error
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, siweURITag):
+			m.URI = strings.TrimPrefix(line, siweURITag)
+		case strings.HasPrefix(line, siweVersionTag):
+			m.Version = strings.TrimPrefix(line, siweVersionTag)
+		case strings.HasPrefix(line, siweChainIDTag):
+			if m.ChainID, err = strconv.Atoi(strings.TrimPrefix(line, siweChainIDTag)); err != nil {
+				return nil, errors.Wrap(err, "invalid siwe message chain id")
+			}
+		case strings.HasPrefix(line, siweNonceTag):
+			m.Nonce = strings.TrimPrefix(line, siweNonceTag)
+		case strings.HasPrefix(line, siweIssuedAtTag):
+			if m.IssuedAt, err = time.Parse(time.RFC3339, strings.TrimPrefix(line, siweIssuedAtTag)); err != nil {
+				return nil, errors.Wrap(err, "invalid siwe message issued at")
+			}
+		case strings.HasPrefix(line, siweExpirationTimeTag):
+			t, err := time.Parse(time.RFC3339, strings.TrimPrefix(line, siweExpirationTimeTag))
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid siwe message expiration time")
+			}
+			m.ExpirationTime = &t
+		case strings.HasPrefix(line, siweNotBeforeTag):
+			t, err := time.Parse(time.RFC3339, strings.TrimPrefix(line, siweNotBeforeTag))
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid siwe message not before")
+			}
+			m.NotBefore = &t
+		case strings.HasPrefix(line, siweRequestIDTag):
+			m.RequestID = strings.TrimPrefix(line, siweRequestIDTag)
+		case line == siweResourcesTag:
+			for i++; i < len(lines); i++ {
+				if !strings.HasPrefix(lines[i], "- ") {
+					return nil, errors.New("invalid siwe message resource")
+				}
+				m.Resources = append(m.Resources, strings.TrimPrefix(lines[i], "- "))
+			}
+		case line == "":
+		default:
+			return nil, errors.New(fmt.Sprintf("unexpected siwe message line: %s", line))
+		}
+	}
+
+	if m.URI == "" || m.Nonce == "" || m.ChainID == 0 || m.IssuedAt.IsZero() {
+		return nil, errors.New("siwe message missing required fields")
+	}
+	if m.Version != siweVersion {
+		return nil, errors.New("unsupported siwe message version")
+	}
+	if len(m.Nonce) < 8 {
+		return nil, errors.New("siwe message nonce too short")
+	}
+
+	return m, nil
+}
+
+// VerifyTime 校验消息时间: issued-at 不晚于当前时间(允许 skew 误差)且不早于 maxAge,
+// 存在 expiration-time / not-before 时一并校验
+func (m *SiweMessage) VerifyTime(now time.Time, maxAge, skew time.Duration) error {
+	if m.IssuedAt.After(now.Add(skew)) {
+		return errors.New("siwe message issued in the future")
+	}
+	if maxAge > 0 && m.IssuedAt.Before(now.Add(-maxAge)) {
+		return errors.New("siwe message too old")
+	}
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return errors.New("siwe message expired")
+	}
+	if m.NotBefore != nil && now.Add(skew).Before(*m.NotBefore) {
+		return errors.New("siwe message not yet valid")
+	}
+
+	return nil
+}
+
+// RecoverPersonalSignAddress 通过 ecrecover 恢复 personal_sign 签名的地址
+func RecoverPersonalSignAddress(message, sigHex string) (common.Address, error) {
+	signature, err := hexutil.Decode(sigHex)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "invalid signature hex")
+	}
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, errors.New("invalid signature length")
+	}
+	// 兼容 v 为 27/28 与 0/1 两种格式
+	if signature[crypto.RecoveryIDOffset] >= 27 {
+		signature[crypto.RecoveryIDOffset] -= 27
+	}
+	if signature[crypto.RecoveryIDOffset] > 1 {
+		return common.Address{}, errors.New("invalid signature recovery id")
+	}
+
+	publicKey, err := crypto.SigToPub(accounts.TextHash([]byte(message)), signature)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "failed on recover public key")
+	}
+
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
+
+// VerifyPersonalSign 校验 personal_sign 签名是否由 addr 签署
+func VerifyPersonalSign(addr, message, sigHex string) bool {
+	recovered, err := RecoverPersonalSignAddress(message, sigHex)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(recovered.Hex(), addr)
+}
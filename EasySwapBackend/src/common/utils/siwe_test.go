@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSiweMessage() *SiweMessage {
+	issuedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expirationTime := issuedAt.Add(10 * time.Minute)
+	return &SiweMessage{
+		Domain:         "easyswap.link",
+		Address:        "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		Statement:      "Welcome to EasySwap!",
+		URI:            "https://easyswap.link",
+		Version:        "1",
+		ChainID:        11155111,
+		Nonce:          "0123456789abcdef",
+		IssuedAt:       issuedAt,
+		ExpirationTime: &expirationTime,
+	}
+}
+
+func TestParseSiweMessage(t *testing.T) {
+	msg := newTestSiweMessage()
+	msg.RequestID = "req-1"
+	msg.Resources = []string{"https://easyswap.link/terms"}
+
+	parsed, err := ParseSiweMessage(msg.String())
+	assert.NoError(t, err)
+	assert.Equal(t, msg, parsed)
+
+	// 兼容 \r\n 换行
+	parsed, err = ParseSiweMessage(strings.ReplaceAll(msg.String(), "\n", "\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, msg.Nonce, parsed.Nonce)
+}
+
+func TestParseSiweMessageInvalid(t *testing.T) {
+	valid := newTestSiweMessage().String()
+	cases := map[string]string{
+		"empty":             "",
+		"nonce only":        "0123456789abcdef",
+		"bad header":        strings.Replace(valid, siweHeaderSuffix, " wants your account:", 1),
+		"empty domain":      strings.TrimPrefix(valid, "easyswap.link"),
+		"bad address":       strings.Replace(valid, "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266", "0x1234", 1),
+		"multi statement":   strings.Replace(valid, "Welcome to EasySwap!", "Welcome\nto EasySwap!", 1),
+		"bad chain id":      strings.Replace(valid, "Chain ID: 11155111", "Chain ID: sepolia", 1),
+		"missing nonce":     strings.Replace(valid, "Nonce: 0123456789abcdef\n", "", 1),
+		"short nonce":       strings.Replace(valid, "Nonce: 0123456789abcdef", "Nonce: 0123", 1),
+		"bad version":       strings.Replace(valid, "Version: 1", "Version: 2", 1),
+		"bad issued at":     strings.Replace(valid, "Issued At: 2024-01-01T00:00:00Z", "Issued At: yesterday", 1),
+		"unexpected line":   valid + "\nFoo: bar",
+		"bad resource item": valid + "\n" + siweResourcesTag + "\nhttps://easyswap.link",
+	}
+	for name, message := range cases {
+		_, err := ParseSiweMessage(message)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestSiweMessageVerifyTime(t *testing.T) {
+	msg := newTestSiweMessage()
+	notBefore := msg.IssuedAt.Add(time.Minute)
+	lateNotBefore := msg.IssuedAt.Add(2 * time.Minute)
+	maxAge := 10 * time.Minute
+	skew := time.Minute
+
+	cases := []struct {
+		name      string
+		now       time.Time
+		notBefore *time.Time
+		ok        bool
+	}{
+		{name: "valid", now: msg.IssuedAt.Add(time.Minute), ok: true},
+		{name: "issued in future within skew", now: msg.IssuedAt.Add(-30 * time.Second), ok: true},
+		{name: "issued in future", now: msg.IssuedAt.Add(-2 * time.Minute)},
+		{name: "too old", now: msg.IssuedAt.Add(11 * time.Minute)},
+		{name: "expired", now: *msg.ExpirationTime},
+		{name: "not yet valid", now: msg.IssuedAt, notBefore: &lateNotBefore},
+		{name: "not before within skew", now: msg.IssuedAt.Add(30 * time.Second), notBefore: &notBefore, ok: true},
+	}
+	for _, c := range cases {
+		m := *msg
+		m.NotBefore = c.notBefore
+		err := m.VerifyTime(c.now, maxAge, skew)
+		if c.ok {
+			assert.NoError(t, err, c.name)
+		} else {
+			assert.Error(t, err, c.name)
+		}
+	}
+}
+
+func TestRecoverPersonalSignAddress(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	message := newTestSiweMessage().String()
+
+	signature, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	assert.NoError(t, err)
+
+	// 钱包返回的签名 v 为 27/28
+	walletSig := append([]byte{}, signature...)
+	walletSig[crypto.RecoveryIDOffset] += 27
+	for _, sig := range [][]byte{signature, walletSig} {
+		recovered, err := RecoverPersonalSignAddress(message, hexutil.Encode(sig))
+		assert.NoError(t, err)
+		assert.Equal(t, address, recovered)
+	}
+	assert.True(t, VerifyPersonalSign(strings.ToLower(address.Hex()), message, hexutil.Encode(walletSig)))
+
+	// 消息被篡改后恢复出其他地址
+	recovered, err := RecoverPersonalSignAddress(message+" ", hexutil.Encode(walletSig))
+	assert.NoError(t, err)
+	assert.NotEqual(t, address, recovered)
+	assert.False(t, VerifyPersonalSign(address.Hex(), message+" ", hexutil.Encode(walletSig)))
+
+	badRecoveryID := append([]byte{}, signature...)
+	badRecoveryID[crypto.RecoveryIDOffset] = 30
+	for name, sig := range map[string]string{
+		"not hex":           "signature",
+		"short":             hexutil.Encode(signature[:64]),
+		"bad recovery id":   hexutil.Encode(badRecoveryID),
+		"missing 0x prefix": strings.TrimPrefix(hexutil.Encode(signature), "0x"),
+	} {
+		_, err := RecoverPersonalSignAddress(message, sig)
+		assert.Error(t, err, name)
+	}
+}
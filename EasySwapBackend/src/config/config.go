@@ -21,7 +21,14 @@ type Config struct {
 	MetadataParse  *MetadataParse    `toml:"metadata_parse" mapstructure:"metadata_parse" json:"metadata_parse"`
 	ChainSupported []*ChainSupported `toml:"chain_supported" mapstructure:"chain_supported" json:"chain_supported"`
 	COS            *COSConfig        `toml:"cos" mapstructure:"cos" json:"cos"`
+	IPFS           *IPFSConfig       `toml:"ipfs" mapstructure:"ipfs" json:"ipfs"`
 	MetaNode       *MetaNodeConfig   `toml:"metanode" mapstructure:"metanode" json:"metanode"`
+	Mint           *MintConfig       `toml:"mint" mapstructure:"mint" json:"mint"`
+	Login          *LoginConfig      `toml:"login" mapstructure:"login" json:"login"`
+	Admin          *AdminConfig      `toml:"admin" mapstructure:"admin" json:"admin"`
+	Push           *PushConfig       `toml:"push" mapstructure:"push" json:"push"`
+	Webhook        *WebhookConfig    `toml:"webhook" mapstructure:"webhook" json:"webhook"`
+	Alert          *AlertConfig      `toml:"alert" mapstructure:"alert" json:"alert"`
 }
 
 type ProjectCfg struct {
@@ -56,25 +63,123 @@ type ChainSupported struct {
 	Name     string `toml:"name" mapstructure:"name" json:"name"`
 	ChainID  int    `toml:"chain_id" mapstructure:"chain_id" json:"chain_id"`
 	Endpoint string `toml:"endpoint" mapstructure:"endpoint" json:"endpoint"`
+	// Endpoints 多个RPC节点, 配置后按健康度路由并自动故障切换, 优先于 Endpoint
+	Endpoints []string `toml:"endpoints" mapstructure:"endpoints" json:"-"`
+	// VaultAddress EasySwapVault 合约地址, 用于判断挂单能否由市场合约成交
+	VaultAddress string `toml:"vault_address" mapstructure:"vault_address" json:"vault_address"`
 }
 
-// COSConfig 腾讯云COS配置
+// COSConfig 腾讯云COS配置, 通过 S3 兼容接口访问, 也可对接 AWS S3 或 MinIO
 type COSConfig struct {
-	SecretID  string `toml:"secret_id" mapstructure:"secret_id" json:"secret_id"`
-	SecretKey string `toml:"secret_key" mapstructure:"secret_key" json:"secret_key"`
-	Bucket    string `toml:"bucket" mapstructure:"bucket" json:"bucket"`
-	Region    string `toml:"region" mapstructure:"region" json:"region"`
-	AppID     string `toml:"app_id" mapstructure:"app_id" json:"app_id"`
-	Domain    string `toml:"domain" mapstructure:"domain" json:"domain"` // 自定义域名（可选）
+	SecretID     string `toml:"secret_id" mapstructure:"secret_id" json:"secret_id"`
+	SecretKey    string `toml:"secret_key" mapstructure:"secret_key" json:"secret_key"`
+	Bucket       string `toml:"bucket" mapstructure:"bucket" json:"bucket"`
+	Region       string `toml:"region" mapstructure:"region" json:"region"`
+	AppID        string `toml:"app_id" mapstructure:"app_id" json:"app_id"`
+	Domain       string `toml:"domain" mapstructure:"domain" json:"domain"`                      // 自定义域名（可选）
+	Endpoint     string `toml:"endpoint" mapstructure:"endpoint" json:"endpoint"`                // S3 兼容接口地址（可选）, 默认 https://cos.<region>.myqcloud.com
+	PathStyle    bool   `toml:"path_style" mapstructure:"path_style" json:"path_style"`          // 使用 endpoint/bucket/key 形式访问, MinIO 需开启
+	UploadExpire int    `toml:"upload_expire" mapstructure:"upload_expire" json:"upload_expire"` // 上传地址与策略有效期（秒）, 默认 900
+}
+
+// IPFSConfig IPFS 节点配置, 通过 Kubo HTTP API 上传并固定铸造用的媒体文件与元数据
+type IPFSConfig struct {
+	APIURL  string            `toml:"api_url" mapstructure:"api_url" json:"api_url"` // Kubo RPC 地址, 如 http://127.0.0.1:5001
+	Headers map[string]string `toml:"headers" mapstructure:"headers" json:"-"`       // 附加请求头, 如托管节点的鉴权信息
+	Timeout int               `toml:"timeout" mapstructure:"timeout" json:"timeout"` // 请求超时（秒）, 默认 300
 }
 
 // MetaNodeConfig MetaNodeNFT配置
 type MetaNodeConfig struct {
-	OwnerPrivateKey   string            `toml:"owner_private_key" mapstructure:"owner_private_key" json:"owner_private_key"`
+	Signer            *SignerConfig     `toml:"signer" mapstructure:"signer" json:"signer"`                                     // 交易签名器
+	OwnerPrivateKey   string            `toml:"owner_private_key" mapstructure:"owner_private_key" json:"-"`                    // 十六进制私钥, 仅用于本地开发, 配置 signer 后忽略
+	DevPrivateKey     bool              `toml:"dev_private_key" mapstructure:"dev_private_key" json:"dev_private_key"`          // 显式开启后才允许使用 owner_private_key, 生产环境禁止开启
 	ContractAddresses map[string]string `toml:"contract_addresses" mapstructure:"contract_addresses" json:"contract_addresses"` // 按链ID映射合约地址
 	RPCEndpoints      map[string]string `toml:"rpc_endpoints" mapstructure:"rpc_endpoints" json:"rpc_endpoints"`                // 按链ID映射RPC端点
 	GasLimit          uint64            `toml:"gas_limit" mapstructure:"gas_limit" json:"gas_limit"`
-	GasPrice          string            `toml:"gas_price" mapstructure:"gas_price" json:"gas_price"` // Gas价格（wei）
+	GasPrice          string            `toml:"gas_price" mapstructure:"gas_price" json:"gas_price"` // Gas价格（wei）, 配置后使用固定价格的传统交易, 否则按 EIP-1559 估算
+	TxManager         *TxManagerConfig  `toml:"tx_manager" mapstructure:"tx_manager" json:"tx_manager"`
+}
+
+// SignerConfig 服务端交易签名器配置, type: keystore, env 或 remote
+type SignerConfig struct {
+	Type          string            `toml:"type" mapstructure:"type" json:"type"`
+	KeyEnv        string            `toml:"key_env" mapstructure:"key_env" json:"key_env"`                   // env: 私钥所在的环境变量名
+	KeystoreFile  string            `toml:"keystore_file" mapstructure:"keystore_file" json:"keystore_file"` // keystore: keystore JSON 文件路径
+	PasswordFile  string            `toml:"password_file" mapstructure:"password_file" json:"password_file"` // keystore: 密码文件路径
+	PasswordEnv   string            `toml:"password_env" mapstructure:"password_env" json:"password_env"`    // keystore: 密码所在的环境变量名
+	RemoteURL     string            `toml:"remote_url" mapstructure:"remote_url" json:"remote_url"`          // remote: Clef/Web3Signer 地址
+	Address       string            `toml:"address" mapstructure:"address" json:"address"`                   // remote: 签名地址
+	RemoteHeaders map[string]string `toml:"remote_headers" mapstructure:"remote_headers" json:"-"`           // remote: 附加请求头, 如鉴权信息
+}
+
+// TxManagerConfig 交易管理器配置, 为 0 时使用默认值
+type TxManagerConfig struct {
+	PollInterval   int `toml:"poll_interval" mapstructure:"poll_interval" json:"poll_interval"`          // 检查待确认交易的间隔（秒）, 默认 5
+	StuckTimeout   int `toml:"stuck_timeout" mapstructure:"stuck_timeout" json:"stuck_timeout"`          // 超过该时长未上链时自动加速（秒）, 默认 180
+	MaxAttempts    int `toml:"max_attempts" mapstructure:"max_attempts" json:"max_attempts"`             // 单笔交易最大发送次数（含自动加速）, 默认 5
+	FeeBumpPercent int `toml:"fee_bump_percent" mapstructure:"fee_bump_percent" json:"fee_bump_percent"` // 加速与取消的费用涨幅（%）, 默认 15, 不低于 10
+}
+
+// MintConfig 合集铸造配置, 铸造交易复用 metanode 的签名器、RPC端点与Gas配置
+type MintConfig struct {
+	Collections []*MintCollection `toml:"collections" mapstructure:"collections" json:"collections"`
+}
+
+// MintCollection 允许通过 /collections/:address/mint 铸造的合集, 合约需实现 safeMint(address,string)
+type MintCollection struct {
+	ChainID int      `toml:"chain_id" mapstructure:"chain_id" json:"chain_id"`
+	Address string   `toml:"address" mapstructure:"address" json:"address"`
+	Minters []string `toml:"minters" mapstructure:"minters" json:"minters"` // 允许铸造的用户地址
+}
+
+// LoginConfig 钱包登录(Sign-In with Ethereum)配置
+type LoginConfig struct {
+	Domain    string `toml:"domain" mapstructure:"domain" json:"domain"`          // 登录消息中的域名, 需与前端站点域名一致
+	URI       string `toml:"uri" mapstructure:"uri" json:"uri"`                   // 登录消息中的 URI
+	Statement string `toml:"statement" mapstructure:"statement" json:"statement"` // 登录消息中展示给用户的说明
+	NonceTTL  int    `toml:"nonce_ttl" mapstructure:"nonce_ttl" json:"nonce_ttl"` // 登录消息有效期（秒）
+
+	SessionSecret string `toml:"session_secret" mapstructure:"session_secret" json:"-"`     // 会话 token 签名密钥, 多实例部署时必须一致
+	SessionTTL    int    `toml:"session_ttl" mapstructure:"session_ttl" json:"session_ttl"` // 会话有效期（秒）
+}
+
+// AdminConfig 管理后台配置
+type AdminConfig struct {
+	SuperAdmins []string `toml:"super_admins" mapstructure:"super_admins" json:"super_admins"` // 超级管理员地址白名单, 用于初始化角色表
+}
+
+// PushConfig 订单簿与活动实时推送配置, 为 0 时使用默认值
+type PushConfig struct {
+	PollInterval   int `toml:"poll_interval" mapstructure:"poll_interval" json:"poll_interval"`       // 轮询 Redis 事件流的间隔（毫秒）, 默认 500
+	Heartbeat      int `toml:"heartbeat" mapstructure:"heartbeat" json:"heartbeat"`                   // 心跳间隔（秒）, 默认 15
+	MaxSubscribers int `toml:"max_subscribers" mapstructure:"max_subscribers" json:"max_subscribers"` // 每条链的最大订阅数, 默认 10000
+}
+
+// WebhookConfig 市场事件 webhook 推送配置, 为 0 时使用默认值
+type WebhookConfig struct {
+	Enabled      bool `toml:"enabled" mapstructure:"enabled" json:"enabled"`                   // 是否启动推送, 多实例部署时可只在部分实例开启
+	PollInterval int  `toml:"poll_interval" mapstructure:"poll_interval" json:"poll_interval"` // 读取事件与扫描待投递记录的间隔（毫秒）, 默认 1000
+	Workers      int  `toml:"workers" mapstructure:"workers" json:"workers"`                   // 并发投递数, 默认 8
+	MaxAttempts  int  `toml:"max_attempts" mapstructure:"max_attempts" json:"max_attempts"`    // 最大投递次数, 超过后进入死信, 默认 15
+	Timeout      int  `toml:"timeout" mapstructure:"timeout" json:"timeout"`                   // 单次请求超时（秒）, 默认 10
+}
+
+// AlertConfig 价格提醒与站内信配置, 为 0 时使用默认值
+type AlertConfig struct {
+	Enabled        bool        `toml:"enabled" mapstructure:"enabled" json:"enabled"`                         // 是否启动规则评估, 多实例部署时可只在部分实例开启
+	PollInterval   int         `toml:"poll_interval" mapstructure:"poll_interval" json:"poll_interval"`       // 读取市场事件的间隔（毫秒）, 默认 1000
+	WebhookTimeout int         `toml:"webhook_timeout" mapstructure:"webhook_timeout" json:"webhook_timeout"` // webhook 渠道请求超时（秒）, 默认 10
+	SMTP           *SMTPConfig `toml:"smtp" mapstructure:"smtp" json:"smtp"`                                  // 邮件渠道, 未配置时不支持邮件通知
+}
+
+// SMTPConfig 邮件发送配置
+type SMTPConfig struct {
+	Host     string `toml:"host" mapstructure:"host" json:"host"`
+	Port     int    `toml:"port" mapstructure:"port" json:"port"`
+	Username string `toml:"username" mapstructure:"username" json:"username"`
+	Password string `toml:"password" mapstructure:"password" json:"-"`
+	From     string `toml:"from" mapstructure:"from" json:"from"` // 发件人地址
 }
 
 // UnmarshalConfig unmarshal conifg file
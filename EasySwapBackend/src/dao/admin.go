@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
 	"github.com/pkg/errors"
 
 	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
@@ -287,3 +288,15 @@ func (d *Dao) AdminCheckContractExists(ctx context.Context, chainID int64, addre
 
 	return count > 0, nil
 }
+
+// AdminQueryTokenIDs 查询合约下已索引的全部 token ID
+func (d *Dao) AdminQueryTokenIDs(ctx context.Context, chain string, address string) ([]string, error) {
+	var tokenIDs []string
+	if err := d.DB.WithContext(ctx).Table(multi.ItemTableName(chain)).
+		Where("collection_address = ?", strings.ToLower(address)).
+		Pluck("token_id", &tokenIDs).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to query token ids")
+	}
+
+	return tokenIDs, nil
+}
@@ -0,0 +1,105 @@
+package dao
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/pkg/errors"
+	"gorm.io/gorm/clause"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+// QueryAdminUser 查询管理员, 不存在时返回 nil
+func (d *Dao) QueryAdminUser(ctx context.Context, address string) (*base.AdminUser, error) {
+	var users []base.AdminUser
+	if err := d.DB.WithContext(ctx).Table(base.AdminUserTableName()).
+		Where("address = ?", strings.ToLower(address)).
+		Limit(1).
+		Find(&users).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query admin user")
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+
+	return &users[0], nil
+}
+
+// QueryAdminUsers 查询全部管理员
+func (d *Dao) QueryAdminUsers(ctx context.Context) ([]base.AdminUser, error) {
+	var users []base.AdminUser
+	if err := d.DB.WithContext(ctx).Table(base.AdminUserTableName()).
+		Order("id ASC").
+		Find(&users).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query admin users")
+	}
+
+	return users, nil
+}
+
+// UpsertAdminUser 添加或更新管理员角色
+func (d *Dao) UpsertAdminUser(ctx context.Context, user *base.AdminUser) error {
+	user.Address = strings.ToLower(user.Address)
+	if err := d.DB.WithContext(ctx).Table(base.AdminUserTableName()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "enabled", "update_time"}),
+	}).Create(user).Error; err != nil {
+		return errors.Wrap(err, "failed on upsert admin user")
+	}
+
+	return nil
+}
+
+// DeleteAdminUser 删除管理员
+func (d *Dao) DeleteAdminUser(ctx context.Context, address string) error {
+	result := d.DB.WithContext(ctx).Table(base.AdminUserTableName()).
+		Where("address = ?", strings.ToLower(address)).
+		Delete(&base.AdminUser{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed on delete admin user")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("admin user not found")
+	}
+
+	return nil
+}
+
+// CreateAdminAuditLog 写入审计日志
+func (d *Dao) CreateAdminAuditLog(ctx context.Context, log *base.AdminAuditLog) error {
+	if err := d.DB.WithContext(ctx).Table(base.AdminAuditLogTableName()).Create(log).Error; err != nil {
+		return errors.Wrap(err, "failed on create admin audit log")
+	}
+
+	return nil
+}
+
+// QueryAdminAuditLogs 分页查询审计日志
+func (d *Dao) QueryAdminAuditLogs(ctx context.Context, req types.AdminGetAuditLogsReq) ([]base.AdminAuditLog, int64, error) {
+	var logs []base.AdminAuditLog
+	var total int64
+
+	query := d.DB.WithContext(ctx).Table(base.AdminAuditLogTableName())
+	if req.Operator != "" {
+		query = query.Where("operator = ?", strings.ToLower(req.Operator))
+	}
+	if req.Action != "" {
+		query = query.Where("action = ?", req.Action)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on count admin audit logs")
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("id DESC").
+		Limit(req.PageSize).
+		Offset(offset).
+		Find(&logs).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on query admin audit logs")
+	}
+
+	return logs, total, nil
+}
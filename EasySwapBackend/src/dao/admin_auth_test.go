@@ -0,0 +1,50 @@
+package dao
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// newTestDao 使用 sqlite 创建 dao, tables 为表名到模型的映射
+func newTestDao(t *testing.T, tables map[string]interface{}) *Dao {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "dao.db")), &gorm.Config{})
+	assert.NoError(t, err)
+	for name, model := range tables {
+		assert.NoError(t, db.Table(name).AutoMigrate(model))
+	}
+
+	return New(context.Background(), db, nil)
+}
+
+func TestUpsertAdminUserDisable(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDao(t, map[string]interface{}{base.AdminUserTableName(): &base.AdminUser{}})
+	address := "0xF39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+
+	assert.NoError(t, d.UpsertAdminUser(ctx, &base.AdminUser{Address: address, Role: base.AdminRoleOperator, Enabled: true}))
+	user, err := d.QueryAdminUser(ctx, address)
+	assert.NoError(t, err)
+	assert.True(t, user.Enabled)
+
+	// 更新时禁用
+	assert.NoError(t, d.UpsertAdminUser(ctx, &base.AdminUser{Address: address, Role: base.AdminRoleViewer, Enabled: false}))
+	user, err = d.QueryAdminUser(ctx, address)
+	assert.NoError(t, err)
+	assert.False(t, user.Enabled)
+	assert.Equal(t, base.AdminRoleViewer, user.Role)
+	assert.Equal(t, strings.ToLower(address), user.Address)
+
+	// 新建时即禁用
+	other := "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+	assert.NoError(t, d.UpsertAdminUser(ctx, &base.AdminUser{Address: other, Role: base.AdminRoleViewer, Enabled: false}))
+	user, err = d.QueryAdminUser(ctx, other)
+	assert.NoError(t, err)
+	assert.False(t, user.Enabled)
+}
@@ -0,0 +1,219 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/pkg/errors"
+	"gorm.io/gorm/clause"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+// QueryWatchlist 查询用户的关注列表
+func (d *Dao) QueryWatchlist(ctx context.Context, userAddr string) ([]base.Watchlist, error) {
+	var watchlist []base.Watchlist
+	if err := d.DB.WithContext(ctx).Table(base.WatchlistTableName()).
+		Where("user_address = ?", userAddr).
+		Order("id DESC").
+		Find(&watchlist).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query watchlist")
+	}
+
+	return watchlist, nil
+}
+
+// CountWatchlist 统计用户的关注数量
+func (d *Dao) CountWatchlist(ctx context.Context, userAddr string) (int64, error) {
+	var count int64
+	if err := d.DB.WithContext(ctx).Table(base.WatchlistTableName()).
+		Where("user_address = ?", userAddr).
+		Count(&count).Error; err != nil {
+		return 0, errors.Wrap(err, "failed on count watchlist")
+	}
+
+	return count, nil
+}
+
+// CreateWatchlist 添加关注, 已关注时不重复添加
+func (d *Dao) CreateWatchlist(ctx context.Context, watchlist *base.Watchlist) error {
+	if err := d.DB.WithContext(ctx).Table(base.WatchlistTableName()).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(watchlist).Error; err != nil {
+		return errors.Wrap(err, "failed on create watchlist")
+	}
+
+	return nil
+}
+
+// DeleteWatchlist 取消关注
+func (d *Dao) DeleteWatchlist(ctx context.Context, userAddr string, id int64) error {
+	result := d.DB.WithContext(ctx).Table(base.WatchlistTableName()).
+		Where("id = ? and user_address = ?", id, userAddr).
+		Delete(&base.Watchlist{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed on delete watchlist")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("watchlist not found")
+	}
+
+	return nil
+}
+
+// QueryAlertRules 查询用户的提醒规则
+func (d *Dao) QueryAlertRules(ctx context.Context, userAddr string) ([]base.AlertRule, error) {
+	var rules []base.AlertRule
+	if err := d.DB.WithContext(ctx).Table(base.AlertRuleTableName()).
+		Where("user_address = ?", userAddr).
+		Order("id DESC").
+		Find(&rules).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query alert rules")
+	}
+
+	return rules, nil
+}
+
+// QueryAlertRule 查询用户的单个提醒规则, 不存在时返回 nil
+func (d *Dao) QueryAlertRule(ctx context.Context, userAddr string, id int64) (*base.AlertRule, error) {
+	var rules []base.AlertRule
+	if err := d.DB.WithContext(ctx).Table(base.AlertRuleTableName()).
+		Where("id = ? and user_address = ?", id, userAddr).
+		Limit(1).
+		Find(&rules).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query alert rule")
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	return &rules[0], nil
+}
+
+// CountAlertRules 统计用户的提醒规则数量
+func (d *Dao) CountAlertRules(ctx context.Context, userAddr string) (int64, error) {
+	var count int64
+	if err := d.DB.WithContext(ctx).Table(base.AlertRuleTableName()).
+		Where("user_address = ?", userAddr).
+		Count(&count).Error; err != nil {
+		return 0, errors.Wrap(err, "failed on count alert rules")
+	}
+
+	return count, nil
+}
+
+// CreateAlertRule 创建提醒规则
+func (d *Dao) CreateAlertRule(ctx context.Context, rule *base.AlertRule) error {
+	if err := d.DB.WithContext(ctx).Table(base.AlertRuleTableName()).Create(rule).Error; err != nil {
+		return errors.Wrap(err, "failed on create alert rule")
+	}
+
+	return nil
+}
+
+// UpdateAlertRule 更新用户的提醒规则
+func (d *Dao) UpdateAlertRule(ctx context.Context, userAddr string, id int64, updates map[string]interface{}) error {
+	if err := d.DB.WithContext(ctx).Table(base.AlertRuleTableName()).
+		Where("id = ? and user_address = ?", id, userAddr).
+		Updates(updates).Error; err != nil {
+		return errors.Wrap(err, "failed on update alert rule")
+	}
+
+	return nil
+}
+
+// DeleteAlertRule 删除用户的提醒规则, 已产生的站内信保留
+func (d *Dao) DeleteAlertRule(ctx context.Context, userAddr string, id int64) error {
+	result := d.DB.WithContext(ctx).Table(base.AlertRuleTableName()).
+		Where("id = ? and user_address = ?", id, userAddr).
+		Delete(&base.AlertRule{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed on delete alert rule")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("alert rule not found")
+	}
+
+	return nil
+}
+
+// QueryNotifications 分页查询用户的站内信, 同时返回未读总数
+func (d *Dao) QueryNotifications(ctx context.Context, userAddr string, req types.NotificationsReq) ([]base.Notification, int64, int64, error) {
+	var notifications []base.Notification
+	var total, unread int64
+
+	if err := d.DB.WithContext(ctx).Table(base.NotificationTableName()).
+		Where("user_address = ? and is_read = ?", userAddr, false).
+		Count(&unread).Error; err != nil {
+		return nil, 0, 0, errors.Wrap(err, "failed on count unread notifications")
+	}
+
+	query := d.DB.WithContext(ctx).Table(base.NotificationTableName()).
+		Where("user_address = ?", userAddr)
+	if req.Unread {
+		query = query.Where("is_read = ?", false)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, 0, errors.Wrap(err, "failed on count notifications")
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("id DESC").
+		Limit(req.PageSize).
+		Offset(offset).
+		Find(&notifications).Error; err != nil {
+		return nil, 0, 0, errors.Wrap(err, "failed on query notifications")
+	}
+
+	return notifications, total, unread, nil
+}
+
+// MarkNotificationsRead 将用户的站内信标记为已读, ids 为空时标记全部
+func (d *Dao) MarkNotificationsRead(ctx context.Context, userAddr string, ids []int64) (int64, error) {
+	query := d.DB.WithContext(ctx).Table(base.NotificationTableName()).
+		Where("user_address = ? and is_read = ?", userAddr, false)
+	if len(ids) > 0 {
+		query = query.Where("id in ?", ids)
+	}
+
+	result := query.Updates(map[string]interface{}{
+		"is_read":   true,
+		"read_time": time.Now().UnixMilli(),
+	})
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, "failed on mark notifications read")
+	}
+
+	return result.RowsAffected, nil
+}
+
+// QueryNotifySetting 查询用户的站外通知配置, 不存在时返回 nil
+func (d *Dao) QueryNotifySetting(ctx context.Context, userAddr string) (*base.NotifySetting, error) {
+	var settings []base.NotifySetting
+	if err := d.DB.WithContext(ctx).Table(base.NotifySettingTableName()).
+		Where("user_address = ?", userAddr).
+		Limit(1).
+		Find(&settings).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query notify setting")
+	}
+	if len(settings) == 0 {
+		return nil, nil
+	}
+
+	return &settings[0], nil
+}
+
+// SaveNotifySetting 保存用户的站外通知配置
+func (d *Dao) SaveNotifySetting(ctx context.Context, setting *base.NotifySetting) error {
+	if err := d.DB.WithContext(ctx).Table(base.NotifySettingTableName()).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_address"}},
+			DoUpdates: clause.AssignmentColumns([]string{"email", "webhook_url", "webhook_secret", "update_time"}),
+		}).
+		Create(setting).Error; err != nil {
+		return errors.Wrap(err, "failed on save notify setting")
+	}
+
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/ProjectsTask/EasySwapBase/ordermanager"
 	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ProjectsTask/EasySwapBase/tradestats"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 
@@ -120,15 +121,34 @@ func (d *Dao) QueryMultiChainCollectionsInfo(ctx context.Context, collectionAddr
 	return collections, nil
 }
 
+// userBalanceOwners 持有数量表中的用户地址(持有数量表中地址为小写)
+func userBalanceOwners(userAddrs []string) []string {
+	owners := make([]string, 0, len(userAddrs))
+	for _, addr := range userAddrs {
+		owners = append(owners, strings.ToLower(addr))
+	}
+	return owners
+}
+
+// userHeldItemCond 用户持有 item 的条件: ERC721 按 owner 判断, ERC1155 按持有数量判断, 返回条件与绑定参数
+func userHeldItemCond(chainName string, userAddrs []string) (string, []interface{}) {
+	return fmt.Sprintf("(gi.owner in ? or exists (select 1 from %s gb "+
+			"where gb.collection_address = gi.collection_address and gb.token_id = gi.token_id and gb.owner in ? and gb.balance > 0))",
+			multi.ItemBalanceTableName(chainName)),
+		[]interface{}{userAddrs, userBalanceOwners(userAddrs)}
+}
+
+// userHeldQuantity 用户持有 item 的数量, ERC721 无持有数量记录时为 1, 返回表达式与绑定参数
+func userHeldQuantity(chainName string, userAddrs []string) (string, []interface{}) {
+	return fmt.Sprintf("coalesce((select sum(gb.balance) from %s gb "+
+			"where gb.collection_address = gi.collection_address and gb.token_id = gi.token_id and gb.owner in ?), 1)",
+			multi.ItemBalanceTableName(chainName)),
+		[]interface{}{userBalanceOwners(userAddrs)}
+}
+
 func (d *Dao) QueryMultiChainUserCollectionInfos(ctx context.Context, chainID []int, chainNames []string, userAddrs []string) ([]types.UserCollections, error) {
 	var userCollections []types.UserCollections
-	var userAddrsParam string
-	for i, addr := range userAddrs {
-		userAddrsParam += fmt.Sprintf(`'%s'`, addr)
-		if i < len(userAddrs)-1 {
-			userAddrsParam += ","
-		}
-	}
+	var args []interface{} // 各链子查询的绑定参数, 按占位符顺序拼接
 
 	sqlHead := "SELECT * FROM ("
 	sqlTail := ") as combined ORDER BY combined.floor_price * CAST(combined.item_count AS DECIMAL) DESC"
@@ -142,7 +162,9 @@ func (d *Dao) QueryMultiChainUserCollectionInfos(ctx context.Context, chainID []
 		sqlMid += fmt.Sprintf("from %s as gc ", multi.CollectionTableName(chainName))
 		sqlMid += fmt.Sprintf("join %s as gi ", multi.ItemTableName(chainName))
 		sqlMid += "on gc.address = gi.collection_address "
-		sqlMid += fmt.Sprintf("where gi.owner in (%s) ", userAddrsParam)
+		cond, condArgs := userHeldItemCond(chainName, userAddrs)
+		sqlMid += fmt.Sprintf("where %s ", cond)
+		args = append(args, condArgs...)
 		sqlMid += "group by gc.address"
 		sqlMid += ")"
 
@@ -159,7 +181,7 @@ func (d *Dao) QueryMultiChainUserCollectionInfos(ctx context.Context, chainID []
 	}
 	sql += sqlTail
 	// execute sql
-	if err := d.DB.WithContext(ctx).Raw(sql).Scan(&userCollections).Error; err != nil {
+	if err := d.DB.WithContext(ctx).Raw(sql, args...).Scan(&userCollections).Error; err != nil {
 		return nil, errors.Wrap(err, "failed on get user multi chain collection infos")
 	}
 
@@ -169,13 +191,7 @@ func (d *Dao) QueryMultiChainUserCollectionInfos(ctx context.Context, chainID []
 func (d *Dao) QueryMultiChainUserItemInfos(ctx context.Context, chain []string, userAddrs []string, contractAddrs []string, page, pageSize int) ([]types.PortfolioItemInfo, int64, error) {
 	var count int64
 	var items []types.PortfolioItemInfo
-	var userAddrsParam string
-	for i, addr := range userAddrs {
-		userAddrsParam += fmt.Sprintf(`'%s'`, addr)
-		if i < len(userAddrs)-1 {
-			userAddrsParam += ","
-		}
-	}
+	var args []interface{} // 各链子查询的绑定参数, 按占位符顺序拼接
 
 	sqlCntHead := "SELECT COUNT(*) FROM ("
 	sqlHead := "SELECT * FROM ("
@@ -185,29 +201,29 @@ func (d *Dao) QueryMultiChainUserItemInfos(ctx context.Context, chain []string,
 	for _, chainName := range chain {
 		//splice sqlMid
 		sqlMid := "("
-		sqlMid += "select gi.chain_id as chain_id, gi.collection_address as collection_address, gi.token_id as token_id, gi.name as name, gi.owner as owner,0 as rarity_rank, sub.last_event_time as owned_time "
+		sqlMid += "select gi.chain_id as chain_id, gi.collection_address as collection_address, gi.token_id as token_id, gi.name as name, gi.owner as owner,0 as rarity_rank, sub.last_event_time as owned_time, "
+		quantity, quantityArgs := userHeldQuantity(chainName, userAddrs)
+		sqlMid += fmt.Sprintf("%s as quantity ", quantity)
+		args = append(args, quantityArgs...)
 		sqlMid += fmt.Sprintf("from %s gi ", multi.ItemTableName(chainName))
 		sqlMid += "left join "
 		sqlMid += "(select sgi.collection_address, sgi.token_id, max(sga.event_time) as last_event_time "
 		sqlMid += fmt.Sprintf("from %s sgi join %s sga ", multi.ItemTableName(chainName), multi.ActivityTableName(chainName))
 		sqlMid += "on sgi.collection_address = sga.collection_address and sgi.token_id = sga.token_id "
-		sqlMid += fmt.Sprintf("where sgi.owner in (%s) and sga.activity_type = %d ", userAddrsParam, multi.Sale)
+		sqlMid += fmt.Sprintf("where sgi.owner in ? and sga.activity_type = %d ", multi.Sale)
+		args = append(args, userAddrs)
 		if len(contractAddrs) > 0 {
-			sqlMid += fmt.Sprintf("and sgi.collection_address in ('%s'", contractAddrs[0])
-			for i := 1; i < len(contractAddrs); i++ {
-				sqlMid += fmt.Sprintf(",'%s'", contractAddrs[i])
-			}
-			sqlMid += ") "
+			sqlMid += "and sgi.collection_address in ? "
+			args = append(args, contractAddrs)
 		}
 		sqlMid += "group by sgi.collection_address, sgi.token_id) sub "
 		sqlMid += "on gi.collection_address = sub.collection_address and gi.token_id = sub.token_id "
-		sqlMid += fmt.Sprintf("where gi.owner in (%s) ", userAddrsParam)
+		cond, condArgs := userHeldItemCond(chainName, userAddrs)
+		sqlMid += fmt.Sprintf("where %s ", cond)
+		args = append(args, condArgs...)
 		if len(contractAddrs) > 0 {
-			sqlMid += fmt.Sprintf("and gi.collection_address in ('%s'", contractAddrs[0])
-			for i := 1; i < len(contractAddrs); i++ {
-				sqlMid += fmt.Sprintf(",'%s'", contractAddrs[i])
-			}
-			sqlMid += ")"
+			sqlMid += "and gi.collection_address in ?"
+			args = append(args, contractAddrs)
 		}
 		sqlMid += ")"
 		//store into slice
@@ -226,10 +242,10 @@ func (d *Dao) QueryMultiChainUserItemInfos(ctx context.Context, chain []string,
 	}
 	sql += sqlTail
 	sqlCnt += ") as combined"
-	if err := d.DB.WithContext(ctx).Raw(sqlCnt).Scan(&count).Error; err != nil {
+	if err := d.DB.WithContext(ctx).Raw(sqlCnt, args...).Scan(&count).Error; err != nil {
 		return nil, 0, errors.Wrap(err, "failed on count user multi chain items")
 	}
-	if err := d.DB.WithContext(ctx).Raw(sql).Scan(&items).Error; err != nil {
+	if err := d.DB.WithContext(ctx).Raw(sql, args...).Scan(&items).Error; err != nil {
 		return nil, 0, errors.Wrap(err, "failed on get user multi chain items")
 	}
 
@@ -239,13 +255,7 @@ func (d *Dao) QueryMultiChainUserItemInfos(ctx context.Context, chain []string,
 func (d *Dao) QueryMultiChainUserListingItemInfos(ctx context.Context, chain []string, userAddrs []string, contractAddrs []string, page, pageSize int) ([]types.PortfolioItemInfo, int64, error) {
 	var count int64
 	var items []types.PortfolioItemInfo
-	var userAddrsParam string
-	for i, addr := range userAddrs {
-		userAddrsParam += fmt.Sprintf(`'%s'`, addr)
-		if i < len(userAddrs)-1 {
-			userAddrsParam += ","
-		}
-	}
+	var args []interface{} // 各链子查询的绑定参数, 按占位符顺序拼接
 
 	sqlCntHead := "SELECT COUNT(*) FROM ("
 	sqlHead := "SELECT * FROM ("
@@ -255,29 +265,29 @@ func (d *Dao) QueryMultiChainUserListingItemInfos(ctx context.Context, chain []s
 	for _, chainName := range chain {
 		//splice sqlMid
 		sqlMid := "("
-		sqlMid += "select gi.chain_id as chain_id, gi.collection_address as collection_address, gi.token_id as token_id, gi.name as name, gi.owner as owner,0 as rarity_rank, sub.last_event_time as owned_time "
+		sqlMid += "select gi.chain_id as chain_id, gi.collection_address as collection_address, gi.token_id as token_id, gi.name as name, gi.owner as owner,0 as rarity_rank, sub.last_event_time as owned_time, "
+		quantity, quantityArgs := userHeldQuantity(chainName, userAddrs)
+		sqlMid += fmt.Sprintf("%s as quantity ", quantity)
+		args = append(args, quantityArgs...)
 		sqlMid += fmt.Sprintf("from %s gi ", multi.ItemTableName(chainName))
 		sqlMid += "left join "
 		sqlMid += "(select sgi.collection_address, sgi.token_id, max(sga.event_time) as last_event_time "
 		sqlMid += fmt.Sprintf("from %s sgi join %s sga ", multi.ItemTableName(chainName), multi.ActivityTableName(chainName))
 		sqlMid += "on sgi.collection_address = sga.collection_address and sgi.token_id = sga.token_id "
-		sqlMid += fmt.Sprintf("where sgi.owner in (%s) and sga.activity_type = %d ", userAddrsParam, multi.Sale)
+		sqlMid += fmt.Sprintf("where sgi.owner in ? and sga.activity_type = %d ", multi.Sale)
+		args = append(args, userAddrs)
 		if len(contractAddrs) > 0 {
-			sqlMid += fmt.Sprintf("and sgi.collection_address in ('%s'", contractAddrs[0])
-			for i := 1; i < len(contractAddrs); i++ {
-				sqlMid += fmt.Sprintf(",'%s'", contractAddrs[i])
-			}
-			sqlMid += ") "
+			sqlMid += "and sgi.collection_address in ? "
+			args = append(args, contractAddrs)
 		}
 		sqlMid += "group by sgi.collection_address, sgi.token_id) sub "
 		sqlMid += "on gi.collection_address = sub.collection_address and gi.token_id = sub.token_id "
-		sqlMid += fmt.Sprintf("where gi.owner in (%s) ", userAddrsParam)
+		cond, condArgs := userHeldItemCond(chainName, userAddrs)
+		sqlMid += fmt.Sprintf("where %s ", cond)
+		args = append(args, condArgs...)
 		if len(contractAddrs) > 0 {
-			sqlMid += fmt.Sprintf("and gi.collection_address in ('%s'", contractAddrs[0])
-			for i := 1; i < len(contractAddrs); i++ {
-				sqlMid += fmt.Sprintf(",'%s'", contractAddrs[i])
-			}
-			sqlMid += ")"
+			sqlMid += "and gi.collection_address in ?"
+			args = append(args, contractAddrs)
 		}
 		sqlMid += ")"
 		//store into slice
@@ -296,10 +306,10 @@ func (d *Dao) QueryMultiChainUserListingItemInfos(ctx context.Context, chain []s
 	}
 	sql += sqlTail
 	sqlCnt += ") as combined"
-	if err := d.DB.WithContext(ctx).Raw(sqlCnt).Scan(&count).Error; err != nil {
+	if err := d.DB.WithContext(ctx).Raw(sqlCnt, args...).Scan(&count).Error; err != nil {
 		return nil, 0, errors.Wrap(err, "failed on count user multi chain items")
 	}
-	if err := d.DB.WithContext(ctx).Raw(sql).Scan(&items).Error; err != nil {
+	if err := d.DB.WithContext(ctx).Raw(sql, args...).Scan(&items).Error; err != nil {
 		return nil, 0, errors.Wrap(err, "failed on get user multi chain items")
 	}
 
@@ -357,13 +367,12 @@ WHERE (co.collection_address= ? and co.order_type = ? and
 	return order.Price, nil
 }
 
+// GetCollectionTradeInfoKey 合集累计成交额缓存键, 由 EasySwapSync 的成交统计任务写入
 func GetCollectionTradeInfoKey(project, chain string, collectionAddr string) string {
-	return fmt.Sprintf("cache:%s:%s:collection:%s:trade", strings.ToLower(project), strings.ToLower(chain), strings.ToLower(collectionAddr))
+	return tradestats.CollectionVolumeKey(project, chain, collectionAddr)
 }
 
-type CollectionVolume struct {
-	Volume decimal.Decimal `json:"volume"`
-}
+type CollectionVolume = tradestats.CollectionVolume
 
 func (d *Dao) QueryCollectionAllVolume(project, chain string, collectionAddr string) (*CollectionVolume, error) {
 	key := GetCollectionTradeInfoKey(project, chain, collectionAddr)
@@ -371,6 +380,10 @@ func (d *Dao) QueryCollectionAllVolume(project, chain string, collectionAddr str
 	if err != nil {
 		return nil, errors.Wrap(err, "failed on get collection all volume")
 	}
+	// 尚无成交的合集没有缓存
+	if rawInfo == "" {
+		return &CollectionVolume{}, nil
+	}
 
 	var vol CollectionVolume
 	if err := json.Unmarshal([]byte(rawInfo), &vol); err != nil {
@@ -0,0 +1,53 @@
+package dao
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryMultiChainUserInfosBindsAddresses(t *testing.T) {
+	ctx := context.Background()
+	chain := "sepolia"
+	user := "0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266"
+	holder := "0x70997970c51812dc3a0b6fa4d6b35d9d3a0eeda5"
+	collection := "0x1111111111111111111111111111111111111111"
+	d := newTestDao(t, map[string]interface{}{
+		multi.CollectionTableName(chain):  &multi.Collection{},
+		multi.ItemTableName(chain):        &multi.Item{},
+		multi.ItemBalanceTableName(chain): &multi.ItemBalance{},
+		multi.ActivityTableName(chain):    &multi.Activity{},
+	})
+	assert.NoError(t, d.DB.Table(multi.CollectionTableName(chain)).Create(&multi.Collection{Name: "test", Address: collection}).Error)
+	assert.NoError(t, d.DB.Table(multi.ItemTableName(chain)).Create(&multi.Item{CollectionAddress: collection, TokenId: "1", Owner: user}).Error)
+	assert.NoError(t, d.DB.Table(multi.ItemTableName(chain)).Create(&multi.Item{CollectionAddress: collection, TokenId: "2", Owner: collection}).Error)
+	assert.NoError(t, d.DB.Table(multi.ItemBalanceTableName(chain)).Create(&multi.ItemBalance{CollectionAddress: collection, TokenId: "2", Owner: holder, Balance: 3}).Error)
+
+	// ERC721 按 owner 匹配, ERC1155 按持有数量匹配(地址不区分大小写)
+	collections, err := d.QueryMultiChainUserCollectionInfos(ctx, nil, []string{chain}, []string{user})
+	assert.NoError(t, err)
+	assert.Len(t, collections, 1)
+	assert.Equal(t, int64(1), collections[0].ItemCount)
+	collections, err = d.QueryMultiChainUserCollectionInfos(ctx, nil, []string{chain}, []string{"0x" + strings.ToUpper(holder[2:])})
+	assert.NoError(t, err)
+	assert.Len(t, collections, 1)
+
+	// 地址作为参数绑定, 注入的条件不生效
+	collections, err = d.QueryMultiChainUserCollectionInfos(ctx, nil, []string{chain}, []string{"x') or 1=1 --"})
+	assert.NoError(t, err)
+	assert.Empty(t, collections)
+
+	// 持有数量、合约过滤与注入地址的绑定参数顺序
+	items, count, err := d.QueryMultiChainUserItemInfos(ctx, []string{chain}, []string{holder}, []string{collection}, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Len(t, items, 1)
+	assert.Equal(t, "2", items[0].TokenID)
+	assert.Equal(t, int64(3), items[0].Quantity)
+	_, count, err = d.QueryMultiChainUserItemInfos(ctx, []string{chain}, []string{holder}, []string{"x') or 1=1 --"}, 1, 10)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,107 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ImportedItem 导入任务解析出的单个 NFT 信息
+type ImportedItem struct {
+	ChainID           int64
+	CollectionAddress string
+	TokenID           string
+	Owner             string
+	Name              string
+	MetaDataUri       string
+	ImageUri          string
+	MetadataFailed    bool // 元数据获取失败, item_external 标记为待刷新
+	Traits            []multi.ItemTrait
+}
+
+// UpsertImportedItem 写入导入的 NFT: item / item_external / item_trait 在同一事务内完成
+// 已存在的 item 只更新 owner 与 name, 不影响挂单等字段
+func (d *Dao) UpsertImportedItem(ctx context.Context, chain string, item *ImportedItem) error {
+	now := time.Now().UnixMilli()
+
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		itemUpdates := []string{"owner", "update_time"}
+		if item.Name != "" {
+			itemUpdates = append(itemUpdates, "name")
+		}
+		newItem := multi.Item{
+			ChainId:           int(item.ChainID),
+			CollectionAddress: item.CollectionAddress,
+			TokenId:           item.TokenID,
+			Name:              item.Name,
+			Owner:             item.Owner,
+			Supply:            1,
+			CreateTime:        now,
+			UpdateTime:        now,
+		}
+		if err := tx.Table(multi.ItemTableName(chain)).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "collection_address"}, {Name: "token_id"}},
+			DoUpdates: clause.AssignmentColumns(itemUpdates),
+		}).Create(&newItem).Error; err != nil {
+			return errors.Wrap(err, "failed on upsert item")
+		}
+
+		uploadStatus := multi.OK
+		if item.MetadataFailed {
+			uploadStatus = multi.FetchMetadataFailed
+		}
+		externalUpdates := []string{"upload_status", "update_time"}
+		if !item.MetadataFailed {
+			externalUpdates = append(externalUpdates, "meta_data_uri", "image_uri")
+		}
+		itemExternal := map[string]interface{}{
+			"collection_address":  item.CollectionAddress,
+			"token_id":            item.TokenID,
+			"meta_data_uri":       item.MetaDataUri,
+			"image_uri":           item.ImageUri,
+			"is_uploaded_oss":     false,
+			"upload_status":       uploadStatus,
+			"is_video_uploaded":   false,
+			"video_upload_status": 0,
+			"video_type":          "0",
+			"create_time":         now,
+			"update_time":         now,
+		}
+		if err := tx.Table(multi.ItemExternalTableName(chain)).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "collection_address"}, {Name: "token_id"}},
+			DoUpdates: clause.AssignmentColumns(externalUpdates),
+		}).Create(&itemExternal).Error; err != nil {
+			return errors.Wrap(err, "failed on upsert item external")
+		}
+
+		// 元数据获取失败时保留原有属性
+		if item.MetadataFailed {
+			return nil
+		}
+
+		if err := tx.Table(multi.ItemTraitTableName(chain)).
+			Where("collection_address = ? and token_id = ?", item.CollectionAddress, item.TokenID).
+			Delete(&multi.ItemTrait{}).Error; err != nil {
+			return errors.Wrap(err, "failed on delete item traits")
+		}
+
+		if len(item.Traits) == 0 {
+			return nil
+		}
+		for i := range item.Traits {
+			item.Traits[i].CollectionAddress = item.CollectionAddress
+			item.Traits[i].TokenId = item.TokenID
+			item.Traits[i].CreateTime = now
+			item.Traits[i].UpdateTime = now
+		}
+		if err := tx.Table(multi.ItemTraitTableName(chain)).Create(&item.Traits).Error; err != nil {
+			return errors.Wrap(err, "failed on create item traits")
+		}
+
+		return nil
+	})
+}
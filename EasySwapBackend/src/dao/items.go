@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ProjectsTask/EasySwapBase/nftapproval"
 	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
@@ -754,6 +755,11 @@ func (d *Dao) UpdateItemOwner(ctx context.Context, chain string, collectionAddr,
 	return nil
 }
 
+// QueryItemFillability 根据授权状态表查询 NFT 最低价挂单能否由市场合约成交
+func (d *Dao) QueryItemFillability(ctx context.Context, chain string, collectionAddr, tokenID, vault string) (*types.ItemFillability, error) {
+	return nftapproval.CheckListing(d.DB.WithContext(ctx), chain, collectionAddr, tokenID, vault, time.Now().Unix())
+}
+
 func (d *Dao) QueryItemBids(ctx context.Context, chain string, collectionAddr, tokenID string, page, pageSize int) ([]types.ItemBid, int64, error) {
 	db := d.DB.WithContext(ctx).Table(fmt.Sprintf("%s", multi.OrderTableName(chain))).
 		Select("marketplace_id, collection_address, token_id, order_id, salt, event_time, expire_time, price, maker as bidder, order_type, quantity_remaining as bid_unfilled, size as bid_size").
@@ -777,3 +783,43 @@ func (d *Dao) QueryItemBids(ctx context.Context, chain string, collectionAddr, t
 
 	return itemBids, count, nil
 }
+
+// QueryItemHolders 查询 ERC1155 NFT 的持有人列表, 按持有数量降序
+func (d *Dao) QueryItemHolders(ctx context.Context, chain string, collectionAddr, tokenID string, page, pageSize int) ([]types.ItemHolder, int64, error) {
+	db := d.DB.WithContext(ctx).Table(multi.ItemBalanceTableName(chain)).
+		Select("owner, balance").
+		Where("collection_address = ? and token_id = ? and balance > 0", strings.ToLower(collectionAddr), tokenID)
+
+	var count int64
+	countTx := db.Session(&gorm.Session{})
+	if err := countTx.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on count item holders")
+	}
+
+	var holders []types.ItemHolder
+	if count == 0 {
+		return holders, count, nil
+	}
+	if err := db.Order("balance desc, id asc").
+		Offset(int((page - 1) * pageSize)).Limit(int(pageSize)).Scan(&holders).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on get item holders")
+	}
+
+	return holders, count, nil
+}
+
+// QueryItemSupply 查询 ERC1155 NFT 的流通数量与持有人数
+func (d *Dao) QueryItemSupply(ctx context.Context, chain string, collectionAddr, tokenID string) (int64, int64, error) {
+	var stats struct {
+		Supply     int64
+		OwnerCount int64
+	}
+	if err := d.DB.WithContext(ctx).Table(multi.ItemBalanceTableName(chain)).
+		Select("coalesce(sum(balance), 0) as supply, count(*) as owner_count").
+		Where("collection_address = ? and token_id = ? and balance > 0", strings.ToLower(collectionAddr), tokenID).
+		Scan(&stats).Error; err != nil {
+		return 0, 0, errors.Wrap(err, "failed on get item supply")
+	}
+
+	return stats.Supply, stats.OwnerCount, nil
+}
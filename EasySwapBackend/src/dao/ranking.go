@@ -2,43 +2,23 @@ package dao
 
 import (
 	"encoding/json"
-	"fmt"
-	"strings"
 
 	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/tradestats"
 	"github.com/go-redis/redis"
 	"github.com/pkg/errors"
-	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
-type CollectionTrade struct {
-	ContractAddress string          `json:"contract_address"`
-	ItemCount       int64           `json:"item_count"`
-	Volume          decimal.Decimal `json:"volume"`
-	VolumeChange    int             `json:"volume_change"`
-	PreFloorPrice   decimal.Decimal `json:"pre_floor_price"`
-	FloorChange     int             `json:"floor_change"`
-}
+type CollectionTrade = tradestats.RankingItem
 
+// GenRankingKey 排行榜缓存键, 由 EasySwapSync 的成交统计任务写入
 func GenRankingKey(project, chain string, period int) string {
-	return fmt.Sprintf("cache:%s:%s:ranking:volume:%d", strings.ToLower(project), strings.ToLower(chain), period)
-}
-
-type periodEpochMap map[string]int
-
-var periodToEpoch = periodEpochMap{
-	"15m": 3,
-	"1h":  12,
-	"6h":  72,
-	"24h": 288,
-	"1d":  288,
-	"7d":  2016,
-	"30d": 8640,
+	return tradestats.RankingKey(project, chain, period)
 }
 
 func (d *Dao) QueryCollectionTradeInfo(project, chain, period string) ([]CollectionTrade, error) {
-	epoch, ok := periodToEpoch[period]
+	epoch, ok := tradestats.PeriodEpochs[period]
 	if !ok {
 		return nil, errors.Errorf("invalid period: %s", period)
 	}
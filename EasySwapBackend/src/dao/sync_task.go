@@ -0,0 +1,113 @@
+package dao
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/pkg/errors"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+// CreateSyncTask 创建同步任务记录
+func (d *Dao) CreateSyncTask(ctx context.Context, task *base.SyncTask) error {
+	if err := d.DB.WithContext(ctx).Table(base.SyncTaskTableName()).Create(task).Error; err != nil {
+		return errors.Wrap(err, "failed on create sync task")
+	}
+
+	return nil
+}
+
+// UpdateSyncTask 更新同步任务的状态、进度与租约, 只更新本实例持有的任务
+func (d *Dao) UpdateSyncTask(ctx context.Context, task *base.SyncTask) error {
+	updates := map[string]interface{}{
+		"status":            task.Status,
+		"progress":          task.Progress,
+		"total_items":       task.TotalItems,
+		"processed_items":   task.ProcessedItems,
+		"failed_items":      task.FailedItems,
+		"error_msg":         task.ErrorMsg,
+		"started_time":      task.StartedTime,
+		"completed_time":    task.CompletedTime,
+		"lease_expire_time": task.LeaseExpireTime,
+	}
+	if err := d.DB.WithContext(ctx).Table(base.SyncTaskTableName()).
+		Where("task_id = ? and owner = ?", task.TaskId, task.Owner).
+		Updates(updates).Error; err != nil {
+		return errors.Wrap(err, "failed on update sync task")
+	}
+
+	return nil
+}
+
+// QuerySyncTask 根据任务ID查询同步任务
+func (d *Dao) QuerySyncTask(ctx context.Context, taskID string) (*base.SyncTask, error) {
+	var task base.SyncTask
+	if err := d.DB.WithContext(ctx).Table(base.SyncTaskTableName()).
+		Where("task_id = ?", taskID).
+		First(&task).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query sync task")
+	}
+
+	return &task, nil
+}
+
+// QuerySyncTasks 分页查询同步任务历史
+func (d *Dao) QuerySyncTasks(ctx context.Context, req types.AdminGetSyncHistoryReq) ([]base.SyncTask, int64, error) {
+	var tasks []base.SyncTask
+	var total int64
+
+	query := d.DB.WithContext(ctx).Table(base.SyncTaskTableName())
+	if req.ContractAddr != "" {
+		query = query.Where("contract_address = ?", strings.ToLower(req.ContractAddr))
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.TaskType != "" {
+		query = query.Where("task_type = ?", req.TaskType)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on count sync tasks")
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("id DESC").
+		Limit(req.PageSize).
+		Offset(offset).
+		Find(&tasks).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on query sync tasks")
+	}
+
+	return tasks, total, nil
+}
+
+// QueryExpiredSyncTasks 查询未结束(pending/running)且租约已过期的同步任务
+func (d *Dao) QueryExpiredSyncTasks(ctx context.Context, statuses []string, now int64) ([]base.SyncTask, error) {
+	var tasks []base.SyncTask
+	if err := d.DB.WithContext(ctx).Table(base.SyncTaskTableName()).
+		Where("status in (?) and lease_expire_time < ?", statuses, now).
+		Find(&tasks).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query expired sync tasks")
+	}
+
+	return tasks, nil
+}
+
+// MarkSyncTaskInterrupted 将租约已过期的任务标记为中断, 任务已被续约或结束时不更新
+func (d *Dao) MarkSyncTaskInterrupted(ctx context.Context, taskID string, fromStatuses []string, now int64, status, errorMsg string) (bool, error) {
+	result := d.DB.WithContext(ctx).Table(base.SyncTaskTableName()).
+		Where("task_id = ? and status in (?) and lease_expire_time < ?", taskID, fromStatuses, now).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"error_msg":      errorMsg,
+			"completed_time": now,
+		})
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "failed on mark sync task interrupted")
+	}
+
+	return result.RowsAffected > 0, nil
+}
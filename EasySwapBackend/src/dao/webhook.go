@@ -0,0 +1,124 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/pkg/errors"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+// QueryWebhookSubscriptions 查询全部 webhook 订阅
+func (d *Dao) QueryWebhookSubscriptions(ctx context.Context) ([]base.WebhookSubscription, error) {
+	var subs []base.WebhookSubscription
+	if err := d.DB.WithContext(ctx).Table(base.WebhookSubscriptionTableName()).
+		Order("id ASC").
+		Find(&subs).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query webhook subscriptions")
+	}
+
+	return subs, nil
+}
+
+// QueryWebhookSubscription 查询 webhook 订阅, 不存在时返回 nil
+func (d *Dao) QueryWebhookSubscription(ctx context.Context, id int64) (*base.WebhookSubscription, error) {
+	var subs []base.WebhookSubscription
+	if err := d.DB.WithContext(ctx).Table(base.WebhookSubscriptionTableName()).
+		Where("id = ?", id).
+		Limit(1).
+		Find(&subs).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query webhook subscription")
+	}
+	if len(subs) == 0 {
+		return nil, nil
+	}
+
+	return &subs[0], nil
+}
+
+// CreateWebhookSubscription 创建 webhook 订阅
+func (d *Dao) CreateWebhookSubscription(ctx context.Context, sub *base.WebhookSubscription) error {
+	if err := d.DB.WithContext(ctx).Table(base.WebhookSubscriptionTableName()).Create(sub).Error; err != nil {
+		return errors.Wrap(err, "failed on create webhook subscription")
+	}
+
+	return nil
+}
+
+// UpdateWebhookSubscription 更新 webhook 订阅
+func (d *Dao) UpdateWebhookSubscription(ctx context.Context, id int64, updates map[string]interface{}) error {
+	if err := d.DB.WithContext(ctx).Table(base.WebhookSubscriptionTableName()).
+		Where("id = ?", id).
+		Updates(updates).Error; err != nil {
+		return errors.Wrap(err, "failed on update webhook subscription")
+	}
+
+	return nil
+}
+
+// DeleteWebhookSubscription 删除 webhook 订阅, 未投递的记录在投递时进入死信
+func (d *Dao) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	result := d.DB.WithContext(ctx).Table(base.WebhookSubscriptionTableName()).
+		Where("id = ?", id).
+		Delete(&base.WebhookSubscription{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed on delete webhook subscription")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("webhook subscription not found")
+	}
+
+	return nil
+}
+
+// QueryWebhookDeliveries 分页查询投递记录
+func (d *Dao) QueryWebhookDeliveries(ctx context.Context, req types.AdminGetWebhookDeliveriesReq) ([]base.WebhookDelivery, int64, error) {
+	var deliveries []base.WebhookDelivery
+	var total int64
+
+	query := d.DB.WithContext(ctx).Table(base.WebhookDeliveryTableName())
+	if req.SubscriptionID > 0 {
+		query = query.Where("subscription_id = ?", req.SubscriptionID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.EventType != "" {
+		query = query.Where("event_type = ?", req.EventType)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on count webhook deliveries")
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("id DESC").
+		Limit(req.PageSize).
+		Offset(offset).
+		Find(&deliveries).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on query webhook deliveries")
+	}
+
+	return deliveries, total, nil
+}
+
+// RedeliverWebhookDelivery 将死信或已成功的投递记录重新放入投递队列并重置投递次数
+func (d *Dao) RedeliverWebhookDelivery(ctx context.Context, id int64) error {
+	result := d.DB.WithContext(ctx).Table(base.WebhookDeliveryTableName()).
+		Where("id = ? and status <> ?", id, base.WebhookDeliveryStatusPending).
+		Updates(map[string]interface{}{
+			"status":          base.WebhookDeliveryStatusPending,
+			"attempts":        0,
+			"next_retry_time": time.Now().UnixMilli(),
+		})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed on redeliver webhook delivery")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("webhook delivery not found or already pending")
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	_ "net/http/pprof"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/ProjectsTask/EasySwapBackend/src/app"
 	"github.com/ProjectsTask/EasySwapBackend/src/config"
 	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/v1"
 )
 
 const (
@@ -34,6 +36,11 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	// 执行实例已退出(租约过期)的导入任务标记为中断
+	if err := service.RecoverSyncTasks(context.Background(), serverCtx); err != nil {
+		panic(err)
+	}
+	go service.RecoverSyncTasksLoop(context.Background(), serverCtx)
 	// Initialize router
 	r := router.NewRouter(serverCtx)
 	app, err := app.NewPlatform(c, r, serverCtx)
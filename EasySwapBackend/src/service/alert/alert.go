@@ -0,0 +1,170 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/marketfeed"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	cacheCursorPre = "cache:es:alert:cursor:%s"
+
+	evaluateBatchSize   = 200
+	defaultPollInterval = time.Second
+)
+
+// RuleTypes 支持的提醒规则类型
+var RuleTypes = []string{
+	base.AlertRuleFloorBelow,
+	base.AlertRuleBidAbove,
+	base.AlertRuleListingUndercut,
+	base.AlertRuleVolumeSpike,
+}
+
+// IsValidRuleType 判断提醒规则类型是否支持
+func IsValidRuleType(ruleType string) bool {
+	for _, t := range RuleTypes {
+		if t == ruleType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Config 提醒服务配置, 为 0 时使用默认值
+type Config struct {
+	PollInterval time.Duration // 读取市场事件的间隔
+}
+
+// Service 读取索引服务与 ordermanager 写入 Redis 的成交、挂单、出价与地板价事件, 评估用户的提醒规则,
+// 触发后写入站内信并通过已配置的渠道发送站外通知
+// 多实例部署时站内信按 (规则, 事件) 去重, 冷却时间通过乐观锁保证只触发一次
+type Service struct {
+	ctx      context.Context
+	db       *gorm.DB
+	kv       *xkv.Store
+	chains   map[string]int64 // 链名称到链ID
+	conf     Config
+	channels map[string]Channel
+}
+
+// New 创建并启动提醒服务, channels 为可用的站外通知渠道
+func New(ctx context.Context, db *gorm.DB, kv *xkv.Store, chains map[string]int64, conf Config, channels ...Channel) *Service {
+	if conf.PollInterval <= 0 {
+		conf.PollInterval = defaultPollInterval
+	}
+
+	s := &Service{
+		ctx:      ctx,
+		db:       db,
+		kv:       kv,
+		chains:   chains,
+		conf:     conf,
+		channels: make(map[string]Channel),
+	}
+	for _, channel := range channels {
+		s.channels[channel.Name()] = channel
+	}
+	for chain := range chains {
+		go s.evaluateLoop(chain)
+	}
+
+	return s
+}
+
+// evaluateLoop 持续读取链上的市场事件并评估提醒规则
+func (s *Service) evaluateLoop(chain string) {
+	ticker := time.NewTicker(s.conf.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.evaluateEvents(chain); err != nil {
+				xzap.WithContext(s.ctx).Error("failed on evaluate alert rules", zap.Error(err), zap.String("chain", chain))
+			}
+		}
+	}
+}
+
+// evaluateEvents 从上次的游标继续读取事件, 首次运行时从最新事件开始
+func (s *Service) evaluateEvents(chain string) error {
+	cursor, ok, err := s.loadCursor(chain)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		latest, err := marketfeed.Latest(s.kv, chain)
+		if err != nil {
+			return err
+		}
+		return s.saveCursor(chain, latest)
+	}
+
+	for {
+		events, gap, err := marketfeed.Read(s.kv, chain, cursor, evaluateBatchSize)
+		if err != nil {
+			return err
+		}
+		if gap {
+			xzap.WithContext(s.ctx).Warn("alert events expired before evaluate",
+				zap.String("chain", chain), zap.Uint64("cursor", cursor))
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		for _, event := range events {
+			if err := s.evaluate(chain, event); err != nil {
+				// 保留已评估事件的进度, 失败的事件在下一轮重新评估
+				if saveErr := s.saveCursor(chain, cursor); saveErr != nil {
+					xzap.WithContext(s.ctx).Error("failed on save alert cursor", zap.Error(saveErr), zap.String("chain", chain))
+				}
+				return err
+			}
+			cursor = event.ID
+		}
+		if err := s.saveCursor(chain, cursor); err != nil {
+			return err
+		}
+		if len(events) < evaluateBatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *Service) loadCursor(chain string) (uint64, bool, error) {
+	value, err := s.kv.Get(fmt.Sprintf(cacheCursorPre, chain))
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed on get alert cursor")
+	}
+	if value == "" {
+		return 0, false, nil
+	}
+	cursor, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "invalid alert cursor %q", value)
+	}
+
+	return cursor, true, nil
+}
+
+func (s *Service) saveCursor(chain string, cursor uint64) error {
+	if err := s.kv.Set(fmt.Sprintf(cacheCursorPre, chain), strconv.FormatUint(cursor, 10)); err != nil {
+		return errors.Wrap(err, "failed on save alert cursor")
+	}
+
+	return nil
+}
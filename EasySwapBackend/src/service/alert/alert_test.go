@@ -0,0 +1,288 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/marketfeed"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glebarez/sqlite"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/stores/kv"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/service/webhook"
+)
+
+const (
+	testChain      = "sepolia"
+	testChainID    = 11155111
+	testUser       = "0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266"
+	testCollection = "0x1111111111111111111111111111111111111111"
+)
+
+// fakeChannel 记录发送的通知, 按顺序返回给定错误
+type fakeChannel struct {
+	name string
+	errs []error
+
+	mu   sync.Mutex
+	sent []*base.Notification
+}
+
+func (f *fakeChannel) Name() string {
+	return f.name
+}
+
+func (f *fakeChannel) Send(ctx context.Context, setting *base.NotifySetting, notification *base.Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, notification)
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *fakeChannel) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.sent)
+}
+
+// newTestService 创建不启动评估循环的提醒服务
+func newTestService(t *testing.T, channels ...Channel) *Service {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "alert.db")), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Table(base.AlertRuleTableName()).AutoMigrate(&base.AlertRule{}))
+	assert.NoError(t, db.Table(base.NotificationTableName()).AutoMigrate(&base.Notification{}))
+	assert.NoError(t, db.Table(base.NotifySettingTableName()).AutoMigrate(&base.NotifySetting{}))
+	store := xkv.NewStore(kv.KvConf{{
+		RedisConf: redis.RedisConf{Host: miniredis.RunT(t).Addr(), Type: redis.NodeType},
+		Weight:    100,
+	}})
+
+	waits := notifyWaits
+	notifyWaits = []time.Duration{time.Millisecond}
+	t.Cleanup(func() { notifyWaits = waits })
+
+	s := &Service{
+		ctx:      xzap.ToContext(context.Background(), zap.NewNop()),
+		db:       db,
+		kv:       store,
+		chains:   map[string]int64{testChain: testChainID},
+		channels: make(map[string]Channel),
+	}
+	for _, channel := range channels {
+		s.channels[channel.Name()] = channel
+	}
+
+	return s
+}
+
+func createRule(t *testing.T, s *Service, rule *base.AlertRule) *base.AlertRule {
+	rule.UserAddress = testUser
+	rule.ChainId = testChainID
+	rule.CollectionAddress = testCollection
+	assert.NoError(t, s.db.Table(base.AlertRuleTableName()).Create(rule).Error)
+
+	return rule
+}
+
+func floorEvent(id uint64, price int64) *marketfeed.Event {
+	return &marketfeed.Event{
+		ID:                id,
+		Type:              marketfeed.TypeFloorPrice,
+		Chain:             testChain,
+		CollectionAddress: testCollection,
+		Price:             decimal.NewFromInt(price),
+	}
+}
+
+func notifications(t *testing.T, s *Service) []base.Notification {
+	var result []base.Notification
+	assert.NoError(t, s.db.Table(base.NotificationTableName()).Order("id asc").Find(&result).Error)
+
+	return result
+}
+
+func TestFloorBelowThreshold(t *testing.T) {
+	s := newTestService(t)
+	rule := createRule(t, s, &base.AlertRule{RuleType: base.AlertRuleFloorBelow, Threshold: decimal.NewFromInt(100), Enabled: true})
+	createRule(t, s, &base.AlertRule{RuleType: base.AlertRuleFloorBelow, Threshold: decimal.NewFromInt(1000), Enabled: false})
+
+	// 首次运行从最新事件开始
+	assert.NoError(t, s.evaluateEvents(testChain))
+	for _, price := range []int64{100, 150, 0, 90} {
+		_, err := marketfeed.Publish(s.kv, testChain, &marketfeed.Event{
+			Type:              marketfeed.TypeFloorPrice,
+			CollectionAddress: testCollection,
+			Price:             decimal.NewFromInt(price),
+		})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, s.evaluateEvents(testChain))
+
+	// 只有低于阈值的正价格触发, 停用的规则不触发
+	result := notifications(t, s)
+	assert.Len(t, result, 1)
+	assert.Equal(t, rule.Id, result[0].RuleId)
+	assert.Equal(t, testUser, result[0].UserAddress)
+	assert.Equal(t, testChain+":4", result[0].EventId)
+	assert.Contains(t, result[0].Title, "90")
+	var event marketfeed.Event
+	assert.NoError(t, json.Unmarshal([]byte(result[0].Content), &event))
+	assert.True(t, event.Price.Equal(decimal.NewFromInt(90)))
+
+	cursor, ok, err := s.loadCursor(testChain)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(4), cursor)
+}
+
+func TestCooldown(t *testing.T) {
+	s := newTestService(t)
+	cooldown := createRule(t, s, &base.AlertRule{RuleType: base.AlertRuleFloorBelow, Threshold: decimal.NewFromInt(100), Cooldown: 3600, Enabled: true})
+
+	// 冷却时间内只触发一次
+	assert.NoError(t, s.evaluate(testChain, floorEvent(1, 90)))
+	assert.NoError(t, s.evaluate(testChain, floorEvent(2, 80)))
+	assert.Len(t, notifications(t, s), 1)
+	var stored base.AlertRule
+	assert.NoError(t, s.db.Table(base.AlertRuleTableName()).Where("id = ?", cooldown.Id).First(&stored).Error)
+	assert.NotZero(t, stored.LastTriggeredTime)
+
+	// 冷却结束后再次触发
+	assert.NoError(t, s.db.Table(base.AlertRuleTableName()).Where("id = ?", cooldown.Id).
+		Update("last_triggered_time", time.Now().Add(-2*time.Hour).UnixMilli()).Error)
+	assert.NoError(t, s.evaluate(testChain, floorEvent(3, 70)))
+	assert.Len(t, notifications(t, s), 2)
+
+	// 多实例重复评估同一事件时只写入一条站内信
+	noCooldown := createRule(t, s, &base.AlertRule{RuleType: base.AlertRuleFloorBelow, Threshold: decimal.NewFromInt(100), Enabled: true})
+	assert.NoError(t, s.db.Table(base.AlertRuleTableName()).Where("id = ?", cooldown.Id).Update("enabled", false).Error)
+	assert.NoError(t, s.evaluate(testChain, floorEvent(4, 60)))
+	assert.NoError(t, s.evaluate(testChain, floorEvent(4, 60)))
+	var count int64
+	assert.NoError(t, s.db.Table(base.NotificationTableName()).Where("rule_id = ?", noCooldown.Id).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestChannelFanOut(t *testing.T) {
+	webhookCh := &fakeChannel{name: base.NotifyChannelWebhook, errs: []error{errors.New("timeout")}}
+	emailCh := &fakeChannel{name: base.NotifyChannelEmail, errs: []error{ErrNoTarget}}
+	other := &fakeChannel{name: "sms"}
+	s := newTestService(t, webhookCh, emailCh, other)
+	assert.NoError(t, s.db.Table(base.NotifySettingTableName()).Create(&base.NotifySetting{UserAddress: testUser, WebhookUrl: "https://example.com/hook"}).Error)
+	createRule(t, s, &base.AlertRule{
+		RuleType:  base.AlertRuleFloorBelow,
+		Threshold: decimal.NewFromInt(100),
+		Channels:  base.NotifyChannelWebhook + "," + base.NotifyChannelEmail,
+		Enabled:   true,
+	})
+
+	assert.NoError(t, s.evaluate(testChain, floorEvent(1, 90)))
+
+	// 发送失败时重试, 未配置接收地址时不重试, 规则未选择的渠道不发送
+	assert.Eventually(t, func() bool { return webhookCh.count() == 2 && emailCh.count() == 1 }, 2*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 2, webhookCh.count())
+	assert.Equal(t, 1, emailCh.count())
+	assert.Equal(t, 0, other.count())
+	assert.Equal(t, notifications(t, s)[0].Id, webhookCh.sent[0].Id)
+
+	// 未配置通知渠道时只写入站内信
+	silent := newTestService(t, webhookCh)
+	createRule(t, silent, &base.AlertRule{RuleType: base.AlertRuleFloorBelow, Threshold: decimal.NewFromInt(100), Enabled: true})
+	assert.NoError(t, silent.evaluate(testChain, floorEvent(1, 90)))
+	assert.Len(t, notifications(t, silent), 1)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 2, webhookCh.count())
+}
+
+func TestWebhookChannel(t *testing.T) {
+	var (
+		header http.Header
+		body   []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+	}))
+	t.Cleanup(srv.Close)
+	setting := &base.NotifySetting{UserAddress: testUser, WebhookUrl: srv.URL, WebhookSecret: "secret"}
+	notification := &base.Notification{Id: 7, RuleType: base.AlertRuleFloorBelow, Title: "floor"}
+
+	// 默认客户端拒绝连接本机地址
+	channel := NewWebhookChannel(time.Second)
+	assert.ErrorIs(t, channel.Send(context.Background(), setting, notification), webhook.ErrForbiddenAddress)
+	assert.Nil(t, header)
+	assert.ErrorIs(t, channel.Send(context.Background(), &base.NotifySetting{}, notification), ErrNoTarget)
+
+	channel = &webhookChannel{client: srv.Client()}
+	assert.NoError(t, channel.Send(context.Background(), setting, notification))
+	assert.Equal(t, "alert."+base.AlertRuleFloorBelow, header.Get(webhook.HeaderEvent))
+	assert.Equal(t, strconv.FormatInt(notification.Id, 10), header.Get(webhook.HeaderDelivery))
+	var sent base.Notification
+	assert.NoError(t, json.Unmarshal(body, &sent))
+	assert.Equal(t, notification.Title, sent.Title)
+	signature := header.Get(webhook.HeaderSignature)
+	var timestamp int64
+	_, err := fmt.Sscanf(signature, "t=%d,", &timestamp)
+	assert.NoError(t, err)
+	assert.Equal(t, webhook.Sign("secret", timestamp, body), signature)
+}
+
+func TestBidAboveOwnsErc1155(t *testing.T) {
+	s := newTestService(t)
+	assert.NoError(t, s.db.Table(multi.OrderTableName(testChain)).AutoMigrate(&multi.Order{}))
+	assert.NoError(t, s.db.Table(multi.ItemTableName(testChain)).AutoMigrate(&multi.Item{}))
+	assert.NoError(t, s.db.Table(multi.ItemBalanceTableName(testChain)).AutoMigrate(&multi.ItemBalance{}))
+	rule := createRule(t, s, &base.AlertRule{RuleType: base.AlertRuleBidAbove, TokenId: "7", Threshold: decimal.NewFromInt(10), Enabled: true})
+	event := &marketfeed.Event{
+		Type:              marketfeed.TypeBid,
+		Chain:             testChain,
+		CollectionAddress: testCollection,
+		TokenID:           "7",
+		OrderID:           "0x01",
+		Maker:             "0x70997970c51812dc3a010c7d01b50e0d17dc79c8",
+		Price:             decimal.NewFromInt(20),
+	}
+
+	matched, err := s.match(testChain, rule, event)
+	assert.NoError(t, err)
+	assert.False(t, matched)
+
+	// ERC1155 的 item 没有 owner, 按持有数量判断
+	assert.NoError(t, s.db.Table(multi.ItemTableName(testChain)).Create(&multi.Item{CollectionAddress: testCollection, TokenId: "7"}).Error)
+	balance := &multi.ItemBalance{CollectionAddress: testCollection, TokenId: "7", Owner: testUser, Balance: 2}
+	assert.NoError(t, s.db.Table(multi.ItemBalanceTableName(testChain)).Create(balance).Error)
+	matched, err = s.match(testChain, rule, event)
+	assert.NoError(t, err)
+	assert.True(t, matched)
+
+	assert.NoError(t, s.db.Table(multi.ItemBalanceTableName(testChain)).Where("id = ?", balance.Id).Update("balance", 0).Error)
+	matched, err = s.match(testChain, rule, event)
+	assert.NoError(t, err)
+	assert.False(t, matched)
+}
@@ -0,0 +1,126 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/pkg/errors"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/service/webhook"
+)
+
+// ErrNoTarget 用户未配置该渠道的接收地址
+var ErrNoTarget = errors.New("notify target not configured")
+
+// Channel 站外通知渠道
+type Channel interface {
+	// Name 渠道名称, 与规则中的 channels 对应
+	Name() string
+	// Send 发送一条通知, 用户未配置接收地址时返回 ErrNoTarget
+	Send(ctx context.Context, setting *base.NotifySetting, notification *base.Notification) error
+}
+
+// SMTPConfig 邮件发送配置
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type emailChannel struct {
+	conf SMTPConfig
+}
+
+// NewEmailChannel 创建 SMTP 邮件渠道
+func NewEmailChannel(conf SMTPConfig) Channel {
+	return &emailChannel{conf: conf}
+}
+
+func (e *emailChannel) Name() string {
+	return base.NotifyChannelEmail
+}
+
+func (e *emailChannel) Send(ctx context.Context, setting *base.NotifySetting, notification *base.Notification) error {
+	if setting.Email == "" {
+		return ErrNoTarget
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.conf.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", setting.Email)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", notification.Title))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(notification.Title)
+	msg.WriteString("\r\n\r\n")
+	msg.WriteString(notification.Content)
+	msg.WriteString("\r\n")
+
+	var auth smtp.Auth
+	if e.conf.Username != "" {
+		auth = smtp.PlainAuth("", e.conf.Username, e.conf.Password, e.conf.Host)
+	}
+	addr := net.JoinHostPort(e.conf.Host, strconv.Itoa(e.conf.Port))
+	if err := smtp.SendMail(addr, auth, e.conf.From, []string{setting.Email}, msg.Bytes()); err != nil {
+		return errors.Wrap(err, "failed on send alert email")
+	}
+
+	return nil
+}
+
+type webhookChannel struct {
+	client *http.Client
+}
+
+// NewWebhookChannel 创建 webhook 渠道, 请求头、签名方式与内网地址限制与市场事件 webhook 一致
+func NewWebhookChannel(timeout time.Duration) Channel {
+	return &webhookChannel{client: webhook.NewClient(timeout)}
+}
+
+func (w *webhookChannel) Name() string {
+	return base.NotifyChannelWebhook
+}
+
+func (w *webhookChannel) Send(ctx context.Context, setting *base.NotifySetting, notification *base.Notification) error {
+	if setting.WebhookUrl == "" {
+		return ErrNoTarget
+	}
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return errors.Wrap(err, "failed on marshal alert notification")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, setting.WebhookUrl, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed on create alert webhook request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.HeaderEvent, "alert."+notification.RuleType)
+	req.Header.Set(webhook.HeaderDelivery, strconv.FormatInt(notification.Id, 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(setting.WebhookSecret, time.Now().Unix(), body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed on send alert webhook request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	return errors.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(raw)))
+}
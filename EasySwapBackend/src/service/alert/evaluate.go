@@ -0,0 +1,262 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/marketfeed"
+	"github.com/ProjectsTask/EasySwapBase/retry"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+const notifyAttempts = 3
+
+// notifyWaits 站外通知两次发送之间的等待时间
+var notifyWaits = []time.Duration{time.Second, 2 * time.Second}
+
+// eventRuleTypes 各类市场事件需要评估的规则类型
+var eventRuleTypes = map[string][]string{
+	marketfeed.TypeFloorPrice: {base.AlertRuleFloorBelow},
+	marketfeed.TypeBid:        {base.AlertRuleBidAbove},
+	marketfeed.TypeListing:    {base.AlertRuleListingUndercut},
+	marketfeed.TypeSale:       {base.AlertRuleVolumeSpike},
+}
+
+// evaluate 评估事件所在合集的提醒规则, 处于冷却时间内的规则跳过
+func (s *Service) evaluate(chain string, event *marketfeed.Event) error {
+	ruleTypes, ok := eventRuleTypes[event.Type]
+	if !ok {
+		return nil
+	}
+
+	var rules []base.AlertRule
+	if err := s.db.WithContext(s.ctx).Table(base.AlertRuleTableName()).
+		Where("chain_id = ? and collection_address = ? and rule_type in ? and enabled = ?",
+			s.chains[chain], event.CollectionAddress, ruleTypes, true).
+		Find(&rules).Error; err != nil {
+		return errors.Wrap(err, "failed on query alert rules")
+	}
+
+	now := time.Now().UnixMilli()
+	for i := range rules {
+		rule := &rules[i]
+		if rule.LastTriggeredTime+rule.Cooldown*1000 > now {
+			continue
+		}
+		matched, err := s.match(chain, rule, event)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+		if err := s.trigger(chain, rule, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// match 判断事件是否满足规则条件
+func (s *Service) match(chain string, rule *base.AlertRule, event *marketfeed.Event) (bool, error) {
+	switch rule.RuleType {
+	case base.AlertRuleFloorBelow:
+		return event.Price.IsPositive() && event.Price.LessThan(rule.Threshold), nil
+	case base.AlertRuleBidAbove:
+		if event.Maker == rule.UserAddress || !event.Price.GreaterThan(rule.Threshold) {
+			return false, nil
+		}
+		return s.ownsBidTarget(chain, rule, event)
+	case base.AlertRuleListingUndercut:
+		if event.Maker == rule.UserAddress {
+			return false, nil
+		}
+		lowest, ok, err := s.lowestListing(chain, rule)
+		if err != nil || !ok {
+			return false, err
+		}
+		return event.Price.LessThan(lowest), nil
+	case base.AlertRuleVolumeSpike:
+		volume, err := s.volume(chain, rule.CollectionAddress, event.EventTime-rule.Window)
+		if err != nil {
+			return false, err
+		}
+		return volume.GreaterThanOrEqual(rule.Threshold), nil
+	}
+
+	return false, nil
+}
+
+// ownsBidTarget 判断出价的 NFT 是否为规则用户持有, 合集出价时持有合集内任意 NFT 即可
+// ERC721 按 item 的 owner 判断, ERC1155 的 item 没有 owner, 按 item_balance 中的持有数量判断
+func (s *Service) ownsBidTarget(chain string, rule *base.AlertRule, event *marketfeed.Event) (bool, error) {
+	var orders []multi.Order
+	if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(chain)).
+		Select("order_type").
+		Where("order_id = ?", event.OrderID).
+		Limit(1).
+		Find(&orders).Error; err != nil {
+		return false, errors.Wrap(err, "failed on query bid order")
+	}
+
+	tokenID := event.TokenID
+	if len(orders) > 0 && orders[0].OrderType == multi.CollectionBidOrder {
+		tokenID = rule.TokenId
+	} else if rule.TokenId != "" && rule.TokenId != tokenID {
+		return false, nil
+	}
+
+	query := s.db.WithContext(s.ctx).Table(multi.ItemTableName(chain)).
+		Where("collection_address = ? and owner = ?", rule.CollectionAddress, rule.UserAddress)
+	if tokenID != "" {
+		query = query.Where("token_id = ?", tokenID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, errors.Wrap(err, "failed on query item owner")
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	query = s.db.WithContext(s.ctx).Table(multi.ItemBalanceTableName(chain)).
+		Where("collection_address = ? and owner = ? and balance > 0", rule.CollectionAddress, rule.UserAddress)
+	if tokenID != "" {
+		query = query.Where("token_id = ?", tokenID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return false, errors.Wrap(err, "failed on query item balance")
+	}
+
+	return count > 0, nil
+}
+
+// lowestListing 查询规则用户在合集内有效挂单的最低价格, 没有挂单时 ok 为 false
+func (s *Service) lowestListing(chain string, rule *base.AlertRule) (decimal.Decimal, bool, error) {
+	query := s.db.WithContext(s.ctx).Table(multi.OrderTableName(chain)).
+		Select("min(price)").
+		Where("collection_address = ? and maker = ? and order_type = ? and order_status = ? and expire_time > ?",
+			rule.CollectionAddress, rule.UserAddress, multi.ListingOrder, multi.OrderStatusActive, time.Now().Unix())
+	if rule.TokenId != "" {
+		query = query.Where("token_id = ?", rule.TokenId)
+	}
+
+	var lowest decimal.NullDecimal
+	if err := query.Row().Scan(&lowest); err != nil {
+		return decimal.Zero, false, errors.Wrap(err, "failed on query lowest listing")
+	}
+
+	return lowest.Decimal, lowest.Valid, nil
+}
+
+// volume 统计合集自 since(秒) 起的成交额
+func (s *Service) volume(chain, collection string, since int64) (decimal.Decimal, error) {
+	var volume decimal.NullDecimal
+	if err := s.db.WithContext(s.ctx).Table(multi.ActivityTableName(chain)).
+		Select("sum(price)").
+		Where("collection_address = ? and activity_type = ? and event_time >= ?", collection, multi.Sale, since).
+		Row().Scan(&volume); err != nil {
+		return decimal.Zero, errors.Wrap(err, "failed on query collection volume")
+	}
+
+	return volume.Decimal, nil
+}
+
+// trigger 记录触发时间并写入站内信, 写入成功后发送站外通知
+func (s *Service) trigger(chain string, rule *base.AlertRule, event *marketfeed.Event) error {
+	// 乐观锁更新触发时间, 多实例同时评估同一规则时只有一个实例触发
+	result := s.db.WithContext(s.ctx).Table(base.AlertRuleTableName()).
+		Where("id = ? and last_triggered_time = ?", rule.Id, rule.LastTriggeredTime).
+		Update("last_triggered_time", time.Now().UnixMilli())
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed on update alert rule trigger time")
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	content, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "failed on marshal alert event")
+	}
+	notification := &base.Notification{
+		UserAddress:       rule.UserAddress,
+		RuleId:            rule.Id,
+		RuleType:          rule.RuleType,
+		EventId:           fmt.Sprintf("%s:%d", chain, event.ID),
+		ChainId:           rule.ChainId,
+		CollectionAddress: event.CollectionAddress,
+		TokenId:           event.TokenID,
+		Title:             title(rule, event),
+		Content:           string(content),
+	}
+	result = s.db.WithContext(s.ctx).Table(base.NotificationTableName()).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(notification)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed on create notification")
+	}
+	if result.RowsAffected > 0 && rule.Channels != "" {
+		go s.notify(rule, notification)
+	}
+
+	return nil
+}
+
+// notify 通过规则选择的渠道发送站外通知, 发送失败只记录日志, 站内信不受影响
+func (s *Service) notify(rule *base.AlertRule, notification *base.Notification) {
+	var settings []base.NotifySetting
+	if err := s.db.WithContext(s.ctx).Table(base.NotifySettingTableName()).
+		Where("user_address = ?", rule.UserAddress).
+		Limit(1).
+		Find(&settings).Error; err != nil {
+		xzap.WithContext(s.ctx).Error("failed on query notify setting", zap.Error(err), zap.String("user", rule.UserAddress))
+		return
+	}
+	if len(settings) == 0 {
+		return
+	}
+
+	for _, name := range strings.Split(rule.Channels, ",") {
+		channel, ok := s.channels[name]
+		if !ok {
+			continue
+		}
+		var lastErr error
+		err := retry.Retry(func(attempt uint) error {
+			lastErr = channel.Send(s.ctx, &settings[0], notification)
+			return lastErr
+		}, retry.Limit(notifyAttempts), retry.Wait(notifyWaits...), func(attempt uint) bool {
+			return !errors.Is(lastErr, ErrNoTarget)
+		})
+		if err != nil && !errors.Is(err, ErrNoTarget) {
+			xzap.WithContext(s.ctx).Error("failed on send alert notification", zap.Error(err),
+				zap.String("channel", name), zap.Int64("notification_id", notification.Id))
+		}
+	}
+}
+
+// title 生成站内信标题, 价格以 wei 为单位
+func title(rule *base.AlertRule, event *marketfeed.Event) string {
+	switch rule.RuleType {
+	case base.AlertRuleFloorBelow:
+		return fmt.Sprintf("合集 %s 地板价降至 %s, 低于设定的 %s", event.CollectionAddress, event.Price, rule.Threshold)
+	case base.AlertRuleBidAbove:
+		return fmt.Sprintf("你持有的 %s #%s 收到 %s 的出价", event.CollectionAddress, event.TokenID, event.Price)
+	case base.AlertRuleListingUndercut:
+		return fmt.Sprintf("合集 %s 出现价格为 %s 的挂单, 低于你的挂单价", event.CollectionAddress, event.Price)
+	case base.AlertRuleVolumeSpike:
+		return fmt.Sprintf("合集 %s 在 %d 秒内的成交额达到 %s", event.CollectionAddress, rule.Window, rule.Threshold)
+	}
+
+	return rule.RuleType
+}
@@ -0,0 +1,205 @@
+package marketpush
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/marketfeed"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// TypeReset 游标之后的事件已过期, 客户端需要通过接口重新加载数据后从新游标继续
+const TypeReset = "reset"
+
+const (
+	defaultPollInterval   = 500 * time.Millisecond
+	defaultMaxSubscribers = 10000
+
+	// readBatchSize 每次从 Redis 读取的事件数
+	readBatchSize = 500
+	// liveBufferSize 订阅者的实时事件缓冲, 写满后订阅者转为从 Redis 追赶
+	liveBufferSize = 256
+)
+
+// ErrTooManySubscribers 订阅数达到上限
+var ErrTooManySubscribers = errors.New("too many subscribers")
+
+// Config 推送配置, 为 0 时使用默认值
+type Config struct {
+	PollInterval   time.Duration
+	MaxSubscribers int
+}
+
+// Hub 轮询单条链的市场事件流并分发给订阅者, 每个进程每条链一个实例
+type Hub struct {
+	ctx   context.Context
+	kv    *xkv.Store
+	chain string
+	conf  Config
+
+	mu   sync.Mutex
+	last uint64 // 已分发的最新事件编号
+	subs map[*Subscription]struct{}
+}
+
+// NewHub 创建并启动 Hub, 从当前最新事件之后开始分发
+func NewHub(ctx context.Context, kv *xkv.Store, chain string, conf Config) (*Hub, error) {
+	if conf.PollInterval <= 0 {
+		conf.PollInterval = defaultPollInterval
+	}
+	if conf.MaxSubscribers <= 0 {
+		conf.MaxSubscribers = defaultMaxSubscribers
+	}
+
+	last, err := marketfeed.Latest(kv, chain)
+	if err != nil {
+		return nil, err
+	}
+	h := &Hub{
+		ctx:   ctx,
+		kv:    kv,
+		chain: chain,
+		conf:  conf,
+		last:  last,
+		subs:  make(map[*Subscription]struct{}),
+	}
+	go h.loop()
+
+	return h, nil
+}
+
+// Chain 返回 Hub 对应的链名称
+func (h *Hub) Chain() string {
+	return h.chain
+}
+
+func (h *Hub) loop() {
+	ticker := time.NewTicker(h.conf.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-ticker.C:
+			h.poll()
+		}
+	}
+}
+
+// poll 读取新事件并分发, 一次读取不完时继续读取
+func (h *Hub) poll() {
+	for {
+		h.mu.Lock()
+		after := h.last
+		h.mu.Unlock()
+
+		events, gap, err := marketfeed.Read(h.kv, h.chain, after, readBatchSize)
+		if err != nil {
+			xzap.WithContext(h.ctx).Warn("failed on read market events", zap.Error(err), zap.String("chain", h.chain))
+			return
+		}
+		if gap {
+			xzap.WithContext(h.ctx).Warn("market events expired before dispatch",
+				zap.String("chain", h.chain), zap.Uint64("after", after))
+		}
+		if len(events) == 0 {
+			return
+		}
+
+		h.dispatch(events)
+		if len(events) < readBatchSize {
+			return
+		}
+	}
+}
+
+// dispatch 按顺序分发事件, 缓冲已满的订阅者被移出并转为从 Redis 追赶, 不阻塞其他订阅者
+func (h *Hub) dispatch(events []*marketfeed.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, event := range events {
+		for sub := range h.subs {
+			if !sub.filter.Match(event) {
+				continue
+			}
+			select {
+			case sub.live <- event:
+			default:
+				delete(h.subs, sub)
+				close(sub.overflow)
+			}
+		}
+	}
+	h.last = events[len(events)-1].ID
+}
+
+// register 登记订阅者, 返回登记时已分发的最新事件编号, 之后的事件通过 live 接收
+func (h *Hub) register(sub *Subscription) (uint64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.subs) >= h.conf.MaxSubscribers {
+		return 0, ErrTooManySubscribers
+	}
+	sub.live = make(chan *marketfeed.Event, liveBufferSize)
+	sub.overflow = make(chan struct{})
+	h.subs[sub] = struct{}{}
+
+	return h.last, nil
+}
+
+func (h *Hub) unregister(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.subs, sub)
+}
+
+// Filter 订阅条件, 满足任一条件的事件都会推送, 全部为空时推送整条链的事件
+type Filter struct {
+	Collections map[string]bool // 合集地址
+	Tokens      map[string]bool // 合集地址:token_id
+	Users       map[string]bool // 挂单者或成交对手方地址
+}
+
+// NewFilter 创建订阅条件, 地址统一转为小写
+func NewFilter(collections, tokens, users []string) Filter {
+	f := Filter{
+		Collections: make(map[string]bool),
+		Tokens:      make(map[string]bool),
+		Users:       make(map[string]bool),
+	}
+	for _, collection := range collections {
+		f.Collections[strings.ToLower(collection)] = true
+	}
+	for _, token := range tokens {
+		f.Tokens[strings.ToLower(token)] = true
+	}
+	for _, user := range users {
+		f.Users[strings.ToLower(user)] = true
+	}
+
+	return f
+}
+
+// Match 判断事件是否满足订阅条件
+func (f Filter) Match(event *marketfeed.Event) bool {
+	if len(f.Collections) == 0 && len(f.Tokens) == 0 && len(f.Users) == 0 {
+		return true
+	}
+	if f.Collections[event.CollectionAddress] {
+		return true
+	}
+	if event.TokenID != "" && f.Tokens[event.CollectionAddress+":"+event.TokenID] {
+		return true
+	}
+
+	return (event.Maker != "" && f.Users[event.Maker]) || (event.Taker != "" && f.Users[event.Taker])
+}
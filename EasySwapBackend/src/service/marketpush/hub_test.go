@@ -0,0 +1,226 @@
+package marketpush
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/marketfeed"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/stores/kv"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"go.uber.org/zap"
+)
+
+const (
+	testChain      = "sepolia"
+	testCollection = "0x1111111111111111111111111111111111111111"
+	testOther      = "0x2222222222222222222222222222222222222222"
+	testMaker      = "0xabcdefabcdefabcdefabcdefabcdefabcdefabcd"
+)
+
+func testContext() context.Context {
+	return xzap.ToContext(context.Background(), zap.NewNop())
+}
+
+// newTestHub 创建不自动轮询的 Hub, 由测试调用 poll 分发事件
+func newTestHub(t *testing.T, conf Config) (*Hub, *xkv.Store) {
+	store := xkv.NewStore(kv.KvConf{{
+		RedisConf: redis.RedisConf{Host: miniredis.RunT(t).Addr(), Type: redis.NodeType},
+		Weight:    100,
+	}})
+	ctx, cancel := context.WithCancel(testContext())
+	t.Cleanup(cancel)
+
+	conf.PollInterval = time.Hour
+	h, err := NewHub(ctx, store, testChain, conf)
+	assert.NoError(t, err)
+
+	return h, store
+}
+
+func publish(t *testing.T, store *xkv.Store, event *marketfeed.Event) uint64 {
+	if event.Type == "" {
+		event.Type = marketfeed.TypeListing
+	}
+	id, err := marketfeed.Publish(store, testChain, event)
+	assert.NoError(t, err)
+
+	return id
+}
+
+func receive(t *testing.T, sub *Subscription) *marketfeed.Event {
+	select {
+	case event, ok := <-sub.Events():
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+
+	return nil
+}
+
+func subscriberCount(h *Hub) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subs)
+}
+
+func TestSubscribeUnsubscribe(t *testing.T) {
+	h, store := newTestHub(t, Config{MaxSubscribers: 1})
+	// 订阅前的事件不推送
+	publish(t, store, &marketfeed.Event{CollectionAddress: testCollection})
+	h.poll()
+
+	ctx, cancel := context.WithCancel(testContext())
+	sub, err := h.Subscribe(ctx, NewFilter(nil, nil, nil), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), sub.Start())
+	assert.Equal(t, 1, subscriberCount(h))
+
+	_, err = h.Subscribe(testContext(), NewFilter(nil, nil, nil), 0)
+	assert.ErrorIs(t, err, ErrTooManySubscribers)
+
+	id := publish(t, store, &marketfeed.Event{CollectionAddress: testCollection})
+	h.poll()
+	assert.Equal(t, id, receive(t, sub).ID)
+
+	// 取消后关闭事件通道并注销订阅
+	cancel()
+	select {
+	case _, ok := <-sub.Events():
+		assert.False(t, ok)
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscription not closed")
+	}
+	assert.Eventually(t, func() bool { return subscriberCount(h) == 0 }, 2*time.Second, 10*time.Millisecond)
+
+	_, err = h.Subscribe(testContext(), NewFilter(nil, nil, nil), 0)
+	assert.NoError(t, err)
+}
+
+func TestSubscribeResumeFromCursor(t *testing.T) {
+	h, store := newTestHub(t, Config{})
+	first := publish(t, store, &marketfeed.Event{CollectionAddress: testCollection})
+	second := publish(t, store, &marketfeed.Event{CollectionAddress: testCollection})
+	h.poll()
+
+	// 从游标之后补发已分发过的事件
+	sub, err := h.Subscribe(testContext(), NewFilter(nil, nil, nil), first)
+	assert.NoError(t, err)
+	assert.Equal(t, second, receive(t, sub).ID)
+
+	third := publish(t, store, &marketfeed.Event{CollectionAddress: testCollection})
+	h.poll()
+	assert.Equal(t, third, receive(t, sub).ID)
+}
+
+func TestFilterMatch(t *testing.T) {
+	event := &marketfeed.Event{CollectionAddress: testCollection, TokenID: "1", Maker: testMaker}
+	cases := map[string]struct {
+		filter Filter
+		match  bool
+	}{
+		"empty":            {NewFilter(nil, nil, nil), true},
+		"collection":       {NewFilter([]string{testCollection}, nil, nil), true},
+		"other collection": {NewFilter([]string{testOther}, nil, nil), false},
+		"token":            {NewFilter(nil, []string{testCollection + ":1"}, nil), true},
+		"other token":      {NewFilter(nil, []string{testCollection + ":2"}, nil), false},
+		"maker upper case": {NewFilter(nil, nil, []string{"0xABCDEFABCDEFABCDEFABCDEFABCDEFABCDEFABCD"}), true},
+		"other user":       {NewFilter(nil, nil, []string{testOther}), false},
+		"any condition":    {NewFilter([]string{testOther}, nil, []string{testMaker}), true},
+	}
+	for name, c := range cases {
+		assert.Equal(t, c.match, c.filter.Match(event), name)
+	}
+
+	// 地址不区分大小写, 成交对手方也会匹配
+	taker := &marketfeed.Event{CollectionAddress: testOther, Taker: testMaker}
+	assert.True(t, NewFilter(nil, nil, []string{"0xABCDEFABCDEFABCDEFABCDEFABCDEFABCDEFABCD"}).Match(taker))
+	assert.False(t, NewFilter(nil, []string{testOther + ":"}, nil).Match(taker))
+}
+
+func TestDispatchFiltered(t *testing.T) {
+	h, store := newTestHub(t, Config{})
+	sub, err := h.Subscribe(testContext(), NewFilter([]string{testCollection}, nil, nil), 0)
+	assert.NoError(t, err)
+
+	publish(t, store, &marketfeed.Event{CollectionAddress: testOther})
+	want := publish(t, store, &marketfeed.Event{CollectionAddress: testCollection})
+	publish(t, store, &marketfeed.Event{CollectionAddress: testOther})
+	h.poll()
+
+	event := receive(t, sub)
+	assert.Equal(t, want, event.ID)
+	assert.Equal(t, testCollection, event.CollectionAddress)
+	select {
+	case event := <-sub.Events():
+		t.Fatalf("unexpected event %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSlowSubscriberCatchUp(t *testing.T) {
+	h, store := newTestHub(t, Config{})
+	slow, err := h.Subscribe(testContext(), NewFilter(nil, nil, nil), 0)
+	assert.NoError(t, err)
+	fast, err := h.Subscribe(testContext(), NewFilter(nil, nil, nil), 0)
+	assert.NoError(t, err)
+
+	// 消费快的订阅者持续读取, 不受慢订阅者影响
+	const count = liveBufferSize + 50
+	received := make(chan []uint64)
+	go func() {
+		var ids []uint64
+		for len(ids) < count {
+			event, ok := <-fast.Events()
+			if !ok {
+				break
+			}
+			ids = append(ids, event.ID)
+		}
+		received <- ids
+	}()
+
+	for i := 0; i < count; i++ {
+		publish(t, store, &marketfeed.Event{CollectionAddress: testCollection})
+	}
+	h.poll()
+
+	// 缓冲写满后移出 Hub, Hub 不会因慢订阅者阻塞
+	h.mu.Lock()
+	_, registered := h.subs[slow]
+	h.mu.Unlock()
+	assert.False(t, registered)
+
+	var fastIDs []uint64
+	select {
+	case fastIDs = <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for fast subscriber")
+	}
+
+	// 慢订阅者从 Redis 追赶, 事件不丢失且不重复
+	var slowIDs []uint64
+	for len(slowIDs) < count {
+		slowIDs = append(slowIDs, receive(t, slow).ID)
+	}
+	assert.Len(t, fastIDs, count)
+	for i := 0; i < len(fastIDs); i++ {
+		assert.Equal(t, uint64(i+1), fastIDs[i])
+		assert.Equal(t, uint64(i+1), slowIDs[i])
+	}
+
+	// 追赶完成后重新登记, 继续接收实时事件
+	assert.Eventually(t, func() bool { return subscriberCount(h) == 2 }, 2*time.Second, 10*time.Millisecond)
+	id := publish(t, store, &marketfeed.Event{CollectionAddress: testCollection})
+	h.poll()
+	assert.Equal(t, id, receive(t, slow).ID)
+}
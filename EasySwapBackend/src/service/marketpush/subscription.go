@@ -0,0 +1,126 @@
+package marketpush
+
+import (
+	"context"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/marketfeed"
+	"go.uber.org/zap"
+)
+
+// Subscription 单个客户端的订阅, 先从 Redis 补发游标之后的事件, 再接收 Hub 分发的实时事件
+type Subscription struct {
+	hub    *Hub
+	filter Filter
+	start  uint64 // 订阅开始时的游标
+	cursor uint64 // 已推送给客户端的最新事件编号
+
+	live     chan *marketfeed.Event
+	overflow chan struct{} // 实时缓冲写满时由 Hub 关闭
+	out      chan *marketfeed.Event
+}
+
+// Subscribe 创建订阅, cursor 为 0 时只推送之后的新事件, 否则从 cursor 之后续传
+// 订阅在 ctx 取消后结束, 并关闭 Events 返回的通道
+func (h *Hub) Subscribe(ctx context.Context, filter Filter, cursor uint64) (*Subscription, error) {
+	sub := &Subscription{
+		hub:    h,
+		filter: filter,
+		out:    make(chan *marketfeed.Event),
+	}
+	last, err := h.register(sub)
+	if err != nil {
+		return nil, err
+	}
+	if cursor == 0 || cursor > last {
+		cursor = last
+	}
+	sub.start, sub.cursor = cursor, cursor
+
+	go sub.run(ctx, last)
+	return sub, nil
+}
+
+// Start 返回订阅开始时的游标, 客户端在收到事件前断开时可用于续传
+func (s *Subscription) Start() uint64 {
+	return s.start
+}
+
+// Events 返回推送给客户端的事件, Type 为 TypeReset 时表示中间有事件丢失
+func (s *Subscription) Events() <-chan *marketfeed.Event {
+	return s.out
+}
+
+func (s *Subscription) run(ctx context.Context, liveFrom uint64) {
+	defer close(s.out)
+	defer s.hub.unregister(s)
+
+	for {
+		if !s.catchUp(ctx, liveFrom) {
+			return
+		}
+
+		overflowed := false
+		for !overflowed {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.overflow:
+				overflowed = true
+			case event := <-s.live:
+				if event.ID <= s.cursor {
+					continue
+				}
+				if !s.send(ctx, event) {
+					return
+				}
+			}
+		}
+
+		// 客户端消费过慢, 重新登记后从 Redis 追赶, 缓冲中的事件由追赶过程补发
+		var err error
+		if liveFrom, err = s.hub.register(s); err != nil {
+			xzap.WithContext(ctx).Warn("failed on resubscribe market events", zap.Error(err), zap.String("chain", s.hub.chain))
+			return
+		}
+	}
+}
+
+// catchUp 从 Redis 补发 cursor 到 liveFrom 之间的事件
+func (s *Subscription) catchUp(ctx context.Context, liveFrom uint64) bool {
+	for s.cursor < liveFrom {
+		events, gap, err := marketfeed.Read(s.hub.kv, s.hub.chain, s.cursor, readBatchSize)
+		if err != nil {
+			xzap.WithContext(ctx).Warn("failed on read market events", zap.Error(err), zap.String("chain", s.hub.chain))
+			return false
+		}
+		if len(events) == 0 {
+			return true
+		}
+		if gap && !s.send(ctx, &marketfeed.Event{ID: events[0].ID - 1, Type: TypeReset, Chain: s.hub.chain}) {
+			return false
+		}
+
+		for _, event := range events {
+			s.cursor = event.ID
+			if !s.filter.Match(event) {
+				continue
+			}
+			if !s.send(ctx, event) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (s *Subscription) send(ctx context.Context, event *marketfeed.Event) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case s.out <- event:
+		s.cursor = event.ID
+		return true
+	}
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
 
 	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/refreshqueue"
 	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -14,10 +14,9 @@ import (
 	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
 )
 
-const CacheRefreshSingleItemMetadataKey = "cache:%s:%s:item:refresh:metadata"
-
+// GetRefreshSingleItemMetadataKey 元数据刷新队列, 由 EasySwapSync 的元数据刷新任务消费
 func GetRefreshSingleItemMetadataKey(project, chain string) string {
-	return fmt.Sprintf(CacheRefreshSingleItemMetadataKey, strings.ToLower(project), strings.ToLower(chain))
+	return refreshqueue.Key(project, chain)
 }
 
 const CacheRefreshPreventReentrancyKeyPrefix = "cache:orderbookdex:item:refresh:prevent:reentrancy:%d:%s:%s"
@@ -2,19 +2,34 @@ package svc
 
 import (
 	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ProjectsTask/EasySwapBase/chain/chainclient"
 	"github.com/ProjectsTask/EasySwapBase/chain/nftchainservice"
+	"github.com/ProjectsTask/EasySwapBase/chain/signer"
 	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
 	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
+	"github.com/ProjectsTask/EasySwapBase/stores/ipfs"
+	"github.com/ProjectsTask/EasySwapBase/stores/objstore"
 	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/pkg/errors"
 	"github.com/zeromicro/go-zero/core/stores/cache"
 	"github.com/zeromicro/go-zero/core/stores/kv"
 	"github.com/zeromicro/go-zero/core/stores/redis"
 	"gorm.io/gorm"
 
+	"github.com/ProjectsTask/EasySwapBackend/src/common/session"
 	"github.com/ProjectsTask/EasySwapBackend/src/config"
 	"github.com/ProjectsTask/EasySwapBackend/src/dao"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/alert"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/marketpush"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/txmanager"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/webhook"
 )
 
 type ServerCtx struct {
@@ -23,8 +38,23 @@ type ServerCtx struct {
 	//ImageMgr image.ImageManager
 	Dao      *dao.Dao
 	KvStore  *xkv.Store
+	Sessions *session.Manager
 	RankKey  string
 	NodeSrvs map[int64]*nftchainservice.Service
+	// Storage 用户上传文件的对象存储, 未配置时为 nil
+	Storage objstore.Storage
+	// IPFS 铸造媒体文件与元数据的 IPFS 节点, 未配置时为 nil
+	IPFS *ipfs.Client
+	// Signer 服务端交易签名器, 未配置时为 nil
+	Signer signer.Signer
+	// TxManagers 按链ID管理 Signer 发送的交易
+	TxManagers map[int64]*txmanager.Manager
+	// MarketHubs 按链ID分发订单簿与活动实时事件
+	MarketHubs map[int64]*marketpush.Hub
+	// Webhooks 市场事件 webhook 推送, 未开启时为 nil
+	Webhooks *webhook.Service
+	// Alerts 价格提醒规则评估, 未开启时为 nil
+	Alerts *alert.Service
 }
 
 func NewServiceContext(c *config.Config) (*ServerCtx, error) {
@@ -62,7 +92,16 @@ func NewServiceContext(c *config.Config) (*ServerCtx, error) {
 
 	nodeSrvs := make(map[int64]*nftchainservice.Service)
 	for _, supported := range c.ChainSupported {
-		nodeSrvs[int64(supported.ChainID)], err = nftchainservice.New(context.Background(), supported.Endpoint, supported.Name, supported.ChainID,
+		endpoints := supported.Endpoints
+		if len(endpoints) == 0 {
+			endpoints = []string{supported.Endpoint}
+		}
+		nodeClient, err := chainclient.NewWithEndpoints(context.Background(), supported.ChainID, endpoints, chainclient.PoolConfig{})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on create node client")
+		}
+
+		nodeSrvs[int64(supported.ChainID)], err = nftchainservice.NewWithNodeClient(context.Background(), nodeClient, supported.Name,
 			c.MetadataParse.NameTags, c.MetadataParse.ImageTags, c.MetadataParse.AttributesTags,
 			c.MetadataParse.TraitNameTags, c.MetadataParse.TraitValueTags)
 
@@ -80,7 +119,245 @@ func NewServiceContext(c *config.Config) (*ServerCtx, error) {
 	)
 	serverCtx.C = c
 
+	sessions, err := newSessionManager(c, store)
+	if err != nil {
+		return nil, err
+	}
+	serverCtx.Sessions = sessions
+
 	serverCtx.NodeSrvs = nodeSrvs
 
+	storage, err := newStorage(c)
+	if err != nil {
+		return nil, err
+	}
+	serverCtx.Storage = storage
+
+	if c.IPFS != nil && c.IPFS.APIURL != "" {
+		serverCtx.IPFS, err = ipfs.NewClient(ipfs.Config{
+			APIURL:  c.IPFS.APIURL,
+			Headers: c.IPFS.Headers,
+			Timeout: time.Duration(c.IPFS.Timeout) * time.Second,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on create ipfs client")
+		}
+	}
+
+	txSigner, err := newSigner(c)
+	if err != nil {
+		return nil, err
+	}
+	serverCtx.Signer = txSigner
+
+	txManagers, err := newTxManagers(c, db, txSigner)
+	if err != nil {
+		return nil, err
+	}
+	serverCtx.TxManagers = txManagers
+
+	marketHubs, err := newMarketHubs(c, store)
+	if err != nil {
+		return nil, err
+	}
+	serverCtx.MarketHubs = marketHubs
+
+	chains := make(map[string]int64)
+	for _, supported := range c.ChainSupported {
+		chains[supported.Name] = int64(supported.ChainID)
+	}
+	if c.Webhook != nil && c.Webhook.Enabled {
+		serverCtx.Webhooks = webhook.New(context.Background(), db, store, chains, webhook.Config{
+			PollInterval: time.Duration(c.Webhook.PollInterval) * time.Millisecond,
+			Workers:      c.Webhook.Workers,
+			MaxAttempts:  c.Webhook.MaxAttempts,
+			Timeout:      time.Duration(c.Webhook.Timeout) * time.Second,
+		})
+	}
+	if c.Alert != nil && c.Alert.Enabled {
+		serverCtx.Alerts = newAlerts(c, db, store, chains)
+	}
+
 	return serverCtx, nil
 }
+
+// newStorage 创建 COS 对象存储, 通过 S3 兼容接口访问, COS 存储桶名称为 <bucket>-<appid>
+func newStorage(c *config.Config) (objstore.Storage, error) {
+	if c.COS == nil || c.COS.SecretID == "" {
+		return nil, nil
+	}
+
+	bucket := c.COS.Bucket
+	if c.COS.AppID != "" && !strings.HasSuffix(bucket, "-"+c.COS.AppID) {
+		bucket = bucket + "-" + c.COS.AppID
+	}
+	endpoint := c.COS.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://cos.%s.myqcloud.com", c.COS.Region)
+	}
+
+	storage, err := objstore.NewS3(objstore.S3Config{
+		Endpoint:  endpoint,
+		Region:    c.COS.Region,
+		Bucket:    bucket,
+		AccessKey: c.COS.SecretID,
+		SecretKey: c.COS.SecretKey,
+		PathStyle: c.COS.PathStyle,
+		PublicURL: c.COS.Domain,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on create object storage")
+	}
+
+	return storage, nil
+}
+
+// newSigner 创建服务端交易签名器, 优先使用 metanode.signer
+// owner_private_key 仅在 dev_private_key 显式开启时用于本地开发, 否则拒绝启动
+func newSigner(c *config.Config) (signer.Signer, error) {
+	if c.MetaNode == nil {
+		return nil, nil
+	}
+
+	if sc := c.MetaNode.Signer; sc != nil {
+		s, err := signer.New(context.Background(), signer.Config{
+			Type:         sc.Type,
+			KeyEnv:       sc.KeyEnv,
+			KeystoreFile: sc.KeystoreFile,
+			PasswordFile: sc.PasswordFile,
+			PasswordEnv:  sc.PasswordEnv,
+			RemoteURL:    sc.RemoteURL,
+			Address:      sc.Address,
+			Headers:      sc.RemoteHeaders,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on create signer")
+		}
+		return s, nil
+	}
+
+	if c.MetaNode.OwnerPrivateKey == "" {
+		return nil, nil
+	}
+	if !c.MetaNode.DevPrivateKey {
+		return nil, errors.New("metanode.owner_private_key is for local development only, configure metanode.signer or set metanode.dev_private_key = true")
+	}
+	xzap.WithContext(context.Background()).Warn("metanode.owner_private_key is for local development only, use metanode.signer in production")
+	s, err := signer.NewPrivateKeySigner(c.MetaNode.OwnerPrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on create signer")
+	}
+
+	return s, nil
+}
+
+// newTxManagers 为 metanode 配置了 RPC 端点的每条链创建交易管理器, 共用同一签名器
+func newTxManagers(c *config.Config, db *gorm.DB, txSigner signer.Signer) (map[int64]*txmanager.Manager, error) {
+	managers := make(map[int64]*txmanager.Manager)
+	if txSigner == nil {
+		return managers, nil
+	}
+
+	conf := txmanager.Config{GasLimit: c.MetaNode.GasLimit}
+	if c.MetaNode.GasPrice != "" {
+		gasPrice, ok := new(big.Int).SetString(c.MetaNode.GasPrice, 10)
+		if !ok {
+			return nil, errors.New("invalid metanode gas price format")
+		}
+		conf.GasPrice = gasPrice
+	}
+	if tm := c.MetaNode.TxManager; tm != nil {
+		conf.PollInterval = time.Duration(tm.PollInterval) * time.Second
+		conf.StuckTimeout = time.Duration(tm.StuckTimeout) * time.Second
+		conf.MaxAttempts = tm.MaxAttempts
+		conf.FeeBumpPercent = tm.FeeBumpPercent
+	}
+
+	for chainIDStr, endpoint := range c.MetaNode.RPCEndpoints {
+		if endpoint == "" {
+			continue
+		}
+		chainID, err := strconv.ParseInt(chainIDStr, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid metanode chain id %s", chainIDStr)
+		}
+		client, err := ethclient.Dial(endpoint)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed on dial rpc endpoint for chain %d", chainID)
+		}
+		managers[chainID], err = txmanager.New(context.Background(), db, chainID, client, txSigner, conf)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed on create tx manager for chain %d", chainID)
+		}
+	}
+
+	return managers, nil
+}
+
+// newMarketHubs 为每条支持的链创建实时事件分发器, 事件由同步服务写入 Redis
+func newMarketHubs(c *config.Config, store *xkv.Store) (map[int64]*marketpush.Hub, error) {
+	var conf marketpush.Config
+	if c.Push != nil {
+		conf.PollInterval = time.Duration(c.Push.PollInterval) * time.Millisecond
+		conf.MaxSubscribers = c.Push.MaxSubscribers
+	}
+
+	hubs := make(map[int64]*marketpush.Hub)
+	for _, supported := range c.ChainSupported {
+		hub, err := marketpush.NewHub(context.Background(), store, supported.Name, conf)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed on create market hub for chain %d", supported.ChainID)
+		}
+		hubs[int64(supported.ChainID)] = hub
+	}
+
+	return hubs, nil
+}
+
+// 未配置 alert.webhook_timeout 时 webhook 渠道的默认请求超时
+const defaultAlertWebhookTimeout = 10 * time.Second
+
+// newAlerts 创建价格提醒服务, webhook 渠道始终可用, 配置 smtp 后支持邮件
+func newAlerts(c *config.Config, db *gorm.DB, store *xkv.Store, chains map[string]int64) *alert.Service {
+	timeout := defaultAlertWebhookTimeout
+	if c.Alert.WebhookTimeout > 0 {
+		timeout = time.Duration(c.Alert.WebhookTimeout) * time.Second
+	}
+	channels := []alert.Channel{alert.NewWebhookChannel(timeout)}
+	if c.Alert.SMTP != nil && c.Alert.SMTP.Host != "" {
+		channels = append(channels, alert.NewEmailChannel(alert.SMTPConfig{
+			Host:     c.Alert.SMTP.Host,
+			Port:     c.Alert.SMTP.Port,
+			Username: c.Alert.SMTP.Username,
+			Password: c.Alert.SMTP.Password,
+			From:     c.Alert.SMTP.From,
+		}))
+	}
+
+	return alert.New(context.Background(), db, store, chains, alert.Config{
+		PollInterval: time.Duration(c.Alert.PollInterval) * time.Millisecond,
+	}, channels...)
+}
+
+// 未配置 session_ttl 时的默认会话有效期
+const defaultSessionTTL = 30 * 24 * time.Hour
+
+// sessionSecretMinLength 会话签名密钥的最小长度
+const sessionSecretMinLength = 32
+
+// newSessionManager 创建会话管理器, 签名密钥必须配置: 随机密钥会在重启后使全部会话失效, 且多实例间无法互认
+func newSessionManager(c *config.Config, store *xkv.Store) (*session.Manager, error) {
+	if c.Login == nil || c.Login.SessionSecret == "" {
+		return nil, errors.New("login session_secret not configured")
+	}
+	if len(c.Login.SessionSecret) < sessionSecretMinLength {
+		return nil, errors.New(fmt.Sprintf("login session_secret must be at least %d characters", sessionSecretMinLength))
+	}
+	secret := []byte(c.Login.SessionSecret)
+	ttl := defaultSessionTTL
+	if c.Login.SessionTTL > 0 {
+		ttl = time.Duration(c.Login.SessionTTL) * time.Second
+	}
+
+	return session.NewManager(store, secret, ttl), nil
+}
@@ -0,0 +1,40 @@
+package svc
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/config"
+)
+
+func TestNewSessionManagerRequiresSecret(t *testing.T) {
+	for name, login := range map[string]*config.LoginConfig{
+		"no login config": nil,
+		"empty secret":    {},
+		"short secret":    {SessionSecret: "secret"},
+	} {
+		_, err := newSessionManager(&config.Config{Login: login}, nil)
+		assert.Error(t, err, name)
+	}
+
+	sessions, err := newSessionManager(&config.Config{Login: &config.LoginConfig{SessionSecret: "0123456789abcdef0123456789abcdef"}}, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, sessions)
+}
+
+func TestNewSignerRequiresDevFlagForPrivateKey(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	metaNode := &config.MetaNodeConfig{OwnerPrivateKey: common.Bytes2Hex(crypto.FromECDSA(key))}
+
+	s, err := newSigner(&config.Config{MetaNode: &config.MetaNodeConfig{}})
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+
+	// 未显式开启时拒绝使用配置中的私钥
+	_, err = newSigner(&config.Config{MetaNode: metaNode})
+	assert.Error(t, err)
+}
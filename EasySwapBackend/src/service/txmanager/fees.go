@@ -0,0 +1,152 @@
+package txmanager
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// minFeeBumpPercent 节点接受同 nonce 替换交易要求的最低涨幅
+const minFeeBumpPercent = 10
+
+// fees 交易费用, gasPrice 不为 nil 时为传统交易, 否则为 EIP-1559 交易
+type fees struct {
+	gasPrice  *big.Int
+	gasFeeCap *big.Int
+	gasTipCap *big.Int
+}
+
+func (f *fees) legacy() bool {
+	return f.gasPrice != nil
+}
+
+// feeCap 交易愿意支付的最高单价, 用于记录与日志
+func (f *fees) feeCap() *big.Int {
+	if f.legacy() {
+		return f.gasPrice
+	}
+
+	return f.gasFeeCap
+}
+
+// suggestFees 估算费用: 配置了固定 gas price 或链不支持 EIP-1559 时使用传统交易,
+// 否则 maxFee = 2 * baseFee + tip, 可承受连续多个区块的 baseFee 上涨
+func (m *Manager) suggestFees(ctx context.Context) (*fees, error) {
+	if m.conf.GasPrice != nil {
+		return &fees{gasPrice: new(big.Int).Set(m.conf.GasPrice)}, nil
+	}
+
+	head, err := m.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get latest header")
+	}
+	if head.BaseFee == nil {
+		gasPrice, err := m.client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on suggest gas price")
+		}
+		return &fees{gasPrice: gasPrice}, nil
+	}
+
+	tip, err := m.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on suggest gas tip cap")
+	}
+	feeCap := new(big.Int).Mul(head.BaseFee, big.NewInt(2))
+	feeCap.Add(feeCap, tip)
+
+	return &fees{gasFeeCap: feeCap, gasTipCap: tip}, nil
+}
+
+// bumpFees 计算替换交易的费用: 在原费用基础上至少上涨 bumpPercent, 且不低于当前估算值
+// 替换交易的类型与原交易保持一致
+func bumpFees(prev, suggested *fees, bumpPercent int) *fees {
+	if prev.legacy() {
+		price := suggested.feeCap()
+		return &fees{gasPrice: maxBig(bump(prev.gasPrice, bumpPercent), price)}
+	}
+
+	tip := suggested.gasTipCap
+	if tip == nil { // 当前估算为传统交易, 以 gas price 作为 tip 上限参考
+		tip = suggested.gasPrice
+	}
+	newTip := maxBig(bump(prev.gasTipCap, bumpPercent), tip)
+	newFeeCap := maxBig(bump(prev.gasFeeCap, bumpPercent), suggested.feeCap())
+	if newFeeCap.Cmp(newTip) < 0 {
+		newFeeCap = new(big.Int).Set(newTip)
+	}
+
+	return &fees{gasFeeCap: newFeeCap, gasTipCap: newTip}
+}
+
+func bump(v *big.Int, percent int) *big.Int {
+	bumped := new(big.Int).Mul(v, big.NewInt(int64(100+percent)))
+	bumped.Div(bumped, big.NewInt(100))
+	// 整数除法可能抹掉涨幅, 保证严格大于原值
+	if bumped.Cmp(v) <= 0 {
+		bumped.Add(v, big.NewInt(1))
+	}
+
+	return bumped
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if b != nil && b.Cmp(a) > 0 {
+		return new(big.Int).Set(b)
+	}
+
+	return new(big.Int).Set(a)
+}
+
+// newTx 按费用类型构造未签名交易
+func (m *Manager) newTx(nonce uint64, to common.Address, value *big.Int, gasLimit uint64, data []byte, f *fees) *types.Transaction {
+	if f.legacy() {
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			To:       &to,
+			Value:    value,
+			Gas:      gasLimit,
+			GasPrice: f.gasPrice,
+			Data:     data,
+		})
+	}
+
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   m.chainID,
+		Nonce:     nonce,
+		To:        &to,
+		Value:     value,
+		Gas:       gasLimit,
+		GasFeeCap: f.gasFeeCap,
+		GasTipCap: f.gasTipCap,
+		Data:      data,
+	})
+}
+
+// recordFees 从交易记录恢复费用
+func recordFees(gasFeeCap, gasTipCap string) (*fees, error) {
+	feeCap, ok := new(big.Int).SetString(gasFeeCap, 10)
+	if !ok {
+		return nil, errors.Errorf("invalid gas fee cap %q", gasFeeCap)
+	}
+	if gasTipCap == "" {
+		return &fees{gasPrice: feeCap}, nil
+	}
+	tipCap, ok := new(big.Int).SetString(gasTipCap, 10)
+	if !ok {
+		return nil, errors.Errorf("invalid gas tip cap %q", gasTipCap)
+	}
+
+	return &fees{gasFeeCap: feeCap, gasTipCap: tipCap}, nil
+}
+
+func (f *fees) tipCapString() string {
+	if f.legacy() {
+		return ""
+	}
+
+	return f.gasTipCap.String()
+}
@@ -0,0 +1,517 @@
+package txmanager
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/signer"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/zeromicro/go-zero/core/threading"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPollInterval   = 5 * time.Second
+	defaultStuckTimeout   = 3 * time.Minute
+	defaultMaxAttempts    = 5
+	defaultFeeBumpPercent = 15
+
+	cancelGasLimit        = 21000 // 取消交易为向自己转账 0, 只需基础 gas
+	waitPollInterval      = 2 * time.Second
+	maxErrorMsgLength     = 1024
+	replacedHashSeparator = ","
+)
+
+var (
+	ErrTxNotFound        = errors.New("transaction not found")
+	ErrTxPending         = errors.New("transaction is still pending")
+	ErrTxNotPending      = errors.New("transaction is not pending")
+	ErrRequestIDConflict = errors.New("request id already used with different transaction")
+)
+
+// Client 交易管理器使用的链上接口, *ethclient.Client 已实现
+type Client interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// Config 交易管理器配置, 零值字段使用默认值
+type Config struct {
+	PollInterval   time.Duration // 检查待确认交易的间隔
+	StuckTimeout   time.Duration // 广播后超过该时长未上链视为卡住, 自动加速
+	MaxAttempts    int           // 单笔交易的最大发送次数(含自动加速), 达到后只重新广播
+	FeeBumpPercent int           // 加速与取消时的费用涨幅(%), 不低于节点要求的 10%
+	GasLimit       uint64        // 默认 gas limit, 为 0 时估算
+	GasPrice       *big.Int      // 固定 gas price, 配置后使用传统交易
+}
+
+// SendRequest 发送交易请求
+type SendRequest struct {
+	RequestID string // 幂等ID, 同一调用方的同一ID只发送一次
+	Caller    string // 调用方, 不同调用方的请求ID互不影响
+	To        common.Address
+	Data      []byte
+	Value     *big.Int
+	GasLimit  uint64 // 为 0 时使用配置值或估算
+}
+
+// Manager 单个签名地址在单条链上的交易管理器
+//   - 串行分配 nonce, 并发请求不会使用同一 nonce
+//   - 交易签名后先落库再广播, 重启后重新广播未确认交易
+//   - 定时检查待确认交易, 卡住时以相同 nonce 提高费用替换
+//
+// 同一签名地址只能由一个进程管理, 多实例部署时需为每个实例配置不同的签名地址
+type Manager struct {
+	ctx     context.Context
+	db      *gorm.DB
+	client  Client
+	chainID *big.Int
+	signer  signer.Signer
+	from    common.Address
+	conf    Config
+
+	mu          sync.Mutex // 串行化 nonce 分配与交易发送
+	nonce       uint64     // 下一个可用 nonce
+	nonceSynced bool       // nonce 是否已与链上及本地记录同步
+}
+
+// New 创建交易管理器, 重新广播上次退出时未确认的交易并启动监控循环
+func New(ctx context.Context, db *gorm.DB, chainID int64, client Client, s signer.Signer, conf Config) (*Manager, error) {
+	if conf.PollInterval <= 0 {
+		conf.PollInterval = defaultPollInterval
+	}
+	if conf.StuckTimeout <= 0 {
+		conf.StuckTimeout = defaultStuckTimeout
+	}
+	if conf.MaxAttempts <= 0 {
+		conf.MaxAttempts = defaultMaxAttempts
+	}
+	if conf.FeeBumpPercent <= 0 {
+		conf.FeeBumpPercent = defaultFeeBumpPercent
+	}
+	if conf.FeeBumpPercent < minFeeBumpPercent {
+		conf.FeeBumpPercent = minFeeBumpPercent
+	}
+
+	m := &Manager{
+		ctx:     ctx,
+		db:      db,
+		client:  client,
+		chainID: big.NewInt(chainID),
+		signer:  s,
+		from:    s.Address(),
+		conf:    conf,
+	}
+
+	records, err := m.pendingRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		if err := m.rebroadcast(ctx, &records[i]); err != nil {
+			xzap.WithContext(ctx).Warn("failed on rebroadcast pending transaction",
+				zap.Error(err), zap.String("request_id", records[i].RequestId))
+		}
+	}
+
+	threading.GoSafe(m.loop)
+
+	return m, nil
+}
+
+// Address 签名地址
+func (m *Manager) Address() common.Address {
+	return m.from
+}
+
+// Send 签名并广播交易, 返回交易记录
+// 调用方的请求ID已存在时直接返回已有记录, 已有记录的目标地址、金额或调用数据不同时返回 ErrRequestIDConflict
+func (m *Manager) Send(ctx context.Context, req *SendRequest) (*base.ManagedTx, error) {
+	if req.RequestID == "" {
+		return nil, errors.New("request id is required")
+	}
+	value := req.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, err := m.Get(ctx, req.Caller, req.RequestID)
+	if err == nil {
+		if !sameRequest(record, req.To, value, req.Data) {
+			return nil, ErrRequestIDConflict
+		}
+		return record, nil
+	}
+	if !errors.Is(err, ErrTxNotFound) {
+		return nil, err
+	}
+
+	gasLimit := req.GasLimit
+	if gasLimit == 0 {
+		gasLimit = m.conf.GasLimit
+	}
+	if gasLimit == 0 {
+		to := req.To
+		if gasLimit, err = m.client.EstimateGas(ctx, ethereum.CallMsg{
+			From:  m.from,
+			To:    &to,
+			Value: value,
+			Data:  req.Data,
+		}); err != nil {
+			return nil, errors.Wrap(err, "failed on estimate gas")
+		}
+	}
+
+	f, err := m.suggestFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := m.nextNonce(ctx)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := m.signer.SignTx(ctx, m.newTx(nonce, req.To, value, gasLimit, req.Data, f), m.chainID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on sign transaction")
+	}
+	rawTx, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on encode transaction")
+	}
+
+	// 先落库再广播, 广播后进程退出也能在重启时继续跟踪
+	record = &base.ManagedTx{
+		RequestId: req.RequestID,
+		Caller:    req.Caller,
+		ChainId:   m.chainID.Int64(),
+		Signer:    m.from.Hex(),
+		ToAddress: req.To.Hex(),
+		Nonce:     nonce,
+		Value:     value.String(),
+		Data:      hexutil.Encode(req.Data),
+		GasLimit:  gasLimit,
+		GasFeeCap: f.feeCap().String(),
+		GasTipCap: f.tipCapString(),
+		TxHash:    signed.Hash().Hex(),
+		RawTx:     hexutil.Encode(rawTx),
+		Attempts:  1,
+		Status:    base.ManagedTxStatusPending,
+		SentTime:  time.Now().Unix(),
+	}
+	if err := m.db.WithContext(ctx).Table(base.ManagedTxTableName()).Create(record).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on create managed transaction")
+	}
+
+	if err := m.broadcast(ctx, signed); err != nil {
+		// 广播失败时 nonce 未被占用, 下次发送时重新同步
+		m.nonceSynced = false
+		m.markFailed(record, err)
+		return record, errors.Wrap(err, "failed on send transaction")
+	}
+	m.nonce = nonce + 1
+
+	xzap.WithContext(ctx).Info("managed transaction sent",
+		zap.String("request_id", record.RequestId),
+		zap.String("tx_hash", record.TxHash),
+		zap.Uint64("nonce", nonce),
+		zap.String("fee_cap", record.GasFeeCap))
+
+	return record, nil
+}
+
+// SpeedUp 以相同 nonce 提高费用重新发送待确认交易
+func (m *Manager) SpeedUp(ctx context.Context, caller, requestID string) (*base.ManagedTx, error) {
+	return m.replaceByID(ctx, caller, requestID, false)
+}
+
+// Cancel 以相同 nonce 发送向自己转账 0 的交易, 替换待确认交易
+func (m *Manager) Cancel(ctx context.Context, caller, requestID string) (*base.ManagedTx, error) {
+	return m.replaceByID(ctx, caller, requestID, true)
+}
+
+func (m *Manager) replaceByID(ctx context.Context, caller, requestID string, cancel bool) (*base.ManagedTx, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, err := m.Get(ctx, caller, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if !isPending(record.Status) {
+		return record, ErrTxNotPending
+	}
+	if err := m.replace(ctx, record, cancel); err != nil {
+		return record, err
+	}
+
+	return record, nil
+}
+
+// replace 以相同 nonce 重新签名并广播, 调用方需持有锁
+// 取消中的交易再次替换时仍为取消交易
+func (m *Manager) replace(ctx context.Context, record *base.ManagedTx, cancel bool) error {
+	prev, err := recordFees(record.GasFeeCap, record.GasTipCap)
+	if err != nil {
+		return err
+	}
+	suggested, err := m.suggestFees(ctx)
+	if err != nil {
+		return err
+	}
+	f := bumpFees(prev, suggested, m.conf.FeeBumpPercent)
+
+	to := common.HexToAddress(record.ToAddress)
+	value, ok := new(big.Int).SetString(record.Value, 10)
+	if !ok {
+		return errors.Errorf("invalid value %q", record.Value)
+	}
+	data, err := hexutil.Decode(record.Data)
+	if err != nil {
+		return errors.Wrap(err, "failed on decode transaction data")
+	}
+	gasLimit := record.GasLimit
+	status := record.Status
+	if cancel || record.Status == base.ManagedTxStatusCancelling {
+		to, value, data, gasLimit = m.from, big.NewInt(0), nil, cancelGasLimit
+		status = base.ManagedTxStatusCancelling
+	}
+
+	signed, err := m.signer.SignTx(ctx, m.newTx(record.Nonce, to, value, gasLimit, data, f), m.chainID)
+	if err != nil {
+		return errors.Wrap(err, "failed on sign transaction")
+	}
+	rawTx, err := signed.MarshalBinary()
+	if err != nil {
+		return errors.Wrap(err, "failed on encode transaction")
+	}
+	if err := m.broadcast(ctx, signed); err != nil {
+		return errors.Wrap(err, "failed on send replacement transaction")
+	}
+
+	replaced := record.TxHash
+	if record.ReplacedHashes != "" {
+		replaced = record.ReplacedHashes + replacedHashSeparator + replaced
+	}
+	updates := map[string]interface{}{
+		"tx_hash":         signed.Hash().Hex(),
+		"raw_tx":          hexutil.Encode(rawTx),
+		"replaced_hashes": replaced,
+		"gas_fee_cap":     f.feeCap().String(),
+		"gas_tip_cap":     f.tipCapString(),
+		"attempts":        record.Attempts + 1,
+		"status":          status,
+		"sent_time":       time.Now().Unix(),
+	}
+	if err := m.db.WithContext(m.ctx).Table(base.ManagedTxTableName()).
+		Where("id = ? and status in ?", record.Id, pendingStatuses).
+		Updates(updates).Error; err != nil {
+		return errors.Wrap(err, "failed on update managed transaction")
+	}
+
+	xzap.WithContext(ctx).Info("managed transaction replaced",
+		zap.String("request_id", record.RequestId),
+		zap.String("old_tx_hash", record.TxHash),
+		zap.String("tx_hash", signed.Hash().Hex()),
+		zap.Bool("cancel", status == base.ManagedTxStatusCancelling),
+		zap.String("fee_cap", f.feeCap().String()))
+
+	record.TxHash = signed.Hash().Hex()
+	record.RawTx = hexutil.Encode(rawTx)
+	record.ReplacedHashes = replaced
+	record.GasFeeCap = f.feeCap().String()
+	record.GasTipCap = f.tipCapString()
+	record.Attempts++
+	record.Status = status
+	record.SentTime = time.Now().Unix()
+
+	return nil
+}
+
+// Get 按调用方与请求ID查询交易记录
+func (m *Manager) Get(ctx context.Context, caller, requestID string) (*base.ManagedTx, error) {
+	return m.first(ctx, "chain_id = ? and signer = ? and caller = ? and request_id = ?",
+		m.chainID.Int64(), m.from.Hex(), caller, requestID)
+}
+
+// getByID 按记录ID查询交易记录
+func (m *Manager) getByID(ctx context.Context, id int64) (*base.ManagedTx, error) {
+	return m.first(ctx, "id = ?", id)
+}
+
+func (m *Manager) first(ctx context.Context, query string, args ...interface{}) (*base.ManagedTx, error) {
+	var record base.ManagedTx
+	err := m.db.WithContext(ctx).Table(base.ManagedTxTableName()).
+		Where(query, args...).
+		First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTxNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get managed transaction")
+	}
+
+	return &record, nil
+}
+
+// sameRequest 检查已有记录与请求的目标地址、金额和调用数据是否一致
+func sameRequest(record *base.ManagedTx, to common.Address, value *big.Int, data []byte) bool {
+	return strings.EqualFold(record.ToAddress, to.Hex()) &&
+		record.Value == value.String() &&
+		strings.EqualFold(record.Data, hexutil.Encode(data))
+}
+
+// Wait 等待交易上链并返回收据, 超时返回 ErrTxPending
+// 广播失败或 nonce 被占用的交易没有收据, 返回的收据为 nil
+func (m *Manager) Wait(ctx context.Context, caller, requestID string, timeout time.Duration) (*base.ManagedTx, *types.Receipt, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		record, err := m.Get(ctx, caller, requestID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil, ErrTxPending
+			}
+			return nil, nil, err
+		}
+
+		if !isPending(record.Status) {
+			if record.BlockNumber == 0 {
+				return record, nil, nil
+			}
+			receipt, err := m.client.TransactionReceipt(ctx, common.HexToHash(record.TxHash))
+			if err != nil {
+				return record, nil, errors.Wrap(err, "failed on get transaction receipt")
+			}
+			return record, receipt, nil
+		}
+
+		receipt, err := m.findReceipt(ctx, record)
+		if err == nil && receipt != nil {
+			m.finalize(record, receipt)
+			return record, receipt, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return record, nil, ErrTxPending
+		case <-ticker.C:
+		}
+	}
+}
+
+// nextNonce 返回下一个可用 nonce, 调用方需持有锁
+// 未同步时取链上 pending nonce 与本地未确认记录最大 nonce+1 的较大值:
+// 节点丢弃的交易仍以本地记录为准, 由监控循环重新广播
+func (m *Manager) nextNonce(ctx context.Context) (uint64, error) {
+	if m.nonceSynced {
+		return m.nonce, nil
+	}
+
+	nonce, err := m.client.PendingNonceAt(ctx, m.from)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on get pending nonce")
+	}
+	var maxNonce sql.NullInt64
+	if err := m.db.WithContext(ctx).Table(base.ManagedTxTableName()).
+		Where("chain_id = ? and signer = ? and status in ?", m.chainID.Int64(), m.from.Hex(), pendingStatuses).
+		Select("max(nonce)").Scan(&maxNonce).Error; err != nil {
+		return 0, errors.Wrap(err, "failed on get max pending nonce")
+	}
+	if maxNonce.Valid && uint64(maxNonce.Int64)+1 > nonce {
+		nonce = uint64(maxNonce.Int64) + 1
+	}
+
+	m.nonce = nonce
+	m.nonceSynced = true
+
+	return nonce, nil
+}
+
+// broadcast 广播交易, 节点已有该交易时视为成功
+func (m *Manager) broadcast(ctx context.Context, tx *types.Transaction) error {
+	err := m.client.SendTransaction(ctx, tx)
+	if err != nil && isKnownTxError(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (m *Manager) rebroadcast(ctx context.Context, record *base.ManagedTx) error {
+	raw, err := hexutil.Decode(record.RawTx)
+	if err != nil {
+		return errors.Wrap(err, "failed on decode raw transaction")
+	}
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return errors.Wrap(err, "failed on unmarshal raw transaction")
+	}
+
+	return m.broadcast(ctx, &tx)
+}
+
+func (m *Manager) markFailed(record *base.ManagedTx, cause error) {
+	msg := cause.Error()
+	if len(msg) > maxErrorMsgLength {
+		msg = msg[:maxErrorMsgLength]
+	}
+	if err := m.db.WithContext(m.ctx).Table(base.ManagedTxTableName()).
+		Where("id = ? and status in ?", record.Id, pendingStatuses).
+		Updates(map[string]interface{}{
+			"status":    base.ManagedTxStatusFailed,
+			"error_msg": msg,
+		}).Error; err != nil {
+		xzap.WithContext(m.ctx).Error("failed on mark managed transaction failed",
+			zap.Error(err), zap.String("request_id", record.RequestId))
+		return
+	}
+	record.Status = base.ManagedTxStatusFailed
+	record.ErrorMsg = msg
+}
+
+func (m *Manager) pendingRecords(ctx context.Context) ([]base.ManagedTx, error) {
+	var records []base.ManagedTx
+	if err := m.db.WithContext(ctx).Table(base.ManagedTxTableName()).
+		Where("chain_id = ? and signer = ? and status in ?", m.chainID.Int64(), m.from.Hex(), pendingStatuses).
+		Order("nonce asc").Find(&records).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on get pending managed transactions")
+	}
+
+	return records, nil
+}
+
+var pendingStatuses = []string{base.ManagedTxStatusPending, base.ManagedTxStatusCancelling}
+
+func isPending(status string) bool {
+	return status == base.ManagedTxStatusPending || status == base.ManagedTxStatusCancelling
+}
+
+func isKnownTxError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction")
+}
@@ -0,0 +1,209 @@
+package txmanager
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/signer"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	testChainID  = 11155111
+	testNonce    = 7 // 链上已确认的 nonce
+	testGasPrice = 1000000000
+)
+
+var testContract = common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+// fakeClient 记录广播的交易, 所有交易都未上链
+type fakeClient struct {
+	mu   sync.Mutex
+	sent []*types.Transaction
+}
+
+func (c *fakeClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{}, nil
+}
+
+func (c *fakeClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(testGasPrice), nil
+}
+
+func (c *fakeClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (c *fakeClient) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return 100000, nil
+}
+
+func (c *fakeClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return testNonce, nil
+}
+
+func (c *fakeClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return testNonce, nil
+}
+
+func (c *fakeClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, tx)
+	return nil
+}
+
+func (c *fakeClient) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	return nil, false, ethereum.NotFound
+}
+
+func (c *fakeClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return nil, ethereum.NotFound
+}
+
+func (c *fakeClient) sentTxs() []*types.Transaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*types.Transaction(nil), c.sent...)
+}
+
+func testContext() context.Context {
+	return xzap.ToContext(context.Background(), zap.NewNop())
+}
+
+func newTestManager(t *testing.T) (*Manager, *fakeClient, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "tx.db")), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Table(base.ManagedTxTableName()).AutoMigrate(&base.ManagedTx{}))
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	s, err := signer.NewPrivateKeySigner(common.Bytes2Hex(crypto.FromECDSA(key)))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(testContext())
+	t.Cleanup(cancel)
+	client := &fakeClient{}
+	// 监控循环不会在测试期间触发, 由测试直接调用 checkPending
+	m, err := New(ctx, db, testChainID, client, s, Config{
+		PollInterval: time.Hour,
+		StuckTimeout: time.Minute,
+		GasLimit:     100000,
+		GasPrice:     big.NewInt(testGasPrice),
+	})
+	assert.NoError(t, err)
+
+	return m, client, db
+}
+
+func TestSendConcurrentNonces(t *testing.T) {
+	m, client, _ := newTestManager(t)
+
+	const count = 20
+	var wg sync.WaitGroup
+	errs := make([]error, count)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = m.Send(testContext(), &SendRequest{
+				RequestID: fmt.Sprintf("req-%d", i),
+				Caller:    "test",
+				To:        testContract,
+				Data:      []byte{byte(i)},
+			})
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+
+	sent := client.sentTxs()
+	assert.Len(t, sent, count)
+	nonces := make(map[uint64]bool)
+	for _, tx := range sent {
+		assert.False(t, nonces[tx.Nonce()], "duplicate nonce %d", tx.Nonce())
+		nonces[tx.Nonce()] = true
+	}
+	for nonce := uint64(testNonce); nonce < testNonce+count; nonce++ {
+		assert.True(t, nonces[nonce], "missing nonce %d", nonce)
+	}
+}
+
+func TestSendRequestIDScope(t *testing.T) {
+	ctx := testContext()
+	m, client, _ := newTestManager(t)
+	req := &SendRequest{RequestID: "req-1", Caller: "0xaaaa", To: testContract, Data: []byte{1}}
+
+	first, err := m.Send(ctx, req)
+	assert.NoError(t, err)
+
+	// 相同请求重复发送时返回已有记录
+	again, err := m.Send(ctx, &SendRequest{RequestID: "req-1", Caller: "0xaaaa", To: testContract, Data: []byte{1}})
+	assert.NoError(t, err)
+	assert.Equal(t, first.Id, again.Id)
+	assert.Len(t, client.sentTxs(), 1)
+
+	// 相同请求ID但调用数据或金额不同时拒绝
+	_, err = m.Send(ctx, &SendRequest{RequestID: "req-1", Caller: "0xaaaa", To: testContract, Data: []byte{2}})
+	assert.ErrorIs(t, err, ErrRequestIDConflict)
+	_, err = m.Send(ctx, &SendRequest{RequestID: "req-1", Caller: "0xaaaa", To: testContract, Data: []byte{1}, Value: big.NewInt(1)})
+	assert.ErrorIs(t, err, ErrRequestIDConflict)
+	assert.Len(t, client.sentTxs(), 1)
+
+	// 其他调用方使用相同请求ID时发送新交易
+	other, err := m.Send(ctx, &SendRequest{RequestID: "req-1", Caller: "0xbbbb", To: testContract, Data: []byte{2}})
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.Id, other.Id)
+	assert.Equal(t, first.Nonce+1, other.Nonce)
+
+	record, err := m.Get(ctx, "0xaaaa", "req-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x01", record.Data)
+	_, err = m.Get(ctx, "0xcccc", "req-1")
+	assert.ErrorIs(t, err, ErrTxNotFound)
+}
+
+func TestCheckPendingReplacesStuckTx(t *testing.T) {
+	ctx := testContext()
+	m, client, db := newTestManager(t)
+
+	record, err := m.Send(ctx, &SendRequest{RequestID: "req-1", Caller: "test", To: testContract, Data: []byte{1}})
+	assert.NoError(t, err)
+
+	// 未超过 StuckTimeout 时不替换
+	m.checkPending()
+	assert.Len(t, client.sentTxs(), 1)
+
+	assert.NoError(t, db.Table(base.ManagedTxTableName()).Where("id = ?", record.Id).
+		Update("sent_time", time.Now().Add(-2*time.Minute).Unix()).Error)
+	m.checkPending()
+
+	sent := client.sentTxs()
+	assert.Len(t, sent, 2)
+	assert.Equal(t, sent[0].Nonce(), sent[1].Nonce())
+	assert.Equal(t, 1, sent[1].GasPrice().Cmp(sent[0].GasPrice()))
+	assert.Equal(t, sent[0].Data(), sent[1].Data())
+
+	replaced, err := m.Get(ctx, "test", "req-1")
+	assert.NoError(t, err)
+	assert.Equal(t, base.ManagedTxStatusPending, replaced.Status)
+	assert.Equal(t, 2, replaced.Attempts)
+	assert.Equal(t, sent[1].Hash().Hex(), replaced.TxHash)
+	assert.Equal(t, sent[0].Hash().Hex(), replaced.ReplacedHashes)
+}
@@ -0,0 +1,155 @@
+package txmanager
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// loop 定时检查待确认交易, 直到上下文取消
+func (m *Manager) loop() {
+	ticker := time.NewTicker(m.conf.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkPending()
+		}
+	}
+}
+
+// checkPending 按 nonce 顺序检查待确认交易:
+//  1. 任一历史交易哈希有收据时更新为最终状态
+//  2. nonce 已被链上确认但没有收据时, 说明被其他交易占用, 标记为失败
+//  3. 超过 StuckTimeout 未上链时提高费用替换, 达到 MaxAttempts 后只重新广播
+func (m *Manager) checkPending() {
+	records, err := m.pendingRecords(m.ctx)
+	if err != nil {
+		xzap.WithContext(m.ctx).Error("failed on get pending managed transactions", zap.Error(err))
+		return
+	}
+	if len(records) == 0 {
+		return
+	}
+
+	confirmedNonce, err := m.client.NonceAt(m.ctx, m.from, nil)
+	if err != nil {
+		xzap.WithContext(m.ctx).Error("failed on get confirmed nonce", zap.Error(err))
+		return
+	}
+
+	for i := range records {
+		record := &records[i]
+		receipt, err := m.findReceipt(m.ctx, record)
+		if err != nil {
+			xzap.WithContext(m.ctx).Warn("failed on get transaction receipt",
+				zap.Error(err), zap.String("request_id", record.RequestId))
+			continue
+		}
+		if receipt != nil {
+			m.finalize(record, receipt)
+			continue
+		}
+		if record.Nonce < confirmedNonce {
+			m.markFailed(record, errors.New("nonce consumed by another transaction"))
+			continue
+		}
+		if time.Since(time.Unix(record.SentTime, 0)) < m.conf.StuckTimeout {
+			continue
+		}
+
+		if err := m.unstick(record.Id); err != nil {
+			xzap.WithContext(m.ctx).Warn("failed on replace stuck transaction",
+				zap.Error(err), zap.String("request_id", record.RequestId))
+		}
+	}
+}
+
+// unstick 加速卡住的交易, 重新读取记录以免覆盖期间发生的手动加速或取消
+func (m *Manager) unstick(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, err := m.getByID(m.ctx, id)
+	if err != nil {
+		return err
+	}
+	if !isPending(record.Status) || time.Since(time.Unix(record.SentTime, 0)) < m.conf.StuckTimeout {
+		return nil
+	}
+	if record.Attempts >= m.conf.MaxAttempts {
+		return m.rebroadcast(m.ctx, record)
+	}
+
+	return m.replace(m.ctx, record, false)
+}
+
+// findReceipt 依次查询当前与被替换的交易哈希, 同一 nonce 最多只有一笔上链
+func (m *Manager) findReceipt(ctx context.Context, record *base.ManagedTx) (*types.Receipt, error) {
+	hashes := []string{record.TxHash}
+	if record.ReplacedHashes != "" {
+		hashes = append(hashes, strings.Split(record.ReplacedHashes, replacedHashSeparator)...)
+	}
+
+	for _, hash := range hashes {
+		receipt, err := m.client.TransactionReceipt(ctx, common.HexToHash(hash))
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return receipt, nil
+	}
+
+	return nil, nil
+}
+
+// finalize 根据收据更新交易的最终状态, 上链的是取消交易时状态为 cancelled
+func (m *Manager) finalize(record *base.ManagedTx, receipt *types.Receipt) {
+	status := base.ManagedTxStatusConfirmed
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		status = base.ManagedTxStatusFailed
+	}
+	if record.Status == base.ManagedTxStatusCancelling && !strings.EqualFold(record.ToAddress, m.from.Hex()) {
+		tx, _, err := m.client.TransactionByHash(m.ctx, receipt.TxHash)
+		if err == nil && tx.To() != nil && *tx.To() == m.from {
+			status = base.ManagedTxStatusCancelled
+		}
+	}
+
+	updates := map[string]interface{}{
+		"status":       status,
+		"tx_hash":      receipt.TxHash.Hex(),
+		"block_number": receipt.BlockNumber.Int64(),
+		"gas_used":     receipt.GasUsed,
+	}
+	if err := m.db.WithContext(m.ctx).Table(base.ManagedTxTableName()).
+		Where("id = ? and status in ?", record.Id, pendingStatuses).
+		Updates(updates).Error; err != nil {
+		xzap.WithContext(m.ctx).Error("failed on update managed transaction status",
+			zap.Error(err), zap.String("request_id", record.RequestId))
+		return
+	}
+
+	record.Status = status
+	record.TxHash = receipt.TxHash.Hex()
+	record.BlockNumber = receipt.BlockNumber.Int64()
+	record.GasUsed = receipt.GasUsed
+
+	xzap.WithContext(m.ctx).Info("managed transaction finalized",
+		zap.String("request_id", record.RequestId),
+		zap.String("tx_hash", record.TxHash),
+		zap.String("status", status))
+}
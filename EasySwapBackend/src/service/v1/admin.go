@@ -6,9 +6,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 
+	"github.com/ProjectsTask/EasySwapBackend/src/service/mq"
 	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
 	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
 )
@@ -72,7 +75,10 @@ func AdminAddContract(ctx context.Context, svcCtx *svc.ServerCtx, req types.Admi
 	}
 
 	// 添加合约
-	if err := svcCtx.Dao.AdminAddContract(ctx, req); err != nil {
+	err = svcCtx.Dao.AdminAddContract(ctx, req)
+	recordAdminAudit(ctx, svcCtx, AuditActionAddContract, req.ChainID, req.Address, nil,
+		getContractSnapshot(ctx, svcCtx, req.ChainID, req.Address), err)
+	if err != nil {
 		return nil, errors.Wrap(err, "failed to add contract")
 	}
 
@@ -96,7 +102,11 @@ func AdminUpdateContractWithChainID(ctx context.Context, svcCtx *svc.ServerCtx,
 		return nil, errors.New("invalid contract address format")
 	}
 
-	if err := svcCtx.Dao.AdminUpdateContract(ctx, chainID, address, req); err != nil {
+	before := getContractSnapshot(ctx, svcCtx, chainID, address)
+	err := svcCtx.Dao.AdminUpdateContract(ctx, chainID, address, req)
+	recordAdminAudit(ctx, svcCtx, AuditActionUpdateContract, chainID, address, before,
+		getContractSnapshot(ctx, svcCtx, chainID, address), err)
+	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			return nil, errors.New("contract not found")
 		}
@@ -123,7 +133,10 @@ func AdminDeleteContractWithChainID(ctx context.Context, svcCtx *svc.ServerCtx,
 		return nil, errors.New("invalid contract address format")
 	}
 
-	if err := svcCtx.Dao.AdminDeleteContract(ctx, chainID, address); err != nil {
+	before := getContractSnapshot(ctx, svcCtx, chainID, address)
+	err := svcCtx.Dao.AdminDeleteContract(ctx, chainID, address)
+	recordAdminAudit(ctx, svcCtx, AuditActionDeleteContract, chainID, address, before, nil, err)
+	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			return nil, errors.New("contract not found")
 		}
@@ -152,16 +165,22 @@ func AdminToggleContractWithChainID(ctx context.Context, svcCtx *svc.ServerCtx,
 
 	auth := 2 // 认证不通过
 	status := "禁用"
+	action := AuditActionDisableContract
 	if enabled {
 		auth = 1 // 认证通过
 		status = "启用"
+		action = AuditActionEnableContract
 	}
 
 	updateReq := types.AdminUpdateContractReq{
 		Auth: &auth,
 	}
 
-	if err := svcCtx.Dao.AdminUpdateContract(ctx, chainID, address, updateReq); err != nil {
+	before := getContractSnapshot(ctx, svcCtx, chainID, address)
+	err := svcCtx.Dao.AdminUpdateContract(ctx, chainID, address, updateReq)
+	recordAdminAudit(ctx, svcCtx, action, chainID, address, before,
+		getContractSnapshot(ctx, svcCtx, chainID, address), err)
+	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			return nil, errors.New("contract not found")
 		}
@@ -182,6 +201,9 @@ func AdminSyncContract(ctx context.Context, svcCtx *svc.ServerCtx, req types.Adm
 	if !isValidAddress(req.ContractAddr) {
 		return nil, errors.New("invalid contract address format")
 	}
+	if req.StartBlock > 0 && req.EndBlock > 0 && req.EndBlock < req.StartBlock {
+		return nil, errors.New("end_block must not be less than start_block")
+	}
 
 	// 检查合约是否存在
 	exists, err := svcCtx.Dao.AdminCheckContractExists(ctx, req.ChainID, req.ContractAddr)
@@ -194,14 +216,26 @@ func AdminSyncContract(ctx context.Context, svcCtx *svc.ServerCtx, req types.Adm
 
 	taskID := uuid.NewString()
 
+	task := types.SyncTask{
+		ID:           taskID,
+		TaskType:     SyncTaskTypeContract,
+		ContractAddr: req.ContractAddr,
+		ChainID:      req.ChainID,
+		Status:       SyncTaskPending,
+		CreatedAt:    time.Now(),
+	}
+	reporter, err := createSyncTask(ctx, svcCtx, task, req.StartBlock, req.EndBlock)
+	if err != nil {
+		return nil, err
+	}
+	tracker := newSyncTaskTracker(task, reporter)
+
 	// 设置合约为同步中状态
 	if err := svcCtx.Dao.AdminSetContractSyncStatus(ctx, req.ChainID, req.ContractAddr, true); err != nil {
+		tracker.finish(errors.Wrap(err, "failed to set sync status"))
 		return nil, errors.Wrap(err, "failed to set sync status")
 	}
 
-	// TODO: 创建同步任务记录到专门的任务表
-	// 这里暂时只是模拟
-
 	// 异步执行同步任务
 	go func() {
 		defer func() {
@@ -209,17 +243,13 @@ func AdminSyncContract(ctx context.Context, svcCtx *svc.ServerCtx, req types.Adm
 			svcCtx.Dao.AdminSetContractSyncStatus(context.Background(), req.ChainID, req.ContractAddr, false)
 		}()
 
-		// TODO: 实现实际的同步逻辑
-		// 1. 从区块链获取合约的 Transfer 事件
-		// 2. 解析事件获取 NFT 信息
-		// 3. 获取元数据
-		// 4. 保存到数据库
-		// 5. 更新任务状态和进度
-
-		// 模拟同步过程
-		time.Sleep(5 * time.Second)
+		tracker.start()
+		err := runContractImport(context.Background(), svcCtx, tracker, req.StartBlock, req.EndBlock)
+		tracker.finish(err)
 	}()
 
+	recordAdminAudit(ctx, svcCtx, AuditActionSyncContract, req.ChainID, req.ContractAddr, nil, req, nil)
+
 	return &types.AdminSyncResp{
 		TaskID: taskID,
 	}, nil
@@ -243,20 +273,30 @@ func AdminSyncToken(ctx context.Context, svcCtx *svc.ServerCtx, req types.AdminS
 
 	taskID := uuid.NewString()
 
-	// TODO: 创建同步任务记录到专门的任务表
+	task := types.SyncTask{
+		ID:           taskID,
+		TaskType:     SyncTaskTypeToken,
+		ContractAddr: req.ContractAddr,
+		TokenID:      req.TokenID,
+		ChainID:      req.ChainID,
+		Status:       SyncTaskPending,
+		CreatedAt:    time.Now(),
+	}
+	reporter, err := createSyncTask(ctx, svcCtx, task, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	tracker := newSyncTaskTracker(task, reporter)
 
 	// 异步执行同步任务
 	go func() {
-		// TODO: 实现实际的同步逻辑
-		// 1. 获取指定 Token 的信息
-		// 2. 获取元数据
-		// 3. 保存到数据库
-		// 4. 更新任务状态
-
-		// 模拟同步过程
-		time.Sleep(2 * time.Second)
+		tracker.start()
+		err := runTokenImport(context.Background(), svcCtx, tracker)
+		tracker.finish(err)
 	}()
 
+	recordAdminAudit(ctx, svcCtx, AuditActionSyncToken, req.ChainID, req.ContractAddr, nil, req, nil)
+
 	return &types.AdminSyncResp{
 		TaskID: taskID,
 	}, nil
@@ -264,58 +304,36 @@ func AdminSyncToken(ctx context.Context, svcCtx *svc.ServerCtx, req types.AdminS
 
 // AdminGetSyncStatus 获取同步状态
 func AdminGetSyncStatus(ctx context.Context, svcCtx *svc.ServerCtx, taskID string) (*types.AdminGetSyncStatusResp, error) {
-	// TODO: 从数据库查询任务状态
-	// 这里先返回模拟数据
-
-	// 模拟数据
-	task := types.SyncTask{
-		ID:             taskID,
-		TaskType:       "contract",
-		ContractAddr:   "0x1234567890123456789012345678901234567890",
-		ChainID:        11155111,
-		Status:         "running",
-		Progress:       65,
-		TotalItems:     1000,
-		ProcessedItems: 650,
-		CreatedAt:      time.Now().Add(-30 * time.Minute),
-		StartedAt:      &time.Time{},
-	}
-	*task.StartedAt = time.Now().Add(-25 * time.Minute)
+	task, err := svcCtx.Dao.QuerySyncTask(ctx, taskID)
+	if err != nil {
+		if strings.Contains(err.Error(), "record not found") {
+			return nil, errors.New("task not found")
+		}
+		return nil, errors.Wrap(err, "failed to get sync task")
+	}
 
 	return &types.AdminGetSyncStatusResp{
-		Task: task,
+		Task: toSyncTaskInfo(*task),
 	}, nil
 }
 
 // AdminGetSyncHistory 获取同步历史
 func AdminGetSyncHistory(ctx context.Context, svcCtx *svc.ServerCtx, req types.AdminGetSyncHistoryReq) (*types.AdminGetSyncHistoryResp, error) {
-	// TODO: 从数据库分页查询同步任务历史
-	// 这里先返回模拟数据
-
-	// 模拟数据
-	tasks := []types.SyncTask{
-		{
-			ID:             "task-001",
-			TaskType:       "contract",
-			ContractAddr:   "0x1234567890123456789012345678901234567890",
-			ChainID:        11155111,
-			Status:         "completed",
-			Progress:       100,
-			TotalItems:     1000,
-			ProcessedItems: 1000,
-			CreatedAt:      time.Now().Add(-2 * time.Hour),
-			StartedAt:      &time.Time{},
-			CompletedAt:    &time.Time{},
-		},
-	}
-	*tasks[0].StartedAt = time.Now().Add(-2 * time.Hour)
-	*tasks[0].CompletedAt = time.Now().Add(-1 * time.Hour)
+	tasks, total, err := svcCtx.Dao.QuerySyncTasks(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get sync history")
+	}
+
+	taskInfos := make([]types.SyncTask, 0, len(tasks))
+	for _, task := range tasks {
+		taskInfos = append(taskInfos, toSyncTaskInfo(task))
+	}
 
 	resp := &types.AdminGetSyncHistoryResp{
-		Total:    1,
+		Total:    total,
 		Page:     req.Page,
 		PageSize: req.PageSize,
-		Tasks:    tasks,
+		Tasks:    taskInfos,
 	}
 
 	return resp, nil
@@ -351,17 +369,35 @@ func AdminRefreshMetadata(ctx context.Context, svcCtx *svc.ServerCtx, req types.
 		return nil, errors.New("contract not found")
 	}
 
-	// 异步执行刷新任务
+	nodeSrv, ok := svcCtx.NodeSrvs[req.ChainID]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("unsupported chain id: %d", req.ChainID))
+	}
+
+	// 异步写入刷新队列, 由 EasySwapSync 的元数据刷新任务消费
 	go func() {
-		// TODO: 实现实际的元数据刷新逻辑
-		// 1. 重新获取 NFT 元数据
-		// 2. 更新数据库中的元数据信息
-		// 3. 更新缓存
+		ctx := context.Background()
+		tokenIDs := req.TokenIDs
+		if len(tokenIDs) == 0 {
+			var err error
+			tokenIDs, err = svcCtx.Dao.AdminQueryTokenIDs(ctx, nodeSrv.ChainName, req.ContractAddr)
+			if err != nil {
+				xzap.WithContext(ctx).Error("failed on query collection token ids",
+					zap.String("contract_addr", req.ContractAddr), zap.Error(err))
+				return
+			}
+		}
 
-		// 模拟刷新过程
-		time.Sleep(3 * time.Second)
+		for _, tokenID := range tokenIDs {
+			if err := mq.AddSingleItemToRefreshMetadataQueue(svcCtx.KvStore, svcCtx.C.ProjectCfg.Name, nodeSrv.ChainName, req.ChainID, req.ContractAddr, tokenID); err != nil {
+				xzap.WithContext(ctx).Error("failed on add item to refresh metadata queue",
+					zap.String("contract_addr", req.ContractAddr), zap.String("token_id", tokenID), zap.Error(err))
+			}
+		}
 	}()
 
+	recordAdminAudit(ctx, svcCtx, AuditActionRefreshMetadata, req.ChainID, req.ContractAddr, nil, req, nil)
+
 	message := fmt.Sprintf("已开始刷新合约 %s 的元数据", req.ContractAddr)
 	if len(req.TokenIDs) > 0 {
 		message = fmt.Sprintf("已开始刷新合约 %s 的 %d 个 Token 的元数据", req.ContractAddr, len(req.TokenIDs))
@@ -375,6 +411,15 @@ func AdminRefreshMetadata(ctx context.Context, svcCtx *svc.ServerCtx, req types.
 
 // =================== 辅助函数 ===================
 
+// getContractSnapshot 获取合约当前信息用于审计日志, 查询失败时返回 nil
+func getContractSnapshot(ctx context.Context, svcCtx *svc.ServerCtx, chainID int64, address string) *types.Contract {
+	contract, err := svcCtx.Dao.AdminGetContract(ctx, chainID, address)
+	if err != nil {
+		return nil
+	}
+	return contract
+}
+
 // isValidAddress 验证以太坊地址格式
 func isValidAddress(address string) bool {
 	if len(address) != 42 {
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/api/middleware"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+// 审计日志操作类型
+const (
+	AuditActionAddContract      = "contract.add"
+	AuditActionUpdateContract   = "contract.update"
+	AuditActionDeleteContract   = "contract.delete"
+	AuditActionEnableContract   = "contract.enable"
+	AuditActionDisableContract  = "contract.disable"
+	AuditActionSyncContract     = "import.sync_contract"
+	AuditActionSyncToken        = "import.sync_token"
+	AuditActionRefreshMetadata  = "system.refresh_metadata"
+	AuditActionSetAdminUser     = "admin_user.set"
+	AuditActionDeleteAdminUser  = "admin_user.delete"
+	AuditActionSpeedUpTx        = "tx.speed_up"
+	AuditActionCancelTx         = "tx.cancel"
+	AuditActionCreateWebhook    = "webhook.create"
+	AuditActionUpdateWebhook    = "webhook.update"
+	AuditActionDeleteWebhook    = "webhook.delete"
+	AuditActionRedeliverWebhook = "webhook.redeliver"
+)
+
+const maxAuditErrorMsgLength = 1024
+
+// GetAdminRole 获取地址的管理员角色, 配置中的超级管理员优先, 非管理员返回空字符串
+func GetAdminRole(ctx context.Context, svcCtx *svc.ServerCtx, address string) (string, error) {
+	if svcCtx.C != nil && svcCtx.C.Admin != nil {
+		for _, superAdmin := range svcCtx.C.Admin.SuperAdmins {
+			if strings.EqualFold(superAdmin, address) {
+				return base.AdminRoleSuper, nil
+			}
+		}
+	}
+
+	user, err := svcCtx.Dao.QueryAdminUser(ctx, address)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get admin user")
+	}
+	if user == nil || !user.Enabled {
+		return "", nil
+	}
+
+	return user.Role, nil
+}
+
+// recordAdminAudit 记录一次管理操作, before/after 以 JSON 保存; 写入失败只记录日志
+func recordAdminAudit(ctx context.Context, svcCtx *svc.ServerCtx, action string, chainID int64, target string, before, after interface{}, opErr error) {
+	log := &base.AdminAuditLog{
+		Action:  action,
+		ChainId: chainID,
+		Target:  strings.ToLower(target),
+		Before:  marshalAuditValue(before),
+		After:   marshalAuditValue(after),
+		Success: opErr == nil,
+	}
+	if identity, ok := middleware.GetAdminIdentity(ctx); ok {
+		log.Operator = identity.Address
+		log.Role = identity.Role
+		log.ClientIp = identity.ClientIp
+	}
+	if opErr != nil {
+		log.ErrorMsg = opErr.Error()
+		if len(log.ErrorMsg) > maxAuditErrorMsgLength {
+			log.ErrorMsg = log.ErrorMsg[:maxAuditErrorMsgLength]
+		}
+	}
+
+	// 请求结束后仍需写入, 不使用请求上下文的取消信号
+	if err := svcCtx.Dao.CreateAdminAuditLog(context.Background(), log); err != nil {
+		xzap.WithContext(ctx).Error("failed on record admin audit log",
+			zap.String("action", action), zap.String("target", target), zap.Error(err))
+	}
+}
+
+func marshalAuditValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	if string(raw) == "null" {
+		return ""
+	}
+	return string(raw)
+}
+
+// AdminGetUsers 获取管理员列表
+func AdminGetUsers(ctx context.Context, svcCtx *svc.ServerCtx) (*types.AdminGetUsersResp, error) {
+	users, err := svcCtx.Dao.QueryAdminUsers(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get admin users")
+	}
+
+	return &types.AdminGetUsersResp{Users: users}, nil
+}
+
+// AdminSetUser 添加或修改管理员角色
+func AdminSetUser(ctx context.Context, svcCtx *svc.ServerCtx, req types.AdminSetUserReq) (*types.AdminCommonResp, error) {
+	if !common.IsHexAddress(req.Address) {
+		return nil, errors.New("invalid admin address format")
+	}
+
+	before, err := svcCtx.Dao.QueryAdminUser(ctx, req.Address)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get admin user")
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	user := &base.AdminUser{
+		Address: req.Address,
+		Role:    req.Role,
+		Enabled: enabled,
+	}
+	if identity, ok := middleware.GetAdminIdentity(ctx); ok {
+		user.CreatedBy = identity.Address
+	}
+
+	err = svcCtx.Dao.UpsertAdminUser(ctx, user)
+	recordAdminAudit(ctx, svcCtx, AuditActionSetAdminUser, 0, req.Address, before, user, err)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to set admin user")
+	}
+
+	return &types.AdminCommonResp{
+		Success: true,
+		Message: "管理员设置成功",
+	}, nil
+}
+
+// AdminDeleteUser 删除管理员
+func AdminDeleteUser(ctx context.Context, svcCtx *svc.ServerCtx, address string) (*types.AdminCommonResp, error) {
+	if !common.IsHexAddress(address) {
+		return nil, errors.New("invalid admin address format")
+	}
+	if identity, ok := middleware.GetAdminIdentity(ctx); ok && strings.EqualFold(identity.Address, address) {
+		return nil, errors.New("can not delete yourself")
+	}
+
+	before, err := svcCtx.Dao.QueryAdminUser(ctx, address)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get admin user")
+	}
+	if before == nil {
+		return nil, errors.New("admin user not found")
+	}
+
+	err = svcCtx.Dao.DeleteAdminUser(ctx, address)
+	recordAdminAudit(ctx, svcCtx, AuditActionDeleteAdminUser, 0, address, before, nil, err)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to delete admin user")
+	}
+
+	return &types.AdminCommonResp{
+		Success: true,
+		Message: "管理员删除成功",
+	}, nil
+}
+
+// AdminGetAuditLogs 分页获取审计日志
+func AdminGetAuditLogs(ctx context.Context, svcCtx *svc.ServerCtx, req types.AdminGetAuditLogsReq) (*types.AdminGetAuditLogsResp, error) {
+	logs, total, err := svcCtx.Dao.QueryAdminAuditLogs(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get audit logs")
+	}
+
+	return &types.AdminGetAuditLogsResp{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Logs:     logs,
+	}, nil
+}
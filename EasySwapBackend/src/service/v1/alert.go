@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+
+	"github.com/ProjectsTask/EasySwapBase/errcode"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/service/alert"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+const (
+	// 每个用户的关注与提醒规则数量上限
+	maxWatchlistPerUser  = 500
+	maxAlertRulesPerUser = 100
+
+	// 未指定冷却时间时两次触发的最小间隔（秒）
+	defaultAlertCooldown = 3600
+	// volume_spike 未指定统计窗口时的默认窗口（秒）
+	defaultVolumeWindow = 3600
+	maxVolumeWindow     = 7 * 24 * 3600
+)
+
+// GetWatchlist 获取用户的关注列表
+func GetWatchlist(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string) (*types.WatchlistResp, error) {
+	watchlist, err := svcCtx.Dao.QueryWatchlist(ctx, strings.ToLower(userAddr))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get watchlist")
+	}
+
+	return &types.WatchlistResp{Result: watchlist}, nil
+}
+
+// AddWatchlist 关注合集或单个 NFT, 重复关注时直接返回成功
+func AddWatchlist(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string, req types.AddWatchlistReq) (*types.WatchlistResp, error) {
+	userAddr = strings.ToLower(userAddr)
+	if err := validateAlertTarget(svcCtx, req.ChainID, req.CollectionAddress); err != nil {
+		return nil, err
+	}
+	count, err := svcCtx.Dao.CountWatchlist(ctx, userAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on count watchlist")
+	}
+	if count >= maxWatchlistPerUser {
+		return nil, errcode.NewCustomErr("watchlist is full")
+	}
+
+	if err := svcCtx.Dao.CreateWatchlist(ctx, &base.Watchlist{
+		UserAddress:       userAddr,
+		ChainId:           req.ChainID,
+		CollectionAddress: strings.ToLower(req.CollectionAddress),
+		TokenId:           req.TokenID,
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed on add watchlist")
+	}
+
+	return GetWatchlist(ctx, svcCtx, userAddr)
+}
+
+// RemoveWatchlist 取消关注
+func RemoveWatchlist(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string, id int64) error {
+	if err := svcCtx.Dao.DeleteWatchlist(ctx, strings.ToLower(userAddr), id); err != nil {
+		return errcode.NewCustomErr(err.Error())
+	}
+
+	return nil
+}
+
+// GetAlertRules 获取用户的提醒规则
+func GetAlertRules(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string) (*types.AlertRulesResp, error) {
+	rules, err := svcCtx.Dao.QueryAlertRules(ctx, strings.ToLower(userAddr))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get alert rules")
+	}
+
+	return &types.AlertRulesResp{Result: rules}, nil
+}
+
+// CreateAlertRule 创建提醒规则
+func CreateAlertRule(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string, req types.CreateAlertRuleReq) (*base.AlertRule, error) {
+	userAddr = strings.ToLower(userAddr)
+	if err := validateAlertTarget(svcCtx, req.ChainID, req.CollectionAddress); err != nil {
+		return nil, err
+	}
+	if !alert.IsValidRuleType(req.RuleType) {
+		return nil, errcode.NewCustomErr("invalid rule_type, supported: " + strings.Join(alert.RuleTypes, ","))
+	}
+	channels, err := joinAlertChannels(svcCtx, req.Channels)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &base.AlertRule{
+		UserAddress:       userAddr,
+		ChainId:           req.ChainID,
+		CollectionAddress: strings.ToLower(req.CollectionAddress),
+		TokenId:           req.TokenID,
+		RuleType:          req.RuleType,
+		Threshold:         req.Threshold,
+		Window:            req.Window,
+		Channels:          channels,
+		Cooldown:          req.Cooldown,
+		Enabled:           true,
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if rule.Cooldown == 0 {
+		rule.Cooldown = defaultAlertCooldown
+	}
+	if rule.RuleType == base.AlertRuleVolumeSpike && rule.Window == 0 {
+		rule.Window = defaultVolumeWindow
+	}
+	if err := validateAlertRule(rule); err != nil {
+		return nil, err
+	}
+
+	count, err := svcCtx.Dao.CountAlertRules(ctx, userAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on count alert rules")
+	}
+	if count >= maxAlertRulesPerUser {
+		return nil, errcode.NewCustomErr("too many alert rules")
+	}
+	if err := svcCtx.Dao.CreateAlertRule(ctx, rule); err != nil {
+		return nil, errors.Wrap(err, "failed on create alert rule")
+	}
+
+	return rule, nil
+}
+
+// UpdateAlertRule 修改提醒规则的阈值、渠道、冷却时间或启用状态
+func UpdateAlertRule(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string, id int64, req types.UpdateAlertRuleReq) (*base.AlertRule, error) {
+	userAddr = strings.ToLower(userAddr)
+	rule, err := svcCtx.Dao.QueryAlertRule(ctx, userAddr, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get alert rule")
+	}
+	if rule == nil {
+		return nil, errcode.NewCustomErr("alert rule not found")
+	}
+
+	updates := make(map[string]interface{})
+	if req.Threshold != nil {
+		rule.Threshold = *req.Threshold
+		updates["threshold"] = rule.Threshold
+	}
+	if req.Window != nil {
+		rule.Window = *req.Window
+		updates["window_seconds"] = rule.Window
+	}
+	if req.Channels != nil {
+		if rule.Channels, err = joinAlertChannels(svcCtx, *req.Channels); err != nil {
+			return nil, err
+		}
+		updates["channels"] = rule.Channels
+	}
+	if req.Cooldown != nil {
+		rule.Cooldown = *req.Cooldown
+		updates["cooldown"] = rule.Cooldown
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+		updates["enabled"] = rule.Enabled
+	}
+	if len(updates) == 0 {
+		return nil, errcode.NewCustomErr("no fields to update")
+	}
+	if err := validateAlertRule(rule); err != nil {
+		return nil, err
+	}
+
+	if err := svcCtx.Dao.UpdateAlertRule(ctx, userAddr, id, updates); err != nil {
+		return nil, errors.Wrap(err, "failed on update alert rule")
+	}
+
+	return rule, nil
+}
+
+// DeleteAlertRule 删除提醒规则
+func DeleteAlertRule(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string, id int64) error {
+	if err := svcCtx.Dao.DeleteAlertRule(ctx, strings.ToLower(userAddr), id); err != nil {
+		return errcode.NewCustomErr(err.Error())
+	}
+
+	return nil
+}
+
+// GetNotifications 分页获取用户的站内信
+func GetNotifications(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string, req types.NotificationsReq) (*types.NotificationsResp, error) {
+	notifications, total, unread, err := svcCtx.Dao.QueryNotifications(ctx, strings.ToLower(userAddr), req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get notifications")
+	}
+
+	return &types.NotificationsResp{
+		Total:       total,
+		UnreadTotal: unread,
+		Page:        req.Page,
+		PageSize:    req.PageSize,
+		Result:      notifications,
+	}, nil
+}
+
+// ReadNotifications 将站内信标记为已读, ids 为空时标记全部
+func ReadNotifications(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string, ids []int64) (*types.ReadNotificationsResp, error) {
+	updated, err := svcCtx.Dao.MarkNotificationsRead(ctx, strings.ToLower(userAddr), ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on read notifications")
+	}
+
+	return &types.ReadNotificationsResp{Updated: updated}, nil
+}
+
+// GetNotifySetting 获取用户的站外通知配置
+func GetNotifySetting(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string) (*types.NotifySettingResp, error) {
+	userAddr = strings.ToLower(userAddr)
+	setting, err := svcCtx.Dao.QueryNotifySetting(ctx, userAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get notify setting")
+	}
+	if setting == nil {
+		setting = &base.NotifySetting{UserAddress: userAddr}
+	}
+
+	return &types.NotifySettingResp{Setting: setting}, nil
+}
+
+// UpdateNotifySetting 修改用户的站外通知配置, 首次配置 webhook 或要求重置时生成签名密钥并返回
+func UpdateNotifySetting(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string, req types.UpdateNotifySettingReq) (*types.NotifySettingResp, error) {
+	userAddr = strings.ToLower(userAddr)
+	setting, err := svcCtx.Dao.QueryNotifySetting(ctx, userAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get notify setting")
+	}
+	if setting == nil {
+		setting = &base.NotifySetting{UserAddress: userAddr}
+	}
+
+	if req.Email != nil {
+		setting.Email = ""
+		if *req.Email != "" {
+			addr, err := mail.ParseAddress(*req.Email)
+			if err != nil || addr.Address != *req.Email {
+				return nil, errcode.NewCustomErr("invalid email")
+			}
+			setting.Email = addr.Address
+		}
+	}
+	if req.WebhookURL != nil {
+		if *req.WebhookURL != "" {
+			if err := validateWebhookURL(ctx, *req.WebhookURL); err != nil {
+				return nil, errcode.NewCustomErr(err.Error())
+			}
+		}
+		setting.WebhookUrl = *req.WebhookURL
+	}
+
+	var secret string
+	if setting.WebhookUrl != "" && (setting.WebhookSecret == "" || req.ResetSecret) {
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+		setting.WebhookSecret = secret
+	}
+	if err := svcCtx.Dao.SaveNotifySetting(ctx, setting); err != nil {
+		return nil, errors.Wrap(err, "failed on save notify setting")
+	}
+
+	return &types.NotifySettingResp{Setting: setting, WebhookSecret: secret}, nil
+}
+
+func validateAlertTarget(svcCtx *svc.ServerCtx, chainID int64, collection string) error {
+	if !isChainSupported(svcCtx, int(chainID)) {
+		return errcode.NewCustomErr("unsupported chain_id")
+	}
+	if !common.IsHexAddress(collection) {
+		return errcode.NewCustomErr("invalid collection_address")
+	}
+
+	return nil
+}
+
+func validateAlertRule(rule *base.AlertRule) error {
+	if rule.Threshold.IsNegative() {
+		return errcode.NewCustomErr("threshold must not be negative")
+	}
+	if rule.RuleType != base.AlertRuleListingUndercut && !rule.Threshold.IsPositive() {
+		return errcode.NewCustomErr("threshold is required")
+	}
+	if rule.Window < 0 || rule.Window > maxVolumeWindow {
+		return errcode.NewCustomErr("invalid window")
+	}
+	if rule.RuleType == base.AlertRuleVolumeSpike && rule.Window == 0 {
+		return errcode.NewCustomErr("window is required")
+	}
+	if rule.Cooldown < 0 {
+		return errcode.NewCustomErr("invalid cooldown")
+	}
+
+	return nil
+}
+
+// joinAlertChannels 校验站外通知渠道, 邮件渠道需要服务端配置 smtp
+func joinAlertChannels(svcCtx *svc.ServerCtx, channels []string) (string, error) {
+	seen := make(map[string]bool)
+	var names []string
+	for _, channel := range channels {
+		switch channel {
+		case base.NotifyChannelWebhook:
+		case base.NotifyChannelEmail:
+			if svcCtx.C.Alert == nil || svcCtx.C.Alert.SMTP == nil || svcCtx.C.Alert.SMTP.Host == "" {
+				return "", errcode.NewCustomErr("email channel is not available")
+			}
+		default:
+			return "", errcode.NewCustomErr("invalid channel " + channel)
+		}
+		if !seen[channel] {
+			seen[channel] = true
+			names = append(names, channel)
+		}
+	}
+
+	return strings.Join(names, ","), nil
+}
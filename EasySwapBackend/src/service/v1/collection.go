@@ -11,13 +11,16 @@ import (
 	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
 	"github.com/ProjectsTask/EasySwapBase/ordermanager"
 	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 
+	"github.com/ProjectsTask/EasySwapBackend/src/config"
 	"github.com/ProjectsTask/EasySwapBackend/src/dao"
 	"github.com/ProjectsTask/EasySwapBackend/src/service/mq"
 	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/txmanager"
 	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
 )
 
@@ -409,10 +412,23 @@ func GetItem(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID i
 		itemDetail.CollectionName = collection.Name
 		itemDetail.FloorPrice = collection.FloorPrice
 		itemDetail.CollectionImageURI = collection.ImageUri
+		itemDetail.TokenStandard = collection.TokenStandard
 		if itemDetail.Name == "" {
 			itemDetail.Name = fmt.Sprintf("%s #%s", collection.Name, tokenID)
 		}
 	}
+	if itemDetail.TokenStandard == multi.TokenStandardERC1155 {
+		// ERC1155 无单一所有者, 流通数量与持有人数来自持有数量表
+		supply, ownerCount, err := svcCtx.Dao.QueryItemSupply(ctx, chain, collectionAddr, tokenID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on get item supply")
+		}
+		itemDetail.Supply = supply
+		itemDetail.OwnerCount = ownerCount
+	} else if itemDetail.OwnerAddress != "" {
+		itemDetail.Supply = 1
+		itemDetail.OwnerCount = 1
+	}
 	price, ok := lastSales[strings.ToLower(tokenID)]
 	if ok {
 		itemDetail.LastSellPrice = price
@@ -523,6 +539,12 @@ func GetHistorySalesPrice(ctx context.Context, svcCtx *svc.ServerCtx, chain, col
 }
 
 func GetItemOwner(ctx context.Context, svcCtx *svc.ServerCtx, chainID int64, chain, collectionAddr, tokenID string) (*types.ItemOwner, error) {
+	// ERC1155 没有单一所有者, 持有情况通过 holders 接口查询
+	collection, err := svcCtx.Dao.QueryCollectionInfo(ctx, chain, collectionAddr)
+	if err == nil && collection.TokenStandard == multi.TokenStandardERC1155 {
+		return nil, errcode.NewCustomErr("erc1155 item has no single owner, query holders instead")
+	}
+
 	address, err := svcCtx.NodeSrvs[chainID].FetchNftOwner(collectionAddr, tokenID)
 	if err != nil {
 		xzap.WithContext(ctx).Error("failed on fetch nft owner onchain", zap.Error(err))
@@ -546,6 +568,27 @@ func GetItemOwner(ctx context.Context, svcCtx *svc.ServerCtx, chainID int64, cha
 	}, nil
 }
 
+// GetItemFillability 查询 NFT 挂单能否成交: 挂单人仍是所有者且已授权给 vault 合约
+func GetItemFillability(ctx context.Context, svcCtx *svc.ServerCtx, chainID int64, chain, collectionAddr, tokenID string) (*types.ItemFillability, error) {
+	var vault string
+	for _, chainCfg := range svcCtx.C.ChainSupported {
+		if int64(chainCfg.ChainID) == chainID {
+			vault = chainCfg.VaultAddress
+		}
+	}
+	if vault == "" {
+		return nil, errcode.NewCustomErr("vault address not configured")
+	}
+
+	result, err := svcCtx.Dao.QueryItemFillability(ctx, chain, collectionAddr, tokenID, vault)
+	if err != nil {
+		xzap.WithContext(ctx).Error("failed on query item fillability", zap.Error(err))
+		return nil, errcode.ErrUnexpected
+	}
+
+	return result, nil
+}
+
 func GetItemTraits(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr, tokenID string) ([]types.TraitInfo, error) {
 	var traitInfos []types.TraitInfo
 	var itemTraits []multi.ItemTrait
@@ -721,100 +764,96 @@ func GetItemImage(ctx context.Context, svcCtx *svc.ServerCtx, chain string, coll
 	}, nil
 }
 
-// MintNFT 安全的NFT铸造服务
+// MintNFT 铸造配置允许的合集NFT
+// 合集与允许铸造的用户地址来自 [[mint.collections]] 配置, 交易由交易管理器以 metanode 配置的签名器签名发送, 与 MintMetaNodeNFT 共用交易流程
 func MintNFT(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr, userAddr string, req *types.MintRequest) (*types.MintResult, error) {
-	// 这里需要实现智能合约交互逻辑
-	// 注意：实际的私钥应该从环境变量或安全的密钥管理系统中获取
-	
-	// 验证用户权限
-	if !isAuthorizedMinter(userAddr) {
-		return nil, errors.New("unauthorized minter")
-	}
-	
 	// 验证请求参数
 	if err := validateMintRequest(req); err != nil {
 		return nil, err
 	}
-	
-	// 这里应该调用区块链服务进行实际的铸造
-	// 示例代码 - 实际实现需要根据您的区块链服务来调整
-	result, err := performMint(ctx, svcCtx, chain, collectionAddr, req)
+
+	// 验证合集与用户权限
+	collection := getMintCollection(svcCtx, req.ChainID, collectionAddr)
+	if collection == nil {
+		return nil, errcode.NewCustomErr("collection is not mintable")
+	}
+	if !isAuthorizedMinter(collection, userAddr) {
+		return nil, errcode.ErrPermissionDenied
+	}
+
+	// 请求ID按用户隔离, 不同用户使用相同请求ID互不影响, 查询交易状态时同样以用户地址作为调用方
+	mint, err := sendSafeMint(ctx, svcCtx, req.ChainID, strings.ToLower(userAddr), req.RequestID, collection.Address, req.ToAddress, req.TokenURI)
+	if errors.Is(err, txmanager.ErrRequestIDConflict) {
+		return nil, errcode.NewCustomErr("request_id already used with different parameters")
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to mint NFT")
 	}
-	
-	return result, nil
-}
+	if mint.receipt == nil {
+		// 等待确认超时, 交易管理器继续跟踪, 由调用方通过 /user/mint-tx/:request_id 查询
+		return &types.MintResult{
+			RequestID: mint.requestID,
+			TxHash:    mint.txHash,
+			Status:    "pending",
+		}, nil
+	}
+
+	status := getTransactionStatus(mint.receipt)
+	if mint.tokenID != "" && status == "confirmed" {
+		if err := insertMintedItemToDB(ctx, svcCtx, chain, req.ChainID, collectionAddr, mint.tokenID, req.ToAddress, mint.from.Hex(), req.Name); err != nil {
+			// 数据库写入失败不影响铸造结果, 索引器同步后会补齐
+			xzap.WithContext(ctx).Error("failed to insert minted item to database", zap.Error(err))
+		}
+	}
 
-// isAuthorizedMinter 检查用户是否有铸造权限
-func isAuthorizedMinter(userAddr string) bool {
-	// 这里应该实现实际的权限检查逻辑
-	// 例如检查用户是否在白名单中，或者是否是合约所有者
-	
-	// 临时实现 - 实际应用中需要更严格的权限控制
-	authorizedMinters := map[string]bool{
-		// 添加授权的铸造者地址
-		"0x742d35Cc6634C0532925a3b8D0Ac9C4C0C0C0C0C": true, // 示例地址
-	}
-	
-	return authorizedMinters[userAddr]
+	return &types.MintResult{
+		RequestID: mint.requestID,
+		TxHash:    mint.txHash,
+		TokenID:   mint.tokenID,
+		Status:    status,
+	}, nil
 }
 
-// validateMintRequest 验证铸造请求参数
-func validateMintRequest(req *types.MintRequest) error {
-	if req.ToAddress == "" {
-		return errors.New("to_address is required")
-	}
-	
-	if req.TokenURI == "" {
-		return errors.New("token_uri is required")
+// getMintCollection 返回配置中允许铸造的合集, 未配置时返回nil
+func getMintCollection(svcCtx *svc.ServerCtx, chainID int, collectionAddr string) *config.MintCollection {
+	if svcCtx.C.Mint == nil {
+		return nil
 	}
-	
-	// 验证地址格式
-	if !isValidEthereumAddress(req.ToAddress) {
-		return errors.New("invalid to_address format")
+
+	for _, collection := range svcCtx.C.Mint.Collections {
+		if collection.ChainID == chainID && strings.EqualFold(collection.Address, collectionAddr) {
+			return collection
+		}
 	}
-	
+
 	return nil
 }
 
-// isValidEthereumAddress 验证以太坊地址格式
-func isValidEthereumAddress(address string) bool {
-	// 简单的地址格式验证
-	if len(address) != 42 {
-		return false
-	}
-	
-	if address[:2] != "0x" {
-		return false
+// isAuthorizedMinter 检查用户是否在合集的铸造白名单中
+func isAuthorizedMinter(collection *config.MintCollection, userAddr string) bool {
+	for _, minter := range collection.Minters {
+		if strings.EqualFold(minter, userAddr) {
+			return true
+		}
 	}
-	
-	// 这里可以添加更严格的地址验证逻辑
-	return true
-}
 
-// performMint 执行实际的铸造操作
-func performMint(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string, req *types.MintRequest) (*types.MintResult, error) {
-	// 重要提示：这里需要实现实际的区块链交互
-	// 私钥应该从环境变量中安全获取，例如：
-	// privateKey := os.Getenv("MINTER_PRIVATE_KEY")
-	
-	// 示例返回 - 实际实现需要调用区块链服务
-	return &types.MintResult{
-		TxHash:  "0x" + generateMockTxHash(), // 实际应该是真实的交易哈希
-		TokenID: generateNextTokenID(),        // 实际应该是合约返回的token ID
-		Status:  "pending",                    // 交易状态
-	}, nil
+	return false
 }
 
-// generateMockTxHash 生成模拟交易哈希（仅用于示例）
-func generateMockTxHash() string {
-	// 实际应用中这应该是真实的交易哈希
-	return "1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef"
-}
+// validateMintRequest 验证铸造请求参数
+func validateMintRequest(req *types.MintRequest) error {
+	if req.ToAddress == "" {
+		return errcode.NewCustomErr("to_address is required")
+	}
 
-// generateNextTokenID 生成下一个token ID（仅用于示例）
-func generateNextTokenID() string {
-	// 实际应用中这应该从合约或数据库中获取
-	return "1001"
+	if req.TokenURI == "" {
+		return errcode.NewCustomErr("token_uri is required")
+	}
+
+	// 验证地址格式
+	if !common.IsHexAddress(req.ToAddress) {
+		return errcode.NewCustomErr("invalid to_address format")
+	}
+
+	return nil
 }
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ProjectsTask/EasySwapBase/errcode"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/config"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+const (
+	testMintCollection = "0x5FbDB2315678afecb367f032d93F642f64180aa3"
+	testMinter         = "0xF39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+	testReceiver       = "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+)
+
+func newMintTestCtx() *svc.ServerCtx {
+	return &svc.ServerCtx{C: &config.Config{
+		Mint: &config.MintConfig{Collections: []*config.MintCollection{
+			{ChainID: 11155111, Address: testMintCollection, Minters: []string{testMinter}},
+		}},
+	}}
+}
+
+func TestGetMintCollection(t *testing.T) {
+	svcCtx := newMintTestCtx()
+
+	// 合集地址不区分大小写
+	collection := getMintCollection(svcCtx, 11155111, "0x5fbdb2315678afecb367f032d93f642f64180aa3")
+	if assert.NotNil(t, collection) {
+		assert.Equal(t, testMintCollection, collection.Address)
+	}
+	assert.Nil(t, getMintCollection(svcCtx, 8453, testMintCollection))
+	assert.Nil(t, getMintCollection(svcCtx, 11155111, testReceiver))
+	assert.Nil(t, getMintCollection(&svc.ServerCtx{C: &config.Config{}}, 11155111, testMintCollection))
+}
+
+func TestIsAuthorizedMinter(t *testing.T) {
+	collection := newMintTestCtx().C.Mint.Collections[0]
+
+	assert.True(t, isAuthorizedMinter(collection, testMinter))
+	assert.True(t, isAuthorizedMinter(collection, "0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266"))
+	assert.False(t, isAuthorizedMinter(collection, testReceiver))
+	assert.False(t, isAuthorizedMinter(collection, ""))
+	assert.False(t, isAuthorizedMinter(&config.MintCollection{}, testMinter))
+}
+
+func TestValidateMintRequest(t *testing.T) {
+	assert.NoError(t, validateMintRequest(&types.MintRequest{ChainID: 11155111, ToAddress: testReceiver, TokenURI: "ipfs://token"}))
+
+	cases := map[string]types.MintRequest{
+		"missing to_address": {ChainID: 11155111, TokenURI: "ipfs://token"},
+		"invalid to_address": {ChainID: 11155111, ToAddress: "0x1234", TokenURI: "ipfs://token"},
+		"missing token_uri":  {ChainID: 11155111, ToAddress: testReceiver},
+	}
+	for name, req := range cases {
+		assert.Error(t, validateMintRequest(&req), name)
+	}
+}
+
+func TestMintNFTRejectsUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	svcCtx := newMintTestCtx()
+	req := &types.MintRequest{ChainID: 11155111, ToAddress: testReceiver, TokenURI: "ipfs://token"}
+
+	// 未配置的合集
+	_, err := MintNFT(ctx, svcCtx, "sepolia", testReceiver, testMinter, req)
+	assert.Error(t, err)
+
+	// 不在白名单中的用户
+	_, err = MintNFT(ctx, svcCtx, "sepolia", testMintCollection, testReceiver, req)
+	assert.Equal(t, errcode.ErrPermissionDenied, err)
+
+	// 白名单用户在未配置交易管理器的链上铸造
+	_, err = MintNFT(ctx, svcCtx, "sepolia", testMintCollection, testMinter, req)
+	assert.ErrorContains(t, err, "tx manager not configured")
+
+	// 参数错误先于权限检查返回
+	_, err = MintNFT(ctx, svcCtx, "sepolia", testMintCollection, testReceiver, &types.MintRequest{ChainID: 11155111, ToAddress: "0x1234", TokenURI: "ipfs://token"})
+	assert.Error(t, err)
+	assert.NotEqual(t, errcode.ErrPermissionDenied, err)
+}
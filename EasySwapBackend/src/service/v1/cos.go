@@ -3,9 +3,9 @@ package service
 import (
 	"context"
 	"crypto/rand"
-	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"mime"
 	"path/filepath"
 	"strings"
 	"time"
@@ -15,11 +15,20 @@ import (
 
 	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
 	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+	"github.com/ProjectsTask/EasySwapBase/errcode"
 	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/objstore"
 )
 
-// GetCOSTemporaryToken 获取腾讯云COS临时访问凭证
+// defaultUploadExpire 未配置 upload_expire 时预签名上传地址与策略的有效期
+const defaultUploadExpire = 15 * time.Minute
+
+// GetCOSTemporaryToken 获取上传到 COS 的预签名地址, 只允许上传 generateFileKey 生成的路径
 func GetCOSTemporaryToken(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string, req *types.COSTokenRequest) (*types.COSTokenResponse, error) {
+	if svcCtx.Storage == nil {
+		return nil, errors.New("object storage not configured")
+	}
+
 	// 验证文件类型
 	if !isValidFileType(req.FileType, req.FileName) {
 		return nil, errors.New("invalid file type")
@@ -33,101 +42,84 @@ func GetCOSTemporaryToken(ctx context.Context, svcCtx *svc.ServerCtx, userAddr s
 	// 生成唯一的文件路径
 	fileKey := generateFileKey(userAddr, req.FileType, req.FileName)
 
-	// 获取COS配置
-	cosConfig := getCOSConfig(svcCtx)
-
-	// 生成临时凭证
-	credentials, err := generateTemporaryCredentials(cosConfig, fileKey)
+	// 生成预签名上传地址, 内容类型与大小参与签名
+	presigned, err := svcCtx.Storage.PresignPut(ctx, fileKey, uploadContentType(req.FileName), req.FileSize, getUploadExpire(svcCtx))
 	if err != nil {
-		xzap.WithContext(ctx).Error("failed to generate temporary credentials", zap.Error(err))
-		return nil, errors.Wrap(err, "failed to generate temporary credentials")
+		xzap.WithContext(ctx).Error("failed to presign upload url", zap.Error(err))
+		return nil, errors.Wrap(err, "failed to presign upload url")
 	}
 
 	// 构建响应
 	response := &types.COSTokenResponse{
-		Credentials:  credentials,
-		RequestID:    generateRequestID(),
-		StartTime:    time.Now().Format(time.RFC3339),
-		Expiration:   time.Now().Add(time.Hour).Format(time.RFC3339),
-		ExpiredTime:  time.Now().Add(time.Hour).Unix(),
-		Bucket:       cosConfig.Bucket,
-		Region:       cosConfig.Region,
-		AllowPrefix:  fileKey,
-		AllowActions: []string{"name/cos:PutObject", "name/cos:PostObject"},
-		UploadURL:    fmt.Sprintf("https://%s.cos.%s.myqcloud.com", cosConfig.Bucket, cosConfig.Region),
-		Key:          fileKey,
+		RequestID:   generateRequestID(),
+		StartTime:   time.Now().Format(time.RFC3339),
+		Expiration:  presigned.Expiration.Format(time.RFC3339),
+		ExpiredTime: presigned.Expiration.Unix(),
+		Bucket:      svcCtx.C.COS.Bucket,
+		Region:      svcCtx.C.COS.Region,
+		AllowPrefix: fileKey,
+		Method:      presigned.Method,
+		UploadURL:   presigned.URL,
+		Headers:     presigned.Headers,
+		ObjectURL:   svcCtx.Storage.ObjectURL(fileKey),
+		Key:         fileKey,
 	}
 
 	return response, nil
 }
 
-// COSConfig 腾讯云COS配置
-type COSConfig struct {
-	SecretID  string
-	SecretKey string
-	Bucket    string
-	Region    string
-	AppID     string
-}
-
-// getCOSConfig 获取COS配置（从配置文件）
-func getCOSConfig(svcCtx *svc.ServerCtx) *COSConfig {
-	if svcCtx.C.COS == nil {
-		// 如果配置文件中没有COS配置，返回默认配置
-		return &COSConfig{
-			SecretID:  "YOUR_SECRET_ID",
-			SecretKey: "YOUR_SECRET_KEY",
-			Bucket:    "nft-assets",
-			Region:    "ap-beijing",
-			AppID:     "1234567890",
-		}
+// getUploadExpire 获取预签名上传地址与策略的有效期
+func getUploadExpire(svcCtx *svc.ServerCtx) time.Duration {
+	if svcCtx.C.COS != nil && svcCtx.C.COS.UploadExpire > 0 {
+		return time.Duration(svcCtx.C.COS.UploadExpire) * time.Second
 	}
 
-	return &COSConfig{
-		SecretID:  svcCtx.C.COS.SecretID,
-		SecretKey: svcCtx.C.COS.SecretKey,
-		Bucket:    svcCtx.C.COS.Bucket,
-		Region:    svcCtx.C.COS.Region,
-		AppID:     svcCtx.C.COS.AppID,
-	}
+	return defaultUploadExpire
 }
 
-// generateTemporaryCredentials 生成临时访问凭证
-func generateTemporaryCredentials(config *COSConfig, allowPrefix string) (*types.COSCredentials, error) {
-	// 注意：这是一个简化版本，实际生产环境中应该调用腾讯云STS服务
-	// 这里返回模拟的临时凭证，实际使用时需要集成腾讯云STS SDK
-
-	// 生成模拟的临时凭证
-	tmpSecretID := generateTempSecretID()
-	tmpSecretKey := generateTempSecretKey()
-	sessionToken := generateSessionToken()
-
-	return &types.COSCredentials{
-		TmpSecretID:  tmpSecretID,
-		TmpSecretKey: tmpSecretKey,
-		SessionToken: sessionToken,
-	}, nil
+// uploadContentTypes 允许上传的扩展名对应的内容类型, 不依赖系统的 mime.types 以保证各实例一致
+var uploadContentTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".svg":  "image/svg+xml",
+	".mp4":  "video/mp4",
+	".avi":  "video/x-msvideo",
+	".mov":  "video/quicktime",
+	".wmv":  "video/x-ms-wmv",
+	".flv":  "video/x-flv",
+	".webm": "video/webm",
+	".mp3":  "audio/mpeg",
+	".wav":  "audio/wav",
+	".flac": "audio/flac",
+	".aac":  "audio/aac",
+	".ogg":  "audio/ogg",
+	".pdf":  "application/pdf",
+	".doc":  "application/msword",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".txt":  "text/plain",
+	".json": "application/json",
 }
 
-// generateTempSecretID 生成临时SecretID（模拟）
-func generateTempSecretID() string {
-	randomBytes := make([]byte, 20)
-	rand.Read(randomBytes)
-	return "AKID" + hex.EncodeToString(randomBytes)
-}
+// uploadContentType 按扩展名确定上传文件的内容类型
+func uploadContentType(fileName string) string {
+	if contentType, ok := uploadContentTypes[strings.ToLower(filepath.Ext(fileName))]; ok {
+		return contentType
+	}
 
-// generateTempSecretKey 生成临时SecretKey（模拟）
-func generateTempSecretKey() string {
-	randomBytes := make([]byte, 20)
-	rand.Read(randomBytes)
-	return hex.EncodeToString(randomBytes)
+	return "application/octet-stream"
 }
 
-// generateSessionToken 生成会话令牌（模拟）
-func generateSessionToken() string {
-	randomBytes := make([]byte, 32)
-	rand.Read(randomBytes)
-	return base64.StdEncoding.EncodeToString(randomBytes)
+// isValidContentType 验证对象的内容类型与文件扩展名一致, 忽略 charset 等参数
+func isValidContentType(fileName, contentType string) bool {
+	actual, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(actual, uploadContentType(fileName))
 }
 
 // isValidFileType 验证文件类型
@@ -202,21 +194,92 @@ func generateRequestID() string {
 	return hex.EncodeToString(randomBytes)
 }
 
-// GetCOSUploadPolicy 获取COS上传策略（备用方法）
-func GetCOSUploadPolicy(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string, fileType string) (map[string]interface{}, error) {
-	config := getCOSConfig(svcCtx)
+// GetCOSUploadPolicy 获取COS表单上传策略（备用方法）, 与预签名地址一样只允许上传 generateFileKey 生成的路径
+func GetCOSUploadPolicy(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string, fileType string, fileName string) (*types.COSUploadPolicy, error) {
+	if svcCtx.Storage == nil {
+		return nil, errors.New("object storage not configured")
+	}
 
-	// 生成策略
-	policy := map[string]interface{}{
-		"expiration": time.Now().Add(time.Hour).Format("2006-01-02T15:04:05.000Z"),
-		"conditions": []interface{}{
-			map[string]string{"bucket": config.Bucket},
-			[]interface{}{"starts-with", "$key", fmt.Sprintf("%s/%s/", fileType, userAddr)},
-			[]interface{}{"content-length-range", 0, getMaxFileSize(fileType)},
-		},
+	// 验证文件类型
+	if !isValidFileType(fileType, fileName) {
+		return nil, errors.New("invalid file type")
 	}
 
-	return policy, nil
+	// 策略限定 key、内容类型与大小上限
+	fileKey := generateFileKey(userAddr, fileType, fileName)
+	maxSize := getMaxFileSize(fileType)
+	policy, err := svcCtx.Storage.PresignPost(ctx, fileKey, uploadContentType(fileName), maxSize, getUploadExpire(svcCtx))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to presign upload policy")
+	}
+
+	return &types.COSUploadPolicy{
+		URL:         policy.URL,
+		Key:         policy.Key,
+		ObjectURL:   svcCtx.Storage.ObjectURL(policy.Key),
+		MaxSize:     maxSize,
+		Fields:      policy.Fields,
+		Expiration:  policy.Expiration.Format(time.RFC3339),
+		ExpiredTime: policy.Expiration.Unix(),
+	}, nil
+}
+
+// VerifyCOSUpload 校验上传完成的文件: 路径属于当前用户, 对象存在且大小与内容类型符合文件类型限制
+// 校验失败的对象会被删除
+func VerifyCOSUpload(ctx context.Context, svcCtx *svc.ServerCtx, owners []string, req *types.COSUploadResult) (*types.COSUploadObject, error) {
+	if svcCtx.Storage == nil {
+		return nil, errors.New("object storage not configured")
+	}
+
+	// 路径格式：fileType/userAddr/timestamp_random.ext
+	parts := strings.SplitN(req.Key, "/", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return nil, errcode.NewCustomErr("invalid file key")
+	}
+	fileType, owner := parts[0], parts[1]
+	owned := false
+	for _, addr := range owners {
+		if strings.EqualFold(addr, owner) {
+			owned = true
+			break
+		}
+	}
+	if !owned {
+		return nil, errcode.ErrPermissionDenied
+	}
+	if !isValidFileType(fileType, req.Key) {
+		return nil, errcode.NewCustomErr("invalid file type")
+	}
+
+	info, err := svcCtx.Storage.Stat(ctx, req.Key)
+	if errors.Is(err, objstore.ErrObjectNotFound) {
+		return nil, errcode.NewCustomErr("uploaded file not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to stat uploaded file")
+	}
+
+	var invalid string
+	if info.Size <= 0 || !isValidFileSize(fileType, info.Size) {
+		invalid = "file size exceeds limit"
+	} else if !isValidContentType(req.Key, info.ContentType) {
+		invalid = "file content type mismatch"
+	}
+	if invalid != "" {
+		if err := svcCtx.Storage.Delete(ctx, req.Key); err != nil {
+			xzap.WithContext(ctx).Error("failed to delete invalid upload", zap.Error(err), zap.String("key", req.Key))
+		}
+		return nil, errcode.NewCustomErr(invalid)
+	}
+
+	return &types.COSUploadObject{
+		Key:         req.Key,
+		URL:         svcCtx.Storage.ObjectURL(req.Key),
+		FileType:    fileType,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ETag:        info.ETag,
+	}, nil
 }
 
 // getMaxFileSize 获取文件类型的最大大小
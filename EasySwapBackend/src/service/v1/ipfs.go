@@ -0,0 +1,84 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"path"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/nftchainservice"
+	"github.com/ProjectsTask/EasySwapBase/errcode"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+// metadataFileName 固定到IPFS的元数据文件名
+const metadataFileName = "metadata.json"
+
+// PinTokenMetadata 将用户上传到COS的图片与生成的ERC721元数据固定到IPFS, 返回 ipfs:// 地址
+// 元数据结构与 nftchainservice.DecodeJsonMetadata 的解析方式一致, 同步服务可直接解析
+func PinTokenMetadata(ctx context.Context, svcCtx *svc.ServerCtx, owners []string, req *types.IPFSMetadataRequest) (*types.IPFSMetadataResult, error) {
+	if svcCtx.IPFS == nil {
+		return nil, errors.New("ipfs not configured")
+	}
+
+	// 校验图片属于当前用户且大小与内容类型合法
+	object, err := VerifyCOSUpload(ctx, svcCtx, owners, &types.COSUploadResult{Key: req.ImageKey})
+	if err != nil {
+		return nil, err
+	}
+	if object.FileType != "image" {
+		return nil, errcode.NewCustomErr("image_key must be an image")
+	}
+
+	content, _, err := svcCtx.Storage.Get(ctx, object.Key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read uploaded image")
+	}
+	defer content.Close()
+
+	image, err := svcCtx.IPFS.Add(ctx, path.Base(object.Key), content)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pin image to ipfs")
+	}
+
+	attributes := make([]*nftchainservice.TokenMetadataAttribute, 0, len(req.Attributes))
+	for _, attr := range req.Attributes {
+		attributes = append(attributes, &nftchainservice.TokenMetadataAttribute{
+			TraitType: attr.TraitType,
+			Value:     attr.Value,
+		})
+	}
+	metadata, err := nftchainservice.EncodeJsonMetadata(&nftchainservice.TokenMetadata{
+		Name:        req.Name,
+		Description: req.Description,
+		Image:       image.URI(),
+		ExternalURL: req.ExternalURL,
+		Attributes:  attributes,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode metadata")
+	}
+
+	pinned, err := svcCtx.IPFS.Add(ctx, metadataFileName, bytes.NewReader(metadata))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pin metadata to ipfs")
+	}
+
+	xzap.WithContext(ctx).Info("token metadata pinned to ipfs",
+		zap.String("image_key", object.Key),
+		zap.String("image_cid", image.CID),
+		zap.String("metadata_cid", pinned.CID))
+
+	return &types.IPFSMetadataResult{
+		TokenURI:    pinned.URI(),
+		MetadataCID: pinned.CID,
+		ImageURI:    image.URI(),
+		ImageCID:    image.CID,
+		Metadata:    string(metadata),
+	}, nil
+}
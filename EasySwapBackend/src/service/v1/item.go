@@ -40,3 +40,16 @@ func GetItemBidsInfo(ctx context.Context, svcCtx *svc.ServerCtx, chain string, c
 		Count:  count,
 	}, nil
 }
+
+// GetItemHolders 查询 ERC1155 NFT 的持有人及持有数量
+func GetItemHolders(ctx context.Context, svcCtx *svc.ServerCtx, chain string, collectionAddr, tokenID string, page, pageSize int) (*types.ItemHoldersResp, error) {
+	holders, count, err := svcCtx.Dao.QueryItemHolders(ctx, chain, collectionAddr, tokenID, page, pageSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get item holders")
+	}
+
+	return &types.ItemHoldersResp{
+		Result: holders,
+		Count:  count,
+	}, nil
+}
@@ -2,7 +2,6 @@ package service
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"fmt"
 	"math/big"
 	"strings"
@@ -14,12 +13,16 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
 
 	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/txmanager"
 	typesv1 "github.com/ProjectsTask/EasySwapBackend/src/types/v1"
 	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
 	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
 )
 
@@ -322,14 +325,14 @@ const MetaNodeNFTABI = `[
 	}
 ]`
 
+// MetaNodeTxCaller 免登录铸造 MetaNodeNFT 的交易调用方, 合集铸造以用户地址作为调用方
+const MetaNodeTxCaller = "metanode"
+
 // MetaNodeConfig MetaNodeNFT配置
+// RPC端点与Gas配置由交易管理器持有
 type MetaNodeConfig struct {
-	ContractAddress string   // 合约地址
-	OwnerPrivateKey string   // 所有者私钥
-	RPCEndpoint     string   // RPC节点地址
-	ChainID         int64    // 链ID
-	GasLimit        uint64   // Gas限制
-	GasPrice        *big.Int // Gas价格
+	ContractAddress string // 合约地址
+	ChainID         int64  // 链ID
 }
 
 // MintMetaNodeNFT 铸造MetaNodeNFT
@@ -349,9 +352,7 @@ func MintMetaNodeNFT(ctx context.Context, svcCtx *svc.ServerCtx, req *typesv1.Me
 
 	xzap.WithContext(ctx).Info("MetaNode config loaded",
 		zap.String("contract_address", config.ContractAddress),
-		zap.String("rpc_endpoint", config.RPCEndpoint),
 		zap.Int64("chain_id", config.ChainID),
-		zap.Uint64("gas_limit", config.GasLimit),
 	)
 
 	// 验证请求参数
@@ -360,114 +361,121 @@ func MintMetaNodeNFT(ctx context.Context, svcCtx *svc.ServerCtx, req *typesv1.Me
 		return nil, errors.Wrap(err, "invalid mint request")
 	}
 
-	// 连接到以太坊节点
-	xzap.WithContext(ctx).Info("Connecting to Ethereum client", zap.String("rpc_endpoint", config.RPCEndpoint))
-	client, err := ethclient.Dial(config.RPCEndpoint)
+	// 发送safeMint交易并等待确认
+	mint, err := sendSafeMint(ctx, svcCtx, req.ChainID, MetaNodeTxCaller, req.RequestID, config.ContractAddress, req.ToAddress, req.TokenURI)
 	if err != nil {
-		xzap.WithContext(ctx).Error("Failed to connect to Ethereum client", zap.Error(err))
-		return nil, errors.Wrap(err, "failed to connect to Ethereum client")
+		return nil, err
 	}
-	defer client.Close()
-
-	// 测试连接
-	chainID, err := client.ChainID(ctx)
-	if err != nil {
-		xzap.WithContext(ctx).Error("Failed to get chain ID from client", zap.Error(err))
-		return nil, errors.Wrap(err, "failed to get chain ID")
+	if mint.receipt == nil {
+		// 如果等待超时，仍然返回pending状态
+		return &typesv1.MetaNodeMintResult{
+			RequestID: mint.requestID,
+			TxHash:    mint.txHash,
+			TokenID:   "", // 无法获取，需要后续查询
+			Status:    "pending",
+			GasPrice:  mint.gasPrice,
+		}, nil
 	}
-	xzap.WithContext(ctx).Info("Connected to Ethereum client",
-		zap.String("chain_id", chainID.String()),
-		zap.Int64("expected_chain_id", config.ChainID),
-	)
 
-	// 解析私钥
-	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(config.OwnerPrivateKey, "0x"))
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to parse private key")
+	// 铸造成功后，写入数据库
+	if mint.tokenID != "" && getTransactionStatus(mint.receipt) == "confirmed" {
+		if err := insertMintedItemToDB(ctx, svcCtx, getChainName(req.ChainID), req.ChainID, config.ContractAddress, mint.tokenID, req.ToAddress, mint.from.Hex(), req.Name); err != nil {
+			xzap.WithContext(ctx).Error("failed to insert minted item to database", zap.Error(err))
+			// 数据库写入失败不影响铸造结果，但记录错误
+		} else {
+			xzap.WithContext(ctx).Info("successfully inserted minted item to database",
+				zap.String("chain_id", fmt.Sprintf("%d", req.ChainID)),
+				zap.String("collection_address", config.ContractAddress),
+				zap.String("token_id", mint.tokenID),
+				zap.String("owner", req.ToAddress),
+			)
+		}
 	}
 
-	// 获取公钥和地址
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New("error casting public key to ECDSA")
-	}
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
+	return &typesv1.MetaNodeMintResult{
+		RequestID:   mint.requestID,
+		TxHash:      mint.txHash,
+		TokenID:     mint.tokenID,
+		Status:      getTransactionStatus(mint.receipt),
+		BlockNumber: mint.receipt.BlockNumber.Int64(),
+		GasUsed:     int64(mint.receipt.GasUsed),
+		GasPrice:    mint.gasPrice,
+	}, nil
+}
 
-	// 获取nonce
-	nonce, err := client.PendingNonceAt(ctx, fromAddress)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get nonce")
-	}
+// safeMintTx safeMint交易的发送结果
+type safeMintTx struct {
+	requestID string
+	txHash    string
+	from      common.Address // 签名地址
+	gasPrice  string         // 最高gas单价(wei)
+	receipt   *types.Receipt // 等待确认超时时为nil
+	tokenID   string         // 从Transfer事件解析, 解析失败时为空
+}
 
-	// 获取Gas价格
-	gasPrice := config.GasPrice
-	if gasPrice == nil {
-		gasPrice, err = client.SuggestGasPrice(ctx)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to suggest gas price")
-		}
+// sendSafeMint 通过交易管理器调用合约的safeMint(to, uri), 等待交易确认并解析TokenID
+// MetaNodeNFT与通过 /collections/:address/mint 铸造的合集共用该交易流程, requestID 为空时自动生成
+// requestID 在 caller 范围内幂等, 重复请求的合约、接收地址或URI不同时返回 txmanager.ErrRequestIDConflict
+func sendSafeMint(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, caller, requestID, contractAddr, to, tokenURI string) (*safeMintTx, error) {
+	manager, ok := svcCtx.TxManagers[int64(chainID)]
+	if !ok {
+		return nil, fmt.Errorf("tx manager not configured for chain ID: %d", chainID)
 	}
-
-	// 创建交易选项
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, big.NewInt(config.ChainID))
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create transactor")
+	if requestID == "" {
+		requestID = uuid.NewString()
 	}
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)
-	auth.GasLimit = config.GasLimit
-	auth.GasPrice = gasPrice
 
-	// 解析合约ABI
+	// 解析合约ABI并编码safeMint调用
 	contractABI, err := abi.JSON(strings.NewReader(MetaNodeNFTABI))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse contract ABI")
 	}
+	data, err := contractABI.Pack("safeMint", common.HexToAddress(to), tokenURI)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pack safeMint call")
+	}
 
-	// 创建合约实例
-	contractAddress := common.HexToAddress(config.ContractAddress)
-	contract := bind.NewBoundContract(contractAddress, contractABI, client, client, client)
-
-	// 调用safeMint函数
-	toAddress := common.HexToAddress(req.ToAddress)
-
-	// 调用safeMint函数 - 这个函数会返回tokenId
 	xzap.WithContext(ctx).Info("Calling safeMint function",
-		zap.String("contract_address", config.ContractAddress),
-		zap.String("to_address", req.ToAddress),
-		zap.String("token_uri", req.TokenURI),
-		zap.String("from_address", fromAddress.Hex()),
-		zap.Uint64("nonce", nonce),
-		zap.String("gas_price", gasPrice.String()),
-		zap.Uint64("gas_limit", config.GasLimit),
+		zap.String("request_id", requestID),
+		zap.String("contract_address", contractAddr),
+		zap.String("to_address", to),
+		zap.String("token_uri", tokenURI),
+		zap.String("from_address", manager.Address().Hex()),
 	)
 
-	tx, err := contract.Transact(auth, "safeMint", toAddress, req.TokenURI)
+	record, err := manager.Send(ctx, &txmanager.SendRequest{
+		RequestID: requestID,
+		Caller:    caller,
+		To:        common.HexToAddress(contractAddr),
+		Data:      data,
+	})
 	if err != nil {
 		xzap.WithContext(ctx).Error("Failed to call safeMint", zap.Error(err))
 		return nil, errors.Wrap(err, "failed to call safeMint")
 	}
 
-	xzap.WithContext(ctx).Info("MetaNodeNFT mint transaction sent",
-		zap.String("tx_hash", tx.Hash().Hex()),
-		zap.String("to_address", req.ToAddress),
-		zap.String("token_uri", req.TokenURI),
-		zap.String("contract_address", config.ContractAddress),
-	)
+	result := &safeMintTx{
+		requestID: requestID,
+		txHash:    record.TxHash,
+		from:      manager.Address(),
+		gasPrice:  record.GasFeeCap,
+	}
 
-	// 等待交易确认（可选，用于获取tokenID）
-	receipt, err := waitForTransaction(ctx, client, tx.Hash(), 30*time.Second)
+	// 等待交易确认（可选，用于获取tokenID）, 超时后由交易管理器继续跟踪, 可按请求ID查询
+	record, receipt, err := manager.Wait(ctx, caller, requestID, 30*time.Second)
 	if err != nil {
-		// 如果等待超时，仍然返回pending状态
 		xzap.WithContext(ctx).Warn("transaction confirmation timeout", zap.Error(err))
-		return &typesv1.MetaNodeMintResult{
-			TxHash:   tx.Hash().Hex(),
-			TokenID:  "", // 无法获取，需要后续查询
-			Status:   "pending",
-			GasPrice: gasPrice.String(),
-		}, nil
+		if record != nil {
+			result.txHash = record.TxHash
+		}
+		return result, nil
+	}
+	result.txHash = record.TxHash
+	result.gasPrice = record.GasFeeCap
+	if receipt == nil {
+		return nil, errors.Errorf("mint transaction %s: %s", record.Status, record.ErrorMsg)
 	}
+	result.receipt = receipt
 
 	// 解析事件获取TokenID
 	tokenID, err := parseTokenIDFromReceipt(receipt, contractABI)
@@ -475,30 +483,9 @@ func MintMetaNodeNFT(ctx context.Context, svcCtx *svc.ServerCtx, req *typesv1.Me
 		xzap.WithContext(ctx).Warn("failed to parse tokenID from receipt", zap.Error(err))
 		tokenID = "" // 设置为空，可以后续查询
 	}
+	result.tokenID = tokenID
 
-	// 铸造成功后，写入数据库
-	if tokenID != "" && getTransactionStatus(receipt) == "confirmed" {
-		if err := insertMintedItemToDB(ctx, svcCtx, req.ChainID, config.ContractAddress, tokenID, req.ToAddress, req.Name); err != nil {
-			xzap.WithContext(ctx).Error("failed to insert minted item to database", zap.Error(err))
-			// 数据库写入失败不影响铸造结果，但记录错误
-		} else {
-			xzap.WithContext(ctx).Info("successfully inserted minted item to database",
-				zap.String("chain_id", fmt.Sprintf("%d", req.ChainID)),
-				zap.String("collection_address", config.ContractAddress),
-				zap.String("token_id", tokenID),
-				zap.String("owner", req.ToAddress),
-			)
-		}
-	}
-
-	return &typesv1.MetaNodeMintResult{
-		TxHash:      tx.Hash().Hex(),
-		TokenID:     tokenID,
-		Status:      getTransactionStatus(receipt),
-		BlockNumber: receipt.BlockNumber.Int64(),
-		GasUsed:     int64(receipt.GasUsed),
-		GasPrice:    gasPrice.String(),
-	}, nil
+	return result, nil
 }
 
 // BatchMintMetaNodeNFT 批量铸造MetaNodeNFT
@@ -522,8 +509,16 @@ func BatchMintMetaNodeNFT(ctx context.Context, svcCtx *svc.ServerCtx, req *types
 	failedCount := 0
 
 	// 逐个执行铸造（实际项目中可以考虑批量合约调用）
-	for _, mintInfo := range req.Mints {
+	var requestIDs []string
+	for i, mintInfo := range req.Mints {
+		requestID := uuid.NewString()
+		if req.RequestID != "" {
+			requestID = fmt.Sprintf("%s-%d", req.RequestID, i)
+		}
+		requestIDs = append(requestIDs, requestID)
+
 		mintReq := &typesv1.MetaNodeMintRequest{
+			RequestID:   requestID,
 			ChainID:     req.ChainID,
 			ToAddress:   mintInfo.ToAddress,
 			TokenURI:    mintInfo.TokenURI,
@@ -547,6 +542,7 @@ func BatchMintMetaNodeNFT(ctx context.Context, svcCtx *svc.ServerCtx, req *types
 
 	return &typesv1.MetaNodeBatchMintResult{
 		TxHash:       "", // 批量操作没有单一交易哈希
+		RequestIDs:   requestIDs,
 		TokenIDs:     tokenIDs,
 		SuccessCount: successCount,
 		FailedCount:  failedCount,
@@ -563,7 +559,11 @@ func QueryMetaNodeNFT(ctx context.Context, svcCtx *svc.ServerCtx, req *typesv1.M
 	}
 
 	// 连接到以太坊节点
-	client, err := ethclient.Dial(config.RPCEndpoint)
+	rpcEndpoint := svcCtx.C.MetaNode.RPCEndpoints[fmt.Sprintf("%d", req.ChainID)]
+	if rpcEndpoint == "" {
+		return nil, fmt.Errorf("RPC endpoint not configured for chain ID: %d", req.ChainID)
+	}
+	client, err := ethclient.Dial(rpcEndpoint)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to connect to Ethereum client")
 	}
@@ -609,49 +609,15 @@ func getMetaNodeConfig(svcCtx *svc.ServerCtx, chainID int) (*MetaNodeConfig, err
 		return nil, errors.New("MetaNode configuration not found in config file")
 	}
 
-	config := svcCtx.C.MetaNode
-	chainIDStr := fmt.Sprintf("%d", chainID)
-
 	// 获取合约地址
-	contractAddr, exists := config.ContractAddresses[chainIDStr]
+	contractAddr, exists := svcCtx.C.MetaNode.ContractAddresses[fmt.Sprintf("%d", chainID)]
 	if !exists || contractAddr == "" {
 		return nil, fmt.Errorf("contract address not configured for chain ID: %d", chainID)
 	}
 
-	// 获取RPC端点
-	rpcEndpoint, exists := config.RPCEndpoints[chainIDStr]
-	if !exists || rpcEndpoint == "" {
-		return nil, fmt.Errorf("RPC endpoint not configured for chain ID: %d", chainID)
-	}
-
-	// 检查私钥
-	if config.OwnerPrivateKey == "" {
-		return nil, errors.New("owner private key not configured")
-	}
-
-	// 解析Gas价格
-	var gasPrice *big.Int
-	if config.GasPrice != "" {
-		var ok bool
-		gasPrice, ok = new(big.Int).SetString(config.GasPrice, 10)
-		if !ok {
-			return nil, errors.New("invalid gas price format")
-		}
-	}
-
-	// 设置默认Gas限制
-	gasLimit := config.GasLimit
-	if gasLimit == 0 {
-		gasLimit = 300000
-	}
-
 	return &MetaNodeConfig{
 		ContractAddress: contractAddr,
-		OwnerPrivateKey: config.OwnerPrivateKey,
-		RPCEndpoint:     rpcEndpoint,
 		ChainID:         int64(chainID),
-		GasLimit:        gasLimit,
-		GasPrice:        gasPrice,
 	}, nil
 }
 
@@ -672,28 +638,6 @@ func validateMetaNodeMintRequest(req *typesv1.MetaNodeMintRequest) error {
 	return nil
 }
 
-// waitForTransaction 等待交易确认
-func waitForTransaction(ctx context.Context, client *ethclient.Client, txHash common.Hash, timeout time.Duration) (*types.Receipt, error) {
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-ticker.C:
-			receipt, err := client.TransactionReceipt(ctx, txHash)
-			if err == nil {
-				return receipt, nil
-			}
-			// 继续等待
-		}
-	}
-}
-
 // parseTokenIDFromReceipt 从交易收据解析TokenID
 func parseTokenIDFromReceipt(receipt *types.Receipt, contractABI abi.ABI) (string, error) {
 	// 解析Transfer事件来获取TokenID
@@ -783,17 +727,16 @@ func queryTokenInfo(ctx context.Context, contract *bind.BoundContract, tokenIDSt
 	}, nil
 }
 
-// insertMintedItemToDB 将铸造的NFT信息插入到数据库中
-func insertMintedItemToDB(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, collectionAddress, tokenID, owner, name string) error {
-	// 构造链名称
-	chainName := getChainName(chainID)
-
+// insertMintedItemToDB 将铸造的NFT信息插入到数据库中, 重复请求或索引器已写入时保留已有记录
+func insertMintedItemToDB(ctx context.Context, svcCtx *svc.ServerCtx, chainName string, chainID int, collectionAddress, tokenID, owner, creator, name string) error {
 	// 构造要插入的数据
 	item := map[string]interface{}{
 		"chain_id":           chainID,
 		"collection_address": collectionAddress,
 		"token_id":           tokenID,
 		"owner":              owner,
+		"creator":            creator,
+		"supply":             1,
 		"name":               name,
 		"is_opensea_banned":  false, // 默认设置为false
 		"create_time":        time.Now().Unix(),
@@ -802,7 +745,7 @@ func insertMintedItemToDB(ctx context.Context, svcCtx *svc.ServerCtx, chainID in
 
 	// 获取表名并插入数据
 	tableName := multi.ItemTableName(chainName)
-	if err := svcCtx.Dao.DB.WithContext(ctx).Table(tableName).Create(&item).Error; err != nil {
+	if err := svcCtx.Dao.DB.WithContext(ctx).Table(tableName).Clauses(clause.OnConflict{DoNothing: true}).Create(&item).Error; err != nil {
 		return errors.Wrap(err, "failed to insert minted item to database")
 	}
 
@@ -826,3 +769,99 @@ func getChainName(chainID int) string {
 		return fmt.Sprintf("chain_%d", chainID) // 默认格式
 	}
 }
+
+// GetManagedTx 按请求ID查询 MetaNodeNFT 铸造交易状态
+func GetManagedTx(ctx context.Context, svcCtx *svc.ServerCtx, requestID string) (*typesv1.ManagedTxInfo, error) {
+	_, record, err := findManagedTx(ctx, svcCtx, MetaNodeTxCaller, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toManagedTxInfo(record), nil
+}
+
+// GetUserMintTx 按请求ID查询用户通过 /collections/:address/mint 发起的铸造交易状态, 只能查询本人的请求
+func GetUserMintTx(ctx context.Context, svcCtx *svc.ServerCtx, userAddr, requestID string) (*typesv1.ManagedTxInfo, error) {
+	_, record, err := findManagedTx(ctx, svcCtx, strings.ToLower(userAddr), requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toManagedTxInfo(record), nil
+}
+
+// findManagedTx 在各链的交易管理器中查找调用方请求ID对应的交易
+func findManagedTx(ctx context.Context, svcCtx *svc.ServerCtx, caller, requestID string) (*txmanager.Manager, *base.ManagedTx, error) {
+	for _, manager := range svcCtx.TxManagers {
+		record, err := manager.Get(ctx, caller, requestID)
+		if errors.Is(err, txmanager.ErrTxNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return manager, record, nil
+	}
+
+	return nil, nil, txmanager.ErrTxNotFound
+}
+
+func toManagedTxInfo(record *base.ManagedTx) *typesv1.ManagedTxInfo {
+	replaced := []string{}
+	if record.ReplacedHashes != "" {
+		replaced = strings.Split(record.ReplacedHashes, ",")
+	}
+
+	return &typesv1.ManagedTxInfo{
+		RequestID:      record.RequestId,
+		Caller:         record.Caller,
+		ChainID:        record.ChainId,
+		Signer:         record.Signer,
+		ToAddress:      record.ToAddress,
+		Nonce:          record.Nonce,
+		TxHash:         record.TxHash,
+		ReplacedHashes: replaced,
+		Status:         record.Status,
+		Attempts:       record.Attempts,
+		GasFeeCap:      record.GasFeeCap,
+		GasTipCap:      record.GasTipCap,
+		BlockNumber:    record.BlockNumber,
+		GasUsed:        record.GasUsed,
+		ErrorMsg:       record.ErrorMsg,
+		CreateTime:     record.CreateTime,
+		UpdateTime:     record.UpdateTime,
+	}
+}
+
+// AdminSpeedUpTx 提高费用重新发送待确认的交易, caller 为空时查找 MetaNodeNFT 铸造交易
+func AdminSpeedUpTx(ctx context.Context, svcCtx *svc.ServerCtx, caller, requestID string) (*typesv1.ManagedTxInfo, error) {
+	return adminReplaceTx(ctx, svcCtx, caller, requestID, AuditActionSpeedUpTx, (*txmanager.Manager).SpeedUp)
+}
+
+// AdminCancelTx 以同 nonce 的 0 值自转账取消待确认的交易, caller 为空时查找 MetaNodeNFT 铸造交易
+func AdminCancelTx(ctx context.Context, svcCtx *svc.ServerCtx, caller, requestID string) (*typesv1.ManagedTxInfo, error) {
+	return adminReplaceTx(ctx, svcCtx, caller, requestID, AuditActionCancelTx, (*txmanager.Manager).Cancel)
+}
+
+func adminReplaceTx(ctx context.Context, svcCtx *svc.ServerCtx, caller, requestID, action string,
+	replace func(*txmanager.Manager, context.Context, string, string) (*base.ManagedTx, error)) (*typesv1.ManagedTxInfo, error) {
+	if caller == "" {
+		caller = MetaNodeTxCaller
+	}
+	manager, before, err := findManagedTx(ctx, svcCtx, caller, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := replace(manager, ctx, caller, requestID)
+	var after *typesv1.ManagedTxInfo
+	if record != nil {
+		after = toManagedTxInfo(record)
+	}
+	recordAdminAudit(ctx, svcCtx, action, before.ChainId, requestID, toManagedTxInfo(before), after, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
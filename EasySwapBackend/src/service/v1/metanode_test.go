@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/config"
+	"github.com/ProjectsTask/EasySwapBackend/src/dao"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+func transferLog(from, to common.Address, tokenID int64) *ethtypes.Log {
+	return &ethtypes.Log{Topics: []common.Hash{
+		crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")),
+		common.BytesToHash(from.Bytes()),
+		common.BytesToHash(to.Bytes()),
+		common.BigToHash(big.NewInt(tokenID)),
+	}}
+}
+
+func TestParseTokenIDFromReceipt(t *testing.T) {
+	contractABI, err := abi.JSON(strings.NewReader(MetaNodeNFTABI))
+	assert.NoError(t, err)
+	receiver := common.HexToAddress(testReceiver)
+
+	// 跳过非铸造的转移事件
+	receipt := &ethtypes.Receipt{Logs: []*ethtypes.Log{
+		{Topics: []common.Hash{crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))}},
+		transferLog(common.HexToAddress(testMinter), receiver, 7),
+		transferLog(common.Address{}, receiver, 1001),
+	}}
+	tokenID, err := parseTokenIDFromReceipt(receipt, contractABI)
+	assert.NoError(t, err)
+	assert.Equal(t, "1001", tokenID)
+
+	_, err = parseTokenIDFromReceipt(&ethtypes.Receipt{Logs: []*ethtypes.Log{transferLog(common.HexToAddress(testMinter), receiver, 7)}}, contractABI)
+	assert.Error(t, err)
+	_, err = parseTokenIDFromReceipt(&ethtypes.Receipt{}, contractABI)
+	assert.Error(t, err)
+}
+
+func TestGetTransactionStatus(t *testing.T) {
+	assert.Equal(t, "confirmed", getTransactionStatus(&ethtypes.Receipt{Status: ethtypes.ReceiptStatusSuccessful}))
+	assert.Equal(t, "failed", getTransactionStatus(&ethtypes.Receipt{Status: ethtypes.ReceiptStatusFailed}))
+}
+
+func TestGetMetaNodeConfig(t *testing.T) {
+	svcCtx := &svc.ServerCtx{
+		C: &config.Config{MetaNode: &config.MetaNodeConfig{
+			ContractAddresses: map[string]string{"11155111": testMintCollection},
+		}},
+	}
+
+	conf, err := getMetaNodeConfig(svcCtx, 11155111)
+	assert.NoError(t, err)
+	assert.Equal(t, testMintCollection, conf.ContractAddress)
+	assert.Equal(t, int64(11155111), conf.ChainID)
+
+	_, err = getMetaNodeConfig(svcCtx, 8453)
+	assert.Error(t, err)
+	_, err = getMetaNodeConfig(&svc.ServerCtx{C: &config.Config{}}, 11155111)
+	assert.Error(t, err)
+}
+
+func TestValidateMetaNodeMintRequest(t *testing.T) {
+	assert.NoError(t, validateMetaNodeMintRequest(&types.MetaNodeMintRequest{ChainID: 11155111, ToAddress: testReceiver, TokenURI: "ipfs://token"}))
+
+	cases := map[string]types.MetaNodeMintRequest{
+		"missing to_address": {ChainID: 11155111, TokenURI: "ipfs://token"},
+		"invalid to_address": {ChainID: 11155111, ToAddress: "receiver", TokenURI: "ipfs://token"},
+		"missing token_uri":  {ChainID: 11155111, ToAddress: testReceiver},
+		"invalid chain_id":   {ToAddress: testReceiver, TokenURI: "ipfs://token"},
+	}
+	for name, req := range cases {
+		assert.Error(t, validateMetaNodeMintRequest(&req), name)
+	}
+}
+
+func TestInsertMintedItemToDB(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "mint.db")), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Table(multi.ItemTableName("sepolia")).AutoMigrate(&multi.Item{}))
+	// 模型中没有而表结构中存在的字段
+	assert.NoError(t, db.Exec("alter table ob_item_sepolia add column is_opensea_banned tinyint(1) default 0").Error)
+	assert.NoError(t, db.Exec("create unique index index_collection_token on ob_item_sepolia (collection_address, token_id)").Error)
+	svcCtx := &svc.ServerCtx{Dao: dao.New(ctx, db, nil)}
+
+	assert.NoError(t, insertMintedItemToDB(ctx, svcCtx, "sepolia", 11155111, testMintCollection, "1001", testReceiver, testMinter, "Test #1001"))
+	// 重复请求不覆盖已有记录
+	assert.NoError(t, insertMintedItemToDB(ctx, svcCtx, "sepolia", 11155111, testMintCollection, "1001", testMinter, testMinter, "Other"))
+
+	var count int64
+	assert.NoError(t, db.Table(multi.ItemTableName("sepolia")).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+	var item multi.Item
+	assert.NoError(t, db.Table(multi.ItemTableName("sepolia")).First(&item).Error)
+	assert.Equal(t, 11155111, item.ChainId)
+	assert.Equal(t, testMintCollection, item.CollectionAddress)
+	assert.Equal(t, "1001", item.TokenId)
+	assert.Equal(t, testReceiver, item.Owner)
+	assert.Equal(t, testMinter, item.Creator)
+	assert.Equal(t, int64(1), item.Supply)
+	assert.Equal(t, "Test #1001", item.Name)
+}
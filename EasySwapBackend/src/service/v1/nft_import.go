@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/nftchainservice"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/dao"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/mq"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+const (
+	importMaxUriLength  = 512  // meta_data_uri / image_uri 字段长度
+	importBlockSize     = 2000 // 单次查询 Transfer 事件的区块跨度
+	importQueryParallel = 4    // 并发查询的区块区间数
+	importTokenWorkers  = 5    // 并发处理 token 的协程数
+)
+
+// 同步任务状态
+const (
+	SyncTaskPending   = "pending"
+	SyncTaskRunning   = "running"
+	SyncTaskCompleted = "completed"
+	SyncTaskFailed    = "failed"
+	// 进程重启时仍未结束的任务
+	SyncTaskInterrupted = "interrupted"
+)
+
+// 同步任务类型
+const (
+	SyncTaskTypeContract = "contract"
+	SyncTaskTypeToken    = "token"
+)
+
+// syncReporter 同步任务进度回调, 每处理完一个 token 调用一次.
+// 由 syncTaskTracker 持锁调用, 快照按产生顺序到达
+type syncReporter func(task types.SyncTask)
+
+// syncTaskTracker 维护导入任务的运行状态, 并发安全
+type syncTaskTracker struct {
+	mu       sync.Mutex
+	task     types.SyncTask
+	reporter syncReporter
+}
+
+func newSyncTaskTracker(task types.SyncTask, reporter syncReporter) *syncTaskTracker {
+	return &syncTaskTracker{task: task, reporter: reporter}
+}
+
+func (t *syncTaskTracker) start() {
+	t.mu.Lock()
+	now := time.Now()
+	t.task.Status = SyncTaskRunning
+	t.task.StartedAt = &now
+	t.report(t.task)
+	t.mu.Unlock()
+}
+
+func (t *syncTaskTracker) setTotal(total int) {
+	t.mu.Lock()
+	t.task.TotalItems = total
+	t.report(t.task)
+	t.mu.Unlock()
+}
+
+// addTotal 合约导入按区块分段扫描, 每发现一批新 token 累加总数
+func (t *syncTaskTracker) addTotal(n int) {
+	if n == 0 {
+		return
+	}
+	t.mu.Lock()
+	t.task.TotalItems += n
+	t.report(t.task)
+	t.mu.Unlock()
+}
+
+// tokenDone 记录单个 token 处理结果
+func (t *syncTaskTracker) tokenDone(err error) {
+	t.mu.Lock()
+	t.task.ProcessedItems++
+	if err != nil {
+		t.task.FailedItems++
+		t.task.ErrorMsg = fmt.Sprintf("%d tokens failed, last error: %s", t.task.FailedItems, err.Error())
+	}
+	// 总数随扫描增长, 进度只增不减, 全部结束前最多 99
+	if t.task.TotalItems > 0 {
+		progress := t.task.ProcessedItems * 100 / t.task.TotalItems
+		if progress > 99 {
+			progress = 99
+		}
+		if progress > t.task.Progress {
+			t.task.Progress = progress
+		}
+	}
+	t.report(t.task)
+	t.mu.Unlock()
+}
+
+// finish 结束任务, err 不为空或全部 token 失败时任务标记为失败
+func (t *syncTaskTracker) finish(err error) types.SyncTask {
+	t.mu.Lock()
+	now := time.Now()
+	t.task.CompletedAt = &now
+	switch {
+	case err != nil:
+		t.task.Status = SyncTaskFailed
+		t.task.ErrorMsg = err.Error()
+	case t.task.TotalItems > 0 && t.task.FailedItems == t.task.TotalItems:
+		t.task.Status = SyncTaskFailed
+	default:
+		t.task.Status = SyncTaskCompleted
+		t.task.Progress = 100
+	}
+	task := t.task
+	t.report(task)
+	t.mu.Unlock()
+	return task
+}
+
+// report 须在持有 t.mu 时调用
+func (t *syncTaskTracker) report(task types.SyncTask) {
+	if t.reporter != nil {
+		t.reporter(task)
+	}
+}
+
+// importTokenJob 待导入的 token 及其在所在区块段中最后的接收地址
+type importTokenJob struct {
+	tokenID   string
+	lastOwner string
+}
+
+// runContractImport 从起始区块(未指定时为创世区块)分段遍历合约的 Transfer 历史,
+// 每段由 GetNFTTransferEventGoroutine 并发查询, 段内首次出现的 token 立即交给工作协程导入,
+// 不在内存中累积全部事件
+func runContractImport(ctx context.Context, svcCtx *svc.ServerCtx, tracker *syncTaskTracker, startBlock, endBlock int64) error {
+	task := tracker.task
+	nodeSrv, ok := svcCtx.NodeSrvs[task.ChainID]
+	if !ok {
+		return errors.New(fmt.Sprintf("unsupported chain id: %d", task.ChainID))
+	}
+
+	fromBlock := uint64(0)
+	if startBlock > 0 {
+		fromBlock = uint64(startBlock)
+	}
+	toBlock := uint64(endBlock)
+	if endBlock <= 0 {
+		latest, err := nodeSrv.NodeClient.BlockNumber()
+		if err != nil {
+			return errors.Wrap(err, "failed on get latest block number")
+		}
+		toBlock = latest
+	}
+	if fromBlock > toBlock {
+		return errors.New(fmt.Sprintf("invalid block range: %d > %d", fromBlock, toBlock))
+	}
+
+	jobCh := make(chan importTokenJob)
+	var wg sync.WaitGroup
+	for i := 0; i < importTokenWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobCh {
+				err := importToken(ctx, svcCtx, nodeSrv, task.ChainID, task.ContractAddr, job.tokenID, job.lastOwner)
+				if err != nil {
+					xzap.WithContext(ctx).Error("failed on import token", zap.String("task_id", task.ID),
+						zap.String("contract_addr", task.ContractAddr), zap.String("token_id", job.tokenID), zap.Error(err))
+				}
+				tracker.tokenDone(err)
+			}
+		}()
+	}
+	defer func() {
+		close(jobCh)
+		wg.Wait()
+	}()
+
+	// 已导入的 token 以链上 ownerOf 为准, 后续区块段中再次出现时无需重复导入
+	seen := make(map[string]struct{})
+	var transferCount int
+	const rangeSize = importBlockSize * importQueryParallel
+	for rangeStart := fromBlock; rangeStart <= toBlock; rangeStart += rangeSize {
+		rangeEnd := rangeStart + rangeSize - 1
+		if rangeEnd > toBlock {
+			rangeEnd = toBlock
+		}
+
+		transferLogs, err := nodeSrv.GetNFTTransferEventGoroutine(rangeStart, rangeEnd, importBlockSize, importQueryParallel, task.ContractAddr)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed on get nft transfer events from %d to %d", rangeStart, rangeEnd))
+		}
+		transferCount += len(transferLogs)
+
+		var jobs []importTokenJob
+		jobIndex := make(map[string]int)
+		for _, log := range transferLogs {
+			if _, ok := seen[log.TokenID]; ok {
+				continue
+			}
+			if i, ok := jobIndex[log.TokenID]; ok {
+				jobs[i].lastOwner = log.To
+				continue
+			}
+			jobIndex[log.TokenID] = len(jobs)
+			jobs = append(jobs, importTokenJob{tokenID: log.TokenID, lastOwner: log.To})
+		}
+		for _, job := range jobs {
+			seen[job.tokenID] = struct{}{}
+		}
+		tracker.addTotal(len(jobs))
+
+		for _, job := range jobs {
+			select {
+			case <-ctx.Done():
+				return errors.Wrap(ctx.Err(), "import canceled")
+			case jobCh <- job:
+			}
+		}
+	}
+
+	xzap.WithContext(ctx).Info("contract import transfer events scanned",
+		zap.String("task_id", task.ID), zap.String("contract_addr", task.ContractAddr),
+		zap.Uint64("from_block", fromBlock), zap.Uint64("to_block", toBlock),
+		zap.Int("transfer_count", transferCount), zap.Int("token_count", len(seen)))
+
+	return nil
+}
+
+// runTokenImport 导入单个 token
+func runTokenImport(ctx context.Context, svcCtx *svc.ServerCtx, tracker *syncTaskTracker) error {
+	task := tracker.task
+	nodeSrv, ok := svcCtx.NodeSrvs[task.ChainID]
+	if !ok {
+		return errors.New(fmt.Sprintf("unsupported chain id: %d", task.ChainID))
+	}
+
+	tracker.setTotal(1)
+	err := importToken(ctx, svcCtx, nodeSrv, task.ChainID, task.ContractAddr, task.TokenID, "")
+	tracker.tokenDone(err)
+
+	return err
+}
+
+// importToken 获取 token 的链上 owner 与元数据并写入 item / item_external / item_trait
+// lastOwner 为 Transfer 历史中最后的接收地址, ownerOf 调用失败时作为兜底
+func importToken(ctx context.Context, svcCtx *svc.ServerCtx, nodeSrv *nftchainservice.Service, chainID int64, collectionAddr, tokenID, lastOwner string) error {
+	owner, err := nodeSrv.FetchNftOwner(collectionAddr, tokenID)
+	if err != nil {
+		if lastOwner == "" {
+			return errors.Wrap(err, "failed on fetch nft owner")
+		}
+		owner = common.HexToAddress(lastOwner)
+	}
+	if owner == (common.Address{}) {
+		// 已销毁的 token 不再导入
+		return nil
+	}
+
+	item := &dao.ImportedItem{
+		ChainID:           chainID,
+		CollectionAddress: collectionAddr,
+		TokenID:           tokenID,
+		Owner:             owner.String(),
+	}
+
+	metadata, tokenUri, err := nodeSrv.FetchOnChainMetadataWithUri(collectionAddr, tokenID)
+	if err != nil {
+		xzap.WithContext(ctx).Warn("failed on fetch nft metadata, add to refresh queue",
+			zap.String("contract_addr", collectionAddr), zap.String("token_id", tokenID), zap.Error(err))
+		item.MetadataFailed = true
+	} else {
+		item.Name = metadata.Name
+		item.MetaDataUri = checkUriLength(ctx, collectionAddr, tokenID, "meta_data_uri", tokenUri)
+		item.ImageUri = checkUriLength(ctx, collectionAddr, tokenID, "image_uri", metadata.Image)
+		for _, attr := range metadata.Attributes {
+			if attr == nil || attr.TraitType == "" {
+				continue
+			}
+			item.Traits = append(item.Traits, multi.ItemTrait{
+				Trait:      attr.TraitType,
+				TraitValue: attr.Value,
+			})
+		}
+	}
+
+	if err := svcCtx.Dao.UpsertImportedItem(ctx, nodeSrv.ChainName, item); err != nil {
+		return errors.Wrap(err, "failed on save imported item")
+	}
+
+	if item.MetadataFailed {
+		if err := mq.AddSingleItemToRefreshMetadataQueue(svcCtx.KvStore, svcCtx.C.ProjectCfg.Name, nodeSrv.ChainName, chainID, collectionAddr, tokenID); err != nil {
+			xzap.WithContext(ctx).Error("failed on add item to refresh metadata queue",
+				zap.String("contract_addr", collectionAddr), zap.String("token_id", tokenID), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// checkUriLength 超出字段长度的 uri (如 base64 内联图片) 截断后无法使用, 不写入并记录日志
+func checkUriLength(ctx context.Context, collectionAddr, tokenID, field, uri string) string {
+	if len(uri) <= importMaxUriLength {
+		return uri
+	}
+	xzap.WithContext(ctx).Warn("uri exceeds column length, skip saving",
+		zap.String("contract_addr", collectionAddr), zap.String("token_id", tokenID),
+		zap.String("field", field), zap.Int("length", len(uri)))
+	return ""
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/ProjectsTask/EasySwapBase/errcode"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/service/marketpush"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+)
+
+// maxStreamFilters 单个订阅每类条件的最大数量
+const maxStreamFilters = 100
+
+// SubscribeMarketEvents 按合集、token 或用户地址订阅链上的挂单、出价、取消、成交与地板价变化事件
+// cursor 为客户端收到的最后一个事件编号, 为 0 时只推送新事件
+func SubscribeMarketEvents(ctx context.Context, svcCtx *svc.ServerCtx, chainID int64, collections, tokens, users []string,
+	cursor uint64) (*marketpush.Subscription, error) {
+	hub, ok := svcCtx.MarketHubs[chainID]
+	if !ok {
+		return nil, errcode.NewCustomErr("unsupported chain_id")
+	}
+	if len(collections) > maxStreamFilters || len(tokens) > maxStreamFilters || len(users) > maxStreamFilters {
+		return nil, errcode.NewCustomErr("too many subscription filters")
+	}
+	for _, addr := range append(append([]string{}, collections...), users...) {
+		if !common.IsHexAddress(addr) {
+			return nil, errcode.NewCustomErr("invalid address: " + addr)
+		}
+	}
+	for _, token := range tokens {
+		collection, tokenID, ok := strings.Cut(token, ":")
+		if !ok || !common.IsHexAddress(collection) || tokenID == "" {
+			return nil, errcode.NewCustomErr("invalid token, expect <collection>:<token_id>: " + token)
+		}
+	}
+
+	sub, err := hub.Subscribe(ctx, marketpush.NewFilter(collections, tokens, users), cursor)
+	if errors.Is(err, marketpush.ErrTooManySubscribers) {
+		return nil, errcode.NewCustomErr("too many subscribers, retry later", http.StatusServiceUnavailable)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on subscribe market events")
+	}
+
+	return sub, nil
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+const (
+	interruptedTaskMsg = "task interrupted: owner instance lease expired"
+	// syncTaskLease 任务租约时长, 执行实例定期续约, 租约过期视为执行实例已退出
+	syncTaskLease = time.Minute
+	// syncTaskFlushInterval 任务进度写入与续约的间隔
+	syncTaskFlushInterval = 5 * time.Second
+)
+
+// syncTaskOwner 本服务实例标识, 写入任务记录以区分执行实例
+var syncTaskOwner = newSyncTaskOwner()
+
+func newSyncTaskOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+	if len(hostname) > 64 {
+		hostname = hostname[:64]
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewString()[:8])
+}
+
+// createSyncTask 持久化新建的同步任务, 返回写入任务表的进度回调
+func createSyncTask(ctx context.Context, svcCtx *svc.ServerCtx, task types.SyncTask, startBlock, endBlock int64) (syncReporter, error) {
+	model := toSyncTaskModel(task)
+	model.StartBlock = startBlock
+	model.EndBlock = endBlock
+	model.Owner = syncTaskOwner
+	model.LeaseExpireTime = time.Now().Add(syncTaskLease).UnixMilli()
+	if err := svcCtx.Dao.CreateSyncTask(ctx, model); err != nil {
+		return nil, errors.Wrap(err, "failed to create sync task")
+	}
+
+	writer := newSyncTaskWriter(task, svcCtx.Dao.UpdateSyncTask, syncTaskLease, syncTaskFlushInterval)
+	return writer.report, nil
+}
+
+// syncTaskWriter 节流写入任务进度: 进度只更新内存快照, 定时写入并续约租约, 状态变化时立即写入.
+// 写入串行执行且每次写入最新快照, 任务表中的进度单调不减
+type syncTaskWriter struct {
+	save  func(ctx context.Context, task *base.SyncTask) error
+	lease time.Duration
+
+	writeMu  sync.Mutex
+	mu       sync.Mutex
+	latest   types.SyncTask
+	written  string // 最近一次写入成功的状态
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newSyncTaskWriter(task types.SyncTask, save func(ctx context.Context, task *base.SyncTask) error, lease, interval time.Duration) *syncTaskWriter {
+	w := &syncTaskWriter{
+		save:    save,
+		lease:   lease,
+		latest:  task,
+		written: task.Status,
+		done:    make(chan struct{}),
+	}
+	go w.loop(interval)
+
+	return w
+}
+
+// report 实现 syncReporter, 由 syncTaskTracker 持锁调用, 快照按产生顺序到达
+func (w *syncTaskWriter) report(task types.SyncTask) {
+	w.mu.Lock()
+	w.latest = task
+	statusChanged := task.Status != w.written
+	w.mu.Unlock()
+
+	if statusChanged {
+		w.flush()
+	}
+}
+
+func (w *syncTaskWriter) loop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			// 进度未变化时也写入, 用于续约
+			w.flush()
+		}
+	}
+}
+
+func (w *syncTaskWriter) flush() {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	w.mu.Lock()
+	task := w.latest
+	w.mu.Unlock()
+
+	finished := !isSyncTaskUnfinished(task.Status)
+	model := toSyncTaskModel(task)
+	model.Owner = syncTaskOwner
+	if !finished {
+		model.LeaseExpireTime = time.Now().Add(w.lease).UnixMilli()
+	}
+	if err := w.save(context.Background(), model); err != nil {
+		xzap.WithContext(context.Background()).Error("failed on persist sync task progress",
+			zap.String("task_id", task.ID), zap.Error(err))
+		return
+	}
+
+	w.mu.Lock()
+	w.written = task.Status
+	w.mu.Unlock()
+	if finished {
+		w.stopOnce.Do(func() { close(w.done) })
+	}
+}
+
+func isSyncTaskUnfinished(status string) bool {
+	return status == SyncTaskPending || status == SyncTaskRunning
+}
+
+// RecoverSyncTasks 将租约已过期(执行实例已退出)的未结束任务标记为中断, 并重置对应合约的同步状态.
+// 其他存活实例持有的任务会持续续约, 不受影响
+func RecoverSyncTasks(ctx context.Context, svcCtx *svc.ServerCtx) error {
+	unfinished := []string{SyncTaskPending, SyncTaskRunning}
+	now := time.Now().UnixMilli()
+	tasks, err := svcCtx.Dao.QueryExpiredSyncTasks(ctx, unfinished, now)
+	if err != nil {
+		return errors.Wrap(err, "failed to query expired sync tasks")
+	}
+
+	var count int
+	for _, task := range tasks {
+		marked, err := svcCtx.Dao.MarkSyncTaskInterrupted(ctx, task.TaskId, unfinished, now, SyncTaskInterrupted, interruptedTaskMsg)
+		if err != nil {
+			return errors.Wrap(err, "failed to mark sync task interrupted")
+		}
+		if !marked {
+			continue
+		}
+		count++
+
+		if task.TaskType != SyncTaskTypeContract {
+			continue
+		}
+		if err := svcCtx.Dao.AdminSetContractSyncStatus(ctx, task.ChainId, task.ContractAddress, false); err != nil {
+			xzap.WithContext(ctx).Error("failed on reset contract sync status",
+				zap.String("task_id", task.TaskId), zap.String("contract_addr", task.ContractAddress), zap.Error(err))
+		}
+	}
+	if count > 0 {
+		xzap.WithContext(ctx).Warn("sync tasks interrupted by expired lease", zap.Int("count", count))
+	}
+
+	return nil
+}
+
+// RecoverSyncTasksLoop 定期回收租约过期的任务, 其他实例退出后其任务不会一直停留在运行中
+func RecoverSyncTasksLoop(ctx context.Context, svcCtx *svc.ServerCtx) {
+	ticker := time.NewTicker(syncTaskLease)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := RecoverSyncTasks(ctx, svcCtx); err != nil {
+				xzap.WithContext(ctx).Error("failed on recover sync tasks", zap.Error(err))
+			}
+		}
+	}
+}
+
+// toSyncTaskModel 转换为任务表模型
+func toSyncTaskModel(task types.SyncTask) *base.SyncTask {
+	model := &base.SyncTask{
+		TaskId:          task.ID,
+		TaskType:        task.TaskType,
+		ChainId:         task.ChainID,
+		ContractAddress: strings.ToLower(task.ContractAddr),
+		TokenId:         task.TokenID,
+		Status:          task.Status,
+		Progress:        task.Progress,
+		TotalItems:      task.TotalItems,
+		ProcessedItems:  task.ProcessedItems,
+		FailedItems:     task.FailedItems,
+		ErrorMsg:        task.ErrorMsg,
+		CreateTime:      task.CreatedAt.UnixMilli(),
+	}
+	if len(model.ErrorMsg) > 1024 {
+		model.ErrorMsg = model.ErrorMsg[:1024]
+	}
+	if task.StartedAt != nil {
+		model.StartedTime = task.StartedAt.UnixMilli()
+	}
+	if task.CompletedAt != nil {
+		model.CompletedTime = task.CompletedAt.UnixMilli()
+	}
+
+	return model
+}
+
+// toSyncTaskInfo 转换为接口返回结构
+func toSyncTaskInfo(model base.SyncTask) types.SyncTask {
+	task := types.SyncTask{
+		ID:             model.TaskId,
+		TaskType:       model.TaskType,
+		ContractAddr:   model.ContractAddress,
+		TokenID:        model.TokenId,
+		ChainID:        model.ChainId,
+		Status:         model.Status,
+		Progress:       model.Progress,
+		TotalItems:     model.TotalItems,
+		ProcessedItems: model.ProcessedItems,
+		FailedItems:    model.FailedItems,
+		ErrorMsg:       model.ErrorMsg,
+		CreatedAt:      time.UnixMilli(model.CreateTime),
+	}
+	if model.StartedTime > 0 {
+		startedAt := time.UnixMilli(model.StartedTime)
+		task.StartedAt = &startedAt
+	}
+	if model.CompletedTime > 0 {
+		completedAt := time.UnixMilli(model.CompletedTime)
+		task.CompletedAt = &completedAt
+	}
+
+	return task
+}
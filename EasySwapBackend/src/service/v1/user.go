@@ -1,23 +1,20 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"encoding/hex"
-	"fmt"
-	"io"
 	"strings"
 	"time"
 
 	"github.com/ProjectsTask/EasySwapBase/errcode"
 	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
 	"github.com/ProjectsTask/EasySwapBackend/src/api/middleware"
+	"github.com/ProjectsTask/EasySwapBackend/src/common/session"
+	"github.com/ProjectsTask/EasySwapBackend/src/common/utils"
+	"github.com/ProjectsTask/EasySwapBackend/src/config"
 	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
 	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
 )
@@ -26,31 +23,92 @@ func getUserLoginMsgCacheKey(address string) string {
 	return middleware.CR_LOGIN_MSG_KEY + ":" + strings.ToLower(address)
 }
 
-func getUserLoginTokenCacheKey(address string) string {
-	return middleware.CR_LOGIN_KEY + ":" + strings.ToLower(address)
+// 未配置 [login] 时使用的默认值
+const (
+	defaultLoginDomain    = "easyswap.link"
+	defaultLoginURI       = "https://easyswap.link"
+	defaultLoginStatement = "Welcome to EasySwap!"
+	defaultLoginNonceTTL  = 10 * 60 // second
+	loginClockSkew        = time.Minute
+)
+
+func getLoginConfig(svcCtx *svc.ServerCtx) config.LoginConfig {
+	loginCfg := config.LoginConfig{}
+	if svcCtx.C != nil && svcCtx.C.Login != nil {
+		loginCfg = *svcCtx.C.Login
+	}
+	if loginCfg.Domain == "" {
+		loginCfg.Domain = defaultLoginDomain
+	}
+	if loginCfg.URI == "" {
+		loginCfg.URI = defaultLoginURI
+	}
+	if loginCfg.Statement == "" {
+		loginCfg.Statement = defaultLoginStatement
+	}
+	if loginCfg.NonceTTL <= 0 {
+		loginCfg.NonceTTL = defaultLoginNonceTTL
+	}
+
+	return loginCfg
 }
 
-func UserLogin(ctx context.Context, svcCtx *svc.ServerCtx, req types.LoginReq) (*types.UserLoginInfo, error) {
-	res := types.UserLoginInfo{}
+func isChainSupported(svcCtx *svc.ServerCtx, chainID int) bool {
+	for _, chain := range svcCtx.C.ChainSupported {
+		if chain.ChainID == chainID {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyLoginMessage 校验 SIWE 登录消息的域名、地址、链ID与时间, 并校验签名
+func verifyLoginMessage(svcCtx *svc.ServerCtx, req types.LoginReq) (*utils.SiweMessage, error) {
+	loginCfg := getLoginConfig(svcCtx)
+
+	msg, err := utils.ParseSiweMessage(req.Message)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid login message")
+	}
+	if msg.Domain != loginCfg.Domain {
+		return nil, errors.New("login message domain mismatch")
+	}
+	if !strings.EqualFold(msg.Address, req.Address) {
+		return nil, errors.New("login message address mismatch")
+	}
+	if msg.ChainID != req.ChainID || !isChainSupported(svcCtx, msg.ChainID) {
+		return nil, errors.New("login message chain id mismatch")
+	}
+	if err := msg.VerifyTime(time.Now(), time.Duration(loginCfg.NonceTTL)*time.Second, loginClockSkew); err != nil {
+		return nil, err
+	}
+	if !utils.VerifyPersonalSign(req.Address, req.Message, req.Signature) {
+		return nil, errors.New("invalid signature")
+	}
 
-	//todo: add verify signature
-	//ok := verifySignature(req.Message, req.Signature, req.PublicKey)
-	//if !ok {
-	//	return nil, errors.New("invalid signature")
-	//}
+	return msg, nil
+}
+
+// UserLogin 校验登录签名并为该设备创建会话
+func UserLogin(ctx context.Context, svcCtx *svc.ServerCtx, req types.LoginReq, device session.Device) (*types.UserLoginInfo, error) {
+	res := types.UserLoginInfo{}
 
-	cachedUUID, err := svcCtx.KvStore.Get(getUserLoginMsgCacheKey(req.Address))
-	if cachedUUID == "" || err != nil {
+	cachedNonce, err := svcCtx.KvStore.Get(getUserLoginMsgCacheKey(req.Address))
+	if cachedNonce == "" || err != nil {
 		return nil, errcode.ErrTokenExpire
 	}
 
-	splits := strings.Split(req.Message, "Nonce:")
-	if len(splits) != 2 {
+	msg, err := verifyLoginMessage(svcCtx, req)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Nonce != cachedNonce {
 		return nil, errcode.ErrTokenExpire
 	}
 
-	loginUUID := strings.Trim(splits[1], "\n")
-	if loginUUID != cachedUUID {
+	// 签名校验通过后消费 nonce, 同一登录消息只能使用一次
+	consumedNonce, err := svcCtx.KvStore.GetDel(getUserLoginMsgCacheKey(req.Address))
+	if err != nil || consumedNonce != msg.Nonce {
 		return nil, errcode.ErrTokenExpire
 	}
 
@@ -78,72 +136,154 @@ func UserLogin(ctx context.Context, svcCtx *svc.ServerCtx, req types.LoginReq) (
 		}
 	}
 
-	tokenKey := getUserLoginTokenCacheKey(req.Address)
-	userToken, err := AesEncryptOFB([]byte(tokenKey), []byte(middleware.CR_LOGIN_SALT))
+	token, sess, err := svcCtx.Sessions.Create(ctx, req.Address, device)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed on get user token")
+		return nil, errors.Wrap(err, "failed on create user session")
 	}
 
-	if err := CacheUserToken(svcCtx, tokenKey, uuid.NewString()); err != nil {
-		return nil, err
+	res.Token = token
+	res.SessionID = sess.ID
+	res.ExpiresAt = sess.ExpiresAt
+	res.IsAllowed = user.IsAllowed
+
+	return &res, nil
+}
+
+func genLoginMessage(loginCfg config.LoginConfig, address string, chainID int, nonce string) string {
+	msg := utils.SiweMessage{
+		Domain:    loginCfg.Domain,
+		Address:   common.HexToAddress(address).Hex(),
+		Statement: loginCfg.Statement,
+		URI:       loginCfg.URI,
+		Version:   "1",
+		ChainID:   chainID,
+		Nonce:     nonce,
+		IssuedAt:  time.Now(),
 	}
+	expirationTime := msg.IssuedAt.Add(time.Duration(loginCfg.NonceTTL) * time.Second)
+	msg.ExpirationTime = &expirationTime
 
-	res.Token = hex.EncodeToString(userToken)
-	res.IsAllowed = user.IsAllowed
+	return msg.String()
+}
+
+func GetUserLoginMsg(ctx context.Context, svcCtx *svc.ServerCtx, address string, chainID int) (*types.UserLoginMsgResp, error) {
+	if !common.IsHexAddress(address) {
+		return nil, errors.New("invalid user address")
+	}
+	if chainID == 0 && len(svcCtx.C.ChainSupported) > 0 {
+		chainID = svcCtx.C.ChainSupported[0].ChainID
+	}
+	if !isChainSupported(svcCtx, chainID) {
+		return nil, errors.New("unsupported chain id")
+	}
 
-	return &res, err
+	loginCfg := getLoginConfig(svcCtx)
+	// SIWE nonce 只允许字母数字
+	nonce := strings.ReplaceAll(uuid.NewString(), "-", "")
+	loginMsg := genLoginMessage(loginCfg, address, chainID, nonce)
+	if err := svcCtx.KvStore.Setex(getUserLoginMsgCacheKey(address), nonce, loginCfg.NonceTTL); err != nil {
+		return nil, errors.Wrap(err, "failed on generate login msg")
+	}
+
+	return &types.UserLoginMsgResp{Address: address, Message: loginMsg, Nonce: nonce}, nil
 }
 
-// 把token写入redis
-func CacheUserToken(svcCtx *svc.ServerCtx, tokenKey, token string) error {
-	if err := svcCtx.KvStore.Setex(tokenKey, token, 30*24*60*60); err != nil {
-		return err
+func GetSigStatusMsg(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string) (*types.UserSignStatusResp, error) {
+	isSigned, err := svcCtx.Dao.GetUserSigStatus(ctx, userAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get user sign status")
 	}
 
-	return nil
+	return &types.UserSignStatusResp{IsSigned: isSigned}, nil
 }
 
-func AesEncryptOFB(data []byte, key []byte) ([]byte, error) {
-	data = PKCS7Padding(data, aes.BlockSize)
-	block, _ := aes.NewCipher([]byte(key))
-	out := make([]byte, aes.BlockSize+len(data))
-	iv := out[:aes.BlockSize]
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
-		return nil, err
+// UserLogout 注销当前请求携带的会话
+func UserLogout(ctx context.Context, svcCtx *svc.ServerCtx, sessions []*session.Session) (*types.UserLogoutResp, error) {
+	res := types.UserLogoutResp{}
+	for _, s := range sessions {
+		revoked, err := svcCtx.Sessions.Revoke(ctx, s.Address, s.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on revoke user session")
+		}
+		if revoked {
+			res.Revoked++
+		}
 	}
 
-	stream := cipher.NewOFB(block, iv)
-	stream.XORKeyStream(out[aes.BlockSize:], data)
-	return out, nil
+	return &res, nil
 }
 
-// 补码
-// AES加密数据块分组长度必须为128bit(byte[16])，密钥长度可以是128bit(byte[16])、192bit(byte[24])、256bit(byte[32])中的任意一个。
-func PKCS7Padding(ciphertext []byte, blocksize int) []byte {
-	padding := blocksize - len(ciphertext)%blocksize
-	padtext := bytes.Repeat([]byte{byte(padding)}, padding)
-	return append(ciphertext, padtext...)
+// UserLogoutAll 注销当前登录地址在所有设备上的会话
+func UserLogoutAll(ctx context.Context, svcCtx *svc.ServerCtx, sessions []*session.Session) (*types.UserLogoutResp, error) {
+	res := types.UserLogoutResp{}
+	for _, addr := range sessionAddresses(sessions) {
+		count, err := svcCtx.Sessions.RevokeAll(ctx, addr)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on revoke user sessions")
+		}
+		res.Revoked += count
+	}
+
+	return &res, nil
 }
 
-func genLoginTemplate(nonce string) string {
-	return fmt.Sprintf("Welcome to EasySwap!\nNonce:%s", nonce)
+// UserRevokeSession 注销当前登录地址下的指定会话(如其他设备)
+func UserRevokeSession(ctx context.Context, svcCtx *svc.ServerCtx, sessions []*session.Session, sessionID string) (*types.UserLogoutResp, error) {
+	res := types.UserLogoutResp{}
+	for _, addr := range sessionAddresses(sessions) {
+		revoked, err := svcCtx.Sessions.Revoke(ctx, addr, sessionID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on revoke user session")
+		}
+		if revoked {
+			res.Revoked++
+			return &res, nil
+		}
+	}
+
+	return nil, errors.New("session not found")
 }
 
-func GetUserLoginMsg(ctx context.Context, svcCtx *svc.ServerCtx, address string) (*types.UserLoginMsgResp, error) {
-	uuid := uuid.NewString()
-	loginMsg := genLoginTemplate(uuid)
-	if err := svcCtx.KvStore.Setex(getUserLoginMsgCacheKey(address), uuid, 72*60*60); err != nil {
-		return nil, errors.Wrap(err, "failed on generate login msg")
+// GetUserSessions 列出当前登录地址的所有会话
+func GetUserSessions(ctx context.Context, svcCtx *svc.ServerCtx, sessions []*session.Session) (*types.UserSessionsResp, error) {
+	current := make(map[string]bool)
+	for _, s := range sessions {
+		current[s.ID] = true
 	}
 
-	return &types.UserLoginMsgResp{Address: address, Message: loginMsg, Nonce: uuid}, nil
+	res := types.UserSessionsResp{}
+	for _, addr := range sessionAddresses(sessions) {
+		list, err := svcCtx.Sessions.List(ctx, addr)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on list user sessions")
+		}
+		for _, s := range list {
+			res.Result = append(res.Result, types.UserSessionInfo{
+				SessionID: s.ID,
+				Address:   s.Address,
+				ClientIp:  s.ClientIp,
+				UserAgent: s.UserAgent,
+				CreatedAt: s.CreatedAt,
+				ExpiresAt: s.ExpiresAt,
+				Current:   current[s.ID],
+			})
+		}
+	}
+
+	return &res, nil
 }
 
-func GetSigStatusMsg(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string) (*types.UserSignStatusResp, error) {
-	isSigned, err := svcCtx.Dao.GetUserSigStatus(ctx, userAddr)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed on get user sign status")
+// sessionAddresses 会话对应的地址(去重)
+func sessionAddresses(sessions []*session.Session) []string {
+	seen := make(map[string]bool)
+	var addrs []string
+	for _, s := range sessions {
+		if seen[s.Address] {
+			continue
+		}
+		seen[s.Address] = true
+		addrs = append(addrs, s.Address)
 	}
 
-	return &types.UserSignStatusResp{IsSigned: isSigned}, nil
+	return addrs
 }
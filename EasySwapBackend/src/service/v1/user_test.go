@@ -0,0 +1,88 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/common/utils"
+	"github.com/ProjectsTask/EasySwapBackend/src/config"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+func newLoginTestCtx() *svc.ServerCtx {
+	return &svc.ServerCtx{C: &config.Config{
+		ChainSupported: []*config.ChainSupported{
+			{Name: "sepolia", ChainID: 11155111},
+			{Name: "base", ChainID: 8453},
+		},
+		Login: &config.LoginConfig{Domain: "market.easyswap.link", URI: "https://market.easyswap.link", NonceTTL: 600},
+	}}
+}
+
+func personalSign(t *testing.T, key *ecdsa.PrivateKey, message string) string {
+	signature, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	assert.NoError(t, err)
+	signature[crypto.RecoveryIDOffset] += 27
+	return hexutil.Encode(signature)
+}
+
+func TestVerifyLoginMessage(t *testing.T) {
+	svcCtx := newLoginTestCtx()
+	loginCfg := getLoginConfig(svcCtx)
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	otherKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	nonce := "0123456789abcdef"
+
+	newReq := func(message string, chainID int) types.LoginReq {
+		return types.LoginReq{ChainID: chainID, Message: message, Signature: personalSign(t, key, message), Address: address}
+	}
+
+	// 非第一个配置链上的钱包同样可以登录
+	for _, chain := range svcCtx.C.ChainSupported {
+		message := genLoginMessage(loginCfg, address, chain.ChainID, nonce)
+		msg, err := verifyLoginMessage(svcCtx, newReq(message, chain.ChainID))
+		assert.NoError(t, err, chain.Name)
+		assert.Equal(t, nonce, msg.Nonce)
+		assert.Equal(t, chain.ChainID, msg.ChainID)
+	}
+
+	message := genLoginMessage(loginCfg, address, 11155111, nonce)
+	otherDomainCfg := loginCfg
+	otherDomainCfg.Domain = "evil.example"
+	expired := utils.SiweMessage{
+		Domain:   loginCfg.Domain,
+		Address:  address,
+		URI:      loginCfg.URI,
+		Version:  "1",
+		ChainID:  11155111,
+		Nonce:    nonce,
+		IssuedAt: time.Now().Add(-time.Hour),
+	}
+
+	cases := map[string]types.LoginReq{
+		"sign nonce only":     {ChainID: 11155111, Message: nonce, Signature: personalSign(t, key, nonce), Address: address},
+		"domain mismatch":     newReq(genLoginMessage(otherDomainCfg, address, 11155111, nonce), 11155111),
+		"req chain mismatch":  newReq(message, 8453),
+		"unsupported chain":   newReq(genLoginMessage(loginCfg, address, 1, nonce), 1),
+		"expired":             newReq(expired.String(), 11155111),
+		"signed by other key": {ChainID: 11155111, Message: message, Signature: personalSign(t, otherKey, message), Address: address},
+	}
+	addressMismatch := newReq(message, 11155111)
+	addressMismatch.Address = crypto.PubkeyToAddress(otherKey.PublicKey).Hex()
+	cases["address mismatch"] = addressMismatch
+
+	for name, req := range cases {
+		_, err := verifyLoginMessage(svcCtx, req)
+		assert.Error(t, err, name)
+	}
+}
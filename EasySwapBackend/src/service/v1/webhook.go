@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+
+	"github.com/ProjectsTask/EasySwapBackend/src/api/middleware"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/svc"
+	"github.com/ProjectsTask/EasySwapBackend/src/service/webhook"
+	"github.com/ProjectsTask/EasySwapBackend/src/types/v1"
+)
+
+// AdminGetWebhooks 获取 webhook 订阅列表
+func AdminGetWebhooks(ctx context.Context, svcCtx *svc.ServerCtx) (*types.AdminGetWebhooksResp, error) {
+	subs, err := svcCtx.Dao.QueryWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get webhook subscriptions")
+	}
+
+	return &types.AdminGetWebhooksResp{Subscriptions: subs}, nil
+}
+
+// AdminGetWebhook 获取单个 webhook 订阅
+func AdminGetWebhook(ctx context.Context, svcCtx *svc.ServerCtx, id int64) (*base.WebhookSubscription, error) {
+	sub, err := svcCtx.Dao.QueryWebhookSubscription(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get webhook subscription")
+	}
+	if sub == nil {
+		return nil, errors.New("webhook subscription not found")
+	}
+
+	return sub, nil
+}
+
+// AdminCreateWebhook 创建 webhook 订阅, 未指定密钥时自动生成, 密钥只在创建时返回
+func AdminCreateWebhook(ctx context.Context, svcCtx *svc.ServerCtx, req types.AdminCreateWebhookReq) (*types.AdminCreateWebhookResp, error) {
+	if err := validateWebhookURL(ctx, req.URL); err != nil {
+		return nil, err
+	}
+	eventTypes, err := joinWebhookEventTypes(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	collections, err := joinWebhookCollections(req.Collections)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	sub := &base.WebhookSubscription{
+		Name:        req.Name,
+		Url:         req.URL,
+		Secret:      secret,
+		EventTypes:  eventTypes,
+		ChainId:     req.ChainID,
+		Collections: collections,
+		Enabled:     enabled,
+	}
+	if identity, ok := middleware.GetAdminIdentity(ctx); ok {
+		sub.CreatedBy = identity.Address
+	}
+
+	err = svcCtx.Dao.CreateWebhookSubscription(ctx, sub)
+	recordAdminAudit(ctx, svcCtx, AuditActionCreateWebhook, req.ChainID, req.URL, nil, sub, err)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create webhook subscription")
+	}
+
+	return &types.AdminCreateWebhookResp{Subscription: sub, Secret: secret}, nil
+}
+
+// AdminUpdateWebhook 修改 webhook 订阅
+func AdminUpdateWebhook(ctx context.Context, svcCtx *svc.ServerCtx, id int64, req types.AdminUpdateWebhookReq) (*types.AdminCommonResp, error) {
+	before, err := AdminGetWebhook(ctx, svcCtx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.URL != nil {
+		if err := validateWebhookURL(ctx, *req.URL); err != nil {
+			return nil, err
+		}
+		updates["url"] = *req.URL
+	}
+	if req.EventTypes != nil {
+		if updates["event_types"], err = joinWebhookEventTypes(*req.EventTypes); err != nil {
+			return nil, err
+		}
+	}
+	if req.ChainID != nil {
+		updates["chain_id"] = *req.ChainID
+	}
+	if req.Collections != nil {
+		if updates["collections"], err = joinWebhookCollections(*req.Collections); err != nil {
+			return nil, err
+		}
+	}
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+	if len(updates) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+
+	err = svcCtx.Dao.UpdateWebhookSubscription(ctx, id, updates)
+	recordAdminAudit(ctx, svcCtx, AuditActionUpdateWebhook, before.ChainId, strconv.FormatInt(id, 10), before, updates, err)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to update webhook subscription")
+	}
+
+	return &types.AdminCommonResp{
+		Success: true,
+		Message: "webhook 订阅更新成功",
+	}, nil
+}
+
+// AdminDeleteWebhook 删除 webhook 订阅
+func AdminDeleteWebhook(ctx context.Context, svcCtx *svc.ServerCtx, id int64) (*types.AdminCommonResp, error) {
+	before, err := AdminGetWebhook(ctx, svcCtx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = svcCtx.Dao.DeleteWebhookSubscription(ctx, id)
+	recordAdminAudit(ctx, svcCtx, AuditActionDeleteWebhook, before.ChainId, strconv.FormatInt(id, 10), before, nil, err)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to delete webhook subscription")
+	}
+
+	return &types.AdminCommonResp{
+		Success: true,
+		Message: "webhook 订阅删除成功",
+	}, nil
+}
+
+// AdminGetWebhookDeliveries 分页获取投递记录, status=dead 时为死信列表
+func AdminGetWebhookDeliveries(ctx context.Context, svcCtx *svc.ServerCtx, req types.AdminGetWebhookDeliveriesReq) (*types.AdminGetWebhookDeliveriesResp, error) {
+	deliveries, total, err := svcCtx.Dao.QueryWebhookDeliveries(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get webhook deliveries")
+	}
+
+	return &types.AdminGetWebhookDeliveriesResp{
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		Deliveries: deliveries,
+	}, nil
+}
+
+// AdminRedeliverWebhook 重新投递死信或已成功的记录
+func AdminRedeliverWebhook(ctx context.Context, svcCtx *svc.ServerCtx, deliveryID int64) (*types.AdminCommonResp, error) {
+	err := svcCtx.Dao.RedeliverWebhookDelivery(ctx, deliveryID)
+	recordAdminAudit(ctx, svcCtx, AuditActionRedeliverWebhook, 0, strconv.FormatInt(deliveryID, 10), nil, nil, err)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to redeliver webhook")
+	}
+
+	return &types.AdminCommonResp{
+		Success: true,
+		Message: "已重新加入投递队列",
+	}, nil
+}
+
+// validateWebhookURL 检查推送地址格式, 并拒绝解析到内网、本机或链路本地地址的域名
+func validateWebhookURL(ctx context.Context, rawURL string) error {
+	if err := webhook.ValidateURL(ctx, rawURL); err != nil {
+		if errors.Is(err, webhook.ErrForbiddenAddress) {
+			return errors.New("invalid webhook url, private or loopback address is not allowed")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func joinWebhookEventTypes(eventTypes []string) (string, error) {
+	for _, eventType := range eventTypes {
+		if !webhook.IsValidEventType(eventType) {
+			return "", errors.Errorf("invalid event type %s, supported: %s", eventType, strings.Join(webhook.EventTypes, ","))
+		}
+	}
+
+	return strings.Join(eventTypes, ","), nil
+}
+
+func joinWebhookCollections(collections []string) (string, error) {
+	addrs := make([]string, 0, len(collections))
+	for _, collection := range collections {
+		if !common.IsHexAddress(collection) {
+			return "", errors.Errorf("invalid collection address %s", collection)
+		}
+		addrs = append(addrs, strings.ToLower(collection))
+	}
+
+	return strings.Join(addrs, ","), nil
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.Wrap(err, "failed to generate webhook secret")
+	}
+
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,212 @@
+package webhook
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/retry"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	deliverBatchSize = 100
+	// claimLease 认领后的租期, 实例在投递中途退出时记录在租期后重新投递
+	claimLease = 5 * time.Minute
+
+	// 每轮投递内的即时重试次数与间隔, 仍失败时按轮次指数退避
+	roundAttempts    = 3
+	minRoundBackoff  = time.Minute
+	maxRoundBackoff  = time.Hour
+	maxErrorMsgBytes = 1024
+)
+
+// roundWaits 同一轮内两次请求之间的等待时间
+var roundWaits = []time.Duration{time.Second, 2 * time.Second}
+
+// deliverLoop 定时扫描到期的待投递记录并发投递
+func (s *Service) deliverLoop() {
+	ticker := time.NewTicker(s.conf.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.deliverDue()
+		}
+	}
+}
+
+func (s *Service) deliverDue() {
+	var deliveries []base.WebhookDelivery
+	if err := s.db.WithContext(s.ctx).Table(base.WebhookDeliveryTableName()).
+		Where("status = ? and next_retry_time <= ?", base.WebhookDeliveryStatusPending, time.Now().UnixMilli()).
+		Order("id asc").Limit(deliverBatchSize).
+		Find(&deliveries).Error; err != nil {
+		xzap.WithContext(s.ctx).Error("failed on query due webhook deliveries", zap.Error(err))
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.conf.Workers)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		if !s.claim(delivery) {
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			s.deliver(delivery)
+		}()
+	}
+	wg.Wait()
+}
+
+// claim 通过乐观锁延后下次投递时间, 避免多实例重复投递
+func (s *Service) claim(delivery *base.WebhookDelivery) bool {
+	leaseUntil := time.Now().Add(claimLease).UnixMilli()
+	result := s.db.WithContext(s.ctx).Table(base.WebhookDeliveryTableName()).
+		Where("id = ? and status = ? and next_retry_time = ?", delivery.Id, base.WebhookDeliveryStatusPending, delivery.NextRetryTime).
+		Update("next_retry_time", leaseUntil)
+	if result.Error != nil {
+		xzap.WithContext(s.ctx).Error("failed on claim webhook delivery", zap.Error(result.Error), zap.Int64("id", delivery.Id))
+		return false
+	}
+
+	return result.RowsAffected == 1
+}
+
+// deliver 执行一轮投递, 失败时安排下一轮或进入死信
+func (s *Service) deliver(delivery *base.WebhookDelivery) {
+	var sub base.WebhookSubscription
+	if err := s.db.WithContext(s.ctx).Table(base.WebhookSubscriptionTableName()).
+		Where("id = ?", delivery.SubscriptionId).
+		First(&sub).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.finish(delivery, 0, errors.New("subscription deleted"), true)
+			return
+		}
+		// 租期到期后重新投递
+		xzap.WithContext(s.ctx).Error("failed on get webhook subscription", zap.Error(err), zap.Int64("id", delivery.Id))
+		return
+	}
+	if !sub.Enabled {
+		s.finish(delivery, 0, errors.New("subscription disabled"), true)
+		return
+	}
+
+	limit := s.conf.MaxAttempts - delivery.Attempts
+	if limit <= 0 {
+		s.finish(delivery, delivery.ResponseStatus, errors.New("max attempts exceeded"), true)
+		return
+	}
+	if limit > roundAttempts {
+		limit = roundAttempts
+	}
+	var lastStatus int
+	err := retry.Retry(func(attempt uint) error {
+		delivery.Attempts++
+		var err error
+		lastStatus, err = s.post(&sub, delivery)
+		return err
+	}, retry.Limit(uint(limit)), retry.Wait(roundWaits...), func(attempt uint) bool {
+		// 410 表示对方已下线该地址, 无需继续重试
+		return lastStatus != http.StatusGone
+	})
+	if err == nil {
+		s.finish(delivery, lastStatus, nil, false)
+		return
+	}
+
+	s.finish(delivery, lastStatus, err, lastStatus == http.StatusGone || delivery.Attempts >= s.conf.MaxAttempts)
+}
+
+// post 签名并发送一次请求, 返回响应状态码, 2xx 视为成功
+func (s *Service) post(sub *base.WebhookSubscription, delivery *base.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, sub.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on create webhook request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, time.Now().Unix(), body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on send webhook request")
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorMsgBytes))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, nil
+	}
+
+	return resp.StatusCode, errors.Errorf("unexpected status %s: %s", resp.Status, raw)
+}
+
+// finish 更新投递结果, dead 为 true 时进入死信
+func (s *Service) finish(delivery *base.WebhookDelivery, status int, deliverErr error, dead bool) {
+	updates := map[string]interface{}{
+		"attempts":        delivery.Attempts,
+		"response_status": status,
+	}
+	switch {
+	case deliverErr == nil:
+		updates["status"] = base.WebhookDeliveryStatusSucceeded
+		updates["error_msg"] = ""
+		updates["delivered_time"] = time.Now().UnixMilli()
+	case dead:
+		updates["status"] = base.WebhookDeliveryStatusDead
+	default:
+		updates["next_retry_time"] = time.Now().Add(roundBackoff(delivery.Attempts)).UnixMilli()
+	}
+	if deliverErr != nil {
+		msg := deliverErr.Error()
+		if len(msg) > maxErrorMsgBytes {
+			msg = msg[:maxErrorMsgBytes]
+		}
+		updates["error_msg"] = msg
+	}
+
+	if err := s.db.WithContext(s.ctx).Table(base.WebhookDeliveryTableName()).
+		Where("id = ?", delivery.Id).
+		Updates(updates).Error; err != nil {
+		xzap.WithContext(s.ctx).Error("failed on update webhook delivery", zap.Error(err), zap.Int64("id", delivery.Id))
+		return
+	}
+	if dead {
+		xzap.WithContext(s.ctx).Warn("webhook delivery moved to dead letter",
+			zap.Int64("id", delivery.Id), zap.Int64("subscription_id", delivery.SubscriptionId),
+			zap.Int("attempts", delivery.Attempts), zap.Error(deliverErr))
+	}
+}
+
+// roundBackoff 按已完成的轮次指数退避: 1m, 2m, 4m ... 最多 1h
+func roundBackoff(attempts int) time.Duration {
+	backoff := minRoundBackoff
+	for round := attempts/roundAttempts - 1; round > 0 && backoff < maxRoundBackoff; round-- {
+		backoff *= 2
+	}
+	if backoff > maxRoundBackoff {
+		backoff = maxRoundBackoff
+	}
+
+	return backoff
+}
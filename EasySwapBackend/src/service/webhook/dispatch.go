@@ -0,0 +1,174 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/marketfeed"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	cacheCursorPre = "cache:es:webhook:cursor:%s"
+
+	dispatchBatchSize = 200
+)
+
+// dispatchLoop 持续读取链上的市场事件并生成投递记录
+func (s *Service) dispatchLoop(chain string) {
+	ticker := time.NewTicker(s.conf.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.dispatch(chain); err != nil {
+				xzap.WithContext(s.ctx).Error("failed on dispatch webhook events", zap.Error(err), zap.String("chain", chain))
+			}
+		}
+	}
+}
+
+// dispatch 从上次的游标继续读取事件, 首次运行时从最新事件开始, 不补发历史事件
+func (s *Service) dispatch(chain string) error {
+	cursor, ok, err := s.loadCursor(chain)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		latest, err := marketfeed.Latest(s.kv, chain)
+		if err != nil {
+			return err
+		}
+		return s.saveCursor(chain, latest)
+	}
+
+	for {
+		events, gap, err := marketfeed.Read(s.kv, chain, cursor, dispatchBatchSize)
+		if err != nil {
+			return err
+		}
+		if gap {
+			xzap.WithContext(s.ctx).Warn("webhook events expired before dispatch",
+				zap.String("chain", chain), zap.Uint64("cursor", cursor))
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		subs, err := s.enabledSubscriptions()
+		if err != nil {
+			return err
+		}
+		var deliveries []*base.WebhookDelivery
+		for _, event := range events {
+			for i := range subs {
+				if !s.match(&subs[i], chain, event) {
+					continue
+				}
+				delivery, err := s.newDelivery(&subs[i], chain, event)
+				if err != nil {
+					return err
+				}
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		if len(deliveries) > 0 {
+			// 多实例重复生成的记录由唯一索引去重
+			if err := s.db.WithContext(s.ctx).Table(base.WebhookDeliveryTableName()).
+				Clauses(clause.OnConflict{DoNothing: true}).
+				CreateInBatches(deliveries, 100).Error; err != nil {
+				return errors.Wrap(err, "failed on create webhook deliveries")
+			}
+		}
+
+		cursor = events[len(events)-1].ID
+		if err := s.saveCursor(chain, cursor); err != nil {
+			return err
+		}
+		if len(events) < dispatchBatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *Service) enabledSubscriptions() ([]base.WebhookSubscription, error) {
+	var subs []base.WebhookSubscription
+	if err := s.db.WithContext(s.ctx).Table(base.WebhookSubscriptionTableName()).
+		Where("enabled = ?", true).
+		Find(&subs).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query webhook subscriptions")
+	}
+
+	return subs, nil
+}
+
+// match 判断事件是否满足订阅的链、事件类型与合集条件
+func (s *Service) match(sub *base.WebhookSubscription, chain string, event *marketfeed.Event) bool {
+	if sub.ChainId != 0 && sub.ChainId != s.chains[chain] {
+		return false
+	}
+	if types := splitList(sub.EventTypes); len(types) > 0 && !contains(types, event.Type) {
+		return false
+	}
+	if collections := splitList(sub.Collections); len(collections) > 0 && !contains(collections, event.CollectionAddress) {
+		return false
+	}
+
+	return true
+}
+
+func (s *Service) newDelivery(sub *base.WebhookSubscription, chain string, event *marketfeed.Event) (*base.WebhookDelivery, error) {
+	eventID := fmt.Sprintf("%s:%d", chain, event.ID)
+	payload, err := json.Marshal(&Payload{
+		ID:        eventID,
+		Type:      event.Type,
+		ChainID:   s.chains[chain],
+		CreatedAt: event.EventTime,
+		Data:      event,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on marshal webhook payload")
+	}
+
+	return &base.WebhookDelivery{
+		SubscriptionId: sub.Id,
+		EventId:        eventID,
+		EventType:      event.Type,
+		Payload:        string(payload),
+		Status:         base.WebhookDeliveryStatusPending,
+		NextRetryTime:  time.Now().UnixMilli(),
+	}, nil
+}
+
+func (s *Service) loadCursor(chain string) (uint64, bool, error) {
+	value, err := s.kv.Get(fmt.Sprintf(cacheCursorPre, chain))
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed on get webhook cursor")
+	}
+	if value == "" {
+		return 0, false, nil
+	}
+	cursor, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "invalid webhook cursor %q", value)
+	}
+
+	return cursor, true, nil
+}
+
+func (s *Service) saveCursor(chain string, cursor uint64) error {
+	if err := s.kv.Set(fmt.Sprintf(cacheCursorPre, chain), strconv.FormatUint(cursor, 10)); err != nil {
+		return errors.Wrap(err, "failed on save webhook cursor")
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrForbiddenAddress 推送地址指向内网、本机或链路本地地址
+var ErrForbiddenAddress = errors.New("webhook address is not allowed")
+
+// sharedAddressSpace 运营商级 NAT 地址段(100.64.0.0/10), 通常用于云厂商内网
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublicIP 判断是否为可推送的公网地址
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip))
+}
+
+// ValidateURL 检查推送地址: 只支持 http 与 https, 且域名解析出的所有地址都必须是公网地址
+// 保存时的检查只用于提前提示, 发送时由 NewClient 的拨号检查防止 DNS 重绑定
+func ValidateURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("invalid webhook url, only http and https are supported")
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsPublicIP(ip) {
+			return ErrForbiddenAddress
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return errors.Errorf("failed to resolve webhook host %s", host)
+	}
+	for _, addr := range addrs {
+		if !IsPublicIP(addr.IP) {
+			return ErrForbiddenAddress
+		}
+	}
+
+	return nil
+}
+
+// NewClient 创建推送使用的 HTTP 客户端:
+//   - 建立连接前检查实际连接的地址, 拒绝内网与本机地址
+//   - 不使用环境变量中的代理, 代理会绕过地址检查
+//   - 不跟随重定向, 避免签名请求被转发到其他地址
+func NewClient(timeout time.Duration) *http.Client {
+	return newClient(timeout, IsPublicIP)
+}
+
+func newClient(timeout time.Duration, allow func(net.IP) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allow(ip) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConnsPerHost: defaultWorkers,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,140 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/marketfeed"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"gorm.io/gorm"
+)
+
+// 推送请求头
+const (
+	HeaderEvent     = "X-EasySwap-Event"
+	HeaderDelivery  = "X-EasySwap-Delivery"
+	HeaderSignature = "X-EasySwap-Signature"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultWorkers      = 8
+	defaultMaxAttempts  = 15
+	defaultTimeout      = 10 * time.Second
+)
+
+// EventTypes 可订阅的事件类型
+var EventTypes = []string{
+	marketfeed.TypeListing,
+	marketfeed.TypeBid,
+	marketfeed.TypeCancel,
+	marketfeed.TypeSale,
+	marketfeed.TypeFloorPrice,
+}
+
+// IsValidEventType 判断事件类型是否可订阅
+func IsValidEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Config 推送配置, 为 0 时使用默认值
+type Config struct {
+	PollInterval time.Duration // 读取事件与扫描待投递记录的间隔
+	Workers      int           // 并发投递数
+	MaxAttempts  int           // 最大投递次数, 超过后进入死信
+	Timeout      time.Duration // 单次请求超时
+}
+
+// Payload 推送内容
+type Payload struct {
+	ID        string            `json:"id"` // 事件ID, 重复投递时不变, 接收方可用于去重
+	Type      string            `json:"type"`
+	ChainID   int64             `json:"chain_id"`
+	CreatedAt int64             `json:"created_at"`
+	Data      *marketfeed.Event `json:"data"`
+}
+
+// Service 将索引服务写入 Redis 的市场事件按订阅生成投递记录, 并签名推送到订阅地址
+// 多实例部署时投递记录按 (订阅, 事件) 去重, 待投递记录通过乐观锁认领
+type Service struct {
+	ctx    context.Context
+	db     *gorm.DB
+	kv     *xkv.Store
+	chains map[string]int64 // 链名称到链ID
+	conf   Config
+	client *http.Client
+}
+
+// New 创建并启动推送服务
+func New(ctx context.Context, db *gorm.DB, kv *xkv.Store, chains map[string]int64, conf Config) *Service {
+	if conf.PollInterval <= 0 {
+		conf.PollInterval = defaultPollInterval
+	}
+	if conf.Workers <= 0 {
+		conf.Workers = defaultWorkers
+	}
+	if conf.MaxAttempts <= 0 {
+		conf.MaxAttempts = defaultMaxAttempts
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultTimeout
+	}
+
+	s := &Service{
+		ctx:    ctx,
+		db:     db,
+		kv:     kv,
+		chains: chains,
+		conf:   conf,
+		client: NewClient(conf.Timeout),
+	}
+	for chain := range chains {
+		go s.dispatchLoop(chain)
+	}
+	go s.deliverLoop()
+
+	return s
+}
+
+// Sign 计算推送签名, 签名内容为 "<timestamp>.<body>", 请求头格式为 t=<timestamp>,v1=<hex>
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d.", timestamp)))
+	mac.Write(body)
+
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// splitList 解析逗号分隔的字段, 统一转为小写
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func contains(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
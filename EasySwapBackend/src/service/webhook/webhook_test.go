@@ -0,0 +1,356 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/marketfeed"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/stores/kv"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	testChain      = "sepolia"
+	testChainID    = 11155111
+	testSecret     = "test-secret"
+	testCollection = "0x1111111111111111111111111111111111111111"
+)
+
+// newTestService 创建不启动后台循环的推送服务, 测试服务器监听本机地址, 因此放开拨号检查
+func newTestService(t *testing.T, conf Config) *Service {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "webhook.db")), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Table(base.WebhookSubscriptionTableName()).AutoMigrate(&base.WebhookSubscription{}))
+	assert.NoError(t, db.Table(base.WebhookDeliveryTableName()).AutoMigrate(&base.WebhookDelivery{}))
+	store := xkv.NewStore(kv.KvConf{{
+		RedisConf: redis.RedisConf{Host: miniredis.RunT(t).Addr(), Type: redis.NodeType},
+		Weight:    100,
+	}})
+
+	if conf.MaxAttempts <= 0 {
+		conf.MaxAttempts = defaultMaxAttempts
+	}
+	conf.Workers = 1
+	// 缩短同一轮内的重试间隔
+	waits := roundWaits
+	roundWaits = []time.Duration{time.Millisecond}
+	t.Cleanup(func() { roundWaits = waits })
+
+	return &Service{
+		ctx:    xzap.ToContext(context.Background(), zap.NewNop()),
+		db:     db,
+		kv:     store,
+		chains: map[string]int64{testChain: testChainID},
+		conf:   conf,
+		client: newClient(time.Second, func(net.IP) bool { return true }),
+	}
+}
+
+func createSubscription(t *testing.T, s *Service, sub *base.WebhookSubscription) *base.WebhookSubscription {
+	if sub.Secret == "" {
+		sub.Secret = testSecret
+	}
+	assert.NoError(t, s.db.Table(base.WebhookSubscriptionTableName()).Create(sub).Error)
+
+	return sub
+}
+
+func createDelivery(t *testing.T, s *Service, sub *base.WebhookSubscription) *base.WebhookDelivery {
+	delivery := &base.WebhookDelivery{
+		SubscriptionId: sub.Id,
+		EventId:        testChain + ":1",
+		EventType:      marketfeed.TypeListing,
+		Payload:        `{"id":"sepolia:1","type":"listing"}`,
+		Status:         base.WebhookDeliveryStatusPending,
+		NextRetryTime:  time.Now().UnixMilli(),
+	}
+	assert.NoError(t, s.db.Table(base.WebhookDeliveryTableName()).Create(delivery).Error)
+
+	return delivery
+}
+
+func getDelivery(t *testing.T, s *Service, id int64) *base.WebhookDelivery {
+	var delivery base.WebhookDelivery
+	assert.NoError(t, s.db.Table(base.WebhookDeliveryTableName()).Where("id = ?", id).First(&delivery).Error)
+
+	return &delivery
+}
+
+// statusServer 按顺序返回给定状态码, 用完后返回最后一个, 并记录收到的请求
+type statusServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func newStatusServer(t *testing.T, statuses ...int) *statusServer {
+	srv := &statusServer{statuses: statuses}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		srv.requests = append(srv.requests, r)
+		srv.bodies = append(srv.bodies, string(body))
+		status := srv.statuses[0]
+		if len(srv.statuses) > 1 {
+			srv.statuses = srv.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func (srv *statusServer) count() int {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	return len(srv.requests)
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"sepolia:1"}`)
+	signature := Sign(testSecret, 1700000000, body)
+
+	parts := strings.Split(signature, ",")
+	assert.Len(t, parts, 2)
+	assert.Equal(t, "t=1700000000", parts[0])
+	assert.True(t, strings.HasPrefix(parts[1], "v1="))
+
+	// 接收方按 "<timestamp>.<body>" 计算 HMAC-SHA256 校验
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte("1700000000." + string(body)))
+	assert.Equal(t, "v1="+hex.EncodeToString(mac.Sum(nil)), parts[1])
+
+	assert.NotEqual(t, signature, Sign("other-secret", 1700000000, body))
+	assert.NotEqual(t, signature, Sign(testSecret, 1700000001, body))
+}
+
+func TestDeliverSigned(t *testing.T) {
+	s := newTestService(t, Config{})
+	srv := newStatusServer(t, http.StatusOK)
+	sub := createSubscription(t, s, &base.WebhookSubscription{Url: srv.URL, Enabled: true})
+	delivery := createDelivery(t, s, sub)
+
+	s.deliverDue()
+
+	assert.Equal(t, 1, srv.count())
+	req, body := srv.requests[0], srv.bodies[0]
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, marketfeed.TypeListing, req.Header.Get(HeaderEvent))
+	assert.Equal(t, strconv.FormatInt(delivery.Id, 10), req.Header.Get(HeaderDelivery))
+	assert.Equal(t, delivery.Payload, body)
+	var timestamp int64
+	_, err := fmt.Sscanf(req.Header.Get(HeaderSignature), "t=%d,", &timestamp)
+	assert.NoError(t, err)
+	assert.Equal(t, Sign(testSecret, timestamp, []byte(body)), req.Header.Get(HeaderSignature))
+
+	delivered := getDelivery(t, s, delivery.Id)
+	assert.Equal(t, base.WebhookDeliveryStatusSucceeded, delivered.Status)
+	assert.Equal(t, 1, delivered.Attempts)
+	assert.Equal(t, http.StatusOK, delivered.ResponseStatus)
+	assert.NotZero(t, delivered.DeliveredTime)
+
+	// 已投递的记录不会重复投递
+	s.deliverDue()
+	assert.Equal(t, 1, srv.count())
+}
+
+func TestDeliverRetryBackoff(t *testing.T) {
+	s := newTestService(t, Config{MaxAttempts: 10})
+	srv := newStatusServer(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK)
+	sub := createSubscription(t, s, &base.WebhookSubscription{Url: srv.URL, Enabled: true})
+	delivery := createDelivery(t, s, sub)
+
+	// 一轮内重试 roundAttempts 次后按轮次退避
+	before := time.Now()
+	s.deliverDue()
+	assert.Equal(t, roundAttempts, srv.count())
+	failed := getDelivery(t, s, delivery.Id)
+	assert.Equal(t, base.WebhookDeliveryStatusPending, failed.Status)
+	assert.Equal(t, roundAttempts, failed.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, failed.ResponseStatus)
+	assert.Contains(t, failed.ErrorMsg, "500")
+	assert.GreaterOrEqual(t, failed.NextRetryTime, before.Add(minRoundBackoff).UnixMilli())
+	assert.Less(t, failed.NextRetryTime, time.Now().Add(minRoundBackoff+time.Second).UnixMilli())
+
+	// 未到重试时间不投递
+	s.deliverDue()
+	assert.Equal(t, roundAttempts, srv.count())
+
+	assert.NoError(t, s.db.Table(base.WebhookDeliveryTableName()).Where("id = ?", delivery.Id).
+		Update("next_retry_time", time.Now().UnixMilli()).Error)
+	s.deliverDue()
+	delivered := getDelivery(t, s, delivery.Id)
+	assert.Equal(t, base.WebhookDeliveryStatusSucceeded, delivered.Status)
+	assert.Equal(t, roundAttempts+1, delivered.Attempts)
+	assert.Empty(t, delivered.ErrorMsg)
+}
+
+func TestRoundBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:                  time.Minute,
+		roundAttempts:      time.Minute,
+		2 * roundAttempts:  2 * time.Minute,
+		3 * roundAttempts:  4 * time.Minute,
+		4 * roundAttempts:  8 * time.Minute,
+		20 * roundAttempts: time.Hour,
+	}
+	for attempts, want := range cases {
+		assert.Equal(t, want, roundBackoff(attempts), "attempts %d", attempts)
+	}
+}
+
+func TestDeliverDeadLetter(t *testing.T) {
+	s := newTestService(t, Config{MaxAttempts: 5})
+	srv := newStatusServer(t, http.StatusBadGateway)
+	sub := createSubscription(t, s, &base.WebhookSubscription{Url: srv.URL, Enabled: true})
+	delivery := createDelivery(t, s, sub)
+
+	s.deliverDue()
+	assert.Equal(t, base.WebhookDeliveryStatusPending, getDelivery(t, s, delivery.Id).Status)
+
+	// 最后一轮只发送剩余次数, 达到最大次数后进入死信
+	assert.NoError(t, s.db.Table(base.WebhookDeliveryTableName()).Where("id = ?", delivery.Id).
+		Update("next_retry_time", time.Now().UnixMilli()).Error)
+	s.deliverDue()
+	dead := getDelivery(t, s, delivery.Id)
+	assert.Equal(t, 5, srv.count())
+	assert.Equal(t, base.WebhookDeliveryStatusDead, dead.Status)
+	assert.Equal(t, 5, dead.Attempts)
+	assert.Equal(t, http.StatusBadGateway, dead.ResponseStatus)
+
+	s.deliverDue()
+	assert.Equal(t, 5, srv.count())
+}
+
+func TestDeliverDeadLetterImmediately(t *testing.T) {
+	s := newTestService(t, Config{})
+
+	// 410 表示对方已下线该地址, 不再重试
+	gone := newStatusServer(t, http.StatusGone)
+	delivery := createDelivery(t, s, createSubscription(t, s, &base.WebhookSubscription{Url: gone.URL, Enabled: true}))
+	s.deliverDue()
+	assert.Equal(t, 1, gone.count())
+	assert.Equal(t, base.WebhookDeliveryStatusDead, getDelivery(t, s, delivery.Id).Status)
+
+	// 停用的订阅不投递
+	disabled := newStatusServer(t, http.StatusOK)
+	sub := createSubscription(t, s, &base.WebhookSubscription{Url: disabled.URL, Enabled: false})
+	var stored base.WebhookSubscription
+	assert.NoError(t, s.db.Table(base.WebhookSubscriptionTableName()).Where("id = ?", sub.Id).First(&stored).Error)
+	assert.False(t, stored.Enabled)
+	delivery = createDelivery(t, s, sub)
+	s.deliverDue()
+	assert.Equal(t, 0, disabled.count())
+	dead := getDelivery(t, s, delivery.Id)
+	assert.Equal(t, base.WebhookDeliveryStatusDead, dead.Status)
+	assert.Equal(t, "subscription disabled", dead.ErrorMsg)
+}
+
+func TestDeliverNoRedirect(t *testing.T) {
+	s := newTestService(t, Config{})
+	target := newStatusServer(t, http.StatusOK)
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	t.Cleanup(redirect.Close)
+	delivery := createDelivery(t, s, createSubscription(t, s, &base.WebhookSubscription{Url: redirect.URL, Enabled: true}))
+
+	s.deliverDue()
+
+	assert.Equal(t, 0, target.count())
+	failed := getDelivery(t, s, delivery.Id)
+	assert.Equal(t, base.WebhookDeliveryStatusPending, failed.Status)
+	assert.Equal(t, http.StatusTemporaryRedirect, failed.ResponseStatus)
+}
+
+func TestDispatch(t *testing.T) {
+	s := newTestService(t, Config{})
+	listing := createSubscription(t, s, &base.WebhookSubscription{Url: "https://example.com/listing", EventTypes: marketfeed.TypeListing, Enabled: true})
+	other := createSubscription(t, s, &base.WebhookSubscription{Url: "https://example.com/other", ChainId: 1, Enabled: true})
+	createSubscription(t, s, &base.WebhookSubscription{Url: "https://example.com/disabled", Enabled: false})
+
+	// 首次运行时从最新事件开始, 不补发历史事件
+	_, err := marketfeed.Publish(s.kv, testChain, &marketfeed.Event{Type: marketfeed.TypeListing, CollectionAddress: testCollection})
+	assert.NoError(t, err)
+	assert.NoError(t, s.dispatch(testChain))
+
+	_, err = marketfeed.Publish(s.kv, testChain, &marketfeed.Event{Type: marketfeed.TypeBid, CollectionAddress: testCollection})
+	assert.NoError(t, err)
+	id, err := marketfeed.Publish(s.kv, testChain, &marketfeed.Event{Type: marketfeed.TypeListing, CollectionAddress: testCollection})
+	assert.NoError(t, err)
+	assert.NoError(t, s.dispatch(testChain))
+	// 重复执行不会重复生成投递记录
+	assert.NoError(t, s.dispatch(testChain))
+
+	var deliveries []base.WebhookDelivery
+	assert.NoError(t, s.db.Table(base.WebhookDeliveryTableName()).Find(&deliveries).Error)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, listing.Id, deliveries[0].SubscriptionId)
+	assert.Equal(t, fmt.Sprintf("%s:%d", testChain, id), deliveries[0].EventId)
+	assert.Contains(t, deliveries[0].Payload, fmt.Sprintf(`"chain_id":%d`, testChainID))
+	assert.NotEqual(t, other.Id, deliveries[0].SubscriptionId)
+}
+
+func TestValidateURL(t *testing.T) {
+	ctx := context.Background()
+	for _, rawURL := range []string{
+		"ftp://8.8.8.8/hook",
+		"http://",
+		"not a url",
+	} {
+		err := ValidateURL(ctx, rawURL)
+		assert.Error(t, err, rawURL)
+		assert.NotErrorIs(t, err, ErrForbiddenAddress, rawURL)
+	}
+	for _, rawURL := range []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:8080/hook",
+		"http://10.0.0.1/hook",
+		"http://172.16.0.1/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://100.64.0.1/hook",
+		"http://0.0.0.0/hook",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+		"http://[fd00::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+	} {
+		assert.ErrorIs(t, ValidateURL(ctx, rawURL), ErrForbiddenAddress, rawURL)
+	}
+	assert.NoError(t, ValidateURL(ctx, "https://8.8.8.8/hook"))
+	assert.NoError(t, ValidateURL(ctx, "http://[2001:4860:4860::8888]:8080/hook"))
+}
+
+func TestClientRejectsPrivateAddress(t *testing.T) {
+	srv := newStatusServer(t, http.StatusOK)
+
+	// 域名在保存后被解析到内网地址时, 拨号检查仍会拒绝连接
+	_, err := NewClient(time.Second).Post(srv.URL, "application/json", nil)
+	assert.ErrorIs(t, err, ErrForbiddenAddress)
+	assert.Equal(t, 0, srv.count())
+}
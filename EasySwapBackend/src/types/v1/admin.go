@@ -1,6 +1,10 @@
 package types
 
-import "time"
+import (
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+)
 
 // NFT 合约管理相关类型 - 对应 ob_collection_* 表结构
 type Contract struct {
@@ -89,10 +93,11 @@ type SyncTask struct {
 	ContractAddr   string     `json:"contract_addr"`   // 合约地址
 	TokenID        string     `json:"token_id"`        // Token ID (当任务类型为token时)
 	ChainID        int64      `json:"chain_id"`        // 链ID
-	Status         string     `json:"status"`          // 状态 (pending/running/completed/failed)
+	Status         string     `json:"status"`          // 状态 (pending/running/completed/failed/interrupted)
 	Progress       int        `json:"progress"`        // 进度 (0-100)
 	TotalItems     int        `json:"total_items"`     // 总数量
 	ProcessedItems int        `json:"processed_items"` // 已处理数量
+	FailedItems    int        `json:"failed_items"`    // 失败数量
 	ErrorMsg       string     `json:"error_msg"`       // 错误信息
 	CreatedAt      time.Time  `json:"created_at"`      // 创建时间
 	StartedAt      *time.Time `json:"started_at"`      // 开始时间
@@ -168,3 +173,33 @@ type AdminCommonResp struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
 }
+
+// =================== 管理员权限 ===================
+
+// 添加/修改管理员请求
+type AdminSetUserReq struct {
+	Address string `json:"address" validate:"required"`                          // 管理员地址
+	Role    string `json:"role" validate:"required,oneof=viewer operator super"` // 角色
+	Enabled *bool  `json:"enabled"`                                              // 是否启用, 默认启用
+}
+
+// 管理员列表响应
+type AdminGetUsersResp struct {
+	Users []base.AdminUser `json:"users"`
+}
+
+// 获取审计日志请求
+type AdminGetAuditLogsReq struct {
+	Page     int    `form:"page" validate:"min=1"`              // 页码
+	PageSize int    `form:"page_size" validate:"min=1,max=100"` // 页大小
+	Operator string `form:"operator"`                           // 操作人筛选
+	Action   string `form:"action"`                             // 操作类型筛选
+}
+
+// 获取审计日志响应
+type AdminGetAuditLogsResp struct {
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+	Logs     []base.AdminAuditLog `json:"logs"`
+}
@@ -0,0 +1,84 @@
+package types
+
+import (
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/shopspring/decimal"
+)
+
+// 添加关注请求
+type AddWatchlistReq struct {
+	ChainID           int64  `json:"chain_id" validate:"required"`
+	CollectionAddress string `json:"collection_address" validate:"required"`
+	TokenID           string `json:"token_id"` // 为空时关注整个合集
+}
+
+// 关注列表响应
+type WatchlistResp struct {
+	Result []base.Watchlist `json:"result"`
+}
+
+// 创建提醒规则请求, 价格均以 wei 为单位
+type CreateAlertRuleReq struct {
+	ChainID           int64           `json:"chain_id" validate:"required"`
+	CollectionAddress string          `json:"collection_address" validate:"required"`
+	TokenID           string          `json:"token_id"`                      // 为空时作用于整个合集
+	RuleType          string          `json:"rule_type" validate:"required"` // floor_below/bid_above/listing_undercut/volume_spike
+	Threshold         decimal.Decimal `json:"threshold"`                     // listing_undercut 不使用
+	Window            int64           `json:"window" validate:"min=0"`       // volume_spike 的统计窗口（秒）
+	Channels          []string        `json:"channels"`                      // 站外通知渠道: email/webhook, 站内信始终写入
+	Cooldown          int64           `json:"cooldown" validate:"min=0"`     // 两次触发的最小间隔（秒）, 为 0 时使用默认值
+	Enabled           *bool           `json:"enabled"`                       // 是否启用, 默认启用
+}
+
+// 修改提醒规则请求, 未传的字段保持不变
+type UpdateAlertRuleReq struct {
+	Threshold *decimal.Decimal `json:"threshold"`
+	Window    *int64           `json:"window"`
+	Channels  *[]string        `json:"channels"`
+	Cooldown  *int64           `json:"cooldown"`
+	Enabled   *bool            `json:"enabled"`
+}
+
+// 提醒规则列表响应
+type AlertRulesResp struct {
+	Result []base.AlertRule `json:"result"`
+}
+
+// 站内信列表请求
+type NotificationsReq struct {
+	Page     int  `form:"page" validate:"min=1"`              // 页码
+	PageSize int  `form:"page_size" validate:"min=1,max=100"` // 页大小
+	Unread   bool `form:"unread"`                             // 只返回未读
+}
+
+// 站内信列表响应
+type NotificationsResp struct {
+	Total       int64               `json:"total"`
+	UnreadTotal int64               `json:"unread_total"`
+	Page        int                 `json:"page"`
+	PageSize    int                 `json:"page_size"`
+	Result      []base.Notification `json:"result"`
+}
+
+// 标记已读请求
+type ReadNotificationsReq struct {
+	IDs []int64 `json:"ids" validate:"max=100"` // 站内信ID, 为空时标记全部
+}
+
+// 标记已读响应
+type ReadNotificationsResp struct {
+	Updated int64 `json:"updated"`
+}
+
+// 修改站外通知配置请求, 未传的字段保持不变, 传空字符串表示清除
+type UpdateNotifySettingReq struct {
+	Email       *string `json:"email"`
+	WebhookURL  *string `json:"webhook_url"`
+	ResetSecret bool    `json:"reset_secret"` // 重新生成 webhook 签名密钥
+}
+
+// 站外通知配置响应, webhook 签名密钥只在生成时返回
+type NotifySettingResp struct {
+	Setting       *base.NotifySetting `json:"setting"`
+	WebhookSecret string              `json:"webhook_secret,omitempty"`
+}
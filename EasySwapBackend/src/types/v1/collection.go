@@ -1,6 +1,7 @@
 package types
 
 import (
+	"github.com/ProjectsTask/EasySwapBase/refreshqueue"
 	"github.com/shopspring/decimal"
 )
 
@@ -137,11 +138,7 @@ type CommonResp struct {
 	Result interface{} `json:"result"`
 }
 
-type RefreshItem struct {
-	ChainID        int64  `json:"chain_id"`
-	CollectionAddr string `json:"collection_addr"`
-	TokenID        string `json:"token_id"`
-}
+type RefreshItem = refreshqueue.Item
 
 type CollectionListed struct {
 	CollectionAddr string `json:"collection_address"`
@@ -150,6 +147,7 @@ type CollectionListed struct {
 
 // MintRequest NFT铸造请求参数
 type MintRequest struct {
+	RequestID   string `json:"request_id"` // 幂等请求ID（可选），为空时自动生成
 	ChainID     int    `json:"chain_id" binding:"required"`
 	ToAddress   string `json:"to_address" binding:"required"`
 	TokenURI    string `json:"token_uri" binding:"required"`
@@ -160,9 +158,10 @@ type MintRequest struct {
 
 // MintResult NFT铸造结果
 type MintResult struct {
-	TxHash  string `json:"tx_hash"`
-	TokenID string `json:"token_id"`
-	Status  string `json:"status"`
+	RequestID string `json:"request_id"`
+	TxHash    string `json:"tx_hash"`
+	TokenID   string `json:"token_id"`
+	Status    string `json:"status"`
 }
 
 // MintResponse NFT铸造响应
@@ -7,36 +7,52 @@ type COSTokenRequest struct {
 	FileSize int64  `json:"file_size" binding:"required"` // 文件大小（字节）
 }
 
-// COSCredentials 腾讯云COS临时凭证
-type COSCredentials struct {
-	TmpSecretID  string `json:"tmpSecretId"`
-	TmpSecretKey string `json:"tmpSecretKey"`
-	SessionToken string `json:"sessionToken"`
+// COSTokenResponse 腾讯云COS预签名上传地址响应
+// 客户端使用 Method 与 Headers 向 UploadURL 上传文件, 只能写入 Key 且内容类型与大小必须与申请时一致
+type COSTokenResponse struct {
+	RequestID   string            `json:"requestId"`
+	Expiration  string            `json:"expiration"`
+	StartTime   string            `json:"startTime"`
+	ExpiredTime int64             `json:"expiredTime"`
+	Bucket      string            `json:"bucket"`
+	Region      string            `json:"region"`
+	AllowPrefix string            `json:"allowPrefix"`
+	Method      string            `json:"method"`    // 上传请求方法
+	UploadURL   string            `json:"uploadUrl"` // 预签名上传地址
+	Headers     map[string]string `json:"headers"`   // 上传时必须携带的请求头
+	ObjectURL   string            `json:"objectUrl"` // 上传完成后的访问地址
+	Key         string            `json:"key"`       // 生成的文件路径
 }
 
-// COSTokenResponse 腾讯云COS临时token响应
-type COSTokenResponse struct {
-	Credentials  *COSCredentials `json:"credentials"`
-	RequestID    string          `json:"requestId"`
-	Expiration   string          `json:"expiration"`
-	StartTime    string          `json:"startTime"`
-	ExpiredTime  int64           `json:"expiredTime"`
-	Bucket       string          `json:"bucket"`
-	Region       string          `json:"region"`
-	AllowPrefix  string          `json:"allowPrefix"`
-	AllowActions []string        `json:"allowActions"`
-	UploadURL    string          `json:"uploadUrl"`
-	Key          string          `json:"key"` // 生成的文件路径
+// COSUploadPolicy COS表单上传策略, Fields(已包含 key 与 Content-Type)与 file 一起以 multipart/form-data 提交到 URL
+type COSUploadPolicy struct {
+	URL         string            `json:"url"`
+	Key         string            `json:"key"`        // 只能上传到该路径
+	ObjectURL   string            `json:"object_url"` // 上传成功后的访问地址
+	MaxSize     int64             `json:"max_size"`   // 文件大小上限（字节）
+	Fields      map[string]string `json:"fields"`
+	Expiration  string            `json:"expiration"`
+	ExpiredTime int64             `json:"expiredTime"`
 }
 
 // COSUploadResult COS上传结果
 type COSUploadResult struct {
-	Key      string `json:"key"`
+	Key      string `json:"key" binding:"required"`
 	Location string `json:"location"`
 	Bucket   string `json:"bucket"`
 	ETag     string `json:"etag"`
 }
 
+// COSUploadObject 校验通过的上传文件信息
+type COSUploadObject struct {
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	FileType    string `json:"file_type"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+	ETag        string `json:"etag"`
+}
+
 // COSTokenResp 统一响应格式
 type COSTokenResp struct {
 	Result *COSTokenResponse `json:"result"`
@@ -0,0 +1,25 @@
+package types
+
+// IPFSMetadataRequest 生成并固定铸造元数据请求
+type IPFSMetadataRequest struct {
+	Name        string                   `json:"name" binding:"required"`      // NFT名称
+	Description string                   `json:"description"`                  // NFT描述
+	ImageKey    string                   `json:"image_key" binding:"required"` // 通过 /upload 上传到COS的图片路径
+	ExternalURL string                   `json:"external_url"`                 // 外部链接（可选）
+	Attributes  []*IPFSMetadataAttribute `json:"attributes" binding:"dive"`    // NFT属性
+}
+
+// IPFSMetadataAttribute NFT属性
+type IPFSMetadataAttribute struct {
+	TraitType string `json:"trait_type" binding:"required"`
+	Value     string `json:"value" binding:"required"`
+}
+
+// IPFSMetadataResult 固定到IPFS的元数据, TokenURI 可直接用于铸造接口的 token_uri
+type IPFSMetadataResult struct {
+	TokenURI    string `json:"token_uri"`    // 元数据地址 ipfs://<cid>
+	MetadataCID string `json:"metadata_cid"` // 元数据CID
+	ImageURI    string `json:"image_uri"`    // 图片地址 ipfs://<cid>
+	ImageCID    string `json:"image_cid"`    // 图片CID
+	Metadata    string `json:"metadata"`     // 元数据JSON
+}
@@ -1,6 +1,9 @@
 package types
 
-import "github.com/shopspring/decimal"
+import (
+	"github.com/ProjectsTask/EasySwapBase/nftapproval"
+	"github.com/shopspring/decimal"
+)
 
 type ItemInfo struct {
 	CollectionAddress string `json:"collection_address"`
@@ -21,6 +24,26 @@ type ItemOwner struct {
 	Owner             string `json:"owner"`
 }
 
+type ItemHoldersFilterParams struct {
+	ChainID  int `json:"chain_id"`
+	Page     int `json:"page"`
+	PageSize int `json:"page_size"`
+}
+
+// ItemHolder ERC1155 NFT 的持有人与持有数量
+type ItemHolder struct {
+	Owner   string `json:"owner"`
+	Balance int64  `json:"balance"`
+}
+
+type ItemHoldersResp struct {
+	Result interface{} `json:"result"`
+	Count  int64       `json:"count"`
+}
+
+// ItemFillability NFT 挂单能否成交, 由 EasySwapSync 维护的授权状态计算
+type ItemFillability = nftapproval.Fillability
+
 type ItemImage struct {
 	CollectionAddress string `json:"collection_address"`
 	TokenID           string `json:"token_id"`
@@ -42,6 +65,9 @@ type ItemDetailInfo struct {
 	LastSellPrice      decimal.Decimal `json:"last_sell_price"`
 	FloorPrice         decimal.Decimal `json:"floor_price"`
 	OwnerAddress       string          `json:"owner_address"`
+	TokenStandard      int64           `json:"token_standard"` // 721/1155
+	Supply             int64           `json:"supply"`         // 流通数量, ERC721 为 1
+	OwnerCount         int64           `json:"owner_count"`    // 持有人数
 	IsOpenseaBanned    bool            `json:"is_opensea_banned"`
 	MarketplaceID      int             `json:"marketplace_id"`
 
@@ -2,6 +2,7 @@ package types
 
 // MetaNodeMintRequest MetaNodeNFT铸造请求参数
 type MetaNodeMintRequest struct {
+	RequestID   string `json:"request_id"`                    // 幂等请求ID（可选），为空时自动生成，可用于查询交易状态
 	ChainID     int    `json:"chain_id" binding:"required"`   // 链ID
 	ToAddress   string `json:"to_address" binding:"required"` // 接收地址
 	TokenURI    string `json:"token_uri" binding:"required"`  // NFT元数据URI
@@ -11,6 +12,7 @@ type MetaNodeMintRequest struct {
 
 // MetaNodeMintResult MetaNodeNFT铸造结果
 type MetaNodeMintResult struct {
+	RequestID   string `json:"request_id"`   // 请求ID, 用于查询交易状态
 	TxHash      string `json:"tx_hash"`      // 交易哈希
 	TokenID     string `json:"token_id"`     // 生成的Token ID
 	Status      string `json:"status"`       // 交易状态: pending, confirmed, failed
@@ -53,8 +55,9 @@ type MetaNodeTokenInfo struct {
 
 // MetaNodeBatchMintRequest 批量铸造请求
 type MetaNodeBatchMintRequest struct {
-	ChainID int                      `json:"chain_id" binding:"required"` // 链ID
-	Mints   []MetaNodeSingleMintInfo `json:"mints" binding:"required"`    // 批量铸造信息
+	RequestID string                   `json:"request_id"`                  // 幂等请求ID（可选），第i个铸造的请求ID为 <request_id>-<i>
+	ChainID   int                      `json:"chain_id" binding:"required"` // 链ID
+	Mints     []MetaNodeSingleMintInfo `json:"mints" binding:"required"`    // 批量铸造信息
 }
 
 // MetaNodeSingleMintInfo 单个铸造信息
@@ -68,6 +71,7 @@ type MetaNodeSingleMintInfo struct {
 // MetaNodeBatchMintResult 批量铸造结果
 type MetaNodeBatchMintResult struct {
 	TxHash       string   `json:"tx_hash"`       // 批量交易哈希
+	RequestIDs   []string `json:"request_ids"`   // 每个铸造的请求ID
 	TokenIDs     []string `json:"token_ids"`     // 生成的Token ID列表
 	SuccessCount int      `json:"success_count"` // 成功数量
 	FailedCount  int      `json:"failed_count"`  // 失败数量
@@ -92,3 +96,24 @@ type MetaNodeQueryResponse struct {
 	Page   int                 `json:"page"`   // 当前页
 	Size   int                 `json:"size"`   // 每页大小
 }
+
+// ManagedTxInfo 交易管理器发送的交易状态
+type ManagedTxInfo struct {
+	RequestID      string   `json:"request_id"`      // 请求ID
+	Caller         string   `json:"caller"`          // 调用方
+	ChainID        int64    `json:"chain_id"`        // 链ID
+	Signer         string   `json:"signer"`          // 签名地址
+	ToAddress      string   `json:"to_address"`      // 目标地址
+	Nonce          uint64   `json:"nonce"`           // nonce
+	TxHash         string   `json:"tx_hash"`         // 最新发送或已上链的交易哈希
+	ReplacedHashes []string `json:"replaced_hashes"` // 被加速或取消替换的交易哈希
+	Status         string   `json:"status"`          // 状态: pending, confirmed, failed, cancelling, cancelled
+	Attempts       int      `json:"attempts"`        // 发送次数（含加速与取消）
+	GasFeeCap      string   `json:"gas_fee_cap"`     // 最高gas单价(wei), 传统交易为gas price
+	GasTipCap      string   `json:"gas_tip_cap"`     // 优先费(wei), 传统交易为空
+	BlockNumber    int64    `json:"block_number"`    // 上链区块号
+	GasUsed        uint64   `json:"gas_used"`        // 消耗的Gas
+	ErrorMsg       string   `json:"error_msg"`       // 错误信息
+	CreateTime     int64    `json:"create_time"`     // 创建时间(毫秒)
+	UpdateTime     int64    `json:"update_time"`     // 更新时间(毫秒)
+}
@@ -1,14 +1,16 @@
 package types
 
 type LoginReq struct {
-	ChainID   int    `json:"chain_id"`
-	Message   string `json:"message"`
-	Signature string `json:"signature"`
-	Address   string `json:"address"`
+	ChainID   int    `json:"chain_id" validate:"required"`
+	Message   string `json:"message" validate:"required"`
+	Signature string `json:"signature" validate:"required"`
+	Address   string `json:"address" validate:"required"`
 }
 
 type UserLoginInfo struct {
 	Token     string `json:"token"`
+	SessionID string `json:"session_id"`
+	ExpiresAt int64  `json:"expires_at"`
 	IsAllowed bool   `json:"is_allowed"`
 }
 
@@ -25,3 +27,21 @@ type UserLoginMsgResp struct {
 type UserSignStatusResp struct {
 	IsSigned bool `json:"is_signed"`
 }
+
+type UserLogoutResp struct {
+	Revoked int `json:"revoked"`
+}
+
+type UserSessionInfo struct {
+	SessionID string `json:"session_id"`
+	Address   string `json:"address"`
+	ClientIp  string `json:"client_ip"`
+	UserAgent string `json:"user_agent"`
+	CreatedAt int64  `json:"created_at"`
+	ExpiresAt int64  `json:"expires_at"`
+	Current   bool   `json:"current"`
+}
+
+type UserSessionsResp struct {
+	Result []UserSessionInfo `json:"result"`
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+)
+
+// 创建 webhook 订阅请求
+type AdminCreateWebhookReq struct {
+	Name        string   `json:"name"`                      // 名称
+	URL         string   `json:"url" validate:"required"`   // 推送地址, 仅支持 http/https
+	Secret      string   `json:"secret" validate:"max=128"` // 签名密钥, 为空时自动生成
+	EventTypes  []string `json:"event_types"`               // 事件类型, 为空时订阅全部
+	ChainID     int64    `json:"chain_id"`                  // 链ID, 0 表示全部链
+	Collections []string `json:"collections"`               // 合集地址, 为空时订阅全部
+	Enabled     *bool    `json:"enabled"`                   // 是否启用, 默认启用
+}
+
+// 创建 webhook 订阅响应, 密钥仅在创建时返回
+type AdminCreateWebhookResp struct {
+	Subscription *base.WebhookSubscription `json:"subscription"`
+	Secret       string                    `json:"secret"`
+}
+
+// 修改 webhook 订阅请求, 未传的字段保持不变
+type AdminUpdateWebhookReq struct {
+	Name        *string   `json:"name"`
+	URL         *string   `json:"url"`
+	EventTypes  *[]string `json:"event_types"`
+	ChainID     *int64    `json:"chain_id"`
+	Collections *[]string `json:"collections"`
+	Enabled     *bool     `json:"enabled"`
+}
+
+// 获取 webhook 订阅列表响应
+type AdminGetWebhooksResp struct {
+	Subscriptions []base.WebhookSubscription `json:"subscriptions"`
+}
+
+// 获取投递记录请求, status=dead 时为死信列表
+type AdminGetWebhookDeliveriesReq struct {
+	Page           int    `form:"page" validate:"min=1"`              // 页码
+	PageSize       int    `form:"page_size" validate:"min=1,max=100"` // 页大小
+	SubscriptionID int64  `form:"subscription_id"`                    // 订阅ID筛选
+	Status         string `form:"status"`                             // 状态筛选: pending/succeeded/dead
+	EventType      string `form:"event_type"`                         // 事件类型筛选
+}
+
+// 获取投递记录响应
+type AdminGetWebhookDeliveriesResp struct {
+	Total      int64                  `json:"total"`
+	Page       int                    `json:"page"`
+	PageSize   int                    `json:"page_size"`
+	Deliveries []base.WebhookDelivery `json:"deliveries"`
+}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/pkg/errors"
 
 	logTypes "github.com/ProjectsTask/EasySwapBase/chain/types"
@@ -31,7 +33,11 @@ func (s *Service) Client() interface{} {
 	return s.client
 }
 
-func (s *Service) FilterLogs(ctx context.Context, q logTypes.FilterQuery) ([]interface{}, error) {
+func (s *Service) Close() {
+	s.client.Close()
+}
+
+func toFilterQuery(q logTypes.FilterQuery) ethereum.FilterQuery {
 	var addresses []common.Address
 	for _, addr := range q.Addresses {
 		addresses = append(addresses, common.HexToAddress(addr))
@@ -46,14 +52,16 @@ func (s *Service) FilterLogs(ctx context.Context, q logTypes.FilterQuery) ([]int
 		topicsHash = append(topicsHash, topicHash)
 	}
 
-	queryParam := ethereum.FilterQuery{
+	return ethereum.FilterQuery{
 		FromBlock: q.FromBlock,
 		ToBlock:   q.ToBlock,
 		Addresses: addresses,
 		Topics:    topicsHash,
 	}
+}
 
-	logs, err := s.client.FilterLogs(ctx, queryParam)
+func (s *Service) FilterLogs(ctx context.Context, q logTypes.FilterQuery) ([]interface{}, error) {
+	logs, err := s.client.FilterLogs(ctx, toFilterQuery(q))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed on get events")
 	}
@@ -100,3 +108,60 @@ func (s *Service) BlockWithTxs(ctx context.Context, blockNumber uint64) (interfa
 	}
 	return blockWithTxs, nil
 }
+
+func (s *Service) HeaderByNumber(ctx context.Context, blockNumber *big.Int) (*logTypes.BlockHeader, error) {
+	header, err := s.client.HeaderByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get block header")
+	}
+
+	return toBlockHeader(header), nil
+}
+
+func toBlockHeader(header *types.Header) *logTypes.BlockHeader {
+	return &logTypes.BlockHeader{
+		Number:     header.Number.Uint64(),
+		Hash:       header.Hash().Hex(),
+		ParentHash: header.ParentHash.Hex(),
+		Time:       header.Time,
+		Bloom:      header.Bloom.Bytes(),
+	}
+}
+
+func (s *Service) SubscribeNewHead(ctx context.Context, ch chan<- *logTypes.BlockHeader) (ethereum.Subscription, error) {
+	heads := make(chan *types.Header)
+	sub, err := s.client.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on subscribe new head")
+	}
+
+	// 将节点返回的区块头转换为通用结构后转发
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case header := <-heads:
+				select {
+				case ch <- toBlockHeader(header):
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+func (s *Service) SubscribeFilterLogs(ctx context.Context, q logTypes.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	sub, err := s.client.SubscribeFilterLogs(ctx, toFilterQuery(q), ch)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on subscribe logs")
+	}
+
+	return sub, nil
+}
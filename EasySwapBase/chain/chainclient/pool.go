@@ -0,0 +1,418 @@
+package chainclient
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/zeromicro/go-zero/core/metric"
+	"github.com/zeromicro/go-zero/core/threading"
+	"go.uber.org/zap"
+
+	logTypes "github.com/ProjectsTask/EasySwapBase/chain/types"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+)
+
+const (
+	defaultCheckInterval  = 10 * time.Second
+	defaultMaxBlockLag    = 5
+	defaultQuarantineTime = 30 * time.Second
+)
+
+var (
+	metricNodeHead = metric.NewGaugeVec(&metric.GaugeVecOpts{
+		Namespace: "chainclient",
+		Subsystem: "node",
+		Name:      "head",
+		Help:      "latest block number reported by rpc node",
+		Labels:    []string{"chain_id", "node"},
+	})
+	metricNodeLatency = metric.NewGaugeVec(&metric.GaugeVecOpts{
+		Namespace: "chainclient",
+		Subsystem: "node",
+		Name:      "latency_ms",
+		Help:      "health check latency of rpc node in milliseconds",
+		Labels:    []string{"chain_id", "node"},
+	})
+	metricNodeHealthy = metric.NewGaugeVec(&metric.GaugeVecOpts{
+		Namespace: "chainclient",
+		Subsystem: "node",
+		Name:      "healthy",
+		Help:      "whether rpc node is routable, 1 healthy, 0 lagging, failing or quarantined",
+		Labels:    []string{"chain_id", "node"},
+	})
+	metricNodeRequests = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: "chainclient",
+		Subsystem: "node",
+		Name:      "requests_total",
+		Help:      "rpc requests routed to node by result: ok, failover or error",
+		Labels:    []string{"chain_id", "node", "result"},
+	})
+)
+
+// PoolConfig 多节点连接池配置, 零值使用默认值
+type PoolConfig struct {
+	CheckInterval  time.Duration // 健康检查间隔
+	MaxBlockLag    uint64        // 落后最高区块超过该值的节点不参与路由
+	QuarantineTime time.Duration // 请求失败(超时、限流、连接断开)的节点隔离时长
+}
+
+type poolNode struct {
+	name   string // 日志与监控标签, 只包含节点域名, 不包含 API Key
+	client ChainClient
+
+	head             uint64
+	latency          time.Duration
+	checked          bool // 最近一次健康检查是否成功
+	lagging          bool
+	quarantinedUntil time.Time
+}
+
+// Pool 多节点 ChainClient, 按区块高度与延迟对节点做健康检查, 请求路由到最健康的节点,
+// 节点请求失败时切换到下一个节点并隔离一段时间, 落后过多的节点不参与路由
+type Pool struct {
+	ctx     context.Context
+	chainID string
+	conf    PoolConfig
+
+	mu    sync.Mutex
+	nodes []*poolNode
+}
+
+// NewPool 创建多节点连接池, 至少需要一个节点创建成功
+func NewPool(ctx context.Context, chainID int, nodeUrls []string, conf PoolConfig) (*Pool, error) {
+	var nodes []*poolNode
+	for i, nodeUrl := range nodeUrls {
+		client, err := New(chainID, nodeUrl)
+		if err != nil {
+			xzap.WithContext(ctx).Warn("failed on create node client",
+				zap.Error(err), zap.String("node", nodeName(i, nodeUrl)))
+			continue
+		}
+		nodes = append(nodes, &poolNode{name: nodeName(i, nodeUrl), client: client})
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("no available rpc node")
+	}
+
+	p := newPool(ctx, chainID, nodes, conf)
+	threading.GoSafe(p.checkLoop)
+
+	return p, nil
+}
+
+func newPool(ctx context.Context, chainID int, nodes []*poolNode, conf PoolConfig) *Pool {
+	if conf.CheckInterval <= 0 {
+		conf.CheckInterval = defaultCheckInterval
+	}
+	if conf.MaxBlockLag == 0 {
+		conf.MaxBlockLag = defaultMaxBlockLag
+	}
+	if conf.QuarantineTime <= 0 {
+		conf.QuarantineTime = defaultQuarantineTime
+	}
+
+	p := &Pool{
+		ctx:     ctx,
+		chainID: strconv.Itoa(chainID),
+		conf:    conf,
+		nodes:   nodes,
+	}
+	p.check()
+
+	return p
+}
+
+// nodeName 节点标签: 序号-域名, 避免 URL 中的 API Key 出现在日志与监控中
+func nodeName(i int, nodeUrl string) string {
+	host := "unknown"
+	if u, err := url.Parse(nodeUrl); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	return fmt.Sprintf("%d-%s", i, host)
+}
+
+func (p *Pool) checkLoop() {
+	ticker := time.NewTicker(p.conf.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.check()
+		}
+	}
+}
+
+// check 并发获取各节点最新区块高度与延迟, 超过检查间隔未返回视为失败
+func (p *Pool) check() {
+	type result struct {
+		head    uint64
+		latency time.Duration
+		err     error
+	}
+
+	results := make([]chan result, len(p.nodes))
+	for i, node := range p.nodes {
+		results[i] = make(chan result, 1)
+		go func(client ChainClient, ch chan<- result) {
+			begin := time.Now()
+			head, err := client.BlockNumber()
+			ch <- result{head: head, latency: time.Since(begin), err: err}
+		}(node.client, results[i])
+	}
+
+	timeout := time.After(p.conf.CheckInterval)
+	checked := make([]result, len(p.nodes))
+	for i := range results {
+		select {
+		case checked[i] = <-results[i]:
+		case <-timeout:
+			checked[i] = result{err: errors.New("health check timeout")}
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var bestHead uint64
+	for i, node := range p.nodes {
+		node.checked = checked[i].err == nil
+		if node.checked {
+			node.head = checked[i].head
+			node.latency = checked[i].latency
+			if node.head > bestHead {
+				bestHead = node.head
+			}
+		} else {
+			xzap.WithContext(p.ctx).Warn("rpc node health check failed",
+				zap.Error(checked[i].err), zap.String("node", node.name))
+		}
+	}
+	for _, node := range p.nodes {
+		lagging := node.checked && bestHead-node.head > p.conf.MaxBlockLag
+		if lagging && !node.lagging {
+			xzap.WithContext(p.ctx).Warn("rpc node is lagging behind, quarantined",
+				zap.String("node", node.name),
+				zap.Uint64("head", node.head),
+				zap.Uint64("best_head", bestHead))
+		}
+		node.lagging = lagging
+		p.reportLocked(node)
+	}
+}
+
+// available 节点是否参与路由, 需持有锁
+func (p *Pool) available(node *poolNode, now time.Time) bool {
+	return node.checked && !node.lagging && now.After(node.quarantinedUntil)
+}
+
+func (p *Pool) reportLocked(node *poolNode) {
+	healthy := 0.0
+	if p.available(node, time.Now()) {
+		healthy = 1
+	}
+	metricNodeHead.Set(float64(node.head), p.chainID, node.name)
+	metricNodeLatency.Set(float64(node.latency.Milliseconds()), p.chainID, node.name)
+	metricNodeHealthy.Set(healthy, p.chainID, node.name)
+}
+
+// candidates 按路由优先级排序的节点: 健康节点按延迟升序, 其余节点按区块高度降序作为最后手段
+func (p *Pool) candidates() []*poolNode {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	nodes := make([]*poolNode, len(p.nodes))
+	copy(nodes, p.nodes)
+	sort.SliceStable(nodes, func(i, j int) bool {
+		ai, aj := p.available(nodes[i], now), p.available(nodes[j], now)
+		if ai != aj {
+			return ai
+		}
+		if ai {
+			return nodes[i].latency < nodes[j].latency
+		}
+		return nodes[i].head > nodes[j].head
+	})
+
+	return nodes
+}
+
+func (p *Pool) quarantine(node *poolNode, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	node.quarantinedUntil = time.Now().Add(p.conf.QuarantineTime)
+	p.reportLocked(node)
+	xzap.WithContext(p.ctx).Warn("rpc node request failed, quarantined",
+		zap.Error(err),
+		zap.String("node", node.name),
+		zap.Duration("quarantine_time", p.conf.QuarantineTime))
+}
+
+// shouldFailover 请求失败后是否切换节点
+// 超时、限流与连接错误切换; 结果过多及节点返回的其他 JSON-RPC 错误(参数错误、合约回滚等)与请求本身有关, 换节点结果相同
+func shouldFailover(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	switch ClassifyError(err) {
+	case ErrorClassRateLimited, ErrorClassTimeout:
+		return true
+	case ErrorClassTooManyResults:
+		return false
+	}
+
+	var rpcErr rpc.Error
+	return !errors.As(err, &rpcErr)
+}
+
+// do 按路由优先级依次在节点上执行请求, 直到成功或遇到无需切换节点的错误
+func (p *Pool) do(fn func(client ChainClient) error) error {
+	var err error
+	for _, node := range p.candidates() {
+		if err = fn(node.client); err == nil {
+			metricNodeRequests.Inc(p.chainID, node.name, "ok")
+			return nil
+		}
+		if errors.Is(err, rpc.ErrNotificationsUnsupported) { // HTTP 节点不支持订阅, 不影响其健康状态
+			continue
+		}
+		if !shouldFailover(err) {
+			metricNodeRequests.Inc(p.chainID, node.name, "error")
+			return err
+		}
+		metricNodeRequests.Inc(p.chainID, node.name, "failover")
+		p.quarantine(node, err)
+	}
+
+	return err
+}
+
+func (p *Pool) FilterLogs(ctx context.Context, q logTypes.FilterQuery) ([]interface{}, error) {
+	var logs []interface{}
+	err := p.do(func(client ChainClient) error {
+		var err error
+		logs, err = client.FilterLogs(ctx, q)
+		return err
+	})
+
+	return logs, err
+}
+
+func (p *Pool) BlockTimeByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	var blockTime uint64
+	err := p.do(func(client ChainClient) error {
+		var err error
+		blockTime, err = client.BlockTimeByNumber(ctx, blockNum)
+		return err
+	})
+
+	return blockTime, err
+}
+
+// Client 返回当前最健康节点的底层客户端
+func (p *Pool) Client() interface{} {
+	return p.candidates()[0].client.Client()
+}
+
+func (p *Pool) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	var res []byte
+	err := p.do(func(client ChainClient) error {
+		var err error
+		res, err = client.CallContract(ctx, msg, blockNumber)
+		return err
+	})
+
+	return res, err
+}
+
+func (p *Pool) CallContractByChain(ctx context.Context, param logTypes.CallParam) (interface{}, error) {
+	var res interface{}
+	err := p.do(func(client ChainClient) error {
+		var err error
+		res, err = client.CallContractByChain(ctx, param)
+		return err
+	})
+
+	return res, err
+}
+
+func (p *Pool) BlockNumber() (uint64, error) {
+	var blockNumber uint64
+	err := p.do(func(client ChainClient) error {
+		var err error
+		blockNumber, err = client.BlockNumber()
+		return err
+	})
+
+	return blockNumber, err
+}
+
+func (p *Pool) BlockWithTxs(ctx context.Context, blockNumber uint64) (interface{}, error) {
+	var block interface{}
+	err := p.do(func(client ChainClient) error {
+		var err error
+		block, err = client.BlockWithTxs(ctx, blockNumber)
+		return err
+	})
+
+	return block, err
+}
+
+func (p *Pool) HeaderByNumber(ctx context.Context, blockNumber *big.Int) (*logTypes.BlockHeader, error) {
+	var header *logTypes.BlockHeader
+	err := p.do(func(client ChainClient) error {
+		var err error
+		header, err = client.HeaderByNumber(ctx, blockNumber)
+		return err
+	})
+
+	return header, err
+}
+
+// SubscribeNewHead 在最健康的节点上订阅, 订阅断开后由调用方重新订阅以切换节点
+func (p *Pool) SubscribeNewHead(ctx context.Context, ch chan<- *logTypes.BlockHeader) (ethereum.Subscription, error) {
+	var sub ethereum.Subscription
+	err := p.do(func(client ChainClient) error {
+		var err error
+		sub, err = client.SubscribeNewHead(ctx, ch)
+		return err
+	})
+
+	return sub, err
+}
+
+// Close 关闭所有节点连接, 健康检查随创建连接池的 ctx 结束
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, node := range p.nodes {
+		node.client.Close()
+	}
+}
+
+func (p *Pool) SubscribeFilterLogs(ctx context.Context, q logTypes.FilterQuery, ch chan<- ethereumTypes.Log) (ethereum.Subscription, error) {
+	var sub ethereum.Subscription
+	err := p.do(func(client ChainClient) error {
+		var err error
+		sub, err = client.SubscribeFilterLogs(ctx, q, ch)
+		return err
+	})
+
+	return sub, err
+}
@@ -0,0 +1,144 @@
+package chainclient
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	logTypes "github.com/ProjectsTask/EasySwapBase/chain/types"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+)
+
+type fakeNode struct {
+	mu      sync.Mutex
+	head    uint64
+	delay   time.Duration
+	callErr error
+	calls   int
+	closed  bool
+}
+
+func (n *fakeNode) call() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.calls++
+	return n.callErr
+}
+
+func (n *fakeNode) callCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.calls
+}
+
+func (n *fakeNode) FilterLogs(ctx context.Context, q logTypes.FilterQuery) ([]interface{}, error) {
+	return nil, n.call()
+}
+
+func (n *fakeNode) BlockTimeByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return 0, n.call()
+}
+
+func (n *fakeNode) Client() interface{} {
+	return n
+}
+
+func (n *fakeNode) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return nil, n.call()
+}
+
+func (n *fakeNode) CallContractByChain(ctx context.Context, param logTypes.CallParam) (interface{}, error) {
+	return nil, n.call()
+}
+
+func (n *fakeNode) BlockNumber() (uint64, error) {
+	time.Sleep(n.delay)
+	return n.head, nil
+}
+
+func (n *fakeNode) BlockWithTxs(ctx context.Context, blockNumber uint64) (interface{}, error) {
+	return nil, n.call()
+}
+
+func (n *fakeNode) HeaderByNumber(ctx context.Context, blockNumber *big.Int) (*logTypes.BlockHeader, error) {
+	return nil, n.call()
+}
+
+func (n *fakeNode) SubscribeNewHead(ctx context.Context, ch chan<- *logTypes.BlockHeader) (ethereum.Subscription, error) {
+	return nil, n.call()
+}
+
+func (n *fakeNode) SubscribeFilterLogs(ctx context.Context, q logTypes.FilterQuery, ch chan<- ethereumTypes.Log) (ethereum.Subscription, error) {
+	return nil, n.call()
+}
+
+func (n *fakeNode) Close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.closed = true
+}
+
+func TestPoolRouting(t *testing.T) {
+	slow := &fakeNode{head: 100, delay: 20 * time.Millisecond}
+	fast := &fakeNode{head: 100}
+	lagging := &fakeNode{head: 80}
+	ctx := xzap.ToContext(context.Background(), zap.NewNop())
+	p := newPool(ctx, 1, []*poolNode{
+		{name: "slow", client: slow},
+		{name: "fast", client: fast},
+		{name: "lagging", client: lagging},
+	}, PoolConfig{CheckInterval: time.Second})
+
+	// 路由到延迟最低的节点, 落后过多的节点排在最后
+	candidates := p.candidates()
+	assert.Equal(t, "fast", candidates[0].name)
+	assert.Equal(t, "slow", candidates[1].name)
+	assert.Equal(t, "lagging", candidates[2].name)
+
+	_, err := p.FilterLogs(context.Background(), logTypes.FilterQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fast.callCount())
+	assert.Equal(t, 0, slow.callCount())
+
+	// 超时切换到下一个节点, 并隔离失败节点
+	fast.callErr = errors.Wrap(context.DeadlineExceeded, "failed on get events")
+	_, err = p.FilterLogs(context.Background(), logTypes.FilterQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, slow.callCount())
+	assert.Equal(t, "slow", p.candidates()[0].name)
+
+	// 节点返回的 JSON-RPC 错误与请求有关, 不切换节点
+	slow.callErr = codeError{code: 3, msg: "execution reverted"}
+	_, err = p.CallContract(context.Background(), ethereum.CallMsg{}, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 2, slow.callCount())
+	assert.Equal(t, 0, lagging.callCount())
+
+	// 节点追上后重新参与路由, 隔离中的节点排在最后
+	lagging.head = 100
+	p.check()
+	candidates = p.candidates()
+	assert.Equal(t, "lagging", candidates[0].name)
+	assert.Equal(t, "fast", candidates[2].name)
+}
+
+func TestPoolClose(t *testing.T) {
+	a, b := &fakeNode{head: 100}, &fakeNode{head: 100}
+	ctx := xzap.ToContext(context.Background(), zap.NewNop())
+	p := newPool(ctx, 1, []*poolNode{{name: "a", client: a}, {name: "b", client: b}}, PoolConfig{})
+
+	p.Close()
+	assert.True(t, a.closed)
+	assert.True(t, b.closed)
+}
@@ -0,0 +1,116 @@
+package chainclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// ErrorClass 节点 RPC 错误分类, 调用方据此选择缩小请求范围、退避或放弃
+type ErrorClass int
+
+const (
+	ErrorClassNone           ErrorClass = iota
+	ErrorClassTooManyResults            // 区块范围或返回结果超过节点限制, 需缩小请求范围
+	ErrorClassRateLimited               // 触发节点限流, 需退避后重试
+	ErrorClassTimeout                   // 请求超时或连接中断, 可缩小范围后重试
+	ErrorClassFatal                     // 其他错误, 重试无法恢复
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassNone:
+		return "none"
+	case ErrorClassTooManyResults:
+		return "too_many_results"
+	case ErrorClassRateLimited:
+		return "rate_limited"
+	case ErrorClassTimeout:
+		return "timeout"
+	default:
+		return "fatal"
+	}
+}
+
+// 各节点服务商返回的错误信息关键字(小写)
+var (
+	tooManyResultsMessages = []string{
+		"query returned more than",     // Infura
+		"log response size exceeded",   // Alchemy
+		"block range",                  // Ankr、QuickNode 等: block range is too wide / exceeds limit
+		"range is too large",           // 通用
+		"response size exceeded",       // 通用
+		"too many results",             // 通用
+		"exceed maximum block range",   // BSC、Polygon 节点
+		"is limited to a 10,000 range", // QuickNode
+	}
+	rateLimitedMessages = []string{
+		"rate limit",
+		"too many requests",
+		"request count exceeded",
+		"compute units",
+		"capacity exceeded",
+	}
+	timeoutMessages = []string{
+		"timeout",
+		"timed out",
+		"deadline exceeded",
+		"connection reset",
+		"connection refused",
+		"unexpected eof",
+	}
+)
+
+// ClassifyError 根据错误码、HTTP 状态码与错误信息对节点 RPC 错误分类
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassNone
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusTooManyRequests:
+			return ErrorClassRateLimited
+		case http.StatusRequestEntityTooLarge:
+			return ErrorClassTooManyResults
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return ErrorClassTimeout
+		}
+	}
+
+	msg := strings.ToLower(err.Error())
+	if containsAny(msg, tooManyResultsMessages) {
+		return ErrorClassTooManyResults
+	}
+	if containsAny(msg, rateLimitedMessages) {
+		return ErrorClassRateLimited
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32005 { // EIP-1474: limit exceeded
+		return ErrorClassRateLimited
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) ||
+		containsAny(msg, timeoutMessages) {
+		return ErrorClassTimeout
+	}
+
+	return ErrorClassFatal
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+
+	return false
+}
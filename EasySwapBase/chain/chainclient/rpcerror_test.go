@@ -0,0 +1,41 @@
+package chainclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type codeError struct {
+	code int
+	msg  string
+}
+
+func (e codeError) Error() string  { return e.msg }
+func (e codeError) ErrorCode() int { return e.code }
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		err   error
+		class ErrorClass
+	}{
+		{nil, ErrorClassNone},
+		{errors.New("query returned more than 10000 results"), ErrorClassTooManyResults},
+		{errors.Wrap(errors.New("Log response size exceeded. You can make eth_getLogs requests with up to a 2K block range"), "failed on filter logs"), ErrorClassTooManyResults},
+		{errors.New("block range is too wide"), ErrorClassTooManyResults},
+		{rpc.HTTPError{StatusCode: 429, Status: "429 Too Many Requests"}, ErrorClassRateLimited},
+		{codeError{code: -32005, msg: "daily request count exceeded"}, ErrorClassRateLimited},
+		{errors.New("Your app has exceeded its compute units per second capacity"), ErrorClassRateLimited},
+		{errors.Wrap(context.DeadlineExceeded, "failed on filter logs"), ErrorClassTimeout},
+		{rpc.HTTPError{StatusCode: 504, Status: "504 Gateway Timeout"}, ErrorClassTimeout},
+		{errors.New("read tcp 10.0.0.1:443: connection reset by peer"), ErrorClassTimeout},
+		{errors.New("invalid argument 0: hex string without 0x prefix"), ErrorClassFatal},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.class, ClassifyError(c.err), "%v", c.err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/pkg/errors"
 
 	"github.com/ProjectsTask/EasySwapBase/chain"
@@ -20,13 +21,32 @@ type ChainClient interface {
 	CallContractByChain(ctx context.Context, param logTypes.CallParam) (interface{}, error)
 	BlockNumber() (uint64, error)
 	BlockWithTxs(ctx context.Context, blockNumber uint64) (interface{}, error)
+	HeaderByNumber(ctx context.Context, blockNumber *big.Int) (*logTypes.BlockHeader, error)
+	// SubscribeNewHead 订阅新区块头, 需要 WebSocket/IPC 节点, HTTP 节点返回错误
+	SubscribeNewHead(ctx context.Context, ch chan<- *logTypes.BlockHeader) (ethereum.Subscription, error)
+	// SubscribeFilterLogs 订阅符合条件的新日志, 链重组时被移除的日志 Removed 为 true
+	SubscribeFilterLogs(ctx context.Context, q logTypes.FilterQuery, ch chan<- ethereumTypes.Log) (ethereum.Subscription, error)
+	// Close 关闭与节点的连接, 关闭后不可再使用
+	Close()
 }
 
 func New(chainID int, nodeUrl string) (ChainClient, error) {
 	switch chainID {
-	case chain.EthChainID, chain.OptimismChainID, chain.SepoliaChainID:
+	case chain.EthChainID, chain.OptimismChainID, chain.SepoliaChainID, chain.BaseChainID:
 		return evmclient.New(nodeUrl)
 	default:
 		return nil, errors.New("unsupported chain id")
 	}
 }
+
+// NewWithEndpoints 根据节点数量创建客户端: 单个节点直接连接, 多个节点创建带健康检查与故障切换的连接池
+func NewWithEndpoints(ctx context.Context, chainID int, nodeUrls []string, conf PoolConfig) (ChainClient, error) {
+	switch len(nodeUrls) {
+	case 0:
+		return nil, errors.New("no rpc endpoint configured")
+	case 1:
+		return New(chainID, nodeUrls[0])
+	default:
+		return NewPool(ctx, chainID, nodeUrls, conf)
+	}
+}
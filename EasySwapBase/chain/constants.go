@@ -12,12 +12,14 @@ const (
 	Eth      = "eth"
 	Optimism = "optimism"
 	Sepolia  = "sepolia"
+	Base     = "base"
 )
 
 const (
 	EthChainID      = 1
 	OptimismChainID = 10
 	SepoliaChainID  = 11155111
+	BaseChainID     = 8453
 )
 
 func UniformAddress(chainName string, address string) (string, error) {
@@ -207,21 +207,28 @@ func (s *Service) fetchJsonData(tokenUri string) (body []byte, err error) {
 }
 
 func (s *Service) FetchOnChainMetadata(collectionAddr string, tokenID string) (*JsonMetadata, error) {
+	metadata, _, err := s.FetchOnChainMetadataWithUri(collectionAddr, tokenID)
+	return metadata, err
+}
+
+// FetchOnChainMetadataWithUri works like FetchOnChainMetadata and also returns the token uri
+// the metadata was loaded from.
+func (s *Service) FetchOnChainMetadataWithUri(collectionAddr string, tokenID string) (*JsonMetadata, string, error) {
 	rawData, tokenUri, err := s.fetchNftMetadata(collectionAddr, tokenID)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed on fetch nft metadata")
+		return nil, "", errors.Wrap(err, "failed on fetch nft metadata")
 	}
 
 	if len(rawData) == 0 {
-		return nil, errors.New("metadata length is zero")
+		return nil, "", errors.New("metadata length is zero")
 	}
 
 	metadata, err := DecodeJsonMetadata(rawData, tokenUri, s.NameTags, s.ImageTags, s.AttributesTags, s.TraitNameTags, s.TraitValueTags)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed on decode metadata")
+		return nil, "", errors.Wrap(err, "failed on decode metadata")
 	}
 
-	return metadata, nil
+	return metadata, tokenUri, nil
 }
 
 // DecodeJsonMetadata parses the NFT token Metadata JSON.
@@ -360,6 +367,21 @@ func DecodeJsonMetadata(content []byte, tokenUri string, nameTags, imageTags, at
 	return nil, errors.New(fmt.Sprintf("unsupported content type:%s", string(content)))
 }
 
+// EncodeJsonMetadata encodes the token metadata JSON that DecodeJsonMetadata parses with the default tags.
+// The JSON is indented: DecodeJsonMetadata treats single-line content that parses as a URL as an image link.
+func EncodeJsonMetadata(metadata *TokenMetadata) ([]byte, error) {
+	if metadata.Name == "" || metadata.Image == "" {
+		return nil, errors.New("metadata name and image are required")
+	}
+
+	content, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on marshal metadata")
+	}
+
+	return content, nil
+}
+
 // isTextFile returns true if file content format is plain text or empty.
 func isTextFile(data []byte) bool {
 	if len(data) == 0 {
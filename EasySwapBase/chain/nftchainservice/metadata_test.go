@@ -0,0 +1,34 @@
+package nftchainservice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeJsonMetadata(t *testing.T) {
+	content, err := EncodeJsonMetadata(&TokenMetadata{
+		Name:        "MetaNode #1",
+		Description: "first token",
+		Image:       "ipfs://bafyimage",
+		Attributes: []*TokenMetadataAttribute{
+			{TraitType: "Background", Value: "Blue"},
+			{TraitType: "Level", Value: "3"},
+		},
+	})
+	require.NoError(t, err)
+
+	metadata, err := DecodeJsonMetadata(content, "ipfs://bafymetadata",
+		[]string{"name", "title"}, []string{"image", "image_url"}, []string{"attributes", "properties"},
+		[]string{"trait_type"}, []string{"value"})
+	require.NoError(t, err)
+	assert.Equal(t, "MetaNode #1", metadata.Name)
+	assert.Equal(t, "ipfs://bafyimage", metadata.Image)
+	require.Len(t, metadata.Attributes, 2)
+	assert.Equal(t, "Background", metadata.Attributes[0].TraitType)
+	assert.Equal(t, "3", metadata.Attributes[1].Value)
+
+	_, err = EncodeJsonMetadata(&TokenMetadata{Name: "no image"})
+	assert.Error(t, err)
+}
@@ -17,8 +17,8 @@ const defaultTimeout = 10 //uint s
 type NodeService interface {
 	FetchOnChainMetadata(chainID int64, collectionAddr string, tokenID string) (*JsonMetadata, error)
 	FetchNftOwner(chainID int64, collectionAddr string, tokenID string) (common.Address, error)
-	GetNFTTransferEvent(chainName string, fromBlock, toBlock uint64) ([]*TransferLog, error)
-	GetNFTTransferEventGoroutine(chainName string, fromBlock, toBlock, blockSize, channelSize uint64) ([]*TransferLog, error)
+	GetNFTTransferEvent(fromBlock, toBlock uint64, contracts ...string) ([]*TransferLog, error)
+	GetNFTTransferEventGoroutine(fromBlock, toBlock, blockSize, channelSize uint64, contracts ...string) ([]*TransferLog, error)
 }
 
 type Service struct {
@@ -38,17 +38,23 @@ type Service struct {
 
 func New(ctx context.Context, endpoint, chainName string, chainID int, nameTags, imageTags, attributesTags,
 	traitNameTags, traitValueTags []string) (*Service, error) {
+	nodeClient, err := chainclient.New(chainID, endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on create node client")
+	}
+
+	return NewWithNodeClient(ctx, nodeClient, chainName, nameTags, imageTags, attributesTags, traitNameTags, traitValueTags)
+}
+
+// NewWithNodeClient 使用已创建的节点客户端(如多节点连接池)创建服务
+func NewWithNodeClient(ctx context.Context, nodeClient chainclient.ChainClient, chainName string, nameTags, imageTags,
+	attributesTags, traitNameTags, traitValueTags []string) (*Service, error) {
 	conf := xhttp.GetDefaultConfig()
 	conf.ForceAttemptHTTP2 = false
 	conf.HTTPTimeout = time.Duration(defaultTimeout) * time.Second
 	conf.DialTimeout = time.Duration(defaultTimeout-5) * time.Second
 	conf.DialKeepAlive = time.Duration(defaultTimeout+10) * time.Second
 
-	nodeClient, err := chainclient.New(chainID, endpoint)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed on create node client")
-	}
-
 	abi, err := NftContractMetaData.GetAbi()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed on get contract abi")
@@ -2,8 +2,10 @@ package nftchainservice
 
 import (
 	"context"
+	"fmt"
 	"math/big"
 	"sort"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	evmTypes "github.com/ethereum/go-ethereum/core/types"
@@ -41,7 +43,9 @@ type TransferLog struct {
 	Removed         bool          `json:"removed"`
 }
 
-func (s *Service) GetNFTTransferEvent(fromBlock, toBlock uint64) ([]*TransferLog, error) {
+// GetNFTTransferEvent returns the ERC721 Transfer logs in [fromBlock, toBlock].
+// When contracts is not empty only logs emitted by those contracts are returned.
+func (s *Service) GetNFTTransferEvent(fromBlock, toBlock uint64, contracts ...string) ([]*TransferLog, error) {
 	// get block time
 	var startBlockTime uint64
 	var err error
@@ -59,12 +63,13 @@ func (s *Service) GetNFTTransferEvent(fromBlock, toBlock uint64) ([]*TransferLog
 	case chain.Eth, chain.Optimism, chain.Sepolia:
 		transferTopic = EVMTransferTopic.String()
 	default:
-		return nil, errors.Wrap(err, "unsupported chain")
+		return nil, errors.New("unsupported chain")
 	}
 
 	logFilter := logTypes.FilterQuery{
 		FromBlock: new(big.Int).SetUint64(fromBlock),
 		ToBlock:   new(big.Int).SetUint64(toBlock),
+		Addresses: contracts,
 		Topics: [][]string{
 			{transferTopic},
 		},
@@ -112,14 +117,97 @@ func (s *Service) GetNFTTransferEvent(fromBlock, toBlock uint64) ([]*TransferLog
 		}
 	}
 
-	// Sort transferLogs by block number
-	sort.Slice(transferLogs, func(i, j int) bool {
-		return transferLogs[i].BlockNumber < transferLogs[j].BlockNumber
-	})
+	sortTransferLogs(transferLogs)
 
 	return transferLogs, nil
 }
 
+// GetNFTTransferEventGoroutine splits [fromBlock, toBlock] into ranges of blockSize
+// blocks and queries them with at most channelSize concurrent requests.
+// The merged result is ordered by block number and log index.
+func (s *Service) GetNFTTransferEventGoroutine(fromBlock, toBlock, blockSize, channelSize uint64, contracts ...string) ([]*TransferLog, error) {
+	if fromBlock > toBlock {
+		return nil, errors.New("invalid block range")
+	}
+	if blockSize == 0 {
+		blockSize = toBlock - fromBlock + 1
+	}
+	if channelSize == 0 {
+		channelSize = 1
+	}
+
+	type blockRange struct {
+		from, to uint64
+	}
+	var ranges []blockRange
+	for start := fromBlock; start <= toBlock; start += blockSize {
+		end := start + blockSize - 1
+		if end > toBlock || end < start {
+			end = toBlock
+		}
+		ranges = append(ranges, blockRange{from: start, to: end})
+		if end == toBlock {
+			break
+		}
+	}
+
+	var (
+		mu           sync.Mutex
+		wg           sync.WaitGroup
+		firstErr     error
+		transferLogs []*TransferLog
+	)
+	limiter := make(chan struct{}, channelSize)
+	for _, r := range ranges {
+		limiter <- struct{}{}
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			<-limiter
+			break
+		}
+
+		wg.Add(1)
+		go func(r blockRange) {
+			defer func() {
+				<-limiter
+				wg.Done()
+			}()
+
+			logs, err := s.GetNFTTransferEvent(r.from, r.to, contracts...)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = errors.Wrap(err, fmt.Sprintf("failed on get transfer event from %d to %d", r.from, r.to))
+				}
+				return
+			}
+			transferLogs = append(transferLogs, logs...)
+		}(r)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	sortTransferLogs(transferLogs)
+
+	return transferLogs, nil
+}
+
+// sortTransferLogs orders logs by block number and log index.
+func sortTransferLogs(transferLogs []*TransferLog) {
+	sort.Slice(transferLogs, func(i, j int) bool {
+		if transferLogs[i].BlockNumber != transferLogs[j].BlockNumber {
+			return transferLogs[i].BlockNumber < transferLogs[j].BlockNumber
+		}
+		return transferLogs[i].Index < transferLogs[j].Index
+	})
+}
+
 func (s *Service) isInSlice(str string, slice []string) bool {
 	addr, err := chain.UniformAddress(s.ChainName, str)
 	if err != nil {
@@ -0,0 +1,116 @@
+package nftchainservice
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	evmTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ProjectsTask/EasySwapBase/chain"
+	logTypes "github.com/ProjectsTask/EasySwapBase/chain/types"
+)
+
+type fakeChainClient struct {
+	mu      sync.Mutex
+	queries []logTypes.FilterQuery
+	logs    []evmTypes.Log
+}
+
+func (f *fakeChainClient) FilterLogs(ctx context.Context, q logTypes.FilterQuery) ([]interface{}, error) {
+	f.mu.Lock()
+	f.queries = append(f.queries, q)
+	f.mu.Unlock()
+
+	var res []interface{}
+	for _, log := range f.logs {
+		if log.BlockNumber >= q.FromBlock.Uint64() && log.BlockNumber <= q.ToBlock.Uint64() {
+			res = append(res, log)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeChainClient) BlockTimeByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return blockNum.Uint64() * 12, nil
+}
+
+func (f *fakeChainClient) Client() interface{} { return nil }
+
+func (f *fakeChainClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (f *fakeChainClient) CallContractByChain(ctx context.Context, param logTypes.CallParam) (interface{}, error) {
+	return nil, nil
+}
+
+func (f *fakeChainClient) BlockNumber() (uint64, error) { return 0, nil }
+
+func (f *fakeChainClient) BlockWithTxs(ctx context.Context, blockNumber uint64) (interface{}, error) {
+	return nil, nil
+}
+
+func (f *fakeChainClient) HeaderByNumber(ctx context.Context, blockNumber *big.Int) (*logTypes.BlockHeader, error) {
+	return nil, nil
+}
+
+func (f *fakeChainClient) SubscribeNewHead(ctx context.Context, ch chan<- *logTypes.BlockHeader) (ethereum.Subscription, error) {
+	return nil, nil
+}
+
+func (f *fakeChainClient) SubscribeFilterLogs(ctx context.Context, q logTypes.FilterQuery, ch chan<- evmTypes.Log) (ethereum.Subscription, error) {
+	return nil, nil
+}
+
+func (f *fakeChainClient) Close() {}
+
+func transferLog(block uint64, index uint, tokenID int64) evmTypes.Log {
+	return evmTypes.Log{
+		Address:     common.HexToAddress("0x1"),
+		BlockNumber: block,
+		Index:       index,
+		Topics: []common.Hash{
+			EVMTransferTopic,
+			common.BigToHash(big.NewInt(0)),
+			common.BigToHash(big.NewInt(2)),
+			common.BigToHash(big.NewInt(tokenID)),
+		},
+	}
+}
+
+func TestGetNFTTransferEventGoroutine(t *testing.T) {
+	client := &fakeChainClient{
+		logs: []evmTypes.Log{
+			transferLog(25, 1, 3),
+			transferLog(1, 0, 1),
+			transferLog(25, 0, 2),
+			transferLog(10, 0, 4),
+		},
+	}
+	s := &Service{ctx: context.Background(), NodeClient: client, ChainName: chain.Sepolia}
+
+	logs, err := s.GetNFTTransferEventGoroutine(1, 25, 10, 2, "0x1")
+	assert.NoError(t, err)
+	assert.Len(t, client.queries, 3)
+	for _, q := range client.queries {
+		assert.Equal(t, []string{"0x1"}, q.Addresses)
+	}
+
+	var tokenIDs []string
+	for _, log := range logs {
+		tokenIDs = append(tokenIDs, log.TokenID)
+	}
+	assert.Equal(t, []string{"1", "4", "2", "3"}, tokenIDs)
+}
+
+func TestGetNFTTransferEventGoroutineInvalidRange(t *testing.T) {
+	s := &Service{ctx: context.Background(), NodeClient: &fakeChainClient{}, ChainName: chain.Sepolia}
+
+	_, err := s.GetNFTTransferEventGoroutine(10, 1, 10, 2)
+	assert.Error(t, err)
+}
@@ -13,3 +13,17 @@ type OpenseaMetadataProps struct {
 	TraitType string `json:"trait_Type"`
 	Value     string `json:"value"`
 }
+
+// TokenMetadata is the ERC-721 Metadata JSON written by EncodeJsonMetadata, attributes use the OpenSea trait_type/value shape.
+type TokenMetadata struct {
+	Name        string                    `json:"name"`
+	Description string                    `json:"description,omitempty"`
+	Image       string                    `json:"image"`
+	ExternalURL string                    `json:"external_url,omitempty"`
+	Attributes  []*TokenMetadataAttribute `json:"attributes,omitempty"`
+}
+
+type TokenMetadataAttribute struct {
+	TraitType string `json:"trait_type"`
+	Value     string `json:"value"`
+}
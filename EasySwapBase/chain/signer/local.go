@@ -0,0 +1,83 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// keySigner 使用进程内私钥签名
+type keySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewPrivateKeySigner 使用十六进制私钥创建签名器
+func NewPrivateKeySigner(hexKey string) (Signer, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse private key")
+	}
+
+	return newKeySigner(key), nil
+}
+
+// NewEnvSigner 使用环境变量中的十六进制私钥创建签名器
+func NewEnvSigner(name string) (Signer, error) {
+	if name == "" {
+		return nil, errors.New("private key env name is required")
+	}
+	hexKey, ok := os.LookupEnv(name)
+	if !ok || hexKey == "" {
+		return nil, errors.Errorf("private key env %s not set", name)
+	}
+
+	s, err := NewPrivateKeySigner(hexKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid private key in env %s", name)
+	}
+
+	return s, nil
+}
+
+// NewKeystoreSigner 解密 geth keystore JSON 文件创建签名器
+func NewKeystoreSigner(path, passphrase string) (Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on read keystore file")
+	}
+	key, err := keystore.DecryptKey(data, passphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on decrypt keystore file")
+	}
+
+	return newKeySigner(key.PrivateKey), nil
+}
+
+func newKeySigner(key *ecdsa.PrivateKey) *keySigner {
+	return &keySigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+}
+
+func (s *keySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *keySigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign transaction")
+	}
+
+	return signed, nil
+}
@@ -0,0 +1,135 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+const signTransactionMethod = "eth_signTransaction"
+
+// remoteSigner 通过签名服务的 eth_signTransaction 签名, 私钥保存在签名服务中
+type remoteSigner struct {
+	client  *rpc.Client
+	address common.Address
+}
+
+// sendTxArgs eth_signTransaction 的请求参数
+type sendTxArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to,omitempty"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	Value                *hexutil.Big    `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Data                 hexutil.Bytes   `json:"data"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+}
+
+// NewRemoteSigner 连接签名服务创建签名器, headers 附加到每个请求
+func NewRemoteSigner(ctx context.Context, url string, address common.Address, headers map[string]string) (Signer, error) {
+	if url == "" {
+		return nil, errors.New("remote signer url is required")
+	}
+
+	var opts []rpc.ClientOption
+	for k, v := range headers {
+		opts = append(opts, rpc.WithHeader(k, v))
+	}
+	client, err := rpc.DialOptions(ctx, url, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on dial remote signer")
+	}
+
+	return &remoteSigner{client: client, address: address}, nil
+}
+
+func (s *remoteSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *remoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := sendTxArgs{
+		From:    s.address,
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   (*hexutil.Big)(tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.Type() == types.LegacyTxType {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	} else {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	}
+
+	var result json.RawMessage
+	if err := s.client.CallContext(ctx, &result, signTransactionMethod, args); err != nil {
+		return nil, errors.Wrap(err, "failed on remote sign transaction")
+	}
+	raw, err := decodeSignResult(result)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, errors.Wrap(err, "failed on decode remote signed transaction")
+	}
+	if err := verifySigned(tx, signed, chainID, s.address); err != nil {
+		return nil, err
+	}
+
+	return signed, nil
+}
+
+// decodeSignResult 解析签名结果: Web3Signer 返回原始交易的十六进制字符串, Clef 返回 {raw, tx}
+func decodeSignResult(result json.RawMessage) ([]byte, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(result), []byte(`"`)) {
+		var raw hexutil.Bytes
+		if err := json.Unmarshal(result, &raw); err != nil {
+			return nil, errors.Wrap(err, "invalid remote sign result")
+		}
+		return raw, nil
+	}
+
+	var res struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := json.Unmarshal(result, &res); err != nil {
+		return nil, errors.Wrap(err, "invalid remote sign result")
+	}
+	if len(res.Raw) == 0 {
+		return nil, errors.New("remote sign result missing raw transaction")
+	}
+
+	return res.Raw, nil
+}
+
+// verifySigned 校验签名服务返回的交易与请求一致且由签名地址签名, 防止签名服务篡改交易内容
+func verifySigned(unsigned, signed *types.Transaction, chainID *big.Int, address common.Address) error {
+	txSigner := types.LatestSignerForChainID(chainID)
+	if unsigned.Type() != signed.Type() || txSigner.Hash(unsigned) != txSigner.Hash(signed) {
+		return errors.New("remote signed transaction does not match request")
+	}
+	sender, err := types.Sender(txSigner, signed)
+	if err != nil {
+		return errors.Wrap(err, "failed on recover remote signed transaction sender")
+	}
+	if sender != address {
+		return errors.Errorf("remote signed transaction sender %s mismatch, expected %s", sender.Hex(), address.Hex())
+	}
+
+	return nil
+}
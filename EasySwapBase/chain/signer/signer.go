@@ -0,0 +1,82 @@
+package signer
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	TypeKeystore = "keystore" // geth keystore JSON 文件 + 密码
+	TypeEnv      = "env"      // 从环境变量读取十六进制私钥
+	TypeRemote   = "remote"   // 通过 eth_signTransaction JSON-RPC 远程签名(Clef/Web3Signer)
+)
+
+// Signer 服务端交易签名接口, 所有服务端发送的交易都通过该接口签名, 私钥不出现在配置文件中
+type Signer interface {
+	// Address 签名地址
+	Address() common.Address
+	// SignTx 为指定链签名交易, 返回签名后的交易
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// Config 签名器配置, 按 Type 使用对应字段
+type Config struct {
+	Type string
+
+	KeyEnv string // env: 私钥所在的环境变量名
+
+	KeystoreFile string // keystore: keystore JSON 文件路径
+	PasswordFile string // keystore: 密码文件路径, 与 PasswordEnv 二选一
+	PasswordEnv  string // keystore: 密码所在的环境变量名
+
+	RemoteURL string            // remote: 签名服务地址
+	Address   string            // remote: 签名地址, 签名服务只用该地址签名
+	Headers   map[string]string // remote: 附加的请求头, 如鉴权信息
+}
+
+// New 按配置创建签名器
+func New(ctx context.Context, conf Config) (Signer, error) {
+	switch conf.Type {
+	case TypeKeystore:
+		passphrase, err := readPassphrase(conf)
+		if err != nil {
+			return nil, err
+		}
+		return NewKeystoreSigner(conf.KeystoreFile, passphrase)
+	case TypeEnv:
+		return NewEnvSigner(conf.KeyEnv)
+	case TypeRemote:
+		if !common.IsHexAddress(conf.Address) {
+			return nil, errors.Errorf("invalid remote signer address %q", conf.Address)
+		}
+		return NewRemoteSigner(ctx, conf.RemoteURL, common.HexToAddress(conf.Address), conf.Headers)
+	default:
+		return nil, errors.Errorf("unsupported signer type %q", conf.Type)
+	}
+}
+
+// readPassphrase 读取 keystore 密码, 去掉密码文件末尾的换行
+func readPassphrase(conf Config) (string, error) {
+	if conf.PasswordFile != "" {
+		data, err := os.ReadFile(conf.PasswordFile)
+		if err != nil {
+			return "", errors.Wrap(err, "failed on read keystore password file")
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if conf.PasswordEnv != "" {
+		passphrase, ok := os.LookupEnv(conf.PasswordEnv)
+		if !ok {
+			return "", errors.Errorf("keystore password env %s not set", conf.PasswordEnv)
+		}
+		return passphrase, nil
+	}
+
+	return "", errors.New("keystore password file or env is required")
+}
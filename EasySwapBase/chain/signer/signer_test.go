@@ -0,0 +1,158 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testChainID = big.NewInt(11155111)
+
+func newTestTxs() []*types.Transaction {
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	return []*types.Transaction{
+		types.NewTx(&types.LegacyTx{Nonce: 1, To: &to, Value: big.NewInt(1), Gas: 21000, GasPrice: big.NewInt(2e9)}),
+		types.NewTx(&types.DynamicFeeTx{ChainID: testChainID, Nonce: 2, To: &to, Value: big.NewInt(0), Gas: 100000,
+			GasFeeCap: big.NewInt(3e9), GasTipCap: big.NewInt(1e9), Data: []byte{0x01, 0x02}}),
+	}
+}
+
+func assertSigned(t *testing.T, s Signer, address common.Address) {
+	assert.Equal(t, address, s.Address())
+	for _, tx := range newTestTxs() {
+		signed, err := s.SignTx(context.Background(), tx, testChainID)
+		require.NoError(t, err)
+		sender, err := types.Sender(types.LatestSignerForChainID(testChainID), signed)
+		require.NoError(t, err)
+		assert.Equal(t, address, sender)
+		assert.Equal(t, tx.Nonce(), signed.Nonce())
+	}
+}
+
+func TestEnvSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	t.Setenv("TEST_SIGNER_KEY", hexutil.Encode(crypto.FromECDSA(key)))
+
+	s, err := New(context.Background(), Config{Type: TypeEnv, KeyEnv: "TEST_SIGNER_KEY"})
+	require.NoError(t, err)
+	assertSigned(t, s, crypto.PubkeyToAddress(key.PublicKey))
+
+	_, err = New(context.Background(), Config{Type: TypeEnv, KeyEnv: "TEST_SIGNER_KEY_MISSING"})
+	assert.Error(t, err)
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	account, err := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP).ImportECDSA(key, "secret")
+	require.NoError(t, err)
+	keyFile := account.URL.Path
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("secret\n"), 0600))
+
+	s, err := New(context.Background(), Config{Type: TypeKeystore, KeystoreFile: keyFile, PasswordFile: passwordFile})
+	require.NoError(t, err)
+	assertSigned(t, s, crypto.PubkeyToAddress(key.PublicKey))
+
+	t.Setenv("TEST_SIGNER_PASSWORD", "wrong")
+	_, err = New(context.Background(), Config{Type: TypeKeystore, KeystoreFile: keyFile, PasswordEnv: "TEST_SIGNER_PASSWORD"})
+	assert.Error(t, err)
+}
+
+// stubSigner 模拟签名服务的 eth 命名空间
+type stubSigner struct {
+	key    *ecdsa.PrivateKey
+	clef   bool // 按 Clef 格式返回 {raw, tx}
+	tamper bool // 篡改交易 nonce 后签名
+}
+
+func (s *stubSigner) SignTransaction(args sendTxArgs) (interface{}, error) {
+	nonce := uint64(args.Nonce)
+	if s.tamper {
+		nonce++
+	}
+	var txData types.TxData
+	if args.GasPrice != nil {
+		txData = &types.LegacyTx{Nonce: nonce, To: args.To, Value: args.Value.ToInt(), Gas: uint64(args.Gas),
+			GasPrice: args.GasPrice.ToInt(), Data: args.Data}
+	} else {
+		txData = &types.DynamicFeeTx{ChainID: args.ChainID.ToInt(), Nonce: nonce, To: args.To, Value: args.Value.ToInt(),
+			Gas: uint64(args.Gas), GasFeeCap: args.MaxFeePerGas.ToInt(), GasTipCap: args.MaxPriorityFeePerGas.ToInt(), Data: args.Data}
+	}
+	signed, err := types.SignNewTx(s.key, types.LatestSignerForChainID(args.ChainID.ToInt()), txData)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if s.clef {
+		return map[string]interface{}{"raw": hexutil.Bytes(raw), "tx": signed}, nil
+	}
+
+	return hexutil.Bytes(raw), nil
+}
+
+func newStubServer(t *testing.T, stub *stubSigner) string {
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", stub))
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+
+	return httpServer.URL
+}
+
+func TestRemoteSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	for _, clef := range []bool{false, true} {
+		url := newStubServer(t, &stubSigner{key: key, clef: clef})
+		s, err := New(context.Background(), Config{Type: TypeRemote, RemoteURL: url, Address: address.Hex(),
+			Headers: map[string]string{"Authorization": "Bearer test"}})
+		require.NoError(t, err)
+		assertSigned(t, s, address)
+	}
+
+	// 签名服务篡改交易或使用其他地址签名时拒绝
+	url := newStubServer(t, &stubSigner{key: key, tamper: true})
+	s, err := NewRemoteSigner(context.Background(), url, address, nil)
+	require.NoError(t, err)
+	_, err = s.SignTx(context.Background(), newTestTxs()[1], testChainID)
+	assert.Error(t, err)
+
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	url = newStubServer(t, &stubSigner{key: other})
+	s, err = NewRemoteSigner(context.Background(), url, address, nil)
+	require.NoError(t, err)
+	_, err = s.SignTx(context.Background(), newTestTxs()[0], testChainID)
+	assert.Error(t, err)
+}
+
+func TestNewInvalid(t *testing.T) {
+	_, err := New(context.Background(), Config{Type: "private_key"})
+	assert.Error(t, err)
+	_, err = New(context.Background(), Config{Type: TypeRemote, RemoteURL: "http://127.0.0.1:1", Address: "0x1"})
+	assert.Error(t, err)
+}
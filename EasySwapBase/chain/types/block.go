@@ -0,0 +1,10 @@
+package types
+
+// BlockHeader 区块头中用于链重组检测的字段
+type BlockHeader struct {
+	Number     uint64
+	Hash       string
+	ParentHash string
+	Time       uint64
+	Bloom      []byte // 日志布隆过滤器, 用于校验订阅推送的日志是否完整
+}
@@ -3,11 +3,14 @@ package erc
 import (
 	"github.com/pkg/errors"
 
+	"github.com/ProjectsTask/EasySwapBase/evm/erc/erc1155"
 	"github.com/ProjectsTask/EasySwapBase/evm/erc/erc721"
 )
 
 type Erc interface {
 	GetItemOwner(address string, tokenId string) (string, error)
+	// GetItemBalance owner 持有的数量, ERC721 为 0 或 1
+	GetItemBalance(address string, tokenId string, owner string) (int64, error)
 }
 
 type NftErc struct {
@@ -23,6 +26,8 @@ func NewErc(c *NftErc) (Erc, error) {
 	switch c.Standard {
 	case erc721.ERC721:
 		return erc721.NewNftErc721(c.Endpoint)
+	case erc1155.ERC1155:
+		return erc1155.NewNftErc1155(c.Endpoint)
 	default:
 		return nil, errors.New("err config")
 	}
@@ -0,0 +1,53 @@
+package erc1155
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	ERC1155 = "erc1155"
+)
+
+type NftErc1155 struct {
+	client   *ethclient.Client
+	endpoint string
+}
+
+func NewNftErc1155(endpoint string) (*NftErc1155, error) {
+	client, err := ethclient.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NftErc1155{
+		client:   client,
+		endpoint: endpoint,
+	}, nil
+}
+
+// GetItemOwner ERC1155 NFT 可以有多个持有人, 没有唯一的所有者
+func (n *NftErc1155) GetItemOwner(address string, tokenId string) (string, error) {
+	return "", errors.New("erc1155 token has no single owner")
+}
+
+func (n *NftErc1155) GetItemBalance(address string, tokenId string, owner string) (int64, error) {
+	addr := common.HexToAddress(address)
+	instance, err := NewErc1155Caller(addr, n.client)
+	if err != nil {
+		return 0, err
+	}
+
+	token := new(big.Int)
+	token.SetString(tokenId, 10)
+	balance, err := instance.BalanceOf(&bind.CallOpts{}, common.HexToAddress(owner), token)
+	if err != nil {
+		return 0, err
+	}
+
+	return balance.Int64(), nil
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"math/big"
+	"strings"
 )
 
 const (
@@ -44,3 +45,15 @@ func (n *NftErc721) GetItemOwner(address string, tokenId string) (string, error)
 
 	return ownerOf.Hex(), nil
 }
+
+func (n *NftErc721) GetItemBalance(address string, tokenId string, owner string) (int64, error) {
+	ownerOf, err := n.GetItemOwner(address, tokenId)
+	if err != nil {
+		return 0, err
+	}
+	if !strings.EqualFold(ownerOf, owner) {
+		return 0, nil
+	}
+
+	return 1, nil
+}
@@ -0,0 +1,165 @@
+package marketfeed
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+)
+
+// 推送事件类型
+const (
+	TypeListing    = "listing"
+	TypeBid        = "bid"
+	TypeCancel     = "cancel"
+	TypeSale       = "sale"
+	TypeFloorPrice = "floor_price_change"
+)
+
+const (
+	// MaxStreamLength 每条链保留的最近事件数, 更早的事件无法通过游标续传
+	MaxStreamLength = 10000
+
+	cacheStreamPre    = "cache:es:market:stream:%s"
+	cacheStreamSeqPre = "cache:es:market:stream:seq:%s"
+)
+
+// publishScript 原子地分配递增编号并写入定长列表, 列表元素格式为 "<id> <json>"
+const publishScript = `local id = redis.call('INCR', KEYS[2]);
+redis.call('RPUSH', KEYS[1], id .. ' ' .. ARGV[1]);
+redis.call('LTRIM', KEYS[1], -tonumber(ARGV[2]), -1);
+return id;`
+
+// Event 市场推送事件, 由索引服务写入, 多个消费者可重复读取
+type Event struct {
+	ID                uint64          `json:"id"` // 同一条链内单调递增, 作为续传游标
+	Type              string          `json:"type"`
+	Chain             string          `json:"chain"`
+	CollectionAddress string          `json:"collection_address"`
+	TokenID           string          `json:"token_id,omitempty"`
+	OrderID           string          `json:"order_id,omitempty"`
+	Maker             string          `json:"maker,omitempty"`
+	Taker             string          `json:"taker,omitempty"`
+	Price             decimal.Decimal `json:"price"`
+	TxHash            string          `json:"tx_hash,omitempty"`
+	EventTime         int64           `json:"event_time"`
+}
+
+// Publish 写入事件并返回分配的编号, 地址统一转为小写便于订阅方过滤
+func Publish(kv *xkv.Store, chain string, event *Event) (uint64, error) {
+	event.Chain = chain
+	event.CollectionAddress = strings.ToLower(event.CollectionAddress)
+	event.Maker = strings.ToLower(event.Maker)
+	event.Taker = strings.ToLower(event.Taker)
+
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on marshal market event")
+	}
+	result, err := kv.Redis.Eval(publishScript, []string{streamKey(chain), seqKey(chain)}, string(raw), MaxStreamLength)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on publish market event")
+	}
+	id, ok := result.(int64)
+	if !ok {
+		return 0, errors.Errorf("unexpected publish result %v", result)
+	}
+
+	event.ID = uint64(id)
+	return event.ID, nil
+}
+
+// Read 读取编号大于 after 的最多 limit 个事件
+// 游标之后的事件已被裁剪时 gap 为 true, 调用方需要通过接口重新加载完整数据
+func Read(kv *xkv.Store, chain string, after uint64, limit int) (events []*Event, gap bool, err error) {
+	key := streamKey(chain)
+	head, err := kv.Redis.Lrange(key, 0, 0)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed on get market stream head")
+	}
+	if len(head) == 0 {
+		return nil, false, nil
+	}
+	first, _, err := parseEntry(head[0])
+	if err != nil {
+		return nil, false, err
+	}
+
+	// 编号连续, 可直接由编号计算列表下标
+	start := 0
+	if after+1 < first {
+		gap = after > 0
+	} else {
+		start = int(after + 1 - first)
+	}
+	entries, err := kv.Redis.Lrange(key, start, start+limit-1)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed on read market stream")
+	}
+
+	for _, entry := range entries {
+		id, raw, err := parseEntry(entry)
+		if err != nil {
+			return nil, false, err
+		}
+		// 读取期间列表可能被裁剪导致下标偏移, 跳过已读事件并检查是否有遗漏
+		if id <= after {
+			continue
+		}
+		if len(events) == 0 && after > 0 && id > after+1 {
+			gap = true
+		}
+
+		var event Event
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			return nil, false, errors.Wrap(err, "failed on unmarshal market event")
+		}
+		event.ID = id
+		events = append(events, &event)
+	}
+
+	return events, gap, nil
+}
+
+// Latest 返回链上最新事件的编号, 没有事件时为 0
+func Latest(kv *xkv.Store, chain string) (uint64, error) {
+	value, err := kv.Redis.Get(seqKey(chain))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on get market stream sequence")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid market stream sequence %q", value)
+	}
+	return id, nil
+}
+
+func parseEntry(entry string) (uint64, string, error) {
+	idStr, raw, ok := strings.Cut(entry, " ")
+	if !ok {
+		return 0, "", errors.Errorf("invalid market stream entry %q", entry)
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return 0, "", errors.Wrapf(err, "invalid market stream entry id %q", idStr)
+	}
+
+	return id, raw, nil
+}
+
+func streamKey(chain string) string {
+	return fmt.Sprintf(cacheStreamPre, chain)
+}
+
+func seqKey(chain string) string {
+	return fmt.Sprintf(cacheStreamSeqPre, chain)
+}
@@ -0,0 +1,134 @@
+package nftapproval
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+)
+
+// 挂单不可成交的原因
+const (
+	ReasonFillable    = "可以购买"
+	ReasonNoListing   = "没有有效的挂单"
+	ReasonNotOwner    = "挂单人不是NFT所有者"
+	ReasonNotApproved = "NFT未授权给vault合约"
+)
+
+// Fillability NFT 当前挂单的可成交状态
+type Fillability struct {
+	CollectionAddress string `json:"collection_address"`
+	TokenID           string `json:"token_id"`
+	OrderID           string `json:"order_id"`
+	Maker             string `json:"maker"`
+	Owner             string `json:"owner"`
+	Quantity          int64  `json:"quantity"`      // 挂单剩余数量
+	MakerBalance      int64  `json:"maker_balance"` // ERC1155 挂单人持有数量
+	VaultApproved     bool   `json:"vault_approved"`
+	Fillable          bool   `json:"fillable"`
+	Reason            string `json:"reason"`
+}
+
+// IsApproved 根据授权状态表判断 owner 是否已将 token 授权给 operator (ApprovalForAll 或单个 token 授权)
+func IsApproved(db *gorm.DB, chain, collection, tokenID, owner, operator string) (bool, error) {
+	var count int64
+	if err := db.Table(multi.NftApprovalTableName(chain)).
+		Where("collection_address = ? and token_id in (?, '') and owner = ? and operator = ? and approved = ?",
+			strings.ToLower(collection), tokenID, strings.ToLower(owner), strings.ToLower(operator), true).
+		Count(&count).Error; err != nil {
+		return false, errors.Wrap(err, "failed on query nft approval")
+	}
+
+	return count > 0, nil
+}
+
+// CheckListing 按价格从低到高检查 NFT 的有效挂单能否由市场合约成交, 返回最低价的可成交挂单:
+// 挂单人仍是 NFT 所有者(ERC1155 为持有数量不少于挂单剩余数量), 且已授权给 vault 合约
+// 没有可成交的挂单时返回最低价挂单的检查结果
+func CheckListing(db *gorm.DB, chain, collection, tokenID, vault string, now int64) (*Fillability, error) {
+	collection = strings.ToLower(collection)
+	var listings []multi.Order
+	if err := db.Table(multi.OrderTableName(chain)).
+		Where("collection_address = ? and token_id = ? and order_type = ? and order_status = ? and expire_time > ?",
+			collection, tokenID, multi.ListingOrder, multi.OrderStatusActive, now).
+		Order("price asc").
+		Find(&listings).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query active listing")
+	}
+	if len(listings) == 0 {
+		return &Fillability{CollectionAddress: collection, TokenID: tokenID, Reason: ReasonNoListing}, nil
+	}
+
+	var item multi.Item
+	if err := db.Table(multi.ItemTableName(chain)).
+		Select("owner").
+		Where("collection_address = ? and token_id = ?", collection, tokenID).
+		Limit(1).
+		Find(&item).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query item owner")
+	}
+
+	var cheapest *Fillability
+	for _, listing := range listings {
+		result, err := checkListing(db, chain, &item, &listing, vault)
+		if err != nil {
+			return nil, err
+		}
+		if result.Fillable {
+			return result, nil
+		}
+		if cheapest == nil {
+			cheapest = result
+		}
+	}
+
+	return cheapest, nil
+}
+
+// checkListing 检查单个挂单能否成交, item 为 NFT 当前所有者记录
+func checkListing(db *gorm.DB, chain string, item *multi.Item, listing *multi.Order, vault string) (*Fillability, error) {
+	result := &Fillability{
+		CollectionAddress: strings.ToLower(listing.CollectionAddress),
+		TokenID:           listing.TokenId,
+		OrderID:           listing.OrderID,
+		Maker:             listing.Maker,
+		Owner:             item.Owner,
+		Quantity:          listing.QuantityRemaining,
+	}
+	isOwner := strings.EqualFold(item.Owner, listing.Maker)
+	// ERC1155 NFT 没有唯一的所有者, 按挂单人的持有数量判断
+	// 同步开始前持有且之后未转移的持有数量未收录, 无法判断时不视为非所有者
+	if item.Owner == "" {
+		var balance multi.ItemBalance
+		if err := db.Table(multi.ItemBalanceTableName(chain)).
+			Select("id, balance").
+			Where("collection_address = ? and token_id = ? and owner = ?",
+				result.CollectionAddress, listing.TokenId, strings.ToLower(listing.Maker)).
+			Limit(1).
+			Find(&balance).Error; err != nil {
+			return nil, errors.Wrap(err, "failed on query item balance")
+		}
+		result.MakerBalance = balance.Balance
+		isOwner = balance.Id == 0 || (balance.Balance > 0 && balance.Balance >= listing.QuantityRemaining)
+	}
+
+	approved, err := IsApproved(db, chain, result.CollectionAddress, listing.TokenId, listing.Maker, vault)
+	if err != nil {
+		return nil, err
+	}
+	result.VaultApproved = approved
+
+	switch {
+	case !isOwner:
+		result.Reason = ReasonNotOwner
+	case !approved || !listing.Fillable:
+		result.Reason = ReasonNotApproved
+	default:
+		result.Fillable = true
+		result.Reason = ReasonFillable
+	}
+
+	return result, nil
+}
@@ -34,6 +34,8 @@ func (om *OrderManager) orderExpiryProcess() {
 	// 3. 每秒检查一次时间轮
 	for {
 		select {
+		case <-om.Ctx.Done(): // 上下文取消时退出
+			return
 		case <-time.After(time.Second * 1): // 每秒执行一次检查
 			// 如果当前索引超过时间轮大小,则取模重置
 			if om.CurrentIndex >= WheelSize {
@@ -14,6 +14,7 @@ import (
 	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
 
 	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/marketfeed"
 	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
 	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
 )
@@ -70,6 +71,10 @@ func (om *OrderManager) floorPriceProcess() {
 
 	// 持续监听并处理交易事件
 	for {
+		if om.Ctx.Err() != nil { // 上下文取消时退出
+			return
+		}
+
 		// 从缓存中获取交易事件
 		result, err := om.Xkv.Lpop(key)
 		if err != nil || result == "" {
@@ -296,7 +301,9 @@ func (om *OrderManager) loadCollectionTradeInfo() error {
 			if err := om.updateFloorPrice(addr, floorPrice); err != nil {
 				xzap.WithContext(om.Ctx).Warn("failed on update collection floor price",
 					zap.String("collection_addr", addr), zap.Error(err))
+				continue
 			}
+			om.publishFloorPrice(addr, floorPrice)
 		}
 	}
 
@@ -343,10 +350,24 @@ func (om *OrderManager) checkAndUpdateFloorPrice(address string) error {
 		// 记录地板价更新日志
 		xzap.WithContext(om.Ctx).Info("update collection floor price",
 			zap.String("collection_addr", address), zap.String("floor_price", newFloorPrice.String()))
+		om.publishFloorPrice(address, newFloorPrice)
 	}
 	return nil
 }
 
+// publishFloorPrice 推送地板价变化事件, 推送失败不影响地板价更新
+func (om *OrderManager) publishFloorPrice(address string, price decimal.Decimal) {
+	if _, err := marketfeed.Publish(om.Xkv, om.chain, &marketfeed.Event{
+		Type:              marketfeed.TypeFloorPrice,
+		CollectionAddress: address,
+		Price:             price,
+		EventTime:         time.Now().Unix(),
+	}); err != nil {
+		xzap.WithContext(om.Ctx).Warn("failed on publish floor price event",
+			zap.String("collection_addr", address), zap.Error(err))
+	}
+}
+
 // getLowestPrice100Orders 函数用于获取指定NFT集合中价格最低的100个订单
 // 主要功能包括:
 // 1. 从数据库中查询指定集合的订单信息
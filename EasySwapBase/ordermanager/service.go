@@ -106,6 +106,10 @@ type ListingInfo struct {
 func (om *OrderManager) ListenNewListingLoop() {
 	key := GenOrdersCacheKey(om.chain)
 	for {
+		if om.Ctx.Err() != nil { // 上下文取消时退出
+			return
+		}
+
 		result, err := om.Xkv.Lpop(key)
 		if err != nil || result == "" {
 			if err != nil && err != redis.Nil {
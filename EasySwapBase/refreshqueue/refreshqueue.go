@@ -0,0 +1,29 @@
+package refreshqueue
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Item 待刷新元数据的 NFT, 以 JSON 写入刷新队列
+type Item struct {
+	ChainID        int64  `json:"chain_id"`
+	CollectionAddr string `json:"collection_addr"`
+	TokenID        string `json:"token_id"`
+	Attempts       int    `json:"attempts,omitempty"` // 已失败次数, 只出现在重试队列中
+}
+
+// Key 刷新队列(set), 由 EasySwapBackend 写入, EasySwapSync 的元数据刷新任务消费
+func Key(project, chain string) string {
+	return fmt.Sprintf("cache:%s:%s:item:refresh:metadata", strings.ToLower(project), strings.ToLower(chain))
+}
+
+// RetryKey 重试队列(zset), score 为下次重试时间(秒)
+func RetryKey(project, chain string) string {
+	return fmt.Sprintf("cache:%s:%s:item:refresh:metadata:retry", strings.ToLower(project), strings.ToLower(chain))
+}
+
+// StatsKey 按合集统计的刷新结果(hash), field 为 <合集地址>:<统计项>
+func StatsKey(project, chain string) string {
+	return fmt.Sprintf("cache:%s:%s:item:refresh:metadata:stats", strings.ToLower(project), strings.ToLower(chain))
+}
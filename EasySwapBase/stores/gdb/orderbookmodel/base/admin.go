@@ -0,0 +1,42 @@
+package base
+
+const (
+	AdminRoleViewer   = "viewer"   // 只读
+	AdminRoleOperator = "operator" // 读写
+	AdminRoleSuper    = "super"    // 读写及管理员维护
+)
+
+// AdminUser 后台管理员及角色
+type AdminUser struct {
+	Id         int64  `json:"id" gorm:"primaryKey;autoIncrement;column:id;comment:主键"`
+	Address    string `json:"address" gorm:"column:address;type:varchar(42);uniqueIndex:index_address;not null;comment:管理员地址"`
+	Role       string `json:"role" gorm:"column:role;type:varchar(16);not null;comment:角色(viewer/operator/super)"`
+	Enabled    bool   `json:"enabled" gorm:"column:enabled;not null;comment:是否启用"`
+	CreatedBy  string `json:"created_by" gorm:"column:created_by;type:varchar(42);not null;default:'';comment:添加人"`
+	CreateTime int64  `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"` // 创建时间
+	UpdateTime int64  `json:"update_time" gorm:"column:update_time;type:bigint(20);autoUpdateTime:milli;comment:更新时间"` // 更新时间
+}
+
+func AdminUserTableName() string {
+	return "ob_admin_user"
+}
+
+// AdminAuditLog 后台管理操作审计日志
+type AdminAuditLog struct {
+	Id         int64  `json:"id" gorm:"primaryKey;autoIncrement;column:id;comment:主键"`
+	Operator   string `json:"operator" gorm:"column:operator;type:varchar(42);index:index_operator;not null;comment:操作人地址"`
+	Role       string `json:"role" gorm:"column:role;type:varchar(16);not null;comment:操作人角色"`
+	Action     string `json:"action" gorm:"column:action;type:varchar(64);index:index_action;not null;comment:操作类型"`
+	ChainId    int64  `json:"chain_id" gorm:"column:chain_id;not null;default:0;comment:链ID"`
+	Target     string `json:"target" gorm:"column:target;type:varchar(256);not null;default:'';comment:操作对象"`
+	Before     string `json:"before" gorm:"column:before_value;type:text;comment:变更前"`
+	After      string `json:"after" gorm:"column:after_value;type:text;comment:变更后"`
+	Success    bool   `json:"success" gorm:"column:success;not null;comment:是否成功"`
+	ErrorMsg   string `json:"error_msg" gorm:"column:error_msg;type:varchar(1024);not null;default:'';comment:错误信息"`
+	ClientIp   string `json:"client_ip" gorm:"column:client_ip;type:varchar(64);not null;default:'';comment:客户端IP"`
+	CreateTime int64  `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"` // 创建时间
+}
+
+func AdminAuditLogTableName() string {
+	return "ob_admin_audit_log"
+}
@@ -0,0 +1,91 @@
+package base
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// 提醒规则类型, 价格均以 wei 为单位
+const (
+	AlertRuleFloorBelow      = "floor_below"      // 合集地板价低于阈值
+	AlertRuleBidAbove        = "bid_above"        // 自己持有的 NFT 收到高于阈值的出价
+	AlertRuleListingUndercut = "listing_undercut" // 他人挂单价格低于自己在该合集的最低挂单价
+	AlertRuleVolumeSpike     = "volume_spike"     // 合集在统计窗口内的成交额达到阈值
+)
+
+// 站外通知渠道, 站内信始终写入
+const (
+	NotifyChannelEmail   = "email"
+	NotifyChannelWebhook = "webhook"
+)
+
+// Watchlist 用户关注的合集或单个 NFT
+type Watchlist struct {
+	Id                int64  `json:"id" gorm:"primaryKey;autoIncrement;column:id;comment:主键"`
+	UserAddress       string `json:"user_address" gorm:"column:user_address;type:varchar(42);not null;uniqueIndex:index_user_chain_collection_token,priority:1;comment:用户地址"`
+	ChainId           int64  `json:"chain_id" gorm:"column:chain_id;not null;uniqueIndex:index_user_chain_collection_token,priority:2;comment:链ID"`
+	CollectionAddress string `json:"collection_address" gorm:"column:collection_address;type:varchar(42);not null;uniqueIndex:index_user_chain_collection_token,priority:3;comment:合集地址"`
+	TokenId           string `json:"token_id" gorm:"column:token_id;type:varchar(128);not null;default:'';uniqueIndex:index_user_chain_collection_token,priority:4;comment:token_id, 为空时关注整个合集"`
+	CreateTime        int64  `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"` // 创建时间
+}
+
+func WatchlistTableName() string {
+	return "ob_watchlist"
+}
+
+// AlertRule 用户的价格提醒规则
+type AlertRule struct {
+	Id                int64           `json:"id" gorm:"primaryKey;autoIncrement;column:id;comment:主键"`
+	UserAddress       string          `json:"user_address" gorm:"column:user_address;type:varchar(42);not null;index:index_user_address;comment:用户地址"`
+	ChainId           int64           `json:"chain_id" gorm:"column:chain_id;not null;index:index_chain_collection,priority:1;comment:链ID"`
+	CollectionAddress string          `json:"collection_address" gorm:"column:collection_address;type:varchar(42);not null;index:index_chain_collection,priority:2;comment:合集地址"`
+	TokenId           string          `json:"token_id" gorm:"column:token_id;type:varchar(128);not null;default:'';comment:token_id, 为空时作用于整个合集"`
+	RuleType          string          `json:"rule_type" gorm:"column:rule_type;type:varchar(32);not null;comment:规则类型"`
+	Threshold         decimal.Decimal `json:"threshold" gorm:"column:threshold;type:decimal(30,0);not null;default:0;comment:阈值(wei)"`
+	Window            int64           `json:"window" gorm:"column:window_seconds;not null;default:0;comment:成交额统计窗口(秒), 仅 volume_spike 使用"`
+	Channels          string          `json:"channels" gorm:"column:channels;type:varchar(64);not null;default:'';comment:站外通知渠道, 逗号分隔"`
+	Cooldown          int64           `json:"cooldown" gorm:"column:cooldown;not null;default:0;comment:两次触发的最小间隔(秒)"`
+	Enabled           bool            `json:"enabled" gorm:"column:enabled;not null;comment:是否启用"`
+	LastTriggeredTime int64           `json:"last_triggered_time" gorm:"column:last_triggered_time;not null;default:0;comment:最近一次触发时间(毫秒)"`
+	CreateTime        int64           `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"` // 创建时间
+	UpdateTime        int64           `json:"update_time" gorm:"column:update_time;type:bigint(20);autoUpdateTime:milli;comment:更新时间"` // 更新时间
+}
+
+func AlertRuleTableName() string {
+	return "ob_alert_rule"
+}
+
+// Notification 站内信, 由提醒规则触发
+type Notification struct {
+	Id                int64  `json:"id" gorm:"primaryKey;autoIncrement;column:id;comment:主键"`
+	UserAddress       string `json:"user_address" gorm:"column:user_address;type:varchar(42);not null;index:index_user_read,priority:1;comment:用户地址"`
+	RuleId            int64  `json:"rule_id" gorm:"column:rule_id;not null;uniqueIndex:index_rule_event,priority:1;comment:提醒规则ID"`
+	RuleType          string `json:"rule_type" gorm:"column:rule_type;type:varchar(32);not null;comment:规则类型"`
+	EventId           string `json:"event_id" gorm:"column:event_id;type:varchar(96);not null;uniqueIndex:index_rule_event,priority:2;comment:触发事件ID(<chain>:<编号>)"`
+	ChainId           int64  `json:"chain_id" gorm:"column:chain_id;not null;comment:链ID"`
+	CollectionAddress string `json:"collection_address" gorm:"column:collection_address;type:varchar(42);not null;comment:合集地址"`
+	TokenId           string `json:"token_id" gorm:"column:token_id;type:varchar(128);not null;default:'';comment:token_id"`
+	Title             string `json:"title" gorm:"column:title;type:varchar(256);not null;comment:标题"`
+	Content           string `json:"content" gorm:"column:content;type:text;comment:触发事件内容(JSON)"`
+	IsRead            bool   `json:"is_read" gorm:"column:is_read;default:0;not null;index:index_user_read,priority:2;comment:是否已读"`
+	ReadTime          int64  `json:"read_time" gorm:"column:read_time;not null;default:0;comment:已读时间(毫秒)"`
+	CreateTime        int64  `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"` // 创建时间
+}
+
+func NotificationTableName() string {
+	return "ob_notification"
+}
+
+// NotifySetting 用户的站外通知渠道配置
+type NotifySetting struct {
+	Id            int64  `json:"id" gorm:"primaryKey;autoIncrement;column:id;comment:主键"`
+	UserAddress   string `json:"user_address" gorm:"column:user_address;type:varchar(42);not null;uniqueIndex:index_notify_user_address;comment:用户地址"`
+	Email         string `json:"email" gorm:"column:email;type:varchar(256);not null;default:'';comment:通知邮箱"`
+	WebhookUrl    string `json:"webhook_url" gorm:"column:webhook_url;type:varchar(512);not null;default:'';comment:通知 webhook 地址"`
+	WebhookSecret string `json:"-" gorm:"column:webhook_secret;type:varchar(128);not null;default:'';comment:webhook HMAC签名密钥"`
+	CreateTime    int64  `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"` // 创建时间
+	UpdateTime    int64  `json:"update_time" gorm:"column:update_time;type:bigint(20);autoUpdateTime:milli;comment:更新时间"` // 更新时间
+}
+
+func NotifySettingTableName() string {
+	return "ob_notify_setting"
+}
@@ -22,3 +22,69 @@ type IndexedStatus struct {
 func IndexedStatusTableName() string {
 	return "ob_indexed_status"
 }
+
+// IndexedBlock 已索引区块的哈希, 用于比对父哈希检测链重组
+type IndexedBlock struct {
+	Id          int64  `json:"id" gorm:"primaryKey;autoIncrement;column:id;comment:主键"`
+	ChainId     int    `json:"chain_id" gorm:"column:chain_id;default:1;NOT NULL"`
+	IndexType   int32  `json:"index_type" gorm:"column:index_type;type:tinyint(4);not null;default:0"`
+	BlockNumber int64  `json:"block_number" gorm:"column:block_number;type:bigint(20);not null"`
+	BlockHash   string `json:"block_hash" gorm:"column:block_hash;type:varchar(66);not null"`
+	ParentHash  string `json:"parent_hash" gorm:"column:parent_hash;type:varchar(66);not null;default:''"` // 只记录了日志中的区块哈希时为空
+	CreateTime  int64  `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"`
+}
+
+func IndexedBlockTableName() string {
+	return "ob_indexed_block"
+}
+
+// 索引变更类型
+const (
+	IndexedChangeOrderCreate = 1 // 新建订单
+	IndexedChangeOrderUpdate = 2 // 订单状态/剩余数量/taker 变更
+	IndexedChangeItemOwner   = 3 // NFT 所有者变更
+	IndexedChangeItemCreate  = 4 // 新建 NFT (Mint)
+	IndexedChangeApproval    = 5 // NFT 授权变更
+	IndexedChangeBalance     = 6 // ERC1155 持有数量变更
+)
+
+// IndexedChange 索引过程中对订单与 NFT 所有权的变更记录, 链重组时按记录逆序回滚
+type IndexedChange struct {
+	Id                    int64  `json:"id" gorm:"primaryKey;autoIncrement;column:id;comment:主键"`
+	ChainId               int    `json:"chain_id" gorm:"column:chain_id;default:1;NOT NULL"`
+	IndexType             int32  `json:"index_type" gorm:"column:index_type;type:tinyint(4);not null;default:0"`
+	BlockNumber           int64  `json:"block_number" gorm:"column:block_number;type:bigint(20);not null"`
+	ChangeType            int    `json:"change_type" gorm:"column:change_type;type:tinyint(4);not null"`
+	OrderId               string `json:"order_id" gorm:"column:order_id;type:varchar(66);not null;default:''"`
+	CollectionAddress     string `json:"collection_address" gorm:"column:collection_address;type:varchar(42);not null;default:''"`
+	TokenId               string `json:"token_id" gorm:"column:token_id;type:varchar(128);not null;default:''"`
+	PrevOrderStatus       int    `json:"prev_order_status" gorm:"column:prev_order_status;not null;default:0"`
+	PrevQuantityRemaining int64  `json:"prev_quantity_remaining" gorm:"column:prev_quantity_remaining;not null;default:0"`
+	PrevTaker             string `json:"prev_taker" gorm:"column:prev_taker;type:varchar(42);not null;default:''"`
+	PrevOwner             string `json:"prev_owner" gorm:"column:prev_owner;type:varchar(42);not null;default:''"`
+	PrevFillable          bool   `json:"prev_fillable" gorm:"column:prev_fillable;not null"`
+	ApprovalId            int64  `json:"approval_id" gorm:"column:approval_id;not null;default:0"`
+	PrevApproved          bool   `json:"prev_approved" gorm:"column:prev_approved;not null;default:false"`
+	BalanceId             int64  `json:"balance_id" gorm:"column:balance_id;not null;default:0"`
+	PrevBalance           int64  `json:"prev_balance" gorm:"column:prev_balance;not null;default:0"`
+	CreateTime            int64  `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"`
+}
+
+func IndexedChangeTableName() string {
+	return "ob_indexed_change"
+}
+
+// IndexedEvent 已处理的链上日志, 以 (tx_hash, log_index) 去重保证重复处理同一区块范围时结果一致
+type IndexedEvent struct {
+	Id          int64  `json:"id" gorm:"primaryKey;autoIncrement;column:id;comment:主键"`
+	ChainId     int    `json:"chain_id" gorm:"column:chain_id;default:1;NOT NULL"`
+	IndexType   int32  `json:"index_type" gorm:"column:index_type;type:tinyint(4);not null;default:0"`
+	BlockNumber int64  `json:"block_number" gorm:"column:block_number;type:bigint(20);not null"`
+	TxHash      string `json:"tx_hash" gorm:"column:tx_hash;type:varchar(66);not null"`
+	LogIndex    int64  `json:"log_index" gorm:"column:log_index;not null"`
+	CreateTime  int64  `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"`
+}
+
+func IndexedEventTableName() string {
+	return "ob_indexed_event"
+}
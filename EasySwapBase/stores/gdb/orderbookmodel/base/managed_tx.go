@@ -0,0 +1,41 @@
+package base
+
+const (
+	ManagedTxStatusPending    = "pending"    // 已广播, 等待确认
+	ManagedTxStatusConfirmed  = "confirmed"  // 已上链且执行成功
+	ManagedTxStatusFailed     = "failed"     // 已上链但执行失败, 或广播失败、nonce 被其他交易占用
+	ManagedTxStatusCancelling = "cancelling" // 已发送同 nonce 的取消交易, 等待确认
+	ManagedTxStatusCancelled  = "cancelled"  // 取消交易已上链
+)
+
+// ManagedTx 交易管理器发送的交易, 按请求ID记录同一 nonce 下的加速、替换与取消
+// 请求ID在同一链、签名地址与调用方范围内唯一
+type ManagedTx struct {
+	Id             int64  `json:"id" gorm:"primaryKey;autoIncrement;column:id;comment:主键"`
+	RequestId      string `json:"request_id" gorm:"column:request_id;type:varchar(64);uniqueIndex:index_chain_signer_caller_request,priority:4;not null;comment:请求ID"`
+	Caller         string `json:"caller" gorm:"column:caller;type:varchar(64);uniqueIndex:index_chain_signer_caller_request,priority:3;not null;default:'';comment:调用方"`
+	ChainId        int64  `json:"chain_id" gorm:"column:chain_id;uniqueIndex:index_chain_signer_caller_request,priority:1;not null;comment:链ID"`
+	Signer         string `json:"signer" gorm:"column:signer;type:varchar(42);uniqueIndex:index_chain_signer_caller_request,priority:2;not null;comment:签名地址"`
+	ToAddress      string `json:"to_address" gorm:"column:to_address;type:varchar(42);not null;comment:目标地址"`
+	Nonce          uint64 `json:"nonce" gorm:"column:nonce;not null;comment:nonce"`
+	Value          string `json:"value" gorm:"column:value;type:varchar(78);not null;default:'0';comment:转账金额(wei)"`
+	Data           string `json:"data" gorm:"column:data;type:text;comment:调用数据(hex)"`
+	GasLimit       uint64 `json:"gas_limit" gorm:"column:gas_limit;not null;default:0;comment:gas limit"`
+	GasFeeCap      string `json:"gas_fee_cap" gorm:"column:gas_fee_cap;type:varchar(78);not null;default:'';comment:EIP-1559 max fee, 传统交易为 gas price"`
+	GasTipCap      string `json:"gas_tip_cap" gorm:"column:gas_tip_cap;type:varchar(78);not null;default:'';comment:EIP-1559 priority fee, 传统交易为空"`
+	TxHash         string `json:"tx_hash" gorm:"column:tx_hash;type:varchar(66);not null;default:'';comment:最新发送或已上链的交易哈希"`
+	RawTx          string `json:"-" gorm:"column:raw_tx;type:text;comment:已签名交易(hex), 重启后重新广播"`
+	ReplacedHashes string `json:"replaced_hashes" gorm:"column:replaced_hashes;type:text;comment:被替换的交易哈希, 逗号分隔"`
+	Attempts       int    `json:"attempts" gorm:"column:attempts;not null;default:0;comment:发送次数(含加速与取消)"`
+	Status         string `json:"status" gorm:"column:status;type:varchar(16);not null;comment:状态(pending/confirmed/failed/cancelling/cancelled)"`
+	ErrorMsg       string `json:"error_msg" gorm:"column:error_msg;type:varchar(1024);not null;default:'';comment:错误信息"`
+	BlockNumber    int64  `json:"block_number" gorm:"column:block_number;not null;default:0;comment:上链区块号"`
+	GasUsed        uint64 `json:"gas_used" gorm:"column:gas_used;not null;default:0;comment:消耗的gas"`
+	SentTime       int64  `json:"sent_time" gorm:"column:sent_time;not null;default:0;comment:最近一次广播时间"`
+	CreateTime     int64  `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"` // 创建时间
+	UpdateTime     int64  `json:"update_time" gorm:"column:update_time;type:bigint(20);autoUpdateTime:milli;comment:更新时间"` // 更新时间
+}
+
+func ManagedTxTableName() string {
+	return "ob_managed_tx"
+}
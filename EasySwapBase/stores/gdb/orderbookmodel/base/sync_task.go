@@ -0,0 +1,29 @@
+package base
+
+// SyncTask 后台 NFT 导入任务记录
+type SyncTask struct {
+	Id              int64  `json:"id" gorm:"primaryKey;autoIncrement;column:id;comment:主键"`
+	TaskId          string `json:"task_id" gorm:"column:task_id;type:varchar(64);uniqueIndex:index_task_id;not null;comment:任务ID"`
+	TaskType        string `json:"task_type" gorm:"column:task_type;type:varchar(16);not null;comment:任务类型(contract/token)"`
+	ChainId         int64  `json:"chain_id" gorm:"column:chain_id;not null;comment:链ID"`
+	ContractAddress string `json:"contract_address" gorm:"column:contract_address;type:varchar(42);index:index_contract_address;not null;comment:合约地址"`
+	TokenId         string `json:"token_id" gorm:"column:token_id;type:varchar(128);not null;default:'';comment:token_id(token任务)"`
+	StartBlock      int64  `json:"start_block" gorm:"column:start_block;not null;default:0;comment:起始区块"`
+	EndBlock        int64  `json:"end_block" gorm:"column:end_block;not null;default:0;comment:结束区块"`
+	Status          string `json:"status" gorm:"column:status;type:varchar(16);index:index_status;not null;comment:状态(pending/running/completed/failed/interrupted)"`
+	Progress        int    `json:"progress" gorm:"column:progress;not null;default:0;comment:进度(0-100)"`
+	TotalItems      int    `json:"total_items" gorm:"column:total_items;not null;default:0;comment:总数量"`
+	ProcessedItems  int    `json:"processed_items" gorm:"column:processed_items;not null;default:0;comment:已处理数量"`
+	FailedItems     int    `json:"failed_items" gorm:"column:failed_items;not null;default:0;comment:失败数量"`
+	ErrorMsg        string `json:"error_msg" gorm:"column:error_msg;type:varchar(1024);not null;default:'';comment:错误信息"`
+	StartedTime     int64  `json:"started_time" gorm:"column:started_time;not null;default:0;comment:开始时间"`
+	CompletedTime   int64  `json:"completed_time" gorm:"column:completed_time;not null;default:0;comment:结束时间"`
+	Owner           string `json:"owner" gorm:"column:owner;type:varchar(128);not null;default:'';comment:执行任务的服务实例"`
+	LeaseExpireTime int64  `json:"lease_expire_time" gorm:"column:lease_expire_time;not null;default:0;comment:租约到期时间"`
+	CreateTime      int64  `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"` // 创建时间
+	UpdateTime      int64  `json:"update_time" gorm:"column:update_time;type:bigint(20);autoUpdateTime:milli;comment:更新时间"` // 更新时间
+}
+
+func SyncTaskTableName() string {
+	return "ob_sync_task"
+}
@@ -0,0 +1,47 @@
+package base
+
+const (
+	WebhookDeliveryStatusPending   = "pending"   // 等待投递或等待重试
+	WebhookDeliveryStatusSucceeded = "succeeded" // 对方返回 2xx
+	WebhookDeliveryStatusDead      = "dead"      // 超过最大重试次数, 进入死信, 可手动重新投递
+)
+
+// WebhookSubscription 外部系统订阅的市场事件推送
+type WebhookSubscription struct {
+	Id          int64  `json:"id" gorm:"primaryKey;autoIncrement;column:id;comment:主键"`
+	Name        string `json:"name" gorm:"column:name;type:varchar(128);not null;default:'';comment:名称"`
+	Url         string `json:"url" gorm:"column:url;type:varchar(512);not null;comment:推送地址"`
+	Secret      string `json:"-" gorm:"column:secret;type:varchar(128);not null;comment:HMAC签名密钥"`
+	EventTypes  string `json:"event_types" gorm:"column:event_types;type:varchar(256);not null;default:'';comment:事件类型, 逗号分隔, 为空时订阅全部"`
+	ChainId     int64  `json:"chain_id" gorm:"column:chain_id;not null;default:0;comment:链ID, 0表示全部链"`
+	Collections string `json:"collections" gorm:"column:collections;type:text;comment:合集地址, 逗号分隔, 为空时订阅全部"`
+	Enabled     bool   `json:"enabled" gorm:"column:enabled;not null;comment:是否启用"`
+	CreatedBy   string `json:"created_by" gorm:"column:created_by;type:varchar(42);not null;default:'';comment:创建人"`
+	CreateTime  int64  `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"` // 创建时间
+	UpdateTime  int64  `json:"update_time" gorm:"column:update_time;type:bigint(20);autoUpdateTime:milli;comment:更新时间"` // 更新时间
+}
+
+func WebhookSubscriptionTableName() string {
+	return "ob_webhook_subscription"
+}
+
+// WebhookDelivery 单个事件对单个订阅的投递记录, 同时作为重试队列与死信存储
+type WebhookDelivery struct {
+	Id             int64  `json:"id" gorm:"primaryKey;autoIncrement;column:id;comment:主键"`
+	SubscriptionId int64  `json:"subscription_id" gorm:"column:subscription_id;not null;uniqueIndex:index_subscription_event,priority:1;comment:订阅ID"`
+	EventId        string `json:"event_id" gorm:"column:event_id;type:varchar(96);not null;uniqueIndex:index_subscription_event,priority:2;comment:事件ID(<chain>:<编号>)"`
+	EventType      string `json:"event_type" gorm:"column:event_type;type:varchar(32);not null;comment:事件类型"`
+	Payload        string `json:"payload" gorm:"column:payload;type:text;comment:推送内容"`
+	Status         string `json:"status" gorm:"column:status;type:varchar(16);not null;comment:状态(pending/succeeded/dead)"`
+	Attempts       int    `json:"attempts" gorm:"column:attempts;not null;default:0;comment:已投递次数"`
+	ResponseStatus int    `json:"response_status" gorm:"column:response_status;not null;default:0;comment:最近一次响应状态码"`
+	ErrorMsg       string `json:"error_msg" gorm:"column:error_msg;type:varchar(1024);not null;default:'';comment:最近一次错误信息"`
+	NextRetryTime  int64  `json:"next_retry_time" gorm:"column:next_retry_time;not null;default:0;comment:下次投递时间(毫秒)"`
+	DeliveredTime  int64  `json:"delivered_time" gorm:"column:delivered_time;not null;default:0;comment:投递成功时间(毫秒)"`
+	CreateTime     int64  `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"` // 创建时间
+	UpdateTime     int64  `json:"update_time" gorm:"column:update_time;type:bigint(20);autoUpdateTime:milli;comment:更新时间"` // 更新时间
+}
+
+func WebhookDeliveryTableName() string {
+	return "ob_webhook_delivery"
+}
@@ -24,6 +24,12 @@ const (
 	OverviewError   = 2
 )
 
+// 合约实现标准 (token_standard)
+const (
+	TokenStandardERC721  = 721
+	TokenStandardERC1155 = 1155
+)
+
 const (
 	NotSyncHistorySale     = 0
 	AlreadySyncHistorySale = 1
@@ -0,0 +1,22 @@
+package multi
+
+import (
+	"fmt"
+)
+
+// ItemBalance ERC1155 NFT 各持有人的持有数量, 由 TransferSingle/TransferBatch 与成交事件维护
+// ERC1155 NFT 没有唯一的所有者, item 的 owner 为空
+type ItemBalance struct {
+	Id                int64  `gorm:"column:id;AUTO_INCREMENT;primary_key" json:"id"`                                          // 主键
+	CollectionAddress string `gorm:"column:collection_address;NOT NULL" json:"collection_address"`                            // 合约地址
+	TokenId           string `gorm:"column:token_id;NOT NULL" json:"token_id"`                                                // token_id
+	Owner             string `gorm:"column:owner;NOT NULL" json:"owner"`                                                      // 持有人
+	Balance           int64  `gorm:"column:balance;NOT NULL" json:"balance"`                                                  // 持有数量
+	BlockNumber       int64  `gorm:"column:block_number;NOT NULL" json:"block_number"`                                        // 最近一次变更的区块号
+	CreateTime        int64  `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"` // 创建时间
+	UpdateTime        int64  `json:"update_time" gorm:"column:update_time;type:bigint(20);autoUpdateTime:milli;comment:更新时间"` // 更新时间
+}
+
+func ItemBalanceTableName(chainName string) string {
+	return fmt.Sprintf("ob_item_balance_%s", chainName)
+}
@@ -0,0 +1,23 @@
+package multi
+
+import (
+	"fmt"
+)
+
+// NftApproval NFT 授权状态, 由 ERC721 Approval/ApprovalForAll 事件维护
+// ApprovalForAll 的 token_id 为空; 单个 token 的授权同一时间只有一条 approved 为 true
+type NftApproval struct {
+	Id                int64  `gorm:"column:id;AUTO_INCREMENT;primary_key" json:"id"`                                          // 主键
+	CollectionAddress string `gorm:"column:collection_address;NOT NULL" json:"collection_address"`                            // 合约地址
+	TokenId           string `gorm:"column:token_id;NOT NULL" json:"token_id"`                                                // token_id, ApprovalForAll 为空
+	Owner             string `gorm:"column:owner;NOT NULL" json:"owner"`                                                      // 授权人
+	Operator          string `gorm:"column:operator;NOT NULL" json:"operator"`                                                // 被授权地址
+	Approved          bool   `gorm:"column:approved;NOT NULL" json:"approved"`                                                // 是否有效
+	BlockNumber       int64  `gorm:"column:block_number;NOT NULL" json:"block_number"`                                        // 最近一次变更的区块号
+	CreateTime        int64  `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"` // 创建时间
+	UpdateTime        int64  `json:"update_time" gorm:"column:update_time;type:bigint(20);autoUpdateTime:milli;comment:更新时间"` // 更新时间
+}
+
+func NftApprovalTableName(chainName string) string {
+	return fmt.Sprintf("ob_nft_approval_%s", chainName)
+}
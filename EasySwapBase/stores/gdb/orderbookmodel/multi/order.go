@@ -49,6 +49,7 @@ type Order struct {
 	// 1: listing 2:offer 3:collection bid 4:item bid
 	OrderType  int64 `gorm:"column:order_type" json:"order_type"`
 	Salt       int64 `gorm:"column:salt" json:"salt"`
+	Fillable   bool  `gorm:"column:fillable;NOT NULL" json:"fillable"`                                                // 挂单人撤销 vault 授权后为 false
 	CreateTime int64 `json:"create_time" gorm:"column:create_time;type:bigint(20);autoCreateTime:milli;comment:创建时间"` // 创建时间
 	UpdateTime int64 `json:"update_time" gorm:"column:update_time;type:bigint(20);autoUpdateTime:milli;comment:更新时间"` // 更新时间
 }
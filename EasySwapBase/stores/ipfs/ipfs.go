@@ -0,0 +1,156 @@
+package ipfs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// URIPrefix 内容寻址的 token URI 前缀, nftchainservice 解析元数据时通过网关读取
+	URIPrefix = "ipfs://"
+
+	defaultTimeout = 5 * time.Minute // 上传视频等大文件需要较长时间
+)
+
+// Config Kubo HTTP API 配置
+type Config struct {
+	APIURL  string            // Kubo RPC 地址, 如 http://127.0.0.1:5001
+	Headers map[string]string // 附加请求头, 如托管节点的鉴权信息
+	Timeout time.Duration
+}
+
+// Client 通过 Kubo HTTP API 上传并固定(pin)内容
+type Client struct {
+	apiURL     string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+// AddResult 上传结果
+type AddResult struct {
+	Name string `json:"Name"`
+	CID  string `json:"Hash"`
+	Size string `json:"Size"`
+}
+
+// URI 返回内容的 ipfs:// 地址
+func (r *AddResult) URI() string {
+	return URIPrefix + r.CID
+}
+
+// apiError Kubo 接口错误响应
+type apiError struct {
+	Message string `json:"Message"`
+	Code    int    `json:"Code"`
+}
+
+// NewClient 创建 Kubo HTTP API 客户端
+func NewClient(conf Config) (*Client, error) {
+	apiURL, err := url.Parse(conf.APIURL)
+	if err != nil || apiURL.Scheme == "" || apiURL.Host == "" {
+		return nil, errors.Errorf("invalid ipfs api url %q", conf.APIURL)
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultTimeout
+	}
+
+	return &Client{
+		apiURL:     strings.TrimRight(conf.APIURL, "/"),
+		headers:    conf.Headers,
+		httpClient: &http.Client{Timeout: conf.Timeout},
+	}, nil
+}
+
+// Add 上传内容并固定, 使用 CIDv1 以便与子域名网关兼容
+func (c *Client) Add(ctx context.Context, name string, content io.Reader) (*AddResult, error) {
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		part, err := form.CreateFormFile("file", name)
+		if err == nil {
+			_, err = io.Copy(part, content)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	query := url.Values{}
+	query.Set("pin", "true")
+	query.Set("cid-version", "1")
+	resp, err := c.call(ctx, "add", query, body, form.FormDataContentType())
+	if err != nil {
+		body.CloseWithError(err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 响应为逐行的 JSON, 上传单个文件时只有一行
+	var result AddResult
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			return nil, errors.Wrap(err, "failed on decode ipfs add response")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed on read ipfs add response")
+	}
+	if result.CID == "" {
+		return nil, errors.New("ipfs add response missing cid")
+	}
+
+	return &result, nil
+}
+
+// Pin 固定已存在的内容
+func (c *Client) Pin(ctx context.Context, cid string) error {
+	query := url.Values{}
+	query.Set("arg", cid)
+	resp, err := c.call(ctx, "pin/add", query, nil, "")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// call 调用 Kubo RPC 接口, Kubo 的所有接口均使用 POST
+func (c *Client) call(ctx context.Context, method string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"/api/v0/"+method+"?"+query.Encode(), body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on create ipfs request")
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed on call ipfs %s", method)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var apiErr apiError
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Message != "" {
+			return nil, errors.Errorf("ipfs %s failed: %s", method, apiErr.Message)
+		}
+		return nil, errors.Errorf("ipfs %s failed with status %d", method, resp.StatusCode)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,84 @@
+package ipfs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubKubo 模拟 Kubo RPC 的 add 与 pin/add 接口
+type stubKubo struct {
+	mu      sync.Mutex
+	content map[string][]byte
+	pinned  map[string]bool
+}
+
+func (s *stubKubo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Basic test" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.URL.Path {
+	case "/api/v0/add":
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		sum := sha256.Sum256(data)
+		cid := "bafy" + hex.EncodeToString(sum[:8])
+		s.content[cid] = data
+		s.pinned[cid] = r.URL.Query().Get("pin") == "true"
+		json.NewEncoder(w).Encode(AddResult{Name: header.Filename, CID: cid, Size: "1"})
+	case "/api/v0/pin/add":
+		cid := r.URL.Query().Get("arg")
+		if _, ok := s.content[cid]; !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(apiError{Message: "invalid path " + cid, Code: 0})
+			return
+		}
+		s.pinned[cid] = true
+		json.NewEncoder(w).Encode(map[string][]string{"Pins": {cid}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	stub := &stubKubo{content: make(map[string][]byte), pinned: make(map[string]bool)}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	c, err := NewClient(Config{APIURL: server.URL + "/", Headers: map[string]string{"Authorization": "Basic test"}})
+	require.NoError(t, err)
+
+	result, err := c.Add(ctx, "image.png", strings.NewReader("fake png content"))
+	require.NoError(t, err)
+	assert.Equal(t, "image.png", result.Name)
+	assert.Equal(t, URIPrefix+result.CID, result.URI())
+	assert.Equal(t, []byte("fake png content"), stub.content[result.CID])
+	assert.True(t, stub.pinned[result.CID])
+
+	require.NoError(t, c.Pin(ctx, result.CID))
+	err = c.Pin(ctx, "bafyunknown")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid path bafyunknown")
+
+	_, err = NewClient(Config{APIURL: "127.0.0.1:5001"})
+	assert.Error(t, err)
+}
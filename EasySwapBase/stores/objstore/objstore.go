@@ -0,0 +1,53 @@
+package objstore
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrObjectNotFound 对象不存在
+var ErrObjectNotFound = errors.New("object not found")
+
+// Storage 对象存储接口, 客户端通过预签名请求直接上传, 服务端只持有长期密钥用于签名与校验
+type Storage interface {
+	// PresignPut 生成上传指定对象的预签名 PUT 请求, 上传时必须携带返回的请求头
+	PresignPut(ctx context.Context, key, contentType string, contentLength int64, expires time.Duration) (*PresignedRequest, error)
+	// PresignPost 生成浏览器表单上传策略, 只允许上传内容类型为 contentType 且大小不超过 maxSize 的指定对象
+	PresignPost(ctx context.Context, key, contentType string, maxSize int64, expires time.Duration) (*PostPolicy, error)
+	// Stat 获取对象信息, 对象不存在时返回 ErrObjectNotFound
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Get 读取对象内容, 调用方负责关闭返回的 ReadCloser, 对象不存在时返回 ErrObjectNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Delete 删除对象, 对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// ObjectURL 对象的访问地址
+	ObjectURL(key string) string
+}
+
+// PresignedRequest 预签名请求
+type PresignedRequest struct {
+	Method     string
+	URL        string
+	Headers    map[string]string // 上传时必须携带的请求头
+	Expiration time.Time
+}
+
+// PostPolicy 表单上传策略, Fields(含 key 与 Content-Type)作为表单字段与文件一起提交到 URL
+type PostPolicy struct {
+	URL        string
+	Key        string
+	Fields     map[string]string
+	Expiration time.Time
+}
+
+// ObjectInfo 对象信息
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
@@ -0,0 +1,287 @@
+package objstore
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+	maxPresignTime = 7 * 24 * time.Hour // SigV4 预签名最长有效期
+)
+
+// S3Config S3 兼容存储配置, 适用于腾讯云 COS、AWS S3 与 MinIO
+type S3Config struct {
+	Endpoint  string // 服务地址, 如 https://cos.ap-beijing.myqcloud.com 或 http://127.0.0.1:9000
+	Region    string
+	Bucket    string // COS 为 <bucket>-<appid>
+	AccessKey string // COS 为 SecretID
+	SecretKey string // COS 为 SecretKey
+	PathStyle bool   // 使用 endpoint/bucket/key 形式访问, MinIO 需开启; 否则为 bucket.endpoint/key
+	PublicURL string // 对象访问地址前缀, 如自定义 CDN 域名, 为空时使用存储桶地址
+	Timeout   time.Duration
+}
+
+// S3 基于 SigV4 签名的 S3 兼容存储
+type S3 struct {
+	conf       S3Config
+	base       *url.URL // 存储桶地址
+	cred       credential
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewS3 创建 S3 兼容存储
+func NewS3(conf S3Config) (*S3, error) {
+	if conf.Bucket == "" || conf.AccessKey == "" || conf.SecretKey == "" {
+		return nil, errors.New("bucket, access key and secret key are required")
+	}
+	if conf.Region == "" {
+		return nil, errors.New("region is required")
+	}
+	endpoint, err := url.Parse(conf.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, errors.Errorf("invalid endpoint %q", conf.Endpoint)
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultTimeout
+	}
+
+	base := &url.URL{Scheme: endpoint.Scheme, Host: endpoint.Host, Path: "/"}
+	if conf.PathStyle {
+		base.Path = "/" + conf.Bucket + "/"
+	} else {
+		base.Host = conf.Bucket + "." + endpoint.Host
+	}
+
+	return &S3{
+		conf: conf,
+		base: base,
+		cred: credential{
+			accessKey: conf.AccessKey,
+			secretKey: conf.SecretKey,
+			region:    conf.Region,
+		},
+		httpClient: &http.Client{Timeout: conf.Timeout},
+		now:        time.Now,
+	}, nil
+}
+
+func (s *S3) objectPath(key string) string {
+	return s.base.Path + strings.TrimPrefix(key, "/")
+}
+
+func (s *S3) objectURL(key string, query url.Values) string {
+	u := url.URL{Scheme: s.base.Scheme, Host: s.base.Host, Path: s.objectPath(key), RawQuery: canonicalQuery(query)}
+	u.RawPath = escapePath(u.Path)
+	return u.String()
+}
+
+// ObjectURL 对象的访问地址, 配置了 PublicURL 时使用该前缀
+func (s *S3) ObjectURL(key string) string {
+	if s.conf.PublicURL != "" {
+		return strings.TrimRight(s.conf.PublicURL, "/") + "/" + escapePath(strings.TrimPrefix(key, "/"))
+	}
+
+	return s.objectURL(key, nil)
+}
+
+// PresignPut 生成预签名 PUT 请求, Content-Type 与 Content-Length 参与签名, 上传内容与申请时不一致会被拒绝
+func (s *S3) PresignPut(ctx context.Context, key, contentType string, contentLength int64, expires time.Duration) (*PresignedRequest, error) {
+	if key == "" {
+		return nil, errors.New("object key is required")
+	}
+	if expires <= 0 || expires > maxPresignTime {
+		return nil, errors.Errorf("invalid presign expiration %s", expires)
+	}
+
+	now := s.now().UTC()
+	headers := map[string]string{"host": s.base.Host}
+	if contentType != "" {
+		headers["content-type"] = contentType
+	}
+	if contentLength > 0 {
+		headers["content-length"] = strconv.FormatInt(contentLength, 10)
+	}
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", signAlgorithm)
+	query.Set("X-Amz-Credential", s.cred.accessKey+"/"+s.cred.scope(now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", signedHeaderNames(headers))
+	query.Set("X-Amz-Signature", s.cred.signRequest(now, http.MethodPut, s.objectPath(key), query, headers, unsignedPayload))
+
+	reqHeaders := make(map[string]string)
+	if contentType != "" {
+		reqHeaders["Content-Type"] = contentType
+	}
+	if contentLength > 0 {
+		reqHeaders["Content-Length"] = strconv.FormatInt(contentLength, 10)
+	}
+
+	return &PresignedRequest{
+		Method:     http.MethodPut,
+		URL:        s.objectURL(key, query),
+		Headers:    reqHeaders,
+		Expiration: now.Add(expires),
+	}, nil
+}
+
+// PresignPost 生成表单上传策略, 策略中限制存储桶、key、Content-Type 与文件大小, 与 PresignPut 一样只允许写入指定对象
+func (s *S3) PresignPost(ctx context.Context, key, contentType string, maxSize int64, expires time.Duration) (*PostPolicy, error) {
+	if key == "" {
+		return nil, errors.New("object key is required")
+	}
+	if contentType == "" {
+		return nil, errors.New("content type is required")
+	}
+	if maxSize <= 0 {
+		return nil, errors.New("max size must be positive")
+	}
+	if expires <= 0 || expires > maxPresignTime {
+		return nil, errors.Errorf("invalid presign expiration %s", expires)
+	}
+
+	now := s.now().UTC()
+	expiration := now.Add(expires)
+	fields := map[string]string{
+		"x-amz-algorithm":  signAlgorithm,
+		"x-amz-credential": s.cred.accessKey + "/" + s.cred.scope(now),
+		"x-amz-date":       now.Format(amzDateFormat),
+		"key":              key,
+		"Content-Type":     contentType,
+	}
+	policy, err := json.Marshal(map[string]interface{}{
+		"expiration": expiration.Format("2006-01-02T15:04:05.000Z"),
+		"conditions": []interface{}{
+			map[string]string{"bucket": s.conf.Bucket},
+			[]interface{}{"eq", "$key", key},
+			[]interface{}{"eq", "$Content-Type", contentType},
+			[]interface{}{"content-length-range", 1, maxSize},
+			map[string]string{"x-amz-algorithm": fields["x-amz-algorithm"]},
+			map[string]string{"x-amz-credential": fields["x-amz-credential"]},
+			map[string]string{"x-amz-date": fields["x-amz-date"]},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on marshal post policy")
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(policy)
+	fields["policy"] = encoded
+	fields["x-amz-signature"] = s.cred.sign(now, encoded)
+
+	return &PostPolicy{
+		URL:        s.objectURL("", nil),
+		Key:        key,
+		Fields:     fields,
+		Expiration: expiration,
+	}, nil
+}
+
+// Stat 通过 HEAD 请求获取对象信息
+func (s *S3) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodHead, key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return objectInfo(key, resp)
+}
+
+// Get 通过 GET 请求读取对象内容
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodGet, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := objectInfo(key, resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, nil, err
+	}
+
+	return resp.Body, info, nil
+}
+
+// objectInfo 从 HEAD/GET 响应头解析对象信息
+func objectInfo(key string, resp *http.Response) (*ObjectInfo, error) {
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrObjectNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("%s object %s failed with status %d", strings.ToLower(resp.Request.Method), key, resp.StatusCode)
+	}
+
+	info := &ObjectInfo{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = lastModified
+	}
+
+	return info, nil
+}
+
+// Delete 删除对象
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return errors.Errorf("delete object %s failed with status %d", key, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// do 发送使用 Authorization 头签名的无请求体请求
+func (s *S3) do(ctx context.Context, method, key string) (*http.Response, error) {
+	if key == "" {
+		return nil, errors.New("object key is required")
+	}
+
+	now := s.now().UTC()
+	headers := map[string]string{
+		"host":                 s.base.Host,
+		"x-amz-content-sha256": emptyPayloadHash,
+		"x-amz-date":           now.Format(amzDateFormat),
+	}
+	signature := s.cred.signRequest(now, method, s.objectPath(key), nil, headers, emptyPayloadHash)
+
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key, nil), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on create object storage request")
+	}
+	req.Header.Set("x-amz-content-sha256", headers["x-amz-content-sha256"])
+	req.Header.Set("x-amz-date", headers["x-amz-date"])
+	req.Header.Set("Authorization", signAlgorithm+
+		" Credential="+s.cred.accessKey+"/"+s.cred.scope(now)+
+		", SignedHeaders="+signedHeaderNames(headers)+
+		", Signature="+signature)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed on %s object %s", strings.ToLower(method), key)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,47 @@
+package tradestats
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// EpochSeconds 成交统计周期, ob_collection_trade_* 中的 epoch_number 为 event_time / EpochSeconds
+const EpochSeconds = 300
+
+// PeriodEpochs 排行榜统计区间对应的周期数
+var PeriodEpochs = map[string]int{
+	"15m": 3,
+	"1h":  12,
+	"6h":  72,
+	"24h": 288,
+	"1d":  288,
+	"7d":  2016,
+	"30d": 8640,
+}
+
+// RankingItem 排行榜缓存中单个合集的成交统计, 价格均以 wei 为单位
+type RankingItem struct {
+	ContractAddress string          `json:"contract_address"`
+	ItemCount       int64           `json:"item_count"`
+	Volume          decimal.Decimal `json:"volume"`
+	VolumeChange    int             `json:"volume_change"` // 相对上一个同长度区间的成交额变化百分比
+	PreFloorPrice   decimal.Decimal `json:"pre_floor_price"`
+	FloorChange     int             `json:"floor_change"` // 相对上一个同长度区间的地板价变化百分比
+}
+
+// CollectionVolume 合集累计成交额缓存
+type CollectionVolume struct {
+	Volume decimal.Decimal `json:"volume"`
+}
+
+// RankingKey 排行榜缓存键, epochs 为统计区间的周期数, 内容为按成交额降序的 []RankingItem
+func RankingKey(project, chain string, epochs int) string {
+	return fmt.Sprintf("cache:%s:%s:ranking:volume:%d", strings.ToLower(project), strings.ToLower(chain), epochs)
+}
+
+// CollectionVolumeKey 合集累计成交额缓存键, 内容为 CollectionVolume
+func CollectionVolumeKey(project, chain, collectionAddr string) string {
+	return fmt.Sprintf("cache:%s:%s:collection:%s:trade", strings.ToLower(project), strings.ToLower(chain), strings.ToLower(collectionAddr))
+}
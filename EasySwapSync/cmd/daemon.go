@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	_ "net/http/pprof"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
 	"github.com/spf13/cobra"
+	"github.com/zeromicro/go-zero/core/prometheus"
 	"go.uber.org/zap"
 
 	"github.com/ProjectsTask/EasySwapSync/service"
@@ -63,6 +65,18 @@ var DaemonCmd = &cobra.Command{
 				return
 			}
 
+			if cfg.Monitor.PrometheusEnable { // 暴露 Prometheus 指标，包含 RPC 节点健康状态
+				prometheus.StartAgent(prometheus.Config{
+					Host: "0.0.0.0",
+					Port: cfg.Monitor.PrometheusPort,
+					Path: "/metrics",
+				})
+			}
+
+			if cfg.Monitor.StatusEnable { // 独立端口提供 /status 查询每条链的同步状态, /metadata-refresh 查询元数据刷新统计
+				go serveStatus(ctx, s, cfg.Monitor.StatusPort)
+			}
+
 			if cfg.Monitor.PprofEnable { // 开启pprof，用于性能监控
 				http.ListenAndServe(fmt.Sprintf("0.0.0.0:%d", cfg.Monitor.PprofPort), nil)
 			}
@@ -87,6 +101,28 @@ var DaemonCmd = &cobra.Command{
 	},
 }
 
+// serveStatus 在独立端口提供同步状态查询, 不依赖 pprof 是否开启
+func serveStatus(ctx context.Context, s *service.Service, port int) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.Status())
+	})
+	mux.HandleFunc("/metadata-refresh", func(w http.ResponseWriter, r *http.Request) {
+		stats, err := s.MetadataRefreshStats()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(stats)
+	})
+
+	if err := http.ListenAndServe(fmt.Sprintf("0.0.0.0:%d", port), mux); err != nil {
+		xzap.WithContext(ctx).Error("Failed to serve status", zap.Error(err), zap.Int("port", port))
+	}
+}
+
 func init() {
 	// 将api初始化命令添加到主命令中
 	rootCmd.AddCommand(DaemonCmd)
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ProjectsTask/EasySwapSync/db/migrations"
+	"github.com/ProjectsTask/EasySwapSync/model"
+	"github.com/ProjectsTask/EasySwapSync/service/config"
+)
+
+var (
+	migrateChain string // 只迁移指定的链, global 表示只迁移全局表, 为空时迁移全局表与配置中的所有链
+	migrateSteps int    // 执行或回滚的版本数
+)
+
+var MigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "migrate database schema.",
+	Long:  "migrate global tables and per-chain ob_*_<chain> tables for all configured chains.",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "apply pending migrations.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		m, scopes, err := newMigrator()
+		if err != nil {
+			return err
+		}
+
+		for _, scope := range scopes {
+			versions, err := m.Up(ctx, scope, migrateSteps)
+			for _, version := range versions {
+				fmt.Printf("%s: applied %d\n", scope, version)
+			}
+			if err != nil {
+				return err
+			}
+			if len(versions) == 0 {
+				fmt.Printf("%s: no pending migration\n", scope)
+			}
+		}
+
+		return nil
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "roll back applied migrations of one scope.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 回滚会删除数据表, 必须明确指定 scope
+		if migrateChain == "" {
+			return errors.New("--chain is required for down, use --chain global to roll back global tables")
+		}
+		steps := migrateSteps
+		if steps <= 0 {
+			steps = 1
+		}
+
+		ctx := context.Background()
+		m, scopes, err := newMigrator()
+		if err != nil {
+			return err
+		}
+
+		versions, err := m.Down(ctx, scopes[0], steps)
+		for _, version := range versions {
+			fmt.Printf("%s: rolled back %d\n", scopes[0], version)
+		}
+
+		return err
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show migration status.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		m, scopes, err := newMigrator()
+		if err != nil {
+			return err
+		}
+
+		for _, scope := range scopes {
+			statuses, err := m.Status(ctx, scope)
+			if err != nil {
+				return err
+			}
+			for _, status := range statuses {
+				applied := "pending"
+				if status.Applied {
+					applied = time.Unix(status.AppliedTime, 0).Format(time.RFC3339)
+				}
+				fmt.Printf("%s\t%06d_%s\t%s\n", scope, status.Version, status.Name, applied)
+			}
+		}
+
+		return nil
+	},
+}
+
+// newMigrator 连接数据库并返回需要迁移的 scope 列表
+func newMigrator() (*migrations.Migrator, []string, error) {
+	cfg, err := config.UnmarshalCmdConfig()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed on unmarshal config")
+	}
+
+	scopes := []string{migrateChain}
+	if migrateChain == "" {
+		scopes = []string{migrations.GlobalScope}
+		for _, chainCfg := range cfg.ChainList() {
+			scopes = append(scopes, chainCfg.Name)
+		}
+	}
+
+	m, err := migrations.New(model.NewDB(cfg.DB))
+	if err != nil {
+		return nil, nil, err
+	}
+	chains := make([]migrations.Chain, 0, len(cfg.ChainList()))
+	for _, chainCfg := range cfg.ChainList() {
+		chains = append(chains, migrations.Chain{Name: chainCfg.Name, ID: chainCfg.ID, StartBlock: chainCfg.StartBlock})
+	}
+	m.SetChains(chains)
+
+	return m, scopes, nil
+}
+
+func init() {
+	flags := MigrateCmd.PersistentFlags()
+	flags.StringVar(&migrateChain, "chain", "", "chain name to migrate, global for global tables (default global and all configured chains)")
+	flags.IntVar(&migrateSteps, "steps", 0, "number of versions to apply or roll back (default all for up, 1 for down)")
+
+	MigrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd)
+	rootCmd.AddCommand(MigrateCmd)
+}
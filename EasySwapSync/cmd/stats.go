@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/zeromicro/go-zero/core/stores/cache"
+	"github.com/zeromicro/go-zero/core/stores/kv"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"gorm.io/gorm"
+
+	"github.com/ProjectsTask/EasySwapSync/model"
+	"github.com/ProjectsTask/EasySwapSync/service/collectiontrade"
+	"github.com/ProjectsTask/EasySwapSync/service/config"
+)
+
+const statsDateLayout = "2006-01-02"
+
+var (
+	statsChain string // 只处理指定的链, 为空时处理配置中的所有链
+	statsFrom  string // 开始日期(UTC), 为空时从最早的成交开始
+	statsTo    string // 结束日期(UTC, 不含), 为空时到当前时间
+)
+
+var StatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "maintain collection trade statistics.",
+	Long:  "aggregate sales into ob_collection_trade_<chain> and refresh ranking and volume caches.",
+}
+
+var statsBackfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "rebuild collection trade statistics from history sales.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.UnmarshalCmdConfig()
+		if err != nil {
+			return errors.Wrap(err, "failed on unmarshal config")
+		}
+
+		to := time.Now().Unix()
+		if statsTo != "" {
+			t, err := time.Parse(statsDateLayout, statsTo)
+			if err != nil {
+				return errors.Wrap(err, "invalid --to")
+			}
+			to = t.Unix()
+		}
+
+		var kvConf kv.KvConf
+		for _, con := range cfg.Kv.Redis {
+			kvConf = append(kvConf, cache.NodeConf{
+				RedisConf: redis.RedisConf{
+					Host: con.Host,
+					Type: con.Type,
+					Pass: con.Pass,
+				},
+				Weight: 10,
+			})
+		}
+		kvStore := xkv.NewStore(kvConf)
+		db := model.NewDB(cfg.DB)
+		ctx := context.Background()
+
+		for _, chainCfg := range cfg.ChainList() {
+			if statsChain != "" && chainCfg.Name != statsChain {
+				continue
+			}
+
+			from, err := backfillFrom(ctx, db, cfg.ProjectCfg.Name, chainCfg.Name)
+			if err != nil {
+				return err
+			}
+			if from >= to {
+				fmt.Printf("%s: no sales to backfill\n", chainCfg.Name)
+				continue
+			}
+
+			aggregator := collectiontrade.New(ctx, db, kvStore, chainCfg.Name, cfg.ProjectCfg.Name, collectiontrade.Config{})
+			if err := aggregator.Backfill(from, to); err != nil {
+				return errors.Wrapf(err, "failed on backfill chain %s", chainCfg.Name)
+			}
+			fmt.Printf("%s: backfilled %s - %s\n", chainCfg.Name,
+				time.Unix(from, 0).UTC().Format(time.RFC3339), time.Unix(to, 0).UTC().Format(time.RFC3339))
+		}
+
+		return nil
+	},
+}
+
+// backfillFrom 返回回填的开始时间, 未指定 --from 时使用该链最早的成交时间
+func backfillFrom(ctx context.Context, db *gorm.DB, project, chain string) (int64, error) {
+	if statsFrom != "" {
+		t, err := time.Parse(statsDateLayout, statsFrom)
+		if err != nil {
+			return 0, errors.Wrap(err, "invalid --from")
+		}
+		return t.Unix(), nil
+	}
+
+	var earliest *int64
+	if err := db.WithContext(ctx).Table(gdb.GetMultiProjectActivityTableName(project, chain)).
+		Select("min(event_time)").
+		Where("activity_type = ?", multi.Sale).
+		Row().Scan(&earliest); err != nil {
+		return 0, errors.Wrap(err, "failed on query earliest sale")
+	}
+	if earliest == nil {
+		return time.Now().Unix(), nil
+	}
+
+	return *earliest, nil
+}
+
+func init() {
+	flags := StatsCmd.PersistentFlags()
+	flags.StringVar(&statsChain, "chain", "", "chain name to process (default all configured chains)")
+	statsBackfillCmd.Flags().StringVar(&statsFrom, "from", "", "start date in UTC, YYYY-MM-DD (default earliest sale)")
+	statsBackfillCmd.Flags().StringVar(&statsTo, "to", "", "end date in UTC, YYYY-MM-DD, exclusive (default now)")
+
+	StatsCmd.AddCommand(statsBackfillCmd)
+	rootCmd.AddCommand(StatsCmd)
+}
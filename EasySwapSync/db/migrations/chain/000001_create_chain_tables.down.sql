@@ -0,0 +1,17 @@
+drop table if exists ob_order_{{chain}};
+
+drop table if exists ob_item_trait_{{chain}};
+
+drop table if exists ob_item_external_{{chain}};
+
+drop table if exists ob_item_{{chain}};
+
+drop table if exists ob_global_collection_{{chain}};
+
+drop table if exists ob_collection_import_record_{{chain}};
+
+drop table if exists ob_collection_floor_price_{{chain}};
+
+drop table if exists ob_collection_{{chain}};
+
+drop table if exists ob_activity_{{chain}};
@@ -1,4 +1,5 @@
-create table ob_activity_sepolia
+-- 使用 if not exists 建表, 已按旧版建表 SQL 创建的数据库执行后仅记录版本
+create table if not exists ob_activity_{{chain}}
 (
     id                 bigint auto_increment comment '主键'
         primary key,
@@ -18,11 +19,14 @@ create table ob_activity_sepolia
     create_time        bigint                  null comment '创建时间',
     update_time        bigint                  null comment '更新时间',
     constraint index_tx_collection_token_type
-        unique (tx_hash, collection_address, token_id, activity_type)
+        unique (tx_hash, collection_address, token_id, activity_type),
+    index index_collection_token_type (collection_address, token_id, activity_type),
+    index index_hash_collection_token_type (tx_hash, collection_address, token_id, activity_type),
+    index index_tx_collection_token_type_time (tx_hash, collection_address, token_id, activity_type, event_time)
 )
     collate = utf8mb4_general_ci;
 
-create table ob_collection_sepolia
+create table if not exists ob_collection_{{chain}}
 (
     id                 bigint auto_increment comment '主键'
         primary key,
@@ -57,16 +61,7 @@ create table ob_collection_sepolia
 )
     collate = utf8mb4_general_ci;
 
-create index index_collection_token_type
-    on ob_activity_sepolia (collection_address, token_id, activity_type);
-
-create index index_hash_collection_token_type
-    on ob_activity_sepolia (tx_hash, collection_address, token_id, activity_type);
-
-create index index_tx_collection_token_type_time
-    on ob_activity_sepolia (tx_hash, collection_address, token_id, activity_type, event_time);
-
-create table ob_collection_floor_price_sepolia
+create table if not exists ob_collection_floor_price_{{chain}}
 (
     id                 bigint auto_increment comment '主键'
         primary key,
@@ -76,17 +71,13 @@ create table ob_collection_floor_price_sepolia
     create_time        bigint      null comment '创建时间',
     update_time        bigint      null comment '更新时间',
     constraint index_price
-        unique (collection_address, price, event_time)
+        unique (collection_address, price, event_time),
+    index index_collection_address (collection_address),
+    index index_event_time (event_time)
 )
     collate = utf8mb4_general_ci;
 
-create index index_collection_address
-    on ob_collection_floor_price_sepolia (collection_address);
-
-create index index_event_time
-    on ob_collection_floor_price_sepolia (event_time);
-
-create table ob_collection_import_record_sepolia
+create table if not exists ob_collection_import_record_{{chain}}
 (
     id                 bigint auto_increment comment '主键'
         primary key,
@@ -98,7 +89,7 @@ create table ob_collection_import_record_sepolia
 )
     collate = utf8mb4_general_ci;
 
-create table ob_global_collection_sepolia
+create table if not exists ob_global_collection_{{chain}}
 (
     id                 bigint auto_increment comment '主键'
         primary key,
@@ -110,7 +101,7 @@ create table ob_global_collection_sepolia
 )
     collate = utf8mb4_general_ci;
 
-create table ob_item_sepolia
+create table if not exists ob_item_{{chain}}
 (
     id                 bigint auto_increment comment '主键'
         primary key,
@@ -129,23 +120,15 @@ create table ob_item_sepolia
     create_time        bigint                                     null comment '创建时间',
     update_time        bigint                                     null comment '更新时间',
     constraint index_collection_token
-        unique (collection_address, token_id)
+        unique (collection_address, token_id),
+    index index_collection_item_name (collection_address, token_id, name),
+    index index_collection_owner (collection_address, owner),
+    index index_collection_token_owner (collection_address, token_id, owner),
+    index index_owner (owner)
 )
     collate = utf8mb4_general_ci;
 
-create index index_collection_item_name
-    on ob_item_sepolia (collection_address, token_id, name);
-
-create index index_collection_owner
-    on ob_item_sepolia (collection_address, owner);
-
-create index index_collection_token_owner
-    on ob_item_sepolia (collection_address, token_id, owner);
-
-create index index_owner
-    on ob_item_sepolia (owner);
-
-create table ob_item_external_sepolia
+create table if not exists ob_item_external_{{chain}}
 (
     id                  bigint auto_increment comment '主键'
         primary key,
@@ -168,7 +151,7 @@ create table ob_item_external_sepolia
 )
     collate = utf8mb4_general_ci;
 
-create table ob_item_trait_sepolia
+create table if not exists ob_item_trait_{{chain}}
 (
     id                 bigint auto_increment comment '主键'
         primary key,
@@ -177,20 +160,14 @@ create table ob_item_trait_sepolia
     trait              varchar(128) not null comment '属性名称',
     trait_value        varchar(512) not null comment '属性值',
     create_time        bigint       null comment '创建时间',
-    update_time        bigint       null comment '更新时间'
+    update_time        bigint       null comment '更新时间',
+    index index_collection_token (collection_address, token_id),
+    index index_collection_trait_value (collection_address, trait, trait_value),
+    index index_trait_value (trait, trait_value)
 )
     collate = utf8mb4_general_ci;
 
-create index index_collection_token
-    on ob_item_trait_sepolia (collection_address, token_id);
-
-create index index_collection_trait_value
-    on ob_item_trait_sepolia (collection_address, trait, trait_value);
-
-create index index_trait_value
-    on ob_item_trait_sepolia (trait, trait_value);
-
-create table ob_order_sepolia
+create table if not exists ob_order_{{chain}}
 (
     id                 bigint auto_increment comment '主键'
         primary key,
@@ -212,39 +189,8 @@ create table ob_order_sepolia
     create_time        bigint                  null comment '创建时间',
     update_time        bigint                  null comment '更新时间',
     constraint index_hash
-        unique (order_id)
+        unique (order_id),
+    index index_collection_maker_status_type_market_token_id (collection_address, maker, order_status, order_type, marketplace_id, token_id),
+    index index_collection_token (collection_address, token_id)
 )
     collate = utf8mb4_general_ci;
-
-create index index_collection_maker_status_type_market_token_id
-    on ob_order_sepolia (collection_address, maker, order_status, order_type, marketplace_id, token_id);
-
-create index index_collection_token
-    on ob_order_sepolia (collection_address, token_id);
-
-create table ob_user
-(
-    id          bigint auto_increment comment '主键'
-        primary key,
-    address     varchar(66)          not null comment '用户地址',
-    is_allowed  tinyint(1) default 0 not null comment '是否允许用户访问',
-    is_signed   tinyint(1) default 0 null,
-    create_time bigint               null comment '创建时间',
-    update_time bigint               null comment '更新时间',
-    constraint index_address
-        unique (address)
-)
-    collate = utf8mb4_general_ci;
-
-create table ob_indexed_status
-(
-    id                 bigint auto_increment comment '主键'
-        primary key,
-    chain_id           bigint  default 1 not null comment '链id (1:以太坊, 56: BSC)',
-    last_indexed_block bigint  default 0 null comment '区块号',
-    last_indexed_time  bigint            null comment '最后同步时间戳',
-    index_type         tinyint default 0 not null comment '0:activity, 1:trade info, 2:listing,3:sale,4:exchange,5:floor price',
-    create_time        bigint            null,
-    update_time        bigint            null
-)
-    collate = utf8mb4_general_ci;
\ No newline at end of file
@@ -0,0 +1 @@
+drop table if exists ob_collection_trade_{{chain}};
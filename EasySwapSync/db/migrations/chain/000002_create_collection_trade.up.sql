@@ -0,0 +1,19 @@
+create table ob_collection_trade_{{chain}}
+(
+    id                 bigint auto_increment comment '主键'
+        primary key,
+    epoch_number       bigint      default 0 not null comment '数据同步周期',
+    collection_address varchar(42)           not null,
+    item_count         bigint      default 0 not null comment '单周期内交易的nft数量',
+    volume             decimal(30) default 0 not null comment '单周期内的成交量',
+    floor_price        decimal(30) default 0 not null comment '地板价',
+    benchmark_price    decimal(30) default 0 not null comment '池子相关数据,基准价格',
+    sell_price         decimal(30) default 0 not null comment '池子相关数据,出售价格',
+    buy_price          decimal(30) default 0 not null comment '池子相关数据,购买价格',
+    create_time        bigint                null comment '创建时间',
+    update_time        bigint                null comment '更新时间'
+)
+    collate = utf8mb4_general_ci;
+
+create index index_collection_epoch
+    on ob_collection_trade_{{chain}} (collection_address, epoch_number);
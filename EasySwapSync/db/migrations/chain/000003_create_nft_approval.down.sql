@@ -0,0 +1,4 @@
+alter table ob_order_{{chain}}
+    drop column fillable;
+
+drop table if exists ob_nft_approval_{{chain}};
@@ -0,0 +1,19 @@
+create table ob_nft_approval_{{chain}}
+(
+    id                 bigint auto_increment comment '主键'
+        primary key,
+    collection_address varchar(42)              not null comment '合约地址',
+    token_id           varchar(128) default ''  not null comment 'token_id, ApprovalForAll 为空',
+    owner              varchar(42)              not null comment '授权人',
+    operator           varchar(42)              not null comment '被授权地址',
+    approved           tinyint(1)   default 0   not null comment '是否有效',
+    block_number       bigint       default 0   not null comment '最近一次变更的区块号',
+    create_time        bigint                   null comment '创建时间',
+    update_time        bigint                   null comment '更新时间',
+    constraint index_collection_token_owner_operator
+        unique (collection_address, token_id, owner, operator)
+)
+    collate = utf8mb4_general_ci;
+
+alter table ob_order_{{chain}}
+    add fillable tinyint(1) default 1 not null comment '挂单人撤销 vault 授权后为 0';
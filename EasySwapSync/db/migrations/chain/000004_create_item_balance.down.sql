@@ -0,0 +1 @@
+drop table if exists ob_item_balance_{{chain}};
@@ -0,0 +1,18 @@
+create table ob_item_balance_{{chain}}
+(
+    id                 bigint auto_increment comment '主键'
+        primary key,
+    collection_address varchar(42)            not null comment '合约地址',
+    token_id           varchar(128)           not null comment 'token_id',
+    owner              varchar(42)            not null comment '持有人',
+    balance            bigint       default 0 not null comment '持有数量',
+    block_number       bigint       default 0 not null comment '最近一次变更的区块号',
+    create_time        bigint                 null comment '创建时间',
+    update_time        bigint                 null comment '更新时间',
+    constraint index_collection_token_owner
+        unique (collection_address, token_id, owner)
+)
+    collate = utf8mb4_general_ci;
+
+create index index_owner_balance
+    on ob_item_balance_{{chain}} (owner, balance);
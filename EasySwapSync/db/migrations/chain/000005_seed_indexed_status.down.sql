@@ -0,0 +1 @@
+delete from ob_indexed_status where chain_id = {{chain_id}} and index_type in (5, 6);
@@ -0,0 +1,10 @@
+-- 初始化订单簿事件(6)与地板价(5)的同步进度, 已有记录时保持原进度
+insert into ob_indexed_status (chain_id, last_indexed_block, last_indexed_time, index_type, create_time, update_time)
+select {{chain_id}}, {{start_block}}, unix_timestamp(), 6, unix_timestamp() * 1000, unix_timestamp() * 1000
+from dual
+where not exists (select 1 from ob_indexed_status where chain_id = {{chain_id}} and index_type = 6);
+
+insert into ob_indexed_status (chain_id, last_indexed_block, last_indexed_time, index_type, create_time, update_time)
+select {{chain_id}}, 0, unix_timestamp(), 5, unix_timestamp() * 1000, unix_timestamp() * 1000
+from dual
+where not exists (select 1 from ob_indexed_status where chain_id = {{chain_id}} and index_type = 5);
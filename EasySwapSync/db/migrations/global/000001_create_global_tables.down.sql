@@ -0,0 +1,9 @@
+drop table if exists ob_admin_audit_log;
+
+drop table if exists ob_admin_user;
+
+drop table if exists ob_sync_task;
+
+drop table if exists ob_indexed_status;
+
+drop table if exists ob_user;
@@ -0,0 +1,90 @@
+-- 使用 if not exists 建表, 已按旧版建表 SQL 创建的数据库执行后仅记录版本
+create table if not exists ob_user
+(
+    id          bigint auto_increment comment '主键'
+        primary key,
+    address     varchar(66)          not null comment '用户地址',
+    is_allowed  tinyint(1) default 0 not null comment '是否允许用户访问',
+    is_signed   tinyint(1) default 0 null,
+    create_time bigint               null comment '创建时间',
+    update_time bigint               null comment '更新时间',
+    constraint index_address
+        unique (address)
+)
+    collate = utf8mb4_general_ci;
+
+create table if not exists ob_indexed_status
+(
+    id                 bigint auto_increment comment '主键'
+        primary key,
+    chain_id           bigint  default 1 not null comment '链id (1:以太坊, 56: BSC)',
+    last_indexed_block bigint  default 0 null comment '区块号',
+    last_indexed_time  bigint            null comment '最后同步时间戳',
+    index_type         tinyint default 0 not null comment '0:activity, 1:trade info, 2:listing,3:sale,4:exchange,5:floor price',
+    create_time        bigint            null,
+    update_time        bigint            null
+)
+    collate = utf8mb4_general_ci;
+
+create table if not exists ob_sync_task
+(
+    id               bigint auto_increment comment '主键'
+        primary key,
+    task_id          varchar(64)                not null comment '任务ID',
+    task_type        varchar(16)                not null comment '任务类型(contract/token)',
+    chain_id         bigint                     not null comment '链ID',
+    contract_address varchar(42)                not null comment '合约地址',
+    token_id         varchar(128)  default ''   not null comment 'token_id(token任务)',
+    start_block      bigint        default 0    not null comment '起始区块',
+    end_block        bigint        default 0    not null comment '结束区块',
+    status           varchar(16)                not null comment '状态(pending/running/completed/failed/interrupted)',
+    progress         int           default 0    not null comment '进度(0-100)',
+    total_items      int           default 0    not null comment '总数量',
+    processed_items  int           default 0    not null comment '已处理数量',
+    failed_items     int           default 0    not null comment '失败数量',
+    error_msg        varchar(1024) default ''   not null comment '错误信息',
+    started_time     bigint        default 0    not null comment '开始时间',
+    completed_time   bigint        default 0    not null comment '结束时间',
+    create_time      bigint                     null comment '创建时间',
+    update_time      bigint                     null comment '更新时间',
+    constraint index_task_id
+        unique (task_id),
+    index index_contract_address (contract_address),
+    index index_status (status)
+)
+    collate = utf8mb4_general_ci;
+
+create table if not exists ob_admin_user
+(
+    id          bigint auto_increment comment '主键'
+        primary key,
+    address     varchar(42)             not null comment '管理员地址',
+    role        varchar(16)             not null comment '角色(viewer/operator/super)',
+    enabled     tinyint(1)  default 1   not null comment '是否启用',
+    created_by  varchar(42) default ''  not null comment '添加人',
+    create_time bigint                  null comment '创建时间',
+    update_time bigint                  null comment '更新时间',
+    constraint index_address
+        unique (address)
+)
+    collate = utf8mb4_general_ci;
+
+create table if not exists ob_admin_audit_log
+(
+    id           bigint auto_increment comment '主键'
+        primary key,
+    operator     varchar(42)                not null comment '操作人地址',
+    role         varchar(16)                not null comment '操作人角色',
+    action       varchar(64)                not null comment '操作类型',
+    chain_id     bigint        default 0    not null comment '链ID',
+    target       varchar(256)  default ''   not null comment '操作对象',
+    before_value text                       null comment '变更前',
+    after_value  text                       null comment '变更后',
+    success      tinyint(1)                 not null comment '是否成功',
+    error_msg    varchar(1024) default ''   not null comment '错误信息',
+    client_ip    varchar(64)   default ''   not null comment '客户端IP',
+    create_time  bigint                     null comment '创建时间',
+    index index_operator (operator),
+    index index_action (action)
+)
+    collate = utf8mb4_general_ci;
@@ -0,0 +1,5 @@
+drop table if exists ob_indexed_event;
+
+drop table if exists ob_indexed_change;
+
+drop table if exists ob_indexed_block;
@@ -0,0 +1,54 @@
+create table ob_indexed_block
+(
+    id           bigint auto_increment comment '主键'
+        primary key,
+    chain_id     bigint      default 1  not null comment '链id',
+    index_type   tinyint     default 0  not null comment '索引类型, 同 ob_indexed_status.index_type',
+    block_number bigint                 not null comment '区块号',
+    block_hash   varchar(66)            not null comment '区块哈希',
+    parent_hash  varchar(66) default '' not null comment '父区块哈希',
+    create_time  bigint                 null comment '创建时间',
+    constraint index_chain_type_block
+        unique (chain_id, index_type, block_number)
+)
+    collate = utf8mb4_general_ci;
+
+create table ob_indexed_change
+(
+    id                      bigint auto_increment comment '主键'
+        primary key,
+    chain_id                bigint       default 1  not null comment '链id',
+    index_type              tinyint      default 0  not null comment '索引类型, 同 ob_indexed_status.index_type',
+    block_number            bigint                  not null comment '产生变更的区块号',
+    change_type             tinyint                 not null comment '1:新建订单, 2:订单变更, 3:NFT所有者变更',
+    order_id                varchar(66)  default '' not null comment '订单ID',
+    collection_address      varchar(42)  default '' not null comment '合约地址',
+    token_id                varchar(128) default '' not null comment 'token_id',
+    prev_order_status       int          default 0  not null comment '变更前订单状态',
+    prev_quantity_remaining bigint       default 0  not null comment '变更前剩余数量',
+    prev_taker              varchar(42)  default '' not null comment '变更前taker',
+    prev_owner              varchar(42)  default '' not null comment '变更前所有者',
+    create_time             bigint                  null comment '创建时间'
+)
+    collate = utf8mb4_general_ci;
+
+create index index_chain_type_block
+    on ob_indexed_change (chain_id, index_type, block_number);
+
+create table ob_indexed_event
+(
+    id           bigint auto_increment comment '主键'
+        primary key,
+    chain_id     bigint      default 1 not null comment '链id',
+    index_type   tinyint     default 0 not null comment '索引类型, 同 ob_indexed_status.index_type',
+    block_number bigint                not null comment '日志所在区块号',
+    tx_hash      varchar(66)           not null comment '交易哈希',
+    log_index    bigint                not null comment '日志在区块中的序号',
+    create_time  bigint                null comment '创建时间',
+    constraint index_chain_type_tx_log
+        unique (chain_id, index_type, tx_hash, log_index)
+)
+    collate = utf8mb4_general_ci;
+
+create index index_chain_type_block
+    on ob_indexed_event (chain_id, index_type, block_number);
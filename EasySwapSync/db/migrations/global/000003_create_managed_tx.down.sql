@@ -0,0 +1 @@
+drop table if exists ob_managed_tx;
@@ -0,0 +1,32 @@
+create table ob_managed_tx
+(
+    id              bigint auto_increment comment '主键'
+        primary key,
+    request_id      varchar(64)                not null comment '请求ID',
+    chain_id        bigint                     not null comment '链ID',
+    signer          varchar(42)                not null comment '签名地址',
+    to_address      varchar(42)                not null comment '目标地址',
+    nonce           bigint                     not null comment 'nonce',
+    value           varchar(78)   default '0'  not null comment '转账金额(wei)',
+    data            text                       null comment '调用数据(hex)',
+    gas_limit       bigint        default 0    not null comment 'gas limit',
+    gas_fee_cap     varchar(78)   default ''   not null comment 'EIP-1559 max fee, 传统交易为 gas price',
+    gas_tip_cap     varchar(78)   default ''   not null comment 'EIP-1559 priority fee, 传统交易为空',
+    tx_hash         varchar(66)   default ''   not null comment '最新发送或已上链的交易哈希',
+    raw_tx          text                       null comment '已签名交易(hex), 重启后重新广播',
+    replaced_hashes text                       null comment '被替换的交易哈希, 逗号分隔',
+    attempts        int           default 0    not null comment '发送次数(含加速与取消)',
+    status          varchar(16)                not null comment '状态(pending/confirmed/failed/cancelling/cancelled)',
+    error_msg       varchar(1024) default ''   not null comment '错误信息',
+    block_number    bigint        default 0    not null comment '上链区块号',
+    gas_used        bigint        default 0    not null comment '消耗的gas',
+    sent_time       bigint        default 0    not null comment '最近一次广播时间',
+    create_time     bigint                     null comment '创建时间',
+    update_time     bigint                     null comment '更新时间',
+    constraint index_request_id
+        unique (request_id)
+)
+    collate = utf8mb4_general_ci;
+
+create index index_chain_signer_status
+    on ob_managed_tx (chain_id, signer, status);
@@ -0,0 +1,2 @@
+drop table if exists ob_webhook_delivery;
+drop table if exists ob_webhook_subscription;
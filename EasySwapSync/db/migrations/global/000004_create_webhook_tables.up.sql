@@ -0,0 +1,40 @@
+create table ob_webhook_subscription
+(
+    id          bigint auto_increment comment '主键'
+        primary key,
+    name        varchar(128) default ''  not null comment '名称',
+    url         varchar(512)             not null comment '推送地址',
+    secret      varchar(128)             not null comment 'HMAC签名密钥',
+    event_types varchar(256) default ''  not null comment '事件类型, 逗号分隔, 为空时订阅全部',
+    chain_id    bigint       default 0   not null comment '链ID, 0表示全部链',
+    collections text                     null comment '合集地址, 逗号分隔, 为空时订阅全部',
+    enabled     tinyint(1)   default 1   not null comment '是否启用',
+    created_by  varchar(42)  default ''  not null comment '创建人',
+    create_time bigint                   null comment '创建时间',
+    update_time bigint                   null comment '更新时间'
+)
+    collate = utf8mb4_general_ci;
+
+create table ob_webhook_delivery
+(
+    id              bigint auto_increment comment '主键'
+        primary key,
+    subscription_id bigint                     not null comment '订阅ID',
+    event_id        varchar(96)                not null comment '事件ID(<chain>:<编号>)',
+    event_type      varchar(32)                not null comment '事件类型',
+    payload         text                       null comment '推送内容',
+    status          varchar(16)                not null comment '状态(pending/succeeded/dead)',
+    attempts        int           default 0    not null comment '已投递次数',
+    response_status int           default 0    not null comment '最近一次响应状态码',
+    error_msg       varchar(1024) default ''   not null comment '最近一次错误信息',
+    next_retry_time bigint        default 0    not null comment '下次投递时间(毫秒)',
+    delivered_time  bigint        default 0    not null comment '投递成功时间(毫秒)',
+    create_time     bigint                     null comment '创建时间',
+    update_time     bigint                     null comment '更新时间',
+    constraint index_subscription_event
+        unique (subscription_id, event_id)
+)
+    collate = utf8mb4_general_ci;
+
+create index index_status_next_retry_time
+    on ob_webhook_delivery (status, next_retry_time);
@@ -0,0 +1,4 @@
+drop table if exists ob_notify_setting;
+drop table if exists ob_notification;
+drop table if exists ob_alert_rule;
+drop table if exists ob_watchlist;
@@ -0,0 +1,78 @@
+create table ob_watchlist
+(
+    id                 bigint auto_increment comment '主键'
+        primary key,
+    user_address       varchar(42)              not null comment '用户地址',
+    chain_id           bigint                   not null comment '链ID',
+    collection_address varchar(42)              not null comment '合集地址',
+    token_id           varchar(128) default ''  not null comment 'token_id, 为空时关注整个合集',
+    create_time        bigint                   null comment '创建时间',
+    constraint index_user_chain_collection_token
+        unique (user_address, chain_id, collection_address, token_id)
+)
+    collate = utf8mb4_general_ci;
+
+create table ob_alert_rule
+(
+    id                  bigint auto_increment comment '主键'
+        primary key,
+    user_address        varchar(42)                 not null comment '用户地址',
+    chain_id            bigint                      not null comment '链ID',
+    collection_address  varchar(42)                 not null comment '合集地址',
+    token_id            varchar(128)   default ''   not null comment 'token_id, 为空时作用于整个合集',
+    rule_type           varchar(32)                 not null comment '规则类型',
+    threshold           decimal(30)    default 0    not null comment '阈值(wei)',
+    window_seconds      bigint         default 0    not null comment '成交额统计窗口(秒), 仅 volume_spike 使用',
+    channels            varchar(64)    default ''   not null comment '站外通知渠道, 逗号分隔',
+    cooldown            bigint         default 0    not null comment '两次触发的最小间隔(秒)',
+    enabled             tinyint(1)     default 1    not null comment '是否启用',
+    last_triggered_time bigint         default 0    not null comment '最近一次触发时间(毫秒)',
+    create_time         bigint                      null comment '创建时间',
+    update_time         bigint                      null comment '更新时间'
+)
+    collate = utf8mb4_general_ci;
+
+create index index_user_address
+    on ob_alert_rule (user_address);
+
+create index index_chain_collection
+    on ob_alert_rule (chain_id, collection_address);
+
+create table ob_notification
+(
+    id                 bigint auto_increment comment '主键'
+        primary key,
+    user_address       varchar(42)              not null comment '用户地址',
+    rule_id            bigint                   not null comment '提醒规则ID',
+    rule_type          varchar(32)              not null comment '规则类型',
+    event_id           varchar(96)              not null comment '触发事件ID(<chain>:<编号>)',
+    chain_id           bigint                   not null comment '链ID',
+    collection_address varchar(42)              not null comment '合集地址',
+    token_id           varchar(128) default ''  not null comment 'token_id',
+    title              varchar(256)             not null comment '标题',
+    content            text                     null comment '触发事件内容(JSON)',
+    is_read            tinyint(1)   default 0   not null comment '是否已读',
+    read_time          bigint       default 0   not null comment '已读时间(毫秒)',
+    create_time        bigint                   null comment '创建时间',
+    constraint index_rule_event
+        unique (rule_id, event_id)
+)
+    collate = utf8mb4_general_ci;
+
+create index index_user_read
+    on ob_notification (user_address, is_read);
+
+create table ob_notify_setting
+(
+    id             bigint auto_increment comment '主键'
+        primary key,
+    user_address   varchar(42)              not null comment '用户地址',
+    email          varchar(256) default ''  not null comment '通知邮箱',
+    webhook_url    varchar(512) default ''  not null comment '通知 webhook 地址',
+    webhook_secret varchar(128) default ''  not null comment 'webhook HMAC签名密钥',
+    create_time    bigint                   null comment '创建时间',
+    update_time    bigint                   null comment '更新时间',
+    constraint index_notify_user_address
+        unique (user_address)
+)
+    collate = utf8mb4_general_ci;
@@ -0,0 +1,5 @@
+alter table ob_indexed_change
+    drop column prev_fillable,
+    drop column approval_id,
+    drop column prev_approved,
+    modify change_type tinyint not null comment '1:新建订单, 2:订单变更, 3:NFT所有者变更';
@@ -0,0 +1,5 @@
+alter table ob_indexed_change
+    add prev_fillable tinyint(1) default 1 not null comment '变更前订单是否可成交',
+    add approval_id   bigint     default 0 not null comment '授权状态记录ID, 同 ob_nft_approval_<chain>.id',
+    add prev_approved tinyint(1) default 0 not null comment '变更前授权是否有效',
+    modify change_type tinyint not null comment '1:新建订单, 2:订单变更, 3:NFT所有者变更, 4:新建NFT, 5:NFT授权变更';
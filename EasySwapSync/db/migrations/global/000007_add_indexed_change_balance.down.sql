@@ -0,0 +1,4 @@
+alter table ob_indexed_change
+    drop column balance_id,
+    drop column prev_balance,
+    modify change_type tinyint not null comment '1:新建订单, 2:订单变更, 3:NFT所有者变更, 4:新建NFT, 5:NFT授权变更';
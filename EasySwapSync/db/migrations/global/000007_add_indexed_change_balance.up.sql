@@ -0,0 +1,4 @@
+alter table ob_indexed_change
+    add balance_id   bigint default 0 not null comment 'ERC1155 持有数量记录ID, 同 ob_item_balance_<chain>.id',
+    add prev_balance bigint default 0 not null comment '变更前持有数量',
+    modify change_type tinyint not null comment '1:新建订单, 2:订单变更, 3:NFT所有者变更, 4:新建NFT, 5:NFT授权变更, 6:ERC1155持有数量变更';
@@ -0,0 +1,5 @@
+drop index index_status_lease on ob_sync_task;
+
+alter table ob_sync_task
+    drop column owner,
+    drop column lease_expire_time;
@@ -0,0 +1,6 @@
+alter table ob_sync_task
+    add owner             varchar(128) default '' not null comment '执行任务的服务实例',
+    add lease_expire_time bigint       default 0  not null comment '租约到期时间';
+
+create index index_status_lease
+    on ob_sync_task (status, lease_expire_time);
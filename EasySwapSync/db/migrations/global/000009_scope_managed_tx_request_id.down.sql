@@ -0,0 +1,5 @@
+alter table ob_managed_tx
+    drop index index_chain_signer_caller_request,
+    drop column caller,
+    add constraint index_request_id
+        unique (request_id);
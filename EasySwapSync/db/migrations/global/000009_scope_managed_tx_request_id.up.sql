@@ -0,0 +1,6 @@
+-- 请求ID只在同一链、签名地址与调用方范围内幂等
+alter table ob_managed_tx
+    add caller varchar(64) default '' not null comment '调用方' after request_id,
+    drop index index_request_id,
+    add constraint index_chain_signer_caller_request
+        unique (chain_id, signer, caller, request_id);
@@ -0,0 +1,392 @@
+/**
+ * migrations.go - 数据库版本化迁移
+ *
+ * 功能：
+ *   - 内嵌 global/ 与 chain/ 目录下的迁移文件，文件名格式 <版本号>_<名称>.up.sql / .down.sql
+ *   - global 目录为全局表（ob_user、ob_indexed_status 等），只迁移一次
+ *   - chain 目录为按链分表的模板，{{chain}} 替换为链名称，{{chain_id}}、{{start_block}} 替换为 SetChains 设置的链参数，每条链独立迁移
+ *   - 已执行的版本记录在 schema_migrations 表中，按 scope（global 或链名称）区分
+ *
+ * 使用方式：
+ *   m, err := migrations.New(db)
+ *   m.SetChains([]migrations.Chain{{Name: "sepolia", ID: 11155111, StartBlock: 5000000}})
+ *   m.Up(ctx, migrations.GlobalScope, 0)
+ *   m.Up(ctx, "sepolia", 0)
+ */
+package migrations
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	GlobalScope = "global" // 全局表的迁移范围
+
+	chainPlaceholder      = "{{chain}}"
+	chainIDPlaceholder    = "{{chain_id}}"
+	startBlockPlaceholder = "{{start_block}}"
+
+	schemaMigrationsTableName = "schema_migrations"
+)
+
+//go:embed global/*.sql chain/*.sql
+var files embed.FS
+
+var (
+	fileNamePattern  = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+	chainNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+// Migration 单个版本的迁移脚本
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Chain 链迁移模板参数
+type Chain struct {
+	Name       string
+	ID         int64
+	StartBlock uint64 // 首次同步的起始区块, 用于初始化 ob_indexed_status
+}
+
+// MigrationStatus 迁移版本在某个 scope 下的执行状态
+type MigrationStatus struct {
+	Version     uint64
+	Name        string
+	Applied     bool
+	AppliedTime int64 // 执行时间(秒), 未执行时为 0
+}
+
+// SchemaMigration schema_migrations 表记录
+type SchemaMigration struct {
+	Scope       string `gorm:"column:scope;primaryKey"`
+	Version     uint64 `gorm:"column:version;primaryKey"`
+	Name        string `gorm:"column:name"`
+	AppliedTime int64  `gorm:"column:applied_time"`
+}
+
+// Migrator 执行全局表与按链分表的迁移
+type Migrator struct {
+	db     *gorm.DB
+	global []Migration
+	chain  []Migration
+	chains map[string]Chain
+}
+
+// New 使用内嵌的迁移文件创建 Migrator
+func New(db *gorm.DB) (*Migrator, error) {
+	return newMigrator(db, files)
+}
+
+func newMigrator(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	global, err := loadMigrations(fsys, "global")
+	if err != nil {
+		return nil, err
+	}
+	chain, err := loadMigrations(fsys, "chain")
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range global {
+		for _, placeholder := range []string{chainPlaceholder, chainIDPlaceholder, startBlockPlaceholder} {
+			if strings.Contains(m.Up, placeholder) || strings.Contains(m.Down, placeholder) {
+				return nil, errors.Errorf("global migration %d_%s contains chain placeholder", m.Version, m.Name)
+			}
+		}
+	}
+
+	return &Migrator{db: db, global: global, chain: chain, chains: make(map[string]Chain)}, nil
+}
+
+// SetChains 设置链迁移模板参数, 迁移脚本使用 {{chain_id}} 或 {{start_block}} 的链必须设置
+func (m *Migrator) SetChains(chains []Chain) {
+	for _, chain := range chains {
+		m.chains[chain.Name] = chain
+	}
+}
+
+// loadMigrations 读取目录下的迁移文件, 每个版本必须同时有 up 与 down 文件
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed on read migration dir %s", dir)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, errors.Errorf("invalid migration file name %s/%s", dir, entry.Name())
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, errors.Errorf("invalid migration version %s/%s", dir, entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed on read migration file %s/%s", dir, entry.Name())
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, errors.Errorf("migration version %d has different names %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, errors.Errorf("migration %s/%d_%s missing up or down file", dir, m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// migrations 返回 scope 对应的迁移列表, 链名称会被直接拼接进表名, 因此只允许小写字母、数字和下划线
+func (m *Migrator) migrations(scope string) ([]Migration, error) {
+	if scope == GlobalScope {
+		return m.global, nil
+	}
+	if !chainNamePattern.MatchString(scope) {
+		return nil, errors.Errorf("invalid chain name %q", scope)
+	}
+
+	return m.chain, nil
+}
+
+// render 返回 scope 下渲染后的迁移脚本, 链迁移中的 {{chain}} 替换为链名称, {{chain_id}} 与 {{start_block}} 替换为链参数
+func (m *Migrator) render(scope string, sql string) (string, error) {
+	if scope == GlobalScope {
+		return sql, nil
+	}
+
+	sql = strings.ReplaceAll(sql, chainPlaceholder, scope)
+	if !strings.Contains(sql, chainIDPlaceholder) && !strings.Contains(sql, startBlockPlaceholder) {
+		return sql, nil
+	}
+	chain, ok := m.chains[scope]
+	if !ok || chain.ID == 0 {
+		return "", errors.Errorf("chain %s is not configured", scope)
+	}
+	// 从 0 区块开始同步会扫描整条链, 必须显式配置起始区块
+	if strings.Contains(sql, startBlockPlaceholder) && chain.StartBlock == 0 {
+		return "", errors.Errorf("start_block of chain %s is required", scope)
+	}
+
+	sql = strings.ReplaceAll(sql, chainIDPlaceholder, strconv.FormatInt(chain.ID, 10))
+	return strings.ReplaceAll(sql, startBlockPlaceholder, strconv.FormatUint(chain.StartBlock, 10)), nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	err := m.db.WithContext(ctx).Exec(`create table if not exists ` + schemaMigrationsTableName + `
+(
+    scope        varchar(64)  not null,
+    version      bigint       not null,
+    name         varchar(255) not null,
+    applied_time bigint       not null,
+    primary key (scope, version)
+)`).Error
+
+	return errors.Wrap(err, "failed on create schema_migrations table")
+}
+
+func (m *Migrator) applied(ctx context.Context, scope string) (map[uint64]SchemaMigration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var records []SchemaMigration
+	if err := m.db.WithContext(ctx).Table(schemaMigrationsTableName).
+		Where("scope = ?", scope).Find(&records).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on get applied migrations")
+	}
+
+	applied := make(map[uint64]SchemaMigration, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+
+	return applied, nil
+}
+
+// Status 返回 scope 下所有迁移版本的执行状态
+func (m *Migrator) Status(ctx context.Context, scope string) ([]MigrationStatus, error) {
+	migrations, err := m.migrations(scope)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		record, ok := applied[migration.Version]
+		statuses = append(statuses, MigrationStatus{
+			Version:     migration.Version,
+			Name:        migration.Name,
+			Applied:     ok,
+			AppliedTime: record.AppliedTime,
+		})
+	}
+
+	return statuses, nil
+}
+
+// Up 按版本升序执行 scope 下未执行的迁移, steps <= 0 时执行全部
+// 返回本次执行的迁移版本
+func (m *Migrator) Up(ctx context.Context, scope string, steps int) ([]uint64, error) {
+	migrations, err := m.migrations(scope)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []uint64
+	for _, migration := range migrations {
+		if steps > 0 && len(versions) >= steps {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		sql, err := m.render(scope, migration.Up)
+		if err != nil {
+			return versions, errors.Wrapf(err, "failed on render %s migration %d_%s", scope, migration.Version, migration.Name)
+		}
+		if err := m.exec(ctx, sql); err != nil {
+			return versions, errors.Wrapf(err, "failed on migrate %s up to %d_%s", scope, migration.Version, migration.Name)
+		}
+		if err := m.db.WithContext(ctx).Table(schemaMigrationsTableName).Create(&SchemaMigration{
+			Scope:       scope,
+			Version:     migration.Version,
+			Name:        migration.Name,
+			AppliedTime: time.Now().Unix(),
+		}).Error; err != nil {
+			return versions, errors.Wrapf(err, "failed on record migration %s %d", scope, migration.Version)
+		}
+		versions = append(versions, migration.Version)
+	}
+
+	return versions, nil
+}
+
+// Down 按版本降序回滚 scope 下已执行的迁移, steps <= 0 时回滚全部
+// 返回本次回滚的迁移版本
+func (m *Migrator) Down(ctx context.Context, scope string, steps int) ([]uint64, error) {
+	migrations, err := m.migrations(scope)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []uint64
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if steps > 0 && len(versions) >= steps {
+			break
+		}
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		sql, err := m.render(scope, migration.Down)
+		if err != nil {
+			return versions, errors.Wrapf(err, "failed on render %s migration %d_%s", scope, migration.Version, migration.Name)
+		}
+		if err := m.exec(ctx, sql); err != nil {
+			return versions, errors.Wrapf(err, "failed on migrate %s down from %d_%s", scope, migration.Version, migration.Name)
+		}
+		if err := m.db.WithContext(ctx).Table(schemaMigrationsTableName).
+			Where("scope = ? and version = ?", scope, migration.Version).
+			Delete(&SchemaMigration{}).Error; err != nil {
+			return versions, errors.Wrapf(err, "failed on delete migration record %s %d", scope, migration.Version)
+		}
+		versions = append(versions, migration.Version)
+	}
+
+	return versions, nil
+}
+
+// exec 逐条执行迁移脚本中的语句
+// MySQL 的 DDL 会隐式提交, 无法放在同一事务中, 执行失败时需根据错误手动处理已执行的语句
+func (m *Migrator) exec(ctx context.Context, sql string) error {
+	for _, stmt := range splitStatements(sql) {
+		if err := m.db.WithContext(ctx).Exec(stmt).Error; err != nil {
+			return errors.Wrapf(err, "failed on exec %q", firstLine(stmt))
+		}
+	}
+
+	return nil
+}
+
+// splitStatements 以行尾的分号切分语句, 忽略空行与 -- 注释行
+func splitStatements(sql string) []string {
+	var (
+		stmts []string
+		buf   strings.Builder
+	)
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSuffix(strings.TrimSpace(buf.String()), ";"))
+			buf.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(buf.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+
+	return stmts
+}
+
+func firstLine(stmt string) string {
+	if i := strings.IndexByte(stmt, '\n'); i >= 0 {
+		return stmt[:i]
+	}
+
+	return stmt
+}
@@ -0,0 +1,225 @@
+package migrations
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+var createTablePattern = regexp.MustCompile(`(?m)^create table (?:if not exists )?(\S+)`)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// testFS 使用 sqlite 可执行的语句, sqlite 的索引名全库唯一, 因此带上链名称
+var testFS = fstest.MapFS{
+	"global/000001_create_user.up.sql":   {Data: []byte("create table ob_user\n(\n    id integer primary key\n);\n")},
+	"global/000001_create_user.down.sql": {Data: []byte("drop table if exists ob_user;\n")},
+	"chain/000001_create_order.up.sql": {Data: []byte("-- 订单表\ncreate table ob_order_{{chain}}\n(\n    id integer primary key\n);\n\n" +
+		"create index index_order_id_{{chain}}\n    on ob_order_{{chain}} (id);\n")},
+	"chain/000001_create_order.down.sql": {Data: []byte("drop table if exists ob_order_{{chain}};\n")},
+	"chain/000002_create_item.up.sql":    {Data: []byte("create table ob_item_{{chain}}\n(\n    id integer primary key\n);\n")},
+	"chain/000002_create_item.down.sql":  {Data: []byte("drop table if exists ob_item_{{chain}};\n")},
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	m, err := newMigrator(db, testFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Up(ctx, GlobalScope, 0); err != nil {
+		t.Fatal(err)
+	}
+	// 每条链独立迁移, steps 限制执行的版本数
+	versions, err := m.Up(ctx, "sepolia", 1)
+	if err != nil || len(versions) != 1 || versions[0] != 1 {
+		t.Fatalf("unexpected up result %v %v", versions, err)
+	}
+	if _, err := m.Up(ctx, "base", 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"ob_user", "ob_order_sepolia", "ob_order_base", "ob_item_base"} {
+		if !db.Migrator().HasTable(table) {
+			t.Fatalf("table %s not created", table)
+		}
+	}
+	if db.Migrator().HasTable("ob_item_sepolia") {
+		t.Fatal("ob_item_sepolia should not be created")
+	}
+
+	// 重复执行只会执行未执行的版本
+	versions, err = m.Up(ctx, "sepolia", 0)
+	if err != nil || len(versions) != 1 || versions[0] != 2 {
+		t.Fatalf("unexpected up result %v %v", versions, err)
+	}
+	if versions, err = m.Up(ctx, "sepolia", 0); err != nil || len(versions) != 0 {
+		t.Fatalf("unexpected up result %v %v", versions, err)
+	}
+
+	versions, err = m.Down(ctx, "sepolia", 1)
+	if err != nil || len(versions) != 1 || versions[0] != 2 {
+		t.Fatalf("unexpected down result %v %v", versions, err)
+	}
+	if db.Migrator().HasTable("ob_item_sepolia") || !db.Migrator().HasTable("ob_item_base") {
+		t.Fatal("down should only drop ob_item_sepolia")
+	}
+
+	statuses, err := m.Status(ctx, "sepolia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || !statuses[0].Applied || statuses[1].Applied {
+		t.Fatalf("unexpected status %+v", statuses)
+	}
+
+	if _, err := m.Up(ctx, "sepolia; drop table ob_user", 0); err == nil {
+		t.Fatal("expected invalid chain name error")
+	}
+}
+
+// TestMigrateChainParams 检查已有表的数据库可以直接迁移, 同步进度按链参数初始化且不覆盖已有进度
+func TestMigrateChainParams(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	fsys := fstest.MapFS{
+		"global/000001_create_status.up.sql": {Data: []byte("create table if not exists ob_indexed_status\n(\n    id integer primary key,\n" +
+			"    chain_id integer,\n    last_indexed_block integer,\n    index_type integer\n);\n")},
+		"global/000001_create_status.down.sql": {Data: []byte("drop table if exists ob_indexed_status;\n")},
+		"chain/000001_seed_status.up.sql": {Data: []byte("insert into ob_indexed_status (chain_id, last_indexed_block, index_type)\n" +
+			"select {{chain_id}}, {{start_block}}, 6\n" +
+			"where not exists (select 1 from ob_indexed_status where chain_id = {{chain_id}} and index_type = 6);\n")},
+		"chain/000001_seed_status.down.sql": {Data: []byte("delete from ob_indexed_status where chain_id = {{chain_id}} and index_type = 6;\n")},
+	}
+	m, err := newMigrator(db, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 迁移前按旧版 SQL 建表且已有同步进度
+	if err := db.Exec("create table ob_indexed_status (id integer primary key, chain_id integer, last_indexed_block integer, index_type integer)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("insert into ob_indexed_status (chain_id, last_indexed_block, index_type) values (8453, 900, 6)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx, GlobalScope, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未设置链参数或起始区块时拒绝迁移
+	if _, err := m.Up(ctx, "sepolia", 0); err == nil {
+		t.Fatal("expected chain not configured error")
+	}
+	m.SetChains([]Chain{{Name: "sepolia", ID: 11155111}, {Name: "base", ID: 8453, StartBlock: 100}})
+	if _, err := m.Up(ctx, "sepolia", 0); err == nil {
+		t.Fatal("expected start_block required error")
+	}
+
+	m.SetChains([]Chain{{Name: "sepolia", ID: 11155111, StartBlock: 5000}})
+	for _, scope := range []string{"sepolia", "base"} {
+		if _, err := m.Up(ctx, scope, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	blocks := make(map[int64]int64)
+	var rows []base.IndexedStatus
+	if err := db.Table(base.IndexedStatusTableName()).Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		blocks[int64(row.ChainId)] = row.LastIndexedBlock
+	}
+	if len(rows) != 2 || blocks[11155111] != 5000 || blocks[8453] != 900 {
+		t.Fatalf("unexpected indexed status %+v", rows)
+	}
+}
+
+func TestLoadMigrationsInvalid(t *testing.T) {
+	fsys := fstest.MapFS{
+		"global/000001_create_user.up.sql": {Data: []byte("create table ob_user (id integer);\n")},
+		"chain/000001_create_order.up.sql": {Data: []byte("create table ob_order_{{chain}} (id integer);\n")},
+	}
+	if _, err := newMigrator(nil, fsys); err == nil {
+		t.Fatal("expected missing down file error")
+	}
+}
+
+// TestEmbeddedMigrations 检查内嵌脚本创建的表与 Base 中的表名定义一致
+func TestEmbeddedMigrations(t *testing.T) {
+	m, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := make(map[string]bool)
+	for _, migration := range m.global {
+		for _, match := range createTablePattern.FindAllStringSubmatch(migration.Up, -1) {
+			created[match[1]] = true
+		}
+	}
+	m.SetChains([]Chain{{Name: "base", ID: 8453, StartBlock: 1}})
+	for _, migration := range m.chain {
+		sql, err := m.render("base", migration.Up)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range createTablePattern.FindAllStringSubmatch(sql, -1) {
+			created[match[1]] = true
+		}
+	}
+
+	for _, table := range []string{
+		base.UserTableName(),
+		base.IndexedStatusTableName(),
+		base.SyncTaskTableName(),
+		base.AdminUserTableName(),
+		base.AdminAuditLogTableName(),
+		base.IndexedBlockTableName(),
+		base.IndexedChangeTableName(),
+		base.IndexedEventTableName(),
+		base.ManagedTxTableName(),
+		base.WebhookSubscriptionTableName(),
+		base.WebhookDeliveryTableName(),
+		base.WatchlistTableName(),
+		base.AlertRuleTableName(),
+		base.NotificationTableName(),
+		base.NotifySettingTableName(),
+		multi.ActivityTableName("base"),
+		multi.CollectionTableName("base"),
+		multi.CollectionFloorPriceTableName("base"),
+		multi.CollectionImportRecordTableName("base"),
+		multi.CollectionTradeTableName("base"),
+		multi.GlobalCollectionTableName("base"),
+		multi.ItemTableName("base"),
+		multi.ItemExternalTableName("base"),
+		multi.ItemTraitTableName("base"),
+		multi.NftApprovalTableName("base"),
+		multi.ItemBalanceTableName("base"),
+		multi.OrderTableName("base"),
+	} {
+		if !created[table] {
+			t.Errorf("table %s not created by migrations", table)
+		}
+	}
+
+	stmts := splitStatements(m.chain[0].Up)
+	if len(stmts) == 0 || stmts[0][len(stmts[0])-1] == ';' {
+		t.Fatalf("unexpected statements %v", stmts)
+	}
+}
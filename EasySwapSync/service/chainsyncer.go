@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/chainclient"
+	"github.com/ProjectsTask/EasySwapBase/chain/nftchainservice"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/ordermanager"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/pkg/errors"
+	"github.com/zeromicro/go-zero/core/metric"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/ProjectsTask/EasySwapSync/service/collectionfilter"
+	"github.com/ProjectsTask/EasySwapSync/service/collectiontrade"
+	"github.com/ProjectsTask/EasySwapSync/service/config"
+	"github.com/ProjectsTask/EasySwapSync/service/metadatarefresh"
+	"github.com/ProjectsTask/EasySwapSync/service/orderbookindexer"
+)
+
+const (
+	minRestartBackoff = time.Second      // 首次重启等待时间
+	maxRestartBackoff = 2 * time.Minute  // 重启等待时间上限
+	stableRunTime     = 10 * time.Minute // 运行超过该时长后重启等待时间重置
+)
+
+// 链同步状态
+const (
+	ChainStateStarting   = "starting"
+	ChainStateRunning    = "running"
+	ChainStateRestarting = "restarting"
+	ChainStateStopped    = "stopped"
+)
+
+var (
+	metricChainUp = metric.NewGaugeVec(&metric.GaugeVecOpts{
+		Namespace: "sync",
+		Subsystem: "chain",
+		Name:      "up",
+		Help:      "whether chain syncer is running, 1 running, 0 starting, restarting or stopped",
+		Labels:    []string{"chain"},
+	})
+	metricChainRestarts = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: "sync",
+		Subsystem: "chain",
+		Name:      "restarts_total",
+		Help:      "chain syncer restarts",
+		Labels:    []string{"chain"},
+	})
+)
+
+// ChainStatus 单条链的同步状态
+type ChainStatus struct {
+	Chain            string `json:"chain"`
+	ChainID          int64  `json:"chain_id"`
+	State            string `json:"state"`
+	Restarts         int64  `json:"restarts"`
+	LastError        string `json:"last_error,omitempty"`
+	StartedAt        int64  `json:"started_at"`         // 最近一次启动时间(秒)
+	LastIndexedBlock int64  `json:"last_indexed_block"` // 下一个待同步的区块
+}
+
+// chainSyncer 监管单条链的全部组件: 链客户端、集合过滤器、订单管理器、成交统计、元数据刷新与订单簿索引器
+// 每次运行使用独立的子上下文, 任一同步循环退出或 panic 时取消上下文并在退避后整体重建
+type chainSyncer struct {
+	ctx     context.Context
+	cfg     *config.Config // 该链的配置, chain_cfg 与 contract_cfg 已替换为该链的值
+	db      *gorm.DB
+	kvStore *xkv.Store
+
+	mu     sync.Mutex
+	status ChainStatus
+}
+
+func newChainSyncer(ctx context.Context, cfg *config.Config, db *gorm.DB, kvStore *xkv.Store) *chainSyncer {
+	return &chainSyncer{
+		ctx:     ctx,
+		cfg:     cfg,
+		db:      db,
+		kvStore: kvStore,
+		status: ChainStatus{
+			Chain:   cfg.ChainCfg.Name,
+			ChainID: cfg.ChainCfg.ID,
+			State:   ChainStateStarting,
+		},
+	}
+}
+
+// supervise 运行该链的同步组件, 异常退出后按指数退避重启, 直到服务退出
+func (c *chainSyncer) supervise() {
+	backoff := minRestartBackoff
+	for {
+		started := time.Now()
+		err := c.run()
+		if c.ctx.Err() != nil {
+			c.setState(ChainStateStopped, nil)
+			xzap.WithContext(c.ctx).Info("chain syncer stopped", zap.String("chain", c.cfg.ChainCfg.Name))
+			return
+		}
+
+		if time.Since(started) > stableRunTime {
+			backoff = minRestartBackoff
+		}
+		c.setState(ChainStateRestarting, err)
+		metricChainRestarts.Inc(c.cfg.ChainCfg.Name)
+		xzap.WithContext(c.ctx).Error("chain syncer exited, restarting",
+			zap.Error(err),
+			zap.String("chain", c.cfg.ChainCfg.Name),
+			zap.Duration("backoff", backoff))
+
+		select {
+		case <-c.ctx.Done():
+			c.setState(ChainStateStopped, nil)
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxRestartBackoff {
+			backoff = maxRestartBackoff
+		}
+	}
+}
+
+// run 在独立的子上下文中创建并启动该链的组件, 阻塞直到同步循环退出或服务退出
+func (c *chainSyncer) run() (err error) {
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel() // 停止订单管理器与其余同步循环
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("chain syncer panic: %v", r)
+		}
+	}()
+
+	chainCfg := c.cfg.ChainCfg
+	project := c.cfg.ProjectCfg.Name
+
+	// 1. 创建链客户端, 配置了多个节点时使用连接池
+	chainClient, err := chainclient.NewWithEndpoints(ctx, int(chainCfg.ID), chainCfg.Endpoints, chainclient.PoolConfig{
+		CheckInterval: time.Duration(chainCfg.HealthCheckInterval) * time.Second,
+		MaxBlockLag:   chainCfg.MaxBlockLag,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed on create evm client")
+	}
+	// 每次重启都会重新创建客户端, 退出时先停止使用它的组件再关闭连接, 避免泄漏连接
+	defer func() {
+		cancel()
+		chainClient.Close()
+	}()
+
+	// 2. 预加载 NFT 集合白名单, 必须在启动索引器之前完成, 否则可能会遗漏事件
+	collectionFilter := collectionfilter.New(ctx, c.db, chainCfg.Name, project)
+	if err := collectionFilter.PreloadCollections(); err != nil {
+		return errors.Wrap(err, "failed on preload collection to filter")
+	}
+
+	// 3. 创建订单管理器与订单簿索引器
+	orderManager := ordermanager.New(ctx, c.db, c.kvStore, chainCfg.Name, project)
+	indexer := orderbookindexer.New(ctx, c.cfg, c.db, c.kvStore, chainClient, chainCfg.ID, chainCfg.Name, orderManager)
+	// 跟踪白名单集合的 ERC721 Transfer 与授权事件, 保持 NFT 所有者、授权与挂单状态正确
+	indexer.EnableCollectionTracking(collectionFilter)
+
+	// 4. 可选: 启用 WebSocket 流式模式, 轮询仍用于追赶与断线回退
+	if chainCfg.EnableWss && chainCfg.WebsocketUrl != "" {
+		streamClient, err := chainclient.New(int(chainCfg.ID), chainCfg.WebsocketUrl)
+		if err != nil {
+			return errors.Wrap(err, "failed on create evm websocket client")
+		}
+		defer func() {
+			cancel()
+			streamClient.Close()
+		}()
+		indexer.EnableStreaming(streamClient)
+	}
+
+	// 5. 创建元数据刷新任务, 消费 EasySwapBackend 写入的刷新队列
+	parse := config.DefaultMetadataParse
+	if c.cfg.MetadataParse != nil {
+		parse = *c.cfg.MetadataParse
+	}
+	nodeSrv, err := nftchainservice.NewWithNodeClient(ctx, chainClient, chainCfg.Name,
+		parse.NameTags, parse.ImageTags, parse.AttributesTags, parse.TraitNameTags, parse.TraitValueTags)
+	if err != nil {
+		return errors.Wrap(err, "failed on create nft chain service")
+	}
+
+	// 6. 启动订单管理器、成交统计、元数据刷新与索引器, 阻塞直到索引器退出
+	orderManager.Start()
+	collectiontrade.New(ctx, c.db, c.kvStore, chainCfg.Name, project, statsConfig(c.cfg.Stats)).Start()
+	metadatarefresh.New(ctx, c.db, c.kvStore, nodeSrv, chainCfg.Name, project, metadataRefreshConfig(c.cfg.MetadataRefresh)).Start()
+	c.setState(ChainStateRunning, nil)
+	xzap.WithContext(ctx).Info("chain syncer started", zap.String("chain", chainCfg.Name))
+
+	return indexer.Run()
+}
+
+// statsConfig 将成交统计配置转换为统计任务参数
+func statsConfig(cfg *config.StatsCfg) collectiontrade.Config {
+	if cfg == nil {
+		return collectiontrade.Config{}
+	}
+
+	return collectiontrade.Config{
+		Interval: time.Duration(cfg.Interval) * time.Second,
+		Lookback: time.Duration(cfg.Lookback) * time.Second,
+	}
+}
+
+// metadataRefreshConfig 将元数据刷新配置转换为刷新任务参数
+func metadataRefreshConfig(cfg *config.MetadataRefreshCfg) metadatarefresh.Config {
+	if cfg == nil {
+		return metadatarefresh.Config{}
+	}
+
+	return metadatarefresh.Config{
+		Concurrency:  cfg.Concurrency,
+		MaxRetries:   cfg.MaxRetries,
+		RetryBackoff: time.Duration(cfg.RetryBackoff) * time.Second,
+		PollInterval: time.Duration(cfg.PollInterval) * time.Second,
+	}
+}
+
+func (c *chainSyncer) setState(state string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.status.State = state
+	switch state {
+	case ChainStateRunning:
+		c.status.StartedAt = time.Now().Unix()
+	case ChainStateRestarting:
+		c.status.Restarts++
+	}
+	if err != nil {
+		c.status.LastError = err.Error()
+	}
+
+	up := 0.0
+	if state == ChainStateRunning {
+		up = 1
+	}
+	metricChainUp.Set(up, c.status.Chain)
+}
+
+// Status 返回该链的同步状态, 同步进度从 indexed_status 表读取
+func (c *chainSyncer) Status() ChainStatus {
+	c.mu.Lock()
+	status := c.status
+	c.mu.Unlock()
+
+	var indexedStatus base.IndexedStatus
+	if err := c.db.WithContext(c.ctx).Table(base.IndexedStatusTableName()).
+		Where("chain_id = ? and index_type = ?", status.ChainID, orderbookindexer.EventIndexType).
+		First(&indexedStatus).Error; err != nil {
+		xzap.WithContext(c.ctx).Warn("failed on get indexed status",
+			zap.Error(err), zap.String("chain", status.Chain))
+	}
+	status.LastIndexedBlock = indexedStatus.LastIndexedBlock
+
+	return status
+}
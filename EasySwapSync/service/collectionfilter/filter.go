@@ -60,6 +60,17 @@ func (f *Filter) Contains(element string) bool {
 	return exists
 }
 
+// Addresses 返回过滤器中所有集合地址(小写)
+func (f *Filter) Addresses() []string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	addresses := make([]string, 0, len(f.set))
+	for address := range f.set {
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
 // PreloadCollections 从数据库预加载集合地址到过滤器中
 // 通常在服务启动时调用，加载所有状态为 "已导入" (CollectionFloorPriceImported) 的集合
 func (f *Filter) PreloadCollections() error {
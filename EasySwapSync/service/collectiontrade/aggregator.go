@@ -0,0 +1,408 @@
+package collectiontrade
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/ProjectsTask/EasySwapBase/tradestats"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/zeromicro/go-zero/core/threading"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	cacheWatermarkPre = "cache:es:tradestats:watermark:%s" // 已统计的成交记录创建时间(毫秒)
+
+	defaultInterval = time.Minute
+	defaultLookback = time.Hour
+	chunkSeconds    = 24 * 3600 // 重算历史数据时每批处理的时长
+)
+
+// Config 成交统计配置, 为 0 时使用默认值
+type Config struct {
+	Interval time.Duration // 统计间隔
+	Lookback time.Duration // 每次重算最近多长时间内的周期, 覆盖区块重组删除的成交
+}
+
+// Aggregator 将 ob_activity 中的成交记录按 5 分钟周期汇总到 ob_collection_trade,
+// 并据此维护各统计区间的排行榜缓存与合集累计成交额
+// 每个周期的数据按成交记录全量重算后整体替换, 重复执行与多实例并发执行结果一致
+type Aggregator struct {
+	ctx     context.Context
+	db      *gorm.DB
+	kv      *xkv.Store
+	chain   string
+	project string
+	conf    Config
+}
+
+// New 创建成交统计任务
+func New(ctx context.Context, db *gorm.DB, kv *xkv.Store, chain string, project string, conf Config) *Aggregator {
+	if conf.Interval <= 0 {
+		conf.Interval = defaultInterval
+	}
+	if conf.Lookback <= 0 {
+		conf.Lookback = defaultLookback
+	}
+
+	return &Aggregator{
+		ctx:     ctx,
+		db:      db,
+		kv:      kv,
+		chain:   chain,
+		project: project,
+		conf:    conf,
+	}
+}
+
+// Start 启动定时统计, 上下文取消时退出
+func (a *Aggregator) Start() {
+	threading.GoSafe(a.loop)
+}
+
+func (a *Aggregator) loop() {
+	ticker := time.NewTicker(a.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.Refresh(time.Now()); err != nil {
+			xzap.WithContext(a.ctx).Error("failed on refresh collection trade stats",
+				zap.Error(err), zap.String("chain", a.chain))
+		}
+
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh 重算最近 Lookback 内的周期以及上次统计后新写入的成交所在的周期(索引追赶历史区块时),
+// 然后刷新排行榜与受影响合集的累计成交额
+func (a *Aggregator) Refresh(now time.Time) error {
+	watermark, err := a.kv.GetInt64(fmt.Sprintf(cacheWatermarkPre, a.chain))
+	if err != nil {
+		return errors.Wrap(err, "failed on get trade stats watermark")
+	}
+	nextWatermark := now.UnixMilli()
+
+	from := now.Add(-a.conf.Lookback).Unix()
+	if watermark > 0 {
+		var earliest *int64
+		if err := a.db.WithContext(a.ctx).Table(gdb.GetMultiProjectActivityTableName(a.project, a.chain)).
+			Select("min(event_time)").
+			Where("activity_type = ? and create_time >= ?", multi.Sale, watermark).
+			Row().Scan(&earliest); err != nil {
+			return errors.Wrap(err, "failed on query new sales")
+		}
+		if earliest != nil && *earliest < from {
+			from = *earliest
+		}
+	}
+
+	collections, err := a.Aggregate(from, now.Unix()+1)
+	if err != nil {
+		return err
+	}
+	if err := a.UpdateRankings(now); err != nil {
+		return err
+	}
+	if len(collections) > 0 {
+		if err := a.UpdateVolumeTotals(collections); err != nil {
+			return err
+		}
+	}
+
+	if err := a.kv.SetInt64(fmt.Sprintf(cacheWatermarkPre, a.chain), nextWatermark); err != nil {
+		return errors.Wrap(err, "failed on save trade stats watermark")
+	}
+
+	return nil
+}
+
+// Backfill 重算 [from, to) 内的全部周期, 然后刷新排行榜与所有合集的累计成交额
+func (a *Aggregator) Backfill(from, to int64) error {
+	if _, err := a.Aggregate(from, to); err != nil {
+		return err
+	}
+	if err := a.UpdateRankings(time.Now()); err != nil {
+		return err
+	}
+
+	return a.UpdateVolumeTotals(nil)
+}
+
+// Aggregate 重算 [from, to) 所覆盖的周期(按周期边界对齐), 返回数据有变化的合集地址
+func (a *Aggregator) Aggregate(from, to int64) ([]string, error) {
+	fromEpoch := from / tradestats.EpochSeconds
+	toEpoch := (to + tradestats.EpochSeconds - 1) / tradestats.EpochSeconds
+
+	touched := make(map[string]bool)
+	chunkEpochs := int64(chunkSeconds / tradestats.EpochSeconds)
+	for start := fromEpoch; start < toEpoch; start += chunkEpochs {
+		end := start + chunkEpochs
+		if end > toEpoch {
+			end = toEpoch
+		}
+		collections, err := a.aggregateEpochs(start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, collection := range collections {
+			touched[collection] = true
+		}
+	}
+
+	collections := make([]string, 0, len(touched))
+	for collection := range touched {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+
+	return collections, nil
+}
+
+// aggregateEpochs 重算 [fromEpoch, toEpoch) 的成交统计, 先删除旧数据再写入, 区块重组删除的成交同时被移除
+func (a *Aggregator) aggregateEpochs(fromEpoch, toEpoch int64) ([]string, error) {
+	var sales []multi.Activity
+	if err := a.db.WithContext(a.ctx).Table(gdb.GetMultiProjectActivityTableName(a.project, a.chain)).
+		Select("collection_address, event_time, price").
+		Where("activity_type = ? and event_time >= ? and event_time < ?",
+			multi.Sale, fromEpoch*tradestats.EpochSeconds, toEpoch*tradestats.EpochSeconds).
+		Find(&sales).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query sales")
+	}
+
+	type tradeKey struct {
+		collection string
+		epoch      int64
+	}
+	trades := make(map[tradeKey]*multi.CollectionTrade)
+	for _, sale := range sales {
+		key := tradeKey{collection: strings.ToLower(sale.CollectionAddress), epoch: sale.EventTime / tradestats.EpochSeconds}
+		trade, ok := trades[key]
+		if !ok {
+			trade = &multi.CollectionTrade{
+				EpochNumber:       key.epoch,
+				CollectionAddress: key.collection,
+				Volume:            decimal.Zero,
+			}
+			trades[key] = trade
+		}
+		trade.ItemCount++
+		trade.Volume = trade.Volume.Add(sale.Price)
+		// 没有地板价快照时以周期内的最低成交价作为地板价
+		if trade.FloorPrice.IsZero() || sale.Price.LessThan(trade.FloorPrice) {
+			trade.FloorPrice = sale.Price
+		}
+	}
+
+	var floorPrices []struct {
+		CollectionAddress string
+		EpochStart        int64
+		Price             decimal.Decimal
+	}
+	if len(trades) > 0 {
+		if err := a.db.WithContext(a.ctx).Table(gdb.GetMultiProjectCollectionFloorPriceTableName(a.project, a.chain)).
+			Select("collection_address, event_time - event_time % ? as epoch_start, min(price) as price", tradestats.EpochSeconds).
+			Where("event_time >= ? and event_time < ? and price > 0",
+				fromEpoch*tradestats.EpochSeconds, toEpoch*tradestats.EpochSeconds).
+			Group("collection_address, epoch_start").
+			Scan(&floorPrices).Error; err != nil {
+			return nil, errors.Wrap(err, "failed on query collection floor price")
+		}
+	}
+	for _, floorPrice := range floorPrices {
+		key := tradeKey{collection: strings.ToLower(floorPrice.CollectionAddress), epoch: floorPrice.EpochStart / tradestats.EpochSeconds}
+		if trade, ok := trades[key]; ok {
+			trade.FloorPrice = floorPrice.Price
+		}
+	}
+
+	touched := make(map[string]bool)
+	var existing []string
+	if err := a.db.WithContext(a.ctx).Table(gdb.GetMultiProjectCollectionTradeTableName(a.project, a.chain)).
+		Distinct("collection_address").
+		Where("epoch_number >= ? and epoch_number < ?", fromEpoch, toEpoch).
+		Pluck("collection_address", &existing).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection trade")
+	}
+	for _, collection := range existing {
+		touched[strings.ToLower(collection)] = true
+	}
+
+	rows := make([]multi.CollectionTrade, 0, len(trades))
+	for key, trade := range trades {
+		touched[key.collection] = true
+		rows = append(rows, *trade)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].EpochNumber != rows[j].EpochNumber {
+			return rows[i].EpochNumber < rows[j].EpochNumber
+		}
+		return rows[i].CollectionAddress < rows[j].CollectionAddress
+	})
+	if len(touched) == 0 {
+		return nil, nil
+	}
+
+	if err := a.db.WithContext(a.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(gdb.GetMultiProjectCollectionTradeTableName(a.project, a.chain)).
+			Where("epoch_number >= ? and epoch_number < ?", fromEpoch, toEpoch).
+			Delete(&multi.CollectionTrade{}).Error; err != nil {
+			return errors.Wrap(err, "failed on delete collection trade")
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := tx.Table(gdb.GetMultiProjectCollectionTradeTableName(a.project, a.chain)).
+			CreateInBatches(&rows, 200).Error; err != nil {
+			return errors.Wrap(err, "failed on create collection trade")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	collections := make([]string, 0, len(touched))
+	for collection := range touched {
+		collections = append(collections, collection)
+	}
+
+	return collections, nil
+}
+
+// UpdateRankings 按各统计区间汇总成交数据并写入排行榜缓存
+// 当前区间为截至 now 所在周期的最近 N 个周期, 成交额与地板价的变化相对上一个同长度区间计算
+func (a *Aggregator) UpdateRankings(now time.Time) error {
+	curEpoch := now.Unix() / tradestats.EpochSeconds
+	done := make(map[int]bool)
+	for _, epochs := range tradestats.PeriodEpochs {
+		if done[epochs] {
+			continue
+		}
+		done[epochs] = true
+
+		ranking, err := a.ranking(curEpoch, int64(epochs))
+		if err != nil {
+			return err
+		}
+		if err := a.kv.Write(tradestats.RankingKey(a.project, a.chain, epochs), ranking); err != nil {
+			return errors.Wrap(err, "failed on cache collection ranking")
+		}
+	}
+
+	return nil
+}
+
+func (a *Aggregator) ranking(curEpoch, epochs int64) ([]tradestats.RankingItem, error) {
+	var stats []struct {
+		CollectionAddress string
+		ItemCount         int64
+		Volume            decimal.Decimal
+		FloorPrice        decimal.NullDecimal
+		PreVolume         decimal.Decimal
+		PreFloorPrice     decimal.NullDecimal
+	}
+	// 当前区间 (cur-N, cur], 上一个区间 (cur-2N, cur-N]
+	split := curEpoch - epochs
+	if err := a.db.WithContext(a.ctx).Table(gdb.GetMultiProjectCollectionTradeTableName(a.project, a.chain)).
+		Select(`collection_address,
+			sum(case when epoch_number > ? then item_count else 0 end) as item_count,
+			sum(case when epoch_number > ? then volume else 0 end) as volume,
+			min(case when epoch_number > ? and floor_price > 0 then floor_price end) as floor_price,
+			sum(case when epoch_number <= ? then volume else 0 end) as pre_volume,
+			min(case when epoch_number <= ? and floor_price > 0 then floor_price end) as pre_floor_price`,
+			split, split, split, split, split).
+		Where("epoch_number > ? and epoch_number <= ?", split-epochs, curEpoch).
+		Group("collection_address").
+		Scan(&stats).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection ranking")
+	}
+
+	ranking := make([]tradestats.RankingItem, 0, len(stats))
+	for _, stat := range stats {
+		if stat.ItemCount == 0 {
+			continue
+		}
+		item := tradestats.RankingItem{
+			ContractAddress: strings.ToLower(stat.CollectionAddress),
+			ItemCount:       stat.ItemCount,
+			Volume:          stat.Volume,
+			VolumeChange:    changePercent(stat.PreVolume, stat.Volume),
+		}
+		if stat.PreFloorPrice.Valid {
+			item.PreFloorPrice = stat.PreFloorPrice.Decimal
+			if stat.FloorPrice.Valid {
+				item.FloorChange = changePercent(stat.PreFloorPrice.Decimal, stat.FloorPrice.Decimal)
+			}
+		}
+		ranking = append(ranking, item)
+	}
+	sort.SliceStable(ranking, func(i, j int) bool {
+		if !ranking[i].Volume.Equal(ranking[j].Volume) {
+			return ranking[i].Volume.GreaterThan(ranking[j].Volume)
+		}
+		return ranking[i].ContractAddress < ranking[j].ContractAddress
+	})
+
+	return ranking, nil
+}
+
+// changePercent 变化百分比, 上一个区间没有数据时返回 0
+func changePercent(pre, cur decimal.Decimal) int {
+	if !pre.IsPositive() {
+		return 0
+	}
+
+	return int(cur.Sub(pre).Mul(decimal.NewFromInt(100)).Div(pre).IntPart())
+}
+
+// UpdateVolumeTotals 汇总合集的累计成交额, 写入 Collection.VolumeTotal 与缓存, collections 为空时更新所有合集
+func (a *Aggregator) UpdateVolumeTotals(collections []string) error {
+	var totals []struct {
+		CollectionAddress string
+		Volume            decimal.Decimal
+	}
+	query := a.db.WithContext(a.ctx).Table(gdb.GetMultiProjectCollectionTradeTableName(a.project, a.chain)).
+		Select("collection_address, sum(volume) as volume").
+		Group("collection_address")
+	if len(collections) > 0 {
+		query = query.Where("collection_address in ?", collections)
+	}
+	if err := query.Scan(&totals).Error; err != nil {
+		return errors.Wrap(err, "failed on query collection volume total")
+	}
+
+	volumes := make(map[string]decimal.Decimal, len(collections))
+	for _, collection := range collections {
+		volumes[strings.ToLower(collection)] = decimal.Zero
+	}
+	for _, total := range totals {
+		volumes[strings.ToLower(total.CollectionAddress)] = total.Volume
+	}
+
+	for collection, volume := range volumes {
+		if err := a.db.WithContext(a.ctx).Table(gdb.GetMultiProjectCollectionTableName(a.project, a.chain)).
+			Where("address = ?", collection).
+			Update("volume_total", volume).Error; err != nil {
+			return errors.Wrap(err, "failed on update collection volume total")
+		}
+		if err := a.kv.Write(tradestats.CollectionVolumeKey(a.project, a.chain, collection),
+			tradestats.CollectionVolume{Volume: volume}); err != nil {
+			return errors.Wrap(err, "failed on cache collection volume total")
+		}
+	}
+
+	return nil
+}
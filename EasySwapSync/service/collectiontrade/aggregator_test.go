@@ -0,0 +1,203 @@
+package collectiontrade
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/ProjectsTask/EasySwapBase/tradestats"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"github.com/zeromicro/go-zero/core/stores/kv"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	testChain       = "sepolia"
+	testCollection  = "0xe7f1725e7734ce288f8367e1bb143e90bb3f0512"
+	otherCollection = "0x5fbdb2315678afecb367f032d93f642f64180aa3"
+)
+
+func newTestAggregator(t *testing.T) (*Aggregator, *gorm.DB, *xkv.Store) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "orderbook.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := []struct {
+		name  string
+		model interface{}
+	}{
+		{multi.ActivityTableName(testChain), &multi.Activity{}},
+		{multi.CollectionTableName(testChain), &multi.Collection{}},
+		{multi.CollectionTradeTableName(testChain), &multi.CollectionTrade{}},
+		{multi.CollectionFloorPriceTableName(testChain), &multi.CollectionFloorPrice{}},
+	}
+	for _, table := range tables {
+		if err := db.Table(table.name).AutoMigrate(table.model); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, address := range []string{testCollection, otherCollection} {
+		if err := db.Table(multi.CollectionTableName(testChain)).Create(&multi.Collection{Address: address}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := xkv.NewStore(kv.KvConf{{
+		RedisConf: redis.RedisConf{Host: miniredis.RunT(t).Addr(), Type: redis.NodeType},
+		Weight:    100,
+	}})
+	ctx := xzap.ToContext(context.Background(), zap.NewNop())
+
+	return New(ctx, db, store, testChain, gdb.OrderBookDexProject, Config{}), db, store
+}
+
+func createSale(t *testing.T, db *gorm.DB, collection string, eventTime, price int64, tx string) {
+	if err := db.Table(multi.ActivityTableName(testChain)).Create(&multi.Activity{
+		ActivityType:      multi.Sale,
+		CollectionAddress: collection,
+		TokenId:           tx,
+		Price:             decimal.NewFromInt(price),
+		TxHash:            tx,
+		EventTime:         eventTime,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAggregateAndRankings(t *testing.T) {
+	a, db, store := newTestAggregator(t)
+
+	now := time.Unix(1700000000, 0) // 位于周期 5666666 内
+	cur := now.Unix() / tradestats.EpochSeconds * tradestats.EpochSeconds
+	createSale(t, db, testCollection, cur+10, 300, "0x01")
+	createSale(t, db, testCollection, cur+20, 200, "0x02")
+	createSale(t, db, otherCollection, cur-tradestats.EpochSeconds, 1000, "0x03")
+	// 上一个 15m 区间的成交
+	createSale(t, db, testCollection, cur-4*tradestats.EpochSeconds, 100, "0x04")
+	if err := db.Table(multi.CollectionFloorPriceTableName(testChain)).Create(&multi.CollectionFloorPrice{
+		CollectionAddress: testCollection,
+		Price:             decimal.NewFromInt(150),
+		EventTime:         cur + 30,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	collections, err := a.Aggregate(cur-4*tradestats.EpochSeconds, now.Unix()+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collections) != 2 {
+		t.Fatalf("expected 2 touched collections, got %v", collections)
+	}
+
+	var trade multi.CollectionTrade
+	if err := db.Table(multi.CollectionTradeTableName(testChain)).
+		Where("collection_address = ? and epoch_number = ?", testCollection, cur/tradestats.EpochSeconds).
+		First(&trade).Error; err != nil {
+		t.Fatal(err)
+	}
+	if trade.ItemCount != 2 || !trade.Volume.Equal(decimal.NewFromInt(500)) || !trade.FloorPrice.Equal(decimal.NewFromInt(150)) {
+		t.Fatalf("unexpected trade %+v", trade)
+	}
+
+	// 重复统计结果不变
+	if _, err := a.Aggregate(cur-4*tradestats.EpochSeconds, now.Unix()+1); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	if err := db.Table(multi.CollectionTradeTableName(testChain)).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 trade rows, got %d", count)
+	}
+
+	if err := a.UpdateRankings(now); err != nil {
+		t.Fatal(err)
+	}
+	var ranking []tradestats.RankingItem
+	if _, err := store.Read(tradestats.RankingKey(gdb.OrderBookDexProject, testChain, tradestats.PeriodEpochs["15m"]), &ranking); err != nil {
+		t.Fatal(err)
+	}
+	if len(ranking) != 2 || ranking[0].ContractAddress != otherCollection || ranking[1].ContractAddress != testCollection {
+		t.Fatalf("unexpected 15m ranking %+v", ranking)
+	}
+	// 成交额 100 -> 500, 地板价 100 -> 150
+	if ranking[1].VolumeChange != 400 || ranking[1].FloorChange != 50 || !ranking[1].PreFloorPrice.Equal(decimal.NewFromInt(100)) {
+		t.Fatalf("unexpected ranking change %+v", ranking[1])
+	}
+
+	if err := a.UpdateVolumeTotals(collections); err != nil {
+		t.Fatal(err)
+	}
+	var collection multi.Collection
+	if err := db.Table(multi.CollectionTableName(testChain)).Where("address = ?", testCollection).First(&collection).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !collection.VolumeTotal.Equal(decimal.NewFromInt(600)) {
+		t.Fatalf("expected volume total 600, got %s", collection.VolumeTotal)
+	}
+	var volume tradestats.CollectionVolume
+	if _, err := store.Read(tradestats.CollectionVolumeKey(gdb.OrderBookDexProject, testChain, testCollection), &volume); err != nil {
+		t.Fatal(err)
+	}
+	if !volume.Volume.Equal(decimal.NewFromInt(600)) {
+		t.Fatalf("expected cached volume 600, got %s", volume.Volume)
+	}
+
+	// 区块重组删除成交后重新统计, 对应周期的数据被移除
+	if err := db.Table(multi.ActivityTableName(testChain)).Where("tx_hash = ?", "0x03").Delete(&multi.Activity{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	collections, err = a.Aggregate(cur-tradestats.EpochSeconds, cur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collections) != 1 || collections[0] != otherCollection {
+		t.Fatalf("expected reorged collection touched, got %v", collections)
+	}
+	if err := a.UpdateVolumeTotals(collections); err != nil {
+		t.Fatal(err)
+	}
+	var other multi.Collection
+	if err := db.Table(multi.CollectionTableName(testChain)).Where("address = ?", otherCollection).First(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !other.VolumeTotal.IsZero() {
+		t.Fatalf("expected volume total reset, got %s", other.VolumeTotal)
+	}
+}
+
+func TestRefreshCatchesUpLateSales(t *testing.T) {
+	a, db, store := newTestAggregator(t)
+
+	now := time.Now()
+	if err := a.Refresh(now); err != nil {
+		t.Fatal(err)
+	}
+
+	// 索引追赶时写入早于 Lookback 的成交
+	old := now.Add(-48 * time.Hour).Unix()
+	createSale(t, db, testCollection, old, 700, "0x01")
+	if err := a.Refresh(now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	var volume tradestats.CollectionVolume
+	ok, err := store.Read(tradestats.CollectionVolumeKey(gdb.OrderBookDexProject, testChain, testCollection), &volume)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || !volume.Volume.Equal(decimal.NewFromInt(700)) {
+		t.Fatalf("expected late sale counted, got %s", volume.Volume)
+	}
+}
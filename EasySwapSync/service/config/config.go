@@ -28,14 +28,72 @@ type Config struct {
 	DB          *gdb.Config      `toml:"db" mapstructure:"db" json:"db"`                               // 数据库配置
 	AnkrCfg     AnkrCfg          `toml:"ankr_cfg" mapstructure:"ankr_cfg" json:"ankr_cfg"`             // 区块链 RPC 配置
 	ChainCfg    ChainCfg         `toml:"chain_cfg" mapstructure:"chain_cfg" json:"chain_cfg"`          // 链配置
+	Chains      []ChainCfg       `toml:"chains" mapstructure:"chains" json:"chains"`                   // 多链配置，配置后忽略 chain_cfg/ankr_cfg
 	ContractCfg ContractCfg      `toml:"contract_cfg" mapstructure:"contract_cfg" json:"contract_cfg"` // 合约地址配置
 	ProjectCfg  ProjectCfg       `toml:"project_cfg" mapstructure:"project_cfg" json:"project_cfg"`    // 项目配置
+	Stats       *StatsCfg        `toml:"stats" mapstructure:"stats" json:"stats"`                      // 成交统计配置（排行榜、合集累计成交额）
+
+	MetadataRefresh *MetadataRefreshCfg `toml:"metadata_refresh" mapstructure:"metadata_refresh" json:"metadata_refresh"` // 元数据刷新配置
+	MetadataParse   *MetadataParse      `toml:"metadata_parse" mapstructure:"metadata_parse" json:"metadata_parse"`       // 元数据解析字段, 与 EasySwapBackend 保持一致
 }
 
 // ChainCfg 区块链配置
 type ChainCfg struct {
 	Name string `toml:"name" mapstructure:"name" json:"name"` // 链名称，如 "eth", "sepolia", "optimism"
 	ID   int64  `toml:"id" mapstructure:"id" json:"id"`       // 链 ID，如 1 (ETH), 11155111 (Sepolia)
+
+	// 多节点配置: 配置多个 RPC 节点时按区块高度与延迟路由到最健康的节点, 节点出错自动切换
+	// 为空时使用 ankr_cfg.https_url + api_key
+	Endpoints           []string `toml:"endpoints" mapstructure:"endpoints" json:"-"`                                             // RPC 节点地址列表（含 API Key，不输出到日志）
+	HealthCheckInterval int64    `toml:"health_check_interval" mapstructure:"health_check_interval" json:"health_check_interval"` // 节点健康检查间隔（秒），默认 10
+	MaxBlockLag         uint64   `toml:"max_block_lag" mapstructure:"max_block_lag" json:"max_block_lag"`                         // 落后最高区块超过该值的节点被隔离，默认 5
+
+	// 多链配置 [[chains]] 中每条链独立的 WebSocket、确认区块数与合约地址
+	WebsocketUrl  string       `toml:"websocket_url" mapstructure:"websocket_url" json:"-"`             // WebSocket RPC URL（含 API Key），用于流式同步
+	EnableWss     bool         `toml:"enable_wss" mapstructure:"enable_wss" json:"enable_wss"`          // 是否启用 WebSocket 流式同步
+	Confirmations uint64       `toml:"confirmations" mapstructure:"confirmations" json:"confirmations"` // 确认区块数，0 使用内置默认值
+	StartBlock    uint64       `toml:"start_block" mapstructure:"start_block" json:"start_block"`       // 首次同步的起始区块（合约部署区块），migrate 与首次同步初始化 ob_indexed_status 时必填
+	ContractCfg   *ContractCfg `toml:"contract_cfg" mapstructure:"contract_cfg" json:"contract_cfg"`    // 该链的合约地址，为空时使用全局 contract_cfg
+}
+
+// ChainList 返回需要同步的链
+// 配置了 [[chains]] 时按多链配置; 否则由 [chain_cfg]、[ankr_cfg]、[contract_cfg] 组成单链配置, 兼容旧配置文件
+func (c *Config) ChainList() []ChainCfg {
+	if len(c.Chains) > 0 {
+		chains := make([]ChainCfg, 0, len(c.Chains))
+		for _, chain := range c.Chains {
+			if chain.ContractCfg == nil {
+				chain.ContractCfg = &c.ContractCfg
+			}
+			chains = append(chains, chain)
+		}
+		return chains
+	}
+
+	chain := c.ChainCfg
+	if len(chain.Endpoints) == 0 {
+		chain.Endpoints = []string{c.AnkrCfg.HttpsUrl + c.AnkrCfg.ApiKey}
+	}
+	if chain.WebsocketUrl == "" && c.AnkrCfg.EnableWss && c.AnkrCfg.WebsocketUrl != "" {
+		chain.WebsocketUrl = c.AnkrCfg.WebsocketUrl + c.AnkrCfg.ApiKey
+		chain.EnableWss = true
+	}
+	if chain.ContractCfg == nil {
+		chain.ContractCfg = &c.ContractCfg
+	}
+
+	return []ChainCfg{chain}
+}
+
+// ForChain 返回单条链使用的配置副本, chain_cfg 与 contract_cfg 替换为该链的配置
+func (c *Config) ForChain(chain ChainCfg) *Config {
+	cfg := *c
+	cfg.ChainCfg = chain
+	if chain.ContractCfg != nil {
+		cfg.ContractCfg = *chain.ContractCfg
+	}
+
+	return &cfg
 }
 
 // ContractCfg 智能合约地址配置
@@ -48,8 +106,12 @@ type ContractCfg struct {
 
 // Monitor 监控配置
 type Monitor struct {
-	PprofEnable bool  `toml:"pprof_enable" mapstructure:"pprof_enable" json:"pprof_enable"` // 是否启用 pprof 性能分析
-	PprofPort   int64 `toml:"pprof_port" mapstructure:"pprof_port" json:"pprof_port"`       // pprof HTTP 端口，如 6060
+	PprofEnable      bool  `toml:"pprof_enable" mapstructure:"pprof_enable" json:"pprof_enable"`                // 是否启用 pprof 性能分析
+	PprofPort        int64 `toml:"pprof_port" mapstructure:"pprof_port" json:"pprof_port"`                      // pprof HTTP 端口，如 6060
+	PrometheusEnable bool  `toml:"prometheus_enable" mapstructure:"prometheus_enable" json:"prometheus_enable"` // 是否暴露 Prometheus 指标（/metrics），包含 RPC 节点健康状态
+	PrometheusPort   int   `toml:"prometheus_port" mapstructure:"prometheus_port" json:"prometheus_port"`       // Prometheus 指标端口，如 9101
+	StatusEnable     bool  `toml:"status_enable" mapstructure:"status_enable" json:"status_enable"`             // 是否开启同步状态查询（/status、/metadata-refresh）
+	StatusPort       int   `toml:"status_port" mapstructure:"status_port" json:"status_port"`                   // 同步状态查询端口，如 6061
 }
 
 // AnkrCfg 区块链 RPC 节点配置
@@ -66,6 +128,38 @@ type ProjectCfg struct {
 	Name string `toml:"name" mapstructure:"name" json:"name"` // 项目名称，用于区分多个项目的数据
 }
 
+// StatsCfg 成交统计配置, 未配置或为 0 时使用默认值
+type StatsCfg struct {
+	Interval int64 `toml:"interval" mapstructure:"interval" json:"interval"` // 统计间隔（秒），默认 60
+	Lookback int64 `toml:"lookback" mapstructure:"lookback" json:"lookback"` // 每次重算最近多长时间内的成交（秒），默认 3600
+}
+
+// MetadataRefreshCfg 元数据刷新配置, 未配置或为 0 时使用默认值
+type MetadataRefreshCfg struct {
+	Concurrency  int   `toml:"concurrency" mapstructure:"concurrency" json:"concurrency"`       // 每条链同时刷新的 NFT 数量，默认 8
+	MaxRetries   int   `toml:"max_retries" mapstructure:"max_retries" json:"max_retries"`       // 失败后的最大重试次数，默认 5，为负数时不重试
+	RetryBackoff int64 `toml:"retry_backoff" mapstructure:"retry_backoff" json:"retry_backoff"` // 首次重试的等待时间（秒），之后每次翻倍，默认 30
+	PollInterval int64 `toml:"poll_interval" mapstructure:"poll_interval" json:"poll_interval"` // 队列为空时的轮询间隔（秒），默认 5
+}
+
+// MetadataParse 元数据 JSON 中名称、图片与属性对应的字段
+type MetadataParse struct {
+	NameTags       []string `toml:"name_tags" mapstructure:"name_tags" json:"name_tags"`
+	ImageTags      []string `toml:"image_tags" mapstructure:"image_tags" json:"image_tags"`
+	AttributesTags []string `toml:"attributes_tags" mapstructure:"attributes_tags" json:"attributes_tags"`
+	TraitNameTags  []string `toml:"trait_name_tags" mapstructure:"trait_name_tags" json:"trait_name_tags"`
+	TraitValueTags []string `toml:"trait_value_tags" mapstructure:"trait_value_tags" json:"trait_value_tags"`
+}
+
+// DefaultMetadataParse 未配置 metadata_parse 时使用的解析字段
+var DefaultMetadataParse = MetadataParse{
+	NameTags:       []string{"name", "title"},
+	ImageTags:      []string{"image", "image_url", "animation_url", "media_url", "image_data", "imageUrl"},
+	AttributesTags: []string{"attributes", "properties", "attribute"},
+	TraitNameTags:  []string{"trait_type"},
+	TraitValueTags: []string{"value"},
+}
+
 // KvConf Redis 缓存配置
 type KvConf struct {
 	Redis []*Redis `toml:"redis" json:"redis"` // Redis 节点列表，支持集群模式
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChainListLegacy(t *testing.T) {
+	c := &Config{
+		ChainCfg:    ChainCfg{Name: "sepolia", ID: 11155111},
+		AnkrCfg:     AnkrCfg{ApiKey: "key", HttpsUrl: "https://rpc/", WebsocketUrl: "wss://rpc/", EnableWss: true},
+		ContractCfg: ContractCfg{DexAddress: "0xdex"},
+	}
+
+	chains := c.ChainList()
+	if len(chains) != 1 {
+		t.Fatalf("expected 1 chain, got %d", len(chains))
+	}
+	chain := chains[0]
+	if len(chain.Endpoints) != 1 || chain.Endpoints[0] != "https://rpc/key" {
+		t.Fatalf("unexpected endpoints %v", chain.Endpoints)
+	}
+	if !chain.EnableWss || chain.WebsocketUrl != "wss://rpc/key" {
+		t.Fatalf("unexpected websocket config %v %s", chain.EnableWss, chain.WebsocketUrl)
+	}
+	if c.ForChain(chain).ContractCfg.DexAddress != "0xdex" {
+		t.Fatalf("unexpected contract config")
+	}
+}
+
+func TestChainListMultiChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(`
+[contract_cfg]
+dex_address = "0xdefault"
+
+[[chains]]
+name = "sepolia"
+id = 11155111
+endpoints = ["https://a", "https://b"]
+
+[[chains]]
+name = "base"
+id = 8453
+endpoints = ["https://c"]
+confirmations = 12
+[chains.contract_cfg]
+dex_address = "0xbase"
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := UnmarshalConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chains := c.ChainList()
+	if len(chains) != 2 {
+		t.Fatalf("expected 2 chains, got %d", len(chains))
+	}
+	if len(chains[0].Endpoints) != 2 || c.ForChain(chains[0]).ContractCfg.DexAddress != "0xdefault" {
+		t.Fatalf("unexpected sepolia config %+v", chains[0])
+	}
+	base := c.ForChain(chains[1])
+	if base.ChainCfg.Confirmations != 12 || base.ContractCfg.DexAddress != "0xbase" {
+		t.Fatalf("unexpected base config %+v", base.ChainCfg)
+	}
+	// 每条链的配置副本互不影响
+	if c.ContractCfg.DexAddress != "0xdefault" {
+		t.Fatalf("global contract config changed to %s", c.ContractCfg.DexAddress)
+	}
+}
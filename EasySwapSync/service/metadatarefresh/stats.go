@@ -0,0 +1,86 @@
+package metadatarefresh
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ProjectsTask/EasySwapBase/refreshqueue"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/pkg/errors"
+)
+
+const statLastTime = "last_refresh_time"
+
+// CollectionStats 单个合集的刷新统计
+type CollectionStats struct {
+	CollectionAddress string `json:"collection_address"`
+	Succeeded         int64  `json:"succeeded"`
+	Retried           int64  `json:"retried"`
+	Failed            int64  `json:"failed"` // 超过重试次数后放弃的数量
+	LastRefreshTime   string `json:"last_refresh_time"`
+}
+
+// Stats 单条链的刷新队列状态
+type Stats struct {
+	Chain       string            `json:"chain"`
+	Pending     int64             `json:"pending"`  // 刷新队列中等待处理的数量
+	Retrying    int               `json:"retrying"` // 等待重试的数量
+	Collections []CollectionStats `json:"collections"`
+}
+
+// ReadStats 读取链的刷新队列长度与按合集统计的刷新结果
+func ReadStats(kv *xkv.Store, project, chain string) (*Stats, error) {
+	pending, err := kv.Scard(refreshqueue.Key(project, chain))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on count metadata refresh queue")
+	}
+	retrying, err := kv.Zcard(refreshqueue.RetryKey(project, chain))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on count metadata refresh retries")
+	}
+	fields, err := kv.Hgetall(refreshqueue.StatsKey(project, chain))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get metadata refresh stats")
+	}
+
+	collections := make(map[string]*CollectionStats)
+	for field, value := range fields {
+		idx := strings.LastIndex(field, ":")
+		if idx <= 0 {
+			continue
+		}
+		addr, name := field[:idx], field[idx+1:]
+		stat, ok := collections[addr]
+		if !ok {
+			stat = &CollectionStats{CollectionAddress: addr}
+			collections[addr] = stat
+		}
+		count, _ := strconv.ParseInt(value, 10, 64)
+		switch name {
+		case ResultSucceeded:
+			stat.Succeeded = count
+		case ResultRetried:
+			stat.Retried = count
+		case ResultFailed:
+			stat.Failed = count
+		case statLastTime:
+			stat.LastRefreshTime = value
+		}
+	}
+
+	stats := &Stats{
+		Chain:       chain,
+		Pending:     pending,
+		Retrying:    retrying,
+		Collections: make([]CollectionStats, 0, len(collections)),
+	}
+	for _, stat := range collections {
+		stats.Collections = append(stats.Collections, *stat)
+	}
+	sort.Slice(stats.Collections, func(i, j int) bool {
+		return stats.Collections[i].CollectionAddress < stats.Collections[j].CollectionAddress
+	})
+
+	return stats, nil
+}
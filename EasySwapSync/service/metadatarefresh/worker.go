@@ -0,0 +1,363 @@
+package metadatarefresh
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/nftchainservice"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/refreshqueue"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/pkg/errors"
+	"github.com/zeromicro/go-zero/core/metric"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"github.com/zeromicro/go-zero/core/threading"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultConcurrency  = 8
+	defaultMaxRetries   = 5
+	defaultRetryBackoff = 30 * time.Second
+	defaultPollInterval = 5 * time.Second
+	maxRetryBackoff     = time.Hour
+
+	maxUriLength = 512 // meta_data_uri / image_uri 字段长度
+)
+
+// 刷新结果
+const (
+	ResultSucceeded = "succeeded"
+	ResultRetried   = "retried"
+	ResultFailed    = "failed"
+)
+
+// errUriTooLong uri 超出字段长度 (如 base64 内联图片), 截断后无法使用, 重试也不会成功
+var errUriTooLong = errors.New("uri exceeds column length")
+
+var metricRefreshTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: "sync",
+	Subsystem: "metadata",
+	Name:      "refresh_total",
+	Help:      "metadata refresh results, result is succeeded, retried or failed",
+	Labels:    []string{"chain", "result"},
+})
+
+// Fetcher 读取链上 tokenURI 并解析元数据, 由 nftchainservice.Service 实现
+type Fetcher interface {
+	FetchOnChainMetadataWithUri(collectionAddr string, tokenID string) (*nftchainservice.JsonMetadata, string, error)
+}
+
+// Config 元数据刷新配置, 为 0 时使用默认值
+type Config struct {
+	Concurrency  int           // 同时刷新的 NFT 数量
+	MaxRetries   int           // 失败后的最大重试次数, 超过后标记为获取元数据失败, 为负数时不重试
+	RetryBackoff time.Duration // 首次重试的等待时间, 之后每次翻倍
+	PollInterval time.Duration // 队列为空时的轮询间隔
+}
+
+// Worker 消费 EasySwapBackend 写入的元数据刷新队列, 重新获取链上元数据并更新 ob_item_external 与 ob_item_trait
+// 任务通过 SPOP/ZREM 认领, 多实例部署时同一任务只会被一个实例处理
+type Worker struct {
+	ctx     context.Context
+	db      *gorm.DB
+	kv      *xkv.Store
+	fetcher Fetcher
+	chain   string
+	project string
+	conf    Config
+}
+
+// New 创建元数据刷新任务
+func New(ctx context.Context, db *gorm.DB, kv *xkv.Store, fetcher Fetcher, chain string, project string, conf Config) *Worker {
+	if conf.Concurrency <= 0 {
+		conf.Concurrency = defaultConcurrency
+	}
+	if conf.MaxRetries < 0 {
+		conf.MaxRetries = 0
+	} else if conf.MaxRetries == 0 {
+		conf.MaxRetries = defaultMaxRetries
+	}
+	if conf.RetryBackoff <= 0 {
+		conf.RetryBackoff = defaultRetryBackoff
+	}
+	if conf.PollInterval <= 0 {
+		conf.PollInterval = defaultPollInterval
+	}
+
+	return &Worker{
+		ctx:     ctx,
+		db:      db,
+		kv:      kv,
+		fetcher: fetcher,
+		chain:   chain,
+		project: project,
+		conf:    conf,
+	}
+}
+
+// Start 启动刷新循环, 上下文取消时退出
+func (w *Worker) Start() {
+	threading.GoSafe(w.loop)
+}
+
+func (w *Worker) loop() {
+	ticker := time.NewTicker(w.conf.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Drain(); err != nil {
+			xzap.WithContext(w.ctx).Error("failed on refresh metadata",
+				zap.Error(err), zap.String("chain", w.chain))
+		}
+
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain 处理刷新队列与已到期的重试任务, 直到两者都为空
+func (w *Worker) Drain() error {
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil
+		default:
+		}
+
+		items, err := w.claim(w.conf.Concurrency)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		var wg sync.WaitGroup
+		for _, item := range items {
+			wg.Add(1)
+			go func(item refreshqueue.Item) {
+				defer wg.Done()
+				w.refresh(item)
+			}(item)
+		}
+		wg.Wait()
+	}
+}
+
+// claim 认领最多 limit 个任务, 优先处理已到期的重试任务
+func (w *Worker) claim(limit int) ([]refreshqueue.Item, error) {
+	var items []refreshqueue.Item
+
+	pairs, err := w.kv.ZrangebyscoreWithScoresAndLimit(refreshqueue.RetryKey(w.project, w.chain), 0, time.Now().Unix(), 0, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query metadata refresh retries")
+	}
+	for _, pair := range pairs {
+		removed, err := w.kv.Zrem(refreshqueue.RetryKey(w.project, w.chain), pair.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on claim metadata refresh retry")
+		}
+		if removed == 0 { // 已被其他实例认领
+			continue
+		}
+		if item, ok := w.decode(pair.Key); ok {
+			items = append(items, item)
+		}
+	}
+
+	for len(items) < limit {
+		raw, err := w.kv.Spop(refreshqueue.Key(w.project, w.chain))
+		if err == redis.Nil {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on pop metadata refresh queue")
+		}
+		if item, ok := w.decode(raw); ok {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+func (w *Worker) decode(raw string) (refreshqueue.Item, bool) {
+	var item refreshqueue.Item
+	if err := json.Unmarshal([]byte(raw), &item); err != nil || item.CollectionAddr == "" || item.TokenID == "" {
+		xzap.WithContext(w.ctx).Warn("invalid metadata refresh item", zap.String("item", raw), zap.Error(err))
+		return item, false
+	}
+
+	return item, true
+}
+
+// refresh 刷新单个 NFT 的元数据, 失败时按指数退避重新入队, 超过重试次数或 uri 超长时标记为获取元数据失败
+func (w *Worker) refresh(item refreshqueue.Item) {
+	err := w.update(item)
+	if err == nil {
+		w.record(item.CollectionAddr, ResultSucceeded)
+		return
+	}
+
+	item.Attempts++
+	fields := []zap.Field{zap.String("chain", w.chain), zap.String("collection_addr", item.CollectionAddr),
+		zap.String("token_id", item.TokenID), zap.Int("attempts", item.Attempts), zap.Error(err)}
+	if item.Attempts > w.conf.MaxRetries || errors.Is(err, errUriTooLong) {
+		xzap.WithContext(w.ctx).Warn("metadata refresh failed, give up", fields...)
+		if err := w.markFailed(item); err != nil {
+			xzap.WithContext(w.ctx).Error("failed on mark metadata failed", append(fields, zap.NamedError("mark_error", err))...)
+		}
+		w.record(item.CollectionAddr, ResultFailed)
+		return
+	}
+
+	xzap.WithContext(w.ctx).Info("metadata refresh failed, retry later", fields...)
+	if err := w.retry(item); err != nil {
+		xzap.WithContext(w.ctx).Error("failed on add metadata refresh retry", append(fields, zap.NamedError("retry_error", err))...)
+	}
+	w.record(item.CollectionAddr, ResultRetried)
+}
+
+func (w *Worker) retry(item refreshqueue.Item) error {
+	backoff := w.conf.RetryBackoff << (item.Attempts - 1)
+	if backoff > maxRetryBackoff || backoff <= 0 {
+		backoff = maxRetryBackoff
+	}
+	raw, err := json.Marshal(&item)
+	if err != nil {
+		return errors.Wrap(err, "failed on marshal metadata refresh item")
+	}
+	if _, err := w.kv.Zadd(refreshqueue.RetryKey(w.project, w.chain), time.Now().Add(backoff).Unix(), string(raw)); err != nil {
+		return errors.Wrap(err, "failed on add metadata refresh retry")
+	}
+
+	return nil
+}
+
+// update 获取链上元数据并在同一事务内更新 item 名称、item_external 与 item_trait
+func (w *Worker) update(item refreshqueue.Item) error {
+	metadata, tokenUri, err := w.fetcher.FetchOnChainMetadataWithUri(item.CollectionAddr, item.TokenID)
+	if err != nil {
+		return err
+	}
+
+	var traits []multi.ItemTrait
+	for _, attr := range metadata.Attributes {
+		if attr == nil || attr.TraitType == "" {
+			continue
+		}
+		traits = append(traits, multi.ItemTrait{
+			CollectionAddress: item.CollectionAddr,
+			TokenId:           item.TokenID,
+			Trait:             attr.TraitType,
+			TraitValue:        attr.Value,
+		})
+	}
+	// 超长的 uri 截断后无法使用, 不更新并保留原有的元数据
+	if len(tokenUri) > maxUriLength {
+		return errors.Wrapf(errUriTooLong, "meta_data_uri length %d", len(tokenUri))
+	}
+	imageUri := metadata.Image
+	if len(imageUri) > maxUriLength {
+		return errors.Wrapf(errUriTooLong, "image_uri length %d", len(imageUri))
+	}
+	now := time.Now().UnixMilli()
+
+	return w.db.WithContext(w.ctx).Transaction(func(tx *gorm.DB) error {
+		if metadata.Name != "" {
+			if err := tx.Table(gdb.GetMultiProjectItemTableName(w.project, w.chain)).
+				Where("collection_address = ? and token_id = ?", item.CollectionAddr, item.TokenID).
+				Updates(map[string]interface{}{"name": metadata.Name, "update_time": now}).Error; err != nil {
+				return errors.Wrap(err, "failed on update item name")
+			}
+		}
+
+		var existing []multi.ItemExternal
+		if err := tx.Table(gdb.GetMultiProjectItemExternalTableName(w.project, w.chain)).
+			Select("image_uri").
+			Where("collection_address = ? and token_id = ?", item.CollectionAddr, item.TokenID).
+			Limit(1).
+			Find(&existing).Error; err != nil {
+			return errors.Wrap(err, "failed on query item external")
+		}
+		externalUpdates := []string{"meta_data_uri", "image_uri", "upload_status", "update_time"}
+		if len(existing) > 0 && existing[0].ImageUri != imageUri {
+			// 图片变化后需要重新上传
+			externalUpdates = append(externalUpdates, "is_uploaded_oss", "oss_uri")
+		}
+		itemExternal := map[string]interface{}{
+			"collection_address":  item.CollectionAddr,
+			"token_id":            item.TokenID,
+			"meta_data_uri":       tokenUri,
+			"image_uri":           imageUri,
+			"is_uploaded_oss":     false,
+			"oss_uri":             "",
+			"upload_status":       multi.OK,
+			"is_video_uploaded":   false,
+			"video_upload_status": 0,
+			"video_type":          "0",
+			"create_time":         now,
+			"update_time":         now,
+		}
+		if err := tx.Table(gdb.GetMultiProjectItemExternalTableName(w.project, w.chain)).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "collection_address"}, {Name: "token_id"}},
+			DoUpdates: clause.AssignmentColumns(externalUpdates),
+		}).Create(&itemExternal).Error; err != nil {
+			return errors.Wrap(err, "failed on upsert item external")
+		}
+
+		if err := tx.Table(gdb.GetMultiProjectItemTraitTableName(w.project, w.chain)).
+			Where("collection_address = ? and token_id = ?", item.CollectionAddr, item.TokenID).
+			Delete(&multi.ItemTrait{}).Error; err != nil {
+			return errors.Wrap(err, "failed on delete item traits")
+		}
+		if len(traits) == 0 {
+			return nil
+		}
+		if err := tx.Table(gdb.GetMultiProjectItemTraitTableName(w.project, w.chain)).Create(&traits).Error; err != nil {
+			return errors.Wrap(err, "failed on create item traits")
+		}
+
+		return nil
+	})
+}
+
+// markFailed 标记为获取元数据失败, 保留原有的元数据与属性
+func (w *Worker) markFailed(item refreshqueue.Item) error {
+	if err := w.db.WithContext(w.ctx).Table(gdb.GetMultiProjectItemExternalTableName(w.project, w.chain)).
+		Where("collection_address = ? and token_id = ?", item.CollectionAddr, item.TokenID).
+		Updates(map[string]interface{}{
+			"upload_status": multi.FetchMetadataFailed,
+			"update_time":   time.Now().UnixMilli(),
+		}).Error; err != nil {
+		return errors.Wrap(err, "failed on update item external status")
+	}
+
+	return nil
+}
+
+// record 记录合集的刷新结果, 统计写入 Redis 供 /metadata-refresh 查询
+func (w *Worker) record(collectionAddr, result string) {
+	metricRefreshTotal.Inc(w.chain, result)
+
+	collectionAddr = strings.ToLower(collectionAddr)
+	key := refreshqueue.StatsKey(w.project, w.chain)
+	if _, err := w.kv.Hincrby(key, collectionAddr+":"+result, 1); err != nil {
+		xzap.WithContext(w.ctx).Warn("failed on record metadata refresh stats", zap.Error(err))
+		return
+	}
+	if err := w.kv.Hset(key, collectionAddr+":"+statLastTime, time.Now().Format(time.RFC3339)); err != nil {
+		xzap.WithContext(w.ctx).Warn("failed on record metadata refresh stats", zap.Error(err))
+	}
+}
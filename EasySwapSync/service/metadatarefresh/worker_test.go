@@ -0,0 +1,262 @@
+package metadatarefresh
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/nftchainservice"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/refreshqueue"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glebarez/sqlite"
+	"github.com/pkg/errors"
+	"github.com/zeromicro/go-zero/core/stores/kv"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	testChain      = "sepolia"
+	testCollection = "0xe7f1725e7734ce288f8367e1bb143e90bb3f0512"
+)
+
+// fakeFetcher 按 token ID 返回预设的元数据, 未预设的 token 返回错误
+type fakeFetcher map[string]*nftchainservice.JsonMetadata
+
+func (f fakeFetcher) FetchOnChainMetadataWithUri(collectionAddr string, tokenID string) (*nftchainservice.JsonMetadata, string, error) {
+	metadata, ok := f[tokenID]
+	if !ok {
+		return nil, "", errors.New("token uri not found")
+	}
+	return metadata, "ipfs://meta/" + tokenID, nil
+}
+
+func newTestWorker(t *testing.T, fetcher Fetcher, conf Config) (*Worker, *gorm.DB, *xkv.Store) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "orderbook.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := []struct {
+		name  string
+		model interface{}
+	}{
+		{multi.ItemTableName(testChain), &multi.Item{}},
+		{multi.ItemExternalTableName(testChain), &multi.ItemExternal{}},
+		{multi.ItemTraitTableName(testChain), &multi.ItemTrait{}},
+	}
+	for _, table := range tables {
+		if err := db.Table(table.name).AutoMigrate(table.model); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 与 db/migrations/chain 中的唯一索引保持一致
+	if err := db.Exec("create unique index item_external_index on ob_item_external_sepolia (collection_address, token_id)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	store := xkv.NewStore(kv.KvConf{{
+		RedisConf: redis.RedisConf{Host: miniredis.RunT(t).Addr(), Type: redis.NodeType},
+		Weight:    100,
+	}})
+	ctx := xzap.ToContext(context.Background(), zap.NewNop())
+
+	return New(ctx, db, store, fetcher, testChain, gdb.OrderBookDexProject, conf), db, store
+}
+
+func enqueue(t *testing.T, store *xkv.Store, tokenID string) {
+	raw, err := json.Marshal(&refreshqueue.Item{ChainID: 11155111, CollectionAddr: testCollection, TokenID: tokenID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Sadd(refreshqueue.Key(gdb.OrderBookDexProject, testChain), string(raw)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDrainRefreshesMetadata(t *testing.T) {
+	fetcher := fakeFetcher{
+		"1": {
+			Name:  "Token #1",
+			Image: "ipfs://image/1",
+			Attributes: []*nftchainservice.OpenseaMetadataProps{
+				{TraitType: "Background", Value: "Blue"},
+				{TraitType: "Eyes", Value: "Laser"},
+			},
+		},
+	}
+	w, db, store := newTestWorker(t, fetcher, Config{Concurrency: 2})
+
+	if err := db.Table(multi.ItemTableName(testChain)).Create(&multi.Item{CollectionAddress: testCollection, TokenId: "1"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 旧的属性与已上传的图片在刷新后被替换
+	if err := db.Table(multi.ItemExternalTableName(testChain)).Create(&multi.ItemExternal{
+		CollectionAddress: testCollection,
+		TokenId:           "1",
+		ImageUri:          "ipfs://image/old",
+		IsUploadedOss:     true,
+		OssUri:            "https://oss/old.png",
+		UploadStatus:      multi.FetchMetadataFailed,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Table(multi.ItemTraitTableName(testChain)).Create(&multi.ItemTrait{
+		CollectionAddress: testCollection, TokenId: "1", Trait: "Background", TraitValue: "Red",
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	enqueue(t, store, "1")
+	if err := w.Drain(); err != nil {
+		t.Fatal(err)
+	}
+
+	var item multi.Item
+	if err := db.Table(multi.ItemTableName(testChain)).Where("token_id = ?", "1").First(&item).Error; err != nil {
+		t.Fatal(err)
+	}
+	if item.Name != "Token #1" {
+		t.Fatalf("expected item name updated, got %q", item.Name)
+	}
+
+	var external multi.ItemExternal
+	if err := db.Table(multi.ItemExternalTableName(testChain)).Where("token_id = ?", "1").First(&external).Error; err != nil {
+		t.Fatal(err)
+	}
+	if external.MetaDataUri != "ipfs://meta/1" || external.ImageUri != "ipfs://image/1" ||
+		external.UploadStatus != multi.OK || external.IsUploadedOss || external.OssUri != "" {
+		t.Fatalf("unexpected item external %+v", external)
+	}
+
+	var traits []multi.ItemTrait
+	if err := db.Table(multi.ItemTraitTableName(testChain)).Where("token_id = ?", "1").Order("trait").Find(&traits).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(traits) != 2 || traits[0].TraitValue != "Blue" || traits[1].Trait != "Eyes" {
+		t.Fatalf("unexpected traits %+v", traits)
+	}
+
+	stats, err := ReadStats(store, gdb.OrderBookDexProject, testChain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Pending != 0 || len(stats.Collections) != 1 || stats.Collections[0].Succeeded != 1 || stats.Collections[0].LastRefreshTime == "" {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestDrainRetriesFailures(t *testing.T) {
+	w, db, store := newTestWorker(t, fakeFetcher{}, Config{MaxRetries: 1, RetryBackoff: time.Minute})
+
+	if err := db.Table(multi.ItemExternalTableName(testChain)).Create(&multi.ItemExternal{
+		CollectionAddress: testCollection,
+		TokenId:           "2",
+		MetaDataUri:       "ipfs://meta/old",
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	enqueue(t, store, "2")
+	if err := w.Drain(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一次失败后进入重试队列, 未到重试时间前不会被处理
+	pairs, err := store.ZrangebyscoreWithScores(refreshqueue.RetryKey(gdb.OrderBookDexProject, testChain), 0, time.Now().Add(time.Hour).Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 1 || pairs[0].Score < time.Now().Add(50*time.Second).Unix() {
+		t.Fatalf("expected one delayed retry, got %+v", pairs)
+	}
+	var retry refreshqueue.Item
+	if err := json.Unmarshal([]byte(pairs[0].Key), &retry); err != nil {
+		t.Fatal(err)
+	}
+	if retry.Attempts != 1 || retry.TokenID != "2" {
+		t.Fatalf("unexpected retry item %+v", retry)
+	}
+
+	// 到期后再次失败, 超过重试次数, 标记为获取元数据失败
+	if _, err := store.Zadd(refreshqueue.RetryKey(gdb.OrderBookDexProject, testChain), time.Now().Unix()-1, pairs[0].Key); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Drain(); err != nil {
+		t.Fatal(err)
+	}
+
+	retrying, err := store.Zcard(refreshqueue.RetryKey(gdb.OrderBookDexProject, testChain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retrying != 0 {
+		t.Fatalf("expected retry queue empty, got %d", retrying)
+	}
+	var external multi.ItemExternal
+	if err := db.Table(multi.ItemExternalTableName(testChain)).Where("token_id = ?", "2").First(&external).Error; err != nil {
+		t.Fatal(err)
+	}
+	if external.UploadStatus != multi.FetchMetadataFailed || external.MetaDataUri != "ipfs://meta/old" {
+		t.Fatalf("unexpected item external %+v", external)
+	}
+
+	stats, err := ReadStats(store, gdb.OrderBookDexProject, testChain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Collections) != 1 || stats.Collections[0].Retried != 1 || stats.Collections[0].Failed != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestDrainSkipsLongUri(t *testing.T) {
+	fetcher := fakeFetcher{
+		"3": {Name: "Token #3", Image: "data:image/svg+xml;base64," + strings.Repeat("A", maxUriLength)},
+	}
+	w, db, store := newTestWorker(t, fetcher, Config{MaxRetries: 3})
+
+	if err := db.Table(multi.ItemExternalTableName(testChain)).Create(&multi.ItemExternal{
+		CollectionAddress: testCollection,
+		TokenId:           "3",
+		ImageUri:          "ipfs://image/old",
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	enqueue(t, store, "3")
+	if err := w.Drain(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 超长 uri 不写入也不重试, 保留原有数据并标记为获取元数据失败
+	retrying, err := store.Zcard(refreshqueue.RetryKey(gdb.OrderBookDexProject, testChain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retrying != 0 {
+		t.Fatalf("expected no retry, got %d", retrying)
+	}
+	var external multi.ItemExternal
+	if err := db.Table(multi.ItemExternalTableName(testChain)).Where("token_id = ?", "3").First(&external).Error; err != nil {
+		t.Fatal(err)
+	}
+	if external.UploadStatus != multi.FetchMetadataFailed || external.ImageUri != "ipfs://image/old" {
+		t.Fatalf("unexpected item external %+v", external)
+	}
+
+	stats, err := ReadStats(store, gdb.OrderBookDexProject, testChain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Collections) != 1 || stats.Collections[0].Failed != 1 || stats.Collections[0].Retried != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,247 @@
+package orderbookindexer
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/nftapproval"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ERC721 ApprovalForAll: 全部授权事件, approved 在 data 中
+const ERC721ApprovalForAllTopic = "0x17307eab39ab6107e8899845ad3d59bd9653f200f220920489ca2b5937696c31"
+
+// handleApprovalEvent 处理 ERC721 授权事件 (Approval)
+// Topics: [Signature, Owner, Approved, TokenId], approved 为零地址表示撤销
+func (s *Service) handleApprovalEvent(b *eventBatch, log ethereumTypes.Log) error {
+	// ERC20 Approval 的 topic 相同, 只有 3 个 topic
+	if !s.isTrackedCollectionLog(log, 4) {
+		return nil
+	}
+
+	owner := strings.ToLower(common.BytesToAddress(log.Topics[1].Bytes()).String())
+	approved := strings.ToLower(common.BytesToAddress(log.Topics[2].Bytes()).String())
+	tokenId := new(big.Int).SetBytes(log.Topics[3].Bytes()).String()
+	collection := strings.ToLower(log.Address.String())
+
+	// 单个 token 同一时间只有一个授权地址, 先撤销其他地址的授权
+	cleared, err := s.clearTokenApprovals(b.tx, log.BlockNumber, collection, tokenId, approved)
+	if err != nil {
+		return err
+	}
+	if approved != ZeroAddress {
+		if err := s.setApprovalWithChange(b.tx, log.BlockNumber, &multi.NftApproval{
+			CollectionAddress: collection,
+			TokenId:           tokenId,
+			Owner:             owner,
+			Operator:          approved,
+		}, true); err != nil {
+			return err
+		}
+	}
+
+	if !strings.EqualFold(approved, s.vaultAddress) && !containsFold(cleared, s.vaultAddress) {
+		return nil
+	}
+	return s.refreshListingsFillable(b, log.BlockNumber, collection, owner, tokenId)
+}
+
+// handleApprovalForAllEvent 处理 ERC721 全部授权事件 (ApprovalForAll)
+// Topics: [Signature, Owner, Operator], Data: approved
+func (s *Service) handleApprovalForAllEvent(b *eventBatch, log ethereumTypes.Log) error {
+	if !s.isTrackedCollectionLog(log, 3) || len(log.Data) < 32 {
+		return nil
+	}
+
+	owner := strings.ToLower(common.BytesToAddress(log.Topics[1].Bytes()).String())
+	operator := strings.ToLower(common.BytesToAddress(log.Topics[2].Bytes()).String())
+	approved := new(big.Int).SetBytes(log.Data[:32]).Sign() != 0
+	collection := strings.ToLower(log.Address.String())
+
+	if err := s.setApprovalWithChange(b.tx, log.BlockNumber, &multi.NftApproval{
+		CollectionAddress: collection,
+		Owner:             owner,
+		Operator:          operator,
+	}, approved); err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(operator, s.vaultAddress) {
+		return nil
+	}
+	return s.refreshListingsFillable(b, log.BlockNumber, collection, owner, "")
+}
+
+// isTrackedCollectionLog 日志来自跟踪的集合且 topic 数量符合 ERC721 事件定义
+func (s *Service) isTrackedCollectionLog(log ethereumTypes.Log, topics int) bool {
+	return s.collectionFilter != nil && len(log.Topics) == topics && s.collectionFilter.Contains(log.Address.String())
+}
+
+// clearTokenApprovals 撤销 token 上除 except 之外的授权, 返回被撤销的地址
+// ERC721 转移时 except 为空, 撤销全部授权
+func (s *Service) clearTokenApprovals(tx *gorm.DB, blockNumber uint64, collection, tokenId, except string) ([]string, error) {
+	var approvals []multi.NftApproval
+	if err := tx.Table(multi.NftApprovalTableName(s.chain)).
+		Where("collection_address = ? and token_id = ? and approved = ?", collection, tokenId, true).
+		Find(&approvals).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on get token approvals")
+	}
+
+	var cleared []string
+	for i := range approvals {
+		if approvals[i].Operator == except {
+			continue
+		}
+		if err := s.setApprovalWithChange(tx, blockNumber, &approvals[i], false); err != nil {
+			return nil, err
+		}
+		cleared = append(cleared, approvals[i].Operator)
+	}
+
+	return cleared, nil
+}
+
+// setApprovalWithChange 更新授权状态并记录变更前的状态, 重组时恢复
+func (s *Service) setApprovalWithChange(tx *gorm.DB, blockNumber uint64, key *multi.NftApproval, approved bool) error {
+	var prev multi.NftApproval
+	if err := tx.Table(multi.NftApprovalTableName(s.chain)).
+		Where("collection_address = ? and token_id = ? and owner = ? and operator = ?",
+			key.CollectionAddress, key.TokenId, key.Owner, key.Operator).
+		Limit(1).
+		Find(&prev).Error; err != nil {
+		return errors.Wrap(err, "failed on get nft approval")
+	}
+	// 未记录的授权等同于未授权
+	if (prev.Id == 0 && !approved) || (prev.Id != 0 && prev.Approved == approved) {
+		return nil
+	}
+
+	if prev.Id == 0 {
+		prev = multi.NftApproval{
+			CollectionAddress: key.CollectionAddress,
+			TokenId:           key.TokenId,
+			Owner:             key.Owner,
+			Operator:          key.Operator,
+			Approved:          approved,
+			BlockNumber:       int64(blockNumber),
+		}
+		if err := tx.Table(multi.NftApprovalTableName(s.chain)).Create(&prev).Error; err != nil {
+			return errors.Wrap(err, "failed on create nft approval")
+		}
+	} else if err := tx.Table(multi.NftApprovalTableName(s.chain)).
+		Where("id = ?", prev.Id).
+		Updates(map[string]interface{}{"approved": approved, "block_number": blockNumber}).Error; err != nil {
+		return errors.Wrap(err, "failed on update nft approval")
+	}
+
+	return s.recordChange(tx, &base.IndexedChange{
+		BlockNumber:       int64(blockNumber),
+		ChangeType:        base.IndexedChangeApproval,
+		CollectionAddress: key.CollectionAddress,
+		TokenId:           key.TokenId,
+		ApprovalId:        prev.Id,
+		PrevApproved:      !approved,
+	})
+}
+
+// refreshListingsFillable 根据 vault 授权状态更新 maker 在集合中的有效挂单是否可成交, tokenId 为空时更新全部挂单
+func (s *Service) refreshListingsFillable(b *eventBatch, blockNumber uint64, collection, maker, tokenId string) error {
+	if s.vaultAddress == "" {
+		return nil
+	}
+
+	query := b.tx.Table(multi.OrderTableName(s.chain)).
+		Where("collection_address = ? and maker = ? and order_type = ? and order_status = ?",
+			collection, maker, multi.ListingOrder, multi.OrderStatusActive)
+	if tokenId != "" {
+		query = query.Where("token_id = ?", tokenId)
+	}
+	var listings []multi.Order
+	if err := query.Find(&listings).Error; err != nil {
+		return errors.Wrap(err, "failed on get maker listings")
+	}
+
+	for _, listing := range listings {
+		approved, err := nftapproval.IsApproved(b.tx, s.chain, collection, listing.TokenId, maker, s.vaultAddress)
+		if err != nil {
+			return err
+		}
+		if approved == listing.Fillable {
+			continue
+		}
+		if err := s.updateOrderWithChange(b.tx, blockNumber, listing.OrderID, map[string]interface{}{"fillable": approved}); err != nil {
+			return errors.Wrapf(err, "failed on update listing fillable, order_id: %s", listing.OrderID)
+		}
+	}
+
+	return nil
+}
+
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckNFTApprovalStatus 根据授权状态表检查 NFT 当前所有者是否已授权给 vault 合约
+func (s *Service) CheckNFTApprovalStatus(collectionAddress string, tokenId string) (bool, error) {
+	var item multi.Item
+	if err := s.db.WithContext(s.ctx).Table(multi.ItemTableName(s.chain)).
+		Select("owner").
+		Where("collection_address = ? and token_id = ?", strings.ToLower(collectionAddress), tokenId).
+		Limit(1).
+		Find(&item).Error; err != nil {
+		return false, errors.Wrap(err, "failed on get item owner")
+	}
+	if item.Owner == "" {
+		return false, nil
+	}
+
+	return nftapproval.IsApproved(s.db.WithContext(s.ctx), s.chain, collectionAddress, tokenId, item.Owner, s.vaultAddress)
+}
+
+// CheckMultipleNFTApprovals 批量检查多个NFT的授权状态
+func (s *Service) CheckMultipleNFTApprovals(nfts []struct {
+	CollectionAddress string
+	TokenId           string
+}) (map[string]bool, error) {
+	results := make(map[string]bool)
+
+	for _, nft := range nfts {
+		key := fmt.Sprintf("%s:%s", nft.CollectionAddress, nft.TokenId)
+		approved, err := s.CheckNFTApprovalStatus(nft.CollectionAddress, nft.TokenId)
+		if err != nil {
+			xzap.WithContext(s.ctx).Error("failed to check NFT approval",
+				zap.String("collection", nft.CollectionAddress),
+				zap.String("token_id", nft.TokenId),
+				zap.Error(err))
+			results[key] = false
+			continue
+		}
+		results[key] = approved
+	}
+
+	return results, nil
+}
+
+// CanMarketBuyNFT 判断市场合约是否能购买指定的NFT: 存在有效挂单, 挂单人仍是所有者且已授权给 vault
+func (s *Service) CanMarketBuyNFT(collectionAddress string, tokenId string) (bool, string, error) {
+	result, err := nftapproval.CheckListing(s.db.WithContext(s.ctx), s.chain, collectionAddress, tokenId, s.vaultAddress, time.Now().Unix())
+	if err != nil {
+		return false, "查询挂单失败", err
+	}
+
+	return result.Fillable, result.Reason, nil
+}
@@ -0,0 +1,187 @@
+package orderbookindexer
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/types"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/nftapproval"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+
+	"github.com/ProjectsTask/EasySwapSync/service/collectionfilter"
+	"github.com/ProjectsTask/EasySwapSync/service/config"
+)
+
+func approvalForAllLog(owner, operator common.Address, approved bool, blockNumber uint64, index uint) ethereumTypes.Log {
+	data := common.Hash{}
+	if approved {
+		data = common.BigToHash(big.NewInt(1))
+	}
+
+	return ethereumTypes.Log{
+		Address: testCollection,
+		Topics: []common.Hash{
+			common.HexToHash(ERC721ApprovalForAllTopic),
+			common.BytesToHash(owner.Bytes()),
+			common.BytesToHash(operator.Bytes()),
+		},
+		Data:        data.Bytes(),
+		BlockNumber: blockNumber,
+		TxHash:      common.BigToHash(big.NewInt(int64(blockNumber))),
+		Index:       index,
+	}
+}
+
+func approvalLog(owner, approved common.Address, tokenId int64, blockNumber uint64, index uint) ethereumTypes.Log {
+	return ethereumTypes.Log{
+		Address: testCollection,
+		Topics: []common.Hash{
+			common.HexToHash(ERC721ApprovalTopic),
+			common.BytesToHash(owner.Bytes()),
+			common.BytesToHash(approved.Bytes()),
+			common.BigToHash(big.NewInt(tokenId)),
+		},
+		BlockNumber: blockNumber,
+		TxHash:      common.BigToHash(big.NewInt(int64(blockNumber))),
+		Index:       index,
+	}
+}
+
+func TestApplyLogsTracksApprovals(t *testing.T) {
+	db := newTestDB(t)
+	vault := common.HexToAddress("0x7d29d1860bd4d3a74bbd9a03c9b043d375311dcb")
+	cfg := &config.Config{
+		ContractCfg: config.ContractCfg{EthAddress: ZeroAddress, VaultAddress: vault.Hex()},
+		ProjectCfg:  config.ProjectCfg{Name: gdb.OrderBookDexProject},
+	}
+	ctx := xzap.ToContext(context.Background(), zap.NewNop())
+	s := New(ctx, cfg, db, nil, fakeChainClient{}, 11155111, testChain, nil)
+	filter := collectionfilter.New(ctx, db, testChain, gdb.OrderBookDexProject)
+	filter.Add(testCollection.Hex())
+	s.EnableCollectionTracking(filter)
+
+	seller := common.HexToAddress("0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266")
+	other := common.HexToAddress("0x70997970c51812dc3a010c7d01e0ea4bbb9a0c4e")
+	if err := db.Table(multi.ItemTableName(testChain)).Create(&multi.Item{
+		CollectionAddress: strings.ToLower(testCollection.Hex()),
+		TokenId:           "7",
+		Owner:             strings.ToLower(seller.Hex()),
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Table(multi.OrderTableName(testChain)).Create(&multi.Order{
+		OrderID:           "0x01",
+		OrderType:         multi.ListingOrder,
+		OrderStatus:       multi.OrderStatusActive,
+		CollectionAddress: strings.ToLower(testCollection.Hex()),
+		TokenId:           "7",
+		Maker:             strings.ToLower(seller.Hex()),
+		Price:             decimal.NewFromInt(1e16),
+		ExpireTime:        1900000000,
+		Fillable:          true,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	fillable := func() (bool, string) {
+		t.Helper()
+		canBuy, reason, err := s.CanMarketBuyNFT(testCollection.String(), "7")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return canBuy, reason
+	}
+
+	// 授权全部后撤销, 单个 token 授权给 vault 后又被改为授权其他地址
+	logs := []ethereumTypes.Log{
+		approvalForAllLog(seller, vault, true, 101, 0),
+	}
+	if _, err := s.applyLogs(logs, &types.BlockHeader{Number: 101, Hash: "0x101", ParentHash: "0x100"}); err != nil {
+		t.Fatal(err)
+	}
+	if canBuy, reason := fillable(); !canBuy {
+		t.Fatalf("expected listing fillable, got %s", reason)
+	}
+
+	logs = []ethereumTypes.Log{
+		approvalForAllLog(seller, vault, false, 102, 0),
+		approvalLog(seller, vault, 7, 103, 0),
+		approvalLog(seller, other, 7, 104, 0),
+	}
+	if _, err := s.applyLogs(logs, &types.BlockHeader{Number: 105, Hash: "0x105", ParentHash: "0x104"}); err != nil {
+		t.Fatal(err)
+	}
+	var listing multi.Order
+	if err := db.Table(multi.OrderTableName(testChain)).Where("order_id = ?", "0x01").First(&listing).Error; err != nil {
+		t.Fatal(err)
+	}
+	if listing.Fillable {
+		t.Fatal("expected listing flagged not fillable")
+	}
+	if canBuy, reason := fillable(); canBuy || reason != nftapproval.ReasonNotApproved {
+		t.Fatalf("expected listing not fillable, got %v %s", canBuy, reason)
+	}
+	if approved, err := s.CheckNFTApprovalStatus(testCollection.String(), "7"); err != nil || approved {
+		t.Fatalf("expected vault not approved, got %v %v", approved, err)
+	}
+
+	// 非所有者的更低价挂单不可成交时, 继续检查更高价的挂单
+	if err := db.Table(multi.OrderTableName(testChain)).Create(&multi.Order{
+		OrderID:           "0x00",
+		OrderType:         multi.ListingOrder,
+		OrderStatus:       multi.OrderStatusActive,
+		CollectionAddress: strings.ToLower(testCollection.Hex()),
+		TokenId:           "7",
+		Maker:             strings.ToLower(other.Hex()),
+		Price:             decimal.NewFromInt(1e15),
+		ExpireTime:        1900000000,
+		Fillable:          true,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if canBuy, reason := fillable(); canBuy || reason != nftapproval.ReasonNotOwner {
+		t.Fatalf("expected cheapest listing not owned, got %v %s", canBuy, reason)
+	}
+
+	// 重新授权后回滚, 挂单恢复为不可成交
+	logs = []ethereumTypes.Log{
+		approvalLog(seller, vault, 7, 106, 0),
+	}
+	if _, err := s.applyLogs(logs, &types.BlockHeader{Number: 106, Hash: "0x106", ParentHash: "0x105"}); err != nil {
+		t.Fatal(err)
+	}
+	if canBuy, reason := fillable(); !canBuy {
+		t.Fatalf("expected listing fillable after approval, got %s", reason)
+	}
+	if err := s.rollbackAboveBlock(105); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Table(multi.OrderTableName(testChain)).Where("order_id = ?", "0x01").First(&listing).Error; err != nil {
+		t.Fatal(err)
+	}
+	if listing.Fillable {
+		t.Fatal("expected listing not fillable after rollback")
+	}
+
+	// 回滚到授权全部之后, 挂单恢复可成交
+	if err := s.rollbackAboveBlock(101); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Table(multi.OrderTableName(testChain)).Where("order_id = ?", "0x01").First(&listing).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !listing.Fillable {
+		t.Fatal("expected listing fillable after rollback")
+	}
+	if approved, err := s.CheckNFTApprovalStatus(testCollection.String(), "7"); err != nil || !approved {
+		t.Fatalf("expected vault approved after rollback, got %v %v", approved, err)
+	}
+}
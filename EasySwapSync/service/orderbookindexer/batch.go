@@ -0,0 +1,156 @@
+package orderbookindexer
+
+import (
+	"math/big"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/types"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// eventBatch 一个区块范围的日志处理上下文, 批次内所有数据库写入共用同一个事务
+type eventBatch struct {
+	tx          *gorm.DB
+	blockTimes  map[uint64]uint64 // 区块号 -> 区块时间, 事务开始前预取
+	afterCommit []func()          // 事务提交后才执行的副作用, 如写入订单队列、拉取元数据
+	matchTxs    map[string]bool   // 包含成交事件的交易, 其中的 NFT 转移由成交事件处理
+}
+
+func newEventBatch(tx *gorm.DB) *eventBatch {
+	return &eventBatch{
+		tx:         tx,
+		blockTimes: make(map[uint64]uint64),
+		matchTxs:   make(map[string]bool),
+	}
+}
+
+// onCommit 登记事务提交后执行的副作用, 事务回滚时丢弃
+func (b *eventBatch) onCommit(fn func()) {
+	b.afterCommit = append(b.afterCommit, fn)
+}
+
+// applyLogs 在一个数据库事务内处理日志、记录区块哈希并将同步进度推进到 endHeader 之后,
+// 返回需在事务提交后执行的副作用
+func (s *Service) applyLogs(logs []ethereumTypes.Log, endHeader *types.BlockHeader) ([]func(), error) {
+	// 区块时间依赖 RPC, 在事务外预取, 避免长时间持有事务
+	blockTimes := make(map[uint64]uint64)
+	for _, log := range logs {
+		if _, ok := blockTimes[log.BlockNumber]; ok {
+			continue
+		}
+		blockTime, err := s.chainClient.BlockTimeByNumber(s.ctx, new(big.Int).SetUint64(log.BlockNumber))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on get block time")
+		}
+		blockTimes[log.BlockNumber] = blockTime
+	}
+
+	var effects []func()
+	err := s.db.WithContext(s.ctx).Transaction(func(tx *gorm.DB) error {
+		b := newEventBatch(tx)
+		b.blockTimes = blockTimes
+		for _, log := range logs {
+			if len(log.Topics) > 0 && log.Topics[0].String() == LogMatchTopic {
+				b.matchTxs[log.TxHash.Hex()] = true
+			}
+		}
+
+		for _, log := range logs {
+			first, err := s.markEventIndexed(tx, log)
+			if err != nil {
+				return err
+			}
+			// 该日志已处理过(例如提交后进程在推进内存进度前退出), 跳过保证幂等
+			if !first {
+				continue
+			}
+			if err := s.handleLog(b, log); err != nil {
+				return errors.Wrapf(err, "failed on handle log %s:%d", log.TxHash.Hex(), log.Index)
+			}
+		}
+
+		// 记录区块哈希, 供下一轮检测重组
+		if err := s.recordBlockHashes(tx, endHeader, logs); err != nil {
+			return err
+		}
+
+		if err := tx.Table(base.IndexedStatusTableName()).
+			Where("chain_id = ? and index_type = ?", s.chainId, EventIndexType).
+			Update("last_indexed_block", endHeader.Number+1).Error; err != nil {
+			return errors.Wrap(err, "failed on update orderbook event sync block number")
+		}
+
+		effects = b.afterCommit
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return effects, nil
+}
+
+// handleLog 根据日志 Topic[0] (事件签名) 分发给不同的处理函数
+func (s *Service) handleLog(b *eventBatch, log ethereumTypes.Log) error {
+	if len(log.Topics) == 0 {
+		return nil
+	}
+
+	switch log.Topics[0].String() {
+	case LogMakeTopic:
+		return s.handleMakeEvent(b, log) // 处理挂单 (Listing/Bid)
+	case LogCancelTopic:
+		return s.handleCancelEvent(b, log) // 处理取消
+	case LogMatchTopic:
+		return s.handleMatchEvent(b, log) // 处理成交
+	case ERC721ApprovalTopic:
+		return s.handleApprovalEvent(b, log) // 处理授权
+	case ERC721ApprovalForAllTopic:
+		return s.handleApprovalForAllEvent(b, log) // 处理全部授权
+	case ERC721TransferTopic:
+		return s.handleTransferEvent(b, log) // 处理 NFT 转移
+	case ERC1155TransferSingleTopic:
+		return s.handleTransferSingleEvent(b, log) // 处理 ERC1155 转移
+	case ERC1155TransferBatchTopic:
+		return s.handleTransferBatchEvent(b, log) // 处理 ERC1155 批量转移
+	default:
+		// 忽略其他未关注的事件
+		return nil
+	}
+}
+
+// markEventIndexed 记录日志已处理, 日志此前已记录过时返回 false
+func (s *Service) markEventIndexed(tx *gorm.DB, log ethereumTypes.Log) (bool, error) {
+	result := tx.Table(base.IndexedEventTableName()).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&base.IndexedEvent{
+			ChainId:     int(s.chainId),
+			IndexType:   EventIndexType,
+			BlockNumber: int64(log.BlockNumber),
+			TxHash:      log.TxHash.Hex(),
+			LogIndex:    int64(log.Index),
+		})
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "failed on record indexed event")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// blockTime 获取日志所在区块时间, 优先使用批次内预取的结果
+func (s *Service) blockTime(b *eventBatch, blockNumber uint64) (uint64, error) {
+	if blockTime, ok := b.blockTimes[blockNumber]; ok {
+		return blockTime, nil
+	}
+
+	blockTime, err := s.chainClient.BlockTimeByNumber(s.ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on get block time")
+	}
+	b.blockTimes[blockNumber] = blockTime
+
+	return blockTime, nil
+}
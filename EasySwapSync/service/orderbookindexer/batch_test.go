@@ -0,0 +1,374 @@
+package orderbookindexer
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/types"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/marketfeed"
+	"github.com/ProjectsTask/EasySwapBase/ordermanager"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/glebarez/sqlite"
+	"github.com/pkg/errors"
+	"github.com/zeromicro/go-zero/core/stores/kv"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/ProjectsTask/EasySwapSync/service/config"
+)
+
+const testChain = "sepolia"
+
+var testCollection = common.HexToAddress("0xe7f1725e7734ce288f8367e1bb143e90bb3f0512")
+
+// testAsset/testOrder 字段名与合约 ABI 一致, 用于打包事件数据
+type testAsset struct {
+	TokenId    *big.Int
+	Collection common.Address
+	Amount     *big.Int
+}
+
+type testOrder struct {
+	Side     uint8
+	SaleKind uint8
+	Maker    common.Address
+	Nft      testAsset
+	Price    *big.Int
+	Expiry   uint64
+	Salt     uint64
+}
+
+// fakeChainClient 只提供区块时间, 其他 RPC 在批处理中不应被调用
+type fakeChainClient struct{}
+
+func (fakeChainClient) FilterLogs(ctx context.Context, q types.FilterQuery) ([]interface{}, error) {
+	return nil, errors.New("unexpected call")
+}
+
+func (fakeChainClient) BlockTimeByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return 1700000000 + blockNum.Uint64()*12, nil
+}
+
+func (fakeChainClient) Client() interface{} {
+	return nil
+}
+
+func (fakeChainClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return nil, errors.New("unexpected call")
+}
+
+func (fakeChainClient) CallContractByChain(ctx context.Context, param types.CallParam) (interface{}, error) {
+	return nil, errors.New("unexpected call")
+}
+
+func (fakeChainClient) BlockNumber() (uint64, error) {
+	return 0, errors.New("unexpected call")
+}
+
+func (fakeChainClient) BlockWithTxs(ctx context.Context, blockNumber uint64) (interface{}, error) {
+	return nil, errors.New("unexpected call")
+}
+
+func (fakeChainClient) HeaderByNumber(ctx context.Context, blockNumber *big.Int) (*types.BlockHeader, error) {
+	return nil, errors.New("unexpected call")
+}
+
+func (fakeChainClient) SubscribeNewHead(ctx context.Context, ch chan<- *types.BlockHeader) (ethereum.Subscription, error) {
+	return nil, errors.New("unexpected call")
+}
+
+func (fakeChainClient) SubscribeFilterLogs(ctx context.Context, q types.FilterQuery, ch chan<- ethereumTypes.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("unexpected call")
+}
+
+func (fakeChainClient) Close() {}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "orderbook.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tables := []struct {
+		name  string
+		model interface{}
+	}{
+		{multi.OrderTableName(testChain), &multi.Order{}},
+		{multi.ItemTableName(testChain), &multi.Item{}},
+		{multi.ActivityTableName(testChain), &multi.Activity{}},
+		{multi.CollectionTableName(testChain), &multi.Collection{}},
+		{multi.ItemExternalTableName(testChain), &multi.ItemExternal{}},
+		{multi.NftApprovalTableName(testChain), &multi.NftApproval{}},
+		{multi.ItemBalanceTableName(testChain), &multi.ItemBalance{}},
+		{base.IndexedStatusTableName(), &base.IndexedStatus{}},
+		{base.IndexedBlockTableName(), &base.IndexedBlock{}},
+		{base.IndexedChangeTableName(), &base.IndexedChange{}},
+		{base.IndexedEventTableName(), &base.IndexedEvent{}},
+	}
+	for _, table := range tables {
+		if err := db.Table(table.name).AutoMigrate(table.model); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 与 db/migrations/chain 中的唯一索引保持一致
+	for _, stmt := range []string{
+		"create unique index order_id on ob_order_sepolia (order_id)",
+		"create unique index item_index on ob_item_sepolia (collection_address, token_id)",
+		"create unique index item_external_index on ob_item_external_sepolia (collection_address, token_id)",
+		"create unique index activity_index on ob_activity_sepolia (tx_hash, collection_address, token_id, activity_type)",
+		"create unique index nft_approval_index on ob_nft_approval_sepolia (collection_address, token_id, owner, operator)",
+		"create unique index item_balance_index on ob_item_balance_sepolia (collection_address, token_id, owner)",
+		"create unique index block_index on ob_indexed_block (chain_id, index_type, block_number)",
+		"create unique index event_index on ob_indexed_event (chain_id, index_type, tx_hash, log_index)",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := db.Table(base.IndexedStatusTableName()).Create(&base.IndexedStatus{
+		ChainId:          11155111,
+		IndexType:        EventIndexType,
+		LastIndexedBlock: 100,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func makeLog(t *testing.T, s *Service, orderKey common.Hash, side, saleKind uint8, maker common.Address, amount int64, blockNumber uint64, index uint) ethereumTypes.Log {
+	nft := testAsset{TokenId: big.NewInt(7), Collection: testCollection, Amount: big.NewInt(amount)}
+	data, err := s.parsedAbi.Events["LogMake"].Inputs.NonIndexed().Pack(orderKey, nft, big.NewInt(1e16), uint64(1900000000), uint64(index))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ethereumTypes.Log{
+		Topics: []common.Hash{
+			common.HexToHash(LogMakeTopic),
+			common.BigToHash(big.NewInt(int64(side))),
+			common.BigToHash(big.NewInt(int64(saleKind))),
+			common.BytesToHash(maker.Bytes()),
+		},
+		Data:        data,
+		BlockNumber: blockNumber,
+		TxHash:      common.BigToHash(big.NewInt(int64(blockNumber))),
+		Index:       index,
+	}
+}
+
+func matchLog(t *testing.T, s *Service, makeOrderKey, takeOrderKey common.Hash, makeOrder, takeOrder testOrder, blockNumber uint64, index uint) ethereumTypes.Log {
+	data, err := s.parsedAbi.Events["LogMatch"].Inputs.NonIndexed().Pack(makeOrder, takeOrder, big.NewInt(1e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ethereumTypes.Log{
+		Topics: []common.Hash{
+			common.HexToHash(LogMatchTopic),
+			makeOrderKey,
+			takeOrderKey,
+		},
+		Data:        data,
+		BlockNumber: blockNumber,
+		TxHash:      common.BigToHash(big.NewInt(int64(blockNumber))),
+		Index:       index,
+	}
+}
+
+func cancelLog(orderKey common.Hash, maker common.Address, blockNumber uint64, index uint) ethereumTypes.Log {
+	return ethereumTypes.Log{
+		Topics: []common.Hash{
+			common.HexToHash(LogCancelTopic),
+			orderKey,
+			common.BytesToHash(maker.Bytes()),
+		},
+		BlockNumber: blockNumber,
+		TxHash:      common.BigToHash(big.NewInt(int64(blockNumber))),
+		Index:       index,
+	}
+}
+
+func snapshot(t *testing.T, db *gorm.DB) map[string][]map[string]interface{} {
+	state := make(map[string][]map[string]interface{})
+	for _, table := range []string{
+		multi.OrderTableName(testChain),
+		multi.ItemTableName(testChain),
+		multi.ActivityTableName(testChain),
+		multi.CollectionTableName(testChain),
+		base.IndexedChangeTableName(),
+		base.IndexedStatusTableName(),
+	} {
+		var rows []map[string]interface{}
+		if err := db.Table(table).Order("id").Find(&rows).Error; err != nil {
+			t.Fatal(err)
+		}
+		state[table] = rows
+	}
+
+	return state
+}
+
+func TestApplyLogsIdempotent(t *testing.T) {
+	db := newTestDB(t)
+	cfg := &config.Config{
+		ContractCfg: config.ContractCfg{EthAddress: ZeroAddress},
+		ProjectCfg:  config.ProjectCfg{Name: gdb.OrderBookDexProject},
+	}
+	ctx := xzap.ToContext(context.Background(), zap.NewNop())
+	s := New(ctx, cfg, db, nil, fakeChainClient{}, 11155111, testChain, nil)
+
+	seller := common.HexToAddress("0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266")
+	buyer := common.HexToAddress("0x70997970c51812dc3a010c7d01e0ea4bbb9a0c4e")
+	listingKey := common.HexToHash("0x01")
+	bidKey := common.HexToHash("0x02")
+
+	nft := testAsset{TokenId: big.NewInt(7), Collection: testCollection, Amount: big.NewInt(1)}
+	sell := testOrder{Side: List, SaleKind: FixForItem, Maker: seller, Nft: nft, Price: big.NewInt(1e16), Expiry: 1900000000}
+	bid := testOrder{Side: Bid, SaleKind: FixForCollection, Maker: buyer, Nft: nft, Price: big.NewInt(1e16), Expiry: 1900000000}
+
+	logs := []ethereumTypes.Log{
+		makeLog(t, s, listingKey, List, FixForItem, seller, 1, 101, 0),
+		makeLog(t, s, bidKey, Bid, FixForCollection, buyer, 3, 102, 0),
+		matchLog(t, s, bidKey, listingKey, bid, sell, 103, 0),
+		cancelLog(bidKey, buyer, 104, 0),
+	}
+	endHeader := &types.BlockHeader{Number: 105, Hash: "0x105", ParentHash: "0x104"}
+
+	if _, err := s.applyLogs(logs, endHeader); err != nil {
+		t.Fatal(err)
+	}
+	first := snapshot(t, db)
+
+	// 同一区块范围重复处理, 状态必须完全一致
+	if _, err := s.applyLogs(logs, endHeader); err != nil {
+		t.Fatal(err)
+	}
+	second := snapshot(t, db)
+
+	for table, rows := range first {
+		if len(rows) != len(second[table]) {
+			t.Fatalf("%s: row count changed from %d to %d", table, len(rows), len(second[table]))
+		}
+		for i := range rows {
+			for column, value := range rows[i] {
+				if second[table][i][column] != value {
+					t.Fatalf("%s: %s changed from %v to %v", table, column, value, second[table][i][column])
+				}
+			}
+		}
+	}
+
+	var bidOrder multi.Order
+	if err := db.Table(multi.OrderTableName(testChain)).Where("order_id = ?", bidKey.Hex()).First(&bidOrder).Error; err != nil {
+		t.Fatal(err)
+	}
+	if bidOrder.QuantityRemaining != 2 || bidOrder.OrderStatus != multi.OrderStatusCancelled {
+		t.Fatalf("unexpected bid order state: quantity_remaining=%d order_status=%d", bidOrder.QuantityRemaining, bidOrder.OrderStatus)
+	}
+	if n := len(first[multi.ActivityTableName(testChain)]); n != 4 {
+		t.Fatalf("expected 4 activities, got %d", n)
+	}
+	status := first[base.IndexedStatusTableName()][0]
+	if status["last_indexed_block"] != int64(106) {
+		t.Fatalf("unexpected last_indexed_block %v", status["last_indexed_block"])
+	}
+
+	// 超出重组深度后清理已处理日志记录
+	if _, err := s.applyLogs(nil, &types.BlockHeader{Number: 104 + MaxReorgDepth, Hash: "0x1", ParentHash: "0x0"}); err != nil {
+		t.Fatal(err)
+	}
+	var events []base.IndexedEvent
+	if err := db.Table(base.IndexedEventTableName()).Order("block_number asc").Find(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].BlockNumber != 104 {
+		t.Fatalf("expected only block 104 indexed event kept, got %+v", events)
+	}
+}
+
+// TestApplyLogsPublishesMarketEvents 检查事务提交后推送的市场事件, 重复处理不会重复推送
+func TestApplyLogsPublishesMarketEvents(t *testing.T) {
+	db := newTestDB(t)
+	cfg := &config.Config{
+		ContractCfg: config.ContractCfg{EthAddress: ZeroAddress},
+		ProjectCfg:  config.ProjectCfg{Name: gdb.OrderBookDexProject},
+	}
+	ctx := xzap.ToContext(context.Background(), zap.NewNop())
+	store := xkv.NewStore(kv.KvConf{{
+		RedisConf: redis.RedisConf{Host: miniredis.RunT(t).Addr(), Type: redis.NodeType},
+		Weight:    100,
+	}})
+	orderManager := ordermanager.New(ctx, db, store, testChain, gdb.OrderBookDexProject)
+	s := New(ctx, cfg, db, store, fakeChainClient{}, 11155111, testChain, orderManager)
+
+	seller := common.HexToAddress("0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266")
+	buyer := common.HexToAddress("0x70997970c51812dc3a010c7d01e0ea4bbb9a0c4e")
+	listingKey := common.HexToHash("0x01")
+	bidKey := common.HexToHash("0x02")
+
+	nft := testAsset{TokenId: big.NewInt(7), Collection: testCollection, Amount: big.NewInt(1)}
+	sell := testOrder{Side: List, SaleKind: FixForItem, Maker: seller, Nft: nft, Price: big.NewInt(1e16), Expiry: 1900000000}
+	bid := testOrder{Side: Bid, SaleKind: FixForCollection, Maker: buyer, Nft: nft, Price: big.NewInt(1e16), Expiry: 1900000000}
+
+	logs := []ethereumTypes.Log{
+		makeLog(t, s, listingKey, List, FixForItem, seller, 1, 101, 0),
+		makeLog(t, s, bidKey, Bid, FixForCollection, buyer, 3, 102, 0),
+		matchLog(t, s, bidKey, listingKey, bid, sell, 103, 0),
+	}
+	endHeader := &types.BlockHeader{Number: 105, Hash: "0x105", ParentHash: "0x104"}
+
+	for i := 0; i < 2; i++ {
+		effects, err := s.applyLogs(logs, endHeader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, effect := range effects {
+			effect()
+		}
+	}
+
+	events, gap, err := marketfeed.Read(store, testChain, 0, 10)
+	if err != nil || gap {
+		t.Fatalf("unexpected read result gap=%v err=%v", gap, err)
+	}
+	expected := []string{marketfeed.TypeListing, marketfeed.TypeBid, marketfeed.TypeSale}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(events))
+	}
+	for i, event := range events {
+		if event.ID != uint64(i+1) || event.Type != expected[i] || event.Chain != testChain {
+			t.Fatalf("unexpected event %d: %+v", i, event)
+		}
+		if event.CollectionAddress != strings.ToLower(testCollection.Hex()) || event.TokenID != "7" {
+			t.Fatalf("unexpected event asset %+v", event)
+		}
+	}
+	if sale := events[2]; sale.Maker != strings.ToLower(seller.Hex()) || sale.Taker != strings.ToLower(buyer.Hex()) ||
+		sale.OrderID != listingKey.Hex() {
+		t.Fatalf("unexpected sale event %+v", sale)
+	}
+
+	// 从游标续传只返回之后的事件
+	events, gap, err = marketfeed.Read(store, testChain, 2, 10)
+	if err != nil || gap || len(events) != 1 || events[0].Type != marketfeed.TypeSale {
+		t.Fatalf("unexpected resume result %v %v %v", events, gap, err)
+	}
+	if latest, err := marketfeed.Latest(store, testChain); err != nil || latest != 3 {
+		t.Fatalf("unexpected latest id %d %v", latest, err)
+	}
+}
@@ -0,0 +1,135 @@
+package orderbookindexer
+
+import (
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/chainclient"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/retry"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+)
+
+const (
+	MinSyncBlockRange = 1    // 自适应区块范围下限
+	MaxSyncBlockRange = 2000 // 自适应区块范围上限, 多数节点 eth_getLogs 允许的最大范围
+
+	rangeTargetLatency = 3 * time.Second // 单次请求目标耗时, 超过则缩小范围
+	rangeMaxLogs       = 5000            // 单次请求目标日志数, 超过则缩小范围
+)
+
+// blockRange 自适应区块范围控制器
+// 请求快且日志少时倍增范围以加快追赶, 请求慢、日志多或节点拒绝时按比例缩小
+type blockRange struct {
+	size     uint64
+	min, max uint64
+}
+
+func newBlockRange(initial, min, max uint64) *blockRange {
+	r := &blockRange{min: min, max: max}
+	r.set(initial)
+	return r
+}
+
+func (r *blockRange) current() uint64 {
+	return r.size
+}
+
+func (r *blockRange) set(size uint64) {
+	if size < r.min {
+		size = r.min
+	}
+	if size > r.max {
+		size = r.max
+	}
+	r.size = size
+}
+
+// onSuccess 根据本次请求的范围、日志数量与耗时调整下一次的范围
+// 只有请求用满了当前范围才放大, 追上链头后的小范围请求不影响窗口
+func (r *blockRange) onSuccess(size uint64, logCount int, latency time.Duration) {
+	switch {
+	case latency > rangeTargetLatency || logCount > rangeMaxLogs:
+		r.set(size / 2)
+	case size >= r.size && latency < rangeTargetLatency/2 && logCount < rangeMaxLogs/2:
+		r.set(r.size * 2)
+	}
+}
+
+// onError 根据错误类型缩小范围; 限流与其他错误和范围无关, 保持不变
+func (r *blockRange) onError(size uint64, class chainclient.ErrorClass) {
+	switch class {
+	case chainclient.ErrorClassTooManyResults:
+		r.set(size / 4)
+	case chainclient.ErrorClassTimeout:
+		r.set(size / 2)
+	}
+}
+
+// newRPCBackoff 各类 RPC 错误的重试策略, base 为退避的基础时长
+// 结果过多时缩小范围后立即重试; 限流与超时指数退避; 其他错误不重试, 由同步循环等待下一轮
+func newRPCBackoff(base time.Duration) map[chainclient.ErrorClass][]retry.Strategy {
+	return map[chainclient.ErrorClass][]retry.Strategy{
+		chainclient.ErrorClassTooManyResults: {retry.Limit(12)},
+		chainclient.ErrorClassRateLimited:    {retry.Limit(6), retry.Wait(base, 2*base, 4*base, 8*base, 16*base)},
+		chainclient.ErrorClassTimeout:        {retry.Limit(4), retry.Wait(base, 2*base, 4*base)},
+		chainclient.ErrorClassFatal:          {retry.Limit(1)},
+	}
+}
+
+// fetchLogsAdaptive 从 startBlock 开始按自适应范围获取日志, 范围不超过 maxEndBlock
+// 失败时按错误类型选择重试策略, 每类错误单独计数; 返回日志与实际处理到的结束区块
+func (s *Service) fetchLogsAdaptive(startBlock, maxEndBlock uint64) ([]ethereumTypes.Log, uint64, error) {
+	var (
+		logs     []ethereumTypes.Log
+		endBlock uint64
+		class    chainclient.ErrorClass
+		attempts = make(map[chainclient.ErrorClass]uint)
+	)
+
+	err := retry.Retry(func(attempt uint) error {
+		endBlock = startBlock + s.blockRange.current() - 1
+		if endBlock > maxEndBlock {
+			endBlock = maxEndBlock
+		}
+		size := endBlock - startBlock + 1
+
+		begin := time.Now()
+		var err error
+		logs, err = s.fetchLogs(startBlock, endBlock)
+		if err != nil {
+			class = chainclient.ClassifyError(err)
+			s.blockRange.onError(size, class)
+			xzap.WithContext(s.ctx).Warn("failed on get log",
+				zap.Error(err),
+				zap.String("error_class", class.String()),
+				zap.Uint64("start_block", startBlock),
+				zap.Uint64("end_block", endBlock),
+				zap.Uint64("next_range", s.blockRange.current()))
+			return err
+		}
+
+		s.blockRange.onSuccess(size, len(logs), time.Since(begin))
+		return nil
+	}, func(attempt uint) bool {
+		if attempt == 0 {
+			return true
+		}
+		if s.ctx.Err() != nil {
+			return false
+		}
+
+		attempts[class]++
+		for _, strategy := range s.rpcBackoff[class] {
+			if !strategy(attempts[class]) {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, endBlock, nil
+}
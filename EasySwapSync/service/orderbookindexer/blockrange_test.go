@@ -0,0 +1,123 @@
+package orderbookindexer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/chainclient"
+	"github.com/ProjectsTask/EasySwapBase/chain/types"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/ProjectsTask/EasySwapSync/service/config"
+)
+
+// limitedChainClient 模拟有区块范围限制与限流的节点
+type limitedChainClient struct {
+	fakeChainClient
+	maxRange    uint64 // 超过该范围返回结果过多
+	rateLimited int    // 前 n 次请求返回限流
+	fatal       bool
+	calls       int
+}
+
+func (c *limitedChainClient) FilterLogs(ctx context.Context, q types.FilterQuery) ([]interface{}, error) {
+	c.calls++
+	if c.fatal {
+		return nil, errors.New("invalid argument 0: hex string without 0x prefix")
+	}
+	if c.rateLimited > 0 {
+		c.rateLimited--
+		return nil, rpc.HTTPError{StatusCode: 429, Status: "429 Too Many Requests"}
+	}
+	if q.ToBlock.Uint64()-q.FromBlock.Uint64()+1 > c.maxRange {
+		return nil, errors.Wrap(errors.New("query returned more than 10000 results"), "failed on filter logs")
+	}
+	return nil, nil
+}
+
+func newRangeTestService(client chainclient.ChainClient) *Service {
+	cfg := &config.Config{
+		ContractCfg: config.ContractCfg{EthAddress: ZeroAddress},
+		ProjectCfg:  config.ProjectCfg{Name: gdb.OrderBookDexProject},
+	}
+	s := New(xzap.ToContext(context.Background(), zap.NewNop()), cfg, nil, nil, client, 11155111, testChain, nil)
+	s.rpcBackoff = newRPCBackoff(time.Millisecond)
+	return s
+}
+
+func TestBlockRangeAdjust(t *testing.T) {
+	r := newBlockRange(100, MinSyncBlockRange, MaxSyncBlockRange)
+
+	r.onSuccess(100, 10, time.Millisecond)
+	if r.current() != 200 {
+		t.Fatalf("expected range to grow to 200, got %d", r.current())
+	}
+	// 追上链头后的小范围请求不放大窗口
+	r.onSuccess(3, 0, time.Millisecond)
+	if r.current() != 200 {
+		t.Fatalf("expected range to stay 200, got %d", r.current())
+	}
+	r.onSuccess(200, rangeMaxLogs+1, time.Millisecond)
+	if r.current() != 100 {
+		t.Fatalf("expected range to shrink to 100 on too many logs, got %d", r.current())
+	}
+	r.onSuccess(100, 10, 2*rangeTargetLatency)
+	if r.current() != 50 {
+		t.Fatalf("expected range to shrink to 50 on slow request, got %d", r.current())
+	}
+	r.onError(50, chainclient.ErrorClassRateLimited)
+	if r.current() != 50 {
+		t.Fatalf("expected range to stay 50 on rate limit, got %d", r.current())
+	}
+	r.onError(50, chainclient.ErrorClassTooManyResults)
+	r.onError(12, chainclient.ErrorClassTooManyResults)
+	r.onError(3, chainclient.ErrorClassTooManyResults)
+	if r.current() != MinSyncBlockRange {
+		t.Fatalf("expected range to stop at %d, got %d", MinSyncBlockRange, r.current())
+	}
+	for i := 0; i < 20; i++ {
+		r.onSuccess(r.current(), 0, time.Millisecond)
+	}
+	if r.current() != MaxSyncBlockRange {
+		t.Fatalf("expected range to stop at %d, got %d", MaxSyncBlockRange, r.current())
+	}
+}
+
+func TestFetchLogsAdaptive(t *testing.T) {
+	// 结果过多时缩小范围直到节点接受, 限流时退避后以原范围重试
+	client := &limitedChainClient{maxRange: 30, rateLimited: 2}
+	s := newRangeTestService(client)
+
+	_, endBlock, err := s.fetchLogsAdaptive(1000, 5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 次限流保持 100, 结果过多缩小到 25 后成功
+	if endBlock != 1024 || client.calls != 4 {
+		t.Fatalf("expected end block 1024 after 4 calls, got %d after %d calls", endBlock, client.calls)
+	}
+
+	// 不超过已确认的区块高度
+	_, endBlock, err = s.fetchLogsAdaptive(2000, 2003)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endBlock != 2003 {
+		t.Fatalf("expected end block 2003, got %d", endBlock)
+	}
+
+	// 其他错误不重试
+	fatal := &limitedChainClient{fatal: true}
+	s = newRangeTestService(fatal)
+	if _, _, err := s.fetchLogsAdaptive(1000, 5000); chainclient.ClassifyError(err) != chainclient.ErrorClassFatal {
+		t.Fatalf("expected fatal error, got %v", err)
+	}
+	if fatal.calls != 1 {
+		t.Fatalf("expected 1 call for fatal error, got %d", fatal.calls)
+	}
+}
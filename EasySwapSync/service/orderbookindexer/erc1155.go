@@ -0,0 +1,363 @@
+package orderbookindexer
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ERC1155 转移事件, Topics: [Signature, Operator, From, To]
+const (
+	// TransferSingle: Data 为 id, value
+	ERC1155TransferSingleTopic = "0xc3d58168c5ae7397731d063d5bbf3d657854427343f4c083240f7aacaa2d0f62"
+	// TransferBatch: Data 为 ids, values
+	ERC1155TransferBatchTopic = "0x4a39dc06d4c0dbc64b70af90fd698a233a518aa5d07e595d983b8c0526c8f7fb"
+)
+
+// erc1155BalanceOfSelector balanceOf(address,uint256) 的方法签名
+const erc1155BalanceOfSelector = "00fdd58e"
+
+// erc1155BatchArgs TransferBatch 事件 Data 的解码参数
+var erc1155BatchArgs = func() abi.Arguments {
+	uint256Array, _ := abi.NewType("uint256[]", "", nil)
+	return abi.Arguments{{Name: "ids", Type: uint256Array}, {Name: "values", Type: uint256Array}}
+}()
+
+// handleTransferSingleEvent 处理 ERC1155 单个 token 转移事件 (TransferSingle)
+func (s *Service) handleTransferSingleEvent(b *eventBatch, log ethereumTypes.Log) error {
+	if !s.isTrackedCollectionLog(log, 4) || len(log.Data) < 64 {
+		return nil
+	}
+
+	id := new(big.Int).SetBytes(log.Data[:32])
+	value := new(big.Int).SetBytes(log.Data[32:64])
+	return s.handleErc1155Transfer(b, log, []*big.Int{id}, []*big.Int{value})
+}
+
+// handleTransferBatchEvent 处理 ERC1155 批量转移事件 (TransferBatch)
+func (s *Service) handleTransferBatchEvent(b *eventBatch, log ethereumTypes.Log) error {
+	if !s.isTrackedCollectionLog(log, 4) {
+		return nil
+	}
+
+	// 解析失败重试也无法成功, 记录日志后跳过
+	values, err := erc1155BatchArgs.Unpack(log.Data)
+	if err != nil {
+		xzap.WithContext(s.ctx).Error("Error unpacking TransferBatch event:", zap.Error(err))
+		return nil
+	}
+	ids, amounts := values[0].([]*big.Int), values[1].([]*big.Int)
+	if len(ids) != len(amounts) {
+		xzap.WithContext(s.ctx).Error("mismatched TransferBatch ids and values",
+			zap.String("tx_hash", log.TxHash.String()))
+		return nil
+	}
+
+	return s.handleErc1155Transfer(b, log, ids, amounts)
+}
+
+// handleErc1155Transfer 更新 ERC1155 持有数量, 记录 Mint/Transfer 活动, 并使持有数量不足的挂单失效
+func (s *Service) handleErc1155Transfer(b *eventBatch, log ethereumTypes.Log, ids, amounts []*big.Int) error {
+	from := strings.ToLower(common.BytesToAddress(log.Topics[2].Bytes()).String())
+	to := strings.ToLower(common.BytesToAddress(log.Topics[3].Bytes()).String())
+	collection := strings.ToLower(log.Address.String())
+
+	if err := s.markErc1155Collection(b.tx, log.Address.String()); err != nil {
+		return err
+	}
+	// 成交交易中的转移由 handleMatchEvent 按成交数量更新持有数量
+	if b.matchTxs[log.TxHash.Hex()] {
+		return nil
+	}
+	// 挂单时 NFT 存入 vault, 取消时取回, 托管期间仍计入挂单人的持有数量
+	if s.vaultAddress != "" && (strings.EqualFold(from, s.vaultAddress) || strings.EqualFold(to, s.vaultAddress)) {
+		return nil
+	}
+
+	blockTime, err := s.blockTime(b, log.BlockNumber)
+	if err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		if amounts[i].Sign() <= 0 || !amounts[i].IsInt64() {
+			continue
+		}
+		tokenId, amount := id.String(), amounts[i].Int64()
+
+		// 1. 更新转出方与接收方的持有数量, Mint 之前未收录的 NFT 新建记录
+		if err := s.transferBalanceWithChange(b.tx, log.BlockNumber, collection, tokenId, from, to, amount); err != nil {
+			return err
+		}
+		var item multi.Item
+		if err := b.tx.Table(multi.ItemTableName(s.chain)).
+			Select("id").
+			Where("collection_address = ? and token_id = ?", collection, tokenId).
+			Limit(1).
+			Find(&item).Error; err != nil {
+			return errors.Wrap(err, "failed on get item")
+		}
+		if item.Id == 0 {
+			if err := s.createItemWithChange(b.tx, log.BlockNumber, &multi.Item{
+				ChainId:           int(s.chainId),
+				CollectionAddress: collection,
+				TokenId:           tokenId,
+				Name:              "Token #" + tokenId,
+				Creator:           to,
+				Supply:            amount,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// 2. 记录 Mint/Transfer 活动
+		activityType := multi.Transfer
+		if from == ZeroAddress {
+			activityType = multi.Mint
+		}
+		if err := b.tx.Table(multi.ActivityTableName(s.chain)).Clauses(clause.OnConflict{
+			DoNothing: true,
+		}).Create(&multi.Activity{
+			ActivityType:      activityType,
+			Maker:             from,
+			Taker:             to,
+			MarketplaceID:     multi.MarketOrderBook,
+			CollectionAddress: collection,
+			TokenId:           tokenId,
+			CurrencyAddress:   s.cfg.ContractCfg.EthAddress,
+			Price:             decimal.Zero,
+			BlockNumber:       int64(log.BlockNumber),
+			TxHash:            log.TxHash.String(),
+			EventTime:         int64(blockTime),
+		}).Error; err != nil {
+			return errors.Wrap(err, "failed on create transfer activity")
+		}
+
+		// 3. 转出方剩余数量不足以覆盖其挂单时, 使超出的挂单失效
+		if from == ZeroAddress {
+			continue
+		}
+		if err := s.deactivateUnbackedListings(b, log.BlockNumber, collection, tokenId, from, int64(blockTime), log.TxHash.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deactivateUnbackedListings 挂单人持有数量小于其有效挂单剩余数量之和时, 从最高价开始使挂单失效
+// 持有数量未收录时无法判断, 不处理
+func (s *Service) deactivateUnbackedListings(b *eventBatch, blockNumber uint64, collection, tokenId, maker string, eventTime int64, txHash string) error {
+	var balance multi.ItemBalance
+	if err := b.tx.Table(multi.ItemBalanceTableName(s.chain)).
+		Select("id, balance").
+		Where("collection_address = ? and token_id = ? and owner = ?", collection, tokenId, maker).
+		Limit(1).
+		Find(&balance).Error; err != nil {
+		return errors.Wrap(err, "failed on get item balance")
+	}
+	if balance.Id == 0 {
+		return nil
+	}
+
+	var listings []multi.Order
+	if err := b.tx.Table(multi.OrderTableName(s.chain)).
+		Where("collection_address = ? and token_id = ? and order_type = ? and order_status = ?",
+			collection, tokenId, multi.ListingOrder, multi.OrderStatusActive).
+		Order("price desc").
+		Find(&listings).Error; err != nil {
+		return errors.Wrap(err, "failed on get active listings")
+	}
+
+	var listed int64
+	for _, listing := range listings {
+		if strings.EqualFold(listing.Maker, maker) {
+			listed += listing.QuantityRemaining
+		}
+	}
+	for _, listing := range listings {
+		if listed <= balance.Balance {
+			break
+		}
+		if !strings.EqualFold(listing.Maker, maker) {
+			continue
+		}
+		if err := s.deactivateListing(b, blockNumber, listing, eventTime, txHash); err != nil {
+			return err
+		}
+		listed -= listing.QuantityRemaining
+	}
+
+	return nil
+}
+
+// transferBalanceWithChange 从 from 转移 amount 个 token 到 to, 零地址(Mint/Burn)一侧不记录持有数量
+func (s *Service) transferBalanceWithChange(tx *gorm.DB, blockNumber uint64, collection, tokenId, from, to string, amount int64) error {
+	if from != ZeroAddress {
+		if err := s.adjustBalanceWithChange(tx, blockNumber, collection, tokenId, from, -amount); err != nil {
+			return err
+		}
+	}
+	if to != ZeroAddress {
+		if err := s.adjustBalanceWithChange(tx, blockNumber, collection, tokenId, to, amount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// adjustBalanceWithChange 调整持有数量并记录变更前的数量, 重组时恢复
+// 首次收录持有人时以上一区块的链上持有数量为初始值, 同步开始前已持有的数量不会丢失;
+// 链上查询失败时转出不记录(持有数量未知), 转入只记录转入数量
+func (s *Service) adjustBalanceWithChange(tx *gorm.DB, blockNumber uint64, collection, tokenId, owner string, delta int64) error {
+	var prev multi.ItemBalance
+	if err := tx.Table(multi.ItemBalanceTableName(s.chain)).
+		Where("collection_address = ? and token_id = ? and owner = ?", collection, tokenId, owner).
+		Limit(1).
+		Find(&prev).Error; err != nil {
+		return errors.Wrap(err, "failed on get item balance")
+	}
+	if prev.Id == 0 {
+		held, err := s.erc1155BalanceOf(collection, tokenId, owner, blockNumber-1)
+		if err != nil {
+			xzap.WithContext(s.ctx).Warn("failed on get erc1155 balance from chain",
+				zap.Error(err), zap.String("collection_address", collection),
+				zap.String("token_id", tokenId), zap.String("owner", owner))
+			if delta < 0 {
+				return nil
+			}
+		}
+		prev.Balance = held
+	}
+
+	balance := prev.Balance + delta
+	if balance < 0 {
+		balance = 0
+	}
+	if balance == prev.Balance {
+		return nil
+	}
+
+	if prev.Id == 0 {
+		// 重组时恢复为初始值
+		held := prev.Balance
+		prev = multi.ItemBalance{
+			CollectionAddress: collection,
+			TokenId:           tokenId,
+			Owner:             owner,
+			Balance:           balance,
+			BlockNumber:       int64(blockNumber),
+		}
+		if err := tx.Table(multi.ItemBalanceTableName(s.chain)).Create(&prev).Error; err != nil {
+			return errors.Wrap(err, "failed on create item balance")
+		}
+		prev.Balance = held
+	} else if err := tx.Table(multi.ItemBalanceTableName(s.chain)).
+		Where("id = ?", prev.Id).
+		Updates(map[string]interface{}{"balance": balance, "block_number": blockNumber}).Error; err != nil {
+		return errors.Wrap(err, "failed on update item balance")
+	}
+
+	return s.recordChange(tx, &base.IndexedChange{
+		BlockNumber:       int64(blockNumber),
+		ChangeType:        base.IndexedChangeBalance,
+		CollectionAddress: collection,
+		TokenId:           tokenId,
+		BalanceId:         prev.Id,
+		PrevBalance:       prev.Balance,
+	})
+}
+
+// erc1155BalanceOf 查询 owner 在 blockNumber 区块结束时的链上持有数量
+func (s *Service) erc1155BalanceOf(collection, tokenId, owner string, blockNumber uint64) (int64, error) {
+	id, ok := new(big.Int).SetString(tokenId, 10)
+	if !ok {
+		return 0, errors.Errorf("invalid token id %s", tokenId)
+	}
+	data := common.Hex2Bytes(erc1155BalanceOfSelector)
+	data = append(data, common.LeftPadBytes(common.HexToAddress(owner).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(id.Bytes(), 32)...)
+
+	contractAddr := common.HexToAddress(collection)
+	result, err := s.chainClient.CallContract(s.ctx, ethereum.CallMsg{To: &contractAddr, Data: data}, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to call balanceOf")
+	}
+	if len(result) < 32 {
+		return 0, errors.New("invalid balanceOf response length")
+	}
+	balance := new(big.Int).SetBytes(result[:32])
+	if !balance.IsInt64() {
+		return 0, errors.New("balance overflows int64")
+	}
+
+	return balance.Int64(), nil
+}
+
+// markErc1155Collection 收到 ERC1155 转移事件的集合标记为 ERC1155, 合约标准与分叉无关, 不记录变更
+func (s *Service) markErc1155Collection(tx *gorm.DB, collectionAddress string) error {
+	if err := tx.Table(gdb.GetMultiProjectCollectionTableName(s.cfg.ProjectCfg.Name, s.chain)).
+		Where("address = ? and token_standard != ?", collectionAddress, multi.TokenStandardERC1155).
+		Update("token_standard", multi.TokenStandardERC1155).Error; err != nil {
+		return errors.Wrap(err, "failed on mark erc1155 collection")
+	}
+
+	return nil
+}
+
+// isErc1155Collection 集合是否为 ERC1155, 未收录的集合按 ERC721 处理
+func (s *Service) isErc1155Collection(tx *gorm.DB, collectionAddress string) (bool, error) {
+	var collection multi.Collection
+	if err := tx.Table(gdb.GetMultiProjectCollectionTableName(s.cfg.ProjectCfg.Name, s.chain)).
+		Select("token_standard").
+		Where("address = ?", collectionAddress).
+		Limit(1).
+		Find(&collection).Error; err != nil {
+		return false, errors.Wrap(err, "failed on get collection token standard")
+	}
+
+	return collection.TokenStandard == multi.TokenStandardERC1155, nil
+}
+
+// fillQuantity 成交数量, 由吃单的 Nft.Amount 给出, ERC721 为 1
+func fillQuantity(takeOrder Order) int64 {
+	if takeOrder.Nft.Amount == nil || takeOrder.Nft.Amount.Sign() <= 0 || !takeOrder.Nft.Amount.IsInt64() {
+		return 1
+	}
+
+	return takeOrder.Nft.Amount.Int64()
+}
+
+// fillOrderWithChange 按成交数量扣减订单剩余数量, 全部成交时置为 Filled, 返回订单是否已全部成交
+func (s *Service) fillOrderWithChange(tx *gorm.DB, blockNumber uint64, order *multi.Order, quantity int64, taker string) (bool, error) {
+	updates := map[string]interface{}{"quantity_remaining": order.QuantityRemaining - quantity}
+	filled := order.QuantityRemaining <= quantity
+	if filled {
+		updates = map[string]interface{}{
+			"order_status":       multi.OrderStatusFilled,
+			"quantity_remaining": 0,
+		}
+	}
+	if taker != "" {
+		updates["taker"] = taker
+	}
+	if err := s.updateOrderWithChange(tx, blockNumber, order.OrderID, updates); err != nil {
+		return false, errors.Wrapf(err, "failed on fill order, order_id: %s", order.OrderID)
+	}
+
+	return filled, nil
+}
@@ -0,0 +1,273 @@
+package orderbookindexer
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/chainclient"
+	"github.com/ProjectsTask/EasySwapBase/chain/types"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/ProjectsTask/EasySwapSync/service/collectionfilter"
+	"github.com/ProjectsTask/EasySwapSync/service/config"
+)
+
+func transferSingleLog(from, to common.Address, tokenId, value int64, blockNumber uint64, index uint) ethereumTypes.Log {
+	return ethereumTypes.Log{
+		Address: testCollection,
+		Topics: []common.Hash{
+			common.HexToHash(ERC1155TransferSingleTopic),
+			common.BytesToHash(from.Bytes()),
+			common.BytesToHash(from.Bytes()),
+			common.BytesToHash(to.Bytes()),
+		},
+		Data:        append(common.BigToHash(big.NewInt(tokenId)).Bytes(), common.BigToHash(big.NewInt(value)).Bytes()...),
+		BlockNumber: blockNumber,
+		TxHash:      common.BigToHash(big.NewInt(int64(blockNumber))),
+		Index:       index,
+	}
+}
+
+func transferBatchLog(t *testing.T, from, to common.Address, tokenIds, values []*big.Int, blockNumber uint64, index uint) ethereumTypes.Log {
+	data, err := erc1155BatchArgs.Pack(tokenIds, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ethereumTypes.Log{
+		Address: testCollection,
+		Topics: []common.Hash{
+			common.HexToHash(ERC1155TransferBatchTopic),
+			common.BytesToHash(from.Bytes()),
+			common.BytesToHash(from.Bytes()),
+			common.BytesToHash(to.Bytes()),
+		},
+		Data:        data,
+		BlockNumber: blockNumber,
+		TxHash:      common.BigToHash(big.NewInt(int64(blockNumber))),
+		Index:       index,
+	}
+}
+
+func TestApplyLogsTracksErc1155(t *testing.T) {
+	db := newTestDB(t)
+	cfg := &config.Config{
+		ContractCfg: config.ContractCfg{EthAddress: ZeroAddress},
+		ProjectCfg:  config.ProjectCfg{Name: gdb.OrderBookDexProject},
+	}
+	ctx := xzap.ToContext(context.Background(), zap.NewNop())
+	s := New(ctx, cfg, db, nil, fakeChainClient{}, 11155111, testChain, nil)
+	filter := collectionfilter.New(ctx, db, testChain, gdb.OrderBookDexProject)
+	filter.Add(testCollection.Hex())
+	s.EnableCollectionTracking(filter)
+
+	if err := db.Table(multi.CollectionTableName(testChain)).Create(&multi.Collection{
+		Address:       testCollection.String(),
+		TokenStandard: multi.TokenStandardERC721,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	seller := common.HexToAddress("0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266")
+	buyer := common.HexToAddress("0x70997970c51812dc3a010c7d01e0ea4bbb9a0c4e")
+	other := common.HexToAddress("0x3c44cdddb6a900fa2b585dd299e03d12fa4293bc")
+	listingKey := common.HexToHash("0x01")
+	bidKey := common.HexToHash("0x02")
+
+	sell := testOrder{Side: List, SaleKind: FixForItem, Maker: seller, Price: big.NewInt(1e16), Expiry: 1900000000,
+		Nft: testAsset{TokenId: big.NewInt(7), Collection: testCollection, Amount: big.NewInt(4)}}
+	bid := testOrder{Side: Bid, SaleKind: FixForItem, Maker: buyer, Price: big.NewInt(1e16), Expiry: 1900000000,
+		Nft: testAsset{TokenId: big.NewInt(7), Collection: testCollection, Amount: big.NewInt(3)}}
+
+	// Mint 10 个给卖家, 批量转给其他地址 3 个, 挂单 4 个, 买家购买其中 3 个
+	logs := []ethereumTypes.Log{
+		transferSingleLog(common.Address{}, seller, 7, 10, 101, 0),
+		transferBatchLog(t, seller, other, []*big.Int{big.NewInt(7)}, []*big.Int{big.NewInt(3)}, 102, 0),
+		makeLog(t, s, listingKey, List, FixForItem, seller, 4, 103, 0),
+		makeLog(t, s, bidKey, Bid, FixForItem, buyer, 3, 104, 0),
+		matchLog(t, s, listingKey, bidKey, sell, bid, 105, 0),
+	}
+	if _, err := s.applyLogs(logs, &types.BlockHeader{Number: 105, Hash: "0x105", ParentHash: "0x104"}); err != nil {
+		t.Fatal(err)
+	}
+
+	balances := func() map[string]int64 {
+		t.Helper()
+		var rows []multi.ItemBalance
+		if err := db.Table(multi.ItemBalanceTableName(testChain)).Find(&rows).Error; err != nil {
+			t.Fatal(err)
+		}
+		result := make(map[string]int64)
+		for _, row := range rows {
+			result[row.Owner] = row.Balance
+		}
+		return result
+	}
+	order := func(key common.Hash) multi.Order {
+		t.Helper()
+		var o multi.Order
+		if err := db.Table(multi.OrderTableName(testChain)).Where("order_id = ?", key.Hex()).First(&o).Error; err != nil {
+			t.Fatal(err)
+		}
+		return o
+	}
+	lower := func(addr common.Address) string {
+		return strings.ToLower(addr.Hex())
+	}
+
+	got := balances()
+	if got[lower(seller)] != 4 || got[lower(other)] != 3 || got[lower(buyer)] != 3 {
+		t.Fatalf("unexpected balances %v", got)
+	}
+	var collection multi.Collection
+	if err := db.Table(multi.CollectionTableName(testChain)).First(&collection).Error; err != nil {
+		t.Fatal(err)
+	}
+	if collection.TokenStandard != multi.TokenStandardERC1155 {
+		t.Fatalf("expected collection marked erc1155, got %d", collection.TokenStandard)
+	}
+	var item multi.Item
+	if err := db.Table(multi.ItemTableName(testChain)).First(&item).Error; err != nil {
+		t.Fatal(err)
+	}
+	if item.Owner != "" || item.Supply != 10 {
+		t.Fatalf("unexpected erc1155 item %+v", item)
+	}
+
+	// 部分成交: 挂单剩余 1 个仍有效, 买单全部成交
+	listing := order(listingKey)
+	if listing.OrderStatus != multi.OrderStatusActive || listing.QuantityRemaining != 1 {
+		t.Fatalf("unexpected listing state: status=%d remaining=%d", listing.OrderStatus, listing.QuantityRemaining)
+	}
+	if o := order(bidKey); o.OrderStatus != multi.OrderStatusFilled || o.QuantityRemaining != 0 {
+		t.Fatalf("unexpected bid state: status=%d remaining=%d", o.OrderStatus, o.QuantityRemaining)
+	}
+
+	// 卖家转出全部剩余数量后挂单失效
+	logs = []ethereumTypes.Log{transferSingleLog(seller, other, 7, 4, 106, 0)}
+	if _, err := s.applyLogs(logs, &types.BlockHeader{Number: 106, Hash: "0x106", ParentHash: "0x105"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := balances(); got[lower(seller)] != 0 || got[lower(other)] != 7 {
+		t.Fatalf("unexpected balances %v", got)
+	}
+	if listing := order(listingKey); listing.OrderStatus != multi.OrderStatusInactive {
+		t.Fatalf("expected listing deactivated, got status %d", listing.OrderStatus)
+	}
+
+	// 重组回滚到挂单之后, 持有数量与订单恢复
+	if err := s.rollbackAboveBlock(104); err != nil {
+		t.Fatal(err)
+	}
+	if got := balances(); got[lower(seller)] != 7 || got[lower(other)] != 3 || got[lower(buyer)] != 0 {
+		t.Fatalf("unexpected balances after rollback %v", got)
+	}
+	if listing := order(listingKey); listing.OrderStatus != multi.OrderStatusActive || listing.QuantityRemaining != 4 {
+		t.Fatalf("unexpected listing after rollback: status=%d remaining=%d", listing.OrderStatus, listing.QuantityRemaining)
+	}
+	if o := order(bidKey); o.OrderStatus != multi.OrderStatusActive || o.QuantityRemaining != 3 {
+		t.Fatalf("unexpected bid after rollback: status=%d remaining=%d", o.OrderStatus, o.QuantityRemaining)
+	}
+}
+
+// balanceChainClient 按持有人返回链上 ERC1155 持有数量
+type balanceChainClient struct {
+	fakeChainClient
+	balances map[common.Address]int64
+}
+
+func (c balanceChainClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if len(msg.Data) != 68 || common.Bytes2Hex(msg.Data[:4]) != erc1155BalanceOfSelector {
+		return nil, errors.New("unexpected call")
+	}
+	owner := common.BytesToAddress(msg.Data[4:36])
+	return common.BigToHash(big.NewInt(c.balances[owner])).Bytes(), nil
+}
+
+func TestErc1155PreSyncBalance(t *testing.T) {
+	seller := common.HexToAddress("0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266")
+	other := common.HexToAddress("0x3c44cdddb6a900fa2b585dd299e03d12fa4293bc")
+	listingKey := common.HexToHash("0x01")
+
+	run := func(t *testing.T, client chainclient.ChainClient) (*gorm.DB, *Service) {
+		db := newTestDB(t)
+		cfg := &config.Config{
+			ContractCfg: config.ContractCfg{EthAddress: ZeroAddress},
+			ProjectCfg:  config.ProjectCfg{Name: gdb.OrderBookDexProject},
+		}
+		ctx := xzap.ToContext(context.Background(), zap.NewNop())
+		s := New(ctx, cfg, db, nil, client, 11155111, testChain, nil)
+		filter := collectionfilter.New(ctx, db, testChain, gdb.OrderBookDexProject)
+		filter.Add(testCollection.Hex())
+		s.EnableCollectionTracking(filter)
+		if err := db.Table(multi.CollectionTableName(testChain)).Create(&multi.Collection{
+			Address:       strings.ToLower(testCollection.Hex()),
+			TokenStandard: multi.TokenStandardERC1155,
+		}).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		// 卖家同步开始前已持有, 挂单 4 个后转出 1 个
+		logs := []ethereumTypes.Log{
+			makeLog(t, s, listingKey, List, FixForItem, seller, 4, 101, 0),
+			transferSingleLog(seller, other, 7, 1, 102, 0),
+		}
+		if _, err := s.applyLogs(logs, &types.BlockHeader{Number: 102, Hash: "0x102", ParentHash: "0x101"}); err != nil {
+			t.Fatal(err)
+		}
+		return db, s
+	}
+	listingStatus := func(t *testing.T, db *gorm.DB) int {
+		var o multi.Order
+		if err := db.Table(multi.OrderTableName(testChain)).Where("order_id = ?", listingKey.Hex()).First(&o).Error; err != nil {
+			t.Fatal(err)
+		}
+		return o.OrderStatus
+	}
+	balance := func(t *testing.T, db *gorm.DB, owner common.Address) *multi.ItemBalance {
+		var row multi.ItemBalance
+		if err := db.Table(multi.ItemBalanceTableName(testChain)).
+			Where("owner = ?", strings.ToLower(owner.Hex())).Limit(1).Find(&row).Error; err != nil {
+			t.Fatal(err)
+		}
+		return &row
+	}
+
+	// 以链上持有数量为初始值, 剩余数量仍覆盖挂单
+	db, s := run(t, balanceChainClient{balances: map[common.Address]int64{seller: 5}})
+	if got := balance(t, db, seller).Balance; got != 4 {
+		t.Fatalf("expected seller balance 4, got %d", got)
+	}
+	if got := balance(t, db, other).Balance; got != 1 {
+		t.Fatalf("expected receiver balance 1, got %d", got)
+	}
+	if status := listingStatus(t, db); status != multi.OrderStatusActive {
+		t.Fatalf("expected listing active, got status %d", status)
+	}
+	// 回滚后恢复为链上初始值
+	if err := s.rollbackAboveBlock(101); err != nil {
+		t.Fatal(err)
+	}
+	if got := balance(t, db, seller).Balance; got != 5 {
+		t.Fatalf("expected seller balance 5 after rollback, got %d", got)
+	}
+
+	// 链上查询失败时持有数量未知, 不使挂单失效
+	db, _ = run(t, fakeChainClient{})
+	if row := balance(t, db, seller); row.Id != 0 {
+		t.Fatalf("expected no seller balance, got %+v", row)
+	}
+	if status := listingStatus(t, db); status != multi.OrderStatusActive {
+		t.Fatalf("expected listing active, got status %d", status)
+	}
+}
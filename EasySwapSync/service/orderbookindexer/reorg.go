@@ -0,0 +1,362 @@
+package orderbookindexer
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/types"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxReorgDepth 保留最近区块哈希与变更记录的区块数, 超过该深度的重组无法自动回滚
+const MaxReorgDepth = 256
+
+// headerFunc 按区块号获取链上区块头
+type headerFunc func(blockNumber uint64) (*types.BlockHeader, error)
+
+// detectReorg 比对链上 nextBlock 的父哈希与已记录的 nextBlock-1 哈希,
+// 不一致时向前查找分叉点, 返回最后一个与链上一致的区块号
+func (s *Service) detectReorg(nextBlock uint64) (uint64, bool, error) {
+	if nextBlock == 0 {
+		return 0, false, nil
+	}
+
+	var last base.IndexedBlock
+	if err := s.db.WithContext(s.ctx).Table(base.IndexedBlockTableName()).
+		Where("chain_id = ? and index_type = ? and block_number = ?", s.chainId, EventIndexType, nextBlock-1).
+		Limit(1).
+		Find(&last).Error; err != nil {
+		return 0, false, errors.Wrap(err, "failed on get indexed block hash")
+	}
+	// 升级前未记录区块哈希, 无法比对
+	if last.Id == 0 {
+		return 0, false, nil
+	}
+
+	header, err := s.headerByNumber(nextBlock)
+	if err != nil {
+		return 0, false, err
+	}
+	if strings.EqualFold(header.ParentHash, last.BlockHash) {
+		return 0, false, nil
+	}
+
+	var stored []base.IndexedBlock
+	if err := s.db.WithContext(s.ctx).Table(base.IndexedBlockTableName()).
+		Where("chain_id = ? and index_type = ? and block_number < ?", s.chainId, EventIndexType, nextBlock).
+		Order("block_number desc").
+		Limit(MaxReorgDepth).
+		Find(&stored).Error; err != nil {
+		return 0, false, errors.Wrap(err, "failed on get indexed block hashes")
+	}
+
+	forkBlock, found, err := findForkBlock(stored, s.headerByNumber)
+	if err != nil {
+		return 0, false, err
+	}
+	if !found {
+		xzap.WithContext(s.ctx).Error("reorg deeper than recorded block hashes, rollback to oldest recorded block",
+			zap.Int64("chain_id", s.chainId),
+			zap.Uint64("fork_block", forkBlock))
+	}
+
+	return forkBlock, true, nil
+}
+
+// findForkBlock 在按区块号倒序排列的已记录区块中查找第一个与链上哈希一致的区块,
+// 全部不一致时返回最早记录区块的前一个区块且 found 为 false
+func findForkBlock(stored []base.IndexedBlock, headerOf headerFunc) (uint64, bool, error) {
+	for _, block := range stored {
+		header, err := headerOf(uint64(block.BlockNumber))
+		if err != nil {
+			return 0, false, err
+		}
+		if strings.EqualFold(header.Hash, block.BlockHash) {
+			return uint64(block.BlockNumber), true, nil
+		}
+	}
+
+	if len(stored) == 0 || stored[len(stored)-1].BlockNumber == 0 {
+		return 0, false, nil
+	}
+	return uint64(stored[len(stored)-1].BlockNumber - 1), false, nil
+}
+
+func (s *Service) headerByNumber(blockNumber uint64) (*types.BlockHeader, error) {
+	header, err := s.chainClient.HeaderByNumber(s.ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get block header")
+	}
+	if header == nil {
+		return nil, errors.New("block header not found")
+	}
+
+	return header, nil
+}
+
+// recordBlockHashes 记录本批次结束区块的区块头以及日志所在区块的哈希, 并清理超出重组深度的区块、变更与已处理日志记录
+func (s *Service) recordBlockHashes(tx *gorm.DB, endHeader *types.BlockHeader, logs []ethereumTypes.Log) error {
+	blocks := []base.IndexedBlock{{
+		ChainId:     int(s.chainId),
+		IndexType:   EventIndexType,
+		BlockNumber: int64(endHeader.Number),
+		BlockHash:   endHeader.Hash,
+		ParentHash:  endHeader.ParentHash,
+	}}
+	seen := map[uint64]bool{endHeader.Number: true}
+	for _, log := range logs {
+		if seen[log.BlockNumber] {
+			continue
+		}
+		seen[log.BlockNumber] = true
+		blocks = append(blocks, base.IndexedBlock{
+			ChainId:     int(s.chainId),
+			IndexType:   EventIndexType,
+			BlockNumber: int64(log.BlockNumber),
+			BlockHash:   log.BlockHash.Hex(),
+		})
+	}
+
+	if err := tx.Table(base.IndexedBlockTableName()).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "chain_id"}, {Name: "index_type"}, {Name: "block_number"}},
+			DoUpdates: clause.AssignmentColumns([]string{"block_hash", "parent_hash"}),
+		}).Create(&blocks).Error; err != nil {
+		return errors.Wrap(err, "failed on record indexed block hashes")
+	}
+
+	if endHeader.Number <= MaxReorgDepth {
+		return nil
+	}
+	expired := int64(endHeader.Number - MaxReorgDepth)
+	if err := tx.Table(base.IndexedBlockTableName()).
+		Where("chain_id = ? and index_type = ? and block_number < ?", s.chainId, EventIndexType, expired).
+		Delete(&base.IndexedBlock{}).Error; err != nil {
+		return errors.Wrap(err, "failed on delete expired indexed block hashes")
+	}
+	if err := tx.Table(base.IndexedChangeTableName()).
+		Where("chain_id = ? and index_type = ? and block_number < ?", s.chainId, EventIndexType, expired).
+		Delete(&base.IndexedChange{}).Error; err != nil {
+		return errors.Wrap(err, "failed on delete expired indexed changes")
+	}
+	if err := tx.Table(base.IndexedEventTableName()).
+		Where("chain_id = ? and index_type = ? and block_number < ?", s.chainId, EventIndexType, expired).
+		Delete(&base.IndexedEvent{}).Error; err != nil {
+		return errors.Wrap(err, "failed on delete expired indexed events")
+	}
+
+	return nil
+}
+
+// rollbackAboveBlock 回滚 forkBlock 之后的所有订单、活动、NFT 所有权(含 ERC1155 持有数量)与授权变更, 并将同步进度重置到 forkBlock+1
+func (s *Service) rollbackAboveBlock(forkBlock uint64) error {
+	var changes []base.IndexedChange
+	collections := make(map[string]bool)
+
+	err := s.db.WithContext(s.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(base.IndexedChangeTableName()).
+			Where("chain_id = ? and index_type = ? and block_number > ?", s.chainId, EventIndexType, forkBlock).
+			Order("id desc").
+			Find(&changes).Error; err != nil {
+			return errors.Wrap(err, "failed on get indexed changes")
+		}
+
+		// 按变更记录逆序恢复
+		for _, change := range changes {
+			switch change.ChangeType {
+			case base.IndexedChangeOrderCreate:
+				if err := tx.Table(multi.OrderTableName(s.chain)).
+					Where("order_id = ?", change.OrderId).
+					Delete(&multi.Order{}).Error; err != nil {
+					return errors.Wrap(err, "failed on delete reorged order")
+				}
+			case base.IndexedChangeOrderUpdate:
+				if err := tx.Table(multi.OrderTableName(s.chain)).
+					Where("order_id = ?", change.OrderId).
+					Updates(map[string]interface{}{
+						"order_status":       change.PrevOrderStatus,
+						"quantity_remaining": change.PrevQuantityRemaining,
+						"taker":              change.PrevTaker,
+						"fillable":           change.PrevFillable,
+					}).Error; err != nil {
+					return errors.Wrap(err, "failed on restore reorged order")
+				}
+			case base.IndexedChangeItemOwner:
+				if err := tx.Table(multi.ItemTableName(s.chain)).
+					Where("collection_address = ? and token_id = ?", change.CollectionAddress, change.TokenId).
+					Update("owner", change.PrevOwner).Error; err != nil {
+					return errors.Wrap(err, "failed on restore reorged item owner")
+				}
+			case base.IndexedChangeItemCreate:
+				if err := tx.Table(multi.ItemTableName(s.chain)).
+					Where("collection_address = ? and token_id = ?", change.CollectionAddress, change.TokenId).
+					Delete(&multi.Item{}).Error; err != nil {
+					return errors.Wrap(err, "failed on delete reorged item")
+				}
+			case base.IndexedChangeApproval:
+				if err := tx.Table(multi.NftApprovalTableName(s.chain)).
+					Where("id = ?", change.ApprovalId).
+					Update("approved", change.PrevApproved).Error; err != nil {
+					return errors.Wrap(err, "failed on restore reorged nft approval")
+				}
+			case base.IndexedChangeBalance:
+				if err := tx.Table(multi.ItemBalanceTableName(s.chain)).
+					Where("id = ?", change.BalanceId).
+					Update("balance", change.PrevBalance).Error; err != nil {
+					return errors.Wrap(err, "failed on restore reorged item balance")
+				}
+			}
+			if change.CollectionAddress != "" {
+				collections[change.CollectionAddress] = true
+			}
+		}
+
+		if err := tx.Table(multi.ActivityTableName(s.chain)).
+			Where("block_number > ? and marketplace_id = ?", forkBlock, multi.MarketOrderBook).
+			Delete(&multi.Activity{}).Error; err != nil {
+			return errors.Wrap(err, "failed on delete reorged activities")
+		}
+		if err := tx.Table(base.IndexedChangeTableName()).
+			Where("chain_id = ? and index_type = ? and block_number > ?", s.chainId, EventIndexType, forkBlock).
+			Delete(&base.IndexedChange{}).Error; err != nil {
+			return errors.Wrap(err, "failed on delete reorged changes")
+		}
+		// 删除已处理日志记录, 分叉点之后的区块需重新处理
+		if err := tx.Table(base.IndexedEventTableName()).
+			Where("chain_id = ? and index_type = ? and block_number > ?", s.chainId, EventIndexType, forkBlock).
+			Delete(&base.IndexedEvent{}).Error; err != nil {
+			return errors.Wrap(err, "failed on delete reorged indexed events")
+		}
+		if err := tx.Table(base.IndexedBlockTableName()).
+			Where("chain_id = ? and index_type = ? and block_number > ?", s.chainId, EventIndexType, forkBlock).
+			Delete(&base.IndexedBlock{}).Error; err != nil {
+			return errors.Wrap(err, "failed on delete reorged block hashes")
+		}
+		if err := tx.Table(base.IndexedStatusTableName()).
+			Where("chain_id = ? and index_type = ?", s.chainId, EventIndexType).
+			Update("last_indexed_block", forkBlock+1).Error; err != nil {
+			return errors.Wrap(err, "failed on reset indexed block")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 回滚影响到的集合重新计算地板价
+	for collection := range collections {
+		if err := s.updateCollectionFloorPrice(s.db.WithContext(s.ctx), collection); err != nil {
+			xzap.WithContext(s.ctx).Error("failed on update collection floor price after rollback",
+				zap.Error(err),
+				zap.String("collection_address", collection))
+		}
+	}
+
+	xzap.WithContext(s.ctx).Warn("rolled back reorged blocks",
+		zap.Int64("chain_id", s.chainId),
+		zap.Uint64("fork_block", forkBlock),
+		zap.Int("changes", len(changes)))
+
+	return nil
+}
+
+// recordOrderCreate 记录新建订单, 重组时删除
+func (s *Service) recordOrderCreate(tx *gorm.DB, blockNumber uint64, order *multi.Order) error {
+	return s.recordChange(tx, &base.IndexedChange{
+		BlockNumber:       int64(blockNumber),
+		ChangeType:        base.IndexedChangeOrderCreate,
+		OrderId:           order.OrderID,
+		CollectionAddress: strings.ToLower(order.CollectionAddress),
+		TokenId:           order.TokenId,
+	})
+}
+
+// updateOrderWithChange 更新订单前记录变更前的状态
+func (s *Service) updateOrderWithChange(tx *gorm.DB, blockNumber uint64, orderID string, updates map[string]interface{}) error {
+	var prev multi.Order
+	if err := tx.Table(multi.OrderTableName(s.chain)).
+		Select("order_id,collection_address,token_id,order_status,quantity_remaining,taker,fillable").
+		Where("order_id = ?", orderID).
+		Limit(1).
+		Find(&prev).Error; err != nil {
+		return errors.Wrap(err, "failed on get order before update")
+	}
+	if prev.OrderID != "" {
+		if err := s.recordChange(tx, &base.IndexedChange{
+			BlockNumber:           int64(blockNumber),
+			ChangeType:            base.IndexedChangeOrderUpdate,
+			OrderId:               orderID,
+			CollectionAddress:     strings.ToLower(prev.CollectionAddress),
+			TokenId:               prev.TokenId,
+			PrevOrderStatus:       prev.OrderStatus,
+			PrevQuantityRemaining: prev.QuantityRemaining,
+			PrevTaker:             prev.Taker,
+			PrevFillable:          prev.Fillable,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Table(multi.OrderTableName(s.chain)).
+		Where("order_id = ?", orderID).
+		Updates(updates).Error
+}
+
+// updateItemOwnerWithChange 更新 NFT 所有者前记录原所有者
+func (s *Service) updateItemOwnerWithChange(tx *gorm.DB, blockNumber uint64, collectionAddress, tokenID, owner string) error {
+	var prev multi.Item
+	if err := tx.Table(multi.ItemTableName(s.chain)).
+		Select("id,owner").
+		Where("collection_address = ? and token_id = ?", collectionAddress, tokenID).
+		Limit(1).
+		Find(&prev).Error; err != nil {
+		return errors.Wrap(err, "failed on get item before update")
+	}
+	if prev.Id != 0 {
+		if err := s.recordChange(tx, &base.IndexedChange{
+			BlockNumber:       int64(blockNumber),
+			ChangeType:        base.IndexedChangeItemOwner,
+			CollectionAddress: collectionAddress,
+			TokenId:           tokenID,
+			PrevOwner:         prev.Owner,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Table(multi.ItemTableName(s.chain)).
+		Where("collection_address = ? and token_id = ?", collectionAddress, tokenID).
+		Update("owner", owner).Error
+}
+
+// createItemWithChange 新建 NFT 并记录变更, 重组时删除
+func (s *Service) createItemWithChange(tx *gorm.DB, blockNumber uint64, item *multi.Item) error {
+	if err := tx.Table(multi.ItemTableName(s.chain)).Create(item).Error; err != nil {
+		return errors.Wrap(err, "failed on create item")
+	}
+
+	return s.recordChange(tx, &base.IndexedChange{
+		BlockNumber:       int64(blockNumber),
+		ChangeType:        base.IndexedChangeItemCreate,
+		CollectionAddress: item.CollectionAddress,
+		TokenId:           item.TokenId,
+	})
+}
+
+func (s *Service) recordChange(tx *gorm.DB, change *base.IndexedChange) error {
+	change.ChainId = int(s.chainId)
+	change.IndexType = EventIndexType
+	if err := tx.Table(base.IndexedChangeTableName()).Create(change).Error; err != nil {
+		return errors.Wrap(err, "failed on record indexed change")
+	}
+
+	return nil
+}
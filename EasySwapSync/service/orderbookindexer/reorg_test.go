@@ -0,0 +1,173 @@
+package orderbookindexer
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/types"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/ProjectsTask/EasySwapSync/service/config"
+)
+
+func TestFindForkBlock(t *testing.T) {
+	// 已记录区块按区块号倒序
+	stored := []base.IndexedBlock{
+		{BlockNumber: 110, BlockHash: "0xold110"},
+		{BlockNumber: 105, BlockHash: "0xold105"},
+		{BlockNumber: 100, BlockHash: "0xhash100"},
+		{BlockNumber: 90, BlockHash: "0xhash90"},
+	}
+	chain := map[uint64]string{
+		110: "0xnew110",
+		105: "0xnew105",
+		100: "0xHASH100",
+		90:  "0xhash90",
+	}
+	headerOf := func(blockNumber uint64) (*types.BlockHeader, error) {
+		hash, ok := chain[blockNumber]
+		if !ok {
+			return nil, errors.New("unexpected block")
+		}
+		return &types.BlockHeader{Number: blockNumber, Hash: hash}, nil
+	}
+
+	fork, found, err := findForkBlock(stored, headerOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found || fork != 100 {
+		t.Fatalf("expected fork at 100, got %d (found=%v)", fork, found)
+	}
+
+	// 所有记录都已被重组时回退到最早记录区块之前
+	chain[100] = "0xnew100"
+	chain[90] = "0xnew90"
+	fork, found, err = findForkBlock(stored, headerOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found || fork != 89 {
+		t.Fatalf("expected fallback fork at 89, got %d (found=%v)", fork, found)
+	}
+
+	// 获取区块头失败时返回错误
+	delete(chain, 110)
+	if _, _, err := findForkBlock(stored, headerOf); err == nil {
+		t.Fatal("expected header error")
+	}
+}
+
+// marketState 返回订单、NFT 和活动表的数据, 忽略回滚时会刷新的 update_time
+func marketState(t *testing.T, db *gorm.DB) map[string][]map[string]interface{} {
+	state := make(map[string][]map[string]interface{})
+	for _, table := range []string{
+		multi.OrderTableName(testChain),
+		multi.ItemTableName(testChain),
+		multi.ActivityTableName(testChain),
+	} {
+		var rows []map[string]interface{}
+		if err := db.Table(table).Order("id").Find(&rows).Error; err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range rows {
+			delete(row, "update_time")
+		}
+		state[table] = rows
+	}
+
+	return state
+}
+
+func assertSameState(t *testing.T, want, got map[string][]map[string]interface{}) {
+	t.Helper()
+	for table, rows := range want {
+		if len(rows) != len(got[table]) {
+			t.Fatalf("%s: expected %d rows, got %d", table, len(rows), len(got[table]))
+		}
+		for i := range rows {
+			for column, value := range rows[i] {
+				if got[table][i][column] != value {
+					t.Fatalf("%s: %s expected %v, got %v", table, column, value, got[table][i][column])
+				}
+			}
+		}
+	}
+}
+
+func TestRollbackAboveBlock(t *testing.T) {
+	db := newTestDB(t)
+	cfg := &config.Config{
+		ContractCfg: config.ContractCfg{EthAddress: ZeroAddress},
+		ProjectCfg:  config.ProjectCfg{Name: gdb.OrderBookDexProject},
+	}
+	ctx := xzap.ToContext(context.Background(), zap.NewNop())
+	s := New(ctx, cfg, db, nil, fakeChainClient{}, 11155111, testChain, nil)
+
+	seller := common.HexToAddress("0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266")
+	buyer := common.HexToAddress("0x70997970c51812dc3a010c7d01e0ea4bbb9a0c4e")
+	listingKey := common.HexToHash("0x01")
+	bidKey := common.HexToHash("0x02")
+
+	nft := testAsset{TokenId: big.NewInt(7), Collection: testCollection, Amount: big.NewInt(1)}
+	sell := testOrder{Side: List, SaleKind: FixForItem, Maker: seller, Nft: nft, Price: big.NewInt(1e16), Expiry: 1900000000}
+	bid := testOrder{Side: Bid, SaleKind: FixForCollection, Maker: buyer, Nft: nft, Price: big.NewInt(1e16), Expiry: 1900000000}
+
+	empty := marketState(t, db)
+
+	// 区块 101 挂单, 挂单事件新建 NFT
+	if _, err := s.applyLogs([]ethereumTypes.Log{
+		makeLog(t, s, listingKey, List, FixForItem, seller, 1, 101, 0),
+	}, &types.BlockHeader{Number: 101, Hash: "0x101", ParentHash: "0x100"}); err != nil {
+		t.Fatal(err)
+	}
+	listed := marketState(t, db)
+	if n := len(listed[multi.ItemTableName(testChain)]); n != 1 {
+		t.Fatalf("expected 1 item after make, got %d", n)
+	}
+
+	// 区块 102-104 出价、成交、取消
+	if _, err := s.applyLogs([]ethereumTypes.Log{
+		makeLog(t, s, bidKey, Bid, FixForCollection, buyer, 3, 102, 0),
+		matchLog(t, s, bidKey, listingKey, bid, sell, 103, 0),
+		cancelLog(bidKey, buyer, 104, 0),
+	}, &types.BlockHeader{Number: 104, Hash: "0x104", ParentHash: "0x103"}); err != nil {
+		t.Fatal(err)
+	}
+	var listing multi.Order
+	if err := db.Table(multi.OrderTableName(testChain)).Where("order_id = ?", listingKey.Hex()).First(&listing).Error; err != nil {
+		t.Fatal(err)
+	}
+	if listing.OrderStatus != multi.OrderStatusFilled {
+		t.Fatalf("expected listing filled before rollback, got status %d", listing.OrderStatus)
+	}
+
+	// 回滚到 101, 订单、NFT 和活动恢复到挂单后的状态
+	if err := s.rollbackAboveBlock(101); err != nil {
+		t.Fatal(err)
+	}
+	assertSameState(t, listed, marketState(t, db))
+
+	// 回滚到 100, 挂单事件新建的 NFT 一并删除
+	if err := s.rollbackAboveBlock(100); err != nil {
+		t.Fatal(err)
+	}
+	assertSameState(t, empty, marketState(t, db))
+
+	var changes int64
+	if err := db.Table(base.IndexedChangeTableName()).Count(&changes).Error; err != nil {
+		t.Fatal(err)
+	}
+	if changes != 0 {
+		t.Fatalf("expected no indexed changes after rollback, got %d", changes)
+	}
+}
@@ -4,6 +4,9 @@
  * 功能：
  *   - 监听链上 EasySwapOrderBook 合约事件（Make, Match, Cancel）
  *   - 将链上事件同步到数据库（Orders, Activities, Items）
+ *   - 跟踪白名单集合的 ERC721 Transfer，维护 NFT 所有者并使失效挂单下线
+ *   - 跟踪白名单集合的 ERC1155 TransferSingle/TransferBatch，维护各持有人的持有数量
+ *   - 跟踪白名单集合的 ERC721 Approval/ApprovalForAll，维护授权状态与挂单是否可成交
  *   - 维护 NFT 集合地板价
  *   - 处理区块链分叉（Reorg）
  */
@@ -21,11 +24,11 @@ import (
 	"time"
 
 	"github.com/ProjectsTask/EasySwapBase/chain/chainclient"
-	"github.com/ProjectsTask/EasySwapBase/chain/types"
 	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/marketfeed"
 	"github.com/ProjectsTask/EasySwapBase/ordermanager"
+	"github.com/ProjectsTask/EasySwapBase/retry"
 	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
-	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
 	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
 	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
 	"github.com/ethereum/go-ethereum"
@@ -39,6 +42,7 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
+	"github.com/ProjectsTask/EasySwapSync/service/collectionfilter"
 	"github.com/ProjectsTask/EasySwapSync/service/comm"
 	"github.com/ProjectsTask/EasySwapSync/service/config"
 )
@@ -46,7 +50,7 @@ import (
 const (
 	EventIndexType  = 6   // 索引类型：6 代表订单簿事件
 	SleepInterval   = 10  // 轮询间隔：10秒
-	SyncBlockPeriod = 100 // 同步步长：每次请求 100 个区块（提高同步效率）
+	SyncBlockPeriod = 100 // 同步步长：初始每次请求 100 个区块, 之后按请求结果自适应调整
 
 	// 链上事件 Topic 签名（Keccak256）
 	// LogMake: 创建订单
@@ -57,6 +61,8 @@ const (
 	LogMatchTopic = "0xf629aecab94607bc43ce4aebd564bf6e61c7327226a797b002de724b9944b20e"
 	// ERC721 Approval: 授权事件
 	ERC721ApprovalTopic = "0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"
+	// ERC721 Transfer: 转移事件, tokenId 为第 3 个 indexed 参数 (ERC20 Transfer 只有 3 个 topic)
+	ERC721TransferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
 	contractAbi         = `[{"inputs":[],"name":"CannotFindNextEmptyKey","type":"error"},{"inputs":[],"name":"CannotFindPrevEmptyKey","type":"error"},{"inputs":[{"internalType":"OrderKey","name":"orderKey","type":"bytes32"}],"name":"CannotInsertDuplicateOrder","type":"error"},{"inputs":[],"name":"CannotInsertEmptyKey","type":"error"},{"inputs":[],"name":"CannotInsertExistingKey","type":"error"},{"inputs":[],"name":"CannotRemoveEmptyKey","type":"error"},{"inputs":[],"name":"CannotRemoveMissingKey","type":"error"},{"inputs":[],"name":"EnforcedPause","type":"error"},{"inputs":[],"name":"ExpectedPause","type":"error"},{"inputs":[],"name":"InvalidInitialization","type":"error"},{"inputs":[],"name":"NotInitializing","type":"error"},{"inputs":[{"internalType":"address","name":"owner","type":"address"}],"name":"OwnableInvalidOwner","type":"error"},{"inputs":[{"internalType":"address","name":"account","type":"address"}],"name":"OwnableUnauthorizedAccount","type":"error"},{"inputs":[],"name":"ReentrancyGuardReentrantCall","type":"error"},{"anonymous":false,"inputs":[{"indexed":false,"internalType":"uint256","name":"offset","type":"uint256"},{"indexed":false,"internalType":"bytes","name":"msg","type":"bytes"}],"name":"BatchMatchInnerError","type":"event"},{"anonymous":false,"inputs":[],"name":"EIP712DomainChanged","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"internalType":"uint64","name":"version","type":"uint64"}],"name":"Initialized","type":"event"},{"anonymous":false,"inputs":[{"indexed":true,"internalType":"OrderKey","name":"orderKey","type":"bytes32"},{"indexed":true,"internalType":"address","name":"maker","type":"address"}],"name":"LogCancel","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"internalType":"OrderKey","name":"orderKey","type":"bytes32"},{"indexed":true,"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"indexed":true,"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"indexed":true,"internalType":"address","name":"maker","type":"address"},{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"}],"indexed":false,"internalType":"structLibOrder.Asset","name":"nft","type":"tuple"},{"indexed":false,"internalType":"Price","name":"price","type":"uint128"},{"indexed":false,"internalType":"uint64","name":"expiry","type":"uint64"},{"indexed":false,"internalType":"uint64","name":"salt","type":"uint64"}],"name":"LogMake","type":"event"},{"anonymous":false,"inputs":[{"indexed":true,"internalType":"OrderKey","name":"makeOrderKey","type":"bytes32"},{"indexed":true,"internalType":"OrderKey","name":"takeOrderKey","type":"bytes32"},{"components":[{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"internalType":"address","name":"maker","type":"address"},{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"}],"internalType":"structLibOrder.Asset","name":"nft","type":"tuple"},{"internalType":"Price","name":"price","type":"uint128"},{"internalType":"uint64","name":"expiry","type":"uint64"},{"internalType":"uint64","name":"salt","type":"uint64"}],"indexed":false,"internalType":"structLibOrder.Order","name":"makeOrder","type":"tuple"},{"components":[{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"internalType":"address","name":"maker","type":"address"},{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"}],"internalType":"structLibOrder.Asset","name":"nft","type":"tuple"},{"internalType":"Price","name":"price","type":"uint128"},{"internalType":"uint64","name":"expiry","type":"uint64"},{"internalType":"uint64","name":"salt","type":"uint64"}],"indexed":false,"internalType":"structLibOrder.Order","name":"takeOrder","type":"tuple"},{"indexed":false,"internalType":"uint128","name":"fillPrice","type":"uint128"}],"name":"LogMatch","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"internalType":"OrderKey","name":"orderKey","type":"bytes32"},{"indexed":false,"internalType":"uint64","name":"salt","type":"uint64"}],"name":"LogSkipOrder","type":"event"},{"anonymous":false,"inputs":[{"indexed":true,"internalType":"uint128","name":"newProtocolShare","type":"uint128"}],"name":"LogUpdatedProtocolShare","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"internalType":"address","name":"recipient","type":"address"},{"indexed":false,"internalType":"uint256","name":"amount","type":"uint256"}],"name":"LogWithdrawETH","type":"event"},{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"previousOwner","type":"address"},{"indexed":true,"internalType":"address","name":"newOwner","type":"address"}],"name":"OwnershipTransferred","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"internalType":"address","name":"account","type":"address"}],"name":"Paused","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"internalType":"address","name":"account","type":"address"}],"name":"Unpaused","type":"event"},{"inputs":[{"internalType":"OrderKey[]","name":"orderKeys","type":"bytes32[]"}],"name":"cancelOrders","outputs":[{"internalType":"bool[]","name":"successes","type":"bool[]"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"components":[{"internalType":"OrderKey","name":"oldOrderKey","type":"bytes32"},{"components":[{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"internalType":"address","name":"maker","type":"address"},{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"}],"internalType":"structLibOrder.Asset","name":"nft","type":"tuple"},{"internalType":"Price","name":"price","type":"uint128"},{"internalType":"uint64","name":"expiry","type":"uint64"},{"internalType":"uint64","name":"salt","type":"uint64"}],"internalType":"structLibOrder.Order","name":"newOrder","type":"tuple"}],"internalType":"structLibOrder.EditDetail[]","name":"editDetails","type":"tuple[]"}],"name":"editOrders","outputs":[{"internalType":"OrderKey[]","name":"newOrderKeys","type":"bytes32[]"}],"stateMutability":"payable","type":"function"},{"inputs":[],"name":"eip712Domain","outputs":[{"internalType":"bytes1","name":"fields","type":"bytes1"},{"internalType":"string","name":"name","type":"string"},{"internalType":"string","name":"version","type":"string"},{"internalType":"uint256","name":"chainId","type":"uint256"},{"internalType":"address","name":"verifyingContract","type":"address"},{"internalType":"bytes32","name":"salt","type":"bytes32"},{"internalType":"uint256[]","name":"extensions","type":"uint256[]"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"OrderKey","name":"","type":"bytes32"}],"name":"filledAmount","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"}],"name":"getBestOrder","outputs":[{"components":[{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"internalType":"address","name":"maker","type":"address"},{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"}],"internalType":"structLibOrder.Asset","name":"nft","type":"tuple"},{"internalType":"Price","name":"price","type":"uint128"},{"internalType":"uint64","name":"expiry","type":"uint64"},{"internalType":"uint64","name":"salt","type":"uint64"}],"internalType":"structLibOrder.Order","name":"orderResult","type":"tuple"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"address","name":"collection","type":"address"},{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"}],"name":"getBestPrice","outputs":[{"internalType":"Price","name":"price","type":"uint128"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"address","name":"collection","type":"address"},{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"Price","name":"price","type":"uint128"}],"name":"getNextBestPrice","outputs":[{"internalType":"Price","name":"nextBestPrice","type":"uint128"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"internalType":"uint256","name":"count","type":"uint256"},{"internalType":"Price","name":"price","type":"uint128"},{"internalType":"OrderKey","name":"firstOrderKey","type":"bytes32"}],"name":"getOrders","outputs":[{"components":[{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"internalType":"address","name":"maker","type":"address"},{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"}],"internalType":"structLibOrder.Asset","name":"nft","type":"tuple"},{"internalType":"Price","name":"price","type":"uint128"},{"internalType":"uint64","name":"expiry","type":"uint64"},{"internalType":"uint64","name":"salt","type":"uint64"}],"internalType":"structLibOrder.Order[]","name":"resultOrders","type":"tuple[]"},{"internalType":"OrderKey","name":"nextOrderKey","type":"bytes32"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"uint128","name":"newProtocolShare","type":"uint128"},{"internalType":"address","name":"newVault","type":"address"},{"internalType":"string","name":"EIP712Name","type":"string"},{"internalType":"string","name":"EIP712Version","type":"string"}],"name":"initialize","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"components":[{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"internalType":"address","name":"maker","type":"address"},{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"}],"internalType":"structLibOrder.Asset","name":"nft","type":"tuple"},{"internalType":"Price","name":"price","type":"uint128"},{"internalType":"uint64","name":"expiry","type":"uint64"},{"internalType":"uint64","name":"salt","type":"uint64"}],"internalType":"structLibOrder.Order[]","name":"newOrders","type":"tuple[]"}],"name":"makeOrders","outputs":[{"internalType":"OrderKey[]","name":"newOrderKeys","type":"bytes32[]"}],"stateMutability":"payable","type":"function"},{"inputs":[{"components":[{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"internalType":"address","name":"maker","type":"address"},{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"}],"internalType":"structLibOrder.Asset","name":"nft","type":"tuple"},{"internalType":"Price","name":"price","type":"uint128"},{"internalType":"uint64","name":"expiry","type":"uint64"},{"internalType":"uint64","name":"salt","type":"uint64"}],"internalType":"structLibOrder.Order","name":"sellOrder","type":"tuple"},{"components":[{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"internalType":"address","name":"maker","type":"address"},{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"}],"internalType":"structLibOrder.Asset","name":"nft","type":"tuple"},{"internalType":"Price","name":"price","type":"uint128"},{"internalType":"uint64","name":"expiry","type":"uint64"},{"internalType":"uint64","name":"salt","type":"uint64"}],"internalType":"structLibOrder.Order","name":"buyOrder","type":"tuple"}],"name":"matchOrder","outputs":[],"stateMutability":"payable","type":"function"},{"inputs":[{"components":[{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"internalType":"address","name":"maker","type":"address"},{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"}],"internalType":"structLibOrder.Asset","name":"nft","type":"tuple"},{"internalType":"Price","name":"price","type":"uint128"},{"internalType":"uint64","name":"expiry","type":"uint64"},{"internalType":"uint64","name":"salt","type":"uint64"}],"internalType":"structLibOrder.Order","name":"sellOrder","type":"tuple"},{"components":[{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"internalType":"address","name":"maker","type":"address"},{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"}],"internalType":"structLibOrder.Asset","name":"nft","type":"tuple"},{"internalType":"Price","name":"price","type":"uint128"},{"internalType":"uint64","name":"expiry","type":"uint64"},{"internalType":"uint64","name":"salt","type":"uint64"}],"internalType":"structLibOrder.Order","name":"buyOrder","type":"tuple"},{"internalType":"uint256","name":"msgValue","type":"uint256"}],"name":"matchOrderWithoutPayback","outputs":[{"internalType":"uint128","name":"costValue","type":"uint128"}],"stateMutability":"payable","type":"function"},{"inputs":[{"components":[{"components":[{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"internalType":"address","name":"maker","type":"address"},{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"}],"internalType":"structLibOrder.Asset","name":"nft","type":"tuple"},{"internalType":"Price","name":"price","type":"uint128"},{"internalType":"uint64","name":"expiry","type":"uint64"},{"internalType":"uint64","name":"salt","type":"uint64"}],"internalType":"structLibOrder.Order","name":"sellOrder","type":"tuple"},{"components":[{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"internalType":"address","name":"maker","type":"address"},{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"}],"internalType":"structLibOrder.Asset","name":"nft","type":"tuple"},{"internalType":"Price","name":"price","type":"uint128"},{"internalType":"uint64","name":"expiry","type":"uint64"},{"internalType":"uint64","name":"salt","type":"uint64"}],"internalType":"structLibOrder.Order","name":"buyOrder","type":"tuple"}],"internalType":"structLibOrder.MatchDetail[]","name":"matchDetails","type":"tuple[]"}],"name":"matchOrders","outputs":[{"internalType":"bool[]","name":"successes","type":"bool[]"}],"stateMutability":"payable","type":"function"},{"inputs":[{"internalType":"address","name":"","type":"address"},{"internalType":"enumLibOrder.Side","name":"","type":"uint8"},{"internalType":"Price","name":"","type":"uint128"}],"name":"orderQueues","outputs":[{"internalType":"OrderKey","name":"head","type":"bytes32"},{"internalType":"OrderKey","name":"tail","type":"bytes32"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"OrderKey","name":"","type":"bytes32"}],"name":"orders","outputs":[{"components":[{"internalType":"enumLibOrder.Side","name":"side","type":"uint8"},{"internalType":"enumLibOrder.SaleKind","name":"saleKind","type":"uint8"},{"internalType":"address","name":"maker","type":"address"},{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"collection","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"}],"internalType":"structLibOrder.Asset","name":"nft","type":"tuple"},{"internalType":"Price","name":"price","type":"uint128"},{"internalType":"uint64","name":"expiry","type":"uint64"},{"internalType":"uint64","name":"salt","type":"uint64"}],"internalType":"structLibOrder.Order","name":"order","type":"tuple"},{"internalType":"OrderKey","name":"next","type":"bytes32"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"owner","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"pause","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[],"name":"paused","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"address","name":"","type":"address"},{"internalType":"enumLibOrder.Side","name":"","type":"uint8"}],"name":"priceTrees","outputs":[{"internalType":"Price","name":"root","type":"uint128"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"protocolShare","outputs":[{"internalType":"uint128","name":"","type":"uint128"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"renounceOwnership","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"uint128","name":"newProtocolShare","type":"uint128"}],"name":"setProtocolShare","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"address","name":"newVault","type":"address"}],"name":"setVault","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"address","name":"newOwner","type":"address"}],"name":"transferOwnership","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[],"name":"unpause","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"address","name":"recipient","type":"address"},{"internalType":"uint256","name":"amount","type":"uint256"}],"name":"withdrawETH","outputs":[],"stateMutability":"nonpayable","type":"function"},{"stateMutability":"payable","type":"receive"}]`
 	FixForCollection    = 0
 	FixForItem          = 1
@@ -92,6 +98,20 @@ type Service struct {
 	chain        string
 	parsedAbi    abi.ABI
 	vaultAddress string
+	pollInterval time.Duration
+	// 确认区块数: 只同步落后链上最新高度该数量以上的区块
+	confirmations uint64
+
+	// 自适应区块范围与各类 RPC 错误的重试策略
+	blockRange *blockRange
+	rpcBackoff map[chainclient.ErrorClass][]retry.Strategy
+
+	// 流式模式: 通过 WebSocket 订阅新区块头与日志, 未启用时 stream 为 nil
+	streamClient chainclient.ChainClient
+	stream       *logStream
+
+	// 跟踪 ERC721 转移与授权事件的集合, 未启用时为 nil
+	collectionFilter *collectionfilter.Filter
 }
 
 var MultiChainMaxBlockDifference = map[string]uint64{
@@ -105,35 +125,86 @@ var MultiChainMaxBlockDifference = map[string]uint64{
 
 func New(ctx context.Context, cfg *config.Config, db *gorm.DB, xkv *xkv.Store, chainClient chainclient.ChainClient, chainId int64, chain string, orderManager *ordermanager.OrderManager) *Service {
 	parsedAbi, _ := abi.JSON(strings.NewReader(contractAbi)) // 通过ABI实例化
+	confirmations := cfg.ChainCfg.Confirmations
+	if confirmations == 0 {
+		confirmations = MultiChainMaxBlockDifference[chain]
+	}
 	return &Service{
-		ctx:          ctx,
-		cfg:          cfg,
-		db:           db,
-		kv:           xkv,
-		chainClient:  chainClient,
-		orderManager: orderManager,
-		chain:        chain,
-		chainId:      chainId,
-		parsedAbi:    parsedAbi,
-		vaultAddress: cfg.ContractCfg.VaultAddress,
+		ctx:           ctx,
+		cfg:           cfg,
+		db:            db,
+		kv:            xkv,
+		chainClient:   chainClient,
+		orderManager:  orderManager,
+		chain:         chain,
+		chainId:       chainId,
+		parsedAbi:     parsedAbi,
+		vaultAddress:  cfg.ContractCfg.VaultAddress,
+		pollInterval:  SleepInterval * time.Second,
+		confirmations: confirmations,
+		blockRange:    newBlockRange(SyncBlockPeriod, MinSyncBlockRange, MaxSyncBlockRange),
+		rpcBackoff:    newRPCBackoff(time.Second),
 	}
 }
 
 func (s *Service) Start() {
+	if s.stream != nil {
+		threading.GoSafe(s.SyncOrderBookStreamLoop)
+	}
 	threading.GoSafe(s.SyncOrderBookEventLoop)
 	threading.GoSafe(s.UpKeepingCollectionFloorChangeLoop)
 }
 
+// Run 启动全部同步循环并阻塞, 上下文取消时返回 nil;
+// 任一循环提前退出(初始化失败或 panic)时返回错误, 由调用方取消上下文停止其余循环并决定是否重启
+func (s *Service) Run() error {
+	// 同步进度记录缺失时循环无法启动, 先按配置的起始区块补齐
+	if err := s.initIndexedStatus(); err != nil {
+		return errors.Wrap(err, "failed on init indexed status")
+	}
+
+	loops := map[string]func(){
+		"event":       s.SyncOrderBookEventLoop,
+		"floor_price": s.UpKeepingCollectionFloorChangeLoop,
+	}
+	if s.stream != nil {
+		loops["stream"] = s.SyncOrderBookStreamLoop
+	}
+
+	exited := make(chan error, len(loops))
+	for name, loop := range loops {
+		go func(name string, loop func()) {
+			defer func() {
+				if r := recover(); r != nil {
+					exited <- errors.Errorf("%s loop panic: %v", name, r)
+					return
+				}
+				exited <- errors.Errorf("%s loop exited", name)
+			}()
+			loop()
+		}(name, loop)
+	}
+
+	select {
+	case <-s.ctx.Done():
+		return nil
+	case err := <-exited:
+		if s.ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+}
+
 // SyncOrderBookEventLoop 订单簿事件同步主循环
-// 持续监听链上事件并同步到数据库
+// 持续监听链上事件并同步到数据库; 流式模式下收到新区块头立即同步, 并优先使用订阅推送的日志,
+// 订阅未覆盖的区块(启动追赶、断线期间)仍通过 FilterLogs 轮询获取
 func (s *Service) SyncOrderBookEventLoop() {
-	var indexedStatus base.IndexedStatus
 	// 1. 获取上次同步进度
 	// 从 indexed_status 表中读取最后一次成功同步的区块高度
 	// 如果服务重启，将从这个高度继续，防止重复或遗漏
-	if err := s.db.WithContext(s.ctx).Table(base.IndexedStatusTableName()).
-		Where("chain_id = ? and index_type = ?", s.chainId, EventIndexType).
-		First(&indexedStatus).Error; err != nil {
+	indexedStatus, err := s.getIndexedStatus(EventIndexType)
+	if err != nil {
 		xzap.WithContext(s.ctx).Error("failed on get listing index status",
 			zap.Error(err))
 		return
@@ -152,94 +223,94 @@ func (s *Service) SyncOrderBookEventLoop() {
 		currentBlockNum, err := s.chainClient.BlockNumber() // 以轮询的方式获取当前区块高度
 		if err != nil {
 			xzap.WithContext(s.ctx).Error("failed on get current block number", zap.Error(err))
-			time.Sleep(SleepInterval * time.Second)
+			s.sleep()
 			continue
 		}
 
 		// 3. 检查是否需要等待（防止超过当前高度）
-		// MultiChainMaxBlockDifference 用于防止同步到未确认的区块（特别是 Reorg 风险）
+		// confirmations(默认取 MultiChainMaxBlockDifference) 用于防止同步到未确认的区块（特别是 Reorg 风险）
 		// 如果落后于最新区块不足一定数量（如 ETH 是 8 个区块），则等待
-		if lastSyncBlock > currentBlockNum-MultiChainMaxBlockDifference[s.chain] { // 如果上次同步的区块高度大于当前区块高度，等待一段时间后再次轮询
-			time.Sleep(SleepInterval * time.Second)
+		if lastSyncBlock > currentBlockNum-s.confirmations { // 如果上次同步的区块高度大于当前区块高度，等待新区块后再次同步
+			s.waitForNewBlock()
 			continue
 		}
 
-		// 4. 计算本次同步的区块范围 [startBlock, endBlock]
-		startBlock := lastSyncBlock
-		endBlock := startBlock + SyncBlockPeriod
-		if endBlock > currentBlockNum-MultiChainMaxBlockDifference[s.chain] { // 如果结束区块高度大于当前区块高度，将结束区块高度设置为当前区块高度
-			endBlock = currentBlockNum - MultiChainMaxBlockDifference[s.chain]
+		// 3.1 链重组检测: 链上 lastSyncBlock 的父哈希需与已记录的上一区块哈希一致,
+		// 不一致时回滚分叉点之后的所有变更并从分叉点重新索引
+		forkBlock, reorged, err := s.detectReorg(lastSyncBlock)
+		if err != nil {
+			xzap.WithContext(s.ctx).Error("failed on detect reorg", zap.Error(err))
+			s.sleep()
+			continue
 		}
-
-		query := types.FilterQuery{
-			FromBlock: new(big.Int).SetUint64(startBlock),
-			ToBlock:   new(big.Int).SetUint64(endBlock),
-			Addresses: []string{s.cfg.ContractCfg.DexAddress},
+		if reorged {
+			if err := s.rollbackAboveBlock(forkBlock); err != nil {
+				xzap.WithContext(s.ctx).Error("failed on rollback reorged blocks",
+					zap.Error(err),
+					zap.Uint64("fork_block", forkBlock))
+				s.sleep()
+				continue
+			}
+			lastSyncBlock = forkBlock + 1
+			continue
 		}
 
-		// 5. 调用 RPC 获取日志
-		// FilterLogs: 根据查询条件向节点请求日志数据
-		// 这里会获取 [startBlock, endBlock] 范围内的所有符合条件（Contract Address）的日志
-		logs, err := s.chainClient.FilterLogs(s.ctx, query) //同时获取多个（SyncBlockPeriod）区块的日志
+		// 4. 按自适应范围获取 [startBlock, endBlock] 内合约地址的日志
+		// 范围根据请求耗时、日志数量与节点错误动态伸缩, 不超过已确认的区块高度
+		// 失败时按错误类型(结果过多、限流、超时、其他)分别重试, 仍失败则等待下一轮
+		startBlock := lastSyncBlock
+		logs, endBlock, err := s.fetchLogsAdaptive(startBlock, currentBlockNum-s.confirmations)
 		if err != nil {
 			xzap.WithContext(s.ctx).Error("failed on get log",
 				zap.Error(err),
-				zap.Uint64("start_block", startBlock),
-				zap.Uint64("end_block", endBlock),
-				zap.Uint64("block_range", endBlock-startBlock+1))
-
-			// 如果是因为请求范围太大导致的错误，尝试减少批量大小
-			if endBlock-startBlock > 1 {
-				xzap.WithContext(s.ctx).Warn("reducing block range due to RPC error",
-					zap.Uint64("original_range", endBlock-startBlock+1),
-					zap.Uint64("new_range", 1))
-				// 只处理单个区块
-				endBlock = startBlock
-				query.ToBlock = new(big.Int).SetUint64(endBlock)
-
-				// 重试单个区块
-				logs, err = s.chainClient.FilterLogs(s.ctx, query)
-				if err != nil {
-					xzap.WithContext(s.ctx).Error("failed on get log even with single block",
-						zap.Error(err),
-						zap.Uint64("block", startBlock))
-					time.Sleep(SleepInterval * time.Second)
-					continue
-				}
-			} else {
-				time.Sleep(SleepInterval * time.Second)
-				continue
-			}
+				zap.Uint64("start_block", startBlock))
+			s.sleep()
+			continue
 		}
 
-		// 6. 遍历并处理日志
-		// 对于每个获取到的日志，根据其 Topic[0] (事件签名) 分发给不同的处理函数
-		for _, log := range logs { // 遍历日志，根据不同的topic处理不同的事件
-			ethLog := log.(ethereumTypes.Log)
-			switch ethLog.Topics[0].String() {
-			case LogMakeTopic:
-				s.handleMakeEvent(ethLog) // 处理挂单 (Listing/Bid)
-			case LogCancelTopic:
-				s.handleCancelEvent(ethLog) // 处理取消
-			case LogMatchTopic:
-				s.handleMatchEvent(ethLog) // 处理成交
-			case ERC721ApprovalTopic:
-				s.handleApprovalEvent(ethLog) // 处理授权
-			default:
-				// 忽略其他未关注的事件
+		// 5. 获取结束区块的区块头, 用于下一轮的父哈希比对
+		// 日志所在区块的哈希与区块头不一致说明请求期间发生了重组, 下一轮重新获取
+		endHeader, err := s.headerByNumber(endBlock)
+		if err != nil {
+			xzap.WithContext(s.ctx).Error("failed on get end block header",
+				zap.Error(err),
+				zap.Uint64("end_block", endBlock))
+			s.sleep()
+			continue
+		}
+		consistent := true
+		for _, ethLog := range logs {
+			if ethLog.BlockNumber == endBlock && !strings.EqualFold(ethLog.BlockHash.Hex(), endHeader.Hash) {
+				consistent = false
+				break
 			}
 		}
+		if !consistent {
+			xzap.WithContext(s.ctx).Warn("block hash changed while fetching logs, retry",
+				zap.Uint64("end_block", endBlock))
+			s.sleep()
+			continue
+		}
+
+		// 6. 在同一个数据库事务内处理日志、记录区块哈希并更新同步进度
+		// 任一写入失败则整批回滚, 下一轮从 startBlock 重新处理; 已处理的日志按 (tx_hash, log_index) 跳过
+		effects, err := s.applyLogs(logs, endHeader)
+		if err != nil {
+			xzap.WithContext(s.ctx).Error("failed on apply orderbook events",
+				zap.Error(err),
+				zap.Uint64("start_block", startBlock),
+				zap.Uint64("end_block", endBlock))
+			s.sleep()
+			continue
+		}
 
-		// 7. 更新同步进度到数据库
-		// 处理完一批区块后，更新 indexed_status 表，标记这批区块已处理完成
-		// 下次循环将从 endBlock + 1 开始
+		// 7. 事务提交后执行外部副作用(订单队列、价格事件、元数据拉取)
+		for _, effect := range effects {
+			effect()
+		}
 		lastSyncBlock = endBlock + 1 // 更新最后同步的区块高度
-		if err := s.db.WithContext(s.ctx).Table(base.IndexedStatusTableName()).
-			Where("chain_id = ? and index_type = ?", s.chainId, EventIndexType).
-			Update("last_indexed_block", lastSyncBlock).Error; err != nil {
-			xzap.WithContext(s.ctx).Error("failed on update orderbook event sync block number",
-				zap.Error(err))
-			return
+		if s.stream != nil {
+			s.stream.prune(endBlock)
 		}
 
 		xzap.WithContext(s.ctx).Info("sync orderbook event ...",
@@ -250,17 +321,7 @@ func (s *Service) SyncOrderBookEventLoop() {
 
 // 处理挂单事件 (LogMake)
 // 当用户在链上创建订单时触发
-func (s *Service) handleMakeEvent(log ethereumTypes.Log) {
-	// 1. 检查区块链分叉 (Reorg)
-	// 如果发现当前事件所在的交易哈希已经存在，但区块高度不同，说明发生了分叉
-	if err := s.checkAndHandleFork(log.BlockNumber, log.TxHash.String()); err != nil {
-		xzap.WithContext(s.ctx).Error("failed to handle fork for make event",
-			zap.Error(err),
-			zap.Uint64("block_number", log.BlockNumber),
-			zap.String("tx_hash", log.TxHash.String()))
-		return
-	}
-
+func (s *Service) handleMakeEvent(b *eventBatch, log ethereumTypes.Log) error {
 	// 定义用于解析 LogMake 事件非索引参数的匿名结构体
 	// 必须与合约 ABI 中的 event 定义严格匹配
 	var event struct {
@@ -277,10 +338,11 @@ func (s *Service) handleMakeEvent(log ethereumTypes.Log) {
 
 	// 2. 解析事件日志数据
 	// 使用 ABI Unpack 将日志数据解析到 event 结构体中
+	// 解析失败重试也无法成功, 记录日志后跳过
 	err := s.parsedAbi.UnpackIntoInterface(&event, "LogMake", log.Data) // 通过ABI解析日志数据
 	if err != nil {
 		xzap.WithContext(s.ctx).Error("Error unpacking LogMake event:", zap.Error(err))
-		return
+		return nil
 	}
 	// 3. 提取 Indexed 字段 (Topic 1, 2, 3)
 	// Topic 0 是事件签名，Topic 1-3 是 indexed 参数
@@ -302,58 +364,85 @@ func (s *Service) handleMakeEvent(log ethereumTypes.Log) {
 	}
 	// 5. 创建订单对象并保存到数据库
 	// 将链上事件数据转换为数据库模型
+	// 地址统一小写存储, 与 NFT 所有权、持有数量与授权记录一致
 	newOrder := multi.Order{
-		CollectionAddress: event.Nft.CollectionAddr.String(),
+		CollectionAddress: strings.ToLower(event.Nft.CollectionAddr.String()),
 		MarketplaceId:     multi.MarketOrderBook, // 标识来自自营市场
 		TokenId:           event.Nft.TokenId.String(),
 		OrderID:           HexPrefix + hex.EncodeToString(event.OrderKey[:]), // OrderKey 转换为十六进制字符串作为 ID
 		OrderStatus:       multi.OrderStatusActive,                           // 初始状态为 Active
+		Fillable:          true,                                              // 授权撤销后由授权事件更新
 		EventTime:         time.Now().Unix(),
 		ExpireTime:        int64(event.Expiry),
 		CurrencyAddress:   s.cfg.ContractCfg.EthAddress, // 目前仅支持 ETH 支付
 		Price:             decimal.NewFromBigInt(event.Price, 0),
-		Maker:             maker.String(),
+		Maker:             strings.ToLower(maker.String()),
 		Taker:             ZeroAddress,              // 尚未成交，Taker 为空
 		QuantityRemaining: event.Nft.Amount.Int64(), // 剩余可交易数量
 		Size:              event.Nft.Amount.Int64(), // 订单总数量
 		OrderType:         orderType,
 		Salt:              int64(event.Salt),
 	}
-	if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(s.chain)).Clauses(clause.OnConflict{
+	result := b.tx.Table(multi.OrderTableName(s.chain)).Clauses(clause.OnConflict{
 		DoNothing: true,
-	}).Create(&newOrder).Error; err != nil { // 将订单信息存入数据库
-		xzap.WithContext(s.ctx).Error("failed on create order",
-			zap.Error(err))
+	}).Create(&newOrder) // 将订单信息存入数据库
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed on create order")
+	}
+	if result.RowsAffected > 0 {
+		if err := s.recordOrderCreate(b.tx, log.BlockNumber, &newOrder); err != nil { // 重组时删除该订单
+			return err
+		}
 	}
 
 	// 6. 更新或创建 NFT Item 信息
 	// 无论是否存在，都尝试更新 Item 信息（主要是为了确保数据库中有这个 Item）
+	// ERC1155 NFT 没有唯一的所有者, 各持有人的数量由转移事件维护
+	owner := maker.String()
+	erc1155, err := s.isErc1155Collection(b.tx, event.Nft.CollectionAddr.String())
+	if err != nil {
+		return err
+	}
+	if erc1155 {
+		owner = ""
+	}
 	newItem := multi.Item{
+		ChainId:           int(s.chainId),
 		CollectionAddress: event.Nft.CollectionAddr.String(),
 		TokenId:           event.Nft.TokenId.String(),
-		Owner:             maker.String(), // 既然能挂单，说明 Maker 大概率是 Owner (或者有授权)
+		Owner:             owner, // 既然能挂单，说明 Maker 大概率是 Owner (或者有授权)
 		Supply:            event.Nft.Amount.Int64(),
 		ListPrice:         decimal.NewFromBigInt(event.Price, 0), // 更新当前挂牌价
 		ListTime:          time.Now().Unix(),
 		UpdateTime:        time.Now().Unix(),
 	}
-	// 将 NFT 写入数据库
-	if err = s.db.WithContext(s.ctx).Table(multi.ItemTableName(s.chain)).Clauses(clause.OnConflict{
-		DoNothing: true,
-	}).Create(&newItem).Error; err != nil {
-		xzap.WithContext(s.ctx).Error("failed on create item",
-			zap.Error(err))
+	// 将 NFT 写入数据库, 新建的 NFT 记录变更以便重组时删除
+	var item multi.Item
+	if err = b.tx.Table(multi.ItemTableName(s.chain)).
+		Select("id").
+		Where("collection_address = ? and token_id = ?", newItem.CollectionAddress, newItem.TokenId).
+		Limit(1).
+		Find(&item).Error; err != nil {
+		return errors.Wrap(err, "failed on get item")
+	}
+	if item.Id == 0 {
+		if err = s.createItemWithChange(b.tx, log.BlockNumber, &newItem); err != nil {
+			return err
+		}
 	}
 
 	// 7. 写入扩展元数据 (createItemExternal)
 	// 尝试从链上获取 TokenURI，并解析 Metadata (image, attributes 等)
 	// 如果是第一次见到这个 NFT，这步操作会填充其元数据
-	s.createItemExternal(event.Nft.CollectionAddr.String(), event.Nft.TokenId.String())
+	// 涉及 RPC 与 HTTP 请求, 在事务提交后执行
+	collectionAddress, tokenId := event.Nft.CollectionAddr.String(), event.Nft.TokenId.String()
+	b.onCommit(func() {
+		s.createItemExternal(collectionAddress, tokenId)
+	})
 
-	blockTime, err := s.chainClient.BlockTimeByNumber(s.ctx, big.NewInt(int64(log.BlockNumber)))
+	blockTime, err := s.blockTime(b, log.BlockNumber)
 	if err != nil {
-		xzap.WithContext(s.ctx).Error("failed to get block time", zap.Error(err))
-		return
+		return err
 	}
 	// 8. 记录活动日志 (Activity)
 	// Activity 表用于前端展示“活动历史”
@@ -381,47 +470,59 @@ func (s *Service) handleMakeEvent(log ethereumTypes.Log) {
 		TxHash:            log.TxHash.String(),
 		EventTime:         int64(blockTime),
 	}
-	if err := s.db.WithContext(s.ctx).Table(multi.ActivityTableName(s.chain)).Clauses(clause.OnConflict{
+	activityResult := b.tx.Table(multi.ActivityTableName(s.chain)).Clauses(clause.OnConflict{
 		DoNothing: true,
-	}).Create(&newActivity).Error; err != nil {
-		xzap.WithContext(s.ctx).Warn("failed on create activity",
-			zap.Error(err))
+	}).Create(&newActivity)
+	if activityResult.Error != nil {
+		return errors.Wrap(activityResult.Error, "failed on create activity")
+	}
+	// 重复处理同一日志时活动已存在, 不再重复推送
+	if activityResult.RowsAffected > 0 {
+		feedType := marketfeed.TypeListing
+		if side == Bid {
+			feedType = marketfeed.TypeBid
+		}
+		s.publishOnCommit(b, &marketfeed.Event{
+			Type:              feedType,
+			CollectionAddress: newOrder.CollectionAddress,
+			TokenID:           newOrder.TokenId,
+			OrderID:           newOrder.OrderID,
+			Maker:             newOrder.Maker,
+			Price:             newOrder.Price,
+			TxHash:            newActivity.TxHash,
+			EventTime:         newActivity.EventTime,
+		})
 	}
 
 	// 9. 将订单添加到 OrderManager 队列
 	// 用于后续的状态管理，如过期检查
-	if err := s.orderManager.AddToOrderManagerQueue(&multi.Order{ // 将订单信息存入订单管理队列
-		ExpireTime:        newOrder.ExpireTime,
-		OrderID:           newOrder.OrderID,
-		CollectionAddress: newOrder.CollectionAddress,
-		TokenId:           newOrder.TokenId,
-		Price:             newOrder.Price,
-		Maker:             newOrder.Maker,
-	}); err != nil {
-		xzap.WithContext(s.ctx).Error("failed on add order to manager queue",
-			zap.Error(err),
-			zap.String("order_id", newOrder.OrderID))
-	}
+	b.onCommit(func() {
+		if err := s.orderManager.AddToOrderManagerQueue(&multi.Order{ // 将订单信息存入订单管理队列
+			ExpireTime:        newOrder.ExpireTime,
+			OrderID:           newOrder.OrderID,
+			CollectionAddress: newOrder.CollectionAddress,
+			TokenId:           newOrder.TokenId,
+			Price:             newOrder.Price,
+			Maker:             newOrder.Maker,
+		}); err != nil {
+			xzap.WithContext(s.ctx).Error("failed on add order to manager queue",
+				zap.Error(err),
+				zap.String("order_id", newOrder.OrderID))
+		}
+	})
 
 	// 10. 维护 Collection 和 Item 统计信息
 	// 只有卖单（Listing）才需要维护 collection 和 item 信息（如 floor price 更新）
 	if side == List { // 卖单
-		s.maintainCollectionAndItem(event.Nft.CollectionAddr.String(), event.Nft.TokenId.String(), decimal.NewFromBigInt(event.Price, 0))
+		return s.maintainCollectionAndItem(b.tx, event.Nft.CollectionAddr.String(), event.Nft.TokenId.String(), decimal.NewFromBigInt(event.Price, 0))
 	}
+
+	return nil
 }
 
 // handleMatchEvent 处理成交事件 (LogMatch)
 // 当买卖双方订单匹配成功时触发
-func (s *Service) handleMatchEvent(log ethereumTypes.Log) {
-	// 1. 检查区块链分叉
-	if err := s.checkAndHandleFork(log.BlockNumber, log.TxHash.String()); err != nil {
-		xzap.WithContext(s.ctx).Error("failed to handle fork for match event",
-			zap.Error(err),
-			zap.Uint64("block_number", log.BlockNumber),
-			zap.String("tx_hash", log.TxHash.String()))
-		return
-	}
-
+func (s *Service) handleMatchEvent(b *eventBatch, log ethereumTypes.Log) error {
 	// 定义用于解析 LogMatch 事件数据的结构体
 	// 注意：LogMatch 事件包含嵌套的 MakeOrder 和 TakeOrder 结构体
 	var event struct {
@@ -434,7 +535,7 @@ func (s *Service) handleMatchEvent(log ethereumTypes.Log) {
 	err := s.parsedAbi.UnpackIntoInterface(&event, "LogMatch", log.Data)
 	if err != nil {
 		xzap.WithContext(s.ctx).Error("Error unpacking LogMatch event:", zap.Error(err))
-		return
+		return nil
 	}
 
 	// 3. 提取订单 ID
@@ -449,6 +550,8 @@ func (s *Service) handleMatchEvent(log ethereumTypes.Log) {
 	var to string
 	var sellOrderId string
 	var buyOrder multi.Order
+	sellFilled := true
+	quantity := fillQuantity(event.TakeOrder) // 成交数量, ERC1155 订单可能部分成交
 
 	// 4. 确定买卖双方角色
 	// MakeOrder 是挂单（被动成交，早已存在于数据库中），TakeOrder 是吃单（主动成交，刚刚触发交易）
@@ -464,48 +567,30 @@ func (s *Service) handleMatchEvent(log ethereumTypes.Log) {
 
 		// 4.1 更新卖方订单状态 (Filled)
 		// 吃单 (TakeOrder) 通常是立即完全成交的，因为它是在交易函数中即时构建的
-		if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(s.chain)).
-			Where("order_id = ?", takeOrderId).
-			Updates(map[string]interface{}{
-				"order_status":       multi.OrderStatusFilled,
-				"quantity_remaining": 0,
-				"taker":              to,
-			}).Error; err != nil {
-			xzap.WithContext(s.ctx).Error("failed on update order status",
-				zap.String("order_id", takeOrderId))
-			return
+		if err := s.updateOrderWithChange(b.tx, log.BlockNumber, takeOrderId, map[string]interface{}{
+			"order_status":       multi.OrderStatusFilled,
+			"quantity_remaining": 0,
+			"taker":              to,
+		}); err != nil {
+			return errors.Wrapf(err, "failed on update order status, order_id: %s", takeOrderId)
 		}
 
 		// 4.2 更新买方订单状态 (Partial Fill or Filled)
 		// 挂单 (MakeOrder) 可能是部分成交 (例如：求购 10 个，只成交了 1 个)
 		// 查询买方订单信息，不存在则无需更新，说明该订单可能不是从平台前端发起的（或者是数据同步延迟）
-		if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(s.chain)).
+		if err := b.tx.Table(multi.OrderTableName(s.chain)).
 			Where("order_id = ?", makeOrderId).
 			First(&buyOrder).Error; err != nil {
-			xzap.WithContext(s.ctx).Error("failed on get buy order",
-				zap.Error(err))
-			return
-		}
-		// 更新买方订单的剩余数量
-		if buyOrder.QuantityRemaining > 1 {
-			if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(s.chain)).
-				Where("order_id = ?", makeOrderId).
-				Update("quantity_remaining", buyOrder.QuantityRemaining-1).Error; err != nil {
-				xzap.WithContext(s.ctx).Error("failed on update order quantity_remaining",
-					zap.String("order_id", makeOrderId))
-				return
-			}
-		} else {
-			if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(s.chain)).
-				Where("order_id = ?", makeOrderId).
-				Updates(map[string]interface{}{
-					"order_status":       multi.OrderStatusFilled,
-					"quantity_remaining": 0,
-				}).Error; err != nil {
-				xzap.WithContext(s.ctx).Error("failed on update order status",
-					zap.String("order_id", makeOrderId))
-				return
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				xzap.WithContext(s.ctx).Error("failed on get buy order",
+					zap.Error(err))
+				return nil
 			}
+			return errors.Wrap(err, "failed on get buy order")
+		}
+		// 按成交数量扣减买方订单的剩余数量
+		if _, err := s.fillOrderWithChange(b.tx, log.BlockNumber, &buyOrder, quantity, ""); err != nil {
+			return err
 		}
 	} else { // Case B: 挂单是卖单 (Listing)，吃单是买单 (Bid) -> 买家主动成交 (Buy Now)
 		// 场景：Alice 挂了一个 Listing (MakeOrder), Bob 直接购买 (TakeOrder)
@@ -516,51 +601,40 @@ func (s *Service) handleMatchEvent(log ethereumTypes.Log) {
 		to = event.TakeOrder.Maker.String()   // 买家 (TakeOrder.Maker)
 		sellOrderId = makeOrderId             // 卖单是 MakeOrder (Listing)
 
-		if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(s.chain)).
+		// ERC1155 挂单可能部分成交, 剩余数量为 0 时才置为 Filled
+		var sellOrder multi.Order
+		if err := b.tx.Table(multi.OrderTableName(s.chain)).
 			Where("order_id = ?", makeOrderId).
-			Updates(map[string]interface{}{
-				"order_status":       multi.OrderStatusFilled,
-				"quantity_remaining": 0,
-				"taker":              to,
-			}).Error; err != nil {
-			xzap.WithContext(s.ctx).Error("failed on update order status",
-				zap.String("order_id", makeOrderId))
-			return
+			Limit(1).
+			Find(&sellOrder).Error; err != nil {
+			return errors.Wrap(err, "failed on get sell order")
+		}
+		if sellOrder.OrderID != "" {
+			filled, err := s.fillOrderWithChange(b.tx, log.BlockNumber, &sellOrder, quantity, to)
+			if err != nil {
+				return err
+			}
+			sellFilled = filled
 		}
 
-		if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(s.chain)).
+		if err := b.tx.Table(multi.OrderTableName(s.chain)).
 			Where("order_id = ?", takeOrderId).
 			First(&buyOrder).Error; err != nil {
-			xzap.WithContext(s.ctx).Error("failed on get buy order",
-				zap.Error(err))
-			return
-		}
-		if buyOrder.QuantityRemaining > 1 {
-			if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(s.chain)).
-				Where("order_id = ?", takeOrderId).
-				Update("quantity_remaining", buyOrder.QuantityRemaining-1).Error; err != nil {
-				xzap.WithContext(s.ctx).Error("failed on update order quantity_remaining",
-					zap.String("order_id", takeOrderId))
-				return
-			}
-		} else {
-			if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(s.chain)).
-				Where("order_id = ?", takeOrderId).
-				Updates(map[string]interface{}{
-					"order_status":       multi.OrderStatusFilled,
-					"quantity_remaining": 0,
-				}).Error; err != nil {
-				xzap.WithContext(s.ctx).Error("failed on update order status",
-					zap.String("order_id", takeOrderId))
-				return
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				xzap.WithContext(s.ctx).Error("failed on get buy order",
+					zap.Error(err))
+				return nil
 			}
+			return errors.Wrap(err, "failed on get buy order")
+		}
+		if _, err := s.fillOrderWithChange(b.tx, log.BlockNumber, &buyOrder, quantity, ""); err != nil {
+			return err
 		}
 	}
 
-	blockTime, err := s.chainClient.BlockTimeByNumber(s.ctx, big.NewInt(int64(log.BlockNumber)))
+	blockTime, err := s.blockTime(b, log.BlockNumber)
 	if err != nil {
-		xzap.WithContext(s.ctx).Error("failed to get block time", zap.Error(err))
-		return
+		return err
 	}
 	// 5. 记录交易活动 (Activity: Sale)
 	newActivity := multi.Activity{
@@ -576,51 +650,68 @@ func (s *Service) handleMatchEvent(log ethereumTypes.Log) {
 		TxHash:            log.TxHash.String(),
 		EventTime:         int64(blockTime),
 	}
-	if err := s.db.WithContext(s.ctx).Table(multi.ActivityTableName(s.chain)).Clauses(clause.OnConflict{
+	activityResult := b.tx.Table(multi.ActivityTableName(s.chain)).Clauses(clause.OnConflict{
 		DoNothing: true,
-	}).Create(&newActivity).Error; err != nil {
-		xzap.WithContext(s.ctx).Warn("failed on create activity",
-			zap.Error(err))
+	}).Create(&newActivity)
+	if activityResult.Error != nil {
+		return errors.Wrap(activityResult.Error, "failed on create activity")
+	}
+	if activityResult.RowsAffected > 0 {
+		s.publishOnCommit(b, &marketfeed.Event{
+			Type:              marketfeed.TypeSale,
+			CollectionAddress: collection,
+			TokenID:           tokenId,
+			OrderID:           sellOrderId,
+			Maker:             from,
+			Taker:             to,
+			Price:             newActivity.Price,
+			TxHash:            newActivity.TxHash,
+			EventTime:         newActivity.EventTime,
+		})
+	}
+
+	// 6. 更新 NFT 所有权 (Item), ERC1155 按成交数量转移持有数量
+	erc1155, err := s.isErc1155Collection(b.tx, collection)
+	if err != nil {
+		return err
+	}
+	if erc1155 {
+		if err := s.transferBalanceWithChange(b.tx, log.BlockNumber, strings.ToLower(collection), tokenId, strings.ToLower(from), owner, quantity); err != nil {
+			return errors.Wrap(err, "failed to transfer item balance")
+		}
+	} else if err := s.updateItemOwnerWithChange(b.tx, log.BlockNumber, strings.ToLower(collection), tokenId, owner); err != nil {
+		return errors.Wrap(err, "failed to update item owner")
 	}
 
-	// 6. 更新 NFT 所有权 (Item)
-	if err := s.db.WithContext(s.ctx).Table(multi.ItemTableName(s.chain)).
-		Where("collection_address = ? and token_id = ?", strings.ToLower(collection), tokenId).
-		Update("owner", owner).Error; err != nil {
-		xzap.WithContext(s.ctx).Error("failed to update item owner",
-			zap.Error(err))
-		return
+	// 部分成交的挂单仍然有效, 地板价不变
+	if !sellFilled {
+		return nil
 	}
 
 	// 7. 发送价格更新事件 (用于计算新的 Floor Price 等)
 	// 通知 OrderManager 有新的交易发生，可能影响集合的地板价、交易量等统计数据
-	if err := ordermanager.AddUpdatePriceEvent(s.kv, &ordermanager.TradeEvent{ // 将交易信息存入价格更新队列
-		OrderId:        sellOrderId,
-		CollectionAddr: collection,
-		EventType:      ordermanager.Buy,
-		TokenID:        tokenId,
-		From:           from,
-		To:             to,
-	}, s.chain); err != nil {
-		xzap.WithContext(s.ctx).Error("failed on add update price event",
-			zap.Error(err),
-			zap.String("type", "sale"),
-			zap.String("order_id", sellOrderId))
-	}
+	b.onCommit(func() {
+		if err := ordermanager.AddUpdatePriceEvent(s.kv, &ordermanager.TradeEvent{ // 将交易信息存入价格更新队列
+			OrderId:        sellOrderId,
+			CollectionAddr: collection,
+			EventType:      ordermanager.Buy,
+			TokenID:        tokenId,
+			From:           from,
+			To:             to,
+		}, s.chain); err != nil {
+			xzap.WithContext(s.ctx).Error("failed on add update price event",
+				zap.Error(err),
+				zap.String("type", "sale"),
+				zap.String("order_id", sellOrderId))
+		}
+	})
+
+	return nil
 }
 
 // handleCancelEvent 处理取消订单事件 (LogCancel)
 // 当用户主动取消订单时触发
-func (s *Service) handleCancelEvent(log ethereumTypes.Log) {
-	// 1. 检查区块链分叉
-	if err := s.checkAndHandleFork(log.BlockNumber, log.TxHash.String()); err != nil {
-		xzap.WithContext(s.ctx).Error("failed to handle fork for cancel event",
-			zap.Error(err),
-			zap.Uint64("block_number", log.BlockNumber),
-			zap.String("tx_hash", log.TxHash.String()))
-		return
-	}
-
+func (s *Service) handleCancelEvent(b *eventBatch, log ethereumTypes.Log) error {
 	// 2. 提取订单 ID
 	// Topic 1: orderKey (32 bytes)
 	// 将 bytes32转换为 hex string 作为数据库主键
@@ -628,29 +719,27 @@ func (s *Service) handleCancelEvent(log ethereumTypes.Log) {
 	//maker := common.BytesToAddress(log.Topics[2].Bytes())
 
 	// 3. 更新订单状态为已取消 (Cancelled)
-	if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(s.chain)).
-		Where("order_id = ?", orderId).
-		Update("order_status", multi.OrderStatusCancelled).Error; err != nil {
-		xzap.WithContext(s.ctx).Error("failed on update order status",
-			zap.String("order_id", orderId))
-		return
+	if err := s.updateOrderWithChange(b.tx, log.BlockNumber, orderId, map[string]interface{}{"order_status": multi.OrderStatusCancelled}); err != nil {
+		return errors.Wrapf(err, "failed on update order status, order_id: %s", orderId)
 	}
 
 	// 4. 查询被取消的订单信息
 	// 需要获取订单详情来记录 Activity
 	var cancelOrder multi.Order
-	if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(s.chain)).
+	if err := b.tx.Table(multi.OrderTableName(s.chain)).
 		Where("order_id = ?", orderId).
 		First(&cancelOrder).Error; err != nil {
-		xzap.WithContext(s.ctx).Error("failed on get cancel order",
-			zap.Error(err))
-		return
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			xzap.WithContext(s.ctx).Error("failed on get cancel order",
+				zap.Error(err))
+			return nil
+		}
+		return errors.Wrap(err, "failed on get cancel order")
 	}
 
-	blockTime, err := s.chainClient.BlockTimeByNumber(s.ctx, big.NewInt(int64(log.BlockNumber)))
+	blockTime, err := s.blockTime(b, log.BlockNumber)
 	if err != nil {
-		xzap.WithContext(s.ctx).Error("failed to get block time", zap.Error(err))
-		return
+		return err
 	}
 	// 5. 确定活动类型 (Cancel Listing/Bid)
 	// 根据原订单类型，决定是 "取消挂单" 还是 "取消出价"
@@ -677,293 +766,54 @@ func (s *Service) handleCancelEvent(log ethereumTypes.Log) {
 		TxHash:            log.TxHash.String(),
 		EventTime:         int64(blockTime),
 	}
-	if err := s.db.WithContext(s.ctx).Table(multi.ActivityTableName(s.chain)).Clauses(clause.OnConflict{
+	activityResult := b.tx.Table(multi.ActivityTableName(s.chain)).Clauses(clause.OnConflict{
 		DoNothing: true,
-	}).Create(&newActivity).Error; err != nil {
-		xzap.WithContext(s.ctx).Warn("failed on create activity",
-			zap.Error(err))
+	}).Create(&newActivity)
+	if activityResult.Error != nil {
+		return errors.Wrap(activityResult.Error, "failed on create activity")
+	}
+	if activityResult.RowsAffected > 0 {
+		s.publishOnCommit(b, &marketfeed.Event{
+			Type:              marketfeed.TypeCancel,
+			CollectionAddress: cancelOrder.CollectionAddress,
+			TokenID:           cancelOrder.TokenId,
+			OrderID:           cancelOrder.OrderID,
+			Maker:             cancelOrder.Maker,
+			Price:             cancelOrder.Price,
+			TxHash:            newActivity.TxHash,
+			EventTime:         newActivity.EventTime,
+		})
 	}
 
 	// 6. 发送价格更新事件 (用于更新 Floor Price)
 	// 取消卖单可能会影响地板价（例如取消了当前最低价的订单），需要重新计算
-	if err := ordermanager.AddUpdatePriceEvent(s.kv, &ordermanager.TradeEvent{
-		OrderId:        cancelOrder.OrderID,
-		CollectionAddr: cancelOrder.CollectionAddress,
-		TokenID:        cancelOrder.TokenId,
-		EventType:      ordermanager.Cancel,
-	}, s.chain); err != nil {
-		xzap.WithContext(s.ctx).Error("failed on add update price event",
-			zap.Error(err),
-			zap.String("type", "cancel"),
-			zap.String("order_id", cancelOrder.OrderID))
-	}
-}
-
-// handleApprovalEvent 处理 ERC721 授权事件 (Approval)
-// 当 NFT 被授权/取消授权给某个地址时触发
-func (s *Service) handleApprovalEvent(log ethereumTypes.Log) {
-	// 1. 检查分叉
-	if err := s.checkAndHandleFork(log.BlockNumber, log.TxHash.String()); err != nil {
-		xzap.WithContext(s.ctx).Error("failed to handle fork for approval event",
-			zap.Error(err),
-			zap.Uint64("block_number", log.BlockNumber),
-			zap.String("tx_hash", log.TxHash.String()))
-		return
-	}
-
-	// 2. 解析事件参数
-	// Topics: [Signature, Owner, Approved, TokenId]
-	owner := common.BytesToAddress(log.Topics[1].Bytes())
-	approved := common.BytesToAddress(log.Topics[2].Bytes())
-	tokenId := new(big.Int).SetBytes(log.Topics[3].Bytes())
-	collectionAddress := log.Address.String()
-
-	// 记录授权信息
-	xzap.WithContext(s.ctx).Info("ERC721 Approval event detected",
-		zap.String("collection", collectionAddress),
-		zap.String("token_id", tokenId.String()),
-		zap.String("owner", owner.String()),
-		zap.String("approved", approved.String()),
-		zap.Bool("is_vault_approved", strings.EqualFold(approved.String(), s.vaultAddress)))
-
-	// 可以在这里添加数据库存储逻辑，记录授权状态
-	blockTime, err := s.chainClient.BlockTimeByNumber(s.ctx, big.NewInt(int64(log.BlockNumber)))
-	if err != nil {
-		xzap.WithContext(s.ctx).Error("failed to get block time", zap.Error(err))
-		return
-	}
-
-	// 存储授权记录到数据库（可选）
-	approvalRecord := multi.Activity{
-		ActivityType:      multi.Listing, // 暂时使用现有的类型，后续可以添加新的常量
-		Maker:             owner.String(),
-		Taker:             approved.String(),
-		MarketplaceID:     multi.MarketOrderBook,
-		CollectionAddress: collectionAddress,
-		TokenId:           tokenId.String(),
-		CurrencyAddress:   s.cfg.ContractCfg.EthAddress,
-		Price:             decimal.Zero,
-		BlockNumber:       int64(log.BlockNumber),
-		TxHash:            log.TxHash.String(),
-		EventTime:         int64(blockTime),
-	}
-
-	if err := s.db.WithContext(s.ctx).Table(multi.ActivityTableName(s.chain)).Clauses(clause.OnConflict{
-		DoNothing: true,
-	}).Create(&approvalRecord).Error; err != nil {
-		xzap.WithContext(s.ctx).Warn("failed on create approval activity",
-			zap.Error(err))
-	}
-}
-
-// CheckNFTApprovalStatus 检查指定NFT是否已授权给vault合约
-func (s *Service) CheckNFTApprovalStatus(collectionAddress string, tokenId string) (bool, error) {
-	// 调用 getApproved 方法
-	tokenIdBig := new(big.Int)
-	tokenIdBig.SetString(tokenId, 10)
-
-	// 获取当前授权的地址
-	approvedAddress, err := s.getApprovedAddress(collectionAddress, tokenIdBig)
-	if err != nil {
-		return false, errors.Wrap(err, "failed to get approved address")
-	}
-
-	// 检查是否授权给vault合约
-	isApproved := strings.EqualFold(approvedAddress.String(), s.vaultAddress)
-
-	xzap.WithContext(s.ctx).Info("NFT approval status checked",
-		zap.String("collection", collectionAddress),
-		zap.String("token_id", tokenId),
-		zap.String("approved_to", approvedAddress.String()),
-		zap.String("vault_address", s.vaultAddress),
-		zap.Bool("is_vault_approved", isApproved))
-
-	return isApproved, nil
-}
-
-// getApprovedAddress 获取NFT当前授权的地址
-func (s *Service) getApprovedAddress(collectionAddress string, tokenId *big.Int) (common.Address, error) {
-	// ERC721 getApproved 方法的方法签名
-	methodSig := "0x081812fc" // getApproved(uint256)的方法签名
-
-	// 编码参数
-	paddedTokenId := common.LeftPadBytes(tokenId.Bytes(), 32)
-	data := append(common.Hex2Bytes(methodSig[2:]), paddedTokenId...)
-
-	// 调用合约
-	contractAddr := common.HexToAddress(collectionAddress)
-	callMsg := ethereum.CallMsg{
-		To:   &contractAddr,
-		Data: data,
-	}
-
-	result, err := s.chainClient.CallContract(s.ctx, callMsg, nil)
-	if err != nil {
-		return common.Address{}, errors.Wrap(err, "failed to call getApproved")
-	}
-
-	if len(result) < 32 {
-		return common.Address{}, errors.New("invalid response length")
-	}
-
-	// 解析结果
-	approvedAddress := common.BytesToAddress(result[12:32]) // 取最后20字节作为地址
-	return approvedAddress, nil
-}
-
-// CheckMultipleNFTApprovals 批量检查多个NFT的授权状态
-func (s *Service) CheckMultipleNFTApprovals(nfts []struct {
-	CollectionAddress string
-	TokenId           string
-}) (map[string]bool, error) {
-	results := make(map[string]bool)
-
-	for _, nft := range nfts {
-		key := fmt.Sprintf("%s:%s", nft.CollectionAddress, nft.TokenId)
-		approved, err := s.CheckNFTApprovalStatus(nft.CollectionAddress, nft.TokenId)
-		if err != nil {
-			xzap.WithContext(s.ctx).Error("failed to check NFT approval",
-				zap.String("collection", nft.CollectionAddress),
-				zap.String("token_id", nft.TokenId),
-				zap.Error(err))
-			results[key] = false
-			continue
-		}
-		results[key] = approved
-	}
-
-	return results, nil
-}
-
-// CanMarketBuyNFT 判断市场合约是否能购买指定的NFT
-func (s *Service) CanMarketBuyNFT(collectionAddress string, tokenId string) (bool, string, error) {
-	// 1. 检查NFT是否授权给vault
-	isApproved, err := s.CheckNFTApprovalStatus(collectionAddress, tokenId)
-	if err != nil {
-		return false, "检查授权状态失败", err
-	}
-
-	if !isApproved {
-		return false, "NFT未授权给vault合约", nil
-	}
-
-	// 2. 检查是否有有效的挂单
-	var activeOrder multi.Order
-	if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(s.chain)).
-		Where("collection_address = ? AND token_id = ? AND order_type = ? AND order_status = ? AND expire_time > ?",
-			strings.ToLower(collectionAddress), tokenId, multi.ListingOrder, multi.OrderStatusActive, time.Now().Unix()).
-		First(&activeOrder).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return false, "没有有效的挂单", nil
-		}
-		return false, "查询挂单失败", err
-	}
-
-	// 3. 验证挂单的maker确实是NFT的owner（可选的额外安全检查）
-	// 这里可以添加更多的验证逻辑
-
-	return true, "可以购买", nil
-}
-
-// checkAndHandleFork 检查并处理区块链分叉 (Reorg)
-// 如果检测到当前事件所在的交易哈希已经存在于数据库中，但区块高度不一致，主要说明发生了分叉
-// Reorg 发生时，旧链上的交易虽然已经执行，但在新链上可能未执行或执行顺序不同
-// 这里采取的策略是：一旦发现分叉，回滚该交易在数据库中的所有状态变更
-func (s *Service) checkAndHandleFork(blockNumber uint64, txHash string) error {
-	// 1. 检查交易是否存在但区块高度不同
-	// tx_hash 相同但 block_number 不同，是 Reorg 的典型特征
-	var count int64
-	if err := s.db.WithContext(s.ctx).Table(multi.ActivityTableName(s.chain)).
-		Where("tx_hash = ? AND block_number != ?", txHash, blockNumber).
-		Count(&count).Error; err != nil {
-		return errors.Wrap(err, "failed to check transaction existence")
-	}
-
-	// 2. 如果检测到分叉
-	if count > 0 {
-		xzap.WithContext(s.ctx).Warn("fork detected, rolling back transaction",
-			zap.String("tx_hash", txHash),
-			zap.Uint64("new_block_number", blockNumber))
-
-		// 2.1 回滚受影响的订单状态
-		// 调用 rollbackOrderStatus 函数，根据 txHash 查找所有相关的活动记录，
-		// 并将这些活动所导致的订单状态和NFT所有权变更进行回滚，恢复到分叉前的状态。
-		if err := s.rollbackOrderStatus(txHash); err != nil {
-			return errors.Wrap(err, "failed to rollback order status")
-		}
-
-		// 2.2 删除原有的（分叉前的）活动记录
-		// 删除 Activity 记录，相当于撤销了这笔交易的历史痕迹。
-		// 这是为了确保数据库中只保留属于最终确定链的交易记录。
-		if err := s.db.WithContext(s.ctx).Table(multi.ActivityTableName(s.chain)).
-			Where("tx_hash = ?", txHash).
-			Delete(&multi.Activity{}).Error; err != nil {
-			return errors.Wrap(err, "failed to delete old activity")
+	b.onCommit(func() {
+		if err := ordermanager.AddUpdatePriceEvent(s.kv, &ordermanager.TradeEvent{
+			OrderId:        cancelOrder.OrderID,
+			CollectionAddr: cancelOrder.CollectionAddress,
+			TokenID:        cancelOrder.TokenId,
+			EventType:      ordermanager.Cancel,
+		}, s.chain); err != nil {
+			xzap.WithContext(s.ctx).Error("failed on add update price event",
+				zap.Error(err),
+				zap.String("type", "cancel"),
+				zap.String("order_id", cancelOrder.OrderID))
 		}
-
-		xzap.WithContext(s.ctx).Info("handled fork situation",
-			zap.String("tx_hash", txHash),
-			zap.Uint64("new_block_number", blockNumber))
-	}
+	})
 
 	return nil
 }
 
-// rollbackOrderStatus 回滚订单状态
-// 当发生分叉时，将相关订单恢复到分叉前的状态，依赖 Activity 表记录的历史操作
-// 此函数通过查询与给定交易哈希相关的所有活动记录，并根据活动类型执行相应的数据库回滚操作。
-func (s *Service) rollbackOrderStatus(txHash string) error {
-	// 1. 查找该交易产生的所有活动
-	// Activity 表记录了交易类型 (Sale/Cancel) 和相关参数，是回滚的依据。
-	// 这些记录代表了在分叉链上发生的、现在需要撤销的操作。
-	var activities []multi.Activity
-	if err := s.db.WithContext(s.ctx).Table(multi.ActivityTableName(s.chain)).
-		Where("tx_hash = ?", txHash).
-		Find(&activities).Error; err != nil {
-		return errors.Wrap(err, "failed to find activities")
-	}
-
-	// 2. 遍历活动并恢复状态
-	for _, activity := range activities {
-		switch activity.ActivityType {
-		case multi.Sale:
-			// 2.1 对于成交 (Sale) 活动
-			// 之前的操作：订单状态从 Active 变为 Filled (已成交)。
-			// 回滚操作：将订单状态恢复为 Active (活跃)，表示该订单并未成交。
-			if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(s.chain)).
-				Where("maker = ? AND collection_address = ? AND token_id = ? AND price = ?",
-					activity.Maker, activity.CollectionAddress, activity.TokenId, activity.Price).
-				Updates(map[string]interface{}{
-					"order_status":       multi.OrderStatusActive,
-					"quantity_remaining": 1,           // 假设 ERC721 数量为 1，恢复
-					"taker":              ZeroAddress, // 清除 taker 信息
-				}).Error; err != nil {
-				return errors.Wrap(err, "failed to restore order status for sale")
-			}
-
-			// 恢复NFT所有权给 Maker (卖方)
-			// 之前的操作：NFT所有权从 Maker 转移到 Taker (买方)。
-			// 回滚操作：将NFT的所有者恢复为原始的 Maker，撤销所有权转移。
-			if err := s.db.WithContext(s.ctx).Table(multi.ItemTableName(s.chain)).
-				Where("collection_address = ? AND token_id = ?",
-					activity.CollectionAddress, activity.TokenId).
-				Update("owner", activity.Maker).Error; err != nil {
-				return errors.Wrap(err, "failed to restore item owner")
-			}
-
-		case multi.CancelListing, multi.CancelCollectionBid, multi.CancelItemBid:
-			// 2.2 对于取消 (Cancel) 活动
-			// 之前的操作：订单状态从 Active 变为 Cancelled (已取消)。
-			// 回滚操作：将订单状态恢复为 Active (活跃)，表示该订单并未被取消。
-			if err := s.db.WithContext(s.ctx).Table(multi.OrderTableName(s.chain)).
-				Where("maker = ? AND collection_address = ? AND token_id = ? AND price = ?",
-					activity.Maker, activity.CollectionAddress, activity.TokenId, activity.Price).
-				Update("order_status", multi.OrderStatusActive).Error; err != nil {
-				return errors.Wrap(err, "failed to restore order status for cancel")
-			}
+// publishOnCommit 事务提交后推送市场事件, 推送失败只记录日志
+func (s *Service) publishOnCommit(b *eventBatch, event *marketfeed.Event) {
+	b.onCommit(func() {
+		if _, err := marketfeed.Publish(s.kv, s.chain, event); err != nil {
+			xzap.WithContext(s.ctx).Error("failed on publish market event",
+				zap.Error(err),
+				zap.String("type", event.Type),
+				zap.String("order_id", event.OrderID))
 		}
-	}
-	return nil
+	})
 }
 
 // UpKeepingCollectionFloorChangeLoop 地板价维护循环
@@ -975,11 +825,7 @@ func (s *Service) UpKeepingCollectionFloorChangeLoop() {
 	updateFloorPriceTimer := time.NewTicker(comm.MaxCollectionFloorTimeDifference * time.Second) // 定期更新地板价
 	defer updateFloorPriceTimer.Stop()
 
-	var indexedStatus base.IndexedStatus
-	if err := s.db.WithContext(s.ctx).Table(base.IndexedStatusTableName()).
-		Select("last_indexed_time").
-		Where("chain_id = ? and index_type = ?", s.chainId, comm.CollectionFloorChangeIndexType).
-		First(&indexedStatus).Error; err != nil {
+	if _, err := s.getIndexedStatus(comm.CollectionFloorChangeIndexType); err != nil {
 		xzap.WithContext(s.ctx).Error("failed on get collection floor change index status",
 			zap.Error(err))
 		return
@@ -1085,36 +931,37 @@ func (s *Service) persistCollectionsFloorChange(FloorPrices []multi.CollectionFl
 // maintainCollectionAndItem 维护 Collection 和 Item 信息
 // 当有新 Listing 创建时调用，用于初始化集合信息和更新地板价。
 // 此函数确保数据库中存在对应的 Collection 和 Item 记录，并更新其上架信息和集合的地板价。
-func (s *Service) maintainCollectionAndItem(collectionAddress, tokenId string, price decimal.Decimal) {
+func (s *Service) maintainCollectionAndItem(db *gorm.DB, collectionAddress, tokenId string, price decimal.Decimal) error {
 	// 1. 检查并创建 collection 记录（如果不存在）
 	// 确保每个 NFT 所属的集合在数据库中都有对应的记录。
 	// 如果集合不存在，则会创建一个带有默认信息的新记录。
-	s.ensureCollectionExists(collectionAddress)
+	if err := s.ensureCollectionExists(db, collectionAddress); err != nil {
+		return err
+	}
 
 	// 2. 更新 item 的上架信息
 	// 更新特定 NFT 的上架价格和上架时间。
 	// 如果该 NFT 之前不存在，则会创建一条新的 Item 记录。
-	s.updateItemListingInfo(collectionAddress, tokenId, price)
+	if err := s.updateItemListingInfo(db, collectionAddress, tokenId, price); err != nil {
+		return err
+	}
 
 	// 3. 更新 collection 的 floor_price
 	// 根据当前集合中所有活跃的 Listing，重新计算并更新该集合的最低地板价。
 	// 这一步确保集合的地板价始终反映最新的市场情况。
-	s.updateCollectionFloorPrice(collectionAddress)
+	return s.updateCollectionFloorPrice(db, collectionAddress)
 }
 
 // ensureCollectionExists 确保 collection 记录存在
-func (s *Service) ensureCollectionExists(collectionAddress string) {
+func (s *Service) ensureCollectionExists(db *gorm.DB, collectionAddress string) error {
 	collectionTableName := gdb.GetMultiProjectCollectionTableName(s.cfg.ProjectCfg.Name, s.chain)
 
 	// 检查 collection 是否存在
 	var count int64
-	if err := s.db.WithContext(s.ctx).Table(collectionTableName).
+	if err := db.Table(collectionTableName).
 		Where("address = ?", collectionAddress).
 		Count(&count).Error; err != nil {
-		xzap.WithContext(s.ctx).Error("failed to check collection existence",
-			zap.Error(err),
-			zap.String("collection_address", collectionAddress))
-		return
+		return errors.Wrap(err, "failed to check collection existence")
 	}
 
 	// 如果不存在，创建基础 collection 记录
@@ -1126,7 +973,7 @@ func (s *Service) ensureCollectionExists(collectionAddress string) {
 			"symbol":         "Unknown", // 默认值，后续可通过其他服务更新
 			"name":           "Unknown Collection",
 			"creator":        "0x0000000000000000000000000000000000000000",
-			"token_standard": 721, // 默认 ERC721
+			"token_standard": multi.TokenStandardERC721, // 默认 ERC721, 收到 ERC1155 转移事件时更新
 			"auth":           0,
 			"owner_amount":   0,
 			"item_amount":    0,
@@ -1137,20 +984,19 @@ func (s *Service) ensureCollectionExists(collectionAddress string) {
 			"update_time":    now,
 		}
 
-		if err := s.db.WithContext(s.ctx).Table(collectionTableName).
+		if err := db.Table(collectionTableName).
 			Create(&collection).Error; err != nil {
-			xzap.WithContext(s.ctx).Error("failed to create collection record",
-				zap.Error(err),
-				zap.String("collection_address", collectionAddress))
-		} else {
-			xzap.WithContext(s.ctx).Info("created new collection record",
-				zap.String("collection_address", collectionAddress))
+			return errors.Wrap(err, "failed to create collection record")
 		}
+		xzap.WithContext(s.ctx).Info("created new collection record",
+			zap.String("collection_address", collectionAddress))
 	}
+
+	return nil
 }
 
 // updateItemListingInfo 更新 item 的上架信息
-func (s *Service) updateItemListingInfo(collectionAddress, tokenId string, price decimal.Decimal) {
+func (s *Service) updateItemListingInfo(db *gorm.DB, collectionAddress, tokenId string, price decimal.Decimal) error {
 	itemTableName := gdb.GetMultiProjectItemTableName(s.cfg.ProjectCfg.Name, s.chain)
 	now := time.Now().Unix()
 
@@ -1162,16 +1008,12 @@ func (s *Service) updateItemListingInfo(collectionAddress, tokenId string, price
 	}
 
 	// 先尝试更新
-	result := s.db.WithContext(s.ctx).Table(itemTableName).
+	result := db.Table(itemTableName).
 		Where("collection_address = ? AND token_id = ?", collectionAddress, tokenId).
 		Updates(updates)
 
 	if result.Error != nil {
-		xzap.WithContext(s.ctx).Error("failed to update item listing info",
-			zap.Error(result.Error),
-			zap.String("collection_address", collectionAddress),
-			zap.String("token_id", tokenId))
-		return
+		return errors.Wrap(result.Error, "failed to update item listing info")
 	}
 
 	// 如果没有更新任何记录，说明 item 不存在，创建基础记录
@@ -1193,50 +1035,43 @@ func (s *Service) updateItemListingInfo(collectionAddress, tokenId string, price
 			"update_time":        now,
 		}
 
-		if err := s.db.WithContext(s.ctx).Table(itemTableName).
+		if err := db.Table(itemTableName).
 			Create(&item).Error; err != nil {
-			xzap.WithContext(s.ctx).Error("failed to create item record",
-				zap.Error(err),
-				zap.String("collection_address", collectionAddress),
-				zap.String("token_id", tokenId))
-		} else {
-			xzap.WithContext(s.ctx).Info("created new item record",
-				zap.String("collection_address", collectionAddress),
-				zap.String("token_id", tokenId))
+			return errors.Wrap(err, "failed to create item record")
 		}
+		xzap.WithContext(s.ctx).Info("created new item record",
+			zap.String("collection_address", collectionAddress),
+			zap.String("token_id", tokenId))
 	}
+
+	return nil
 }
 
 // updateCollectionFloorPrice 更新 collection 的 floor_price
-func (s *Service) updateCollectionFloorPrice(collectionAddress string) {
+func (s *Service) updateCollectionFloorPrice(db *gorm.DB, collectionAddress string) error {
 	itemTableName := gdb.GetMultiProjectItemTableName(s.cfg.ProjectCfg.Name, s.chain)
 	collectionTableName := gdb.GetMultiProjectCollectionTableName(s.cfg.ProjectCfg.Name, s.chain)
 
 	// 查询该 collection 中最低的上架价格
 	var minPrice decimal.Decimal
-	if err := s.db.WithContext(s.ctx).Table(itemTableName).
+	if err := db.Table(itemTableName).
 		Select("MIN(list_price)").
 		Where("collection_address = ? AND list_price IS NOT NULL AND list_price > 0", collectionAddress).
 		Scan(&minPrice).Error; err != nil {
-		xzap.WithContext(s.ctx).Error("failed to get collection min price",
-			zap.Error(err),
-			zap.String("collection_address", collectionAddress))
-		return
+		return errors.Wrap(err, "failed to get collection min price")
 	}
 
 	// 更新 collection 的 floor_price
-	if err := s.db.WithContext(s.ctx).Table(collectionTableName).
+	if err := db.Table(collectionTableName).
 		Where("address = ?", collectionAddress).
 		Update("floor_price", minPrice).Error; err != nil {
-		xzap.WithContext(s.ctx).Error("failed to update collection floor price",
-			zap.Error(err),
-			zap.String("collection_address", collectionAddress),
-			zap.String("floor_price", minPrice.String()))
-	} else {
-		xzap.WithContext(s.ctx).Debug("updated collection floor price",
-			zap.String("collection_address", collectionAddress),
-			zap.String("floor_price", minPrice.String()))
+		return errors.Wrap(err, "failed to update collection floor price")
 	}
+	xzap.WithContext(s.ctx).Debug("updated collection floor price",
+		zap.String("collection_address", collectionAddress),
+		zap.String("floor_price", minPrice.String()))
+
+	return nil
 }
 
 // getTokenURI 获取NFT的tokenURI（元数据URI）
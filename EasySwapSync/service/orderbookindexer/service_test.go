@@ -43,11 +43,11 @@ func TestSyncEvent(t *testing.T) {
 		ethLog := log.(ethereumTypes.Log)
 		switch ethLog.Topics[0].String() {
 		case LogMakeTopic:
-			orderbookSyncer.handleMakeEvent(ethLog)
+			orderbookSyncer.handleMakeEvent(newEventBatch(db), ethLog)
 		case LogCancelTopic:
-			orderbookSyncer.handleCancelEvent(ethLog)
+			orderbookSyncer.handleCancelEvent(newEventBatch(db), ethLog)
 		case LogMatchTopic:
-			orderbookSyncer.handleMatchEvent(ethLog)
+			orderbookSyncer.handleMatchEvent(newEventBatch(db), ethLog)
 		default:
 
 		}
@@ -80,7 +80,7 @@ func TestHandleMakeEvent(t *testing.T) {
 		BlockNumber: 111482956,
 		TxHash:      common.HexToHash("0x000000000000000000000000f39fd6e51aad88f6f4ce6ab8827279cfffb92266"),
 	}
-	orderbookSyncer.handleMakeEvent(log)
+	orderbookSyncer.handleMakeEvent(newEventBatch(db), log)
 }
 
 func TestHandleApprovalEvent(t *testing.T) {
@@ -112,7 +112,7 @@ func TestHandleApprovalEvent(t *testing.T) {
 		TxHash:      common.HexToHash("0x111000000000000000000000f39fd6e51aad88f6f4ce6ab8827279cfffb92266"),
 	}
 
-	orderbookSyncer.handleApprovalEvent(log)
+	orderbookSyncer.handleApprovalEvent(newEventBatch(db), log)
 }
 
 func TestCheckNFTApprovalStatus(t *testing.T) {
@@ -0,0 +1,60 @@
+package orderbookindexer
+
+import (
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/ProjectsTask/EasySwapSync/service/comm"
+)
+
+// initIndexedStatus 初始化订单簿事件与地板价的同步进度记录
+// 记录不存在时订单簿事件从配置的 start_block 开始同步, 已存在时保持原进度
+func (s *Service) initIndexedStatus() error {
+	now := time.Now().Unix()
+	statuses := []base.IndexedStatus{
+		{ChainId: int(s.chainId), IndexType: EventIndexType, LastIndexedBlock: int64(s.cfg.ChainCfg.StartBlock), LastIndexedTime: now},
+		{ChainId: int(s.chainId), IndexType: comm.CollectionFloorChangeIndexType, LastIndexedTime: now},
+	}
+
+	return s.db.WithContext(s.ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range statuses {
+			status := &statuses[i]
+			var count int64
+			if err := tx.Table(base.IndexedStatusTableName()).
+				Where("chain_id = ? and index_type = ?", status.ChainId, status.IndexType).
+				Count(&count).Error; err != nil {
+				return errors.Wrap(err, "failed on get indexed status")
+			}
+			if count > 0 {
+				continue
+			}
+			// 从 0 区块开始同步会扫描整条链, 必须显式配置起始区块
+			if status.IndexType == EventIndexType && status.LastIndexedBlock == 0 {
+				return errors.Errorf("indexed status of chain %s not found, start_block is required", s.chain)
+			}
+			if err := tx.Table(base.IndexedStatusTableName()).Create(status).Error; err != nil {
+				return errors.Wrap(err, "failed on create indexed status")
+			}
+		}
+
+		return nil
+	})
+}
+
+// getIndexedStatus 查询同步进度记录, 记录不存在时返回错误
+func (s *Service) getIndexedStatus(indexType int32) (*base.IndexedStatus, error) {
+	var status base.IndexedStatus
+	if err := s.db.WithContext(s.ctx).Table(base.IndexedStatusTableName()).
+		Where("chain_id = ? and index_type = ?", s.chainId, indexType).
+		First(&status).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Errorf("indexed status of chain %s index type %d not found", s.chain, indexType)
+		}
+		return nil, errors.Wrap(err, "failed on get indexed status")
+	}
+
+	return &status, nil
+}
@@ -0,0 +1,70 @@
+package orderbookindexer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"go.uber.org/zap"
+
+	"github.com/ProjectsTask/EasySwapSync/service/comm"
+	"github.com/ProjectsTask/EasySwapSync/service/config"
+)
+
+func TestInitIndexedStatus(t *testing.T) {
+	db := newTestDB(t)
+	ctx := xzap.ToContext(context.Background(), zap.NewNop())
+	cfg := &config.Config{ChainCfg: config.ChainCfg{Name: testChain, ID: 11155111, StartBlock: 5000}}
+	s := New(ctx, cfg, db, nil, fakeChainClient{}, 11155111, testChain, nil)
+
+	// 已有记录保持原进度, 缺失的地板价记录被补齐
+	if err := s.initIndexedStatus(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.initIndexedStatus(); err != nil {
+		t.Fatal(err)
+	}
+	status, err := s.getIndexedStatus(EventIndexType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.LastIndexedBlock != 100 {
+		t.Fatalf("expected existing progress 100, got %d", status.LastIndexedBlock)
+	}
+	if _, err := s.getIndexedStatus(comm.CollectionFloorChangeIndexType); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	if err := db.Table(base.IndexedStatusTableName()).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 indexed status rows, got %d", count)
+	}
+
+	// 新链从配置的起始区块开始同步
+	other := New(ctx, &config.Config{ChainCfg: config.ChainCfg{Name: "base", ID: 8453, StartBlock: 5000}}, db, nil, fakeChainClient{}, 8453, "base", nil)
+	if _, err := other.getIndexedStatus(EventIndexType); err == nil {
+		t.Fatal("expected missing indexed status error")
+	}
+	if err := other.initIndexedStatus(); err != nil {
+		t.Fatal(err)
+	}
+	status, err = other.getIndexedStatus(EventIndexType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.LastIndexedBlock != 5000 {
+		t.Fatalf("expected start block 5000, got %d", status.LastIndexedBlock)
+	}
+
+	// 未配置起始区块时拒绝从 0 区块开始同步
+	optimism := New(ctx, &config.Config{ChainCfg: config.ChainCfg{Name: "optimism", ID: 10}}, db, nil, fakeChainClient{}, 10, "optimism", nil)
+	if err := optimism.initIndexedStatus(); err == nil {
+		t.Fatal("expected start_block required error")
+	}
+	if _, err := optimism.getIndexedStatus(comm.CollectionFloorChangeIndexType); err == nil {
+		t.Fatal("expected no indexed status created without start_block")
+	}
+}
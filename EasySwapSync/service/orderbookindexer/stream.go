@@ -0,0 +1,324 @@
+package orderbookindexer
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/chainclient"
+	"github.com/ProjectsTask/EasySwapBase/chain/types"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// logStream 缓存 WebSocket 订阅推送的区块头与日志
+// 订阅建立后的区块由推送数据直接处理, 订阅之前、断开期间或推送日志不完整的区块由轮询 FilterLogs 补齐
+type logStream struct {
+	mu        sync.Mutex
+	active    bool
+	address   common.Address                 // 订阅的合约地址
+	topics    []common.Hash                  // 需要校验完整性的事件签名
+	fromBlock uint64                         // 订阅建立后第一个可由推送数据完整覆盖的区块
+	headers   map[uint64]*types.BlockHeader  // 区块号 -> 最新推送的区块头
+	logs      map[uint64][]ethereumTypes.Log // 区块号 -> 推送的日志
+	heads     chan struct{}                  // 收到新区块头时通知同步循环
+}
+
+func newLogStream(address common.Address, topics ...common.Hash) *logStream {
+	return &logStream{
+		address: address,
+		topics:  topics,
+		headers: make(map[uint64]*types.BlockHeader),
+		logs:    make(map[uint64][]ethereumTypes.Log),
+		heads:   make(chan struct{}, 1),
+	}
+}
+
+// start 订阅建立, 清空之前的缓存
+func (ls *logStream) start(fromBlock uint64) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.active = true
+	ls.fromBlock = fromBlock
+	ls.headers = make(map[uint64]*types.BlockHeader)
+	ls.logs = make(map[uint64][]ethereumTypes.Log)
+}
+
+// stop 订阅断开, 之后的区块回退到轮询
+func (ls *logStream) stop() {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.active = false
+}
+
+func (ls *logStream) isActive() bool {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	return ls.active
+}
+
+func (ls *logStream) addHead(header *types.BlockHeader) {
+	ls.mu.Lock()
+	ls.headers[header.Number] = header
+	ls.mu.Unlock()
+
+	select {
+	case ls.heads <- struct{}{}:
+	default:
+	}
+}
+
+// addLog 缓存推送的日志, 被重组移除的日志从缓存中删除
+func (ls *logStream) addLog(log ethereumTypes.Log) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	logs := ls.logs[log.BlockNumber]
+	for i, cached := range logs {
+		if cached.TxHash == log.TxHash && cached.Index == log.Index && cached.BlockHash == log.BlockHash {
+			logs = append(logs[:i], logs[i+1:]...)
+			break
+		}
+	}
+	if !log.Removed {
+		logs = append(logs, log)
+	}
+	ls.logs[log.BlockNumber] = logs
+}
+
+// logsInRange 返回 [startBlock, endBlock] 内推送的日志, 只保留与最新区块头哈希一致的日志;
+// 订阅未覆盖整个区间、缺少区块头或推送的日志不完整时返回 false, 由调用方回退到 FilterLogs
+func (ls *logStream) logsInRange(startBlock, endBlock uint64) ([]ethereumTypes.Log, bool) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if !ls.active || startBlock < ls.fromBlock {
+		return nil, false
+	}
+
+	var logs []ethereumTypes.Log
+	for blockNumber := startBlock; blockNumber <= endBlock; blockNumber++ {
+		header, ok := ls.headers[blockNumber]
+		if !ok {
+			return nil, false
+		}
+		var blockLogs []ethereumTypes.Log
+		for _, log := range ls.logs[blockNumber] {
+			if strings.EqualFold(log.BlockHash.Hex(), header.Hash) {
+				blockLogs = append(blockLogs, log)
+			}
+		}
+		if !ls.complete(header, blockLogs) {
+			return nil, false
+		}
+		logs = append(logs, blockLogs...)
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+
+	return logs, true
+}
+
+// complete 用区块头的日志布隆过滤器校验推送的日志是否完整:
+// 过滤器显示区块包含合约的某个事件, 但没有收到该事件的日志(推送丢失或晚于区块头到达)时视为不完整.
+// 节点未返回布隆过滤器时无法校验, 同样视为不完整
+func (ls *logStream) complete(header *types.BlockHeader, logs []ethereumTypes.Log) bool {
+	if len(header.Bloom) != ethereumTypes.BloomByteLength {
+		return false
+	}
+	bloom := ethereumTypes.BytesToBloom(header.Bloom)
+	if !bloom.Test(ls.address.Bytes()) {
+		return true
+	}
+
+	for _, topic := range ls.topics {
+		if !bloom.Test(topic.Bytes()) {
+			continue
+		}
+		found := false
+		for _, log := range logs {
+			if len(log.Topics) > 0 && log.Topics[0] == topic {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// prune 清理已处理区块的缓存
+func (ls *logStream) prune(endBlock uint64) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	for blockNumber := range ls.headers {
+		if blockNumber <= endBlock {
+			delete(ls.headers, blockNumber)
+		}
+	}
+	for blockNumber := range ls.logs {
+		if blockNumber <= endBlock {
+			delete(ls.logs, blockNumber)
+		}
+	}
+}
+
+// EnableStreaming 启用 WebSocket 流式模式, 需在 Start 之前调用
+// client 需连接支持订阅的节点(ws/wss), 订阅失败或断开时自动回退到轮询
+func (s *Service) EnableStreaming(client chainclient.ChainClient) {
+	s.streamClient = client
+	s.stream = newLogStream(common.HexToAddress(s.cfg.ContractCfg.DexAddress),
+		common.HexToHash(LogMakeTopic), common.HexToHash(LogCancelTopic), common.HexToHash(LogMatchTopic))
+}
+
+// SyncOrderBookStreamLoop 维持新区块头与日志订阅, 断开后等待一个轮询间隔重连
+func (s *Service) SyncOrderBookStreamLoop() {
+	for {
+		err := s.subscribe()
+		s.stream.stop()
+		if s.ctx.Err() != nil {
+			xzap.WithContext(s.ctx).Info("SyncOrderBookStreamLoop stopped due to context cancellation")
+			return
+		}
+		xzap.WithContext(s.ctx).Warn("orderbook event stream disconnected, fallback to polling",
+			zap.Error(err))
+		s.sleep()
+	}
+}
+
+// subscribe 建立订阅并持续接收推送, 直到订阅出错或服务退出
+func (s *Service) subscribe() error {
+	heads := make(chan *types.BlockHeader, 16)
+	headSub, err := s.streamClient.SubscribeNewHead(s.ctx, heads)
+	if err != nil {
+		return err
+	}
+	defer headSub.Unsubscribe()
+
+	logs := make(chan ethereumTypes.Log, 256)
+	logSub, err := s.streamClient.SubscribeFilterLogs(s.ctx, types.FilterQuery{
+		Addresses: []string{s.cfg.ContractCfg.DexAddress},
+	}, logs)
+	if err != nil {
+		return err
+	}
+	defer logSub.Unsubscribe()
+
+	// 订阅建立之后产生的区块才能保证日志完整
+	current, err := s.streamClient.BlockNumber()
+	if err != nil {
+		return errors.Wrap(err, "failed on get current block number")
+	}
+	s.stream.start(current + 1)
+	xzap.WithContext(s.ctx).Info("orderbook event stream subscribed",
+		zap.Uint64("from_block", current+1))
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return nil
+		case header := <-heads:
+			s.stream.addHead(header)
+		case log := <-logs:
+			s.stream.addLog(log)
+		case err := <-headSub.Err():
+			return errors.Wrap(err, "new head subscription failed")
+		case err := <-logSub.Err():
+			return errors.Wrap(err, "log subscription failed")
+		}
+	}
+}
+
+// fetchLogs 获取 [startBlock, endBlock] 内的合约日志与跟踪集合的转移、授权日志,
+// 合约日志优先使用订阅推送的数据, 结果按区块号与日志序号排序
+func (s *Service) fetchLogs(startBlock, endBlock uint64) ([]ethereumTypes.Log, error) {
+	logs, err := s.fetchDexLogs(startBlock, endBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	collectionLogs, err := s.fetchCollectionLogs(startBlock, endBlock)
+	if err != nil {
+		return nil, err
+	}
+	if len(collectionLogs) == 0 {
+		return logs, nil
+	}
+
+	logs = append(logs, collectionLogs...)
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+
+	return logs, nil
+}
+
+func (s *Service) fetchDexLogs(startBlock, endBlock uint64) ([]ethereumTypes.Log, error) {
+	if s.stream != nil {
+		if logs, ok := s.stream.logsInRange(startBlock, endBlock); ok {
+			return logs, nil
+		}
+	}
+
+	return s.filterLogs(types.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(startBlock),
+		ToBlock:   new(big.Int).SetUint64(endBlock),
+		Addresses: []string{s.cfg.ContractCfg.DexAddress},
+	})
+}
+
+// filterLogs FilterLogs: 根据查询条件向节点请求日志数据
+func (s *Service) filterLogs(query types.FilterQuery) ([]ethereumTypes.Log, error) {
+	logs, err := s.chainClient.FilterLogs(s.ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	ethLogs := make([]ethereumTypes.Log, 0, len(logs))
+	for _, log := range logs {
+		ethLogs = append(ethLogs, log.(ethereumTypes.Log))
+	}
+
+	return ethLogs, nil
+}
+
+// sleep 出错后等待一个轮询间隔, 服务退出时立即返回
+func (s *Service) sleep() {
+	select {
+	case <-s.ctx.Done():
+	case <-time.After(s.pollInterval):
+	}
+}
+
+// waitForNewBlock 等待新区块: 流式模式下收到新区块头立即返回, 否则等待一个轮询间隔
+func (s *Service) waitForNewBlock() {
+	var heads <-chan struct{}
+	if s.stream != nil {
+		heads = s.stream.heads
+	}
+
+	select {
+	case <-s.ctx.Done():
+	case <-heads:
+	case <-time.After(s.pollInterval):
+	}
+}
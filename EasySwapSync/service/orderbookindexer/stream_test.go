@@ -0,0 +1,390 @@
+package orderbookindexer
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/types"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/ordermanager"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ProjectsTask/EasySwapBase/stores/xkv"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/pkg/errors"
+	"github.com/zeromicro/go-zero/core/stores/kv"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/ProjectsTask/EasySwapSync/service/config"
+)
+
+const testDexAddress = "0x7d29d1860bD4d3A74bBD9a03C9B043d375311dCb"
+
+// simulatedChain 内存中的模拟链, 同时支持轮询(FilterLogs)与订阅(SubscribeNewHead/SubscribeFilterLogs)
+type simulatedChain struct {
+	mu       sync.Mutex
+	headers  []*types.BlockHeader
+	logs     []ethereumTypes.Log
+	polled   map[uint64]bool // 被 FilterLogs 查询过的区块
+	headFeed event.Feed
+	logFeed  event.Feed
+	dropped  chan struct{} // 关闭时所有订阅断开
+}
+
+func newSimulatedChain() *simulatedChain {
+	c := &simulatedChain{
+		polled:  make(map[uint64]bool),
+		dropped: make(chan struct{}),
+	}
+	c.mine()
+	return c
+}
+
+// mine 打包一个新区块, 并推送区块头与日志
+func (c *simulatedChain) mine(logs ...ethereumTypes.Log) {
+	c.mu.Lock()
+	number := uint64(len(c.headers))
+	header := &types.BlockHeader{
+		Number: number,
+		Hash:   crypto.Keccak256Hash(new(big.Int).SetUint64(number).Bytes()).Hex(),
+		Time:   1700000000 + number*12,
+	}
+	if number > 0 {
+		header.ParentHash = c.headers[number-1].Hash
+	}
+	c.headers = append(c.headers, header)
+	var bloom ethereumTypes.Bloom
+	for i := range logs {
+		logs[i].Address = common.HexToAddress(testDexAddress)
+		logs[i].BlockNumber = number
+		logs[i].BlockHash = common.HexToHash(header.Hash)
+		logs[i].TxHash = crypto.Keccak256Hash([]byte(header.Hash), []byte{byte(i)})
+		logs[i].Index = uint(i)
+		bloom.Add(logs[i].Address.Bytes())
+		for _, topic := range logs[i].Topics {
+			bloom.Add(topic.Bytes())
+		}
+	}
+	header.Bloom = bloom.Bytes()
+	c.logs = append(c.logs, logs...)
+	c.mu.Unlock()
+
+	for _, log := range logs {
+		c.logFeed.Send(log)
+	}
+	c.headFeed.Send(header)
+}
+
+// disconnect 断开当前所有订阅
+func (c *simulatedChain) disconnect() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	close(c.dropped)
+	c.dropped = make(chan struct{})
+}
+
+func (c *simulatedChain) head() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return uint64(len(c.headers) - 1)
+}
+
+func (c *simulatedChain) isPolled(blockNumber uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.polled[blockNumber]
+}
+
+func (c *simulatedChain) FilterLogs(ctx context.Context, q types.FilterQuery) ([]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var res []interface{}
+	for n := q.FromBlock.Uint64(); n <= q.ToBlock.Uint64(); n++ {
+		c.polled[n] = true
+	}
+	for _, log := range c.logs {
+		if log.BlockNumber >= q.FromBlock.Uint64() && log.BlockNumber <= q.ToBlock.Uint64() {
+			res = append(res, log)
+		}
+	}
+	return res, nil
+}
+
+func (c *simulatedChain) BlockTimeByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	header, err := c.HeaderByNumber(ctx, blockNum)
+	if err != nil {
+		return 0, err
+	}
+	return header.Time, nil
+}
+
+func (c *simulatedChain) Client() interface{} {
+	return nil
+}
+
+func (c *simulatedChain) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return nil, errors.New("unexpected call")
+}
+
+func (c *simulatedChain) CallContractByChain(ctx context.Context, param types.CallParam) (interface{}, error) {
+	return nil, errors.New("unexpected call")
+}
+
+func (c *simulatedChain) BlockNumber() (uint64, error) {
+	return c.head(), nil
+}
+
+func (c *simulatedChain) BlockWithTxs(ctx context.Context, blockNumber uint64) (interface{}, error) {
+	return nil, errors.New("unexpected call")
+}
+
+func (c *simulatedChain) HeaderByNumber(ctx context.Context, blockNumber *big.Int) (*types.BlockHeader, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if blockNumber.Uint64() >= uint64(len(c.headers)) {
+		return nil, errors.New("block not found")
+	}
+	return c.headers[blockNumber.Uint64()], nil
+}
+
+func (c *simulatedChain) SubscribeNewHead(ctx context.Context, ch chan<- *types.BlockHeader) (ethereum.Subscription, error) {
+	return c.subscribe(c.headFeed.Subscribe(ch)), nil
+}
+
+func (c *simulatedChain) SubscribeFilterLogs(ctx context.Context, q types.FilterQuery, ch chan<- ethereumTypes.Log) (ethereum.Subscription, error) {
+	return c.subscribe(c.logFeed.Subscribe(ch)), nil
+}
+
+func (c *simulatedChain) Close() {}
+
+func (c *simulatedChain) subscribe(inner event.Subscription) ethereum.Subscription {
+	c.mu.Lock()
+	dropped := c.dropped
+	c.mu.Unlock()
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer inner.Unsubscribe()
+		select {
+		case <-quit:
+			return nil
+		case err := <-inner.Err():
+			return err
+		case <-dropped:
+			return errors.New("connection closed")
+		}
+	})
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// syncedTo 同步进度是否已追上链上已确认区块
+func syncedTo(t *testing.T, db *gorm.DB, chain *simulatedChain) func() bool {
+	return func() bool {
+		var status base.IndexedStatus
+		if err := db.Table(base.IndexedStatusTableName()).First(&status).Error; err != nil {
+			t.Fatal(err)
+		}
+		return uint64(status.LastIndexedBlock) == chain.head()-MultiChainMaxBlockDifference[testChain]+1
+	}
+}
+
+func finalState(t *testing.T, db *gorm.DB) map[string][]map[string]interface{} {
+	state := make(map[string][]map[string]interface{})
+	queries := map[string]*gorm.DB{
+		"orders": db.Table(multi.OrderTableName(testChain)).
+			Select("order_id,order_status,quantity_remaining,maker,taker,price").Order("order_id"),
+		"activities": db.Table(multi.ActivityTableName(testChain)).
+			Select("activity_type,maker,taker,tx_hash,block_number,event_time").Order("block_number,activity_type"),
+		"items": db.Table(multi.ItemTableName(testChain)).
+			Select("collection_address,token_id,owner").Order("token_id"),
+		"status": db.Table(base.IndexedStatusTableName()).
+			Select("last_indexed_block"),
+	}
+	for name, query := range queries {
+		var rows []map[string]interface{}
+		if err := query.Find(&rows).Error; err != nil {
+			t.Fatal(err)
+		}
+		state[name] = rows
+	}
+
+	return state
+}
+
+// runIndexer 在模拟链上按相同节奏出块, 分别以轮询或流式模式同步, 返回最终状态
+func runIndexer(t *testing.T, streaming bool) (map[string][]map[string]interface{}, *simulatedChain, []uint64) {
+	db := newTestDB(t)
+	if err := db.Table(base.IndexedStatusTableName()).Where("1 = 1").Update("last_indexed_block", 1).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	chain := newSimulatedChain()
+	for chain.head() < 12 {
+		chain.mine()
+	}
+
+	ctx, cancel := context.WithCancel(xzap.ToContext(context.Background(), zap.NewNop()))
+	defer cancel()
+	cfg := &config.Config{
+		ContractCfg: config.ContractCfg{EthAddress: ZeroAddress, DexAddress: testDexAddress},
+		ProjectCfg:  config.ProjectCfg{Name: gdb.OrderBookDexProject},
+	}
+	store := xkv.NewStore(kv.KvConf{{
+		RedisConf: redis.RedisConf{Host: miniredis.RunT(t).Addr(), Type: redis.NodeType},
+		Weight:    100,
+	}})
+	orderManager := ordermanager.New(ctx, db, store, testChain, gdb.OrderBookDexProject)
+	s := New(ctx, cfg, db, store, chain, 11155111, testChain, orderManager)
+	s.pollInterval = 5 * time.Millisecond
+	if streaming {
+		s.EnableStreaming(chain)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.SyncOrderBookEventLoop()
+	}()
+	if streaming {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.SyncOrderBookStreamLoop()
+		}()
+		waitFor(t, s.stream.isActive)
+	}
+	waitFor(t, syncedTo(t, db, chain))
+
+	seller := common.HexToAddress("0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266")
+	buyer := common.HexToAddress("0x70997970c51812dc3a010c7d01e0ea4bbb9a0c4e")
+	listingKey := common.HexToHash("0x01")
+	bidKey := common.HexToHash("0x02")
+	nft := testAsset{TokenId: big.NewInt(7), Collection: testCollection, Amount: big.NewInt(1)}
+	sell := testOrder{Side: List, SaleKind: FixForItem, Maker: seller, Nft: nft, Price: big.NewInt(1e16), Expiry: 1900000000}
+	bid := testOrder{Side: Bid, SaleKind: FixForCollection, Maker: buyer, Nft: nft, Price: big.NewInt(1e16), Expiry: 1900000000}
+
+	// 逐个出块, 每次等待同步追上, 使订阅建立后的区块均由推送数据处理
+	mine := func(logs ...ethereumTypes.Log) uint64 {
+		chain.mine(logs...)
+		waitFor(t, syncedTo(t, db, chain))
+		return chain.head()
+	}
+	var eventBlocks []uint64
+	eventBlocks = append(eventBlocks, mine(makeLog(t, s, listingKey, List, FixForItem, seller, 1, 0, 0)))
+	eventBlocks = append(eventBlocks, mine(makeLog(t, s, bidKey, Bid, FixForCollection, buyer, 3, 0, 0)))
+	// 重连会清空推送缓存, 断开前先让已推送的事件区块达到确认数并完成处理
+	for i := uint64(0); i < MultiChainMaxBlockDifference[testChain]; i++ {
+		mine()
+	}
+
+	// 订阅断开期间的区块由轮询补齐, 重连后继续使用推送数据
+	if streaming {
+		chain.disconnect()
+		waitFor(t, func() bool { return !s.stream.isActive() })
+	}
+	mine(matchLog(t, s, bidKey, listingKey, bid, sell, 0, 0))
+	if streaming {
+		waitFor(t, s.stream.isActive)
+	}
+	eventBlocks = append(eventBlocks, mine(cancelLog(bidKey, buyer, 0, 0)))
+	for i := uint64(0); i < MultiChainMaxBlockDifference[testChain]; i++ {
+		mine()
+	}
+
+	cancel()
+	wg.Wait()
+
+	return finalState(t, db), chain, eventBlocks
+}
+
+func TestStreamingMatchesPolling(t *testing.T) {
+	polling, _, _ := runIndexer(t, false)
+	streaming, chain, eventBlocks := runIndexer(t, true)
+
+	if len(polling["activities"]) != 4 {
+		t.Fatalf("expected 4 activities, got %d", len(polling["activities"]))
+	}
+	for name, rows := range polling {
+		if len(rows) != len(streaming[name]) {
+			t.Fatalf("%s: polling has %d rows, streaming has %d", name, len(rows), len(streaming[name]))
+		}
+		for i := range rows {
+			for column, value := range rows[i] {
+				if streaming[name][i][column] != value {
+					t.Fatalf("%s[%d].%s: polling %v, streaming %v", name, i, column, value, streaming[name][i][column])
+				}
+			}
+		}
+	}
+
+	// 订阅期间产生的事件区块不应再通过 FilterLogs 获取
+	for _, blockNumber := range eventBlocks {
+		if chain.isPolled(blockNumber) {
+			t.Fatalf("block %d was polled while streaming", blockNumber)
+		}
+	}
+}
+
+func TestLogStreamChecksBloom(t *testing.T) {
+	dex := common.HexToAddress(testDexAddress)
+	ls := newLogStream(dex, common.HexToHash(LogMakeTopic), common.HexToHash(LogMatchTopic))
+	ls.start(10)
+
+	var bloom ethereumTypes.Bloom
+	bloom.Add(dex.Bytes())
+	bloom.Add(common.HexToHash(LogMakeTopic).Bytes())
+	hash := common.HexToHash("0x0a")
+	ls.addHead(&types.BlockHeader{Number: 10, Hash: hash.Hex(), Bloom: bloom.Bytes()})
+
+	// 区块头显示包含 LogMake, 但日志尚未推送
+	if _, ok := ls.logsInRange(10, 10); ok {
+		t.Fatal("expected incomplete logs without LogMake")
+	}
+	ls.addLog(ethereumTypes.Log{
+		Address:     dex,
+		Topics:      []common.Hash{common.HexToHash(LogMakeTopic)},
+		BlockNumber: 10,
+		BlockHash:   hash,
+	})
+	logs, ok := ls.logsInRange(10, 10)
+	if !ok || len(logs) != 1 {
+		t.Fatalf("expected complete logs, got %d, %v", len(logs), ok)
+	}
+
+	// 不包含合约地址的区块无需推送日志
+	ls.addHead(&types.BlockHeader{Number: 11, Hash: common.HexToHash("0x0b").Hex(), Bloom: new(ethereumTypes.Bloom).Bytes()})
+	if _, ok := ls.logsInRange(10, 11); !ok {
+		t.Fatal("expected complete logs for block without dex events")
+	}
+
+	// 缺少布隆过滤器时无法校验
+	ls.addHead(&types.BlockHeader{Number: 12, Hash: common.HexToHash("0x0c").Hex()})
+	if _, ok := ls.logsInRange(12, 12); ok {
+		t.Fatal("expected incomplete logs without bloom")
+	}
+}
@@ -0,0 +1,210 @@
+package orderbookindexer
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/types"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/marketfeed"
+	"github.com/ProjectsTask/EasySwapBase/ordermanager"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+
+	"github.com/ProjectsTask/EasySwapSync/service/collectionfilter"
+)
+
+// transferAddressBatch 单次 eth_getLogs 请求携带的集合地址数量上限
+const transferAddressBatch = 500
+
+// collectionLogTopics 跟踪集合时获取的事件
+var collectionLogTopics = []string{
+	ERC721TransferTopic,
+	ERC721ApprovalTopic,
+	ERC721ApprovalForAllTopic,
+	ERC1155TransferSingleTopic,
+	ERC1155TransferBatchTopic,
+}
+
+// EnableCollectionTracking 跟踪 filter 中集合的 ERC721 Transfer/Approval/ApprovalForAll 与 ERC1155 TransferSingle/TransferBatch 事件, 需在 Start 之前调用
+// 站外转账、Mint、Burn 以及其他市场的成交都会更新 NFT 所有者(ERC1155 为持有数量), 并使原所有者的挂单失效;
+// 撤销 vault 授权的挂单标记为不可成交. 集合日志始终通过轮询 FilterLogs 获取, 不依赖流式订阅
+func (s *Service) EnableCollectionTracking(filter *collectionfilter.Filter) {
+	s.collectionFilter = filter
+}
+
+// fetchCollectionLogs 获取 [startBlock, endBlock] 内跟踪集合的转移与授权日志, 未启用跟踪时返回空
+func (s *Service) fetchCollectionLogs(startBlock, endBlock uint64) ([]ethereumTypes.Log, error) {
+	if s.collectionFilter == nil {
+		return nil, nil
+	}
+
+	addresses := s.collectionFilter.Addresses()
+	var logs []ethereumTypes.Log
+	for i := 0; i < len(addresses); i += transferAddressBatch {
+		end := i + transferAddressBatch
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+
+		batch, err := s.filterLogs(types.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(startBlock),
+			ToBlock:   new(big.Int).SetUint64(endBlock),
+			Addresses: addresses[i:end],
+			Topics:    [][]string{collectionLogTopics},
+		})
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, batch...)
+	}
+
+	return logs, nil
+}
+
+// handleTransferEvent 处理 ERC721 转移事件 (Transfer)
+// Topics: [Signature, From, To, TokenId]
+func (s *Service) handleTransferEvent(b *eventBatch, log ethereumTypes.Log) error {
+	// 1. 只处理跟踪集合的 ERC721 Transfer, ERC20 Transfer 的 tokenId 不在 topic 中
+	if !s.isTrackedCollectionLog(log, 4) {
+		return nil
+	}
+
+	from := strings.ToLower(common.BytesToAddress(log.Topics[1].Bytes()).String())
+	to := strings.ToLower(common.BytesToAddress(log.Topics[2].Bytes()).String())
+	tokenId := new(big.Int).SetBytes(log.Topics[3].Bytes()).String()
+	collection := strings.ToLower(log.Address.String())
+
+	// 转移后 token 的单独授权失效
+	if _, err := s.clearTokenApprovals(b.tx, log.BlockNumber, collection, tokenId, ""); err != nil {
+		return err
+	}
+	// 成交交易中的转移由 handleMatchEvent 更新所有者并记录 Sale
+	if b.matchTxs[log.TxHash.Hex()] {
+		return nil
+	}
+
+	// 挂单时 NFT 存入 vault, 取消时取回, 托管期间所有者仍是挂单人
+	if s.vaultAddress != "" && (strings.EqualFold(from, s.vaultAddress) || strings.EqualFold(to, s.vaultAddress)) {
+		return nil
+	}
+
+	blockTime, err := s.blockTime(b, log.BlockNumber)
+	if err != nil {
+		return err
+	}
+
+	// 2. 更新 NFT 所有者, Mint 之前未收录的 NFT 新建记录
+	var item multi.Item
+	if err := b.tx.Table(multi.ItemTableName(s.chain)).
+		Select("id,owner").
+		Where("collection_address = ? and token_id = ?", collection, tokenId).
+		Limit(1).
+		Find(&item).Error; err != nil {
+		return errors.Wrap(err, "failed on get item")
+	}
+	if item.Id == 0 {
+		if err := s.createItemWithChange(b.tx, log.BlockNumber, &multi.Item{
+			ChainId:           int(s.chainId),
+			CollectionAddress: collection,
+			TokenId:           tokenId,
+			Name:              "Token #" + tokenId,
+			Owner:             to,
+			Creator:           to,
+			Supply:            1,
+		}); err != nil {
+			return err
+		}
+	} else if !strings.EqualFold(item.Owner, to) {
+		if err := s.updateItemOwnerWithChange(b.tx, log.BlockNumber, collection, tokenId, to); err != nil {
+			return errors.Wrap(err, "failed to update item owner")
+		}
+	}
+
+	// 3. 记录 Mint/Transfer 活动
+	activityType := multi.Transfer
+	if from == ZeroAddress {
+		activityType = multi.Mint
+	}
+	if err := b.tx.Table(multi.ActivityTableName(s.chain)).Clauses(clause.OnConflict{
+		DoNothing: true,
+	}).Create(&multi.Activity{
+		ActivityType:      activityType,
+		Maker:             from,
+		Taker:             to,
+		MarketplaceID:     multi.MarketOrderBook,
+		CollectionAddress: collection,
+		TokenId:           tokenId,
+		CurrencyAddress:   s.cfg.ContractCfg.EthAddress,
+		Price:             decimal.Zero,
+		BlockNumber:       int64(log.BlockNumber),
+		TxHash:            log.TxHash.String(),
+		EventTime:         int64(blockTime),
+	}).Error; err != nil {
+		return errors.Wrap(err, "failed on create transfer activity")
+	}
+
+	// 4. 原所有者的挂单已无法成交, 置为失效
+	return s.deactivateStaleListings(b, log.BlockNumber, collection, tokenId, to, int64(blockTime), log.TxHash.String())
+}
+
+// deactivateStaleListings 将 NFT 上挂单人不再是所有者的有效挂单置为失效
+func (s *Service) deactivateStaleListings(b *eventBatch, blockNumber uint64, collection, tokenId, owner string, eventTime int64, txHash string) error {
+	var listings []multi.Order
+	if err := b.tx.Table(multi.OrderTableName(s.chain)).
+		Where("collection_address = ? and token_id = ? and order_type = ? and order_status = ?",
+			collection, tokenId, multi.ListingOrder, multi.OrderStatusActive).
+		Find(&listings).Error; err != nil {
+		return errors.Wrap(err, "failed on get active listings")
+	}
+
+	for _, listing := range listings {
+		if strings.EqualFold(listing.Maker, owner) {
+			continue
+		}
+		if err := s.deactivateListing(b, blockNumber, listing, eventTime, txHash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deactivateListing 将挂单置为失效, 事务提交后推送取消事件并重新计算地板价
+func (s *Service) deactivateListing(b *eventBatch, blockNumber uint64, listing multi.Order, eventTime int64, txHash string) error {
+	if err := s.updateOrderWithChange(b.tx, blockNumber, listing.OrderID, map[string]interface{}{"order_status": multi.OrderStatusInactive}); err != nil {
+		return errors.Wrapf(err, "failed on deactivate listing, order_id: %s", listing.OrderID)
+	}
+
+	s.publishOnCommit(b, &marketfeed.Event{
+		Type:              marketfeed.TypeCancel,
+		CollectionAddress: listing.CollectionAddress,
+		TokenID:           listing.TokenId,
+		OrderID:           listing.OrderID,
+		Maker:             listing.Maker,
+		Price:             listing.Price,
+		TxHash:            txHash,
+		EventTime:         eventTime,
+	})
+	// 失效的挂单可能是当前地板价, 需要重新计算
+	b.onCommit(func() {
+		if err := ordermanager.AddUpdatePriceEvent(s.kv, &ordermanager.TradeEvent{
+			OrderId:        listing.OrderID,
+			CollectionAddr: listing.CollectionAddress,
+			TokenID:        listing.TokenId,
+			EventType:      ordermanager.Cancel,
+		}, s.chain); err != nil {
+			xzap.WithContext(s.ctx).Error("failed on add update price event",
+				zap.Error(err),
+				zap.String("type", "transfer"),
+				zap.String("order_id", listing.OrderID))
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package orderbookindexer
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ProjectsTask/EasySwapBase/chain/types"
+	"github.com/ProjectsTask/EasySwapBase/logger/xzap"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb"
+	"github.com/ProjectsTask/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+
+	"github.com/ProjectsTask/EasySwapSync/service/collectionfilter"
+	"github.com/ProjectsTask/EasySwapSync/service/config"
+)
+
+func transferLog(collection, from, to common.Address, tokenId int64, blockNumber uint64, index uint) ethereumTypes.Log {
+	return ethereumTypes.Log{
+		Address: collection,
+		Topics: []common.Hash{
+			common.HexToHash(ERC721TransferTopic),
+			common.BytesToHash(from.Bytes()),
+			common.BytesToHash(to.Bytes()),
+			common.BigToHash(big.NewInt(tokenId)),
+		},
+		BlockNumber: blockNumber,
+		TxHash:      common.BigToHash(big.NewInt(int64(blockNumber))),
+		Index:       index,
+	}
+}
+
+func TestApplyLogsTracksTransfers(t *testing.T) {
+	db := newTestDB(t)
+	vault := common.HexToAddress("0x7d29d1860bd4d3a74bbd9a03c9b043d375311dcb")
+	cfg := &config.Config{
+		ContractCfg: config.ContractCfg{EthAddress: ZeroAddress, VaultAddress: vault.Hex()},
+		ProjectCfg:  config.ProjectCfg{Name: gdb.OrderBookDexProject},
+	}
+	ctx := xzap.ToContext(context.Background(), zap.NewNop())
+	s := New(ctx, cfg, db, nil, fakeChainClient{}, 11155111, testChain, nil)
+	filter := collectionfilter.New(ctx, db, testChain, gdb.OrderBookDexProject)
+	filter.Add(testCollection.Hex())
+	s.EnableCollectionTracking(filter)
+
+	seller := common.HexToAddress("0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266")
+	receiver := common.HexToAddress("0x70997970c51812dc3a010c7d01e0ea4bbb9a0c4e")
+	collection := strings.ToLower(testCollection.Hex())
+
+	if err := db.Table(multi.ItemTableName(testChain)).Create(&multi.Item{
+		CollectionAddress: collection,
+		TokenId:           "7",
+		Owner:             strings.ToLower(seller.Hex()),
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Table(multi.OrderTableName(testChain)).Create(&multi.Order{
+		OrderID:           "0x01",
+		OrderType:         multi.ListingOrder,
+		OrderStatus:       multi.OrderStatusActive,
+		CollectionAddress: collection,
+		TokenId:           "7",
+		Maker:             strings.ToLower(seller.Hex()),
+		Price:             decimal.NewFromInt(1e16),
+		Fillable:          true,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	untracked := common.HexToAddress("0x5fbdb2315678afecb367f032d93f642f64180aa3")
+	erc20Transfer := transferLog(testCollection, seller, receiver, 0, 104, 1)
+	erc20Transfer.Topics = erc20Transfer.Topics[:3]
+	logs := []ethereumTypes.Log{
+		transferLog(testCollection, common.Address{}, seller, 8, 101, 0),
+		// 存入 vault 不改变所有者
+		transferLog(testCollection, seller, vault, 7, 102, 0),
+		transferLog(testCollection, seller, receiver, 7, 103, 0),
+		transferLog(untracked, seller, receiver, 7, 104, 0),
+		erc20Transfer,
+	}
+	if _, err := s.applyLogs(logs, &types.BlockHeader{Number: 105, Hash: "0x105", ParentHash: "0x104"}); err != nil {
+		t.Fatal(err)
+	}
+
+	owners := map[string]string{}
+	var items []multi.Item
+	if err := db.Table(multi.ItemTableName(testChain)).Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range items {
+		owners[item.TokenId] = item.Owner
+	}
+	if len(owners) != 2 || owners["7"] != strings.ToLower(receiver.Hex()) || owners["8"] != strings.ToLower(seller.Hex()) {
+		t.Fatalf("unexpected item owners %v", owners)
+	}
+
+	var activities []multi.Activity
+	if err := db.Table(multi.ActivityTableName(testChain)).Order("block_number").Find(&activities).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(activities) != 2 || activities[0].ActivityType != multi.Mint || activities[1].ActivityType != multi.Transfer ||
+		activities[1].Taker != strings.ToLower(receiver.Hex()) {
+		t.Fatalf("unexpected activities %+v", activities)
+	}
+
+	var listing multi.Order
+	if err := db.Table(multi.OrderTableName(testChain)).Where("order_id = ?", "0x01").First(&listing).Error; err != nil {
+		t.Fatal(err)
+	}
+	if listing.OrderStatus != multi.OrderStatusInactive {
+		t.Fatalf("expected listing deactivated, got status %d", listing.OrderStatus)
+	}
+
+	// 重组回滚后 Mint 的 NFT 被删除, 所有者与挂单状态恢复
+	if err := s.rollbackAboveBlock(100); err != nil {
+		t.Fatal(err)
+	}
+	items = nil
+	if err := db.Table(multi.ItemTableName(testChain)).Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Owner != strings.ToLower(seller.Hex()) {
+		t.Fatalf("unexpected items after rollback %+v", items)
+	}
+	if err := db.Table(multi.OrderTableName(testChain)).Where("order_id = ?", "0x01").First(&listing).Error; err != nil {
+		t.Fatal(err)
+	}
+	if listing.OrderStatus != multi.OrderStatusActive {
+		t.Fatalf("expected listing restored, got status %d", listing.OrderStatus)
+	}
+}
@@ -1,7 +1,8 @@
 import { request } from './request';
 
-function GetLoginMessage(address: string) {
-    return request.get(`/user/${address}/login-message`);
+// 登录消息(SIWE)中包含链ID, 须与钱包当前所在链一致
+function GetLoginMessage(address: string, chainId: number) {
+    return request.get(`/user/${address}/login-message?chain_id=${chainId}`);
 };
 
 function GetSigStatus(address: string) {
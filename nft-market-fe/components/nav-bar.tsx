@@ -31,7 +31,7 @@ export function NavBar() {
   }
 
   async function getLoginMessage() {
-    const res = await userApi.GetLoginMessage(address as string);
+    const res = await userApi.GetLoginMessage(address as string, chainId);
     return res;
   }
 
@@ -45,9 +45,10 @@ export function NavBar() {
     }
     const res = await getLoginMessage();
 
+    // 签名完整的登录消息, 服务端从消息中校验域名、链ID、nonce 与有效期
     const signature = await window.ethereum.request({
       method: "personal_sign",
-      params: [(res as any).nonce, address as string],
+      params: [(res as any).message, address as string],
     });
     const loginRes = await userApi.Login({
       chain_id: chainId,